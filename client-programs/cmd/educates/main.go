@@ -4,10 +4,15 @@ Command line client for Educates.
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cmd"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 )
 
 // NOTE: The version of Educates which is installed by the CLI is overridden
@@ -26,11 +31,35 @@ func main() {
 
 	c := p.NewEducatesCmdGroup()
 
+	// Commands are given a context which is cancelled when the CLI is
+	// interrupted, so that requests in progress are abandoned and commands
+	// can report or undo what they had done before exiting. Once the context
+	// is cancelled the default signal handling is restored, so interrupting
+	// a second time exits immediately.
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-ctx.Done()
+
+		stop()
+
+		fmt.Fprintln(os.Stderr, "Interrupted, cancelling operations in progress (interrupt again to exit immediately).")
+	}()
+
 	// Execute the actual command with arguments sourced from os.Args.
 
-	err := c.Execute()
+	err := c.ExecuteContext(ctx)
+
+	// The error message itself has already been output by Cobra, so we only
+	// need to output any hint about how to remedy the problem. The exit code
+	// is dependent on the type of failure so scripts can react to it.
 
 	if err != nil {
-		os.Exit(1)
+		if hint := failures.HintFor(err); hint != "" {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+		}
+
+		os.Exit(failures.ExitCode(err))
 	}
 }
@@ -0,0 +1,135 @@
+/*
+Export of analytics events reported by training portals to external storage,
+so they can be used for reporting beyond what the training portal retains.
+*/
+package analytics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type Portal struct {
+	Name       string `json:"name"`
+	UID        string `json:"uid,omitempty"`
+	Generation int64  `json:"generation,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+type Details struct {
+	Name        string                 `json:"name"`
+	Timestamp   string                 `json:"timestamp"`
+	User        string                 `json:"user,omitempty"`
+	Session     string                 `json:"session,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Workshop    string                 `json:"workshop,omitempty"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+}
+
+/*
+An analytics event as sent by a training portal to the analytics webhook.
+*/
+type Event struct {
+	Portal Portal  `json:"portal"`
+	Event  Details `json:"event"`
+}
+
+/*
+Flattened form of an event for sinks which store events in a database table.
+Event specific data is stored as a JSON encoded string.
+*/
+type Row struct {
+	Timestamp   string `json:"timestamp"`
+	Event       string `json:"event"`
+	Portal      string `json:"portal"`
+	PortalURL   string `json:"portal_url"`
+	User        string `json:"user"`
+	Session     string `json:"session"`
+	Environment string `json:"environment"`
+	Workshop    string `json:"workshop"`
+	Data        string `json:"data"`
+}
+
+func (e *Event) Row() Row {
+	data := "{}"
+
+	if len(e.Event.Data) != 0 {
+		if jsonData, err := json.Marshal(e.Event.Data); err == nil {
+			data = string(jsonData)
+		}
+	}
+
+	return Row{
+		Timestamp:   e.Event.Timestamp,
+		Event:       e.Event.Name,
+		Portal:      e.Portal.Name,
+		PortalURL:   e.Portal.URL,
+		User:        e.Event.User,
+		Session:     e.Event.Session,
+		Environment: e.Event.Environment,
+		Workshop:    e.Event.Workshop,
+		Data:        data,
+	}
+}
+
+/*
+Return an identifier for the event derived from its content, which sinks
+supporting it can use to avoid storing the same event twice when a batch is
+retried.
+*/
+func (e *Event) ID() string {
+	jsonData, _ := json.Marshal(e)
+
+	digest := sha256.Sum256(jsonData)
+
+	return hex.EncodeToString(digest[:16])
+}
+
+/*
+Destination to which batches of events are written.
+*/
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+	String() string
+}
+
+/*
+Create a sink from its location. The location is a file path, or a URL with
+scheme file, s3, clickhouse or bigquery.
+*/
+func NewSink(location string) (Sink, error) {
+	if location == "-" {
+		return &FileSink{}, nil
+	}
+
+	if !strings.Contains(location, "://") {
+		return &FileSink{Path: location}, nil
+	}
+
+	parsed, err := url.Parse(location)
+
+	if err != nil {
+		return nil, failures.NewValidationError(errors.Wrapf(err, "invalid sink location %q", location), "")
+	}
+
+	switch parsed.Scheme {
+	case "file":
+		return &FileSink{Path: parsed.Path}, nil
+	case "s3":
+		return newS3Sink(parsed)
+	case "clickhouse":
+		return newClickHouseSink(parsed)
+	case "bigquery":
+		return newBigQuerySink(parsed)
+	}
+
+	return nil, failures.NewValidationError(errors.Errorf("unsupported sink type %q", parsed.Scheme), "sink must be a file path, or a file, s3, clickhouse or bigquery URL")
+}
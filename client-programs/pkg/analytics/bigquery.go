@@ -0,0 +1,133 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Sink which streams events as rows into a BigQuery table. Credentials are the
+Google application default credentials. The table must have columns matching
+the fields of Row.
+*/
+type BigQuerySink struct {
+	Project string
+	Dataset string
+	Table   string
+}
+
+func newBigQuerySink(location *url.URL) (*BigQuerySink, error) {
+	parts := strings.Split(strings.Trim(location.Path, "/"), "/")
+
+	if location.Host == "" || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, failures.NewValidationError(errors.New("invalid BigQuery sink location"), "use bigquery://PROJECT/DATASET/TABLE")
+	}
+
+	return &BigQuerySink{
+		Project: location.Host,
+		Dataset: parts[0],
+		Table:   parts[1],
+	}, nil
+}
+
+func (s *BigQuerySink) String() string {
+	return fmt.Sprintf("bigquery://%s/%s/%s", s.Project, s.Dataset, s.Table)
+}
+
+type bigQueryInsertRequest struct {
+	Rows []bigQueryRow `json:"rows"`
+}
+
+type bigQueryRow struct {
+	InsertID string `json:"insertId"`
+	JSON     Row    `json:"json"`
+}
+
+type bigQueryInsertResponse struct {
+	InsertErrors []struct {
+		Index  int `json:"index"`
+		Errors []struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"insertErrors"`
+}
+
+func (s *BigQuerySink) Write(ctx context.Context, events []Event) error {
+	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/bigquery.insertdata")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to load Google application default credentials")
+	}
+
+	// The insert ID allows BigQuery to discard duplicate rows if a batch is
+	// retried after a failure.
+
+	request := bigQueryInsertRequest{}
+
+	for i := range events {
+		request.Rows = append(request.Rows, bigQueryRow{InsertID: events[i].ID(), JSON: events[i].Row()})
+	}
+
+	body, err := json.Marshal(&request)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode analytics events")
+	}
+
+	endpoint := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/datasets/%s/tables/%s/insertAll",
+		url.PathEscape(s.Project), url.PathEscape(s.Dataset), url.PathEscape(s.Table))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create BigQuery request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrap(err, "unable to insert events into BigQuery"), "")
+	}
+
+	defer res.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 1024*1024))
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("unable to insert events into BigQuery, status %d: %s", res.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var response bigQueryInsertResponse
+
+	if err = json.Unmarshal(data, &response); err != nil {
+		return errors.Wrap(err, "unable to decode BigQuery response")
+	}
+
+	if len(response.InsertErrors) != 0 {
+		first := response.InsertErrors[0]
+
+		message := "unknown error"
+
+		if len(first.Errors) != 0 {
+			message = first.Errors[0].Message
+		}
+
+		return errors.Errorf("unable to insert %d of %d events into BigQuery: %s", len(response.InsertErrors), len(events), message)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+var clickHouseIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+/*
+Sink which inserts events as rows into a ClickHouse table using the HTTP
+interface. The table must have columns matching the fields of Row.
+*/
+type ClickHouseSink struct {
+	Endpoint string
+	Database string
+	Table    string
+	Username string
+	Password string
+}
+
+func newClickHouseSink(location *url.URL) (*ClickHouseSink, error) {
+	parts := strings.Split(strings.Trim(location.Path, "/"), "/")
+
+	if location.Host == "" || len(parts) != 2 {
+		return nil, failures.NewValidationError(errors.New("invalid ClickHouse sink location"), "use clickhouse://HOST:PORT/DATABASE/TABLE")
+	}
+
+	for _, name := range parts {
+		if !clickHouseIdentifier.MatchString(name) {
+			return nil, failures.NewValidationError(errors.Errorf("invalid ClickHouse identifier %q", name), "")
+		}
+	}
+
+	scheme := "http"
+	port := "8123"
+
+	if location.Query().Get("secure") == "true" {
+		scheme = "https"
+		port = "8443"
+	}
+
+	host := location.Host
+
+	if location.Port() == "" {
+		host = fmt.Sprintf("%s:%s", location.Hostname(), port)
+	}
+
+	sink := &ClickHouseSink{
+		Endpoint: fmt.Sprintf("%s://%s/", scheme, host),
+		Database: parts[0],
+		Table:    parts[1],
+	}
+
+	if location.User != nil {
+		sink.Username = location.User.Username()
+		sink.Password, _ = location.User.Password()
+	}
+
+	return sink, nil
+}
+
+func (s *ClickHouseSink) String() string {
+	return fmt.Sprintf("%s%s.%s", s.Endpoint, s.Database, s.Table)
+}
+
+func (s *ClickHouseSink) Write(ctx context.Context, events []Event) error {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+
+	for i := range events {
+		if err := encoder.Encode(events[i].Row()); err != nil {
+			return errors.Wrap(err, "unable to encode analytics event")
+		}
+	}
+
+	query := url.Values{}
+
+	query.Set("query", fmt.Sprintf("INSERT INTO %s.%s FORMAT JSONEachRow", s.Database, s.Table))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint+"?"+query.Encode(), &buffer)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create ClickHouse request")
+	}
+
+	if s.Username != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrap(err, "unable to insert events into ClickHouse"), "")
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+		return errors.Errorf("unable to insert events into ClickHouse, status %d: %s", res.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
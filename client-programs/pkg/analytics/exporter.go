@@ -0,0 +1,161 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+Batches events received from training portals and writes them to a sink.
+A batch is written when it reaches the batch size, or when the flush interval
+elapses. If writing a batch fails, the events are kept and the write retried
+at the next flush, with the oldest events being dropped if the number of
+events waiting exceeds the buffer limit.
+*/
+type Exporter struct {
+	Sink          Sink
+	BatchSize     int
+	FlushInterval time.Duration
+	BufferLimit   int
+
+	// Called with the outcome of each attempt to write a batch to the sink,
+	// where the error is nil if the batch was written.
+
+	OnFlush func(count int, err error)
+
+	// Called with the number of events dropped when the buffer limit is
+	// exceeded.
+
+	OnDrop func(count int)
+
+	mutex   sync.Mutex
+	flushes sync.Mutex
+	pending []Event
+	trigger chan struct{}
+}
+
+func (e *Exporter) init() {
+	e.mutex.Lock()
+
+	defer e.mutex.Unlock()
+
+	if e.trigger == nil {
+		e.trigger = make(chan struct{}, 1)
+	}
+}
+
+/*
+Queue an event to be written to the sink.
+*/
+func (e *Exporter) Add(event Event) {
+	e.init()
+
+	e.mutex.Lock()
+
+	e.pending = append(e.pending, event)
+
+	full := e.BatchSize > 0 && len(e.pending) >= e.BatchSize
+
+	e.mutex.Unlock()
+
+	if full {
+		select {
+		case e.trigger <- struct{}{}:
+		default:
+		}
+	}
+}
+
+/*
+Write any queued events to the sink, in batches of no more than the batch
+size.
+*/
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.flushes.Lock()
+
+	defer e.flushes.Unlock()
+
+	for {
+		e.mutex.Lock()
+
+		count := len(e.pending)
+
+		if e.BatchSize > 0 && count > e.BatchSize {
+			count = e.BatchSize
+		}
+
+		batch := e.pending[:count:count]
+
+		e.mutex.Unlock()
+
+		if count == 0 {
+			return nil
+		}
+
+		err := e.Sink.Write(ctx, batch)
+
+		if e.OnFlush != nil {
+			e.OnFlush(count, err)
+		}
+
+		e.mutex.Lock()
+
+		if err == nil {
+			e.pending = e.pending[count:]
+		}
+
+		dropped := 0
+
+		if e.BufferLimit > 0 && len(e.pending) > e.BufferLimit {
+			dropped = len(e.pending) - e.BufferLimit
+			e.pending = e.pending[dropped:]
+		}
+
+		e.mutex.Unlock()
+
+		if dropped != 0 && e.OnDrop != nil {
+			e.OnDrop(dropped)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+/*
+Write batches to the sink as they fill or the flush interval elapses, until
+the context is cancelled, at which point a final attempt is made to write any
+queued events.
+*/
+func (e *Exporter) Run(ctx context.Context) {
+	e.init()
+
+	interval := e.FlushInterval
+
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+
+			e.Flush(flushCtx)
+
+			cancel()
+
+			return
+		case <-ticker.C:
+			e.Flush(ctx)
+		case <-e.trigger:
+			e.Flush(ctx)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Sink which appends events to a file as JSON lines, or writes them to stdout
+if no path is given.
+*/
+type FileSink struct {
+	Path string
+}
+
+func (s *FileSink) String() string {
+	if s.Path == "" {
+		return "stdout"
+	}
+
+	return s.Path
+}
+
+func (s *FileSink) Write(_ context.Context, events []Event) error {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+
+	for i := range events {
+		if err := encoder.Encode(&events[i]); err != nil {
+			return errors.Wrap(err, "unable to encode analytics event")
+		}
+	}
+
+	if s.Path == "" {
+		_, err := os.Stdout.Write(buffer.Bytes())
+
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "unable to create directory for %s", s.Path)
+	}
+
+	file, err := os.OpenFile(s.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to open %s", s.Path)
+	}
+
+	defer file.Close()
+
+	if _, err = file.Write(buffer.Bytes()); err != nil {
+		return errors.Wrapf(err, "unable to write %s", s.Path)
+	}
+
+	return nil
+}
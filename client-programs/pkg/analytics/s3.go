@@ -0,0 +1,135 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Sink which writes each batch of events as a separate JSON lines object to an
+S3 bucket, under a key prefix organised by date. Credentials are determined
+in the same way as by the AWS CLI. An alternate endpoint can be given for S3
+compatible storage such as MinIO, in which case path style URLs are used.
+*/
+type S3Sink struct {
+	Bucket   string
+	Prefix   string
+	Region   string
+	Endpoint string
+}
+
+func newS3Sink(location *url.URL) (*S3Sink, error) {
+	if location.Host == "" {
+		return nil, failures.NewValidationError(errors.New("no bucket given for S3 sink"), "use s3://BUCKET/PREFIX")
+	}
+
+	region := location.Query().Get("region")
+
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3Sink{
+		Bucket:   location.Host,
+		Prefix:   strings.Trim(location.Path, "/"),
+		Region:   region,
+		Endpoint: strings.TrimSuffix(location.Query().Get("endpoint"), "/"),
+	}, nil
+}
+
+func (s *S3Sink) String() string {
+	return fmt.Sprintf("s3://%s/%s", s.Bucket, s.Prefix)
+}
+
+func (s *S3Sink) objectURL(key string) string {
+	if s.Endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", s.Endpoint, s.Bucket, key)
+	}
+
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, s.Region, key)
+}
+
+func (s *S3Sink) Write(ctx context.Context, events []Event) error {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+
+	for i := range events {
+		if err := encoder.Encode(&events[i]); err != nil {
+			return errors.Wrap(err, "unable to encode analytics event")
+		}
+	}
+
+	suffix := make([]byte, 4)
+
+	if _, err := rand.Read(suffix); err != nil {
+		return errors.Wrap(err, "unable to generate object name")
+	}
+
+	now := time.Now().UTC()
+
+	key := path.Join(s.Prefix, now.Format("2006/01/02"), fmt.Sprintf("%s-%s.jsonl", now.Format("150405.000000000"), hex.EncodeToString(suffix)))
+
+	awsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(s.Region))
+
+	if err != nil {
+		return errors.Wrap(err, "unable to load AWS configuration")
+	}
+
+	credentials, err := awsConfig.Credentials.Retrieve(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve AWS credentials")
+	}
+
+	body := buffer.Bytes()
+
+	digest := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(digest[:])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(body))
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create S3 request")
+	}
+
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	if err = v4.NewSigner().SignHTTP(ctx, credentials, req, payloadHash, "s3", s.Region, now); err != nil {
+		return errors.Wrap(err, "unable to sign S3 request")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrapf(err, "unable to write object %s to S3", key), "")
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+		return errors.Errorf("unable to write object %s to S3, status %d: %s", key, res.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Local audit log of changes made using the Educates CLI.
+*/
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+)
+
+type Entry struct {
+	Time    time.Time         `json:"time"`
+	User    string            `json:"user"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Flags   map[string]string `json:"flags,omitempty"`
+	Result  string            `json:"result"`
+	Error   string            `json:"error,omitempty"`
+}
+
+func LogFile() string {
+	return filepath.Join(xdg.StateHome, "educates", "audit.log")
+}
+
+/*
+Append an entry to the audit log. The log file is only ever appended to, with
+each entry being written as a single line of JSON.
+*/
+func Record(entry Entry) error {
+	logFile := LogFile()
+
+	err := os.MkdirAll(filepath.Dir(logFile), os.ModePerm)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create audit log directory")
+	}
+
+	data, err := json.Marshal(&entry)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode audit log entry")
+	}
+
+	file, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to open audit log %s", logFile)
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write audit log %s", logFile)
+	}
+
+	return nil
+}
+
+/*
+Read all entries from the audit log, oldest first. Lines which cannot be
+decoded are skipped.
+*/
+func Read() ([]Entry, error) {
+	var entries []Entry
+
+	file, err := os.Open(LogFile())
+
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open audit log %s", LogFile())
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var entry Entry
+
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "unable to read audit log %s", LogFile())
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,377 @@
+package bundle
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/pkg/errors"
+	imgpkgcmd "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/cmd"
+	"github.com/vmware-tanzu/carvel-kapp/pkg/kapp/cmd"
+	"gopkg.in/yaml.v2"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/httpclient"
+)
+
+/*
+Default location of the packages for the platform. Packages are imgpkg
+bundles holding the ytt templates for deploying a component, along with a
+lock file listing the images it uses.
+*/
+const DefaultPackageRepository = "ghcr.io/vmware-tanzu-labs"
+
+var PlatformPackages = []string{
+	"educates-cluster-essentials",
+	"educates-training-platform",
+}
+
+/*
+Images used by the CLI itself when working with the cluster, such as for
+running scheduled changes to training portals, which are not otherwise pulled
+by name from within the cluster. These are copied into the bundle as separate
+images, so that when relocated along with the package they belong to, they
+keep the same tag and can be found alongside the package.
+*/
+var PlatformImages = []BundleImage{
+	{Name: "educates-base-environment", Package: "educates-training-platform"},
+}
+
+const manifestFile = "bundle.yaml"
+
+/*
+Manifest stored at the root of a platform bundle describing its contents.
+*/
+type PlatformBundle struct {
+	Version  string          `yaml:"version"`
+	Packages []BundlePackage `yaml:"packages"`
+	Images   []BundleImage   `yaml:"images,omitempty"`
+}
+
+type BundlePackage struct {
+	Name  string `yaml:"name"`
+	Image string `yaml:"image"`
+	File  string `yaml:"file"`
+}
+
+type BundleImage struct {
+	Name    string `yaml:"name"`
+	Package string `yaml:"package"`
+	Image   string `yaml:"image,omitempty"`
+	File    string `yaml:"file,omitempty"`
+}
+
+/*
+Locate a package in the bundle by name.
+*/
+func (b *PlatformBundle) Package(name string) (*BundlePackage, bool) {
+	for i := range b.Packages {
+		if b.Packages[i].Name == name {
+			return &b.Packages[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func newConfUI() *ui.ConfUI {
+	confUI := ui.NewConfUI(ui.NewNoopLogger())
+
+	uiFlags := cmd.UIFlags{
+		Color:          true,
+		JSON:           false,
+		NonInteractive: true,
+	}
+
+	uiFlags.ConfigureUI(confUI)
+
+	return confUI
+}
+
+/*
+Create a bundle for installing the platform in a disconnected environment.
+Each package, together with all the images it references, is copied into an
+imgpkg tarball, and these are then packaged into the one archive file with a
+manifest describing what it holds.
+*/
+func CreateBundle(version string, repository string, output string, registryFlags imgpkgcmd.RegistryFlags) error {
+	tempDir, err := os.MkdirTemp("", "educates-bundle")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary directory")
+	}
+
+	defer os.RemoveAll(tempDir)
+
+	confUI := newConfUI()
+
+	defer confUI.Flush()
+
+	manifest := PlatformBundle{Version: version}
+
+	for _, name := range PlatformPackages {
+		image := fmt.Sprintf("%s/%s:%s", repository, name, version)
+
+		fmt.Printf("Copying package %s ...\n", image)
+
+		copyOptions := imgpkgcmd.NewCopyOptions(confUI)
+
+		copyOptions.BundleFlags.Bundle = image
+		copyOptions.TarFlags.TarDst = filepath.Join(tempDir, name+".tar")
+		copyOptions.RegistryFlags = registryFlags
+		copyOptions.RegistryFlags.CACertPaths = httpclient.RegistryCACertPaths(registryFlags.CACertPaths)
+		copyOptions.Concurrency = 5
+
+		if err = copyOptions.Run(); err != nil {
+			return errors.Wrapf(err, "unable to copy package %s", image)
+		}
+
+		manifest.Packages = append(manifest.Packages, BundlePackage{
+			Name:  name,
+			Image: image,
+			File:  name + ".tar",
+		})
+	}
+
+	for _, item := range PlatformImages {
+		image := fmt.Sprintf("%s/%s:%s", repository, item.Name, version)
+
+		fmt.Printf("Copying image %s ...\n", image)
+
+		copyOptions := imgpkgcmd.NewCopyOptions(confUI)
+
+		copyOptions.ImageFlags.Image = image
+		copyOptions.TarFlags.TarDst = filepath.Join(tempDir, item.Name+".tar")
+		copyOptions.RegistryFlags = registryFlags
+		copyOptions.RegistryFlags.CACertPaths = httpclient.RegistryCACertPaths(registryFlags.CACertPaths)
+		copyOptions.Concurrency = 5
+
+		if err = copyOptions.Run(); err != nil {
+			return errors.Wrapf(err, "unable to copy image %s", image)
+		}
+
+		manifest.Images = append(manifest.Images, BundleImage{
+			Name:    item.Name,
+			Package: item.Package,
+			Image:   image,
+			File:    item.Name + ".tar",
+		})
+	}
+
+	manifestData, err := yaml.Marshal(&manifest)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate bundle manifest")
+	}
+
+	if err = os.WriteFile(filepath.Join(tempDir, manifestFile), manifestData, 0644); err != nil {
+		return errors.Wrap(err, "unable to write bundle manifest")
+	}
+
+	outputFile, err := os.Create(output)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create bundle file %q", output)
+	}
+
+	defer outputFile.Close()
+
+	tarWriter := tar.NewWriter(outputFile)
+
+	files := []string{manifestFile}
+
+	for _, item := range manifest.Packages {
+		files = append(files, item.File)
+	}
+
+	for _, item := range manifest.Images {
+		files = append(files, item.File)
+	}
+
+	for _, name := range files {
+		if err = addFile(tarWriter, filepath.Join(tempDir, name), name); err != nil {
+			return errors.Wrapf(err, "unable to write bundle file %q", output)
+		}
+	}
+
+	if err = tarWriter.Close(); err != nil {
+		return errors.Wrapf(err, "unable to write bundle file %q", output)
+	}
+
+	return outputFile.Close()
+}
+
+func addFile(tarWriter *tar.Writer, path string, name string) error {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+
+	if err != nil {
+		return err
+	}
+
+	header.Name = name
+
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tarWriter, file)
+
+	return err
+}
+
+/*
+Relocate the packages held in a bundle, along with the images they use, to
+an image repository reachable from the cluster. The imgpkg lock file within
+each package is rewritten as part of the copy, so images are pulled from the
+new location when the package is deployed. Only the named packages are
+relocated, along with any images in the bundle belonging to them, which keep
+their tags. The manifest for the bundle is returned, with the images for the
+relocated packages and images updated to refer to the new location.
+*/
+func RelocateBundle(path string, repository string, registryFlags imgpkgcmd.RegistryFlags, names ...string) (*PlatformBundle, error) {
+	tempDir, err := os.MkdirTemp("", "educates-bundle")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create temporary directory")
+	}
+
+	defer os.RemoveAll(tempDir)
+
+	if err = extractBundle(path, tempDir); err != nil {
+		return nil, err
+	}
+
+	manifestData, err := os.ReadFile(filepath.Join(tempDir, manifestFile))
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read manifest from bundle %q", path)
+	}
+
+	manifest := &PlatformBundle{}
+
+	if err = yaml.Unmarshal(manifestData, manifest); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse manifest from bundle %q", path)
+	}
+
+	confUI := newConfUI()
+
+	defer confUI.Flush()
+
+	repository = strings.TrimSuffix(repository, "/")
+
+	for _, name := range names {
+		item, found := manifest.Package(name)
+
+		if !found {
+			return nil, errors.Errorf("package %s not found in bundle %q", name, path)
+		}
+
+		destination := fmt.Sprintf("%s/%s", repository, item.Name)
+
+		fmt.Printf("Relocating package %s to %s ...\n", item.Name, destination)
+
+		copyOptions := imgpkgcmd.NewCopyOptions(confUI)
+
+		copyOptions.TarFlags.TarSrc = filepath.Join(tempDir, item.File)
+		copyOptions.RepoDst = destination
+		copyOptions.RegistryFlags = registryFlags
+		copyOptions.RegistryFlags.CACertPaths = httpclient.RegistryCACertPaths(registryFlags.CACertPaths)
+		copyOptions.Concurrency = 5
+
+		if err = copyOptions.Run(); err != nil {
+			return nil, errors.Wrapf(err, "unable to relocate package %s", item.Name)
+		}
+
+		item.Image = fmt.Sprintf("%s:%s", destination, manifest.Version)
+
+		for i := range manifest.Images {
+			image := &manifest.Images[i]
+
+			if image.Package != name {
+				continue
+			}
+
+			destination := fmt.Sprintf("%s/%s", repository, image.Name)
+
+			fmt.Printf("Relocating image %s to %s ...\n", image.Name, destination)
+
+			copyOptions := imgpkgcmd.NewCopyOptions(confUI)
+
+			copyOptions.TarFlags.TarSrc = filepath.Join(tempDir, image.File)
+			copyOptions.RepoDst = destination
+			copyOptions.RegistryFlags = registryFlags
+			copyOptions.RegistryFlags.CACertPaths = httpclient.RegistryCACertPaths(registryFlags.CACertPaths)
+			copyOptions.Concurrency = 5
+
+			if err = copyOptions.Run(); err != nil {
+				return nil, errors.Wrapf(err, "unable to relocate image %s", image.Name)
+			}
+
+			image.Image = fmt.Sprintf("%s:%s", destination, manifest.Version)
+		}
+	}
+
+	return manifest, nil
+}
+
+func extractBundle(path string, directory string) error {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to open bundle %q", path)
+	}
+
+	defer file.Close()
+
+	tarReader := tar.NewReader(file)
+
+	for {
+		header, err := tarReader.Next()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read bundle %q", path)
+		}
+
+		// Only regular files at the top level of the archive are expected,
+		// so reject anything which could be written outside the directory.
+
+		if header.Typeflag != tar.TypeReg || header.Name != filepath.Base(header.Name) {
+			return errors.Errorf("unexpected entry %q in bundle %q", header.Name, path)
+		}
+
+		target, err := os.Create(filepath.Join(directory, header.Name))
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to extract bundle %q", path)
+		}
+
+		_, err = io.Copy(target, tarReader)
+
+		target.Close()
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to extract bundle %q", path)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+/*
+On disk cache for data fetched from clusters and remote hosts, so repeated
+invocations of the CLI don't need to fetch the same data again.
+*/
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+)
+
+const DefaultTTL = 10 * time.Minute
+
+/*
+Global options controlling use of the cache. These are bound to persistent
+flags on the root command. When the cache is disabled entries are not read,
+but fresh data is still written so later invocations benefit.
+*/
+var Disabled bool
+
+var TTL = DefaultTTL
+
+func Dir() string {
+	return filepath.Join(xdg.CacheHome, "educates", "cache")
+}
+
+func entryFile(category string, key string) string {
+	digest := sha256.Sum256([]byte(key))
+
+	return filepath.Join(Dir(), category, hex.EncodeToString(digest[:]))
+}
+
+/*
+Return the data cached for a key, if it exists and was written within the
+TTL for the cache.
+*/
+func Get(category string, key string) ([]byte, bool) {
+	if Disabled || TTL <= 0 {
+		return nil, false
+	}
+
+	file := entryFile(category, key)
+
+	info, err := os.Stat(file)
+
+	if err != nil || time.Since(info.ModTime()) > TTL {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+func Put(category string, key string, data []byte) error {
+	file := entryFile(category, key)
+
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return errors.Wrap(err, "unable to create cache directory")
+	}
+
+	// Write to a temporary file and rename it so that concurrent invocations
+	// never see a partially written entry.
+
+	temporary := file + ".tmp"
+
+	if err := os.WriteFile(temporary, data, 0o600); err != nil {
+		return errors.Wrap(err, "unable to write cache entry")
+	}
+
+	if err := os.Rename(temporary, file); err != nil {
+		return errors.Wrap(err, "unable to write cache entry")
+	}
+
+	return nil
+}
+
+/*
+Variants of Get and Put which store a value encoded as JSON.
+*/
+func GetJSON(category string, key string, value interface{}) bool {
+	data, found := Get(category, key)
+
+	if !found {
+		return false
+	}
+
+	return json.Unmarshal(data, value) == nil
+}
+
+func PutJSON(category string, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode cache entry")
+	}
+
+	return Put(category, key, data)
+}
@@ -0,0 +1,161 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+/*
+Apply resources to the cluster using server side apply, in the order given.
+Namespaced resources which don't specify a namespace are created in the
+default namespace. The resources can include custom resource definitions
+along with resources of the type they define.
+*/
+func (o *ClusterConfig) ApplyResources(ctx context.Context, objects []*unstructured.Unstructured, fieldManager string) error {
+	client, err := o.GetClient()
+
+	if err != nil {
+		return err
+	}
+
+	dynamicClient, err := o.GetDynamicClient()
+
+	if err != nil {
+		return err
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client.Discovery()))
+
+	for _, object := range objects {
+		resourceClient, err := resourceClientFor(dynamicClient, mapper, object)
+
+		// Where an earlier resource was a custom resource definition, the
+		// discovery data will need to be refreshed for resources of that type.
+
+		if meta.IsNoMatchError(err) {
+			mapper.Reset()
+
+			resourceClient, err = resourceClientFor(dynamicClient, mapper, object)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to determine resource type for %s %q", object.GetKind(), object.GetName())
+		}
+
+		data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, object)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to encode %s %q", object.GetKind(), object.GetName())
+		}
+
+		_, err = resourceClient.Patch(ctx, object.GetName(), types.ApplyPatchType, data, metav1.ApplyOptions{FieldManager: fieldManager, Force: true}.ToPatchOptions())
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to apply %s %q", object.GetKind(), object.GetName())
+		}
+	}
+
+	return nil
+}
+
+/*
+Wait for resources to be ready. Custom resource definitions need to be
+established, namespaces active and deployments and stateful sets to have
+all replicas ready. Other types of resources are ready once they exist.
+*/
+func (o *ClusterConfig) WaitForResourcesReady(ctx context.Context, objects []*unstructured.Unstructured, timeout time.Duration) error {
+	client, err := o.GetClient()
+
+	if err != nil {
+		return err
+	}
+
+	dynamicClient, err := o.GetDynamicClient()
+
+	if err != nil {
+		return err
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client.Discovery()))
+
+	deadline := time.Now().Add(timeout)
+
+	for _, object := range objects {
+		resourceClient, err := resourceClientFor(dynamicClient, mapper, object)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to determine resource type for %s %q", object.GetKind(), object.GetName())
+		}
+
+		err = WaitForResource(ctx, resourceClient, object.GetName(), time.Until(deadline), resourceReady)
+
+		if err != nil {
+			return errors.Wrapf(err, "%s %q did not become ready", object.GetKind(), object.GetName())
+		}
+	}
+
+	return nil
+}
+
+func resourceClientFor(client dynamic.Interface, mapper meta.RESTMapper, object *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := object.GroupVersionKind()
+
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return client.Resource(mapping.Resource), nil
+	}
+
+	namespace := object.GetNamespace()
+
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	return client.Resource(mapping.Resource).Namespace(namespace), nil
+}
+
+func resourceReady(object *unstructured.Unstructured) (bool, error) {
+	switch object.GroupVersionKind().GroupKind().String() {
+	case "CustomResourceDefinition.apiextensions.k8s.io":
+		conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+
+		for _, item := range conditions {
+			if condition, ok := item.(map[string]interface{}); ok && condition["type"] == "Established" {
+				return condition["status"] == "True", nil
+			}
+		}
+
+		return false, nil
+	case "Namespace":
+		phase, _, _ := unstructured.NestedString(object.Object, "status", "phase")
+
+		return phase == "Active", nil
+	case "Deployment.apps", "StatefulSet.apps":
+		replicas, found, _ := unstructured.NestedInt64(object.Object, "spec", "replicas")
+
+		if !found {
+			replicas = 1
+		}
+
+		ready, _, _ := unstructured.NestedInt64(object.Object, "status", "readyReplicas")
+
+		return ready >= replicas, nil
+	}
+
+	return true, nil
+}
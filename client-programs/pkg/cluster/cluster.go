@@ -1,34 +1,53 @@
 package cluster
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cache"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 )
 
 type ClusterConfig struct {
 	Kubeconfig string
+	Context    string
 }
 
 func NewClusterConfig(kubeconfig string) *ClusterConfig {
-	return &ClusterConfig{kubeconfig}
+	return &ClusterConfig{Kubeconfig: kubeconfig}
+}
+
+/*
+Create cluster config for a specific context in the kubeconfig file rather
+than the current context.
+*/
+func NewClusterConfigForContext(kubeconfig string, context string) *ClusterConfig {
+	return &ClusterConfig{Kubeconfig: kubeconfig, Context: context}
 }
 
 func GetConfig(masterURL, kubeconfigPath string) (*rest.Config, error) {
+	return GetConfigForContext(masterURL, kubeconfigPath, "")
+}
+
+func GetConfigForContext(masterURL, kubeconfigPath string, contextName string) (*rest.Config, error) {
 	envVarName := clientcmd.RecommendedConfigPathEnvVar
 
-	if kubeconfigPath == "" && masterURL == "" && os.Getenv(envVarName) == "" {
+	if kubeconfigPath == "" && masterURL == "" && contextName == "" && os.Getenv(envVarName) == "" {
 		// No explicit overrides so attempt to use in cluster config first.
 
 		kubeconfig, err := rest.InClusterConfig()
 
 		if err == nil {
-			return kubeconfig, nil
+			return configureRequests(kubeconfig), nil
 		}
 	}
 
@@ -42,31 +61,120 @@ func GetConfig(masterURL, kubeconfigPath string) (*rest.Config, error) {
 
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	loadingRules.ExplicitPath = kubeconfigPath
-	configOverrides := &clientcmd.ConfigOverrides{ClusterInfo: clientcmdapi.Cluster{Server: masterURL}}
+	configOverrides := &clientcmd.ConfigOverrides{ClusterInfo: clientcmdapi.Cluster{Server: masterURL}, CurrentContext: contextName}
+
+	kubeconfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides).ClientConfig()
 
-	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return configureRequests(kubeconfig), nil
 }
 
 func (o *ClusterConfig) GetClient() (*kubernetes.Clientset, error) {
-	config, err := GetConfig("", o.Kubeconfig)
+	config, err := GetConfigForContext("", o.Kubeconfig, o.Context)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to build client config")
+		return nil, failures.NewConnectionError(errors.Wrap(err, "unable to build client config"), failures.ClusterHint)
 	}
 
 	return kubernetes.NewForConfig(config)
 }
 
 func (o *ClusterConfig) GetDynamicClient() (dynamic.Interface, error) {
-	config, err := GetConfig("", o.Kubeconfig)
+	config, err := GetConfigForContext("", o.Kubeconfig, o.Context)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to build client config")
+		return nil, failures.NewConnectionError(errors.Wrap(err, "unable to build client config"), failures.ClusterHint)
 	}
 
 	return dynamic.NewForConfig(config)
 }
 
+/*
+Return a key identifying the cluster and the user it is accessed as, for use
+when caching data retrieved from the cluster.
+*/
+func (o *ClusterConfig) CacheKey() (string, error) {
+	config, err := GetConfigForContext("", o.Kubeconfig, o.Context)
+
+	if err != nil {
+		return "", failures.NewConnectionError(errors.Wrap(err, "unable to build client config"), failures.ClusterHint)
+	}
+
+	return fmt.Sprintf("%s|%s|%s|%s", config.Host, o.Context, config.Username, config.CertFile), nil
+}
+
+/*
+Return the names of the resources served by the cluster for an API group
+version. Results are cached, subject to the cache TTL, as discovery requires
+a round trip to the cluster on every invocation of the CLI.
+*/
+func (o *ClusterConfig) ServedResources(groupVersion string) ([]string, error) {
+	key, err := o.CacheKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	key = key + "|" + groupVersion
+
+	var names []string
+
+	if cache.GetJSON("discovery", key, &names) {
+		return names, nil
+	}
+
+	client, err := o.GetClient()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(groupVersion)
+
+	if k8serrors.IsNotFound(err) {
+		resources, err = &metav1.APIResourceList{}, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to discover resources for %s", groupVersion)
+	}
+
+	names = []string{}
+
+	for _, resource := range resources.APIResources {
+		names = append(names, resource.Name)
+	}
+
+	cache.PutJSON("discovery", key, names)
+
+	return names, nil
+}
+
+/*
+Return the names of the contexts defined in the kubeconfig file.
+*/
+func ContextNames(kubeconfigPath string) ([]string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfigPath
+
+	config, err := loadingRules.Load()
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load kubeconfig")
+	}
+
+	var names []string
+
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
 func KubeconfigPath(override string, fallback string) string {
 	if override != "" {
 		return override
@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Run a command in a container of a pod, with input for the command read from
+stdin if supplied, and its output written to stdout. Any error output from
+the command is included in the error returned if the command fails.
+*/
+func (o *ClusterConfig) ExecInPod(ctx context.Context, namespace string, pod string, container string, command []string, stdin io.Reader, stdout io.Writer) error {
+	config, err := GetConfigForContext("", o.Kubeconfig, o.Context)
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrap(err, "unable to build client config"), failures.ClusterHint)
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+
+	if err != nil {
+		return err
+	}
+
+	request := client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&apiv1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", request.URL())
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to execute command in pod %s/%s", namespace, pod)
+	}
+
+	var stderr bytes.Buffer
+
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: &stderr,
+	})
+
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return errors.Wrapf(err, "command in pod %s/%s failed: %s", namespace, pod, message)
+		}
+
+		return errors.Wrapf(err, "command in pod %s/%s failed", namespace, pod)
+	}
+
+	return nil
+}
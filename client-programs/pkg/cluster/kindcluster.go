@@ -17,9 +17,12 @@ import (
 	"golang.org/x/exp/slices"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
 	"sigs.k8s.io/kind/pkg/cmd"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 )
 
 type KindClusterConfig struct {
@@ -35,7 +38,7 @@ func NewKindClusterConfig(kubeconfig string) *KindClusterConfig {
 		fallback = filepath.Join(home, clientcmd.RecommendedHomeDir, clientcmd.RecommendedFileName)
 	}
 
-	return &KindClusterConfig{ClusterConfig{KubeconfigPath(kubeconfig, fallback)}}
+	return &KindClusterConfig{ClusterConfig{Kubeconfig: KubeconfigPath(kubeconfig, fallback)}}
 }
 
 //go:embed kindclusterconfig.yaml.tpl
@@ -113,7 +116,7 @@ func (o *KindClusterConfig) StopCluster() error {
 	}
 
 	if !slices.Contains(clusters, "educates") {
-		return errors.New("cluster for Educates doesn't exist")
+		return failures.NewNotFoundError(errors.New("cluster for Educates doesn't exist"), "create the cluster with `educates admin cluster create`")
 	}
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
@@ -159,7 +162,7 @@ func (o *KindClusterConfig) StartCluster() error {
 	}
 
 	if !slices.Contains(clusters, "educates") {
-		return errors.New("cluster for Educates doesn't exist")
+		return failures.NewNotFoundError(errors.New("cluster for Educates doesn't exist"), "create the cluster with `educates admin cluster create`")
 	}
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
@@ -182,3 +185,92 @@ func (o *KindClusterConfig) StartCluster() error {
 
 	return nil
 }
+
+func (o *KindClusterConfig) ClusterExists() (bool, error) {
+	provider := cluster.NewProvider(
+		cluster.ProviderWithLogger(cmd.NewLogger()),
+	)
+
+	clusters, err := provider.List()
+
+	if err != nil {
+		return false, errors.Wrap(err, "unable to get list of clusters")
+	}
+
+	return slices.Contains(clusters, "educates"), nil
+}
+
+func (o *KindClusterConfig) internalNodes() ([]nodes.Node, error) {
+	provider := cluster.NewProvider(
+		cluster.ProviderWithLogger(cmd.NewLogger()),
+	)
+
+	clusterNodes, err := provider.ListInternalNodes("educates")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get list of cluster nodes")
+	}
+
+	return clusterNodes, nil
+}
+
+/*
+Check whether an image is already present on all nodes of the cluster.
+*/
+func (o *KindClusterConfig) HasImage(image string) (bool, error) {
+	clusterNodes, err := o.internalNodes()
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, node := range clusterNodes {
+		if _, err := nodeutils.ImageID(node, image); err != nil {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+/*
+Load an image archive, as created by `docker save`, into all nodes of the
+cluster. Nodes are loaded in parallel.
+*/
+func (o *KindClusterConfig) LoadImageArchive(archive string) error {
+	clusterNodes, err := o.internalNodes()
+
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, len(clusterNodes))
+
+	for _, node := range clusterNodes {
+		go func(node nodes.Node) {
+			file, err := os.Open(archive)
+
+			if err != nil {
+				errs <- errors.Wrap(err, "unable to open image archive")
+				return
+			}
+
+			defer file.Close()
+
+			if err := nodeutils.LoadImageArchive(node, file); err != nil {
+				errs <- errors.Wrapf(err, "unable to load image into node %s", node.String())
+				return
+			}
+
+			errs <- nil
+		}(node)
+	}
+
+	for range clusterNodes {
+		if nodeErr := <-errs; nodeErr != nil && err == nil {
+			err = nodeErr
+		}
+	}
+
+	return err
+}
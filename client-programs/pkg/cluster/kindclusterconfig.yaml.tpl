@@ -37,6 +37,10 @@ nodes:
     containerPath: {{ .ContainerPath }}
   {{- end }}
   {{- end }}
+{{- if .LocalKindCluster.IPFamily }}
+networking:
+  ipFamily: {{ .LocalKindCluster.IPFamily }}
+{{- end }}
 containerdConfigPatches:
 - |-
   [plugins."io.containerd.grpc.v1.cri".registry.mirrors."registry.default.svc.cluster.local"]
@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Forward a local port to a port of a pod. A local port of 0 results in a free
+port being selected, with the local port actually used being returned once
+the port forward is ready. Connections are forwarded in the background until
+the stop channel is closed, with any error which causes the port forward to
+fail being sent on the returned channel.
+*/
+func (o *ClusterConfig) ForwardPort(namespace string, pod string, address string, localPort int, remotePort int, stopChannel chan struct{}) (int, <-chan error, error) {
+	config, err := GetConfigForContext("", o.Kubeconfig, o.Context)
+
+	if err != nil {
+		return 0, nil, failures.NewConnectionError(errors.Wrap(err, "unable to build client config"), failures.ClusterHint)
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "unable to create port forward transport")
+	}
+
+	request := client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("portforward")
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", request.URL())
+
+	readyChannel := make(chan struct{})
+
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{address}, []string{fmt.Sprintf("%d:%d", localPort, remotePort)}, stopChannel, readyChannel, io.Discard, io.Discard)
+
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "unable to forward port to pod %s/%s", namespace, pod)
+	}
+
+	errorChannel := make(chan error, 1)
+
+	go func() {
+		errorChannel <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case err = <-errorChannel:
+		return 0, nil, errors.Wrapf(err, "unable to forward port to pod %s/%s", namespace, pod)
+	case <-readyChannel:
+	}
+
+	ports, err := forwarder.GetPorts()
+
+	if err != nil || len(ports) == 0 {
+		return 0, nil, errors.Errorf("unable to determine local port forwarded to pod %s/%s", namespace, pod)
+	}
+
+	return int(ports[0].Local), errorChannel, nil
+}
@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+/*
+Global options controlling requests made to the Kubernetes API server. These
+are bound to persistent flags on the root command. A request timeout of zero
+means requests do not time out.
+*/
+var RequestTimeout time.Duration
+
+var RequestRetries = 3
+
+const retryInitialBackoff = 500 * time.Millisecond
+
+const retryMaximumBackoff = 10 * time.Second
+
+/*
+Apply the timeout to the client config and wrap the transport such that all
+requests made by clients created from it are retried when they fail due to
+transient problems.
+*/
+func configureRequests(config *rest.Config) *rest.Config {
+	config.Timeout = RequestTimeout
+
+	if RequestRetries > 0 {
+		config.Wrap(func(next http.RoundTripper) http.RoundTripper {
+			return &retryTransport{next: next, retries: RequestRetries}
+		})
+	}
+
+	return config
+}
+
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+}
+
+/*
+Make the request, retrying with exponential backoff if the API server could
+not be reached, or responded that it is overloaded or unavailable. Requests
+which may have changed state in the cluster, that is where a connection was
+made but the request failed, are only retried if repeating them is safe.
+Watch requests are never retried as they are reestablished by the caller.
+*/
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Query().Get("watch") == "true" {
+		return t.next.RoundTrip(req)
+	}
+
+	backoff := retryInitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		// A round tripper must not modify the request it is given, so each
+		// retry is made using a copy of the request with a fresh body.
+
+		request := req
+
+		if attempt > 0 {
+			request = req.Clone(req.Context())
+
+			if req.Body != nil && req.Body != http.NoBody {
+				if req.GetBody == nil {
+					return nil, errors.New("unable to retry request with body")
+				}
+
+				body, err := req.GetBody()
+
+				if err != nil {
+					return nil, err
+				}
+
+				request.Body = body
+			}
+		}
+
+		res, err := t.next.RoundTrip(request)
+
+		if attempt >= t.retries || !retryable(req, res, err) {
+			return res, err
+		}
+
+		wait := backoff
+
+		if res != nil {
+			if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				wait = time.Duration(seconds) * time.Second
+			}
+
+			res.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+
+		if backoff > retryMaximumBackoff {
+			backoff = retryMaximumBackoff
+		}
+	}
+}
+
+func retryable(req *http.Request, res *http.Response, err error) bool {
+	// A patch may not be idempotent, such as where it appends to a list, so
+	// is treated the same as a create.
+
+	idempotent := req.Method != http.MethodPost && req.Method != http.MethodPatch
+
+	if err != nil {
+		if req.Context().Err() != nil {
+			return false
+		}
+
+		// Failure to connect means the request was never sent, so it is
+		// always safe to retry. Other network errors, such as a timeout
+		// waiting on a response, are only retried if idempotent.
+
+		var opError *net.OpError
+
+		if errors.As(err, &opError) && opError.Op == "dial" {
+			return true
+		}
+
+		return idempotent
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+package cluster
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func testResponse(status int) *http.Response {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		wantStatus   int
+		wantAttempts int
+	}{
+		{name: "success not retried", method: http.MethodGet, statuses: []int{200}, wantStatus: 200, wantAttempts: 1},
+		{name: "unavailable retried", method: http.MethodPut, statuses: []int{503, 503, 200}, wantStatus: 200, wantAttempts: 3},
+		{name: "bad gateway retried when idempotent", method: http.MethodPut, statuses: []int{502, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "bad gateway not retried for post", method: http.MethodPost, statuses: []int{502, 200}, wantStatus: 502, wantAttempts: 1},
+		{name: "bad gateway not retried for patch", method: http.MethodPatch, statuses: []int{502, 200}, wantStatus: 502, wantAttempts: 1},
+		{name: "retries limited", method: http.MethodGet, statuses: []int{429, 429, 429}, wantStatus: 429, wantAttempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+
+			var requests []*http.Request
+
+			transport := &retryTransport{
+				retries: 2,
+				next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					body, _ := io.ReadAll(req.Body)
+
+					if string(body) != "payload" {
+						t.Errorf("attempt %d sent body %q", attempts+1, body)
+					}
+
+					requests = append(requests, req)
+
+					res := testResponse(tt.statuses[attempts])
+
+					res.Header.Set("Retry-After", "0")
+
+					attempts++
+
+					return res, nil
+				}),
+			}
+
+			req, err := http.NewRequest(tt.method, "https://cluster.example.com/api/v1/namespaces", bytes.NewReader([]byte("payload")))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			original := req.Body
+
+			res, err := transport.RoundTrip(req)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d but got %d", tt.wantStatus, res.StatusCode)
+			}
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts but got %d", tt.wantAttempts, attempts)
+			}
+
+			if req.Body != original {
+				t.Errorf("expected body of original request to be left unchanged")
+			}
+
+			for i, request := range requests[1:] {
+				if request == req {
+					t.Errorf("expected retry %d to use a copy of the request", i+1)
+				}
+			}
+		})
+	}
+}
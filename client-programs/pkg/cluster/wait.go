@@ -0,0 +1,102 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+/*
+Wait for a named resource to satisfy a condition. Rather than repeatedly
+fetching the resource, it is listed once and then watched, with the condition
+being evaluated each time the resource changes. The watch is restarted if it
+is closed by the API server before the condition is met. If the timeout
+expires first, wait.ErrWaitTimeout is returned. If the condition returns an
+error waiting stops and that error is returned. If the context is cancelled,
+such as when the user interrupts the command, the error of the context is
+returned.
+*/
+func WaitForResource(ctx context.Context, client dynamic.ResourceInterface, name string, timeout time.Duration, condition func(resource *unstructured.Unstructured) (bool, error)) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return client.List(waitCtx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return client.Watch(waitCtx, options)
+		},
+	}
+
+	_, err := watchtools.UntilWithSync(waitCtx, listWatch, &unstructured.Unstructured{}, nil, func(event watch.Event) (bool, error) {
+		if event.Type == watch.Deleted {
+			return false, nil
+		}
+
+		resource, ok := event.Object.(*unstructured.Unstructured)
+
+		if !ok {
+			return false, nil
+		}
+
+		return condition(resource)
+	})
+
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err != nil && waitCtx.Err() == context.DeadlineExceeded {
+		return wait.ErrWaitTimeout
+	}
+
+	return err
+}
+
+/*
+Poll a condition at the given interval until it returns true or an error, or
+the timeout expires, in which case wait.ErrWaitTimeout is returned. Unlike
+the Kubernetes helpers, if the context is cancelled the error of the context
+is returned rather than it being reported as a timeout.
+*/
+func PollImmediate(ctx context.Context, interval time.Duration, timeout time.Duration, condition func() (bool, error)) error {
+	err := wait.PollImmediateWithContext(ctx, interval, timeout, func(context.Context) (bool, error) {
+		return condition()
+	})
+
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+/*
+Wait for the given duration, returning early with the error of the context if
+it is cancelled first.
+*/
+func Sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+var secretCopierResource = schema.GroupVersionResource{Group: "secrets.educates.dev", Version: "v1beta1", Resource: "secretcopiers"}
+var secretInjectorResource = schema.GroupVersionResource{Group: "secrets.educates.dev", Version: "v1beta1", Resource: "secretinjectors"}
+
+/*
+Cluster scoped resources saved in a backup, in the order they need to be
+restored. Workshops and secret configuration come before training portals
+so they exist by the time workshop environments are created.
+*/
+var backupResources = []schema.GroupVersionResource{
+	workshopResource,
+	secretCopierResource,
+	secretInjectorResource,
+	trainingPortalResource,
+}
+
+/*
+Location of the files holding the state of a training portal within the
+container running the portal.
+*/
+const (
+	portalDataDirectory = "/opt/app-root/data"
+	portalDatabaseFile  = "db.sqlite3"
+	portalSecretKeyFile = "secret-key.txt"
+)
+
+/*
+Manifest stored at the root of a backup archive describing its contents.
+*/
+type platformBackup struct {
+	Version   string   `json:"version"`
+	Created   string   `json:"created"`
+	Resources []string `json:"resources"`
+	Portals   []string `json:"portals"`
+}
+
+type AdminBackupOptions struct {
+	Kubeconfig    string
+	Output        string
+	SkipDatabases bool
+}
+
+func (o *AdminBackupOptions) Run(ctx context.Context, cliVersion string) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	created := time.Now().UTC()
+
+	if o.Output == "" {
+		o.Output = fmt.Sprintf("educates-backup-%s.tar.gz", created.Format("20060102-150405"))
+	}
+
+	manifest := platformBackup{
+		Version: cliVersion,
+		Created: created.Format(time.RFC3339),
+	}
+
+	// Collect everything before writing the archive so that a failure part
+	// way through doesn't leave behind an incomplete backup.
+
+	files := map[string][]byte{}
+
+	var names []string
+
+	addFile := func(name string, data []byte) {
+		files[name] = data
+		names = append(names, name)
+	}
+
+	for _, resource := range backupResources {
+		items, err := dynamicClient.Resource(resource).List(ctx, metav1.ListOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s as not installed in the cluster.\n", resource.Resource)
+			continue
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to list %s", resource.Resource)
+		}
+
+		fmt.Printf("Saving %d %s ...\n", len(items.Items), resource.Resource)
+
+		for i := range items.Items {
+			item := &items.Items[i]
+
+			cleanBackupObject(item)
+
+			data, err := yaml.Marshal(item.Object)
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to generate YAML for %s %q", resource.Resource, item.GetName())
+			}
+
+			addFile(fmt.Sprintf("resources/%s/%s.yaml", resource.Resource, item.GetName()), data)
+
+			if resource == trainingPortalResource {
+				manifest.Portals = append(manifest.Portals, item.GetName())
+			}
+		}
+
+		manifest.Resources = append(manifest.Resources, resource.Resource)
+	}
+
+	if !o.SkipDatabases {
+		for _, portal := range manifest.Portals {
+			fmt.Printf("Saving database for training portal %s ...\n", portal)
+
+			pod, err := trainingPortalPod(ctx, client, portal)
+
+			if err != nil {
+				return err
+			}
+
+			// Use the SQLite backup API so a consistent copy of the database
+			// is obtained even if the portal is updating it at the time.
+
+			script := fmt.Sprintf("import sqlite3,sys; s=sqlite3.connect('%s/%s'); d=sqlite3.connect('/tmp/backup.sqlite3'); s.backup(d); d.close(); s.close()", portalDataDirectory, portalDatabaseFile)
+
+			command := []string{"sh", "-c", fmt.Sprintf("python3 -c \"%s\" && cat /tmp/backup.sqlite3 && rm -f /tmp/backup.sqlite3", script)}
+
+			var database bytes.Buffer
+
+			if err = clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, "portal", command, nil, &database); err != nil {
+				return errors.Wrapf(err, "unable to save database for training portal %q", portal)
+			}
+
+			addFile(fmt.Sprintf("portals/%s/%s", portal, portalDatabaseFile), database.Bytes())
+
+			var secretKey bytes.Buffer
+
+			command = []string{"cat", fmt.Sprintf("%s/%s", portalDataDirectory, portalSecretKeyFile)}
+
+			if err = clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, "portal", command, nil, &secretKey); err != nil {
+				return errors.Wrapf(err, "unable to save secret key for training portal %q", portal)
+			}
+
+			addFile(fmt.Sprintf("portals/%s/%s", portal, portalSecretKeyFile), secretKey.Bytes())
+		}
+	}
+
+	manifestData, err := yaml.Marshal(&manifest)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate backup manifest")
+	}
+
+	// The backup holds the database and secret key for each training portal,
+	// so only the owner should be able to read it.
+
+	file, err := os.OpenFile(o.Output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create backup %q", o.Output)
+	}
+
+	// A partial backup is of no use so is removed if writing it fails.
+
+	completed := false
+
+	defer func() {
+		file.Close()
+
+		if !completed {
+			os.Remove(o.Output)
+		}
+	}()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, name := range append([]string{"backup.yaml"}, names...) {
+		data := files[name]
+
+		if name == "backup.yaml" {
+			data = manifestData
+		}
+
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: created,
+		}
+
+		if err = tarWriter.WriteHeader(header); err == nil {
+			_, err = tarWriter.Write(data)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to write backup %q", o.Output)
+		}
+	}
+
+	if err = tarWriter.Close(); err == nil {
+		err = gzipWriter.Close()
+	}
+
+	if err == nil {
+		err = file.Close()
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write backup %q", o.Output)
+	}
+
+	completed = true
+
+	fmt.Printf("Backup written to %s.\n", o.Output)
+
+	return nil
+}
+
+/*
+Find the running pod for a training portal.
+*/
+func trainingPortalPod(ctx context.Context, client *kubernetes.Clientset, portal string) (*apiv1.Pod, error) {
+	namespace := portal + "-ui"
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "deployment=training-portal"})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list pods for training portal %q", portal)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		if pod.Status.Phase == apiv1.PodRunning && pod.DeletionTimestamp == nil {
+			return pod, nil
+		}
+	}
+
+	return nil, failures.NewNotFoundError(errors.Errorf("no running pod found for training portal %q", portal), "check the status of the training portal with `educates cluster portal list`")
+}
+
+/*
+Remove the fields from a resource which are set by the cluster or operators,
+so that it can be created again in a different cluster.
+*/
+func cleanBackupObject(object *unstructured.Unstructured) {
+	object.SetManagedFields(nil)
+	object.SetUID("")
+	object.SetResourceVersion("")
+	object.SetGeneration(0)
+	object.SetCreationTimestamp(metav1.Time{})
+	object.SetFinalizers(nil)
+	object.SetOwnerReferences(nil)
+
+	annotations := object.GetAnnotations()
+
+	for key := range annotations {
+		if key == "kubectl.kubernetes.io/last-applied-configuration" || strings.HasPrefix(key, "kopf.zalando.org/") {
+			delete(annotations, key)
+		}
+	}
+
+	object.SetAnnotations(annotations)
+
+	unstructured.RemoveNestedField(object.Object, "status")
+}
+
+func (p *ProjectInfo) NewAdminBackupCmd() *cobra.Command {
+	var o AdminBackupOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "backup",
+		Short: "Backup state of the training platform",
+		Long: `Backup state of the training platform.
+
+Saves all workshop definitions, training portals and the secret copier and
+secret injector configurations from the cluster to an archive file. For each
+training portal, the database holding user accounts and the history of
+workshop sessions is also saved. The backup can be restored onto a fresh
+cluster with "educates admin restore".
+
+Secrets referenced by the secret copier and injector configurations are not
+included in the backup, so must be recreated separately before restoring.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context(), p.Version) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"",
+		"path to the backup file, defaults to a name including a timestamp",
+	)
+	c.Flags().BoolVar(
+		&o.SkipDatabases,
+		"skip-databases",
+		false,
+		"don't save the databases of training portals",
+	)
+
+	return c
+}
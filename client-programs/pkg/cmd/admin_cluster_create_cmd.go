@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path"
+	"path/filepath"
 	"time"
 
 	"github.com/adrg/xdg"
@@ -28,24 +28,31 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/kubectl/pkg/scheme"
 
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/bundle"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/preflight"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/registry"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/services"
 )
 
 type AdminClusterCreateOptions struct {
-	Config       string
-	Kubeconfig   string
-	Image        string
-	Domain       string
-	Version      string
-	WithServices bool
-	WithPlatform bool
+	Config            string
+	Kubeconfig        string
+	Image             string
+	Domain            string
+	IPFamily          string
+	IngressController string
+	IngressClass      string
+	Version           string
+	WithServices      bool
+	WithPlatform      bool
+	SkipPreflight     bool
 }
 
-func (o *AdminClusterCreateOptions) Run() error {
+func (o *AdminClusterCreateOptions) Run(ctx context.Context) error {
 	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
 
 	if err != nil {
@@ -54,6 +61,16 @@ func (o *AdminClusterCreateOptions) Run() error {
 
 	fullConfig.ClusterInfrastructure.Provider = "kind"
 
+	if o.IPFamily != "" {
+		if err = fullConfig.SetIPFamily(o.IPFamily); err != nil {
+			return failures.NewValidationError(err, "")
+		}
+	}
+
+	if err = applyIngressController(fullConfig, o.IngressController, o.IngressClass); err != nil {
+		return err
+	}
+
 	if o.Domain != "" {
 		fullConfig.ClusterIngress.Domain = o.Domain
 
@@ -79,7 +96,16 @@ func (o *AdminClusterCreateOptions) Run() error {
 
 	clusterConfig := cluster.NewKindClusterConfig(o.Kubeconfig)
 
-	httpAvailable, err := checkPortAvailability(fullConfig.LocalKindCluster.ListenAddress, []uint{80, 443})
+	// The cluster doesn't exist yet, so only checks not needing the cluster
+	// can be run up front.
+
+	if !o.SkipPreflight {
+		if err = runPreflightChecks(ctx, os.Stderr, nil, fullConfig, preflight.LocalClusterChecks()); err != nil {
+			return err
+		}
+	}
+
+	httpAvailable, err := checkPortAvailability(fullConfig.LocalKindCluster.ListenAddress, fullConfig.LocalKindCluster.IPFamily, []uint{80, 443})
 
 	if err != nil {
 		return errors.Wrap(err, "couldn't test whether ports 80/443 available")
@@ -101,12 +127,81 @@ func (o *AdminClusterCreateOptions) Run() error {
 		return err
 	}
 
-	err = SyncSecretsToCluster(client)
+	err = SyncSecretsToCluster(ctx, client)
 
 	if err != nil {
 		return err
 	}
 
+	if err = deployKappController(clusterConfig.Kubeconfig, fullConfig); err != nil {
+		return err
+	}
+
+	err = registry.DeployRegistry(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to deploy registry")
+	}
+
+	err = registry.LinkRegistryToCluster(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to link registry to cluster")
+	}
+
+	if err = registry.UpdateRegistryService(ctx, client); err != nil {
+		return errors.Wrap(err, "failed to create service for registry")
+	}
+
+	if !o.WithServices {
+		return nil
+	}
+
+	servicesConfig := config.ClusterEssentialsConfig{
+		ClusterInfrastructure: fullConfig.ClusterInfrastructure,
+		ClusterPackages:       fullConfig.ClusterPackages,
+		ClusterSecurity:       fullConfig.ClusterSecurity,
+	}
+
+	if err = services.DeployServices(ctx, o.Version, bundle.DefaultPackageRepository, &clusterConfig.ClusterConfig, &servicesConfig); err != nil {
+		return errors.Wrap(err, "failed to deploy cluster essentials services")
+	}
+
+	if !o.WithPlatform {
+		return nil
+	}
+
+	platformConfig := config.TrainingPlatformConfig{
+		ClusterSecurity:   fullConfig.ClusterSecurity,
+		ClusterRuntime:    fullConfig.ClusterRuntime,
+		ClusterIngress:    fullConfig.ClusterIngress,
+		SessionCookies:    fullConfig.SessionCookies,
+		ClusterStorage:    fullConfig.ClusterStorage,
+		ClusterSecrets:    fullConfig.ClusterSecrets,
+		TrainingPortal:    fullConfig.TrainingPortal,
+		WorkshopSecurity:  fullConfig.WorkshopSecurity,
+		ImageRegistry:     fullConfig.ImageRegistry,
+		ImageVersions:     fullConfig.ImageVersions,
+		DockerDaemon:      fullConfig.DockerDaemon,
+		ClusterNetwork:    fullConfig.ClusterNetwork,
+		WorkshopAnalytics: fullConfig.WorkshopAnalytics,
+		WebsiteStyling:    fullConfig.WebsiteStyling,
+		FeatureGates:      fullConfig.FeatureGates,
+	}
+
+	if err = operators.DeployOperators(ctx, o.Version, bundle.DefaultPackageRepository, &clusterConfig.ClusterConfig, &platformConfig); err != nil {
+		return errors.Wrap(err, "failed to deploy training platform components")
+	}
+
+	return nil
+}
+
+/*
+Deploy kapp-controller into the cluster, which is required for installing the
+cluster essentials and training platform packages. If a CA certificate is
+configured for the ingress domain, kapp-controller is configured to trust it.
+*/
+func deployKappController(kubeconfig string, fullConfig *config.InstallationConfig) error {
 	confUI := ui.NewConfUI(ui.NewNoopLogger())
 
 	uiFlags := cmd.UIFlags{
@@ -120,7 +215,7 @@ func (o *AdminClusterCreateOptions) Run() error {
 	defer confUI.Flush()
 
 	configFactory := core.NewConfigFactoryImpl()
-	configFactory.ConfigurePathResolver(func() (string, error) { return clusterConfig.Kubeconfig, nil })
+	configFactory.ConfigurePathResolver(func() (string, error) { return kubeconfig, nil })
 	configFactory.ConfigureContextResolver(func() (string, error) { return "", nil })
 	configFactory.ConfigureYAMLResolver(func() (string, error) { return "", nil })
 
@@ -139,10 +234,10 @@ func (o *AdminClusterCreateOptions) Run() error {
 	var deploymentFiles []string
 
 	if fullConfig.ClusterIngress.CACertificateRef.Name != "" {
-		configFileDir := path.Join(xdg.DataHome, "educates")
-		secretsCacheDir := path.Join(configFileDir, "secrets")
+		configFileDir := filepath.Join(xdg.DataHome, "educates")
+		secretsCacheDir := filepath.Join(configFileDir, "secrets")
 		name := fullConfig.ClusterIngress.CACertificateRef.Name + ".yaml"
-		certificateFullPath := path.Join(secretsCacheDir, name)
+		certificateFullPath := filepath.Join(secretsCacheDir, name)
 
 		secretYAML, err := os.ReadFile(certificateFullPath)
 
@@ -191,7 +286,7 @@ func (o *AdminClusterCreateOptions) Run() error {
 			return errors.Wrap(err, "couldn't generate YAML for kapp-controller config")
 		}
 
-		kappConfigPath := path.Join(configFileDir, "kapp-controller-config.yaml")
+		kappConfigPath := filepath.Join(configFileDir, "kapp-controller-config.yaml")
 
 		err = os.WriteFile(kappConfigPath, kappConfigYAML, 0644)
 
@@ -220,67 +315,10 @@ func (o *AdminClusterCreateOptions) Run() error {
 	kappConfig.DeployFlags.ExistingNonLabeledResourcesCheckConcurrency = 5
 	kappConfig.DeployFlags.AppChangesMaxToKeep = 5
 
-	err = kappConfig.Run()
-
-	if err != nil {
+	if err := kappConfig.Run(); err != nil {
 		return errors.Wrap(err, "failed to deploy kapp-controller")
 	}
 
-	err = registry.DeployRegistry()
-
-	if err != nil {
-		return errors.Wrap(err, "failed to deploy registry")
-	}
-
-	err = registry.LinkRegistryToCluster()
-
-	if err != nil {
-		return errors.Wrap(err, "failed to link registry to cluster")
-	}
-
-	if err = registry.UpdateRegistryService(client); err != nil {
-		return errors.Wrap(err, "failed to create service for registry")
-	}
-
-	if !o.WithServices {
-		return nil
-	}
-
-	servicesConfig := config.ClusterEssentialsConfig{
-		ClusterInfrastructure: fullConfig.ClusterInfrastructure,
-		ClusterPackages:       fullConfig.ClusterPackages,
-		ClusterSecurity:       fullConfig.ClusterSecurity,
-	}
-
-	if err = services.DeployServices(o.Version, &clusterConfig.ClusterConfig, &servicesConfig); err != nil {
-		return errors.Wrap(err, "failed to deploy cluster essentials services")
-	}
-
-	if !o.WithPlatform {
-		return nil
-	}
-
-	platformConfig := config.TrainingPlatformConfig{
-		ClusterSecurity:   fullConfig.ClusterSecurity,
-		ClusterRuntime:    fullConfig.ClusterRuntime,
-		ClusterIngress:    fullConfig.ClusterIngress,
-		SessionCookies:    fullConfig.SessionCookies,
-		ClusterStorage:    fullConfig.ClusterStorage,
-		ClusterSecrets:    fullConfig.ClusterSecrets,
-		TrainingPortal:    fullConfig.TrainingPortal,
-		WorkshopSecurity:  fullConfig.WorkshopSecurity,
-		ImageRegistry:     fullConfig.ImageRegistry,
-		ImageVersions:     fullConfig.ImageVersions,
-		DockerDaemon:      fullConfig.DockerDaemon,
-		ClusterNetwork:    fullConfig.ClusterNetwork,
-		WorkshopAnalytics: fullConfig.WorkshopAnalytics,
-		WebsiteStyling:    fullConfig.WebsiteStyling,
-	}
-
-	if err = operators.DeployOperators(o.Version, &clusterConfig.ClusterConfig, &platformConfig); err != nil {
-		return errors.Wrap(err, "failed to deploy training platform components")
-	}
-
 	return nil
 }
 
@@ -291,7 +329,7 @@ func (p *ProjectInfo) NewAdminClusterCreateCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "create",
 		Short: "Creates a local Kubernetes cluster",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -318,6 +356,15 @@ func (p *ProjectInfo) NewAdminClusterCreateCmd() *cobra.Command {
 		"",
 		"wildcard ingress subdomain name for Educates",
 	)
+	c.Flags().StringVar(
+		&o.IPFamily,
+		"ip-family",
+		"",
+		"IP family for the cluster network, one of ipv4, ipv6 or dual",
+	)
+
+	addIngressControllerFlags(c, &o.IngressController, &o.IngressClass)
+
 	c.Flags().StringVar(
 		&o.Version,
 		"version",
@@ -336,11 +383,17 @@ func (p *ProjectInfo) NewAdminClusterCreateCmd() *cobra.Command {
 		true,
 		"deploy all the Educates training platform components",
 	)
+	c.Flags().BoolVar(
+		&o.SkipPreflight,
+		"skip-preflight",
+		false,
+		"skip pre-flight checks made before creating the cluster",
+	)
 
-	return c
+	return withAuditLogging(c)
 }
 
-func checkPortAvailability(listenAddress string, ports []uint) (bool, error) {
+func checkPortAvailability(listenAddress string, ipFamily string, ports []uint) (bool, error) {
 	ctx := context.Background()
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
@@ -360,7 +413,7 @@ func checkPortAvailability(listenAddress string, ports []uint) (bool, error) {
 	io.Copy(os.Stdout, reader)
 
 	if listenAddress == "" {
-		listenAddress, err = config.HostIP()
+		listenAddress, err = config.HostIPForFamily(ipFamily)
 
 		if err != nil {
 			listenAddress = "127.0.0.1"
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
@@ -13,12 +15,24 @@ type AdminClusterDeleteOptions struct {
 	AllComponents bool
 }
 
-func (o *AdminClusterDeleteOptions) Run() error {
+func (o *AdminClusterDeleteOptions) Run(ctx context.Context) error {
+	message := "delete the local Kubernetes cluster"
+
+	if o.AllComponents {
+		message = "delete the local Kubernetes cluster, image registry and resolver"
+	}
+
+	err := confirmAction(message)
+
+	if err != nil {
+		return err
+	}
+
 	c := cluster.NewKindClusterConfig("")
 
 	if o.AllComponents {
-		registry.DeleteRegistry()
-		resolver.DeleteResolver()
+		registry.DeleteRegistry(ctx)
+		resolver.DeleteResolver(ctx)
 	}
 
 	return c.DeleteCluster()
@@ -31,7 +45,7 @@ func (p *ProjectInfo) NewAdminClusterDeleteCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "delete",
 		Short: "Deletes the local Kubernetes cluster",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().BoolVar(
@@ -41,5 +55,5 @@ func (p *ProjectInfo) NewAdminClusterDeleteCmd() *cobra.Command {
 		"delete everything, including image registry and resolver",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
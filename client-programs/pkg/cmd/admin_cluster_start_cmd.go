@@ -18,5 +18,5 @@ func (p *ProjectInfo) NewAdminClusterStartCmd() *cobra.Command {
 		},
 	}
 
-	return c
+	return withAuditLogging(c)
 }
@@ -18,5 +18,5 @@ func (p *ProjectInfo) NewAdminClusterStopCmd() *cobra.Command {
 		},
 	}
 
-	return c
+	return withAuditLogging(c)
 }
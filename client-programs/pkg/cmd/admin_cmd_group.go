@@ -23,9 +23,17 @@ func (p *ProjectInfo) NewAdminCmdGroup() *cobra.Command {
 				p.NewAdminConfigCmdGroup(),
 				p.NewAdminSecretsCmdGroup(),
 				p.NewAdminRegistryCmdGroup(),
+				p.NewAdminImagesCmdGroup(),
 				p.NewAdminResolverCmdGroup(),
 				p.NewAdminServicesCmdGroup(),
 				p.NewAdminPlatformCmdGroup(),
+				p.NewAdminPreflightCmd(),
+				p.NewAdminBackupCmd(),
+				p.NewAdminRestoreCmd(),
+				p.NewAdminOrphansCmdGroup(),
+				p.NewAdminRbacCmdGroup(),
+				p.NewAdminDiagnosticsCmdGroup(),
+				p.NewAdminFeatureGatesCmdGroup(),
 			},
 		},
 	}
@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path"
+	"path/filepath"
 
 	"github.com/adrg/xdg"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
 )
 
 func (p *ProjectInfo) NewAdminConfigEditCmd() *cobra.Command {
@@ -18,8 +19,8 @@ func (p *ProjectInfo) NewAdminConfigEditCmd() *cobra.Command {
 		Use:   "edit",
 		Short: "Edit default configuration",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			configFileDir := path.Join(xdg.DataHome, "educates")
-			valuesFilePath := path.Join(configFileDir, "values.yaml")
+			configFileDir := filepath.Join(xdg.DataHome, "educates")
+			valuesFilePath := filepath.Join(configFileDir, "values.yaml")
 			tmpValuesFilePath := fmt.Sprintf("%s.%d", valuesFilePath, os.Getpid())
 
 			tmpValuesFile, err := os.OpenFile(tmpValuesFilePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
@@ -38,11 +39,7 @@ func (p *ProjectInfo) NewAdminConfigEditCmd() *cobra.Command {
 
 			defer os.Remove(tmpValuesFilePath)
 
-			editor := "vi"
-
-			if s := os.Getenv("EDITOR"); s != "" {
-				editor = s
-			}
+			editor := platform.UserEditor()
 
 			editorPath, err := exec.LookPath(editor)
 
@@ -85,5 +82,5 @@ func (p *ProjectInfo) NewAdminConfigEditCmd() *cobra.Command {
 		},
 	}
 
-	return c
+	return withAuditLogging(c)
 }
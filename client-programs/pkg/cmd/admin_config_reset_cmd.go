@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"os"
-	"path"
+	"path/filepath"
 
 	"github.com/adrg/xdg"
 	"github.com/spf13/cobra"
@@ -14,8 +14,8 @@ func (p *ProjectInfo) NewAdminConfigResetCmd() *cobra.Command {
 		Use:   "reset",
 		Short: "Reset default configuration",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			configFileDir := path.Join(xdg.DataHome, "educates")
-			valuesFile := path.Join(configFileDir, "values.yaml")
+			configFileDir := filepath.Join(xdg.DataHome, "educates")
+			valuesFile := filepath.Join(configFileDir, "values.yaml")
 
 			os.Remove(valuesFile)
 
@@ -23,5 +23,5 @@ func (p *ProjectInfo) NewAdminConfigResetCmd() *cobra.Command {
 		},
 	}
 
-	return c
+	return withAuditLogging(c)
 }
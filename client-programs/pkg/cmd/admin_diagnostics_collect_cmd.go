@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+)
+
+// Cluster scoped Educates resources captured in the diagnostics bundle.
+
+var diagnosticsClusterResources = []schema.GroupVersionResource{
+	trainingPortalResource,
+	workshopResource,
+	workshopEnvironmentResource,
+	workshopSessionResource,
+	workshopAllocationResource,
+	customResourceDefinitionResource,
+}
+
+var kappAppDiagnosticsResource = schema.GroupVersionResource{Group: "kappctrl.k14s.io", Version: "v1alpha1", Resource: "apps"}
+
+type AdminDiagnosticsCollectOptions struct {
+	Kubeconfig string
+	Output     string
+	TailLines  int64
+}
+
+/*
+Writer for the diagnostics bundle. Problems collecting any one item are
+recorded in the bundle rather than stopping the collection, so that as much
+information as possible is captured.
+*/
+type diagnosticsBundle struct {
+	writer   *tar.Writer
+	prefix   string
+	problems []string
+}
+
+func (b *diagnosticsBundle) addFile(name string, data []byte) {
+	header := &tar.Header{
+		Name:    b.prefix + "/" + name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+
+	if err := b.writer.WriteHeader(header); err != nil {
+		b.addProblem(errors.Wrapf(err, "unable to add %s to bundle", name))
+		return
+	}
+
+	if _, err := b.writer.Write(data); err != nil {
+		b.addProblem(errors.Wrapf(err, "unable to add %s to bundle", name))
+	}
+}
+
+func (b *diagnosticsBundle) addObject(name string, object interface{}) {
+	data, err := yaml.Marshal(object)
+
+	if err != nil {
+		b.addProblem(errors.Wrapf(err, "unable to generate %s", name))
+		return
+	}
+
+	b.addFile(name, data)
+}
+
+func (b *diagnosticsBundle) addProblem(err error) {
+	b.problems = append(b.problems, err.Error())
+}
+
+func (o *AdminDiagnosticsCollectOptions) Run(ctx context.Context, cliVersion string) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	timestamp := time.Now().UTC().Format("20060102-150405")
+
+	if o.Output == "" {
+		o.Output = fmt.Sprintf("educates-diagnostics-%s.tar.gz", timestamp)
+	}
+
+	file, err := os.Create(o.Output)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create diagnostics bundle %q", o.Output)
+	}
+
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	bundle := &diagnosticsBundle{writer: tarWriter, prefix: fmt.Sprintf("educates-diagnostics-%s", timestamp)}
+
+	fmt.Println("Collecting versions ...")
+
+	collectDiagnosticsVersions(ctx, bundle, clusterConfig, client, cliVersion)
+
+	fmt.Println("Collecting resources ...")
+
+	namespaces := collectDiagnosticsResources(ctx, bundle, dynamicClient)
+
+	fmt.Println("Collecting events and logs ...")
+
+	for _, namespace := range namespaces {
+		collectDiagnosticsNamespace(ctx, bundle, client, namespace, o.TailLines)
+	}
+
+	if len(bundle.problems) != 0 {
+		bundle.addFile("problems.txt", []byte(strings.Join(bundle.problems, "\n")+"\n"))
+	}
+
+	if err = tarWriter.Close(); err == nil {
+		err = gzipWriter.Close()
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write diagnostics bundle %q", o.Output)
+	}
+
+	fmt.Printf("Diagnostics written to %s.\n", o.Output)
+
+	if len(bundle.problems) != 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d items could not be collected, see problems.txt in the bundle.\n", len(bundle.problems))
+	}
+
+	return nil
+}
+
+func collectDiagnosticsVersions(ctx context.Context, bundle *diagnosticsBundle, clusterConfig *cluster.ClusterConfig, client *kubernetes.Clientset, cliVersion string) {
+	var lines []string
+
+	lines = append(lines, fmt.Sprintf("Educates CLI: %s (%s/%s)", cliVersion, runtime.GOOS, runtime.GOARCH))
+
+	platformVersion, err := operators.InstalledVersion(ctx, clusterConfig)
+
+	if err != nil {
+		bundle.addProblem(err)
+	} else if platformVersion == "" {
+		platformVersion = "not installed"
+	}
+
+	lines = append(lines, fmt.Sprintf("Educates platform: %s", platformVersion))
+
+	serverVersion, err := client.Discovery().ServerVersion()
+
+	if err != nil {
+		bundle.addProblem(errors.Wrap(err, "unable to query Kubernetes version"))
+	} else {
+		lines = append(lines, fmt.Sprintf("Kubernetes: %s (%s)", serverVersion.GitVersion, serverVersion.Platform))
+	}
+
+	bundle.addFile("versions.txt", []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+/*
+Capture the Educates resources in the cluster, returning the namespaces which
+are associated with them, for which logs and events should also be captured.
+*/
+func collectDiagnosticsResources(ctx context.Context, bundle *diagnosticsBundle, client dynamic.Interface) []string {
+	namespaces := []string{"educates", "educates-package", "educates-secrets"}
+
+	for _, resource := range diagnosticsClusterResources {
+		list, err := client.Resource(resource).List(ctx, metav1.ListOptions{})
+
+		if err != nil {
+			bundle.addProblem(errors.Wrapf(err, "unable to list %s", resource.Resource))
+			continue
+		}
+
+		var items []interface{}
+
+		for _, item := range list.Items {
+			// Only the custom resource definitions for Educates are of
+			// interest.
+
+			if resource == customResourceDefinitionResource && !strings.HasSuffix(item.GetName(), ".educates.dev") {
+				continue
+			}
+
+			cleanDiagnosticsObject(&item)
+
+			items = append(items, item.Object)
+
+			switch resource {
+			case trainingPortalResource:
+				namespaces = append(namespaces, item.GetName()+"-ui")
+			case workshopEnvironmentResource:
+				namespaces = append(namespaces, item.GetName())
+			}
+		}
+
+		bundle.addObject(fmt.Sprintf("resources/%s.yaml", resource.Resource), items)
+	}
+
+	apps, err := client.Resource(kappAppDiagnosticsResource).Namespace("educates-package").List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		bundle.addProblem(errors.Wrap(err, "unable to list package apps"))
+	} else {
+		var items []interface{}
+
+		for _, item := range apps.Items {
+			cleanDiagnosticsObject(&item)
+
+			items = append(items, item.Object)
+		}
+
+		bundle.addObject("resources/apps.yaml", items)
+	}
+
+	return namespaces
+}
+
+/*
+Capture the pods, events and pod logs for a namespace. Secrets are never
+captured.
+*/
+func collectDiagnosticsNamespace(ctx context.Context, bundle *diagnosticsBundle, client *kubernetes.Clientset, namespace string, tailLines int64) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return
+	}
+
+	if err != nil {
+		bundle.addProblem(errors.Wrapf(err, "unable to list pods in namespace %s", namespace))
+		return
+	}
+
+	if len(pods.Items) != 0 {
+		for i := range pods.Items {
+			pods.Items[i].ManagedFields = nil
+		}
+
+		bundle.addObject(fmt.Sprintf("namespaces/%s/pods.yaml", namespace), pods.Items)
+	}
+
+	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		bundle.addProblem(errors.Wrapf(err, "unable to list events in namespace %s", namespace))
+	} else if len(events.Items) != 0 {
+		var lines []string
+
+		for _, event := range events.Items {
+			lines = append(lines, fmt.Sprintf("%s\t%s\t%s/%s\t%s\t%s", event.LastTimestamp.UTC().Format(time.RFC3339), event.Type, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason, event.Message))
+		}
+
+		bundle.addFile(fmt.Sprintf("namespaces/%s/events.txt", namespace), []byte(strings.Join(lines, "\n")+"\n"))
+	}
+
+	for _, pod := range pods.Items {
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			collectDiagnosticsLogs(ctx, bundle, client, namespace, pod.Name, container.Name, false, tailLines)
+
+			// Also capture logs of the prior instance of a container which
+			// has restarted, as they often show why it failed.
+
+			for _, status := range pod.Status.ContainerStatuses {
+				if status.Name == container.Name && status.RestartCount != 0 {
+					collectDiagnosticsLogs(ctx, bundle, client, namespace, pod.Name, container.Name, true, tailLines)
+				}
+			}
+		}
+	}
+}
+
+func collectDiagnosticsLogs(ctx context.Context, bundle *diagnosticsBundle, client *kubernetes.Clientset, namespace string, pod string, container string, previous bool, tailLines int64) {
+	options := &apiv1.PodLogOptions{Container: container, Previous: previous}
+
+	if tailLines > 0 {
+		options.TailLines = &tailLines
+	}
+
+	stream, err := client.CoreV1().Pods(namespace).GetLogs(pod, options).Stream(ctx)
+
+	if err != nil {
+		bundle.addProblem(errors.Wrapf(err, "unable to get logs for container %s of pod %s in namespace %s", container, pod, namespace))
+		return
+	}
+
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+
+	if err != nil {
+		bundle.addProblem(errors.Wrapf(err, "unable to read logs for container %s of pod %s in namespace %s", container, pod, namespace))
+		return
+	}
+
+	name := fmt.Sprintf("namespaces/%s/logs/%s/%s.log", namespace, pod, container)
+
+	if previous {
+		name = fmt.Sprintf("namespaces/%s/logs/%s/%s.previous.log", namespace, pod, container)
+	}
+
+	bundle.addFile(name, data)
+}
+
+/*
+Remove managed fields from a resource, which are noise for diagnostics, and
+redact any values which look to be credentials, such as the training portal
+password and the admin and robot credentials in its status.
+*/
+func cleanDiagnosticsObject(object *unstructured.Unstructured) {
+	object.SetManagedFields(nil)
+
+	annotations := object.GetAnnotations()
+
+	delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+
+	object.SetAnnotations(annotations)
+
+	redactDiagnosticsValues(object.Object)
+}
+
+func redactDiagnosticsValues(value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if _, ok := item.(string); ok && sensitiveFlagPattern.MatchString(strings.ToLower(key)) {
+				value[key] = "<redacted>"
+			} else {
+				redactDiagnosticsValues(item)
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			redactDiagnosticsValues(item)
+		}
+	}
+}
+
+func (p *ProjectInfo) NewAdminDiagnosticsCollectCmd() *cobra.Command {
+	var o AdminDiagnosticsCollectOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "collect",
+		Short: "Collect diagnostics into a bundle for bug reports",
+		Long: `Collect diagnostics into a bundle for bug reports.
+
+Gathers versions of the CLI, platform and Kubernetes, the Educates resources
+in the cluster, and the pods, events and container logs from the namespaces
+for the operators, training portals and workshop environments, into a single
+compressed tar file which can be attached to a bug report. Secrets are not
+collected, and values in resources which look to be passwords, tokens or
+secrets are redacted, but you should still review the bundle before sharing
+it. Logs for individual workshop sessions are not collected.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context(), p.Version) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"",
+		"path of the diagnostics bundle to create, defaults to a timestamped name",
+	)
+	c.Flags().Int64Var(
+		&o.TailLines,
+		"tail",
+		5000,
+		"maximum number of lines of each container log to collect, or 0 for all",
+	)
+
+	return c
+}
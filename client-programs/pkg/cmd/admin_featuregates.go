@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Details of a feature gate supported by the installed version of the platform,
+as listed in the feature gates config map created by the package bundle.
+*/
+type featureGate struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Default     bool   `yaml:"default"`
+}
+
+/*
+Feature gates as known to the platform installed in the cluster. Supported
+holds the feature gates the operator understands, Active those the operator
+is currently running with, and Configured those set in the data values for
+the platform, which only become active once the platform is reconciled.
+*/
+type platformFeatureGates struct {
+	Version    string
+	Reported   bool
+	Supported  []featureGate
+	Active     map[string]bool
+	Configured map[string]bool
+}
+
+func (g *platformFeatureGates) lookup(name string) (featureGate, bool) {
+	for _, gate := range g.Supported {
+		if gate.Name == name {
+			return gate, true
+		}
+	}
+
+	return featureGate{}, false
+}
+
+/*
+Return the effective state of a feature gate, being the configured value if
+set, otherwise the default for the feature gate.
+*/
+func (g *platformFeatureGates) enabled(name string) bool {
+	if value, found := g.Configured[name]; found {
+		return value
+	}
+
+	gate, _ := g.lookup(name)
+
+	return gate.Default
+}
+
+/*
+Return the names of all feature gates, whether supported or only configured,
+in sorted order.
+*/
+func (g *platformFeatureGates) names() []string {
+	var names []string
+
+	seen := map[string]bool{}
+
+	for _, gate := range g.Supported {
+		names = append(names, gate.Name)
+		seen[gate.Name] = true
+	}
+
+	for name := range g.Configured {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+/*
+Query the feature gates for the platform installed in the cluster. Versions
+of the platform which predate feature gates don't create the config map
+listing the supported feature gates, in which case none are supported.
+*/
+func queryFeatureGates(ctx context.Context, clusterConfig *cluster.ClusterConfig) (*platformFeatureGates, error) {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	values, err := platformValues(ctx, clusterConfig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gates := &platformFeatureGates{
+		Configured: map[string]bool{},
+		Active:     map[string]bool{},
+	}
+
+	if err = decodeFeatureGates(values, gates.Configured); err != nil {
+		return nil, err
+	}
+
+	if gates.Version, err = cachedInstalledVersion(ctx, clusterConfig); err != nil {
+		return nil, errors.Wrapf(err, "unable to determine installed platform version")
+	}
+
+	if gates.Version == "" {
+		gates.Version = "unknown"
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps("educates").Get(ctx, "educates-feature-gates", metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return gates, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to query supported feature gates")
+	}
+
+	gates.Reported = true
+
+	if err = yaml.Unmarshal([]byte(configMap.Data["supported.yaml"]), &gates.Supported); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse supported feature gates")
+	}
+
+	if err = yaml.Unmarshal([]byte(configMap.Data["enabled.yaml"]), &gates.Active); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse active feature gates")
+	}
+
+	return gates, nil
+}
+
+/*
+Read the data values the platform was installed with. These are kept in
+their original form, rather than being decoded into the platform config, so
+that settings unknown to this version of the CLI aren't lost when updated.
+*/
+func platformValues(ctx context.Context, clusterConfig *cluster.ClusterConfig) (yaml.MapSlice, error) {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	secret, err := client.CoreV1().Secrets("educates-package").Get(ctx, "educates-training-platform-values", metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return nil, failures.NewNotFoundError(errors.New("platform not deployed"), failures.PlatformHint)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to retrieve platform configuration")
+	}
+
+	valuesData, ok := secret.Data["values.yml"]
+
+	if !ok {
+		return nil, failures.NewNotFoundError(errors.New("no platform configuration found"), failures.PlatformHint)
+	}
+
+	var values yaml.MapSlice
+
+	if err = yaml.Unmarshal(valuesData, &values); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse platform configuration")
+	}
+
+	return values, nil
+}
+
+func decodeFeatureGates(values yaml.MapSlice, gates map[string]bool) error {
+	for _, item := range values {
+		if item.Key != "featureGates" || item.Value == nil {
+			continue
+		}
+
+		data, err := yaml.Marshal(item.Value)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read feature gates")
+		}
+
+		if err = yaml.Unmarshal(data, &gates); err != nil {
+			return errors.Wrapf(err, "invalid feature gates in platform configuration")
+		}
+	}
+
+	return nil
+}
+
+/*
+Update the feature gates in the data values for the platform, leaving all
+other settings as they were. A nil value for a feature gate removes it.
+*/
+func updateFeatureGates(ctx context.Context, clusterConfig *cluster.ClusterConfig, changes map[string]*bool) error {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	values, err := platformValues(ctx, clusterConfig)
+
+	if err != nil {
+		return err
+	}
+
+	gates := map[string]bool{}
+
+	if err = decodeFeatureGates(values, gates); err != nil {
+		return err
+	}
+
+	for name, value := range changes {
+		if value == nil {
+			delete(gates, name)
+		} else {
+			gates[name] = *value
+		}
+	}
+
+	var updated yaml.MapSlice
+
+	for _, item := range values {
+		if item.Key != "featureGates" {
+			updated = append(updated, item)
+		}
+	}
+
+	if len(gates) != 0 {
+		updated = append(updated, yaml.MapItem{Key: "featureGates", Value: gates})
+	}
+
+	valuesData, err := yaml.Marshal(updated)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to generate platform configuration")
+	}
+
+	secretsClient := client.CoreV1().Secrets("educates-package")
+
+	secret, err := secretsClient.Get(ctx, "educates-training-platform-values", metav1.GetOptions{})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to retrieve platform configuration")
+	}
+
+	secret.Data["values.yml"] = valuesData
+
+	if _, err = secretsClient.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return errors.Wrapf(err, "unable to update platform configuration")
+	}
+
+	return nil
+}
+
+/*
+Shared implementation of enabling and disabling feature gates. Feature gates
+not supported by the installed platform can only be enabled when forced, but
+can always be disabled, in which case they are removed from the platform
+configuration.
+*/
+func setFeatureGates(ctx context.Context, clusterConfig *cluster.ClusterConfig, names []string, enable bool, force bool, reconcile bool) error {
+	gates, err := queryFeatureGates(ctx, clusterConfig)
+
+	if err != nil {
+		return err
+	}
+
+	changes := map[string]*bool{}
+
+	for _, name := range names {
+		value := enable
+
+		if _, found := gates.lookup(name); found {
+			changes[name] = &value
+
+			continue
+		}
+
+		if !enable {
+			changes[name] = nil
+
+			continue
+		}
+
+		if !force {
+			hint := "list supported feature gates with `educates admin featuregates list`, or use --force to enable it anyway"
+
+			if !gates.Reported {
+				return failures.NewValidationError(errors.Errorf("installed platform version %s does not support feature gates", gates.Version), hint)
+			}
+
+			return failures.NewValidationError(errors.Errorf("feature gate %q is not supported by installed platform version %s", name, gates.Version), hint)
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: feature gate %q is not supported by installed platform version %s.\n", name, gates.Version)
+
+		changes[name] = &value
+	}
+
+	if err = updateFeatureGates(ctx, clusterConfig, changes); err != nil {
+		return err
+	}
+
+	if reconcile {
+		return reconcilePlatform(ctx, clusterConfig)
+	}
+
+	return nil
+}
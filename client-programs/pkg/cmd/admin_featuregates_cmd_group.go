@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+func (p *ProjectInfo) NewAdminFeatureGatesCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "featuregates",
+		Short: "Manage platform feature gates",
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewAdminFeatureGatesListCmd(),
+				p.NewAdminFeatureGatesEnableCmd(),
+				p.NewAdminFeatureGatesDisableCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type AdminFeatureGatesDisableOptions struct {
+	Reconcile  bool
+	Kubeconfig string
+}
+
+func (o *AdminFeatureGatesDisableOptions) Run(ctx context.Context, names []string) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	return setFeatureGates(ctx, clusterConfig, names, false, false, o.Reconcile)
+}
+
+func (p *ProjectInfo) NewAdminFeatureGatesDisableCmd() *cobra.Command {
+	var o AdminFeatureGatesDisableOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MinimumNArgs(1),
+		Use:   "disable NAME...",
+		Short: "Disable platform feature gates",
+		Long: `Disable feature gates of the platform installed in the cluster.
+
+Updates the platform configuration to disable the named feature gates. Any
+feature gates not supported by the installed version of the platform are
+removed from the platform configuration instead. The change is applied when
+the platform is next reconciled, which can be triggered straight away using
+--reconcile.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
+	}
+
+	c.Flags().BoolVar(
+		&o.Reconcile,
+		"reconcile",
+		false,
+		"trigger reconcilation after configuration update",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	return withAuditLogging(c)
+}
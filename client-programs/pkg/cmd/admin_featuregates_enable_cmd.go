@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type AdminFeatureGatesEnableOptions struct {
+	Force      bool
+	Reconcile  bool
+	Kubeconfig string
+}
+
+func (o *AdminFeatureGatesEnableOptions) Run(ctx context.Context, names []string) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	return setFeatureGates(ctx, clusterConfig, names, true, o.Force, o.Reconcile)
+}
+
+func (p *ProjectInfo) NewAdminFeatureGatesEnableCmd() *cobra.Command {
+	var o AdminFeatureGatesEnableOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MinimumNArgs(1),
+		Use:   "enable NAME...",
+		Short: "Enable platform feature gates",
+		Long: `Enable feature gates of the platform installed in the cluster.
+
+Updates the platform configuration to enable the named feature gates. Only
+feature gates supported by the installed version of the platform can be
+enabled, unless --force is used. The change is applied when the platform is
+next reconciled, which can be triggered straight away using --reconcile.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
+	}
+
+	c.Flags().BoolVar(
+		&o.Force,
+		"force",
+		false,
+		"enable feature gates even if not supported by the installed platform",
+	)
+	c.Flags().BoolVar(
+		&o.Reconcile,
+		"reconcile",
+		false,
+		"trigger reconcilation after configuration update",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type AdminFeatureGatesListOptions struct {
+	Kubeconfig string
+}
+
+func (o *AdminFeatureGatesListOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	gates, err := queryFeatureGates(ctx, clusterConfig)
+
+	if err != nil {
+		return err
+	}
+
+	if !gates.Reported {
+		fmt.Fprintf(os.Stderr, "Warning: installed platform version %s does not support feature gates.\n", gates.Version)
+	}
+
+	var w tabwriter.Writer
+
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintln(&w, "NAME\tENABLED\tSUPPORTED\tDESCRIPTION")
+
+	pending := false
+
+	for _, name := range gates.names() {
+		gate, supported := gates.lookup(name)
+
+		enabled := gates.enabled(name)
+
+		state := fmt.Sprintf("%t", enabled)
+
+		active, found := gates.Active[name]
+
+		if !found {
+			active = gate.Default
+		}
+
+		if supported && active != enabled {
+			state += " (pending)"
+			pending = true
+		}
+
+		description := gate.Description
+
+		if description == "" {
+			description = "-"
+		}
+
+		fmt.Fprintf(&w, "%s\t%s\t%t\t%s\n", name, state, supported, description)
+	}
+
+	if pending {
+		defer fmt.Fprintf(os.Stderr, "Warning: some feature gate changes have not yet been applied, they will take effect when the platform is next reconciled.\n")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewAdminFeatureGatesListCmd() *cobra.Command {
+	var o AdminFeatureGatesListOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "list",
+		Short: "List platform feature gates",
+		Long: `List the feature gates of the platform installed in the cluster.
+
+Shows each feature gate supported by the installed version of the platform,
+whether it is enabled and a description of what it does. Feature gates which
+are set in the platform configuration but which the installed version of the
+platform doesn't support are also listed. Where a change to a feature gate
+has not yet been applied because the platform hasn't been reconciled since,
+the state is marked as pending.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	return c
+}
@@ -0,0 +1,438 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+/*
+Images used by the platform for workshop session applications. These must
+match the image versions the training platform package is configured with.
+*/
+const (
+	dockerInDockerImage = "docker.io/library/docker:20.10.18-dind"
+	vclusterSyncerImage = "docker.io/loftsh/vcluster:0.13.0"
+)
+
+var vclusterK3sImages = map[string]string{
+	"1.22": "docker.io/rancher/k3s:v1.22.16-k3s1",
+	"1.23": "docker.io/rancher/k3s:v1.23.14-k3s1",
+	"1.24": "docker.io/rancher/k3s:v1.24.8-k3s1",
+	"1.25": "docker.io/rancher/k3s:v1.25.3-k3s1",
+}
+
+type AdminImagesPreloadOptions struct {
+	Kubeconfig      string
+	Workshops       []string
+	WorkshopFile    string
+	WorkshopVersion string
+	Repository      string
+	ImageVersion    string
+	Parallel        int
+	Pull            bool
+	Force           bool
+	DataValuesFlags yttcmd.DataValuesFlags
+}
+
+func (o *AdminImagesPreloadOptions) Run(ctx context.Context) error {
+	var images []string
+
+	if len(o.Workshops) == 0 {
+		o.Workshops = []string{"."}
+	}
+
+	for _, path := range o.Workshops {
+		workshop, err := training.LoadWorkshopDefinition(&training.DefinitionOptions{
+			Path:            path,
+			Portal:          "educates-cli",
+			WorkshopFile:    o.WorkshopFile,
+			WorkshopVersion: o.WorkshopVersion,
+			DataValuesFlags: o.DataValuesFlags,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		workshopImages, err := workshopImageReferences(workshop, o.Repository, o.ImageVersion, o.WorkshopVersion)
+
+		if err != nil {
+			return err
+		}
+
+		for _, image := range workshopImages {
+			if !containsString(images, image) {
+				images = append(images, image)
+			}
+		}
+	}
+
+	kindClusterConfig := cluster.NewKindClusterConfig(o.Kubeconfig)
+
+	exists, err := kindClusterConfig.ClusterExists()
+
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return failures.NewNotFoundError(errors.New("cluster for Educates doesn't exist"), "create the cluster with `educates admin cluster create`")
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create docker client")
+	}
+
+	if o.Parallel < 1 {
+		o.Parallel = 1
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+
+	failed := 0
+
+	limit := make(chan struct{}, o.Parallel)
+
+	for _, image := range images {
+		wg.Add(1)
+
+		go func(image string) {
+			defer wg.Done()
+
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			status, err := o.preloadImage(ctx, cli, kindClusterConfig, image)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				failed++
+
+				fmt.Fprintf(os.Stderr, "%s: failed: %s\n", image, err)
+			} else {
+				fmt.Printf("%s: %s\n", image, status)
+			}
+		}(image)
+	}
+
+	wg.Wait()
+
+	if failed != 0 {
+		return errors.Errorf("failed to preload %d of %d images", failed, len(images))
+	}
+
+	return nil
+}
+
+/*
+Pull an image into the local docker daemon if required, and then load it
+into the nodes of the Kind cluster.
+*/
+func (o *AdminImagesPreloadOptions) preloadImage(ctx context.Context, cli *client.Client, kindClusterConfig *cluster.KindClusterConfig, image string) (string, error) {
+	if !o.Force {
+		present, err := kindClusterConfig.HasImage(image)
+
+		if err != nil {
+			return "", err
+		}
+
+		if present {
+			return "already present", nil
+		}
+	}
+
+	_, _, err := cli.ImageInspectWithRaw(ctx, image)
+
+	if err != nil || o.Pull {
+		reader, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+
+		if err != nil {
+			return "", errors.Wrap(err, "cannot pull image")
+		}
+
+		_, err = io.Copy(io.Discard, reader)
+
+		reader.Close()
+
+		if err != nil {
+			return "", errors.Wrap(err, "cannot pull image")
+		}
+	}
+
+	archive, err := os.CreateTemp("", "educates-image-*.tar")
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create image archive")
+	}
+
+	defer os.Remove(archive.Name())
+
+	reader, err := cli.ImageSave(ctx, []string{image})
+
+	if err != nil {
+		archive.Close()
+
+		return "", errors.Wrap(err, "unable to save image")
+	}
+
+	_, err = io.Copy(archive, reader)
+
+	reader.Close()
+	archive.Close()
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to save image")
+	}
+
+	if err = kindClusterConfig.LoadImageArchive(archive.Name()); err != nil {
+		return "", err
+	}
+
+	return "loaded", nil
+}
+
+/*
+Determine the images which will be pulled by the cluster when a session is
+created for the workshop. This includes the workshop image, images for the
+docker and virtual cluster applications, and images for any containers in
+objects created for the environment or session. Images which rely on session
+variables, and images used by docker compose services within the session,
+can't be preloaded and are skipped.
+*/
+func workshopImageReferences(workshop *unstructured.Unstructured, repository string, imageVersion string, workshopVersion string) ([]string, error) {
+	var images []string
+
+	add := func(image string) {
+		if strings.Contains(image, "$(") {
+			fmt.Fprintf(os.Stderr, "Warning: skipping image %s as it depends on session variables.\n", image)
+			return
+		}
+
+		if image != "" && !containsString(images, image) {
+			images = append(images, image)
+		}
+	}
+
+	workshopImage, err := generateWorkshopImageName(workshop, repository, imageVersion, "", workshopVersion)
+
+	if err != nil {
+		return nil, err
+	}
+
+	add(workshopImage)
+
+	for _, image := range sessionApplicationImages(workshop, imageVersion) {
+		add(image)
+	}
+
+	for _, fields := range [][]string{{"spec", "environment", "objects"}, {"spec", "session", "objects"}} {
+		objects, _, _ := unstructured.NestedSlice(workshop.Object, fields...)
+
+		for _, object := range objects {
+			for _, image := range containerImages(object) {
+				add(strings.ReplaceAll(image, "$(image_repository)", repository))
+			}
+		}
+	}
+
+	return images, nil
+}
+
+/*
+Return the images used by the platform for the session applications enabled
+for a workshop, such as docker and virtual clusters.
+*/
+func sessionApplicationImages(workshop *unstructured.Unstructured, imageVersion string) []string {
+	var images []string
+
+	if enabled, _, _ := unstructured.NestedBool(workshop.Object, "spec", "session", "applications", "docker", "enabled"); enabled {
+		images = append(images, dockerInDockerImage)
+	}
+
+	if enabled, _, _ := unstructured.NestedBool(workshop.Object, "spec", "session", "applications", "registry", "enabled"); enabled {
+		images = append(images, fmt.Sprintf("ghcr.io/vmware-tanzu-labs/educates-docker-registry:%s", imageVersion))
+	}
+
+	if enabled, _, _ := unstructured.NestedBool(workshop.Object, "spec", "session", "applications", "vcluster", "enabled"); enabled {
+		version, _, _ := unstructured.NestedString(workshop.Object, "spec", "session", "applications", "vcluster", "version")
+
+		k3sImage, found := vclusterK3sImages[version]
+
+		if !found {
+			k3sImage = vclusterK3sImages["1.25"]
+		}
+
+		images = append(images, k3sImage, vclusterSyncerImage)
+	}
+
+	return images
+}
+
+/*
+Find images for containers anywhere within a resource definition, so pod
+templates nested in deployments, jobs and other workload types are found.
+*/
+func containerImages(value interface{}) []string {
+	var images []string
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if key == "containers" || key == "initContainers" {
+				containers, _ := item.([]interface{})
+
+				for _, container := range containers {
+					if container, ok := container.(map[string]interface{}); ok {
+						if image, ok := container["image"].(string); ok {
+							images = append(images, image)
+						}
+					}
+				}
+			} else {
+				images = append(images, containerImages(item)...)
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			images = append(images, containerImages(item)...)
+		}
+	}
+
+	return images
+}
+
+func (p *ProjectInfo) NewAdminImagesPreloadCmd() *cobra.Command {
+	var o AdminImagesPreloadOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "preload",
+		Short: "Preload images for workshops into the local cluster",
+		Long: `Preload images for workshops into the local cluster.
+
+Determines the images which will be pulled when a session is created for
+each workshop, pulls them into the local docker daemon, and loads them into
+the nodes of the local Kind cluster, with multiple images being handled in
+parallel. Images already present on all nodes are skipped unless forced.
+This avoids a long delay when the first session for a workshop is started.
+
+Workshop content and extension packages downloaded by the workshop container
+when a session starts are not pulled by the cluster, so are not preloaded.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringArrayVar(
+		&o.Workshops,
+		"workshop",
+		nil,
+		"path to local workshop directory, definition file, or URL for workshop definition file (can be specified multiple times)",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopVersion,
+		"workshop-version",
+		"latest",
+		"version of the workshop being published",
+	)
+	c.Flags().StringVar(
+		&o.Repository,
+		"image-repository",
+		"localhost:5001",
+		"the address of the image repository",
+	)
+	c.Flags().StringVar(
+		&o.ImageVersion,
+		"image-version",
+		p.Version,
+		"version of workshop base images to be used",
+	)
+	c.Flags().IntVar(
+		&o.Parallel,
+		"parallel",
+		4,
+		"maximum number of images to preload at the same time",
+	)
+	c.Flags().BoolVar(
+		&o.Pull,
+		"pull",
+		false,
+		"always pull images even if present in the local docker daemon",
+	)
+	c.Flags().BoolVar(
+		&o.Force,
+		"force",
+		false,
+		"load images even if already present on the cluster nodes",
+	)
+
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.EnvFromStrings,
+		"data-values-env",
+		nil,
+		"Extract data values (as strings) from prefixed env vars (format: PREFIX for PREFIX_all__key1=str) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.EnvFromYAML,
+		"data-values-env-yaml",
+		nil,
+		"Extract data values (parsed as YAML) from prefixed env vars (format: PREFIX for PREFIX_all__key1=true) (can be specified multiple times)",
+	)
+
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.KVsFromStrings,
+		"data-value",
+		nil,
+		"Set specific data value to given value, as string (format: all.key1.subkey=123) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.KVsFromYAML,
+		"data-value-yaml",
+		nil,
+		"Set specific data value to given value, parsed as YAML (format: all.key1.subkey=true) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.KVsFromFiles,
+		"data-value-file",
+		nil,
+		"Set specific data value to contents of a file (format: [@lib1:]all.key1.subkey={file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.FromFiles,
+		"data-values-file",
+		nil,
+		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
+	)
+
+	return withAuditLogging(c)
+}
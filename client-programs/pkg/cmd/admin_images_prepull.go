@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+/*
+Namespace and labels for daemon sets which pre-pull images for workshops on
+the cluster nodes.
+*/
+const (
+	prepullNamespace     = "educates"
+	prepullWorkshopLabel = "training.educates.dev/prepull.workshop"
+)
+
+/*
+Images for the helper and placeholder containers of the pre-pull daemon set.
+The busybox image provides a static binary which is copied into a shared
+volume and run in place of the command of each image being pulled, as the
+images may not include a shell, or any other command which could be run.
+*/
+const (
+	prepullHelperImage = "docker.io/library/busybox:1.36"
+	prepullPauseImage  = "registry.k8s.io/pause:3.9"
+)
+
+func prepullDaemonSetName(workshop string) string {
+	return fmt.Sprintf("educates-prepull-%s", workshop)
+}
+
+/*
+Generate the daemon set which pre-pulls images for a workshop. An init
+container is used for each image so that the images are pulled one after the
+other, with the pod only becoming ready once all images have been pulled.
+*/
+func prepullDaemonSet(workshop string, images []string, nodeSelector map[string]string, tolerateTaints bool) *unstructured.Unstructured {
+	labels := map[string]interface{}{
+		prepullWorkshopLabel: workshop,
+	}
+
+	resources := map[string]interface{}{
+		"requests": map[string]interface{}{
+			"cpu":    "10m",
+			"memory": "16Mi",
+		},
+	}
+
+	volumeMounts := []interface{}{
+		map[string]interface{}{
+			"name":      "prepull",
+			"mountPath": "/educates-prepull",
+		},
+	}
+
+	initContainers := []interface{}{
+		map[string]interface{}{
+			"name":         "prepare",
+			"image":        prepullHelperImage,
+			"command":      []interface{}{"cp", "/bin/busybox", "/educates-prepull/busybox"},
+			"resources":    resources,
+			"volumeMounts": volumeMounts,
+		},
+	}
+
+	for i, image := range images {
+		initContainers = append(initContainers, map[string]interface{}{
+			"name":         fmt.Sprintf("image-%d", i+1),
+			"image":        image,
+			"command":      []interface{}{"/educates-prepull/busybox", "true"},
+			"resources":    resources,
+			"volumeMounts": volumeMounts,
+		})
+	}
+
+	podSpec := map[string]interface{}{
+		"initContainers": initContainers,
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name":      "pause",
+				"image":     prepullPauseImage,
+				"resources": resources,
+			},
+		},
+		"volumes": []interface{}{
+			map[string]interface{}{
+				"name":     "prepull",
+				"emptyDir": map[string]interface{}{},
+			},
+		},
+		"terminationGracePeriodSeconds": int64(0),
+	}
+
+	if len(nodeSelector) != 0 {
+		selector := map[string]interface{}{}
+
+		for key, value := range nodeSelector {
+			selector[key] = value
+		}
+
+		podSpec["nodeSelector"] = selector
+	}
+
+	if tolerateTaints {
+		podSpec["tolerations"] = []interface{}{
+			map[string]interface{}{
+				"operator": "Exists",
+			},
+		}
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "DaemonSet",
+		"metadata": map[string]interface{}{
+			"name":      prepullDaemonSetName(workshop),
+			"namespace": prepullNamespace,
+			"labels":    labels,
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": labels,
+			},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": labels,
+				},
+				"spec": podSpec,
+			},
+		},
+	}}
+}
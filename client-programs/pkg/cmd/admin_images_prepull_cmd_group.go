@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+func (p *ProjectInfo) NewAdminImagesPrepullCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "prepull",
+		Short: "Manage pre-pulling of workshop images on cluster nodes",
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewAdminImagesPrepullEnableCmd(),
+				p.NewAdminImagesPrepullDisableCmd(),
+				p.NewAdminImagesPrepullStatusCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type AdminImagesPrepullDisableOptions struct {
+	Kubeconfig string
+	Workshop   string
+}
+
+func (o *AdminImagesPrepullDisableOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	err = client.AppsV1().DaemonSets(prepullNamespace).Delete(ctx, prepullDaemonSetName(o.Workshop), metav1.DeleteOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("images for workshop %q are not being pre-pulled", o.Workshop), "")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to delete pre-pull daemon set for workshop %q", o.Workshop)
+	}
+
+	fmt.Printf("Stopped pre-pulling images for workshop %s.\n", o.Workshop)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewAdminImagesPrepullDisableCmd() *cobra.Command {
+	var o AdminImagesPrepullDisableOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "disable",
+		Short: "Stop pre-pulling images for workshop",
+		Long: `Stop pre-pulling images for a workshop.
+
+Deletes the daemon set used to pre-pull images for a workshop. Images already
+pulled are left on the cluster nodes, but may be removed by the nodes when
+they need to free up disk space.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Workshop,
+		"workshop",
+		"w",
+		"",
+		"name of the workshop deployed to the cluster",
+	)
+
+	c.MarkFlagRequired("workshop")
+
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+)
+
+type AdminImagesPrepullEnableOptions struct {
+	Kubeconfig     string
+	Workshop       string
+	Repository     string
+	ImageVersion   string
+	NodeSelector   map[string]string
+	TolerateTaints bool
+}
+
+func (o *AdminImagesPrepullEnableOptions) Run(ctx context.Context, cliVersion string) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	workshop, err := dynamicClient.Resource(workshopResource).Get(ctx, o.Workshop, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("workshop %q not found in cluster", o.Workshop), "list workshops with `educates cluster workshop list`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to retrieve workshop %q", o.Workshop)
+	}
+
+	// Where not supplied, the image repository and version of the workshop
+	// base images are those the platform was installed with, so the images
+	// are the same as those which will be pulled for workshop sessions.
+
+	if o.Repository == "" || o.ImageVersion == "" {
+		platformConfig, err := operators.InstalledConfig(ctx, clusterConfig)
+
+		if err != nil {
+			return err
+		}
+
+		if platformConfig == nil {
+			return failures.NewNotFoundError(errors.New("Educates is not installed in the cluster"), failures.PlatformHint)
+		}
+
+		if o.Repository == "" {
+			o.Repository = strings.TrimSuffix(strings.Join([]string{platformConfig.ImageRegistry.Host, platformConfig.ImageRegistry.Namespace}, "/"), "/")
+		}
+
+		if o.Repository == "" {
+			o.Repository = "localhost:5001"
+		}
+
+		if o.ImageVersion == "" {
+			if o.ImageVersion, err = operators.InstalledVersion(ctx, clusterConfig); err != nil {
+				return err
+			}
+		}
+
+		if o.ImageVersion == "" {
+			o.ImageVersion = cliVersion
+		}
+	}
+
+	workshopVersion, _, _ := unstructured.NestedString(workshop.Object, "spec", "version")
+
+	if workshopVersion == "" {
+		workshopVersion = "latest"
+	}
+
+	images, err := workshopImageReferences(workshop, o.Repository, o.ImageVersion, workshopVersion)
+
+	if err != nil {
+		return err
+	}
+
+	if len(images) == 0 {
+		return failures.NewValidationError(errors.Errorf("no images to pre-pull for workshop %q", o.Workshop), "")
+	}
+
+	daemonSet := prepullDaemonSet(o.Workshop, images, o.NodeSelector, o.TolerateTaints)
+
+	if err = clusterConfig.ApplyResources(ctx, []*unstructured.Unstructured{daemonSet}, "educates-cli"); err != nil {
+		return err
+	}
+
+	fmt.Printf("Pre-pulling %d images for workshop %s:\n", len(images), o.Workshop)
+
+	for _, image := range images {
+		fmt.Printf("  %s\n", image)
+	}
+
+	fmt.Println("Check progress with `educates admin images prepull status`.")
+
+	return nil
+}
+
+func (p *ProjectInfo) NewAdminImagesPrepullEnableCmd() *cobra.Command {
+	var o AdminImagesPrepullEnableOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "enable",
+		Short: "Pre-pull images for workshop on cluster nodes",
+		Long: `Pre-pull images for a workshop on the cluster nodes.
+
+Creates, or updates if it already exists, a daemon set which pulls the images
+used by a workshop deployed to the cluster onto each node, so the first
+workshop sessions started on a node, such as at the start of an event, don't
+have to wait for the images to be pulled. The images are those found in the
+same way as by ` + "`educates admin images preload`" + `, with the image
+repository and workshop base images version being those the platform was
+installed with unless overridden.
+
+Images are pulled on all nodes where pods can be scheduled. Use
+--node-selector to pull images only on nodes with matching labels, and
+--tolerate-taints to also pull images on nodes with taints, such as control
+plane nodes or nodes reserved for workshop sessions. The daemon set is left
+running until removed using ` + "`educates admin images prepull disable`" + `,
+so images are also pulled onto nodes added to the cluster later.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context(), p.Version) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Workshop,
+		"workshop",
+		"w",
+		"",
+		"name of the workshop deployed to the cluster",
+	)
+	c.Flags().StringVar(
+		&o.Repository,
+		"image-repository",
+		"",
+		"the address of the image repository, defaults to that of the platform",
+	)
+	c.Flags().StringVar(
+		&o.ImageVersion,
+		"image-version",
+		"",
+		"version of workshop base images to be used, defaults to that of the platform",
+	)
+	c.Flags().StringToStringVar(
+		&o.NodeSelector,
+		"node-selector",
+		nil,
+		"only pull images on nodes with this label (format: key=value) (can be specified multiple times)",
+	)
+	c.Flags().BoolVar(
+		&o.TolerateTaints,
+		"tolerate-taints",
+		false,
+		"also pull images on nodes with taints",
+	)
+
+	c.MarkFlagRequired("workshop")
+
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+
+	return withAuditLogging(c)
+}
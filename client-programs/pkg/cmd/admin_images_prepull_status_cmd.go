@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type AdminImagesPrepullStatusOptions struct {
+	Kubeconfig string
+	Workshop   string
+}
+
+/*
+Work out how many of the images a pre-pull pod has pulled, and a description
+of what it is currently doing. The first init container only prepares the
+shared volume, so isn't counted as one of the images.
+*/
+func prepullPodStatus(pod *corev1.Pod) (int, int, string) {
+	total := len(pod.Spec.InitContainers) - 1
+
+	pulled := 0
+
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name == "prepare" {
+			continue
+		}
+
+		if status.State.Terminated != nil && status.State.Terminated.ExitCode == 0 {
+			pulled++
+
+			continue
+		}
+
+		if status.State.Waiting != nil {
+			if status.State.Waiting.Reason == "PodInitializing" || status.State.Waiting.Reason == "ContainerCreating" {
+				return pulled, total, fmt.Sprintf("Pulling %s", status.Image)
+			}
+
+			return pulled, total, fmt.Sprintf("%s: %s", status.State.Waiting.Reason, status.Image)
+		}
+
+		if status.State.Terminated != nil {
+			return pulled, total, fmt.Sprintf("Failed: %s", status.Image)
+		}
+
+		return pulled, total, fmt.Sprintf("Pulling %s", status.Image)
+	}
+
+	if pulled == total {
+		return pulled, total, "Complete"
+	}
+
+	return pulled, total, string(pod.Status.Phase)
+}
+
+func (o *AdminImagesPrepullStatusOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	selector := prepullWorkshopLabel
+
+	if o.Workshop != "" {
+		selector = fmt.Sprintf("%s=%s", prepullWorkshopLabel, o.Workshop)
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets(prepullNamespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to list pre-pull daemon sets")
+	}
+
+	if len(daemonSets.Items) == 0 {
+		fmt.Println("No workshops are having images pre-pulled.")
+		return nil
+	}
+
+	pods, err := client.CoreV1().Pods(prepullNamespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to list pre-pull pods")
+	}
+
+	sort.Slice(pods.Items, func(i, j int) bool {
+		left, right := pods.Items[i], pods.Items[j]
+
+		if left.Labels[prepullWorkshopLabel] != right.Labels[prepullWorkshopLabel] {
+			return left.Labels[prepullWorkshopLabel] < right.Labels[prepullWorkshopLabel]
+		}
+
+		return left.Spec.NodeName < right.Spec.NodeName
+	})
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "WORKSHOP", "NODE", "PULLED", "STATUS")
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		node := pod.Spec.NodeName
+
+		if node == "" {
+			node = "<pending>"
+		}
+
+		pulled, total, status := prepullPodStatus(pod)
+
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\n", pod.Labels[prepullWorkshopLabel], node, pulled, total, status)
+	}
+
+	w.Flush()
+
+	// Summarize against the number of nodes the daemon set is meant to be
+	// scheduled on, so nodes which don't have a pod yet are accounted for.
+
+	fmt.Println()
+
+	for _, daemonSet := range daemonSets.Items {
+		fmt.Printf("Workshop %s: %d of %d nodes complete.\n", daemonSet.Labels[prepullWorkshopLabel], daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewAdminImagesPrepullStatusCmd() *cobra.Command {
+	var o AdminImagesPrepullStatusOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "status",
+		Short: "Show progress of pre-pulling images",
+		Long: `Show progress of pre-pulling images.
+
+Reports for each cluster node how many of the images for a workshop have been
+pulled, and what the node is currently pulling, or the reason it is unable to
+pull an image, such as the image not existing or credentials being required.
+Images are pulled in turn, so a node stays on an image which can't be pulled.
+By default all workshops having images pre-pulled are reported on.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Workshop,
+		"workshop",
+		"w",
+		"",
+		"name of the workshop deployed to the cluster",
+	)
+
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+
+	return c
+}
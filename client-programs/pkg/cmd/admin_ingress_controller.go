@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Add the options for choosing the ingress controller used by Educates to the
+commands for installing the cluster services and training platform.
+*/
+func addIngressControllerFlags(c *cobra.Command, controller *string, ingressClass *string) {
+	c.Flags().StringVar(
+		controller,
+		"ingress-controller",
+		"",
+		"ingress controller to use, one of contour, nginx or existing",
+	)
+	c.Flags().StringVar(
+		ingressClass,
+		"ingress-class",
+		"",
+		"ingress class to use for ingresses created by Educates",
+	)
+
+	c.RegisterFlagCompletionFunc("ingress-controller", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{config.IngressControllerContour, config.IngressControllerNginx, config.IngressControllerExisting}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+/*
+Update the installation config for the ingress controller chosen using the
+command line options. Where only an ingress class is given, the ingress
+controller from the installation config is kept.
+*/
+func applyIngressController(fullConfig *config.InstallationConfig, controller string, ingressClass string) error {
+	if controller == "" && ingressClass == "" {
+		return nil
+	}
+
+	if controller == "" {
+		controller = fullConfig.IngressController()
+	}
+
+	if err := fullConfig.SetIngressController(controller, ingressClass); err != nil {
+		return failures.NewValidationError(err, "")
+	}
+
+	return nil
+}
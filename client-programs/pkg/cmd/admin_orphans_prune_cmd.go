@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type AdminOrphansPruneOptions struct {
+	Kubeconfig string
+	DryRun     bool
+	MinimumAge time.Duration
+	StuckAfter time.Duration
+}
+
+/*
+A resource left behind by the operators, together with the reason it is
+believed to be orphaned and the action required to clean it up.
+*/
+type orphanedResource struct {
+	Kind   string
+	Name   string
+	Reason string
+	Action string
+	prune  func() error
+}
+
+/*
+Names of the Educates resources which currently exist in the cluster, used
+to check whether resources which reference them have been orphaned.
+*/
+type orphanOwners struct {
+	portals      map[string]bool
+	environments map[string]bool
+	sessions     map[string]bool
+}
+
+func (o *AdminOrphansPruneOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	orphans, err := o.findOrphans(ctx, client, dynamicClient)
+
+	if err != nil {
+		return err
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned resources found.")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "KIND", "NAME", "REASON", "ACTION")
+
+	for _, orphan := range orphans {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", orphan.Kind, orphan.Name, orphan.Reason, orphan.Action)
+	}
+
+	w.Flush()
+
+	if o.DryRun {
+		return nil
+	}
+
+	fmt.Println()
+
+	if err = confirmAction(fmt.Sprintf("clean up %d orphaned resources", len(orphans))); err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, orphan := range orphans {
+		if err := orphan.prune(); err != nil && !k8serrors.IsNotFound(err) {
+			failed++
+
+			fmt.Fprintf(os.Stderr, "%s/%s: failed: %s\n", orphan.Kind, orphan.Name, err)
+
+			continue
+		}
+
+		fmt.Printf("%s/%s: %s\n", orphan.Kind, orphan.Name, orphan.Action)
+	}
+
+	if failed != 0 {
+		return errors.Errorf("failed to clean up %d of %d orphaned resources", failed, len(orphans))
+	}
+
+	return nil
+}
+
+/*
+Find resources whose owning training portal, workshop environment or
+workshop session no longer exists, along with workshop environments and
+sessions which have been stuck being deleted. Resources created recently are
+ignored, as owners may still be in the process of being created or deleted.
+*/
+func (o *AdminOrphansPruneOptions) findOrphans(ctx context.Context, client *kubernetes.Clientset, dynamicClient dynamic.Interface) ([]orphanedResource, error) {
+	owners := orphanOwners{
+		portals:      map[string]bool{},
+		environments: map[string]bool{},
+		sessions:     map[string]bool{},
+	}
+
+	portals, err := dynamicClient.Resource(trainingPortalResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list training portals")
+	}
+
+	for _, item := range portals.Items {
+		owners.portals[item.GetName()] = true
+	}
+
+	environments, err := dynamicClient.Resource(workshopEnvironmentResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list workshop environments")
+	}
+
+	for _, item := range environments.Items {
+		owners.environments[item.GetName()] = true
+	}
+
+	sessions, err := dynamicClient.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list workshop sessions")
+	}
+
+	for _, item := range sessions.Items {
+		owners.sessions[item.GetName()] = true
+	}
+
+	now := time.Now()
+
+	var orphans []orphanedResource
+
+	// Workshop environments and sessions which are stuck being deleted, or
+	// whose owner no longer exists.
+
+	customResources := []struct {
+		kind     string
+		client   dynamic.ResourceInterface
+		items    []unstructured.Unstructured
+		label    string
+		existing map[string]bool
+	}{
+		{"workshopenvironment", dynamicClient.Resource(workshopEnvironmentResource), environments.Items, "training.educates.dev/portal.name", owners.portals},
+		{"workshopsession", dynamicClient.Resource(workshopSessionResource), sessions.Items, "training.educates.dev/environment.name", owners.environments},
+	}
+
+	for _, resource := range customResources {
+		for i := range resource.items {
+			item := &resource.items[i]
+
+			resourceClient := resource.client
+			name := item.GetName()
+
+			if deleted := item.GetDeletionTimestamp(); deleted != nil {
+				if now.Sub(deleted.Time) < o.StuckAfter || len(item.GetFinalizers()) == 0 {
+					continue
+				}
+
+				orphans = append(orphans, orphanedResource{
+					Kind:   resource.kind,
+					Name:   name,
+					Reason: fmt.Sprintf("stuck deleting for %s", formatTopAge(deleted.Time)),
+					Action: "finalizers removed",
+					prune: func() error {
+						_, err := resourceClient.Patch(ctx, name, types.MergePatchType, []byte(`{"metadata":{"finalizers":null}}`), metav1.PatchOptions{})
+						return err
+					},
+				})
+
+				continue
+			}
+
+			if now.Sub(item.GetCreationTimestamp().Time) < o.MinimumAge {
+				continue
+			}
+
+			owner := item.GetLabels()[resource.label]
+
+			if owner == "" || resource.existing[owner] {
+				continue
+			}
+
+			orphans = append(orphans, orphanedResource{
+				Kind:   resource.kind,
+				Name:   name,
+				Reason: fmt.Sprintf("owner %s no longer exists", owner),
+				Action: "deleted",
+				prune: func() error {
+					return resourceClient.Delete(ctx, name, metav1.DeleteOptions{})
+				},
+			})
+		}
+	}
+
+	// Namespaces and cluster scoped resources created for workshop
+	// environments and sessions, which should have been deleted along with
+	// them.
+
+	isOrphaned := func(labels map[string]string) (string, bool) {
+		if session := labels["training.educates.dev/session.name"]; session != "" {
+			return session, !owners.sessions[session]
+		}
+
+		if environment := labels["training.educates.dev/environment.name"]; environment != "" {
+			return environment, !owners.environments[environment]
+		}
+
+		return "", false
+	}
+
+	propagation := metav1.DeletePropagationBackground
+
+	deleteOptions := metav1.DeleteOptions{PropagationPolicy: &propagation}
+
+	namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: "training.educates.dev/component in (environment,session)"})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list namespaces")
+	}
+
+	for _, item := range namespaces.Items {
+		name := item.Name
+
+		if item.DeletionTimestamp != nil || now.Sub(item.CreationTimestamp.Time) < o.MinimumAge {
+			continue
+		}
+
+		if owner, orphaned := isOrphaned(item.Labels); orphaned {
+			orphans = append(orphans, orphanedResource{
+				Kind:   "namespace",
+				Name:   name,
+				Reason: fmt.Sprintf("owner %s no longer exists", owner),
+				Action: "deleted",
+				prune: func() error {
+					return client.CoreV1().Namespaces().Delete(ctx, name, deleteOptions)
+				},
+			})
+		}
+	}
+
+	clusterRoleBindings, err := client.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{LabelSelector: "training.educates.dev/component"})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list cluster role bindings")
+	}
+
+	for _, item := range clusterRoleBindings.Items {
+		name := item.Name
+
+		if item.DeletionTimestamp != nil || now.Sub(item.CreationTimestamp.Time) < o.MinimumAge {
+			continue
+		}
+
+		if owner, orphaned := isOrphaned(item.Labels); orphaned {
+			orphans = append(orphans, orphanedResource{
+				Kind:   "clusterrolebinding",
+				Name:   name,
+				Reason: fmt.Sprintf("owner %s no longer exists", owner),
+				Action: "deleted",
+				prune: func() error {
+					return client.RbacV1().ClusterRoleBindings().Delete(ctx, name, deleteOptions)
+				},
+			})
+		}
+	}
+
+	clusterRoles, err := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{LabelSelector: "training.educates.dev/component"})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list cluster roles")
+	}
+
+	for _, item := range clusterRoles.Items {
+		name := item.Name
+
+		if item.DeletionTimestamp != nil || now.Sub(item.CreationTimestamp.Time) < o.MinimumAge {
+			continue
+		}
+
+		if owner, orphaned := isOrphaned(item.Labels); orphaned {
+			orphans = append(orphans, orphanedResource{
+				Kind:   "clusterrole",
+				Name:   name,
+				Reason: fmt.Sprintf("owner %s no longer exists", owner),
+				Action: "deleted",
+				prune: func() error {
+					return client.RbacV1().ClusterRoles().Delete(ctx, name, deleteOptions)
+				},
+			})
+		}
+	}
+
+	return orphans, nil
+}
+
+func (p *ProjectInfo) NewAdminOrphansPruneCmd() *cobra.Command {
+	var o AdminOrphansPruneOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "prune",
+		Short: "Delete orphaned workshop resources",
+		Long: `Delete orphaned workshop resources.
+
+Finds resources left behind when the operators failed to clean up after a
+workshop environment or session was deleted, such as when an operator was
+restarted part way through. This includes namespaces, cluster roles and
+cluster role bindings for workshop environments and sessions which no longer
+exist, and workshop environments and sessions whose owner no longer exists.
+Workshop environments and sessions which have been stuck being deleted have
+their finalizers removed so deletion can complete.
+
+The resources found are listed, and confirmation requested before they are
+cleaned up. Use --dry-run to only list the resources. Resources created
+recently are ignored, as their owners may still be being created.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().BoolVar(
+		&o.DryRun,
+		"dry-run",
+		false,
+		"only list the orphaned resources which would be cleaned up",
+	)
+	c.Flags().DurationVar(
+		&o.MinimumAge,
+		"min-age",
+		5*time.Minute,
+		"ignore resources created more recently than this duration",
+	)
+	c.Flags().DurationVar(
+		&o.StuckAfter,
+		"stuck-after",
+		15*time.Minute,
+		"time after which a resource being deleted is considered stuck",
+	)
+
+	return withAuditLogging(c)
+}
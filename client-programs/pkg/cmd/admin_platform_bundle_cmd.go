@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	imgpkgcmd "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/cmd"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/bundle"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type AdminPlatformBundleOptions struct {
+	Output        string
+	Repository    string
+	Version       string
+	RegistryFlags imgpkgcmd.RegistryFlags
+}
+
+func (o *AdminPlatformBundleOptions) Run() error {
+	if o.Output == "" {
+		return failures.NewValidationError(errors.New("no output file for bundle provided"), "supply the name of the bundle file using `--output`")
+	}
+
+	if err := bundle.CreateBundle(o.Version, o.Repository, o.Output, o.RegistryFlags); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s.\n", o.Output)
+
+	return nil
+}
+
+/*
+Resolve which package repository to deploy a package from, relocating the
+package from a platform bundle first if one was supplied. The version of the
+platform recorded in the bundle overrides the version given.
+*/
+func resolvePackageRepository(fromBundle string, bundleRepository string, version string, registryFlags imgpkgcmd.RegistryFlags, name string) (string, string, error) {
+	if fromBundle == "" {
+		return bundle.DefaultPackageRepository, version, nil
+	}
+
+	if bundleRepository == "" {
+		return "", "", failures.NewValidationError(errors.New("no image repository for bundle provided"), "supply the image repository reachable from the cluster using `--bundle-repository`")
+	}
+
+	manifest, err := bundle.RelocateBundle(fromBundle, bundleRepository, registryFlags, name)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSuffix(bundleRepository, "/"), manifest.Version, nil
+}
+
+func addBundleRegistryFlags(c *cobra.Command, registryFlags *imgpkgcmd.RegistryFlags) {
+	c.Flags().StringSliceVar(
+		&registryFlags.CACertPaths,
+		"registry-ca-cert-path",
+		nil,
+		"Add CA certificates for registry API",
+	)
+	c.Flags().BoolVar(
+		&registryFlags.VerifyCerts,
+		"registry-verify-certs",
+		true,
+		"Set whether to verify server's certificate chain and host name",
+	)
+	c.Flags().BoolVar(
+		&registryFlags.Insecure,
+		"registry-insecure",
+		false,
+		"Allow the use of http when interacting with registries",
+	)
+	c.Flags().StringVar(
+		&registryFlags.Username,
+		"registry-username",
+		"",
+		"Set username for registry authentication",
+	)
+	c.Flags().StringVar(
+		&registryFlags.Password,
+		"registry-password",
+		"",
+		"Set password for registry authentication",
+	)
+	c.Flags().IntVar(
+		&registryFlags.RetryCount,
+		"registry-retry-count",
+		5,
+		"Set the number of times imgpkg retries to send requests to the registry in case of an error",
+	)
+}
+
+func (p *ProjectInfo) NewAdminPlatformBundleCmd() *cobra.Command {
+	var o AdminPlatformBundleOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "bundle",
+		Short: "Create bundle for installing in disconnected environments",
+		Long: `Create bundle for installing in disconnected environments.
+
+Packages the cluster essentials and training platform packages, including
+the ytt templates used to deploy them and all the container images they
+reference, into a single archive file. The workshop base image, which the
+CLI also uses to run scheduled changes to training portals, is included as
+a separate image so it can be found by its tag once relocated. The bundle can be transferred to an
+environment without internet access and installed using the --from-bundle
+option of the "admin services deploy" and "admin platform deploy" commands,
+which relocate the packages and images to an image registry reachable from
+the cluster before deploying them.
+
+The cluster must already have kapp-controller installed.`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"platform-bundle.tar",
+		"path to the file to write the bundle to",
+	)
+	c.Flags().StringVar(
+		&o.Repository,
+		"repository",
+		bundle.DefaultPackageRepository,
+		"image repository the platform packages are copied from",
+	)
+	c.Flags().StringVar(
+		&o.Version,
+		"version",
+		p.Version,
+		"version of the platform to be bundled",
+	)
+
+	addBundleRegistryFlags(c, &o.RegistryFlags)
+
+	return c
+}
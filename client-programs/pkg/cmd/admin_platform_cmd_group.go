@@ -22,6 +22,8 @@ func (p *ProjectInfo) NewAdminPlatformCmdGroup() *cobra.Command {
 				p.NewAdminPlatformConfigCmdGroup(),
 				p.NewAdminPlatformDeployCmd(),
 				p.NewAdminPlatformDeleteCmd(),
+				p.NewAdminPlatformLogsCmd(),
+				p.NewAdminPlatformBundleCmd(),
 			},
 		},
 	}
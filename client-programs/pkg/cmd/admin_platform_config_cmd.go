@@ -9,6 +9,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
 	"gopkg.in/yaml.v2"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -49,7 +51,7 @@ type AdminPlatformConfigViewOptions struct {
 	Kubeconfig string
 }
 
-func (o *AdminPlatformConfigViewOptions) Run() error {
+func (o *AdminPlatformConfigViewOptions) Run(ctx context.Context) error {
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	client, err := clusterConfig.GetClient()
@@ -60,7 +62,7 @@ func (o *AdminPlatformConfigViewOptions) Run() error {
 
 	secretsClient := client.CoreV1().Secrets("educates-package")
 
-	valuesSecret, err := secretsClient.Get(context.TODO(), "educates-training-platform-values", metav1.GetOptions{})
+	valuesSecret, err := secretsClient.Get(ctx, "educates-training-platform-values", metav1.GetOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "platform not deployed")
@@ -69,7 +71,7 @@ func (o *AdminPlatformConfigViewOptions) Run() error {
 	valuesData, ok := valuesSecret.Data["values.yml"]
 
 	if !ok {
-		return errors.New("no platform configuration found")
+		return failures.NewNotFoundError(errors.New("no platform configuration found"), failures.PlatformHint)
 	}
 
 	fmt.Print(string(valuesData))
@@ -84,7 +86,7 @@ func (p *ProjectInfo) NewAdminPlatformConfigViewCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "view",
 		Short: "View platform configuration",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -105,7 +107,7 @@ type AdminPlatformConfigUpdateOptions struct {
 
 var kappAppResource = schema.GroupVersionResource{Group: "kappctrl.k14s.io", Version: "v1alpha1", Resource: "apps"}
 
-func (o *AdminPlatformConfigUpdateOptions) Run() error {
+func (o *AdminPlatformConfigUpdateOptions) Run(ctx context.Context) error {
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	client, err := clusterConfig.GetClient()
@@ -116,7 +118,7 @@ func (o *AdminPlatformConfigUpdateOptions) Run() error {
 
 	secretsClient := client.CoreV1().Secrets("educates-package")
 
-	valuesSecret, err := secretsClient.Get(context.TODO(), "educates-training-platform-values", metav1.GetOptions{})
+	valuesSecret, err := secretsClient.Get(ctx, "educates-training-platform-values", metav1.GetOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "platform not deployed")
@@ -125,7 +127,7 @@ func (o *AdminPlatformConfigUpdateOptions) Run() error {
 	_, ok := valuesSecret.Data["values.yml"]
 
 	if !ok {
-		return errors.New("no platform configuration found")
+		return failures.NewNotFoundError(errors.New("no platform configuration found"), failures.PlatformHint)
 	}
 
 	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
@@ -149,6 +151,7 @@ func (o *AdminPlatformConfigUpdateOptions) Run() error {
 		ClusterNetwork:    fullConfig.ClusterNetwork,
 		WorkshopAnalytics: fullConfig.WorkshopAnalytics,
 		WebsiteStyling:    fullConfig.WebsiteStyling,
+		FeatureGates:      fullConfig.FeatureGates,
 	}
 
 	platformConfigData, err := yaml.Marshal(platformConfig)
@@ -165,59 +168,85 @@ func (o *AdminPlatformConfigUpdateOptions) Run() error {
 
 	patch := applycorev1.Secret("educates-training-platform-values", "educates-package").WithType(secretObj.Type).WithData(secretObj.Data)
 
-	_, err = secretsClient.Apply(context.TODO(), patch, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
+	_, err = secretsClient.Apply(ctx, patch, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
 
 	if err != nil {
 		return errors.Wrapf(err, "unable to update platform configuration")
 	}
 
-	if o.Reconcile {
-		dynamicClient, err := clusterConfig.GetDynamicClient()
+	// Overlays are updated at the same time as the platform configuration so
+	// that any changes to them are applied when the platform reconciles.
+
+	overlays, err := operators.ApplyOverlays(ctx, clusterConfig)
+
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
+	if overlays != 0 {
+		fmt.Printf("Applied %d platform overlays from %s.\n", overlays, config.PlatformOverlaysDir())
+	}
+
+	if o.Reconcile {
+		if err := reconcilePlatform(ctx, clusterConfig); err != nil {
 			return err
 		}
+	}
 
-		appResourceClient := dynamicClient.Resource(kappAppResource).Namespace("educates-package")
+	return nil
+}
 
-		pausePatch := []map[string]interface{}{
-			{
-				"op":    "add",
-				"path":  "/spec/paused",
-				"value": true,
-			},
-		}
+/*
+Trigger reconcilation of the kapp App resource for the platform, so that
+changes to the platform configuration are applied straight away rather than
+on the next periodic sync, by pausing and then resuming the App.
+*/
+func reconcilePlatform(ctx context.Context, clusterConfig *cluster.ClusterConfig) error {
+	dynamicClient, err := clusterConfig.GetDynamicClient()
 
-		patchJSON, err := json.Marshal(pausePatch)
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
-			return errors.Wrapf(err, "unable to create patch for deployment")
-		}
+	appResourceClient := dynamicClient.Resource(kappAppResource).Namespace("educates-package")
 
-		_, err = appResourceClient.Patch(context.TODO(), "educates-training-platform", types.JSONPatchType, patchJSON, metav1.PatchOptions{})
+	pausePatch := []map[string]interface{}{
+		{
+			"op":    "add",
+			"path":  "/spec/paused",
+			"value": true,
+		},
+	}
 
-		if err != nil {
-			return errors.Wrapf(err, "unable to pause reconcilation")
-		}
+	patchJSON, err := json.Marshal(pausePatch)
 
-		unpausePatch := []map[string]interface{}{
-			{
-				"op":   "remove",
-				"path": "/spec/paused",
-			},
-		}
+	if err != nil {
+		return errors.Wrapf(err, "unable to create patch for deployment")
+	}
 
-		patchJSON, err = json.Marshal(unpausePatch)
+	_, err = appResourceClient.Patch(ctx, "educates-training-platform", types.JSONPatchType, patchJSON, metav1.PatchOptions{})
 
-		if err != nil {
-			return errors.Wrapf(err, "unable to create patch for deployment")
-		}
+	if err != nil {
+		return errors.Wrapf(err, "unable to pause reconcilation")
+	}
+
+	unpausePatch := []map[string]interface{}{
+		{
+			"op":   "remove",
+			"path": "/spec/paused",
+		},
+	}
 
-		_, err = appResourceClient.Patch(context.TODO(), "educates-training-platform", types.JSONPatchType, patchJSON, metav1.PatchOptions{})
+	patchJSON, err = json.Marshal(unpausePatch)
 
-		if err != nil {
-			return errors.Wrapf(err, "unable to resume reconcilation")
-		}
+	if err != nil {
+		return errors.Wrapf(err, "unable to create patch for deployment")
+	}
+
+	_, err = appResourceClient.Patch(ctx, "educates-training-platform", types.JSONPatchType, patchJSON, metav1.PatchOptions{})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to resume reconcilation")
 	}
 
 	return nil
@@ -230,7 +259,13 @@ func (p *ProjectInfo) NewAdminPlatformConfigUpdateCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "update",
 		Short: "Update platform configuration",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Long: `Update the configuration of the installed platform.
+
+Replaces the data values the platform was installed with using those from
+the installation config file. Any ytt overlay files in the overlays directory
+of the CLI, usually $HOME/.local/share/educates/overlays, are also applied on
+top of the platform templates, replacing any overlays applied previously. Use --reconcile for the changes to take effect straight away.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -252,5 +287,5 @@ func (p *ProjectInfo) NewAdminPlatformConfigUpdateCmd() *cobra.Command {
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
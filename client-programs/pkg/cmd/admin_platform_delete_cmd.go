@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
@@ -13,13 +15,19 @@ type AdminPlatformDeleteOptions struct {
 	Kubeconfig string
 }
 
-func (o *AdminPlatformDeleteOptions) Run() error {
+func (o *AdminPlatformDeleteOptions) Run(ctx context.Context) error {
 	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
 
 	if err != nil {
 		return err
 	}
 
+	err = confirmAction("delete the Educates platform operators")
+
+	if err != nil {
+		return err
+	}
+
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	platformConfig := config.TrainingPlatformConfig{
@@ -37,9 +45,10 @@ func (o *AdminPlatformDeleteOptions) Run() error {
 		ClusterNetwork:    fullConfig.ClusterNetwork,
 		WorkshopAnalytics: fullConfig.WorkshopAnalytics,
 		WebsiteStyling:    fullConfig.WebsiteStyling,
+		FeatureGates:      fullConfig.FeatureGates,
 	}
 
-	return operators.DeleteOperators(clusterConfig, &platformConfig)
+	return operators.DeleteOperators(ctx, clusterConfig, &platformConfig)
 }
 
 func (p *ProjectInfo) NewAdminPlatformDeleteCmd() *cobra.Command {
@@ -49,7 +58,7 @@ func (p *ProjectInfo) NewAdminPlatformDeleteCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "delete",
 		Short: "Delete platform operators",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -65,5 +74,5 @@ func (p *ProjectInfo) NewAdminPlatformDeleteCmd() *cobra.Command {
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
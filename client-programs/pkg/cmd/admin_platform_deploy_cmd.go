@@ -1,22 +1,38 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"time"
+
 	"github.com/spf13/cobra"
+	imgpkgcmd "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/cmd"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/dns"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/preflight"
 )
 
 type AdminPlatformDeployOptions struct {
-	Config     string
-	Kubeconfig string
-	Provider   string
-	Domain     string
-	Version    string
+	Config            string
+	Kubeconfig        string
+	Provider          string
+	Domain            string
+	IngressController string
+	IngressClass      string
+	Version           string
+	FromBundle        string
+	BundleRepository  string
+	ManageDNS         bool
+	DNSProvider       string
+	DNSTimeout        time.Duration
+	SkipPreflight     bool
+	RegistryFlags     imgpkgcmd.RegistryFlags
 }
 
-func (o *AdminPlatformDeployOptions) Run() error {
+func (o *AdminPlatformDeployOptions) Run(ctx context.Context) error {
 	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
 
 	if err != nil {
@@ -44,6 +60,24 @@ func (o *AdminPlatformDeployOptions) Run() error {
 
 	fullConfig.ClusterInfrastructure.Provider = o.Provider
 
+	if err = applyIngressController(fullConfig, o.IngressController, o.IngressClass); err != nil {
+		return err
+	}
+
+	if o.DNSProvider != "" {
+		fullConfig.ClusterDNS.Provider = o.DNSProvider
+	}
+
+	var dnsProvider dns.Provider
+
+	if o.ManageDNS {
+		dnsProvider, err = ingressDNSProvider(fullConfig.ClusterDNS, fullConfig.ClusterIngress.Domain)
+
+		if err != nil {
+			return err
+		}
+	}
+
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	platformConfig := config.TrainingPlatformConfig{
@@ -61,9 +95,39 @@ func (o *AdminPlatformDeployOptions) Run() error {
 		ClusterNetwork:    fullConfig.ClusterNetwork,
 		WorkshopAnalytics: fullConfig.WorkshopAnalytics,
 		WebsiteStyling:    fullConfig.WebsiteStyling,
+		FeatureGates:      fullConfig.FeatureGates,
+	}
+
+	packageRepository, version, err := resolvePackageRepository(o.FromBundle, o.BundleRepository, o.Version, o.RegistryFlags, "educates-training-platform")
+
+	if err != nil {
+		return err
+	}
+
+	if !o.SkipPreflight {
+		checks := preflight.PlatformChecks()
+
+		// The wildcard DNS record only needs to exist after deploying when
+		// it is being created as part of the deployment.
+
+		if o.ManageDNS {
+			checks = preflight.Without(checks, preflight.WildcardDNSCheck.Name)
+		}
+
+		if err = runPreflightChecks(ctx, os.Stderr, clusterConfig, fullConfig, checks); err != nil {
+			return err
+		}
+	}
+
+	if err = operators.DeployOperators(ctx, version, packageRepository, clusterConfig, &platformConfig); err != nil {
+		return err
+	}
+
+	if o.ManageDNS {
+		return manageIngressDNS(clusterConfig, dnsProvider, fullConfig.ClusterDNS, fullConfig.ClusterIngress.Domain, o.DNSTimeout)
 	}
 
-	return operators.DeployOperators(o.Version, clusterConfig, &platformConfig)
+	return nil
 }
 
 func (p *ProjectInfo) NewAdminPlatformDeployCmd() *cobra.Command {
@@ -73,7 +137,16 @@ func (p *ProjectInfo) NewAdminPlatformDeployCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "deploy",
 		Short: "Deploy platform operators",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Long: `Deploy the platform operators to the cluster.
+
+Any ytt overlay files found in the overlays directory of the CLI, usually
+$HOME/.local/share/educates/overlays, are applied on top of the platform
+templates. Overlays can be used to make customizations not covered by the
+installation config, such as adding environment variables, sidecar
+containers or annotations to the platform deployments, and as they are kept
+separate from the templates they are retained when the version of the
+platform being installed changes.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -100,12 +173,55 @@ func (p *ProjectInfo) NewAdminPlatformDeployCmd() *cobra.Command {
 		"",
 		"wildcard ingress subdomain name for Educates",
 	)
+
+	addIngressControllerFlags(c, &o.IngressController, &o.IngressClass)
+
 	c.Flags().StringVar(
 		&o.Version,
 		"version",
 		p.Version,
 		"version to be installed",
 	)
+	c.Flags().StringVar(
+		&o.FromBundle,
+		"from-bundle",
+		"",
+		"path to platform bundle to install from when in a disconnected environment",
+	)
+	c.Flags().StringVar(
+		&o.BundleRepository,
+		"bundle-repository",
+		"",
+		"image repository reachable from the cluster to relocate the bundle to",
+	)
+
+	c.Flags().BoolVar(
+		&o.ManageDNS,
+		"manage-dns",
+		false,
+		"create or update wildcard DNS record for ingress domain pointing at ingress load balancer",
+	)
+	c.Flags().StringVar(
+		&o.DNSProvider,
+		"dns-provider",
+		"",
+		"DNS provider hosting the ingress domain, one of route53, cloudflare or google",
+	)
+	c.Flags().DurationVar(
+		&o.DNSTimeout,
+		"dns-timeout",
+		10*time.Minute,
+		"maximum time to wait for load balancer and DNS record to propagate",
+	)
+
+	c.Flags().BoolVar(
+		&o.SkipPreflight,
+		"skip-preflight",
+		false,
+		"skip pre-flight checks made before deploying",
+	)
+
+	addBundleRegistryFlags(c, &o.RegistryFlags)
 
-	return c
+	return withAuditLogging(c)
 }
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/dns"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Wait for the service of the ingress controller to be allocated a load
+balancer, returning the IP addresses or host names of the load balancer.
+*/
+func ingressLoadBalancerAddresses(ctx context.Context, clusterConfig *cluster.ClusterConfig, serviceRef config.ServiceRefConfig) ([]string, error) {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	for {
+		service, err := client.CoreV1().Services(serviceRef.Namespace).Get(ctx, serviceRef.Name, metav1.GetOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			return nil, failures.NewNotFoundError(errors.Errorf("no ingress controller service %s/%s found", serviceRef.Namespace, serviceRef.Name), "set clusterDNS.serviceRef in the installation config to the load balancer service of the ingress controller")
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to retrieve ingress controller service %s/%s", serviceRef.Namespace, serviceRef.Name)
+		}
+
+		var addresses []string
+
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				addresses = append(addresses, ingress.Hostname)
+			} else if ingress.IP != "" {
+				addresses = append(addresses, ingress.IP)
+			}
+		}
+
+		if len(addresses) != 0 {
+			return addresses, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, failures.NewTimeoutError(errors.Errorf("no load balancer allocated for ingress controller service %s/%s", serviceRef.Namespace, serviceRef.Name), "check the service is of type LoadBalancer and the cluster supports load balancers")
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+/*
+Return the DNS provider for managing the record for the ingress domain. This
+is checked before deploying so that a mistake in the configuration is found
+before any changes are made to the cluster.
+*/
+func ingressDNSProvider(dnsConfig config.ClusterDNSConfig, domain string) (dns.Provider, error) {
+	if dnsConfig.Provider == "" {
+		return nil, failures.NewValidationError(errors.New("no DNS provider specified"), "use --dns-provider or set clusterDNS.provider in the installation config")
+	}
+
+	if domain == "" || strings.HasSuffix(domain, ".nip.io") || strings.HasSuffix(domain, ".sslip.io") {
+		return nil, failures.NewValidationError(errors.Errorf("DNS records cannot be managed for ingress domain %q", domain), "use --domain to supply a domain hosted by the DNS provider")
+	}
+
+	return dns.NewProvider(dnsConfig.Provider, dnsConfig.Zone)
+}
+
+/*
+Create or update the wildcard DNS record for the ingress domain so it points
+at the load balancer of the ingress controller, then wait for the record to
+be visible to the DNS resolver of the local host.
+*/
+func manageIngressDNS(clusterConfig *cluster.ClusterConfig, provider dns.Provider, dnsConfig config.ClusterDNSConfig, domain string, timeout time.Duration) error {
+	serviceRef := dnsConfig.ServiceRef
+
+	if serviceRef.Namespace == "" {
+		serviceRef.Namespace = "projectcontour"
+	}
+
+	if serviceRef.Name == "" {
+		serviceRef.Name = "envoy"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	defer cancel()
+
+	addresses, err := ingressLoadBalancerAddresses(ctx, clusterConfig, serviceRef)
+
+	if err != nil {
+		return err
+	}
+
+	record, err := dns.WildcardRecord(domain, addresses, dnsConfig.TTL)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Updating %s record %s in %s to %s.\n", record.Type, record.Name, provider, strings.Join(record.Values, ", "))
+
+	if err = provider.UpsertRecord(ctx, record); err != nil {
+		return errors.Wrapf(err, "unable to update DNS record %s", record.Name)
+	}
+
+	fmt.Fprintf(os.Stderr, "Waiting for DNS record %s to propagate.\n", record.Name)
+
+	deadline, _ := ctx.Deadline()
+
+	return dns.WaitForPropagation(ctx, record, time.Until(deadline))
+}
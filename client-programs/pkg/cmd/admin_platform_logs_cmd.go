@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Components of the training platform which logs can be output for. The
+operators run in the namespace Educates is installed into, with training
+portals each running in their own namespace.
+*/
+var platformLogComponents = []string{"session-manager", "secrets-manager", "training-portal"}
+
+type AdminPlatformLogsOptions struct {
+	Kubeconfig string
+	Components []string
+	Portal     string
+	Follow     bool
+	Since      time.Duration
+	Tail       int64
+	Timestamps bool
+}
+
+type platformLogSource struct {
+	label     string
+	namespace string
+	pod       string
+}
+
+type platformLogLine struct {
+	timestamp string
+	label     string
+	text      string
+}
+
+/*
+Locate the pods for the selected components of the training platform.
+*/
+func (o *AdminPlatformLogsOptions) findSources(ctx context.Context, client kubernetes.Interface) ([]platformLogSource, error) {
+	var sources []platformLogSource
+
+	for _, component := range o.Components {
+		var pods *apiv1.PodList
+		var err error
+
+		if component == "training-portal" {
+			selector := "deployment=training-portal,training.educates.dev/component=portal"
+
+			if o.Portal != "" {
+				selector = fmt.Sprintf("%s,training.educates.dev/portal.name=%s", selector, o.Portal)
+			}
+
+			pods, err = client.CoreV1().Pods("").List(ctx, metav1.ListOptions{LabelSelector: selector})
+		} else {
+			pods, err = client.CoreV1().Pods("educates").List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("deployment=%s", component)})
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list pods for %s", component)
+		}
+
+		sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+
+		for _, pod := range pods.Items {
+			label := component
+
+			// Label portal logs with the portal name as there can be many.
+
+			if component == "training-portal" {
+				label = fmt.Sprintf("%s/%s", component, pod.Labels["training.educates.dev/portal.name"])
+			}
+
+			sources = append(sources, platformLogSource{label: label, namespace: pod.Namespace, pod: pod.Name})
+		}
+	}
+
+	return sources, nil
+}
+
+func (o *AdminPlatformLogsOptions) Run(ctx context.Context) error {
+	if len(o.Components) == 0 {
+		o.Components = platformLogComponents
+	}
+
+	for _, component := range o.Components {
+		if !containsString(platformLogComponents, component) {
+			return failures.NewValidationError(errors.Errorf("unknown component %q", component), fmt.Sprintf("component must be one of %s", strings.Join(platformLogComponents, ", ")))
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	sources, err := o.findSources(ctx, client)
+
+	if err != nil {
+		return err
+	}
+
+	if len(sources) == 0 {
+		return failures.NewNotFoundError(errors.New("no pods found for training platform components"), "check the training platform is deployed with `educates admin platform deploy`")
+	}
+
+	// Timestamps are always requested so that when not following the logs,
+	// the lines from all components can be merged in time order.
+
+	var outputLock sync.Mutex
+
+	var collected []platformLogLine
+
+	output := func(line platformLogLine) {
+		outputLock.Lock()
+		defer outputLock.Unlock()
+
+		if !o.Follow {
+			collected = append(collected, line)
+			return
+		}
+
+		o.printLine(line)
+	}
+
+	stream := func(s platformLogSource) error {
+		options := &apiv1.PodLogOptions{Follow: o.Follow, Timestamps: true}
+
+		if o.Tail >= 0 {
+			options.TailLines = &o.Tail
+		}
+
+		if o.Since > 0 {
+			seconds := int64(o.Since.Seconds())
+			options.SinceSeconds = &seconds
+		}
+
+		reader, err := client.CoreV1().Pods(s.namespace).GetLogs(s.pod, options).Stream(ctx)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to get logs for pod %s/%s", s.namespace, s.pod)
+		}
+
+		defer reader.Close()
+
+		scanner := bufio.NewScanner(reader)
+
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+		for scanner.Scan() {
+			timestamp, text, _ := strings.Cut(scanner.Text(), " ")
+
+			output(platformLogLine{timestamp: timestamp, label: s.label, text: text})
+		}
+
+		return scanner.Err()
+	}
+
+	errs := runParallel(len(sources), len(sources), func(i int) error {
+		return stream(sources[i])
+	})
+
+	failed := 0
+
+	for _, err := range errs {
+		if err != nil {
+			failed++
+
+			fmt.Fprintf(os.Stderr, "Warning: %s.\n", err)
+		}
+	}
+
+	if failed == len(sources) {
+		return errors.New("unable to get logs for any training platform components")
+	}
+
+	sort.SliceStable(collected, func(i, j int) bool {
+		return parseLogTimestamp(collected[i].timestamp).Before(parseLogTimestamp(collected[j].timestamp))
+	})
+
+	for _, line := range collected {
+		o.printLine(line)
+	}
+
+	return nil
+}
+
+func (o *AdminPlatformLogsOptions) printLine(line platformLogLine) {
+	if o.Timestamps {
+		fmt.Printf("[%s] %s %s\n", line.label, line.timestamp, line.text)
+	} else {
+		fmt.Printf("[%s] %s\n", line.label, line.text)
+	}
+}
+
+func parseLogTimestamp(value string) time.Time {
+	timestamp, _ := time.Parse(time.RFC3339Nano, value)
+
+	return timestamp
+}
+
+func (p *ProjectInfo) NewAdminPlatformLogsCmd() *cobra.Command {
+	var o AdminPlatformLogsOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "logs",
+		Short: "Output logs for training platform",
+		Long: `Output logs for the training platform.
+
+Locates the pods for the session manager and secrets manager operators, and
+for the training portals, and outputs their logs merged together in time
+order, with each line labelled by the component it came from. Use
+"--component" to only output logs for some components, "--portal" to only
+output logs for one training portal, and "--since" to only output recent
+logs. With "--follow", logs are streamed from all components as they are
+written.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringSliceVar(
+		&o.Components,
+		"component",
+		[]string{},
+		"component to output logs for, one of session-manager, secrets-manager or training-portal (can be specified multiple times)",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"",
+		"only output logs for the training portal with this name",
+	)
+	c.Flags().BoolVarP(
+		&o.Follow,
+		"follow",
+		"f",
+		false,
+		"follow the logs as they are written",
+	)
+	c.Flags().DurationVar(
+		&o.Since,
+		"since",
+		0,
+		"only output logs newer than a relative duration such as 5m or 1h",
+	)
+	c.Flags().Int64Var(
+		&o.Tail,
+		"tail",
+		-1,
+		"number of lines from the end of the logs of each pod to output, all if negative",
+	)
+	c.Flags().BoolVar(
+		&o.Timestamps,
+		"timestamps",
+		false,
+		"include the timestamp of each line in the output",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("component", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return platformLogComponents, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return c
+}
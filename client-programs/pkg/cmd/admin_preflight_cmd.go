@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/preflight"
+)
+
+/*
+Run pre-flight checks and report the results, returning an error if any of
+the checks failed. The cluster config can be nil when no cluster exists yet,
+in which case only checks not needing the cluster are run.
+*/
+func runPreflightChecks(ctx context.Context, out io.Writer, clusterConfig *cluster.ClusterConfig, fullConfig *config.InstallationConfig, checks []preflight.Check) error {
+	env := preflight.Environment{
+		Config: fullConfig,
+	}
+
+	if clusterConfig != nil {
+		client, err := clusterConfig.GetClient()
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
+
+		env.Client = client
+	}
+
+	results := preflight.RunChecks(ctx, &env, checks)
+
+	preflight.Report(out, results)
+
+	if preflight.Failed(results) {
+		return failures.NewValidationError(errors.New("pre-flight checks failed"), "fix the problems reported, or use --skip-preflight to bypass the checks")
+	}
+
+	return nil
+}
+
+type AdminPreflightOptions struct {
+	Config     string
+	Kubeconfig string
+	Domain     string
+	Services   bool
+}
+
+func (o *AdminPreflightOptions) Run(ctx context.Context) error {
+	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
+
+	if err != nil {
+		return err
+	}
+
+	if o.Domain != "" {
+		fullConfig.ClusterIngress.Domain = o.Domain
+	}
+
+	checks := preflight.PlatformChecks()
+
+	if o.Services {
+		checks = preflight.ServicesChecks()
+	}
+
+	if err = runPreflightChecks(ctx, os.Stdout, cluster.NewClusterConfig(o.Kubeconfig), fullConfig, checks); err != nil {
+		return err
+	}
+
+	fmt.Println("All pre-flight checks passed.")
+
+	return nil
+}
+
+func (p *ProjectInfo) NewAdminPreflightCmd() *cobra.Command {
+	var o AdminPreflightOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "preflight",
+		Short: "Run pre-flight checks against cluster",
+		Long: `Run pre-flight checks against a cluster.
+
+Runs the same checks as are run before deploying the training platform,
+covering the Kubernetes version, whether an ingress controller and storage
+class are available, whether the cluster supports the pod security policy
+engine selected, whether host names under the ingress domain resolve and
+whether the image registry can be reached. Use --services to instead run
+the checks made before deploying the cluster services.
+
+Problems which would prevent Educates working are reported as failures,
+with problems which may be intended, or can be fixed after installing,
+reported as warnings. The checks are also run by the commands which install
+or deploy Educates, and can be bypassed using --skip-preflight.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Config,
+		"config",
+		"",
+		"path to the installation config file for Educates",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVar(
+		&o.Domain,
+		"domain",
+		"",
+		"wildcard ingress subdomain name for Educates",
+	)
+	c.Flags().BoolVar(
+		&o.Services,
+		"services",
+		false,
+		"run checks made before deploying cluster services instead",
+	)
+
+	return c
+}
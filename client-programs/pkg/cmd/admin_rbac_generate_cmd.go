@@ -0,0 +1,486 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const rbacRoleLabel = "training.educates.dev/cli.role"
+
+/*
+Permissions needed by each type of user of the CLI. Educates resources are
+cluster scoped and workshop sessions each have their own namespace, so most
+permissions are granted cluster wide. Access to logs of pods and exec into
+them is only granted in the namespaces for workshop environments and
+sessions, as the pods for the session manager and training portals run with
+service accounts which have cluster wide access. Other permissions are
+granted only in the namespace they are needed, such as secrets where the CLI
+keeps secrets for copying to workshops.
+
+No persona can create or update secret copiers or secret injectors, as the
+secrets operator runs with cluster admin access and could be used to copy
+any secret in the cluster into a namespace the user can read secrets in.
+Being able to create or update workshops still means being able to have the
+session manager, which also runs with cluster admin access, create arbitrary
+resources for workshop environments and sessions, so the author, trainer and
+operator personas are close to being cluster admins.
+*/
+type rbacPersona struct {
+	clusterRules   []interface{}
+	sessionRules   []interface{}
+	namespaceRules map[string][]interface{}
+}
+
+var rbacSessionRules = []interface{}{
+	rbacRule([]string{""}, []string{"pods/log"}, []string{"get"}),
+	rbacRule([]string{""}, []string{"pods/exec"}, []string{"create"}),
+}
+
+var rbacReadVerbs = []string{"get", "list", "watch"}
+
+var rbacWriteVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+func rbacRule(groups []string, resources []string, verbs []string) interface{} {
+	toList := func(values []string) []interface{} {
+		var result []interface{}
+
+		for _, value := range values {
+			result = append(result, value)
+		}
+
+		return result
+	}
+
+	return map[string]interface{}{
+		"apiGroups": toList(groups),
+		"resources": toList(resources),
+		"verbs":     toList(verbs),
+	}
+}
+
+var rbacPersonas = map[string]rbacPersona{
+	// Authors deploy their own workshops to the training portal used by the
+	// CLI, and access workshop sessions to check and debug them.
+
+	"author": {
+		clusterRules: []interface{}{
+			rbacRule([]string{"training.educates.dev"}, []string{"workshops"}, rbacWriteVerbs),
+			rbacRule([]string{"training.educates.dev"}, []string{"trainingportals"}, []string{"get", "list", "watch", "create", "update", "patch"}),
+			rbacRule([]string{"training.educates.dev"}, []string{"workshopenvironments", "workshopsessions", "workshopallocations", "workshoprequests"}, rbacReadVerbs),
+			rbacRule([]string{""}, []string{"namespaces", "pods", "events"}, rbacReadVerbs),
+		},
+		sessionRules: rbacSessionRules,
+	},
+
+	// Trainers run classes, so manage training portals and workshops, look
+	// after the workshop sessions of attendees, and add the secrets needed
+	// by workshops. Secret copiers and injectors can only be read, so
+	// configuring secret settings of a training portal requires cluster
+	// admin access.
+
+	"trainer": {
+		clusterRules: []interface{}{
+			rbacRule([]string{"training.educates.dev"}, []string{"workshops", "trainingportals"}, rbacWriteVerbs),
+			rbacRule([]string{"training.educates.dev"}, []string{"workshopsessions"}, []string{"get", "list", "watch", "update", "patch", "delete"}),
+			rbacRule([]string{"training.educates.dev"}, []string{"workshopenvironments", "workshopallocations", "workshoprequests"}, rbacReadVerbs),
+			rbacRule([]string{"secrets.educates.dev"}, []string{"secretcopiers", "secretinjectors"}, rbacReadVerbs),
+			rbacRule([]string{""}, []string{"namespaces", "pods", "services", "events"}, rbacReadVerbs),
+			rbacRule([]string{"apps"}, []string{"deployments"}, rbacReadVerbs),
+		},
+		sessionRules: rbacSessionRules,
+		namespaceRules: map[string][]interface{}{
+			"educates-secrets": {
+				rbacRule([]string{""}, []string{"secrets"}, rbacWriteVerbs),
+			},
+		},
+	},
+
+	// Operators look after Educates once installed, including cleaning up
+	// resources left behind, backups and diagnostics, but installing the
+	// platform itself still requires cluster admin access. Deployments can
+	// only be read, as changing the deployment of the session manager or a
+	// training portal would allow running code with its service account.
+	// For the same reason secret copiers and injectors can only be read and
+	// deleted, so restoring them from a backup requires cluster admin.
+
+	"operator": {
+		clusterRules: []interface{}{
+			rbacRule([]string{"training.educates.dev"}, []string{"*"}, []string{"*"}),
+			rbacRule([]string{"secrets.educates.dev"}, []string{"secretcopiers", "secretinjectors"}, []string{"get", "list", "watch", "delete"}),
+			rbacRule([]string{""}, []string{"namespaces"}, rbacWriteVerbs),
+			rbacRule([]string{""}, []string{"pods", "services", "configmaps", "serviceaccounts", "events", "nodes"}, rbacReadVerbs),
+			rbacRule([]string{"apps"}, []string{"deployments"}, rbacReadVerbs),
+			rbacRule([]string{"rbac.authorization.k8s.io"}, []string{"clusterroles", "clusterrolebindings"}, []string{"get", "list", "watch", "delete"}),
+			rbacRule([]string{"apiextensions.k8s.io"}, []string{"customresourcedefinitions"}, rbacReadVerbs),
+			rbacRule([]string{"kappctrl.k14s.io"}, []string{"apps"}, rbacReadVerbs),
+		},
+		sessionRules: rbacSessionRules,
+		namespaceRules: map[string][]interface{}{
+			"educates-secrets": {
+				rbacRule([]string{""}, []string{"secrets"}, rbacWriteVerbs),
+			},
+			"educates": {
+				rbacRule([]string{""}, []string{"pods/log"}, []string{"get"}),
+			},
+		},
+	},
+}
+
+type AdminRbacGenerateOptions struct {
+	Kubeconfig      string
+	Role            string
+	Users           []string
+	Groups          []string
+	ServiceAccounts []string
+	Apply           bool
+}
+
+var invalidBindingNameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+/*
+Generate the name of the binding for a subject, so that a subject can be
+granted a role separately to others with the same role.
+*/
+func rbacBindingName(role string, kind string, name string) string {
+	name = invalidBindingNameCharacters.ReplaceAllString(strings.ToLower(name), "-")
+
+	return fmt.Sprintf("educates-cli-%s-%s-%s", role, strings.ToLower(kind), strings.Trim(name, "-."))
+}
+
+func (o *AdminRbacGenerateOptions) Run(ctx context.Context) error {
+	persona, found := rbacPersonas[o.Role]
+
+	if !found {
+		var names []string
+
+		for name := range rbacPersonas {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		return failures.NewValidationError(errors.Errorf("unsupported role %q", o.Role), fmt.Sprintf("supported roles are %s", strings.Join(names, ", ")))
+	}
+
+	roleName := fmt.Sprintf("educates-cli-%s", o.Role)
+
+	labels := map[string]interface{}{rbacRoleLabel: o.Role}
+
+	objects := []*unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRole",
+			"metadata": map[string]interface{}{
+				"name":   roleName,
+				"labels": labels,
+			},
+			"rules": persona.clusterRules,
+		}},
+	}
+
+	// The cluster role holding the session rules is never bound cluster
+	// wide. The operator binds it in the namespace for each workshop
+	// environment and session it creates, for each cluster role binding
+	// below, with bindings also being added to existing namespaces when
+	// the resources are applied.
+
+	sessionRoleName := fmt.Sprintf("%s-sessions", roleName)
+
+	if len(persona.sessionRules) != 0 {
+		objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRole",
+			"metadata": map[string]interface{}{
+				"name":   sessionRoleName,
+				"labels": labels,
+			},
+			"rules": persona.sessionRules,
+		}})
+	}
+
+	var namespaces []string
+
+	for namespace := range persona.namespaceRules {
+		namespaces = append(namespaces, namespace)
+	}
+
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "Role",
+			"metadata": map[string]interface{}{
+				"name":      roleName,
+				"namespace": namespace,
+				"labels":    labels,
+			},
+			"rules": persona.namespaceRules[namespace],
+		}})
+	}
+
+	var sessionNamespaces []string
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	if o.Apply && len(persona.sessionRules) != 0 {
+		client, err := clusterConfig.GetClient()
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
+
+		namespaceList, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: "training.educates.dev/component in (environment,session)"})
+
+		if err != nil {
+			return errors.Wrap(err, "unable to list workshop namespaces")
+		}
+
+		for _, item := range namespaceList.Items {
+			sessionNamespaces = append(sessionNamespaces, item.Name)
+		}
+	}
+
+	type subject struct {
+		kind      string
+		name      string
+		namespace string
+	}
+
+	var subjects []subject
+
+	for _, name := range o.Users {
+		subjects = append(subjects, subject{kind: "User", name: name})
+	}
+
+	for _, name := range o.Groups {
+		subjects = append(subjects, subject{kind: "Group", name: name})
+	}
+
+	for _, value := range o.ServiceAccounts {
+		parts := strings.SplitN(value, ":", 2)
+
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return failures.NewValidationError(errors.Errorf("invalid service account %q", value), "service accounts must be given as NAMESPACE:NAME")
+		}
+
+		subjects = append(subjects, subject{kind: "ServiceAccount", name: parts[1], namespace: parts[0]})
+	}
+
+	for _, item := range subjects {
+		entry := map[string]interface{}{
+			"kind": item.kind,
+			"name": item.name,
+		}
+
+		bindingName := rbacBindingName(o.Role, item.kind, item.name)
+
+		if item.kind == "ServiceAccount" {
+			entry["namespace"] = item.namespace
+
+			bindingName = rbacBindingName(o.Role, item.kind, item.namespace+"-"+item.name)
+		} else {
+			entry["apiGroup"] = "rbac.authorization.k8s.io"
+		}
+
+		if errs := validation.IsDNS1123Subdomain(bindingName); len(errs) != 0 {
+			return failures.NewValidationError(errors.Errorf("invalid binding name %q for %s %q: %s", bindingName, strings.ToLower(item.kind), item.name, strings.Join(errs, ", ")), "")
+		}
+
+		objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRoleBinding",
+			"metadata": map[string]interface{}{
+				"name":   bindingName,
+				"labels": labels,
+			},
+			"roleRef": map[string]interface{}{
+				"apiGroup": "rbac.authorization.k8s.io",
+				"kind":     "ClusterRole",
+				"name":     roleName,
+			},
+			"subjects": []interface{}{entry},
+		}})
+
+		for _, namespace := range namespaces {
+			objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "RoleBinding",
+				"metadata": map[string]interface{}{
+					"name":      bindingName,
+					"namespace": namespace,
+					"labels":    labels,
+				},
+				"roleRef": map[string]interface{}{
+					"apiGroup": "rbac.authorization.k8s.io",
+					"kind":     "Role",
+					"name":     roleName,
+				},
+				"subjects": []interface{}{entry},
+			}})
+		}
+
+		for _, namespace := range sessionNamespaces {
+			objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "RoleBinding",
+				"metadata": map[string]interface{}{
+					"name":      bindingName,
+					"namespace": namespace,
+					"labels":    labels,
+				},
+				"roleRef": map[string]interface{}{
+					"apiGroup": "rbac.authorization.k8s.io",
+					"kind":     "ClusterRole",
+					"name":     sessionRoleName,
+				},
+				"subjects": []interface{}{entry},
+			}})
+		}
+	}
+
+	if !o.Apply {
+		for i, object := range objects {
+			data, err := yaml.Marshal(object.Object)
+
+			if err != nil {
+				return errors.Wrap(err, "unable to generate RBAC resources")
+			}
+
+			if i != 0 {
+				fmt.Println("---")
+			}
+
+			fmt.Print(string(data))
+		}
+
+		return nil
+	}
+
+	if err := clusterConfig.ApplyResources(ctx, objects, "educates-cli"); err != nil {
+		return err
+	}
+
+	if len(subjects) == 0 {
+		fmt.Printf("Applied cluster role %s, no subjects were given to bind it to.\n", roleName)
+	} else {
+		fmt.Printf("Applied cluster role %s and bindings for %d subjects.\n", roleName, len(subjects))
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewAdminRbacGenerateCmd() *cobra.Command {
+	var o AdminRbacGenerateOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "generate",
+		Short: "Generate RBAC resources for users of the CLI",
+		Long: `Generate RBAC resources for users of the CLI.
+
+Outputs a cluster role granting just the permissions needed by a type of user
+of the CLI, along with cluster role bindings for the users, groups and service
+accounts given, so they can be given access without being cluster admins. Use
+--apply to apply the resources to the cluster instead of outputting them.
+
+The roles which can be given using --role are:
+
+  author    Deploy workshops to the training portal used by the CLI, and
+            access workshop sessions to check and debug them.
+  trainer   Manage training portals and workshops for classes, look after
+            workshop sessions of attendees, and add secrets for workshops.
+  operator  Manage all Educates resources, and clean up resources left
+            behind. Installing Educates still requires cluster admin.
+
+These roles are not a security boundary against the users granted them. The
+session manager runs with cluster admin access and creates the resources a
+workshop asks for in the namespaces of workshop environments and sessions,
+so anyone who can create or update workshops, which all of the roles can, is
+able to have it create arbitrary resources. Only grant these roles to users
+who would be trusted with cluster admin access.
+
+No role can create or update secret copiers or secret injectors, as these
+could be used to copy any secret in the cluster into a namespace where the
+user can read it. Configuring the authentication, analytics and access code
+settings of a training portal, which are held in secrets copied using secret
+copiers, and restoring secret copiers and injectors from a backup, therefore
+still require cluster admin.
+
+Viewing logs of pods and exec into them is not granted cluster wide, as the
+session manager and training portals run with service accounts which have
+cluster wide access. Instead a separate cluster role is created for access
+to the pods of workshop sessions, which the operator binds, for each of the
+cluster role bindings, in the namespaces it creates for workshop environments
+and sessions. When using --apply, bindings are also added to the namespaces
+of existing workshop environments and sessions. Logs of training portals can
+therefore not be viewed, and only the operator role can view logs of the
+other platform components, in the "educates" namespace.
+
+The trainer and operator roles also have a role and role bindings created in
+the "educates-secrets" namespace, which must exist, for managing the secrets
+added using "educates cluster secrets". Service accounts are given as
+NAMESPACE:NAME. Each binding is named after the role and the user, so users
+can be granted a role separately, and access removed by deleting the bindings
+for them in all namespaces, which are labelled with the role.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVar(
+		&o.Role,
+		"role",
+		"",
+		"type of user to grant access for, one of author, trainer or operator",
+	)
+	c.Flags().StringSliceVar(
+		&o.Users,
+		"user",
+		nil,
+		"name of a user to bind the role to (can be specified multiple times)",
+	)
+	c.Flags().StringSliceVar(
+		&o.Groups,
+		"group",
+		nil,
+		"name of a group to bind the role to (can be specified multiple times)",
+	)
+	c.Flags().StringSliceVar(
+		&o.ServiceAccounts,
+		"service-account",
+		nil,
+		"service account to bind the role to as NAMESPACE:NAME (can be specified multiple times)",
+	)
+	c.Flags().BoolVar(
+		&o.Apply,
+		"apply",
+		false,
+		"apply the resources to the cluster instead of outputting them",
+	)
+
+	c.MarkFlagRequired("role")
+
+	c.RegisterFlagCompletionFunc("role", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{"author", "trainer", "operator"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return withAuditLogging(c)
+}
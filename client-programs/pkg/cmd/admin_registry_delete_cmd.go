@@ -11,8 +11,16 @@ func (p *ProjectInfo) NewAdminRegistryDeleteCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "delete",
 		Short: "Deletes the local image registry",
-		RunE:  func(_ *cobra.Command, _ []string) error { return registry.DeleteRegistry() },
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			err := confirmAction("delete the local image registry")
+
+			if err != nil {
+				return err
+			}
+
+			return registry.DeleteRegistry(cmd.Context())
+		},
 	}
 
-	return c
+	return withAuditLogging(c)
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/pkg/errors"
@@ -14,8 +15,8 @@ type AdminRegistryDeployOptions struct {
 	Kubeconfig string
 }
 
-func (o *AdminRegistryDeployOptions) Run() error {
-	err := registry.DeployRegistry()
+func (o *AdminRegistryDeployOptions) Run(ctx context.Context) error {
+	err := registry.DeployRegistry(ctx)
 
 	if err != nil {
 		return errors.Wrap(err, "failed to deploy registry")
@@ -25,7 +26,7 @@ func (o *AdminRegistryDeployOptions) Run() error {
 	// deploy just the image registry alone without Kubernetes. If a Kubernetes
 	// cluster is created later, then the registry service will be added then.
 
-	err = registry.LinkRegistryToCluster()
+	err = registry.LinkRegistryToCluster(ctx)
 
 	if err != nil {
 		fmt.Println("Warning: Kubernetes cluster not linked to image registry.")
@@ -41,7 +42,7 @@ func (o *AdminRegistryDeployOptions) Run() error {
 		return nil
 	}
 
-	if err = registry.UpdateRegistryService(client); err != nil {
+	if err = registry.UpdateRegistryService(ctx, client); err != nil {
 		return errors.Wrap(err, "failed to create service for registry")
 	}
 
@@ -55,7 +56,7 @@ func (p *ProjectInfo) NewAdminRegistryDeployCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "deploy",
 		Short: "Deploys a local image registry",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -65,5 +66,5 @@ func (p *ProjectInfo) NewAdminRegistryDeployCmd() *cobra.Command {
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
@@ -11,8 +11,8 @@ func (p *ProjectInfo) NewAdminResolverDeleteCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "delete",
 		Short: "Deletes the local DNS resolver",
-		RunE:  func(_ *cobra.Command, _ []string) error { return resolver.DeleteResolver() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return resolver.DeleteResolver(cmd.Context()) },
 	}
 
-	return c
+	return withAuditLogging(c)
 }
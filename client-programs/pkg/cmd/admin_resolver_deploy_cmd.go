@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
@@ -12,7 +14,7 @@ type AdminResolverDeployOptions struct {
 	Domain string
 }
 
-func (o *AdminResolverDeployOptions) Run() error {
+func (o *AdminResolverDeployOptions) Run(ctx context.Context) error {
 	config, err := config.NewInstallationConfigFromFile(o.Config)
 
 	if err != nil {
@@ -23,7 +25,7 @@ func (o *AdminResolverDeployOptions) Run() error {
 		config.ClusterIngress.Domain = o.Domain
 	}
 
-	return resolver.DeployResolver(config.ClusterIngress.Domain, config.LocalDNSResolver.TargetAddress, config.LocalDNSResolver.ExtraDomains)
+	return resolver.DeployResolver(ctx, config.ClusterIngress.Domain, config.LocalDNSResolver.TargetAddress, config.LocalDNSResolver.ExtraDomains)
 }
 
 func (p *ProjectInfo) NewAdminResolverDeployCmd() *cobra.Command {
@@ -33,7 +35,7 @@ func (p *ProjectInfo) NewAdminResolverDeployCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "deploy",
 		Short: "Deploys a local DNS resolver",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -49,5 +51,5 @@ func (p *ProjectInfo) NewAdminResolverDeployCmd() *cobra.Command {
 		"wildcard ingress subdomain name for Educates",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+var deploymentResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+type AdminRestoreOptions struct {
+	Kubeconfig    string
+	File          string
+	Overwrite     bool
+	SkipDatabases bool
+	Timeout       time.Duration
+}
+
+func (o *AdminRestoreOptions) Run(ctx context.Context) error {
+	if o.File == "" {
+		return failures.NewValidationError(errors.New("no backup file provided"), "supply the backup file using `--file`")
+	}
+
+	files, err := readBackupArchive(o.File)
+
+	if err != nil {
+		return err
+	}
+
+	manifestData, found := files["backup.yaml"]
+
+	if !found {
+		return failures.NewValidationError(errors.Errorf("%q is not a backup of the training platform", o.File), "create a backup using `educates admin backup`")
+	}
+
+	manifest := platformBackup{}
+
+	if err = yaml.Unmarshal(manifestData, &manifest); err != nil {
+		return errors.Wrapf(err, "unable to parse manifest from backup %q", o.File)
+	}
+
+	// Restoring the database and secret key for a training portal replaces
+	// those in use by the portal, so confirm before making any changes.
+
+	if !o.SkipDatabases {
+		var portals []string
+
+		for _, portal := range manifest.Portals {
+			if _, found := files[fmt.Sprintf("portals/%s/%s", portal, portalDatabaseFile)]; found {
+				portals = append(portals, portal)
+			}
+		}
+
+		if len(portals) != 0 {
+			if err = confirmAction(fmt.Sprintf("replace the databases of training portals %s", strings.Join(portals, ", "))); err != nil {
+				return err
+			}
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	// Restore resources for each type in turn, in the order they need to
+	// be created.
+
+	var names []string
+
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, resource := range backupResources {
+		prefix := fmt.Sprintf("resources/%s/", resource.Resource)
+
+		resourceClient := dynamicClient.Resource(resource)
+
+		for _, name := range names {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+
+			data := files[name]
+
+			object := &unstructured.Unstructured{}
+
+			if err = yaml.Unmarshal(data, &object.Object); err != nil {
+				return errors.Wrapf(err, "unable to parse %s from backup", name)
+			}
+
+			_, err = resourceClient.Create(ctx, object, metav1.CreateOptions{})
+
+			if k8serrors.IsAlreadyExists(err) {
+				if !o.Overwrite {
+					fmt.Printf("Skipped %s %s as already exists.\n", resource.Resource, object.GetName())
+					continue
+				}
+
+				existing, err := resourceClient.Get(ctx, object.GetName(), metav1.GetOptions{})
+
+				if err != nil {
+					return errors.Wrapf(err, "unable to query %s %q", resource.Resource, object.GetName())
+				}
+
+				object.SetResourceVersion(existing.GetResourceVersion())
+
+				if _, err = resourceClient.Update(ctx, object, metav1.UpdateOptions{}); err != nil {
+					return errors.Wrapf(err, "unable to update %s %q", resource.Resource, object.GetName())
+				}
+
+				fmt.Printf("Updated %s %s.\n", resource.Resource, object.GetName())
+
+				continue
+			}
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to create %s %q", resource.Resource, object.GetName())
+			}
+
+			fmt.Printf("Created %s %s.\n", resource.Resource, object.GetName())
+		}
+	}
+
+	if o.SkipDatabases {
+		return nil
+	}
+
+	for _, portal := range manifest.Portals {
+		database, found := files[fmt.Sprintf("portals/%s/%s", portal, portalDatabaseFile)]
+
+		if !found {
+			continue
+		}
+
+		fmt.Printf("Restoring database for training portal %s ...\n", portal)
+
+		// Wait for the training portal to be deployed so that the volume
+		// holding the database exists.
+
+		namespace := portal + "-ui"
+
+		err = cluster.WaitForResource(ctx, dynamicClient.Resource(deploymentResource).Namespace(namespace), "training-portal", o.Timeout, func(resource *unstructured.Unstructured) (bool, error) {
+			ready, _, _ := unstructured.NestedInt64(resource.Object, "status", "readyReplicas")
+			return ready > 0, nil
+		})
+
+		if err == wait.ErrWaitTimeout {
+			return failures.NewTimeoutError(errors.Errorf("timed out waiting for training portal %q to be deployed", portal), "check the status of the training portal with `educates cluster portal list`")
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to wait for training portal %q", portal)
+		}
+
+		pod, err := trainingPortalPod(ctx, client, portal)
+
+		if err != nil {
+			return err
+		}
+
+		restore := func(name string, data []byte) error {
+			path := fmt.Sprintf("%s/%s", portalDataDirectory, name)
+			command := []string{"sh", "-c", fmt.Sprintf("cat > %s.restore && mv -f %s.restore %s", path, path, path)}
+
+			return clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, "portal", command, bytes.NewReader(data), io.Discard)
+		}
+
+		if err = restore(portalDatabaseFile, database); err != nil {
+			return errors.Wrapf(err, "unable to restore database for training portal %q", portal)
+		}
+
+		if secretKey, found := files[fmt.Sprintf("portals/%s/%s", portal, portalSecretKeyFile)]; found {
+			if err = restore(portalSecretKeyFile, secretKey); err != nil {
+				return errors.Wrapf(err, "unable to restore secret key for training portal %q", portal)
+			}
+		}
+
+		// Restart the training portal so the restored database is used.
+
+		if err = client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
+			return errors.Wrapf(err, "unable to restart training portal %q", portal)
+		}
+	}
+
+	return nil
+}
+
+func readBackupArchive(path string) (map[string][]byte, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open backup %q", path)
+	}
+
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read backup %q", path)
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+
+	files := map[string][]byte{}
+
+	for {
+		header, err := tarReader.Next()
+
+		if err == io.EOF {
+			return files, nil
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read backup %q", path)
+		}
+
+		data, err := io.ReadAll(tarReader)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read backup %q", path)
+		}
+
+		files[header.Name] = data
+	}
+}
+
+func (p *ProjectInfo) NewAdminRestoreCmd() *cobra.Command {
+	var o AdminRestoreOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "restore",
+		Short: "Restore state of the training platform",
+		Long: `Restore state of the training platform.
+
+Restores the workshop definitions, training portals and secret configuration
+saved by "educates admin backup" onto a cluster. Resources which already
+exist are skipped unless overwriting is requested. Once each training portal
+has been deployed, its database is replaced with the one from the backup and
+the training portal restarted. Confirmation is asked for before restoring
+unless --yes is given, as the existing databases are lost.
+
+Workshop sessions active at the time of the backup are not recreated.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.File,
+		"file",
+		"f",
+		"",
+		"path to the backup file to restore",
+	)
+	c.Flags().BoolVar(
+		&o.Overwrite,
+		"overwrite",
+		false,
+		"replace resources which already exist in the cluster",
+	)
+	c.Flags().BoolVar(
+		&o.SkipDatabases,
+		"skip-databases",
+		false,
+		"don't restore the databases of training portals",
+	)
+	c.Flags().DurationVar(
+		&o.Timeout,
+		"timeout",
+		10*time.Minute,
+		"maximum time to wait for each training portal to be deployed",
+	)
+
+	return withAuditLogging(c)
+}
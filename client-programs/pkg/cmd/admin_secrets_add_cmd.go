@@ -5,8 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/adrg/xdg"
 	"github.com/pkg/errors"
@@ -15,6 +16,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubectl/pkg/util/templates"
 	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 )
 
 func (p *ProjectInfo) NewAdminSecretsAddCmdGroup() *cobra.Command {
@@ -34,7 +38,9 @@ func (p *ProjectInfo) NewAdminSecretsAddCmdGroup() *cobra.Command {
 			Commands: []*cobra.Command{
 				p.NewAdminSecretsAddCaCmd(),
 				p.NewAdminSecretsAddDockerRegistryCmd(),
-				// NewAdminSecretsAddGenericCmd(),
+				p.NewAdminSecretsAddGenericCmd(),
+				p.NewAdminSecretsAddGitCmd(),
+				p.NewAdminSecretsAddSshCmd(),
 				p.NewAdminSecretsAddTlsCmd(),
 			},
 		},
@@ -62,7 +68,7 @@ func (o *AdminSecretsAddTlsOptions) Run(name string) error {
 	}
 
 	if !matched {
-		return errors.New("invalid secret name")
+		return failures.NewValidationError(errors.New("invalid secret name"), "secret names must be valid Kubernetes resource names")
 	}
 
 	var certificateFileData []byte
@@ -112,8 +118,8 @@ func (o *AdminSecretsAddTlsOptions) Run(name string) error {
 		return errors.Wrap(err, "failed to generate YAML data")
 	}
 
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	secretsCacheDir := path.Join(configFileDir, "secrets")
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	secretsCacheDir := filepath.Join(configFileDir, "secrets")
 
 	err = os.MkdirAll(secretsCacheDir, os.ModePerm)
 
@@ -121,7 +127,7 @@ func (o *AdminSecretsAddTlsOptions) Run(name string) error {
 		return errors.Wrapf(err, "unable to create secrets cache directory")
 	}
 
-	secretFilePath := path.Join(secretsCacheDir, name+".yaml")
+	secretFilePath := filepath.Join(secretsCacheDir, name+".yaml")
 
 	secretFile, err := os.OpenFile(secretFilePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
 
@@ -171,7 +177,7 @@ func (p *ProjectInfo) NewAdminSecretsAddTlsCmd() *cobra.Command {
 
 	c.MarkFlagsRequiredTogether("cert", "key")
 
-	return c
+	return withAuditLogging(c)
 }
 
 type AdminSecretsAddCaOptions struct {
@@ -188,7 +194,7 @@ func (o *AdminSecretsAddCaOptions) Run(name string) error {
 	}
 
 	if !matched {
-		return errors.New("invalid secret name")
+		return failures.NewValidationError(errors.New("invalid secret name"), "secret names must be valid Kubernetes resource names")
 	}
 
 	var certificateFileData []byte
@@ -228,8 +234,8 @@ func (o *AdminSecretsAddCaOptions) Run(name string) error {
 		return errors.Wrap(err, "failed to generate YAML data")
 	}
 
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	secretsCacheDir := path.Join(configFileDir, "secrets")
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	secretsCacheDir := filepath.Join(configFileDir, "secrets")
 
 	err = os.MkdirAll(secretsCacheDir, os.ModePerm)
 
@@ -237,7 +243,7 @@ func (o *AdminSecretsAddCaOptions) Run(name string) error {
 		return errors.Wrapf(err, "unable to create secrets cache directory")
 	}
 
-	secretFilePath := path.Join(secretsCacheDir, name+".yaml")
+	secretFilePath := filepath.Join(secretsCacheDir, name+".yaml")
 
 	secretFile, err := os.OpenFile(secretFilePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
 
@@ -281,7 +287,7 @@ func (p *ProjectInfo) NewAdminSecretsAddCaCmd() *cobra.Command {
 
 	c.MarkFlagsRequiredTogether("cert")
 
-	return c
+	return withAuditLogging(c)
 }
 
 type AdminSecretsAddDockerRegistryOptions struct {
@@ -300,7 +306,7 @@ func (o *AdminSecretsAddDockerRegistryOptions) Run(name string) error {
 	}
 
 	if !matched {
-		return errors.New("invalid secret name")
+		return failures.NewValidationError(errors.New("invalid secret name"), "secret names must be valid Kubernetes resource names")
 	}
 
 	authString := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", o.Username, o.Password)))
@@ -328,44 +334,7 @@ func (o *AdminSecretsAddDockerRegistryOptions) Run(name string) error {
 		},
 	}
 
-	secretData, err := json.MarshalIndent(&secret, "", "    ")
-
-	if err != nil {
-		return errors.Wrap(err, "failed to generate secret data")
-	}
-
-	secretData, err = yaml.JSONToYAML(secretData)
-
-	if err != nil {
-		return errors.Wrap(err, "failed to generate YAML data")
-	}
-
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	secretsCacheDir := path.Join(configFileDir, "secrets")
-
-	err = os.MkdirAll(secretsCacheDir, os.ModePerm)
-
-	if err != nil {
-		return errors.Wrapf(err, "unable to create secrets cache directory")
-	}
-
-	secretFilePath := path.Join(secretsCacheDir, name+".yaml")
-
-	secretFile, err := os.OpenFile(secretFilePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
-
-	if err != nil {
-		return errors.Wrapf(err, "unable to create secret file %s", secretFile)
-	}
-
-	if _, err = secretFile.Write(secretData); err != nil {
-		return errors.Wrapf(err, "unable to write secret file %s", secretFile)
-	}
-
-	if err := secretFile.Close(); err != nil {
-		return errors.Wrapf(err, "unable to close secret file %s", secretFile)
-	}
-
-	return nil
+	return writeCachedSecret(secret)
 }
 
 func (p *ProjectInfo) NewAdminSecretsAddDockerRegistryCmd() *cobra.Command {
@@ -405,7 +374,7 @@ func (p *ProjectInfo) NewAdminSecretsAddDockerRegistryCmd() *cobra.Command {
 
 	c.MarkFlagsRequiredTogether("docker-username", "docker-password", "docker-email")
 
-	return c
+	return withAuditLogging(c)
 }
 
 type AdminSecretsAddGenericOptions struct {
@@ -414,7 +383,95 @@ type AdminSecretsAddGenericOptions struct {
 }
 
 func (o *AdminSecretsAddGenericOptions) Run(name string) error {
-	return nil
+	var err error
+	var matched bool
+
+	if matched, err = regexp.MatchString("^[a-z0-9]([.a-z0-9-]+)?[a-z0-9]$", name); err != nil {
+		return errors.Wrapf(err, "regex match on secret name failed")
+	}
+
+	if !matched {
+		return failures.NewValidationError(errors.New("invalid secret name"), "secret names must be valid Kubernetes resource names")
+	}
+
+	secretData := map[string][]byte{}
+
+	for _, source := range o.LiteralSources {
+		key, value, found := strings.Cut(source, "=")
+
+		if !found || key == "" {
+			return failures.NewValidationError(errors.Errorf("invalid literal source %q", source), "literal sources must be given as key=value")
+		}
+
+		secretData[key] = []byte(value)
+	}
+
+	for _, source := range o.FileSources {
+		key, filePath, found := strings.Cut(source, "=")
+
+		if !found {
+			key = ""
+			filePath = source
+		}
+
+		fileInfo, err := os.Stat(filePath)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to access file source %q", filePath)
+		}
+
+		if fileInfo.IsDir() {
+			if key != "" {
+				return failures.NewValidationError(errors.Errorf("cannot give key name for directory %q", filePath), "")
+			}
+
+			files, err := os.ReadDir(filePath)
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to read directory %q", filePath)
+			}
+
+			for _, f := range files {
+				if !f.Type().IsRegular() {
+					continue
+				}
+
+				data, err := os.ReadFile(filepath.Join(filePath, f.Name()))
+
+				if err != nil {
+					return errors.Wrapf(err, "unable to read file %q", f.Name())
+				}
+
+				secretData[f.Name()] = data
+			}
+		} else {
+			if key == "" {
+				key = filepath.Base(filePath)
+			}
+
+			data, err := os.ReadFile(filePath)
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to read file %q", filePath)
+			}
+
+			secretData[key] = data
+		}
+	}
+
+	if len(secretData) == 0 {
+		return failures.NewValidationError(errors.New("no secret data supplied"), "use --from-file or --from-literal to supply secret data")
+	}
+
+	secret := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Type: "Opaque",
+		Data: secretData,
+	}
+
+	return writeCachedSecret(secret)
 }
 
 func (p *ProjectInfo) NewAdminSecretsAddGenericCmd() *cobra.Command {
@@ -440,5 +497,215 @@ func (p *ProjectInfo) NewAdminSecretsAddGenericCmd() *cobra.Command {
 		"Specify a key and literal value to insert in secret (i.e. mykey=somevalue)",
 	)
 
-	return c
+	return withAuditLogging(c)
+}
+
+type AdminSecretsAddGitOptions struct {
+	Url      string
+	Username string
+	Token    string
+}
+
+func (o *AdminSecretsAddGitOptions) Run(name string) error {
+	var err error
+	var matched bool
+
+	if matched, err = regexp.MatchString("^[a-z0-9]([.a-z0-9-]+)?[a-z0-9]$", name); err != nil {
+		return errors.Wrapf(err, "regex match on secret name failed")
+	}
+
+	if !matched {
+		return failures.NewValidationError(errors.New("invalid secret name"), "secret names must be valid Kubernetes resource names")
+	}
+
+	username := o.Username
+
+	if username == "" {
+		// Git hosting services generally accept any username when a token
+		// is being used for authentication.
+
+		username = "git"
+	}
+
+	secret := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				"training.educates.dev/url": o.Url,
+			},
+		},
+		Type: "kubernetes.io/basic-auth",
+		Data: map[string][]byte{
+			"username": []byte(username),
+			"password": []byte(o.Token),
+		},
+	}
+
+	return writeCachedSecret(secret)
+}
+
+func (p *ProjectInfo) NewAdminSecretsAddGitCmd() *cobra.Command {
+	var o AdminSecretsAddGitOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "git NAME",
+		Short: "Create a secret holding an access token for a Git server",
+		RunE:  func(_ *cobra.Command, args []string) error { return o.Run(args[0]) },
+	}
+
+	c.Flags().StringVar(
+		&o.Url,
+		"git-url",
+		"https://github.com",
+		"URL of the Git server the access token is for",
+	)
+	c.Flags().StringVar(
+		&o.Username,
+		"git-username",
+		"",
+		"username for Git server authentication",
+	)
+	c.Flags().StringVar(
+		&o.Token,
+		"git-token",
+		"",
+		"access token for Git server authentication",
+	)
+
+	c.MarkFlagRequired("git-token")
+
+	return withAuditLogging(c)
+}
+
+type AdminSecretsAddSshOptions struct {
+	PrivateKeyFile string
+	KnownHostsFile string
+}
+
+func (o *AdminSecretsAddSshOptions) Run(name string) error {
+	var err error
+	var matched bool
+
+	if matched, err = regexp.MatchString("^[a-z0-9]([.a-z0-9-]+)?[a-z0-9]$", name); err != nil {
+		return errors.Wrapf(err, "regex match on secret name failed")
+	}
+
+	if !matched {
+		return failures.NewValidationError(errors.New("invalid secret name"), "secret names must be valid Kubernetes resource names")
+	}
+
+	privateKey, err := os.ReadFile(o.PrivateKeyFile)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to read SSH key file %q", o.PrivateKeyFile)
+	}
+
+	secretData := map[string][]byte{
+		"ssh-privatekey": privateKey,
+	}
+
+	if o.KnownHostsFile != "" {
+		knownHosts, err := os.ReadFile(o.KnownHostsFile)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read SSH known hosts file %q", o.KnownHostsFile)
+		}
+
+		secretData["ssh-knownhosts"] = knownHosts
+	}
+
+	secret := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Type: "kubernetes.io/ssh-auth",
+		Data: secretData,
+	}
+
+	return writeCachedSecret(secret)
+}
+
+func (p *ProjectInfo) NewAdminSecretsAddSshCmd() *cobra.Command {
+	var o AdminSecretsAddSshOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "ssh NAME",
+		Short: "Create a secret holding an SSH private key for a Git server",
+		RunE:  func(_ *cobra.Command, args []string) error { return o.Run(args[0]) },
+	}
+
+	c.Flags().StringVar(
+		&o.PrivateKeyFile,
+		"ssh-privatekey",
+		"",
+		"path to SSH private key file",
+	)
+	c.Flags().StringVar(
+		&o.KnownHostsFile,
+		"ssh-knownhosts",
+		"",
+		"path to SSH known hosts file for verifying the server",
+	)
+
+	c.MarkFlagRequired("ssh-privatekey")
+
+	return withAuditLogging(c)
+}
+
+/*
+Write a secret to the local secrets cache, replacing any existing secret of
+the same name. If a credentials backend is configured the data of the secret
+is stored there instead.
+*/
+func writeCachedSecret(secret *apiv1.Secret) error {
+	store, err := config.OpenCredentialsStore()
+
+	if err != nil {
+		return err
+	}
+
+	if store != nil && isCredentialSecret(secret) {
+		secret = secret.DeepCopy()
+
+		if err = storeSecretCredentials(store, secret); err != nil {
+			return err
+		}
+	}
+
+	return writeCachedSecretFile(secret)
+}
+
+func writeCachedSecretFile(secret *apiv1.Secret) error {
+	secretData, err := json.MarshalIndent(secret, "", "    ")
+
+	if err != nil {
+		return errors.Wrap(err, "failed to generate secret data")
+	}
+
+	secretData, err = yaml.JSONToYAML(secretData)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to generate YAML data")
+	}
+
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	secretsCacheDir := filepath.Join(configFileDir, "secrets")
+
+	err = os.MkdirAll(secretsCacheDir, os.ModePerm)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create secrets cache directory")
+	}
+
+	secretFilePath := filepath.Join(secretsCacheDir, secret.ObjectMeta.Name+".yaml")
+
+	err = os.WriteFile(secretFilePath, secretData, 0o600)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write secret file %s", secretFilePath)
+	}
+
+	return nil
 }
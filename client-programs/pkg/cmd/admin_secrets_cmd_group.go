@@ -3,7 +3,7 @@ package cmd
 import (
 	"context"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/adrg/xdg"
@@ -52,8 +52,8 @@ func (p *ProjectInfo) NewAdminSecretsCmdGroup() *cobra.Command {
 }
 
 func CachedSecretForIngressDomain(domain string) string {
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	secretsCacheDir := path.Join(configFileDir, "secrets")
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	secretsCacheDir := filepath.Join(configFileDir, "secrets")
 
 	files, err := os.ReadDir(secretsCacheDir)
 
@@ -64,7 +64,7 @@ func CachedSecretForIngressDomain(domain string) string {
 	for _, f := range files {
 		if strings.HasSuffix(f.Name(), ".yaml") {
 			name := strings.TrimSuffix(f.Name(), ".yaml")
-			fullPath := path.Join(secretsCacheDir, f.Name())
+			fullPath := filepath.Join(secretsCacheDir, f.Name())
 
 			yamlData, err := os.ReadFile(fullPath)
 
@@ -119,8 +119,8 @@ func CachedSecretForIngressDomain(domain string) string {
 }
 
 func CachedSecretForCertificateAuthority(domain string) string {
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	secretsCacheDir := path.Join(configFileDir, "secrets")
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	secretsCacheDir := filepath.Join(configFileDir, "secrets")
 
 	files, err := os.ReadDir(secretsCacheDir)
 
@@ -131,7 +131,7 @@ func CachedSecretForCertificateAuthority(domain string) string {
 	for _, f := range files {
 		if strings.HasSuffix(f.Name(), ".yaml") {
 			name := strings.TrimSuffix(f.Name(), ".yaml")
-			fullPath := path.Join(secretsCacheDir, f.Name())
+			fullPath := filepath.Join(secretsCacheDir, f.Name())
 
 			yamlData, err := os.ReadFile(fullPath)
 
@@ -181,9 +181,9 @@ func CachedSecretForCertificateAuthority(domain string) string {
 	return ""
 }
 
-func SyncSecretsToCluster(client *kubernetes.Clientset) error {
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	secretsCacheDir := path.Join(configFileDir, "secrets")
+func SyncSecretsToCluster(ctx context.Context, client *kubernetes.Clientset) error {
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	secretsCacheDir := filepath.Join(configFileDir, "secrets")
 
 	err := os.MkdirAll(secretsCacheDir, os.ModePerm)
 
@@ -193,7 +193,7 @@ func SyncSecretsToCluster(client *kubernetes.Clientset) error {
 
 	namespacesClient := client.CoreV1().Namespaces()
 
-	_, err = namespacesClient.Get(context.TODO(), "educates-secrets", metav1.GetOptions{})
+	_, err = namespacesClient.Get(ctx, "educates-secrets", metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
 		namespaceObj := apiv1.Namespace{
@@ -202,7 +202,7 @@ func SyncSecretsToCluster(client *kubernetes.Clientset) error {
 			},
 		}
 
-		namespacesClient.Create(context.TODO(), &namespaceObj, metav1.CreateOptions{})
+		namespacesClient.Create(ctx, &namespaceObj, metav1.CreateOptions{})
 	}
 
 	secretsClient := client.CoreV1().Secrets("educates-secrets")
@@ -216,7 +216,7 @@ func SyncSecretsToCluster(client *kubernetes.Clientset) error {
 	for _, f := range files {
 		if strings.HasSuffix(f.Name(), ".yaml") {
 			name := strings.TrimSuffix(f.Name(), ".yaml")
-			fullPath := path.Join(secretsCacheDir, f.Name())
+			fullPath := filepath.Join(secretsCacheDir, f.Name())
 
 			yamlData, err := os.ReadFile(fullPath)
 
@@ -232,15 +232,19 @@ func SyncSecretsToCluster(client *kubernetes.Clientset) error {
 				return errors.Wrapf(err, "unable to read secret file %q", fullPath)
 			}
 
+			if err = resolveSecretCredentials(secretObj); err != nil {
+				return err
+			}
+
 			secretObj.ObjectMeta.Namespace = ""
 
-			_, err = secretsClient.Get(context.TODO(), name, metav1.GetOptions{})
+			_, err = secretsClient.Get(ctx, name, metav1.GetOptions{})
 
 			if err != nil {
 				if !k8serrors.IsNotFound(err) {
 					return errors.Wrap(err, "unable to read secrets from cluster")
 				} else {
-					_, err = secretsClient.Create(context.TODO(), secretObj, metav1.CreateOptions{})
+					_, err = secretsClient.Create(ctx, secretObj, metav1.CreateOptions{})
 
 					if err != nil {
 						return errors.Wrapf(err, "unable to copy secret to cluster %q", name)
@@ -255,7 +259,7 @@ func SyncSecretsToCluster(client *kubernetes.Clientset) error {
 					patch = applycorev1.Secret(name, "educates-secrets").WithType(secretObj.Type).WithData(secretObj.Data)
 				}
 
-				_, err = secretsClient.Apply(context.TODO(), patch, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
+				_, err = secretsClient.Apply(ctx, patch, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
 
 				if err != nil {
 					return errors.Wrapf(err, "unable to update secret in cluster %q", name)
@@ -3,7 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/adrg/xdg"
@@ -18,8 +18,8 @@ func (p *ProjectInfo) NewAdminSecretsExportCmd() *cobra.Command {
 		Use:   "export [NAME]",
 		Short: "Export secrets in the cache",
 		RunE: func(_ *cobra.Command, args []string) error {
-			configFileDir := path.Join(xdg.DataHome, "educates")
-			secretsCacheDir := path.Join(configFileDir, "secrets")
+			configFileDir := filepath.Join(xdg.DataHome, "educates")
+			secretsCacheDir := filepath.Join(configFileDir, "secrets")
 
 			err := os.MkdirAll(secretsCacheDir, os.ModePerm)
 
@@ -38,7 +38,7 @@ func (p *ProjectInfo) NewAdminSecretsExportCmd() *cobra.Command {
 			for _, f := range files {
 				if strings.HasSuffix(f.Name(), ".yaml") {
 					name := strings.TrimSuffix(f.Name(), ".yaml")
-					fullPath := path.Join(secretsCacheDir, f.Name())
+					fullPath := filepath.Join(secretsCacheDir, f.Name())
 
 					if len(args) == 0 || slices.Contains(args, name) {
 						yamlData, err := os.ReadFile(fullPath)
@@ -51,6 +51,16 @@ func (p *ProjectInfo) NewAdminSecretsExportCmd() *cobra.Command {
 							continue
 						}
 
+						// Include the data of the secret if it is held in
+						// the credentials backend, so the exported secret
+						// can be imported elsewhere.
+
+						if strings.Contains(string(yamlData), credentialsRefAnnotation) {
+							if yamlData, err = resolveCachedSecretFile(yamlData); err != nil {
+								return err
+							}
+						}
+
 						if count != 0 {
 							fmt.Println("---")
 						}
@@ -3,7 +3,7 @@ package cmd
 import (
 	"encoding/json"
 	"os"
-	"path"
+	"path/filepath"
 	"regexp"
 	"syscall"
 
@@ -22,8 +22,8 @@ type AdminSecretsImportOptions struct {
 }
 
 func (o *AdminSecretsImportOptions) Run() error {
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	secretsCacheDir := path.Join(configFileDir, "secrets")
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	secretsCacheDir := filepath.Join(configFileDir, "secrets")
 
 	err := os.MkdirAll(secretsCacheDir, os.ModePerm)
 
@@ -56,7 +56,7 @@ func (o *AdminSecretsImportOptions) Run() error {
 		// if it does remove it.
 
 		name := secretObj.ObjectMeta.Name + ".yaml"
-		secretFilePath := path.Join(secretsCacheDir, name)
+		secretFilePath := filepath.Join(secretsCacheDir, name)
 		secretFilePathTmp := secretFilePath + ".tmp"
 
 		err = os.Remove(secretFilePathTmp)
@@ -126,5 +126,5 @@ func (p *ProjectInfo) NewAdminSecretsImportCmd() *cobra.Command {
 
 	c.MarkFlagRequired("file")
 
-	return c
+	return withAuditLogging(c)
 }
@@ -3,7 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/adrg/xdg"
@@ -17,8 +17,8 @@ func (p *ProjectInfo) NewAdminSecretsListCmd() *cobra.Command {
 		Use:   "list",
 		Short: "List secrets in the cache",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			configFileDir := path.Join(xdg.DataHome, "educates")
-			secretsCacheDir := path.Join(configFileDir, "secrets")
+			configFileDir := filepath.Join(xdg.DataHome, "educates")
+			secretsCacheDir := filepath.Join(configFileDir, "secrets")
 
 			err := os.MkdirAll(secretsCacheDir, os.ModePerm)
 
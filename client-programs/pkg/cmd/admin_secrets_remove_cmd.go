@@ -2,12 +2,14 @@ package cmd
 
 import (
 	"os"
-	"path"
+	"path/filepath"
 	"regexp"
 
 	"github.com/adrg/xdg"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 )
 
 func (p *ProjectInfo) NewAdminSecretsRemoveCmd() *cobra.Command {
@@ -26,19 +28,19 @@ func (p *ProjectInfo) NewAdminSecretsRemoveCmd() *cobra.Command {
 			}
 
 			if !matched {
-				return errors.Errorf("invalid secret name %q", name)
+				return failures.NewValidationError(errors.Errorf("invalid secret name %q", name), "")
 			}
 
-			configFileDir := path.Join(xdg.DataHome, "educates")
-			secretsCacheDir := path.Join(configFileDir, "secrets")
+			configFileDir := filepath.Join(xdg.DataHome, "educates")
+			secretsCacheDir := filepath.Join(configFileDir, "secrets")
 
-			secretFilePath := path.Join(secretsCacheDir, name+".yaml")
+			secretFilePath := filepath.Join(secretsCacheDir, name+".yaml")
 
 			os.Remove(secretFilePath)
 
-			return nil
+			return deleteSecretCredentials(name)
 		},
 	}
 
-	return c
+	return withAuditLogging(c)
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
@@ -10,7 +12,7 @@ type AdminSecretsSyncOptions struct {
 	Kubeconfig string
 }
 
-func (o *AdminSecretsSyncOptions) Run() error {
+func (o *AdminSecretsSyncOptions) Run(ctx context.Context) error {
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	client, err := clusterConfig.GetClient()
@@ -19,7 +21,7 @@ func (o *AdminSecretsSyncOptions) Run() error {
 		return errors.Wrapf(err, "unable to create Kubernetes client")
 	}
 
-	return SyncSecretsToCluster(client)
+	return SyncSecretsToCluster(ctx, client)
 }
 
 func (p *ProjectInfo) NewAdminSecretsSyncCmd() *cobra.Command {
@@ -29,7 +31,7 @@ func (p *ProjectInfo) NewAdminSecretsSyncCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "sync",
 		Short: "Copy secrets to cluster",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -39,5 +41,5 @@ func (p *ProjectInfo) NewAdminSecretsSyncCmd() *cobra.Command {
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 	"gopkg.in/yaml.v2"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -48,7 +49,7 @@ type AdminServicesConfigViewOptions struct {
 	Kubeconfig string
 }
 
-func (o *AdminServicesConfigViewOptions) Run() error {
+func (o *AdminServicesConfigViewOptions) Run(ctx context.Context) error {
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	client, err := clusterConfig.GetClient()
@@ -59,7 +60,7 @@ func (o *AdminServicesConfigViewOptions) Run() error {
 
 	secretsClient := client.CoreV1().Secrets("educates-package")
 
-	valuesSecret, err := secretsClient.Get(context.TODO(), "educates-cluster-essentials-values", metav1.GetOptions{})
+	valuesSecret, err := secretsClient.Get(ctx, "educates-cluster-essentials-values", metav1.GetOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "services not deployed")
@@ -68,7 +69,7 @@ func (o *AdminServicesConfigViewOptions) Run() error {
 	valuesData, ok := valuesSecret.Data["values.yml"]
 
 	if !ok {
-		return errors.New("no services configuration found")
+		return failures.NewNotFoundError(errors.New("no services configuration found"), "are the cluster services installed? run `educates admin services deploy`")
 	}
 
 	fmt.Print(string(valuesData))
@@ -83,7 +84,7 @@ func (p *ProjectInfo) NewAdminServicesConfigViewCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "view",
 		Short: "View services configuration",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -102,7 +103,7 @@ type AdminServicesConfigUpdateOptions struct {
 	Kubeconfig string
 }
 
-func (o *AdminServicesConfigUpdateOptions) Run() error {
+func (o *AdminServicesConfigUpdateOptions) Run(ctx context.Context) error {
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	client, err := clusterConfig.GetClient()
@@ -113,7 +114,7 @@ func (o *AdminServicesConfigUpdateOptions) Run() error {
 
 	secretsClient := client.CoreV1().Secrets("educates-package")
 
-	valuesSecret, err := secretsClient.Get(context.TODO(), "educates-cluster-essentials-values", metav1.GetOptions{})
+	valuesSecret, err := secretsClient.Get(ctx, "educates-cluster-essentials-values", metav1.GetOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "services not deployed")
@@ -122,7 +123,7 @@ func (o *AdminServicesConfigUpdateOptions) Run() error {
 	valuesData, ok := valuesSecret.Data["values.yml"]
 
 	if !ok {
-		return errors.New("no services configuration found")
+		return failures.NewNotFoundError(errors.New("no services configuration found"), "are the cluster services installed? run `educates admin services deploy`")
 	}
 
 	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
@@ -161,7 +162,7 @@ func (o *AdminServicesConfigUpdateOptions) Run() error {
 
 	patch := applycorev1.Secret("educates-cluster-essentials-values", "educates-package").WithType(secretObj.Type).WithData(secretObj.Data)
 
-	_, err = secretsClient.Apply(context.TODO(), patch, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
+	_, err = secretsClient.Apply(ctx, patch, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
 
 	if err != nil {
 		return errors.Wrapf(err, "unable to update services configuration")
@@ -190,7 +191,7 @@ func (o *AdminServicesConfigUpdateOptions) Run() error {
 			return errors.Wrapf(err, "unable to create patch for deployment")
 		}
 
-		_, err = appResourceClient.Patch(context.TODO(), "educates-cluster-essentials", types.JSONPatchType, patchJSON, metav1.PatchOptions{})
+		_, err = appResourceClient.Patch(ctx, "educates-cluster-essentials", types.JSONPatchType, patchJSON, metav1.PatchOptions{})
 
 		if err != nil {
 			return errors.Wrapf(err, "unable to pause reconcilation")
@@ -209,7 +210,7 @@ func (o *AdminServicesConfigUpdateOptions) Run() error {
 			return errors.Wrapf(err, "unable to create patch for deployment")
 		}
 
-		_, err = appResourceClient.Patch(context.TODO(), "educates-cluster-essentials", types.JSONPatchType, patchJSON, metav1.PatchOptions{})
+		_, err = appResourceClient.Patch(ctx, "educates-cluster-essentials", types.JSONPatchType, patchJSON, metav1.PatchOptions{})
 
 		if err != nil {
 			return errors.Wrapf(err, "unable to resume reconcilation")
@@ -226,7 +227,7 @@ func (p *ProjectInfo) NewAdminServicesConfigUpdateCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "update",
 		Short: "Update services configuration",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -248,5 +249,5 @@ func (p *ProjectInfo) NewAdminServicesConfigUpdateCmd() *cobra.Command {
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
@@ -13,7 +15,7 @@ type AdminServicesDeleteOptions struct {
 	Kubeconfig string
 }
 
-func (o *AdminServicesDeleteOptions) Run() error {
+func (o *AdminServicesDeleteOptions) Run(ctx context.Context) error {
 	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
 
 	if err != nil {
@@ -28,7 +30,7 @@ func (o *AdminServicesDeleteOptions) Run() error {
 		ClusterSecurity:       fullConfig.ClusterSecurity,
 	}
 
-	return services.DeleteServices(clusterConfig, &servicesConfig)
+	return services.DeleteServices(ctx, clusterConfig, &servicesConfig)
 }
 
 func (p *ProjectInfo) NewAdminServicesDeleteCmd() *cobra.Command {
@@ -38,7 +40,7 @@ func (p *ProjectInfo) NewAdminServicesDeleteCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "delete",
 		Short: "Delete cluster services",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -54,5 +56,5 @@ func (p *ProjectInfo) NewAdminServicesDeleteCmd() *cobra.Command {
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
@@ -1,21 +1,32 @@
 package cmd
 
 import (
+	"context"
+	"os"
+
 	"github.com/spf13/cobra"
+	imgpkgcmd "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/cmd"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/preflight"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/services"
 )
 
 type AdminServicesDeployOptions struct {
-	Config     string
-	Kubeconfig string
-	Provider   string
-	Version    string
+	Config            string
+	Kubeconfig        string
+	Provider          string
+	IngressController string
+	IngressClass      string
+	Version           string
+	FromBundle        string
+	BundleRepository  string
+	SkipPreflight     bool
+	RegistryFlags     imgpkgcmd.RegistryFlags
 }
 
-func (o *AdminServicesDeployOptions) Run() error {
+func (o *AdminServicesDeployOptions) Run(ctx context.Context) error {
 	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
 
 	if err != nil {
@@ -24,6 +35,10 @@ func (o *AdminServicesDeployOptions) Run() error {
 
 	fullConfig.ClusterInfrastructure.Provider = o.Provider
 
+	if err = applyIngressController(fullConfig, o.IngressController, o.IngressClass); err != nil {
+		return err
+	}
+
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	servicesConfig := config.ClusterEssentialsConfig{
@@ -32,7 +47,19 @@ func (o *AdminServicesDeployOptions) Run() error {
 		ClusterSecurity:       fullConfig.ClusterSecurity,
 	}
 
-	return services.DeployServices(o.Version, clusterConfig, &servicesConfig)
+	packageRepository, version, err := resolvePackageRepository(o.FromBundle, o.BundleRepository, o.Version, o.RegistryFlags, "educates-cluster-essentials")
+
+	if err != nil {
+		return err
+	}
+
+	if !o.SkipPreflight {
+		if err = runPreflightChecks(ctx, os.Stderr, clusterConfig, fullConfig, preflight.ServicesChecks()); err != nil {
+			return err
+		}
+	}
+
+	return services.DeployServices(ctx, version, packageRepository, clusterConfig, &servicesConfig)
 }
 
 func (p *ProjectInfo) NewAdminServicesDeployCmd() *cobra.Command {
@@ -42,7 +69,7 @@ func (p *ProjectInfo) NewAdminServicesDeployCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "deploy",
 		Short: "Deploy cluster services",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -69,8 +96,31 @@ func (p *ProjectInfo) NewAdminServicesDeployCmd() *cobra.Command {
 		p.Version,
 		"version to be installed",
 	)
+	c.Flags().StringVar(
+		&o.FromBundle,
+		"from-bundle",
+		"",
+		"path to platform bundle to install from when in a disconnected environment",
+	)
+	c.Flags().StringVar(
+		&o.BundleRepository,
+		"bundle-repository",
+		"",
+		"image repository reachable from the cluster to relocate the bundle to",
+	)
+
+	addIngressControllerFlags(c, &o.IngressController, &o.IngressClass)
+
+	c.Flags().BoolVar(
+		&o.SkipPreflight,
+		"skip-preflight",
+		false,
+		"skip pre-flight checks made before deploying",
+	)
+
+	addBundleRegistryFlags(c, &o.RegistryFlags)
 
 	c.MarkFlagRequired("provider")
 
-	return c
+	return withAuditLogging(c)
 }
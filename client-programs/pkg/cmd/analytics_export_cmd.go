@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/analytics"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type AnalyticsExportOptions struct {
+	Sink          string
+	Kubeconfig    string
+	Portal        string
+	Address       string
+	Token         string
+	Events        []string
+	BatchSize     int
+	FlushInterval time.Duration
+	BufferLimit   int
+	Verbose       bool
+}
+
+func (o *AnalyticsExportOptions) Run(ctx context.Context) error {
+	sink, err := analytics.NewSink(o.Sink)
+
+	if err != nil {
+		return err
+	}
+
+	if o.BatchSize <= 0 {
+		return failures.NewValidationError(errors.Errorf("invalid batch size %d", o.BatchSize), "batch size must be greater than zero")
+	}
+
+	for _, pattern := range o.Events {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return failures.NewValidationError(errors.Errorf("invalid event pattern %q", pattern), "event patterns use shell wildcards, such as Session/*")
+		}
+	}
+
+	// When a training portal is named it is configured to send events to
+	// the receiver while the export runs, otherwise the analytics webhook
+	// of the training portal must already have been configured to do so.
+
+	var clusterConfig *cluster.ClusterConfig
+
+	var trainingPortal *unstructured.Unstructured
+
+	var previous analyticsWebhook
+
+	if o.Portal != "" {
+		clusterConfig = cluster.NewClusterConfig(o.Kubeconfig)
+
+		trainingPortal, err = getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+		if err != nil {
+			return err
+		}
+
+		previous, err = readAnalyticsWebhook(ctx, clusterConfig, trainingPortal)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	exporter := &analytics.Exporter{
+		Sink:          sink,
+		BatchSize:     o.BatchSize,
+		FlushInterval: o.FlushInterval,
+		BufferLimit:   o.BufferLimit,
+		OnFlush: func(count int, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: unable to export %d events to %s, will retry: %s.\n", count, sink, err)
+			} else if o.Verbose {
+				fmt.Fprintf(os.Stderr, "Exported %d events to %s.\n", count, sink)
+			}
+		},
+		OnDrop: func(count int) {
+			fmt.Fprintf(os.Stderr, "Warning: discarded %d events as too many are waiting to be exported.\n", count)
+		},
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	done := make(chan struct{})
+
+	go func() {
+		exporter.Run(ctx)
+		close(done)
+	}()
+
+	err = receiveAnalyticsEvents(ctx, clusterConfig, trainingPortal, previous, o.Address, o.Token, o.Events, exporter.Add)
+
+	// Stopping the exporter makes a final attempt to write any events which
+	// are still waiting to be exported.
+
+	cancel()
+
+	<-done
+
+	return err
+}
+
+func (p *ProjectInfo) NewAnalyticsExportCmd() *cobra.Command {
+	var o AnalyticsExportOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "export",
+		Short: "Export analytics events to external storage",
+		Long: `Export analytics events to external storage.
+
+Runs a receiver for the analytics events reported by training portals, and
+writes them in batches to a sink for long term reporting. The sink is given
+using --sink and can be one of:
+
+  PATH                                 append events to a JSON lines file
+  -                                    write events to stdout
+  s3://BUCKET/PREFIX[?region=REGION&endpoint=URL]
+                                       write each batch as an object in S3
+  clickhouse://[USER:PASSWORD@]HOST[:PORT]/DATABASE/TABLE[?secure=true]
+                                       insert events into a ClickHouse table
+  bigquery://PROJECT/DATASET/TABLE     insert events into a BigQuery table
+
+Credentials for S3 are determined in the same way as by the AWS CLI, and for
+BigQuery the Google application default credentials are used. For ClickHouse
+and BigQuery the table must have string columns named timestamp, event,
+portal, portal_url, user, session, environment, workshop and data, with any
+event specific data being stored as JSON in the data column.
+
+If a training portal is named using --portal, it is configured to send its
+analytics events to the receiver while the export runs, with the prior
+configuration restored when stopped. Otherwise configure the analytics
+webhook of the training portal using "educates cluster portal analytics
+configure" with the URL and access token displayed when the export starts.
+
+Events are written when a batch is full or the flush interval elapses. If
+writing a batch fails it is retried at the next flush, with any remaining
+events written when the export is stopped.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Sink,
+		"sink",
+		"",
+		"location to export analytics events to",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"",
+		"name of training portal to configure to send events to the receiver",
+	)
+	c.Flags().StringVar(
+		&o.Address,
+		"address",
+		"0.0.0.0:10092",
+		"address to listen on for analytics events, given as host:port",
+	)
+	c.Flags().StringVar(
+		&o.Token,
+		"token",
+		"",
+		"access token which training portals must send with analytics events",
+	)
+	c.Flags().StringArrayVar(
+		&o.Events,
+		"event",
+		[]string{},
+		"pattern for names of analytics events to export when using --portal (can be specified multiple times)",
+	)
+	c.Flags().IntVar(
+		&o.BatchSize,
+		"batch-size",
+		100,
+		"maximum number of analytics events to write to the sink at one time",
+	)
+	c.Flags().DurationVar(
+		&o.FlushInterval,
+		"flush-interval",
+		30*time.Second,
+		"how often to write waiting analytics events to the sink",
+	)
+	c.Flags().IntVar(
+		&o.BufferLimit,
+		"buffer-limit",
+		10000,
+		"maximum number of analytics events to hold while the sink is unavailable",
+	)
+	c.Flags().BoolVarP(
+		&o.Verbose,
+		"verbose",
+		"v",
+		false,
+		"report each batch of analytics events written to the sink",
+	)
+
+	c.MarkFlagRequired("sink")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+// Time allowed for operations in progress to complete when the API server is
+// stopped, before connections are closed regardless.
+
+const apiShutdownTimeout = 30 * time.Second
+
+type ApiServeOptions struct {
+	Address    string
+	Token      string
+	TokenFile  string
+	Kubeconfig string
+}
+
+func (o *ApiServeOptions) Run(ctx context.Context, p *ProjectInfo) error {
+	// Use the access token supplied, otherwise generate one. A generated
+	// token is written to the token file if one is given, so that other
+	// tools can read it, else it is displayed.
+
+	token := o.Token
+
+	if token == "" {
+		token = os.Getenv("EDUCATES_API_TOKEN")
+	}
+
+	generated := false
+
+	if token == "" {
+		data := make([]byte, 32)
+
+		if _, err := rand.Read(data); err != nil {
+			return errors.Wrap(err, "unable to generate access token")
+		}
+
+		token = hex.EncodeToString(data)
+
+		generated = true
+	}
+
+	if o.TokenFile != "" {
+		if err := os.WriteFile(o.TokenFile, []byte(token+"\n"), 0600); err != nil {
+			return errors.Wrapf(err, "unable to write access token to %s", o.TokenFile)
+		}
+
+		defer os.Remove(o.TokenFile)
+	}
+
+	if host, _, err := net.SplitHostPort(o.Address); err != nil {
+		return failures.NewValidationError(errors.Wrapf(err, "invalid address %q", o.Address), "address must be given as host:port")
+	} else if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		fmt.Fprintf(os.Stderr, "Warning: API server is listening on non loopback address %s, access token will be sent in the clear.\n", o.Address)
+	}
+
+	listener, err := net.Listen("tcp", o.Address)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to listen on %s", o.Address)
+	}
+
+	defer listener.Close()
+
+	apiServer := &ApiServer{
+		Project:    p,
+		Token:      token,
+		Kubeconfig: o.Kubeconfig,
+	}
+
+	server := http.Server{
+		Handler: apiServer.Handler(),
+	}
+
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: operations still in progress after %s, closing connections.\n", apiShutdownTimeout)
+
+			server.Close()
+		}
+	}()
+
+	fmt.Printf("Serving API on http://%s/api/v1/, press Ctrl-C to stop.\n", listener.Addr())
+
+	if generated && o.TokenFile == "" {
+		fmt.Printf("Access token: %s\n", token)
+	}
+
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(err, "unable to serve API")
+	}
+
+	// Serve returns as soon as shutdown starts, so wait for operations in
+	// progress to complete or be cut off.
+
+	<-stopped
+
+	return nil
+}
+
+func (p *ProjectInfo) NewApiServeCmd() *cobra.Command {
+	var o ApiServeOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "serve",
+		Short: "Serve local REST API",
+		Long: `Serve local REST API.
+
+Runs a HTTP server exposing core operations of the CLI as a REST API, so that
+IDE plugins and other tools can drive Educates without running the CLI for
+each operation. All requests must supply the access token for the server in
+an "Authorization: Bearer" header. If no token is supplied using --token or
+the EDUCATES_API_TOKEN environment variable, one is generated.
+
+The endpoints provided are:
+
+  GET    /api/v1/version            version of the CLI
+  GET    /api/v1/workshops          workshops deployed to a training portal
+  POST   /api/v1/workshops          deploy workshops
+  DELETE /api/v1/workshops/NAME     delete a workshop
+  GET    /api/v1/sessions           workshop sessions for a training portal
+  POST   /api/v1/publish            publish workshop content
+
+The list endpoints accept a "portal" query parameter, with sessions also able
+to be filtered by "environment". The remaining endpoints accept a JSON body
+of the form {"args": [...], "flags": {...}} where flags use the same names as
+the command line options of the corresponding command, with lists used for
+options which can be given more than once. The response holds any output from
+the operation, along with an error message if the operation failed.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context(), p) },
+	}
+
+	c.Flags().StringVar(
+		&o.Address,
+		"address",
+		"127.0.0.1:10090",
+		"address to listen on for API requests, given as host:port",
+	)
+	c.Flags().StringVar(
+		&o.Token,
+		"token",
+		"",
+		"access token which must be supplied with API requests",
+	)
+	c.Flags().StringVar(
+		&o.TokenFile,
+		"token-file",
+		"",
+		"file to write the access token to, removed when the server stops",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	return c
+}
@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+/*
+Request body for API operations which are implemented by running the same
+code as the corresponding CLI command. Flags are given using the names of the
+command line options, with lists being used for options which can be given
+more than once.
+*/
+type ApiOperationRequest struct {
+	Args  []string               `json:"args,omitempty"`
+	Flags map[string]interface{} `json:"flags,omitempty"`
+}
+
+type ApiOperationResponse struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ApiWorkshopDetails struct {
+	Name        string `json:"name"`
+	Portal      string `json:"portal"`
+	Environment string `json:"environment,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+type ApiSessionDetails struct {
+	Name        string `json:"name"`
+	Portal      string `json:"portal"`
+	Environment string `json:"environment"`
+	Status      string `json:"status"`
+	URL         string `json:"url,omitempty"`
+}
+
+type ApiServer struct {
+	Project    *ProjectInfo
+	Token      string
+	Kubeconfig string
+
+	// Commands write their progress to stdout and stderr, which are captured
+	// while an operation runs, so only one operation can run at a time.
+
+	mutex sync.Mutex
+}
+
+func (s *ApiServer) Handler() http.Handler {
+	router := http.NewServeMux()
+
+	router.HandleFunc("/api/v1/version", func(w http.ResponseWriter, r *http.Request) {
+		writeApiResponse(w, http.StatusOK, map[string]string{"version": s.Project.Version})
+	})
+
+	router.HandleFunc("/api/v1/workshops", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s.listWorkshops(w, r)
+		case http.MethodPost:
+			s.runOperation(w, r, s.Project.nestedCommand(s.Project.NewClusterWorkshopDeployCmd(), "cluster", "workshop"), nil)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	router.HandleFunc("/api/v1/workshops/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/api/v1/workshops/")
+
+		if name == "" || strings.Contains(name, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.runOperation(w, r, s.Project.nestedCommand(s.Project.NewClusterWorkshopDeleteCmd(), "cluster", "workshop"), map[string]interface{}{"name": name})
+	})
+
+	router.HandleFunc("/api/v1/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.listSessions(w, r)
+	})
+
+	router.HandleFunc("/api/v1/publish", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.runOperation(w, r, s.Project.nestedCommand(s.Project.NewWorkshopPublishCmd(), "workshop"), nil)
+	})
+
+	return s.authenticate(router)
+}
+
+/*
+Require all requests to supply the access token for the server as a bearer
+token in the Authorization header.
+*/
+func (s *ApiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(header, "Bearer ")
+
+		if token == header || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "invalid or missing access token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *ApiServer) portalName(r *http.Request) string {
+	if portal := r.URL.Query().Get("portal"); portal != "" {
+		return portal
+	}
+
+	return "educates-cli"
+}
+
+func (s *ApiServer) listWorkshops(w http.ResponseWriter, r *http.Request) {
+	portal := s.portalName(r)
+
+	dynamicClient, err := cluster.NewClusterConfig(s.Kubeconfig).GetDynamicClient()
+
+	if err != nil {
+		http.Error(w, errors.Wrap(err, "unable to create Kubernetes client").Error(), http.StatusInternalServerError)
+		return
+	}
+
+	workshops := []ApiWorkshopDetails{}
+
+	trainingPortal, err := dynamicClient.Resource(trainingPortalResource).Get(r.Context(), portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		writeApiResponse(w, http.StatusOK, workshops)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, errors.Wrap(err, "unable to retrieve training portal").Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	for _, item := range entries {
+		object, ok := item.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		name, _ := object["name"].(string)
+
+		details := ApiWorkshopDetails{Name: name, Portal: portal}
+
+		if environment, err := workshopEnvironmentForPortal(r.Context(), dynamicClient, portal, name); err == nil && environment != nil {
+			details.Environment = environment.GetName()
+			details.Status, _, _ = unstructured.NestedString(environment.Object, "status", "educates", "phase")
+		}
+
+		workshops = append(workshops, details)
+	}
+
+	writeApiResponse(w, http.StatusOK, workshops)
+}
+
+func (s *ApiServer) listSessions(w http.ResponseWriter, r *http.Request) {
+	portal := s.portalName(r)
+
+	dynamicClient, err := cluster.NewClusterConfig(s.Kubeconfig).GetDynamicClient()
+
+	if err != nil {
+		http.Error(w, errors.Wrap(err, "unable to create Kubernetes client").Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Values come from the query string, so build the selector from a set
+	// of labels, which rejects values that aren't valid label values rather
+	// than allowing extra requirements to be added to the selector.
+
+	selectorLabels := labels.Set{"training.educates.dev/portal.name": portal}
+
+	if environment := r.URL.Query().Get("environment"); environment != "" {
+		selectorLabels["training.educates.dev/environment.name"] = environment
+	}
+
+	selector, err := labels.ValidatedSelectorFromSet(selectorLabels)
+
+	if err != nil {
+		http.Error(w, errors.Wrap(err, "invalid portal or environment name").Error(), http.StatusBadRequest)
+		return
+	}
+
+	workshopSessions, err := dynamicClient.Resource(workshopSessionResource).List(r.Context(), metav1.ListOptions{LabelSelector: selector.String()})
+
+	sessions := []ApiSessionDetails{}
+
+	if k8serrors.IsNotFound(err) {
+		writeApiResponse(w, http.StatusOK, sessions)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, errors.Wrap(err, "unable to list workshop sessions").Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, item := range workshopSessions.Items {
+		sessionLabels := item.GetLabels()
+
+		status, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+		url, _, _ := unstructured.NestedString(item.Object, "status", "educates", "url")
+
+		sessions = append(sessions, ApiSessionDetails{
+			Name:        item.GetName(),
+			Portal:      sessionLabels["training.educates.dev/portal.name"],
+			Environment: sessionLabels["training.educates.dev/environment.name"],
+			Status:      status,
+			URL:         url,
+		})
+	}
+
+	writeApiResponse(w, http.StatusOK, sessions)
+}
+
+/*
+Run a CLI command on behalf of an API request, returning anything it output.
+Flags from the request are passed as command line arguments to a fresh
+instance of the command, which is executed through Cobra, so defaults and
+validation of flags are the same as when the command is run from the command
+line. Confirmation prompts are skipped, as the request is itself the
+confirmation.
+*/
+func (s *ApiServer) runOperation(w http.ResponseWriter, r *http.Request, c *cobra.Command, overrides map[string]interface{}) {
+	var request ApiOperationRequest
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+			http.Error(w, errors.Wrap(err, "unable to decode request").Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	flags := map[string]interface{}{}
+
+	for name, value := range request.Flags {
+		flags[name] = value
+	}
+
+	for name, value := range overrides {
+		flags[name] = value
+	}
+
+	if _, found := flags["kubeconfig"]; !found && s.Kubeconfig != "" && c.Flags().Lookup("kubeconfig") != nil {
+		flags["kubeconfig"] = s.Kubeconfig
+	}
+
+	args, err := commandFlagArgs(c, flags)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Positional arguments follow a "--" so they can't be mistaken for flags.
+
+	args = append(append(args, "--"), request.Args...)
+
+	// Anything which fails before the command itself is run, such as invalid
+	// arguments or flags which can't be used together, is a bad request.
+
+	started := false
+
+	run := c.RunE
+
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		started = true
+
+		return run(cmd, args)
+	}
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	assumeYes := interactionOptions.AssumeYes
+
+	interactionOptions.AssumeYes = true
+
+	defer func() { interactionOptions.AssumeYes = assumeYes }()
+
+	// Use the context of the request so the operation is cancelled if the
+	// client goes away.
+
+	output, err := captureOutput(func() error { return runNestedCommand(r.Context(), c, args...) })
+
+	response := ApiOperationResponse{Output: output}
+
+	if err != nil {
+		response.Error = err.Error()
+
+		if !started {
+			writeApiResponse(w, http.StatusBadRequest, response)
+		} else {
+			writeApiResponse(w, http.StatusInternalServerError, response)
+		}
+
+		return
+	}
+
+	writeApiResponse(w, http.StatusOK, response)
+}
+
+/*
+Convert a map of flag names to values into command line arguments for a
+command. List values are used for flags which can be given more than once.
+Flags are output in order of name so the arguments are always the same for
+the same request.
+*/
+func commandFlagArgs(c *cobra.Command, flags map[string]interface{}) ([]string, error) {
+	names := make([]string, 0, len(flags))
+
+	for name := range flags {
+		if c.Flags().Lookup(name) == nil || name == "help" {
+			return nil, errors.Errorf("unknown flag %q for %s", name, c.Name())
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	args := []string{}
+
+	for _, name := range names {
+		values, isList := flags[name].([]interface{})
+
+		if !isList {
+			values = []interface{}{flags[name]}
+		}
+
+		for _, item := range values {
+			args = append(args, fmt.Sprintf("--%s=%v", name, item))
+		}
+	}
+
+	return args, nil
+}
+
+/*
+Run a function, capturing anything written to stdout and stderr.
+*/
+func captureOutput(fn func() error) (output string, err error) {
+	reader, writer, err := os.Pipe()
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to capture output")
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+
+	os.Stdout, os.Stderr = writer, writer
+
+	var buffer bytes.Buffer
+
+	done := make(chan struct{})
+
+	go func() {
+		io.Copy(&buffer, reader)
+		close(done)
+	}()
+
+	// Restore the original output streams even if the function panics, so
+	// later output isn't lost.
+
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+
+		writer.Close()
+
+		<-done
+
+		reader.Close()
+
+		output = buffer.String()
+	}()
+
+	err = fn()
+
+	return "", err
+}
+
+func writeApiResponse(w http.ResponseWriter, status int, value interface{}) {
+	jsonData, err := json.Marshal(value)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	w.WriteHeader(status)
+	w.Write(jsonData)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+/*
+Use empty directories for the client config and cache, so tests aren't
+affected by any defaults configured by the user running them.
+*/
+func isolateClientConfig(t *testing.T) {
+	t.Helper()
+
+	dataHome, cacheHome := xdg.DataHome, xdg.CacheHome
+
+	xdg.DataHome, xdg.CacheHome = t.TempDir(), t.TempDir()
+
+	t.Cleanup(func() { xdg.DataHome, xdg.CacheHome = dataHome, cacheHome })
+}
+
+func TestApiServerRejectsExclusiveFlags(t *testing.T) {
+	isolateClientConfig(t)
+
+	project := NewProjectInfo("0.0.1")
+
+	server := &ApiServer{
+		Project:    &project,
+		Token:      "token",
+		Kubeconfig: filepath.Join(t.TempDir(), "kubeconfig"),
+	}
+
+	body := `{"flags": {"name": "lab-sample", "idle-exempt": true, "orphaned": "10m"}}`
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/workshops", strings.NewReader(body))
+	request.Header.Set("Authorization", "Bearer token")
+
+	recorder := httptest.NewRecorder()
+
+	server.Handler().ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusBadRequest, recorder.Code, recorder.Body.String())
+	}
+
+	var response ApiOperationResponse
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("unable to decode response: %v", err)
+	}
+
+	if !strings.Contains(response.Error, "idle-exempt") || !strings.Contains(response.Error, "orphaned") {
+		t.Fatalf("expected error about exclusive flags, got %q", response.Error)
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/audit"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type AuditLogOptions struct {
+	Since   time.Duration
+	Limit   int
+	Command string
+	Output  string
+}
+
+func (o *AuditLogOptions) Run() error {
+	if o.Output != "table" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are table and json")
+	}
+
+	entries, err := audit.Read()
+
+	if err != nil {
+		return err
+	}
+
+	var selected []audit.Entry
+
+	for _, entry := range entries {
+		if o.Since != 0 && time.Since(entry.Time) > o.Since {
+			continue
+		}
+
+		if o.Command != "" && !strings.Contains(entry.Command, o.Command) {
+			continue
+		}
+
+		selected = append(selected, entry)
+	}
+
+	if o.Limit > 0 && len(selected) > o.Limit {
+		selected = selected[len(selected)-o.Limit:]
+	}
+
+	if o.Output == "json" {
+		if selected == nil {
+			selected = []audit.Entry{}
+		}
+
+		data, err := json.MarshalIndent(selected, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode audit log entries")
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("No audit log entries found.")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "TIME", "USER", "COMMAND", "RESULT", "DETAILS")
+
+	for _, entry := range selected {
+		var details []string
+
+		details = append(details, entry.Args...)
+
+		var names []string
+
+		for name := range entry.Flags {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			details = append(details, fmt.Sprintf("--%s=%s", name, entry.Flags[name]))
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Time.Local().Format(time.RFC3339), entry.User, entry.Command, entry.Result, strings.Join(details, " "))
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewAuditLogCmd() *cobra.Command {
+	var o AuditLogOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "log",
+		Short: "Display log of changes made using the CLI",
+		Long: `Display the log of changes made using the CLI.
+
+Every command which makes changes records an entry in a local append only
+log, including who ran the command, when, the arguments used and whether it
+succeeded. The values of options holding passwords, tokens or secrets are not
+recorded. Use the --audit-events option when running a command to also record
+the change as a Kubernetes event.`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+
+	c.Flags().DurationVar(
+		&o.Since,
+		"since",
+		0,
+		"only show changes made within this time duration",
+	)
+	c.Flags().IntVar(
+		&o.Limit,
+		"limit",
+		0,
+		"maximum number of most recent changes to show",
+	)
+	c.Flags().StringVar(
+		&o.Command,
+		"command",
+		"",
+		"only show changes made by commands matching this string",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"table",
+		"output format for the audit log (table or json)",
+	)
+
+	return c
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/audit"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+// Annotation added to commands which make changes, either to the cluster or
+// to local configuration, and so have the outcome of running them recorded in
+// the audit log.
+
+const auditLoggingAnnotation = "educates.dev/audit-logging"
+
+const auditEventTimeout = 10 * time.Second
+
+var sensitiveFlagPattern = regexp.MustCompile("password|token|secret")
+
+/*
+Global option controlling whether audit log entries are also recorded as
+Kubernetes events. This is bound to a persistent flag on the root command.
+*/
+var auditEvents bool
+
+/*
+Mark a command as making changes so that the outcome of running it is
+recorded in the audit log. This is applied where the command is defined, so
+that aliases for the command, such as "deploy-workshop", are also audited.
+*/
+func withAuditLogging(c *cobra.Command) *cobra.Command {
+	if c.Annotations == nil {
+		c.Annotations = map[string]string{}
+	}
+
+	c.Annotations[auditLoggingAnnotation] = "true"
+
+	return c
+}
+
+/*
+Wrap the run function of all commands which have been marked as making
+changes so that the outcome of running the command is recorded in the audit
+log.
+*/
+func enableAuditLogging(c *cobra.Command) {
+	for _, child := range c.Commands() {
+		enableAuditLogging(child)
+	}
+
+	if c.RunE == nil || c.Annotations[auditLoggingAnnotation] != "true" {
+		return
+	}
+
+	runE := c.RunE
+
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		err := runE(cmd, args)
+
+		recordAuditEntry(cmd, args, err)
+
+		return err
+	}
+}
+
+func recordAuditEntry(cmd *cobra.Command, args []string, err error) {
+	entry := audit.Entry{
+		Time:    time.Now().UTC(),
+		Command: cmd.CommandPath(),
+		Args:    args,
+		Flags:   map[string]string{},
+		Result:  "success",
+	}
+
+	if currentUser, userErr := user.Current(); userErr == nil {
+		entry.User = currentUser.Username
+	}
+
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if sensitiveFlagPattern.MatchString(flag.Name) {
+			entry.Flags[flag.Name] = "<redacted>"
+		} else {
+			entry.Flags[flag.Name] = flag.Value.String()
+		}
+	})
+
+	if err != nil {
+		entry.Result = "failure"
+		entry.Error = err.Error()
+	}
+
+	if recordErr := audit.Record(entry); recordErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", recordErr)
+	}
+
+	if auditEvents {
+		createAuditEvent(cmd, entry)
+	}
+}
+
+/*
+Record an audit log entry as a Kubernetes event. Where the command targets a
+training portal the event is associated with it, otherwise it is associated
+with the namespace for the Educates operators. Failures are only warned about
+as the command itself has already been run.
+*/
+func createAuditEvent(cmd *cobra.Command, entry audit.Entry) {
+	if cmd.Flags().Lookup("kubeconfig") == nil {
+		return
+	}
+
+	kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
+
+	client, err := cluster.NewClusterConfig(kubeconfig).GetClient()
+
+	if err != nil {
+		return
+	}
+
+	involvedObject := apiv1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Name:       "educates",
+	}
+
+	if portal, _ := cmd.Flags().GetString("portal"); portal != "" {
+		involvedObject = apiv1.ObjectReference{
+			APIVersion: "training.educates.dev/v1beta1",
+			Kind:       "TrainingPortal",
+			Name:       portal,
+		}
+	}
+
+	eventType := apiv1.EventTypeNormal
+
+	message := fmt.Sprintf("%s by %s", entry.Command, entry.User)
+
+	if entry.Result != "success" {
+		eventType = apiv1.EventTypeWarning
+		message = fmt.Sprintf("%s by %s failed: %s", entry.Command, entry.User, entry.Error)
+	}
+
+	event := &apiv1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "educates-cli-",
+			Namespace:    "default",
+		},
+		InvolvedObject: involvedObject,
+		Reason:         "EducatesCLI",
+		Message:        message,
+		Type:           eventType,
+		Source: apiv1.EventSource{
+			Component: "educates-cli",
+		},
+		FirstTimestamp: metav1.NewTime(entry.Time),
+		LastTimestamp:  metav1.NewTime(entry.Time),
+		Count:          1,
+	}
+
+	// Where the command was itself interrupted, a separate context with a
+	// time limit is used, so that the interrupted command is still recorded.
+
+	ctx := cmd.Context()
+
+	if ctx == nil || ctx.Err() != nil {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(context.Background(), auditEventTimeout)
+		defer cancel()
+	}
+
+	_, err = client.CoreV1().Events("default").Create(ctx, event, metav1.CreateOptions{})
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to record audit event: %s.\n", err)
+	}
+}
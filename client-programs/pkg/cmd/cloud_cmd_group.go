@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+func (p *ProjectInfo) NewCloudCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "cloud",
+		Short: "Manage clusters hosted by cloud providers",
+		Long: `Manage clusters hosted by cloud providers.
+
+Creates managed Kubernetes clusters with a cloud provider, sized for hosting
+workshops, with Educates installed. Each cluster is registered as a profile,
+which records the cloud provider and region and holds the kubeconfig file
+for accessing the cluster, so the cluster can later be deleted by name.`,
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewCloudCreateCmd(),
+				p.NewCloudListCmd(),
+				p.NewCloudDeleteCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
+
+func completeProfileNames(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	profiles, err := config.ListProfileConfigs()
+
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type CloudCreateOptions struct {
+	Name        string
+	Config      string
+	Provider    string
+	Region      string
+	Size        string
+	Domain      string
+	DNSProvider string
+	DNSTimeout  time.Duration
+	Version     string
+}
+
+func (o *CloudCreateOptions) Run(ctx context.Context) error {
+	// Ensure have cluster name.
+
+	if o.Name == "" {
+		o.Name = "educates"
+	}
+
+	provider, err := lookupCloudProvider(o.Provider)
+
+	if err != nil {
+		return err
+	}
+
+	if _, found := provider.Presets[o.Size]; !found {
+		return failures.NewValidationError(errors.Errorf("unsupported node size preset %q", o.Size), "supported presets are small, medium and large")
+	}
+
+	if _, err := config.LoadProfileConfig(o.Name); err == nil {
+		return failures.NewValidationError(errors.Errorf("profile %q already exists", o.Name), "delete the existing cluster with `educates cloud delete` or choose a different name")
+	}
+
+	// Read the installation config up front so any error in it is found
+	// before a cluster is created.
+
+	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
+
+	if err != nil {
+		return err
+	}
+
+	fullConfig.ClusterInfrastructure.Provider = o.Provider
+
+	dnsProvider := o.DNSProvider
+
+	if dnsProvider == "" {
+		dnsProvider = fullConfig.ClusterDNS.Provider
+	}
+
+	if dnsProvider == "" {
+		dnsProvider = provider.DNSProvider
+	}
+
+	// Without a domain of its own, the cluster uses a nip.io domain for the
+	// address of the load balancer of the ingress controller, which can only
+	// be worked out once the cluster has been created.
+
+	domain := o.Domain
+
+	if domain == "" && !strings.HasSuffix(fullConfig.ClusterIngress.Domain, ".nip.io") && !strings.HasSuffix(fullConfig.ClusterIngress.Domain, ".sslip.io") {
+		domain = fullConfig.ClusterIngress.Domain
+	}
+
+	manageDNS := domain != ""
+
+	if manageDNS && dnsProvider == "" {
+		return failures.NewValidationError(errors.Errorf("no DNS provider for ingress domain %q", domain), "use --dns-provider to give the DNS provider hosting the domain")
+	}
+
+	profile := &config.ProfileConfig{
+		Name:       o.Name,
+		Provider:   o.Provider,
+		Region:     o.Region,
+		Cluster:    o.Name,
+		Size:       o.Size,
+		Kubeconfig: config.ProfileKubeconfigFile(o.Name),
+	}
+
+	if err = os.MkdirAll(filepath.Dir(profile.Kubeconfig), os.ModePerm); err != nil {
+		return errors.Wrap(err, "unable to create profiles directory")
+	}
+
+	fmt.Printf("Creating %s cluster %q in region %s ...\n", o.Provider, profile.Cluster, o.Region)
+
+	if err = createCloudCluster(provider, profile); err != nil {
+		return err
+	}
+
+	// Save the profile as soon as the cluster exists so that it can still
+	// be deleted by name if installing Educates fails.
+
+	if err = config.SaveProfileConfig(profile); err != nil {
+		return err
+	}
+
+	clusterConfig := cluster.NewClusterConfig(profile.Kubeconfig)
+
+	if err = deployKappController(profile.Kubeconfig, fullConfig); err != nil {
+		return err
+	}
+
+	servicesOptions := AdminServicesDeployOptions{
+		Config:     o.Config,
+		Kubeconfig: profile.Kubeconfig,
+		Provider:   o.Provider,
+		Version:    o.Version,
+	}
+
+	if err = servicesOptions.Run(ctx); err != nil {
+		return errors.Wrap(err, "failed to deploy cluster essentials services")
+	}
+
+	if domain == "" {
+		if domain, err = loadBalancerWildcardDomain(clusterConfig, o.DNSTimeout); err != nil {
+			return err
+		}
+	}
+
+	platformOptions := AdminPlatformDeployOptions{
+		Config:      o.Config,
+		Kubeconfig:  profile.Kubeconfig,
+		Provider:    o.Provider,
+		Domain:      domain,
+		Version:     o.Version,
+		ManageDNS:   manageDNS,
+		DNSProvider: dnsProvider,
+		DNSTimeout:  o.DNSTimeout,
+	}
+
+	if err = platformOptions.Run(ctx); err != nil {
+		return errors.Wrap(err, "failed to deploy training platform components")
+	}
+
+	profile.Domain = domain
+
+	if err = config.SaveProfileConfig(profile); err != nil {
+		return err
+	}
+
+	fmt.Printf("Cluster %q created with ingress domain %s.\n", profile.Name, domain)
+	fmt.Printf("Use --kubeconfig %s with other commands to target the cluster.\n", profile.Kubeconfig)
+
+	return nil
+}
+
+/*
+Wait for the load balancer of the ingress controller and return a nip.io
+domain for its IP address. Where the load balancer is given a host name, as
+with EKS, the IP address the host name resolves to is used.
+*/
+func loadBalancerWildcardDomain(clusterConfig *cluster.ClusterConfig, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	defer cancel()
+
+	addresses, err := ingressLoadBalancerAddresses(ctx, clusterConfig, config.ServiceRefConfig{Namespace: "projectcontour", Name: "envoy"})
+
+	if err != nil {
+		return "", err
+	}
+
+	if ip := net.ParseIP(addresses[0]); ip != nil {
+		return config.WildcardDomainForAddress(ip.String()), nil
+	}
+
+	for {
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", addresses[0])
+
+		if err == nil && len(ips) != 0 {
+			return config.WildcardDomainForAddress(ips[0].String()), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", failures.NewTimeoutError(errors.Errorf("host name %s of load balancer for ingress controller doesn't resolve", addresses[0]), "use --domain to give an ingress domain for the cluster")
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (p *ProjectInfo) NewCloudCreateCmd() *cobra.Command {
+	var o CloudCreateOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "create [NAME]",
+		Short: "Create cluster hosted by cloud provider",
+		Long: `Create a cluster hosted by a cloud provider.
+
+Creates a managed Kubernetes cluster using EKS, GKE or AKS, by running the
+command line tool for the cloud provider, being eksctl, gcloud or az. You
+must already be logged in using the command line tool. The size of the nodes
+and number of nodes is given by a preset, with "small" suitable for a handful
+of users, and "medium" and "large" for a class of around twenty and fifty
+users.
+
+Once the cluster is created, the cluster essentials services, including the
+ingress controller, and the training platform are installed. If an ingress
+domain is given, the wildcard DNS record for the domain is created, by
+default using the DNS service of the cloud provider, else a nip.io domain
+for the address of the load balancer of the ingress controller is used.
+
+The cluster is registered as a profile with the name of the cluster, which
+defaults to "educates", with the kubeconfig file for accessing the cluster
+kept with the profile.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				o.Name = args[0]
+			}
+
+			return o.Run(cmd.Context())
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.Config,
+		"config",
+		"",
+		"path to the installation config file for Educates",
+	)
+	c.Flags().StringVar(
+		&o.Provider,
+		"provider",
+		"",
+		"cloud provider to create the cluster with, one of eks, gke or aks",
+	)
+	c.Flags().StringVar(
+		&o.Region,
+		"region",
+		"",
+		"region of the cloud provider to create the cluster in",
+	)
+	c.Flags().StringVar(
+		&o.Size,
+		"size",
+		"small",
+		"node size preset for the cluster, one of small, medium or large",
+	)
+	c.Flags().StringVar(
+		&o.Domain,
+		"domain",
+		"",
+		"wildcard ingress subdomain name for Educates",
+	)
+	c.Flags().StringVar(
+		&o.DNSProvider,
+		"dns-provider",
+		"",
+		"DNS provider hosting the ingress domain, one of route53, cloudflare or google",
+	)
+	c.Flags().DurationVar(
+		&o.DNSTimeout,
+		"dns-timeout",
+		10*time.Minute,
+		"maximum time to wait for load balancer and DNS record to propagate",
+	)
+	c.Flags().StringVar(
+		&o.Version,
+		"version",
+		p.Version,
+		"version to be installed",
+	)
+
+	c.MarkFlagRequired("provider")
+	c.MarkFlagRequired("region")
+
+	c.RegisterFlagCompletionFunc("provider", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return cloudProviderNames, cobra.ShellCompDirectiveNoFileComp
+	})
+	c.RegisterFlagCompletionFunc("size", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return cloudNodePresetNames, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return withAuditLogging(c)
+}
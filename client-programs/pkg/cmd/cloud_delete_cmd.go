@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+type CloudDeleteOptions struct {
+	KeepProfile bool
+}
+
+func (o *CloudDeleteOptions) Run(name string) error {
+	profile, err := config.LoadProfileConfig(name)
+
+	if err != nil {
+		return err
+	}
+
+	provider, err := lookupCloudProvider(profile.Provider)
+
+	if err != nil {
+		return err
+	}
+
+	if err = confirmAction(fmt.Sprintf("delete %s cluster %q in region %s", profile.Provider, profile.Cluster, profile.Region)); err != nil {
+		return err
+	}
+
+	if err = deleteCloudCluster(provider, profile); err != nil {
+		return err
+	}
+
+	if o.KeepProfile {
+		return nil
+	}
+
+	if err = os.Remove(profile.Kubeconfig); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Warning: unable to remove kubeconfig file %s: %s.\n", profile.Kubeconfig, err)
+	}
+
+	if err = config.DeleteProfileConfig(name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Profile %q deleted.\n", name)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewCloudDeleteCmd() *cobra.Command {
+	var o CloudDeleteOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "delete NAME",
+		Short: "Delete cluster hosted by cloud provider",
+		Long: `Delete a cluster hosted by a cloud provider.
+
+Deletes the cluster recorded by the profile from the cloud provider, along
+with the profile and the kubeconfig file for the cluster. Any DNS record
+created for the ingress domain of the cluster is not deleted.`,
+		ValidArgsFunction: completeProfileNames,
+		RunE:              func(_ *cobra.Command, args []string) error { return o.Run(args[0]) },
+	}
+
+	c.Flags().BoolVar(
+		&o.KeepProfile,
+		"keep-profile",
+		false,
+		"keep the profile after deleting the cluster",
+	)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+type CloudListOptions struct {
+}
+
+func (o *CloudListOptions) Run() error {
+	profiles, err := config.ListProfileConfigs()
+
+	if err != nil {
+		return err
+	}
+
+	if len(profiles) == 0 {
+		fmt.Println("No profiles found.")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "NAME", "PROVIDER", "REGION", "SIZE", "DOMAIN", "KUBECONFIG")
+
+	for _, profile := range profiles {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", profile.Name, profile.Provider, profile.Region, profile.Size, profile.Domain, profile.Kubeconfig)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewCloudListCmd() *cobra.Command {
+	var o CloudListOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "list",
+		Short: "List profiles for clusters hosted by cloud providers",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+
+	return c
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Size of the nodes and number of nodes for a cluster suitable for training.
+*/
+type cloudNodePreset struct {
+	MachineType string
+	Nodes       int
+}
+
+/*
+A cloud provider offering managed Kubernetes clusters, created using the
+command line tool for the provider. Node size presets are sized so that the
+"small" preset runs a workshop for a handful of users, with "medium" and
+"large" running a class of around twenty and fifty users. For GKE, clusters
+are regional and the number of nodes is for each zone in the region.
+*/
+type cloudProvider struct {
+	Name        string
+	Command     string
+	DNSProvider string
+	Presets     map[string]cloudNodePreset
+}
+
+var cloudProviders = map[string]cloudProvider{
+	"eks": {
+		Name:        "eks",
+		Command:     "eksctl",
+		DNSProvider: "route53",
+		Presets: map[string]cloudNodePreset{
+			"small":  {MachineType: "m5.xlarge", Nodes: 2},
+			"medium": {MachineType: "m5.2xlarge", Nodes: 3},
+			"large":  {MachineType: "m5.2xlarge", Nodes: 6},
+		},
+	},
+	"gke": {
+		Name:        "gke",
+		Command:     "gcloud",
+		DNSProvider: "google",
+		Presets: map[string]cloudNodePreset{
+			"small":  {MachineType: "e2-standard-4", Nodes: 1},
+			"medium": {MachineType: "e2-standard-8", Nodes: 1},
+			"large":  {MachineType: "e2-standard-8", Nodes: 2},
+		},
+	},
+	"aks": {
+		Name:    "aks",
+		Command: "az",
+		Presets: map[string]cloudNodePreset{
+			"small":  {MachineType: "Standard_D4s_v3", Nodes: 2},
+			"medium": {MachineType: "Standard_D8s_v3", Nodes: 3},
+			"large":  {MachineType: "Standard_D8s_v3", Nodes: 6},
+		},
+	},
+}
+
+var cloudProviderNames = []string{"eks", "gke", "aks"}
+
+var cloudNodePresetNames = []string{"small", "medium", "large"}
+
+/*
+Return the cloud provider with the given name.
+*/
+func lookupCloudProvider(name string) (cloudProvider, error) {
+	provider, found := cloudProviders[name]
+
+	if !found {
+		return cloudProvider{}, failures.NewValidationError(errors.Errorf("unsupported cloud provider %q", name), "supported providers are eks, gke and aks")
+	}
+
+	if _, err := exec.LookPath(provider.Command); err != nil {
+		return cloudProvider{}, failures.NewValidationError(errors.Errorf("the %s command is required for creating clusters with %s", provider.Command, name), "install the command line tool for the cloud provider and log in")
+	}
+
+	return provider, nil
+}
+
+/*
+Run a command line tool for a cloud provider, passing through its output so
+the progress of creating or deleting the cluster can be seen. The kubeconfig
+file for the cluster, if given, is used in place of the default kubeconfig.
+*/
+func runCloudCommand(kubeconfig string, command string, args ...string) error {
+	cloudCommand := exec.Command(command, args...)
+
+	cloudCommand.Stdout = os.Stdout
+	cloudCommand.Stderr = os.Stderr
+
+	if kubeconfig != "" {
+		cloudCommand.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", kubeconfig))
+	}
+
+	if err := cloudCommand.Run(); err != nil {
+		return errors.Wrapf(err, "%s command failed", command)
+	}
+
+	return nil
+}
+
+/*
+Create a cluster with the cloud provider for a profile, writing the
+credentials for accessing the cluster to the kubeconfig file of the profile.
+*/
+func createCloudCluster(provider cloudProvider, profile *config.ProfileConfig) error {
+	preset, found := provider.Presets[profile.Size]
+
+	if !found {
+		return failures.NewValidationError(errors.Errorf("unsupported node size preset %q", profile.Size), "supported presets are small, medium and large")
+	}
+
+	nodes := strconv.Itoa(preset.Nodes)
+
+	switch provider.Name {
+	case "eks":
+		return runCloudCommand(profile.Kubeconfig, provider.Command, "create", "cluster",
+			"--name", profile.Cluster, "--region", profile.Region,
+			"--node-type", preset.MachineType, "--nodes", nodes,
+			"--kubeconfig", profile.Kubeconfig)
+	case "gke":
+		err := runCloudCommand("", provider.Command, "container", "clusters", "create", profile.Cluster,
+			"--region", profile.Region, "--machine-type", preset.MachineType, "--num-nodes", nodes)
+
+		if err != nil {
+			return err
+		}
+
+		return runCloudCommand(profile.Kubeconfig, provider.Command, "container", "clusters", "get-credentials", profile.Cluster,
+			"--region", profile.Region)
+	case "aks":
+		err := runCloudCommand("", provider.Command, "group", "create",
+			"--name", profile.Cluster, "--location", profile.Region)
+
+		if err != nil {
+			return err
+		}
+
+		err = runCloudCommand("", provider.Command, "aks", "create",
+			"--resource-group", profile.Cluster, "--name", profile.Cluster,
+			"--node-vm-size", preset.MachineType, "--node-count", nodes,
+			"--generate-ssh-keys")
+
+		if err != nil {
+			return err
+		}
+
+		return runCloudCommand("", provider.Command, "aks", "get-credentials",
+			"--resource-group", profile.Cluster, "--name", profile.Cluster,
+			"--file", profile.Kubeconfig, "--overwrite-existing")
+	}
+
+	return nil
+}
+
+/*
+Delete the cluster for a profile from the cloud provider. For AKS the
+resource group created for the cluster is deleted, along with the cluster.
+*/
+func deleteCloudCluster(provider cloudProvider, profile *config.ProfileConfig) error {
+	switch provider.Name {
+	case "eks":
+		return runCloudCommand("", provider.Command, "delete", "cluster",
+			"--name", profile.Cluster, "--region", profile.Region, "--wait")
+	case "gke":
+		return runCloudCommand("", provider.Command, "container", "clusters", "delete", profile.Cluster,
+			"--region", profile.Region, "--quiet")
+	case "aks":
+		return runCloudCommand("", provider.Command, "group", "delete",
+			"--name", profile.Cluster, "--yes")
+	}
+
+	return nil
+}
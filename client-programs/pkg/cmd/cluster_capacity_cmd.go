@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Resource quotas applied to a session namespace for each namespace budget. The
+values mirror those used by the session manager and are the upper bound on
+what workloads deployed from a session may consume.
+*/
+var namespaceBudgetLimits = map[string][2]string{
+	"small":     {"1", "1Gi"},
+	"medium":    {"2", "2Gi"},
+	"large":     {"4", "4Gi"},
+	"x-large":   {"8", "8Gi"},
+	"xx-large":  {"8", "12Gi"},
+	"xxx-large": {"8", "16Gi"},
+}
+
+type ClusterCapacityOptions struct {
+	Kubeconfig string
+	Portal     string
+}
+
+/*
+Estimated resources required by a single workshop session.
+*/
+type sessionFootprint struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+	Pods   int64
+}
+
+/*
+Resources which are still available for scheduling pods in the cluster.
+*/
+type clusterAvailable struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+	Pods   int64
+}
+
+/*
+Work out how many sessions of the given footprint will fit into the
+available resources, and which resource limits the number.
+*/
+func (a *clusterAvailable) fit(footprint sessionFootprint) (int64, string) {
+	fits := int64(-1)
+	constraint := "none"
+
+	check := func(name string, available int64, required int64) {
+		if required <= 0 {
+			return
+		}
+
+		count := available / required
+
+		if count < 0 {
+			count = 0
+		}
+
+		if fits < 0 || count < fits {
+			fits = count
+			constraint = name
+		}
+	}
+
+	check("cpu", a.CPU.MilliValue(), footprint.CPU.MilliValue())
+	check("memory", a.Memory.Value(), footprint.Memory.Value())
+	check("pods", a.Pods, footprint.Pods)
+
+	return fits, constraint
+}
+
+func (o *ClusterCapacityOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := dynamicClient.Resource(trainingPortalResource).Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("no portal found with name %q", o.Portal), "list training portals with `educates cluster portal list`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query training portal %q", o.Portal)
+	}
+
+	available, err := availableClusterResources(ctx, client)
+
+	if err != nil {
+		return err
+	}
+
+	portalCapacity, _, _ := unstructured.NestedInt64(trainingPortal.Object, "spec", "portal", "sessions", "maximum")
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	if len(workshops) == 0 {
+		fmt.Println("No workshops found.")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "WORKSHOP", "CAPACITY", "CPU", "MEMORY", "PODS", "FITS", "LIMITED-BY")
+
+	var required sessionFootprint
+
+	for _, entry := range workshops {
+		object, ok := entry.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		name, _ := object["name"].(string)
+
+		capacity, ok := object["capacity"].(int64)
+
+		if !ok || capacity <= 0 {
+			capacity = portalCapacity
+		}
+
+		workshop, err := dynamicClient.Resource(workshopResource).Get(ctx, name, metav1.GetOptions{})
+
+		if err != nil {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name, formatCapacity(capacity), "-", "-", "-", "-", "workshop definition not found")
+			continue
+		}
+
+		footprint := workshopSessionFootprint(workshop)
+
+		fits, constraint := available.fit(footprint)
+
+		fitsText := fmt.Sprintf("%d", fits)
+
+		if fits < 0 {
+			fitsText = "unlimited"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", name, formatCapacity(capacity), footprint.CPU.String(), footprint.Memory.String(), footprint.Pods, fitsText, constraint)
+
+		for i := int64(0); i < capacity; i++ {
+			required.CPU.Add(footprint.CPU)
+			required.Memory.Add(footprint.Memory)
+			required.Pods += footprint.Pods
+		}
+	}
+
+	w.Flush()
+
+	fmt.Println()
+
+	fmt.Printf("Available: cpu %s, memory %s, pods %d\n", available.CPU.String(), available.Memory.String(), available.Pods)
+	fmt.Printf("Required:  cpu %s, memory %s, pods %d\n", required.CPU.String(), required.Memory.String(), required.Pods)
+
+	fmt.Println()
+
+	// Report on the configured capacity of the training portal as a whole,
+	// identifying the resource most oversubscribed.
+
+	var shortfalls []string
+
+	if required.CPU.Cmp(available.CPU) > 0 {
+		shortfalls = append(shortfalls, "cpu")
+	}
+
+	if required.Memory.Cmp(available.Memory) > 0 {
+		shortfalls = append(shortfalls, "memory")
+	}
+
+	if required.Pods > available.Pods {
+		shortfalls = append(shortfalls, "pods")
+	}
+
+	if len(shortfalls) == 0 {
+		fmt.Println("The configured capacity of the training portal fits in the cluster.")
+		return nil
+	}
+
+	fmt.Printf("The configured capacity of the training portal does not fit in the cluster, insufficient %s.\n", joinWords(shortfalls))
+
+	return nil
+}
+
+/*
+Determine the nodes pods can be scheduled to and subtract the resources
+requested by existing pods from what the nodes have allocatable.
+*/
+func availableClusterResources(ctx context.Context, client *kubernetes.Clientset) (*clusterAvailable, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list nodes")
+	}
+
+	pods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: "status.phase!=Succeeded,status.phase!=Failed"})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list pods")
+	}
+
+	available := &clusterAvailable{}
+
+	schedulable := map[string]bool{}
+
+	for _, node := range nodes.Items {
+		if !nodeSchedulable(&node) {
+			continue
+		}
+
+		schedulable[node.Name] = true
+
+		available.CPU.Add(*node.Status.Allocatable.Cpu())
+		available.Memory.Add(*node.Status.Allocatable.Memory())
+		available.Pods += node.Status.Allocatable.Pods().Value()
+	}
+
+	for _, pod := range pods.Items {
+		if !schedulable[pod.Spec.NodeName] {
+			continue
+		}
+
+		for _, container := range pod.Spec.Containers {
+			available.CPU.Sub(*container.Resources.Requests.Cpu())
+			available.Memory.Sub(*container.Resources.Requests.Memory())
+		}
+
+		available.Pods--
+	}
+
+	return available, nil
+}
+
+func nodeSchedulable(node *apiv1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == apiv1.TaintEffectNoSchedule || taint.Effect == apiv1.TaintEffectNoExecute {
+			return false
+		}
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady && condition.Status != apiv1.ConditionTrue {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Estimate the resources for a session of a workshop from its definition. This
+counts memory for the workshop container and enabled docker and registry
+applications, and the quota on the session namespace where a namespace
+budget is set. Resources for additional session objects are not counted.
+*/
+func workshopSessionFootprint(workshop *unstructured.Unstructured) sessionFootprint {
+	footprint := sessionFootprint{Pods: 1}
+
+	defaultMemory := "512Mi"
+
+	if enabled, _, _ := unstructured.NestedBool(workshop.Object, "spec", "session", "applications", "editor", "enabled"); enabled {
+		defaultMemory = "1Gi"
+	}
+
+	footprint.Memory.Add(workshopQuantity(workshop, defaultMemory, "spec", "session", "resources", "memory"))
+
+	if enabled, _, _ := unstructured.NestedBool(workshop.Object, "spec", "session", "applications", "docker", "enabled"); enabled {
+		footprint.Memory.Add(workshopQuantity(workshop, "768Mi", "spec", "session", "applications", "docker", "memory"))
+	}
+
+	if enabled, _, _ := unstructured.NestedBool(workshop.Object, "spec", "session", "applications", "registry", "enabled"); enabled {
+		footprint.Memory.Add(workshopQuantity(workshop, "768Mi", "spec", "session", "applications", "registry", "memory"))
+		footprint.Pods++
+	}
+
+	budget, _, _ := unstructured.NestedString(workshop.Object, "spec", "session", "namespaces", "budget")
+
+	if limits, ok := namespaceBudgetLimits[budget]; ok {
+		footprint.CPU.Add(resource.MustParse(limits[0]))
+		footprint.Memory.Add(resource.MustParse(limits[1]))
+	}
+
+	return footprint
+}
+
+func workshopQuantity(workshop *unstructured.Unstructured, defaultValue string, fields ...string) resource.Quantity {
+	value, found, _ := unstructured.NestedString(workshop.Object, fields...)
+
+	if found {
+		if quantity, err := resource.ParseQuantity(value); err == nil {
+			return quantity
+		}
+	}
+
+	return resource.MustParse(defaultValue)
+}
+
+func formatCapacity(capacity int64) string {
+	if capacity <= 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d", capacity)
+}
+
+func joinWords(words []string) string {
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	default:
+		text := words[0]
+
+		for _, word := range words[1 : len(words)-1] {
+			text = text + ", " + word
+		}
+
+		return text + " and " + words[len(words)-1]
+	}
+}
+
+func (p *ProjectInfo) NewClusterCapacityCmd() *cobra.Command {
+	var o ClusterCapacityOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "capacity",
+		Short: "Estimate how many sessions will fit in the cluster",
+		Long: `Estimate how many sessions will fit in the cluster.
+
+For each workshop in the training portal, estimates the resources a session
+requires from the workshop definition, and calculates how many concurrent
+sessions of that workshop would fit in the resources not already requested
+by pods on schedulable nodes, showing whether CPU, memory or the number of
+pods is the limiting constraint. The total required for the configured
+capacity of all workshops is then compared against what is available.
+
+The estimate assumes sessions can be packed perfectly across nodes and does
+not account for resources consumed by workloads a workshop deploys beyond
+the quota set by its namespace budget, so treat the result as an upper
+bound.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
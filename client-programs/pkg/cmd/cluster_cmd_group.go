@@ -22,6 +22,18 @@ func (p *ProjectInfo) NewClusterCmdGroup() *cobra.Command {
 				p.NewClusterPortalCmdGroup(),
 				p.NewClusterWorkshopCmdGroup(),
 				p.NewClusterSessionCmdGroup(),
+				p.NewClusterEventsCmd(),
+				p.NewClusterSecretsCmdGroup(),
+				p.NewClusterKubeconfigCmdGroup(),
+				p.NewClusterSyncCmd(),
+				p.NewClusterDiffCmd(),
+				p.NewClusterWatchCmd(),
+				p.NewClusterFleetCmdGroup(),
+				p.NewClusterNotifyCmdGroup(),
+				p.NewClusterTopCmd(),
+				p.NewClusterMetricsCmd(),
+				p.NewClusterCapacityCmd(),
+				p.NewClusterUsageCmd(),
 			},
 		},
 	}
@@ -0,0 +1,484 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterDiffOptions struct {
+	Kubeconfig string
+	Portal     string
+	GitURL     string
+	GitRef     string
+	Directory  string
+	Path       string
+	Output     string
+}
+
+/*
+A field which differs between the definition of a resource in the source and
+the resource in the cluster. The change is "changed" where the field has a
+different value, "missing" where the field is only in the source, and
+"extra" where the field is only in the cluster.
+*/
+type fieldDrift struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+}
+
+/*
+Drift of a resource in the cluster from its definition in the source. The
+status is "in-sync", "changed", "missing" where the resource is only in the
+source, or "extra" where the resource is only in the cluster.
+*/
+type resourceDrift struct {
+	Kind   string       `json:"kind"`
+	Name   string       `json:"name"`
+	Status string       `json:"status"`
+	Fields []fieldDrift `json:"fields,omitempty"`
+}
+
+func (o *ClusterDiffOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if (o.GitURL == "") == (o.Directory == "") {
+		return failures.NewValidationError(errors.New("exactly one of --from-git or --from-dir must be supplied"), "")
+	}
+
+	if o.Output != "table" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are table and json")
+	}
+
+	directory := o.Directory
+
+	if o.GitURL != "" {
+		cloneDir, err := os.MkdirTemp("", "educates-diff-")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to create temporary directory for checkout")
+		}
+
+		defer os.RemoveAll(cloneDir)
+
+		if err = gitClone(o.GitURL, o.GitRef, cloneDir); err != nil {
+			return err
+		}
+
+		directory = cloneDir
+	}
+
+	workshops, trainingPortal, err := loadSyncResources(filepath.Join(directory, o.Path))
+
+	if err != nil {
+		return err
+	}
+
+	if trainingPortal != nil {
+		if trainingPortal.GetName() == "" {
+			trainingPortal.SetName(o.Portal)
+		}
+
+		o.Portal = trainingPortal.GetName()
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	existingPortal, err := client.Resource(trainingPortalResource).Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		existingPortal = nil
+	} else if err != nil {
+		return errors.Wrapf(err, "unable to query training portal %q in cluster", o.Portal)
+	}
+
+	var drifts []resourceDrift
+
+	hosted := portalWorkshopNames(existingPortal)
+
+	desired := map[string]bool{}
+
+	for _, workshop := range workshops {
+		desired[workshop.GetName()] = true
+
+		drift, err := diffResource(ctx, client, workshopResource, workshop)
+
+		if err != nil {
+			return err
+		}
+
+		// When the source doesn't define the training portal, the workshop
+		// still needs to be hosted by the existing training portal.
+
+		if trainingPortal == nil && drift.Status != "missing" && !hosted[workshop.GetName()] {
+			drift.Status = "changed"
+			drift.Fields = append(drift.Fields, fieldDrift{Path: fmt.Sprintf("trainingportal/%s spec.workshops", o.Portal), Change: "missing"})
+		}
+
+		drifts = append(drifts, drift)
+	}
+
+	// Workshops hosted by the training portal, or previously synced to it,
+	// which are not in the source are extra.
+
+	synced, err := client.Resource(workshopResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", syncPortalLabel, o.Portal)})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to list workshops in cluster")
+	}
+
+	for _, item := range synced.Items {
+		hosted[item.GetName()] = true
+	}
+
+	var extra []string
+
+	for name := range hosted {
+		if !desired[name] {
+			extra = append(extra, name)
+		}
+	}
+
+	sort.Strings(extra)
+
+	for _, name := range extra {
+		drifts = append(drifts, resourceDrift{Kind: "Workshop", Name: name, Status: "extra"})
+	}
+
+	if trainingPortal != nil {
+		drift, err := diffResource(ctx, client, trainingPortalResource, trainingPortal)
+
+		if err != nil {
+			return err
+		}
+
+		drifts = append(drifts, drift)
+	}
+
+	drifted := 0
+
+	for _, drift := range drifts {
+		if drift.Status != "in-sync" {
+			drifted++
+		}
+	}
+
+	if o.Output == "json" {
+		data, err := json.MarshalIndent(drifts, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode drift report")
+		}
+
+		fmt.Println(string(data))
+	} else {
+		printResourceDrifts(drifts)
+	}
+
+	if drifted != 0 {
+		return failures.NewDriftError(errors.Errorf("drift detected for %d resources", drifted), "run `educates cluster sync` to reconcile the cluster with the source")
+	}
+
+	return nil
+}
+
+func portalWorkshopNames(trainingPortal *unstructured.Unstructured) map[string]bool {
+	names := map[string]bool{}
+
+	if trainingPortal == nil {
+		return names
+	}
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	for _, item := range workshops {
+		if object, ok := item.(map[string]interface{}); ok {
+			if name, ok := object["name"].(string); ok {
+				names[name] = true
+			}
+		}
+	}
+
+	return names
+}
+
+/*
+Compare the definition of a resource from the source against the resource in
+the cluster. So that defaults filled in by the cluster are not reported as
+drift, the definition is first run through a dry run create under a unique
+name, giving the resource as the cluster would store it. The spec is compared
+in full, but for labels and annotations only those set in the source are
+compared, as other tools may add their own.
+*/
+func diffResource(ctx context.Context, client dynamic.Interface, resource schema.GroupVersionResource, object *unstructured.Unstructured) (resourceDrift, error) {
+	drift := resourceDrift{Kind: object.GetKind(), Name: object.GetName()}
+
+	existing, err := client.Resource(resource).Get(ctx, object.GetName(), metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		drift.Status = "missing"
+
+		return drift, nil
+	}
+
+	if err != nil {
+		return drift, errors.Wrapf(err, "unable to query %s %q in cluster", resource.Resource, object.GetName())
+	}
+
+	suffix := make([]byte, 4)
+
+	if _, err = rand.Read(suffix); err != nil {
+		return drift, errors.Wrap(err, "unable to generate resource name")
+	}
+
+	candidate := object.DeepCopy()
+
+	candidate.SetName(fmt.Sprintf("educates-diff-%s", hex.EncodeToString(suffix)))
+	candidate.SetResourceVersion("")
+
+	defaulted, err := client.Resource(resource).Create(ctx, candidate, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+
+	if err != nil {
+		return drift, errors.Wrapf(err, "unable to validate %s %q from source", resource.Resource, object.GetName())
+	}
+
+	desiredSpec, _, _ := unstructured.NestedFieldNoCopy(defaulted.Object, "spec")
+	existingSpec, _, _ := unstructured.NestedFieldNoCopy(existing.Object, "spec")
+
+	drift.Fields = diffFields("spec", desiredSpec, existingSpec)
+
+	for _, field := range []string{"labels", "annotations"} {
+		desiredValues, _, _ := unstructured.NestedStringMap(object.Object, "metadata", field)
+		existingValues, _, _ := unstructured.NestedStringMap(existing.Object, "metadata", field)
+
+		var keys []string
+
+		for key := range desiredValues {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value, found := existingValues[key]
+
+			if !found {
+				drift.Fields = append(drift.Fields, fieldDrift{Path: fmt.Sprintf("metadata.%s[%s]", field, key), Change: "missing"})
+			} else if value != desiredValues[key] {
+				drift.Fields = append(drift.Fields, fieldDrift{Path: fmt.Sprintf("metadata.%s[%s]", field, key), Change: "changed"})
+			}
+		}
+	}
+
+	drift.Status = "in-sync"
+
+	if len(drift.Fields) != 0 {
+		drift.Status = "changed"
+	}
+
+	return drift, nil
+}
+
+/*
+Return the fields which differ between the desired and existing values.
+Nested objects are compared field by field, as are lists of the same length
+holding objects. Any other list is compared as a whole.
+*/
+func diffFields(path string, desired interface{}, existing interface{}) []fieldDrift {
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+	existingMap, existingIsMap := existing.(map[string]interface{})
+
+	if desiredIsMap && existingIsMap {
+		keys := map[string]bool{}
+
+		for key := range desiredMap {
+			keys[key] = true
+		}
+
+		for key := range existingMap {
+			keys[key] = true
+		}
+
+		var sortedKeys []string
+
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+
+		sort.Strings(sortedKeys)
+
+		var drifts []fieldDrift
+
+		for _, key := range sortedKeys {
+			desiredValue, inDesired := desiredMap[key]
+			existingValue, inExisting := existingMap[key]
+
+			switch {
+			case !inExisting:
+				drifts = append(drifts, fieldDrift{Path: path + "." + key, Change: "missing"})
+			case !inDesired:
+				drifts = append(drifts, fieldDrift{Path: path + "." + key, Change: "extra"})
+			default:
+				drifts = append(drifts, diffFields(path+"."+key, desiredValue, existingValue)...)
+			}
+		}
+
+		return drifts
+	}
+
+	desiredList, desiredIsList := desired.([]interface{})
+	existingList, existingIsList := existing.([]interface{})
+
+	if desiredIsList && existingIsList && len(desiredList) == len(existingList) {
+		var drifts []fieldDrift
+
+		for i := range desiredList {
+			_, desiredItemIsMap := desiredList[i].(map[string]interface{})
+			_, existingItemIsMap := existingList[i].(map[string]interface{})
+
+			if !desiredItemIsMap || !existingItemIsMap {
+				if !reflect.DeepEqual(desired, existing) {
+					return []fieldDrift{{Path: path, Change: "changed"}}
+				}
+
+				return nil
+			}
+
+			drifts = append(drifts, diffFields(fmt.Sprintf("%s[%d]", path, i), desiredList[i], existingList[i])...)
+		}
+
+		return drifts
+	}
+
+	if !reflect.DeepEqual(desired, existing) {
+		return []fieldDrift{{Path: path, Change: "changed"}}
+	}
+
+	return nil
+}
+
+func printResourceDrifts(drifts []resourceDrift) {
+	symbols := map[string]string{"changed": "~", "missing": "+", "extra": "-"}
+
+	descriptions := map[string]string{
+		"in-sync": "in sync",
+		"changed": "changed",
+		"missing": "missing from cluster",
+		"extra":   "not in source",
+	}
+
+	for _, drift := range drifts {
+		fmt.Printf("%s %s: %s\n", drift.Kind, drift.Name, descriptions[drift.Status])
+
+		for _, field := range drift.Fields {
+			switch field.Change {
+			case "missing":
+				fmt.Printf("  %s %s (only in source)\n", symbols[field.Change], field.Path)
+			case "extra":
+				fmt.Printf("  %s %s (only in cluster)\n", symbols[field.Change], field.Path)
+			default:
+				fmt.Printf("  %s %s\n", symbols[field.Change], field.Path)
+			}
+		}
+	}
+}
+
+func (p *ProjectInfo) NewClusterDiffCmd() *cobra.Command {
+	var o ClusterDiffOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "diff",
+		Short: "Report drift of workshops in Kubernetes from a Git repository",
+		Long: `Report drift of workshops in Kubernetes from a Git repository.
+
+Reads workshop definitions, and optionally a training portal definition, from
+YAML files in a directory of a Git repository or a local directory, the same
+as "educates cluster sync" does, and compares them against the resources in
+the cluster. Reports resources missing from the cluster, workshops hosted by
+the training portal which are not in the source, and the fields which have
+changed. Defaults filled in by the cluster are not reported as changes.
+
+The command exits with status 6 when drift is found, so it can be used in CI
+to check the cluster matches the source. No changes are made to the cluster.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal if not defined by the source",
+	)
+	c.Flags().StringVar(
+		&o.GitURL,
+		"from-git",
+		"",
+		"URL of Git repository holding the resources to compare",
+	)
+	c.Flags().StringVar(
+		&o.GitRef,
+		"ref",
+		"",
+		"branch or tag of the Git repository to compare against",
+	)
+	c.Flags().StringVar(
+		&o.Directory,
+		"from-dir",
+		"",
+		"local directory holding the resources to compare instead of a Git repository",
+	)
+	c.Flags().StringVar(
+		&o.Path,
+		"path",
+		"",
+		"path of the directory within the source holding the resources",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"table",
+		"output format, one of table or json",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
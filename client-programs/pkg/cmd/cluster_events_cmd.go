@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type ClusterEventsOptions struct {
+	Kubeconfig string
+	Portal     string
+	Workshop   string
+	Session    string
+	Since      time.Duration
+	Follow     bool
+	Interval   time.Duration
+}
+
+/*
+A single entry in the merged event stream. Entries come from Kubernetes
+events, or are derived from the status of workshop environments and sessions
+as updated by the operators.
+*/
+type clusterEvent struct {
+	Key       string
+	Time      time.Time
+	Type      string
+	Namespace string
+	Object    string
+	Reason    string
+	Message   string
+}
+
+/*
+Names of the Educates resources and namespaces matching the filters given on
+the command line, which are used to select the events of interest.
+*/
+type clusterEventsScope struct {
+	namespaces map[string]bool
+	objects    map[string]bool
+	prefixes   []string
+}
+
+func (s *clusterEventsScope) add(name string) {
+	s.namespaces[name] = true
+	s.objects[name] = true
+}
+
+func (s *clusterEventsScope) matches(namespace string, name string) bool {
+	if s.namespaces[namespace] || s.objects[name] {
+		return true
+	}
+
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *ClusterEventsOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	seen := map[string]bool{}
+
+	header := true
+
+	since := time.Time{}
+
+	if o.Since > 0 {
+		since = time.Now().Add(-o.Since)
+	}
+
+	for {
+		events, err := o.collect(ctx, client, dynamicClient)
+
+		if err != nil {
+			if !o.Follow {
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		}
+
+		var latest []clusterEvent
+
+		for _, event := range events {
+			if seen[event.Key] || event.Time.Before(since) {
+				continue
+			}
+
+			seen[event.Key] = true
+
+			latest = append(latest, event)
+		}
+
+		if !o.Follow && len(latest) == 0 {
+			fmt.Println("No events found.")
+			return nil
+		}
+
+		if len(latest) != 0 {
+			w := new(tabwriter.Writer)
+			w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+			if header {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "TIME", "TYPE", "NAMESPACE", "OBJECT", "REASON", "MESSAGE")
+
+				header = false
+			}
+
+			for _, event := range latest {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", event.Time.Local().Format("2006-01-02 15:04:05"), event.Type, event.Namespace, event.Object, event.Reason, event.Message)
+			}
+
+			w.Flush()
+		}
+
+		if !o.Follow {
+			return nil
+		}
+
+		if cluster.Sleep(ctx, o.Interval) != nil {
+			return nil
+		}
+	}
+}
+
+/*
+Collect the events for the Educates resources matching the filters, ordered
+by time. Kubernetes events are included where they occurred in a namespace
+belonging to Educates, or relate to an Educates custom resource, in which
+case they are recorded in the default namespace.
+*/
+func (o *ClusterEventsOptions) collect(ctx context.Context, client *kubernetes.Clientset, dynamicClient dynamic.Interface) ([]clusterEvent, error) {
+	scope, resources, err := o.scope(ctx, dynamicClient)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var events []clusterEvent
+
+	for _, item := range resources {
+		kind := strings.ToLower(item.GetKind())
+
+		events = append(events, clusterEvent{
+			Key:     fmt.Sprintf("%s/%s/created/%s", kind, item.GetName(), item.GetUID()),
+			Time:    item.GetCreationTimestamp().Time,
+			Type:    "Normal",
+			Object:  fmt.Sprintf("%s/%s", kind, item.GetName()),
+			Reason:  "Created",
+			Message: fmt.Sprintf("%s created", item.GetKind()),
+		})
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if phase == "" {
+			continue
+		}
+
+		updated, ok := statusUpdateTime(&item)
+
+		if !ok {
+			continue
+		}
+
+		message, _, _ := unstructured.NestedString(item.Object, "status", "educates", "message")
+
+		if message == "" {
+			message = fmt.Sprintf("%s is %s", item.GetKind(), strings.ToLower(phase))
+		}
+
+		eventType := "Normal"
+
+		if phase == "Failed" {
+			eventType = "Warning"
+		}
+
+		events = append(events, clusterEvent{
+			Key:     fmt.Sprintf("%s/%s/%s/%s", kind, item.GetName(), phase, updated.Format(time.RFC3339)),
+			Time:    updated,
+			Type:    eventType,
+			Object:  fmt.Sprintf("%s/%s", kind, item.GetName()),
+			Reason:  phase,
+			Message: strings.TrimSpace(message),
+		})
+	}
+
+	eventList, err := client.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list events")
+	}
+
+	for i := range eventList.Items {
+		event := &eventList.Items[i]
+
+		educatesObject := strings.HasPrefix(event.InvolvedObject.APIVersion, "training.educates.dev/")
+
+		if !scope.matches(event.Namespace, event.InvolvedObject.Name) {
+			// Without any filters events for all Educates resources are
+			// included, including those recorded for changes made using
+			// the CLI.
+
+			if !(o.Portal == "" && o.Workshop == "" && o.Session == "" && (educatesObject || event.Source.Component == "educates-cli")) {
+				continue
+			}
+		}
+
+		events = append(events, clusterEvent{
+			Key:       fmt.Sprintf("%s/%d", event.UID, event.Count),
+			Time:      eventTimestamp(event),
+			Type:      event.Type,
+			Namespace: event.Namespace,
+			Object:    fmt.Sprintf("%s/%s", strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name),
+			Reason:    event.Reason,
+			Message:   strings.TrimSpace(event.Message),
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events, nil
+}
+
+/*
+Work out the namespaces and resource names to match events against, also
+returning the workshop environments and sessions in scope.
+*/
+func (o *ClusterEventsOptions) scope(ctx context.Context, client dynamic.Interface) (*clusterEventsScope, []unstructured.Unstructured, error) {
+	scope := &clusterEventsScope{namespaces: map[string]bool{}, objects: map[string]bool{}}
+
+	var resources []unstructured.Unstructured
+
+	environments, err := client.Resource(workshopEnvironmentResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to list workshop environments")
+	}
+
+	sessions, err := client.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to list workshop sessions")
+	}
+
+	if o.Session == "" {
+		if o.Workshop == "" {
+			scope.add("educates")
+
+			if o.Portal != "" {
+				scope.add(o.Portal)
+				scope.add(o.Portal + "-ui")
+			}
+		} else {
+			scope.objects[o.Workshop] = true
+		}
+	}
+
+	matchedEnvironments := map[string]bool{}
+
+	for _, item := range environments.Items {
+		portal := item.GetLabels()["training.educates.dev/portal.name"]
+		workshop, _, _ := unstructured.NestedString(item.Object, "spec", "workshop", "name")
+
+		if o.Portal != "" && portal != o.Portal {
+			continue
+		}
+
+		if o.Workshop != "" && workshop != o.Workshop {
+			continue
+		}
+
+		matchedEnvironments[item.GetName()] = true
+
+		if o.Session == "" {
+			scope.add(item.GetName())
+
+			resources = append(resources, item)
+		}
+	}
+
+	for _, item := range sessions.Items {
+		labels := item.GetLabels()
+
+		if !matchedEnvironments[labels["training.educates.dev/environment.name"]] {
+			continue
+		}
+
+		if o.Session != "" && item.GetName() != o.Session {
+			continue
+		}
+
+		// The deployments and pods for a session are created in the
+		// workshop environment namespace and named after the session.
+
+		scope.add(item.GetName())
+		scope.prefixes = append(scope.prefixes, item.GetName()+"-")
+
+		resources = append(resources, item)
+	}
+
+	return scope, resources, nil
+}
+
+func (p *ProjectInfo) NewClusterEventsCmd() *cobra.Command {
+	var o ClusterEventsOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "events",
+		Short: "Output events for portals, workshops and sessions",
+		Long: `Output events for portals, workshops and sessions.
+
+Merges Kubernetes events from the namespaces used by Educates and for the
+Educates custom resources, with changes in status of workshop environments
+and sessions made by the operators, into a single stream ordered by time.
+Events can be filtered by training portal, workshop or session. Kubernetes
+only retains events for a limited time, usually one hour, so older events
+may not be shown.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"",
+		"name of training portal to filter events, defaults to all training portals",
+	)
+	c.Flags().StringVarP(
+		&o.Workshop,
+		"workshop",
+		"w",
+		"",
+		"name of workshop to filter events",
+	)
+	c.Flags().StringVarP(
+		&o.Session,
+		"session",
+		"s",
+		"",
+		"name of workshop session to filter events",
+	)
+	c.Flags().DurationVar(
+		&o.Since,
+		"since",
+		0,
+		"only output events newer than a relative duration such as 30m",
+	)
+	c.Flags().BoolVarP(
+		&o.Follow,
+		"follow",
+		"f",
+		false,
+		"continue to output new events as they occur",
+	)
+	c.Flags().DurationVar(
+		&o.Interval,
+		"interval",
+		5*time.Second,
+		"time duration between checks for new events when following",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+
+	return c
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+func (p *ProjectInfo) NewClusterFleetCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "fleet",
+		Short: "Manage fleets of clusters targeted together",
+		Long: `Manage fleets of clusters targeted together.
+
+A fleet is a named set of kubeconfig contexts. Commands which deploy, update
+or delete workshops and training portals accept a --fleet option, in which
+case the same change is applied to every cluster in the fleet in parallel,
+with success or failure reported for each cluster.`,
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewClusterFleetCreateCmd(),
+				p.NewClusterFleetListCmd(),
+				p.NewClusterFleetDeleteCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
+
+/*
+Apply an action to each cluster in a fleet. The action is run in parallel
+against all clusters, with the outcome for each cluster reported once all have
+completed. An error is returned if the action failed for any cluster.
+*/
+func applyToFleet(name string, kubeconfig string, action func(clusterConfig *cluster.ClusterConfig) error) error {
+	fleet, err := config.LoadFleetConfig(name)
+
+	if err != nil {
+		return err
+	}
+
+	if len(fleet.Contexts) == 0 {
+		return errors.Errorf("fleet %q has no clusters", name)
+	}
+
+	results := map[string]error{}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, kubeContext := range fleet.Contexts {
+		wg.Add(1)
+
+		go func(kubeContext string) {
+			defer wg.Done()
+
+			err := action(cluster.NewClusterConfigForContext(kubeconfig, kubeContext))
+
+			mutex.Lock()
+			results[kubeContext] = err
+			mutex.Unlock()
+		}(kubeContext)
+	}
+
+	wg.Wait()
+
+	contexts := append([]string{}, fleet.Contexts...)
+
+	sort.Strings(contexts)
+
+	failed := 0
+
+	for _, kubeContext := range contexts {
+		if err := results[kubeContext]; err != nil {
+			fmt.Fprintf(os.Stderr, "%s: failed: %s\n", kubeContext, err)
+			failed++
+		} else {
+			fmt.Printf("%s: succeeded\n", kubeContext)
+		}
+	}
+
+	if failed != 0 {
+		return errors.Errorf("failed on %d of %d clusters in fleet %q", failed, len(contexts), name)
+	}
+
+	return nil
+}
+
+func completeFleetNames(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	fleets, err := config.ListFleetConfigs()
+
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+
+	for _, fleet := range fleets {
+		names = append(names, fleet.Name)
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterFleetCreateOptions struct {
+	Kubeconfig string
+	Contexts   []string
+}
+
+func (o *ClusterFleetCreateOptions) Run(ctx context.Context, name string) error {
+	if len(o.Contexts) == 0 {
+		return failures.NewValidationError(errors.New("no kubeconfig contexts supplied for fleet"), "supply contexts using the --context option")
+	}
+
+	// Check that the contexts exist so mistakes are caught now rather than
+	// when the fleet is first used.
+
+	contextNames, err := cluster.ContextNames(o.Kubeconfig)
+
+	if err != nil {
+		return err
+	}
+
+	known := map[string]bool{}
+
+	for _, contextName := range contextNames {
+		known[contextName] = true
+	}
+
+	for _, contextName := range o.Contexts {
+		if !known[contextName] {
+			return failures.NewNotFoundError(errors.Errorf("no kubeconfig context named %q", contextName), "run `kubectl config get-contexts` to see available contexts")
+		}
+	}
+
+	err = config.SaveFleetConfig(&config.FleetConfig{Name: name, Contexts: o.Contexts})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Fleet %q created with %d clusters.\n", name, len(o.Contexts))
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterFleetCreateCmd() *cobra.Command {
+	var o ClusterFleetCreateOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "create NAME",
+		Short: "Create or replace a fleet of clusters",
+		RunE:  func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args[0]) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringSliceVar(
+		&o.Contexts,
+		"context",
+		[]string{},
+		"kubeconfig context for a cluster in the fleet (can be specified multiple times)",
+	)
+
+	return withAuditLogging(c)
+}
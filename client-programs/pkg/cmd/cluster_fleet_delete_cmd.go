@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+type ClusterFleetDeleteOptions struct {
+}
+
+func (o *ClusterFleetDeleteOptions) Run(ctx context.Context, name string) error {
+	err := config.DeleteFleetConfig(name)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Fleet %q deleted.\n", name)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterFleetDeleteCmd() *cobra.Command {
+	var o ClusterFleetDeleteOptions
+
+	var c = &cobra.Command{
+		Args:              cobra.ExactArgs(1),
+		Use:               "delete NAME",
+		Short:             "Delete a fleet of clusters",
+		ValidArgsFunction: completeFleetNames,
+		RunE:              func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args[0]) },
+	}
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+type ClusterFleetListOptions struct {
+}
+
+func (o *ClusterFleetListOptions) Run(ctx context.Context) error {
+	fleets, err := config.ListFleetConfigs()
+
+	if err != nil {
+		return err
+	}
+
+	if len(fleets) == 0 {
+		fmt.Println("No fleets found.")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\n", "NAME", "CONTEXTS")
+
+	for _, fleet := range fleets {
+		fmt.Fprintf(w, "%s\t%s\n", fleet.Name, strings.Join(fleet.Contexts, ","))
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterFleetListCmd() *cobra.Command {
+	var o ClusterFleetListOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "list",
+		Short: "List fleets of clusters",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	return c
+}
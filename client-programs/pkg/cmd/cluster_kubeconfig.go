@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Limited access to a training portal is given using a service account created
+in the namespace of the training portal, so it is removed along with the
+training portal. The service account is bound to a built-in cluster role in
+each namespace it can access, and to a cluster role allowing it to read the
+Educates resources for the training portal. All the resources created are
+labelled so they can be found when access is revoked.
+*/
+const (
+	accessNameLabel   = "training.educates.dev/access.name"
+	accessPortalLabel = "training.educates.dev/access.portal"
+)
+
+func accessServiceAccountName(name string) string {
+	return fmt.Sprintf("educates-access-%s", name)
+}
+
+func accessBindingName(portal string, name string) string {
+	return fmt.Sprintf("educates-access-%s-%s", portal, name)
+}
+
+func accessLabels(portal string, name string) map[string]interface{} {
+	return map[string]interface{}{
+		accessNameLabel:   name,
+		accessPortalLabel: portal,
+	}
+}
+
+/*
+Work out the namespaces access is given to, being those of the workshop
+environments for the training portal, or just the given workshop, and the
+workshop sessions which currently exist for them. When access is for the
+whole training portal, the namespace of the training portal is included.
+Also returns the names of the workshop environments.
+*/
+func accessNamespaces(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string, workshop string) ([]string, []string, error) {
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	if _, err = dynamicClient.Resource(trainingPortalResource).Get(ctx, portal, metav1.GetOptions{}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil, failures.NewNotFoundError(errors.Errorf("no training portal %q found", portal), "run `educates cluster portal list` to see training portals")
+		}
+
+		return nil, nil, errors.Wrapf(err, "unable to retrieve training portal %q", portal)
+	}
+
+	var namespaces []string
+	var environments []string
+
+	if workshop == "" {
+		namespaces = append(namespaces, fmt.Sprintf("%s-ui", portal))
+	}
+
+	environmentList, err := dynamicClient.Resource(workshopEnvironmentResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("training.educates.dev/portal.name=%s", portal)})
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to list workshop environments")
+	}
+
+	for _, item := range environmentList.Items {
+		name, _, _ := unstructured.NestedString(item.Object, "spec", "workshop", "name")
+
+		if item.GetDeletionTimestamp() != nil || (workshop != "" && name != workshop) {
+			continue
+		}
+
+		environments = append(environments, item.GetName())
+		namespaces = append(namespaces, item.GetName())
+
+		sessionList, err := dynamicClient.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("training.educates.dev/environment.name=%s", item.GetName())})
+
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "unable to list workshop sessions")
+		}
+
+		for _, session := range sessionList.Items {
+			if session.GetDeletionTimestamp() == nil {
+				namespaces = append(namespaces, session.GetName())
+			}
+		}
+	}
+
+	if workshop != "" && len(environments) == 0 {
+		return nil, nil, failures.NewNotFoundError(errors.Errorf("unable to find workshop %q in training portal %q", workshop, portal), "run `educates cluster workshop list` to see deployed workshops")
+	}
+
+	sort.Strings(environments)
+
+	return namespaces, environments, nil
+}
+
+/*
+Create or update the service account and bindings giving access to the
+namespaces with the given cluster role. The namespaces are those which exist
+at the time, so this must be run again for the service account to be able to
+access namespaces created since, such as for new workshop sessions. Bindings
+left from an earlier grant for namespaces no longer included are removed.
+*/
+func grantAccess(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string, name string, role string, namespaces []string, environments []string) error {
+	serviceAccount := accessServiceAccountName(name)
+	serviceAccountNamespace := fmt.Sprintf("%s-ui", portal)
+	bindingName := accessBindingName(portal, name)
+
+	subjects := []interface{}{
+		map[string]interface{}{
+			"kind":      "ServiceAccount",
+			"name":      serviceAccount,
+			"namespace": serviceAccountNamespace,
+		},
+	}
+
+	var environmentNames []interface{}
+
+	for _, environment := range environments {
+		environmentNames = append(environmentNames, environment)
+	}
+
+	rules := []interface{}{
+		map[string]interface{}{
+			"apiGroups":     []interface{}{"training.educates.dev"},
+			"resources":     []interface{}{"trainingportals"},
+			"resourceNames": []interface{}{portal},
+			"verbs":         []interface{}{"get"},
+		},
+	}
+
+	if len(environmentNames) != 0 {
+		rules = append(rules, map[string]interface{}{
+			"apiGroups":     []interface{}{"training.educates.dev"},
+			"resources":     []interface{}{"workshopenvironments"},
+			"resourceNames": environmentNames,
+			"verbs":         []interface{}{"get"},
+		})
+	}
+
+	objects := []*unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ServiceAccount",
+			"metadata": map[string]interface{}{
+				"name":      serviceAccount,
+				"namespace": serviceAccountNamespace,
+				"labels":    accessLabels(portal, name),
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRole",
+			"metadata": map[string]interface{}{
+				"name":   bindingName,
+				"labels": accessLabels(portal, name),
+			},
+			"rules": rules,
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRoleBinding",
+			"metadata": map[string]interface{}{
+				"name":   bindingName,
+				"labels": accessLabels(portal, name),
+			},
+			"roleRef": map[string]interface{}{
+				"apiGroup": "rbac.authorization.k8s.io",
+				"kind":     "ClusterRole",
+				"name":     bindingName,
+			},
+			"subjects": subjects,
+		}},
+	}
+
+	for _, namespace := range namespaces {
+		// The training portal namespace holds the token for the service
+		// account of the training portal, which has access to the whole
+		// cluster. Being able to run pods or exec into the training portal
+		// would give a way to use it, so only view access is ever given.
+
+		namespaceRole := role
+
+		if namespace == serviceAccountNamespace {
+			namespaceRole = "view"
+		}
+
+		objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "RoleBinding",
+			"metadata": map[string]interface{}{
+				"name":      bindingName,
+				"namespace": namespace,
+				"labels":    accessLabels(portal, name),
+			},
+			"roleRef": map[string]interface{}{
+				"apiGroup": "rbac.authorization.k8s.io",
+				"kind":     "ClusterRole",
+				"name":     namespaceRole,
+			},
+			"subjects": subjects,
+		}})
+	}
+
+	if err := clusterConfig.ApplyResources(ctx, objects, "educates-cli"); err != nil {
+		return err
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	bindings, err := client.RbacV1().RoleBindings("").List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s,%s=%s", accessNameLabel, name, accessPortalLabel, portal)})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to list role bindings")
+	}
+
+	for _, binding := range bindings.Items {
+		if slices.Contains(namespaces, binding.Namespace) {
+			continue
+		}
+
+		err = client.RbacV1().RoleBindings(binding.Namespace).Delete(ctx, binding.Name, metav1.DeleteOptions{})
+
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "unable to delete role binding in namespace %q", binding.Namespace)
+		}
+	}
+
+	return nil
+}
+
+/*
+Remove the service account and bindings giving access to a training portal,
+returning whether any existed. Deleting the service account invalidates any
+tokens issued for it.
+*/
+func revokeAccess(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string, name string) (bool, error) {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	found := false
+
+	selector := fmt.Sprintf("%s=%s,%s=%s", accessNameLabel, name, accessPortalLabel, portal)
+
+	err = client.CoreV1().ServiceAccounts(fmt.Sprintf("%s-ui", portal)).Delete(ctx, accessServiceAccountName(name), metav1.DeleteOptions{})
+
+	if err == nil {
+		found = true
+	} else if !k8serrors.IsNotFound(err) {
+		return false, errors.Wrapf(err, "unable to delete service account for %q", name)
+	}
+
+	bindings, err := client.RbacV1().RoleBindings("").List(ctx, metav1.ListOptions{LabelSelector: selector})
+
+	if err != nil {
+		return false, errors.Wrap(err, "unable to list role bindings")
+	}
+
+	for _, binding := range bindings.Items {
+		err = client.RbacV1().RoleBindings(binding.Namespace).Delete(ctx, binding.Name, metav1.DeleteOptions{})
+
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "unable to delete role binding in namespace %q", binding.Namespace)
+		}
+
+		found = true
+	}
+
+	err = client.RbacV1().ClusterRoleBindings().Delete(ctx, accessBindingName(portal, name), metav1.DeleteOptions{})
+
+	if err == nil {
+		found = true
+	} else if !k8serrors.IsNotFound(err) {
+		return false, errors.Wrapf(err, "unable to delete cluster role binding for %q", name)
+	}
+
+	err = client.RbacV1().ClusterRoles().Delete(ctx, accessBindingName(portal, name), metav1.DeleteOptions{})
+
+	if err == nil {
+		found = true
+	} else if !k8serrors.IsNotFound(err) {
+		return false, errors.Wrapf(err, "unable to delete cluster role for %q", name)
+	}
+
+	return found, nil
+}
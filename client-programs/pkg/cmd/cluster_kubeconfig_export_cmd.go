@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterKubeconfigExportOptions struct {
+	Kubeconfig string
+	Portal     string
+	Workshop   string
+	Name       string
+	Role       string
+	Duration   time.Duration
+	Output     string
+}
+
+func (o *ClusterKubeconfigExportOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Role != "view" && o.Role != "edit" {
+		return failures.NewValidationError(errors.Errorf("unsupported role %q", o.Role), "supported roles are view and edit")
+	}
+
+	if o.Duration < 10*time.Minute {
+		return failures.NewValidationError(errors.Errorf("invalid duration %s", o.Duration), "the kubeconfig must be valid for at least ten minutes")
+	}
+
+	name := o.Name
+
+	if name == "" {
+		name = "portal"
+
+		if o.Workshop != "" {
+			name = o.Workshop
+		}
+	}
+
+	if errs := validation.IsDNS1123Label(name); len(errs) != 0 {
+		return failures.NewValidationError(errors.Errorf("invalid name %q: %s", name, strings.Join(errs, ", ")), "supply a name using --name")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	namespaces, environments, err := accessNamespaces(ctx, clusterConfig, o.Portal, o.Workshop)
+
+	if err != nil {
+		return err
+	}
+
+	if err = grantAccess(ctx, clusterConfig, o.Portal, name, o.Role, namespaces, environments); err != nil {
+		return err
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	// Tokens issued using the token request API expire, and are invalidated
+	// straight away if the service account is deleted, so there is no long
+	// lived secret holding the token left in the cluster.
+
+	expirationSeconds := int64(o.Duration.Seconds())
+
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+
+	serviceAccountNamespace := fmt.Sprintf("%s-ui", o.Portal)
+
+	token, err := client.CoreV1().ServiceAccounts(serviceAccountNamespace).CreateToken(ctx, accessServiceAccountName(name), tokenRequest, metav1.CreateOptions{})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create token for service account %q", accessServiceAccountName(name))
+	}
+
+	restConfig, err := cluster.GetConfigForContext("", o.Kubeconfig, "")
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrap(err, "unable to build client config"), failures.ClusterHint)
+	}
+
+	// The certificate authority data is embedded and the token is given
+	// directly, so the kubeconfig doesn't depend on any local files or
+	// credential plugins for a cloud provider being installed.
+
+	caData := restConfig.CAData
+
+	if len(caData) == 0 && restConfig.CAFile != "" {
+		if caData, err = os.ReadFile(restConfig.CAFile); err != nil {
+			return errors.Wrapf(err, "unable to read certificate authority file %s", restConfig.CAFile)
+		}
+	}
+
+	contextName := fmt.Sprintf("%s-%s", o.Portal, name)
+
+	// Where access is for a single workshop, default to the namespace of the
+	// current workshop environment for the workshop.
+
+	namespace := serviceAccountNamespace
+
+	if o.Workshop != "" {
+		dynamicClient, err := clusterConfig.GetDynamicClient()
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
+
+		environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, o.Workshop)
+
+		if err != nil {
+			return err
+		}
+
+		if environment != nil {
+			namespace = environment.GetName()
+		}
+	}
+
+	kubeconfig := clientcmdapi.NewConfig()
+
+	kubeconfig.Clusters[contextName] = &clientcmdapi.Cluster{
+		Server:                   restConfig.Host,
+		CertificateAuthorityData: caData,
+		InsecureSkipTLSVerify:    restConfig.Insecure,
+		TLSServerName:            restConfig.ServerName,
+	}
+
+	kubeconfig.AuthInfos[contextName] = &clientcmdapi.AuthInfo{
+		Token: token.Status.Token,
+	}
+
+	kubeconfig.Contexts[contextName] = &clientcmdapi.Context{
+		Cluster:   contextName,
+		AuthInfo:  contextName,
+		Namespace: namespace,
+	}
+
+	kubeconfig.CurrentContext = contextName
+
+	data, err := clientcmd.Write(*kubeconfig)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate kubeconfig")
+	}
+
+	if o.Output == "" || o.Output == "-" {
+		fmt.Print(string(data))
+
+		return nil
+	}
+
+	if err = os.WriteFile(o.Output, data, 0o600); err != nil {
+		return errors.Wrapf(err, "unable to write kubeconfig to %s", o.Output)
+	}
+
+	fmt.Printf("Kubeconfig for %d namespaces written to %s, valid until %s.\n", len(namespaces), o.Output, token.Status.ExpirationTimestamp.Format(time.RFC3339))
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterKubeconfigExportCmd() *cobra.Command {
+	var o ClusterKubeconfigExportOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "export",
+		Short: "Export limited kubeconfig for training portal",
+		Long: `Export a limited kubeconfig for a training portal.
+
+Creates a service account with access to just the namespaces of a training
+portal and outputs a kubeconfig for it, which can be given to co-instructors
+or to automation instead of sharing a kubeconfig with cluster admin access.
+The service account is given the built-in "view" cluster role, or the "edit"
+cluster role when --role edit is used, for the namespaces of the workshop
+environments of the training portal, and the namespaces of workshop sessions.
+For the namespace of the training portal itself only the "view" cluster role
+is ever given, as the training portal runs with elevated access to the
+cluster. The service account can also read the training portal and workshop
+environment resources. Use --workshop to limit access to the namespaces for a
+single workshop.
+
+The namespaces the service account can access are those which exist at the
+time the kubeconfig is exported. Workshop sessions created afterwards, or a
+workshop environment which has been replaced, are not accessible until the
+kubeconfig is exported again with the same --name, or --workshop, which
+updates the namespaces the service account can access.
+
+The kubeconfig holds the address of the cluster, its certificate authority
+and a token for the service account, so it doesn't depend on any local files
+or on credential plugins for a cloud provider being installed. The token
+expires after the time given by --duration, subject to the maximum allowed
+by the cluster. Export again to issue a new token. Use "educates cluster
+kubeconfig revoke" with the same --name, or --workshop, to remove access
+before the token expires.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVarP(
+		&o.Workshop,
+		"workshop",
+		"w",
+		"",
+		"name of the workshop to limit access to",
+	)
+	c.Flags().StringVar(
+		&o.Name,
+		"name",
+		"",
+		"name identifying who the access is for, defaults to the workshop name",
+	)
+	c.Flags().StringVar(
+		&o.Role,
+		"role",
+		"view",
+		"level of access to give to workshop namespaces, either view or edit",
+	)
+	c.Flags().DurationVar(
+		&o.Duration,
+		"duration",
+		24*time.Hour,
+		"how long the token in the kubeconfig is valid for",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"",
+		"file to write the kubeconfig to instead of stdout",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+
+	return withAuditLogging(c)
+}
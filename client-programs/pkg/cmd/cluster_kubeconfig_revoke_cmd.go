@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterKubeconfigRevokeOptions struct {
+	Kubeconfig string
+	Portal     string
+	Workshop   string
+	Name       string
+}
+
+func (o *ClusterKubeconfigRevokeOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	name := o.Name
+
+	if name == "" {
+		name = "portal"
+
+		if o.Workshop != "" {
+			name = o.Workshop
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	found, err := revokeAccess(ctx, clusterConfig, o.Portal, name)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return failures.NewNotFoundError(errors.Errorf("no access named %q found for training portal %q", name, o.Portal), "")
+	}
+
+	fmt.Printf("Access %q to training portal %q revoked.\n", name, o.Portal)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterKubeconfigRevokeCmd() *cobra.Command {
+	var o ClusterKubeconfigRevokeOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "revoke",
+		Short: "Revoke access given by exported kubeconfig",
+		Long: `Revoke the access given by an exported kubeconfig.
+
+Deletes the service account and role bindings created when exporting a
+limited kubeconfig for a training portal. Deleting the service account
+invalidates the token in any kubeconfig exported for it, so access is
+removed straight away rather than when the token expires. The access to
+revoke is identified by --name, or --workshop, as used when exporting.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVarP(
+		&o.Workshop,
+		"workshop",
+		"w",
+		"",
+		"name of the workshop access was limited to",
+	)
+	c.Flags().StringVar(
+		&o.Name,
+		"name",
+		"",
+		"name identifying who the access was for, defaults to the workshop name",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+
+	return withAuditLogging(c)
+}
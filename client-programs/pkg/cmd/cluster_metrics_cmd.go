@@ -0,0 +1,436 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+var workshopAllocationResource = schema.GroupVersionResource{Group: "training.educates.dev", Version: "v1beta1", Resource: "workshopallocations"}
+
+type ClusterMetricsOptions struct {
+	Kubeconfig string
+	Portal     string
+	Output     string
+	Serve      string
+}
+
+/*
+A single metric sample. Metrics are named and labelled following Prometheus
+conventions so they can be output in the Prometheus text exposition format.
+*/
+type metricSample struct {
+	Name   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+}
+
+func (m metricSample) labelString() string {
+	var keys []string
+
+	for key := range m.Labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var parts []string
+
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", key, m.Labels[key]))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (o *ClusterMetricsOptions) Run(ctx context.Context) error {
+	if o.Output != "table" && o.Output != "prometheus" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are table and prometheus")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	// When asked to serve the metrics, collect them afresh each time they
+	// are scraped.
+
+	if o.Serve != "" {
+		http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			samples, err := collectMetrics(ctx, dynamicClient, o.Portal)
+
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+			writePrometheusMetrics(w, samples)
+		})
+
+		fmt.Printf("Serving metrics on http://%s/metrics, press Ctrl-C to stop.\n", o.Serve)
+
+		return errors.Wrap(http.ListenAndServe(o.Serve, nil), "unable to serve metrics")
+	}
+
+	samples, err := collectMetrics(ctx, dynamicClient, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	if o.Output == "prometheus" {
+		writePrometheusMetrics(os.Stdout, samples)
+
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\n", "METRIC", "LABELS", "VALUE")
+
+	for _, sample := range samples {
+		fmt.Fprintf(w, "%s\t%s\t%g\n", sample.Name, sample.labelString(), sample.Value)
+	}
+
+	return nil
+}
+
+func writePrometheusMetrics(w io.Writer, samples []metricSample) {
+	seen := map[string]bool{}
+
+	for _, sample := range samples {
+		if !seen[sample.Name] {
+			seen[sample.Name] = true
+
+			fmt.Fprintf(w, "# HELP %s %s\n", sample.Name, sample.Help)
+			fmt.Fprintf(w, "# TYPE %s gauge\n", sample.Name)
+		}
+
+		fmt.Fprintf(w, "%s{%s} %g\n", sample.Name, sample.labelString(), sample.Value)
+	}
+}
+
+/*
+Return the time at which the operator last updated the status of a resource.
+This is derived from the managed fields of the resource, so is only available
+where the cluster records updates to the status subresource separately.
+*/
+func statusUpdateTime(object *unstructured.Unstructured) (time.Time, bool) {
+	var latest time.Time
+
+	for _, entry := range object.GetManagedFields() {
+		if entry.Subresource == "status" && entry.Time != nil && entry.Time.After(latest) {
+			latest = entry.Time.Time
+		}
+	}
+
+	return latest, !latest.IsZero()
+}
+
+/*
+Collect metrics for training portals, workshop environments and sessions
+from the resources in the cluster. Startup and allocation times are measured
+from when the resource was created until the operator last updated its status,
+so are an approximation.
+*/
+func collectMetrics(ctx context.Context, client dynamic.Interface, portalName string) ([]metricSample, error) {
+	var samples []metricSample
+
+	trainingPortals, err := client.Resource(trainingPortalResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list training portals")
+	}
+
+	environments, err := client.Resource(workshopEnvironmentResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list workshop environments")
+	}
+
+	sessions, err := client.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list workshop sessions")
+	}
+
+	allocations, err := client.Resource(workshopAllocationResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list workshop allocations")
+	}
+
+	for _, item := range trainingPortals.Items {
+		if portalName != "" && item.GetName() != portalName {
+			continue
+		}
+
+		sessionsMaximum, found, _ := unstructured.NestedInt64(item.Object, "spec", "portal", "sessions", "maximum")
+
+		if found {
+			samples = append(samples, metricSample{
+				Name:   "educates_portal_sessions_maximum",
+				Help:   "Maximum number of concurrent sessions allowed for the training portal.",
+				Labels: map[string]string{"portal": item.GetName()},
+				Value:  float64(sessionsMaximum),
+			})
+		}
+
+		workshops, _, _ := unstructured.NestedSlice(item.Object, "spec", "workshops")
+
+		samples = append(samples, metricSample{
+			Name:   "educates_portal_workshops",
+			Help:   "Number of workshops hosted by the training portal.",
+			Labels: map[string]string{"portal": item.GetName()},
+			Value:  float64(len(workshops)),
+		})
+	}
+
+	for _, item := range environments.Items {
+		portal := item.GetLabels()["training.educates.dev/portal.name"]
+
+		if portalName != "" && portal != portalName {
+			continue
+		}
+
+		workshop, _, _ := unstructured.NestedString(item.Object, "spec", "workshop", "name")
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		labels := map[string]string{"portal": portal, "workshop": workshop, "environment": item.GetName()}
+
+		samples = append(samples, metricSample{
+			Name:   "educates_environment_running",
+			Help:   "Whether the workshop environment is running.",
+			Labels: labels,
+			Value:  boolMetric(phase == "Running"),
+		})
+
+		if updated, ok := statusUpdateTime(&item); ok && phase == "Running" {
+			samples = append(samples, metricSample{
+				Name:   "educates_environment_startup_seconds",
+				Help:   "Approximate time taken for the workshop environment to start.",
+				Labels: labels,
+				Value:  updated.Sub(item.GetCreationTimestamp().Time).Seconds(),
+			})
+		}
+	}
+
+	// Sessions and allocations are aggregated for each workshop as there can
+	// be large numbers of them.
+
+	type durationTotals struct {
+		sum   float64
+		count int
+	}
+
+	sessionCounts := map[[3]string]int{}
+	sessionStartup := map[[2]string]*durationTotals{}
+	allocationLatency := map[[2]string]*durationTotals{}
+
+	for _, item := range sessions.Items {
+		labels := item.GetLabels()
+
+		portal := labels["training.educates.dev/portal.name"]
+		workshop := labels["training.educates.dev/workshop.name"]
+
+		if portalName != "" && portal != portalName {
+			continue
+		}
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if phase == "" {
+			phase = "Pending"
+		}
+
+		sessionCounts[[3]string{portal, workshop, phase}]++
+
+		if updated, ok := statusUpdateTime(&item); ok && (phase == "Running" || phase == "Allocated") {
+			key := [2]string{portal, workshop}
+
+			if sessionStartup[key] == nil {
+				sessionStartup[key] = &durationTotals{}
+			}
+
+			sessionStartup[key].sum += updated.Sub(item.GetCreationTimestamp().Time).Seconds()
+			sessionStartup[key].count++
+		}
+	}
+
+	for _, item := range allocations.Items {
+		labels := item.GetLabels()
+
+		portal := labels["training.educates.dev/portal.name"]
+		workshop := labels["training.educates.dev/workshop.name"]
+
+		if portalName != "" && portal != portalName {
+			continue
+		}
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if updated, ok := statusUpdateTime(&item); ok && phase == "Allocated" {
+			key := [2]string{portal, workshop}
+
+			if allocationLatency[key] == nil {
+				allocationLatency[key] = &durationTotals{}
+			}
+
+			allocationLatency[key].sum += updated.Sub(item.GetCreationTimestamp().Time).Seconds()
+			allocationLatency[key].count++
+		}
+	}
+
+	var sessionKeys [][3]string
+
+	for key := range sessionCounts {
+		sessionKeys = append(sessionKeys, key)
+	}
+
+	sort.Slice(sessionKeys, func(i, j int) bool {
+		return strings.Join(sessionKeys[i][:], "/") < strings.Join(sessionKeys[j][:], "/")
+	})
+
+	for _, key := range sessionKeys {
+		samples = append(samples, metricSample{
+			Name:   "educates_workshop_sessions",
+			Help:   "Number of workshop sessions in each phase.",
+			Labels: map[string]string{"portal": key[0], "workshop": key[1], "phase": key[2]},
+			Value:  float64(sessionCounts[key]),
+		})
+	}
+
+	durationSamples := func(name string, help string, totals map[[2]string]*durationTotals) {
+		var keys [][2]string
+
+		for key := range totals {
+			keys = append(keys, key)
+		}
+
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i][0]+"/"+keys[i][1] < keys[j][0]+"/"+keys[j][1]
+		})
+
+		for _, key := range keys {
+			labels := map[string]string{"portal": key[0], "workshop": key[1]}
+
+			samples = append(samples, metricSample{
+				Name:   name + "_sum",
+				Help:   help,
+				Labels: labels,
+				Value:  totals[key].sum,
+			}, metricSample{
+				Name:   name + "_count",
+				Help:   help,
+				Labels: labels,
+				Value:  float64(totals[key].count),
+			})
+		}
+	}
+
+	durationSamples("educates_session_startup_seconds", "Approximate time taken for workshop sessions to start.", sessionStartup)
+	durationSamples("educates_session_allocation_seconds", "Approximate time taken to allocate workshop sessions to users.", allocationLatency)
+
+	// Group samples by metric name, as required for the Prometheus text
+	// format, keeping the order in which the metrics were first added.
+
+	order := map[string]int{}
+
+	for i, sample := range samples {
+		if _, exists := order[sample.Name]; !exists {
+			order[sample.Name] = i
+		}
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return order[samples[i].Name] < order[samples[j].Name]
+	})
+
+	return samples, nil
+}
+
+func boolMetric(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+func (p *ProjectInfo) NewClusterMetricsCmd() *cobra.Command {
+	var o ClusterMetricsOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "metrics",
+		Short: "Display metrics for training portals and workshops",
+		Long: `Display metrics for training portals and workshops.
+
+Metrics are derived from the Educates resources in the cluster and include the
+number of workshop sessions in each phase, and approximate times taken for
+workshop environments and sessions to start and for sessions to be allocated
+to users. Startup times are measured from when a resource was created until
+the operator last updated its status.
+
+Use --serve to expose the metrics locally for Prometheus to scrape, with the
+metrics being collected from the cluster each time they are scraped.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"",
+		"name of training portal to collect metrics for, defaults to all training portals",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"table",
+		"output format for the metrics (table or prometheus)",
+	)
+	c.Flags().StringVar(
+		&o.Serve,
+		"serve",
+		"",
+		"address to serve metrics on for Prometheus, for example localhost:9090",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/notify"
+)
+
+func (p *ProjectInfo) NewClusterNotifyCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "notify",
+		Short: "Manage webhook notifications for events",
+		Long: `Manage webhook notifications for events.
+
+When configured, a POST request is made to the webhook URL when a workshop is
+deployed. Run the watch command for the duration of an event to also be
+notified when a workshop environment fails or a training portal or workshop
+runs out of capacity for further sessions. The default payload is compatible
+with Slack incoming webhooks. A custom payload can be supplied as a Go
+template, with the fields .Type, .Portal, .Workshop, .Message and .Time
+available, and a json function for quoting values.`,
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewClusterNotifyConfigureCmd(),
+				p.NewClusterNotifyViewCmd(),
+				p.NewClusterNotifyTestCmd(),
+				p.NewClusterNotifyWatchCmd(),
+				p.NewClusterNotifyRemoveCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
+
+/*
+Send a notification for an event if notifications have been configured. A
+failure to send the notification is reported as a warning only, so that it
+doesn't cause the operation being reported on to fail.
+*/
+func sendNotification(event notify.Event) {
+	config, err := notify.LoadConfig()
+
+	if err == nil && config != nil {
+		err = config.Send(event)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", err)
+	}
+}
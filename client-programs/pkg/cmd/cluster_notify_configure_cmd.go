@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/notify"
+)
+
+type ClusterNotifyConfigureOptions struct {
+	URL          string
+	Template     string
+	TemplateFile string
+	Events       []string
+}
+
+func (o *ClusterNotifyConfigureOptions) Run(ctx context.Context) error {
+	urlInfo, err := url.Parse(o.URL)
+
+	if err != nil || (urlInfo.Scheme != "http" && urlInfo.Scheme != "https") {
+		return failures.NewValidationError(errors.Errorf("invalid webhook URL %q", o.URL), "supply a HTTP or HTTPS URL using --url")
+	}
+
+	for _, event := range o.Events {
+		if !containsString(notify.EventTypes, event) {
+			return failures.NewValidationError(errors.Errorf("unknown event type %q", event), fmt.Sprintf("supported event types are %v", notify.EventTypes))
+		}
+	}
+
+	config := &notify.Config{
+		URL:      o.URL,
+		Template: o.Template,
+		Events:   o.Events,
+	}
+
+	if o.TemplateFile != "" {
+		data, err := os.ReadFile(o.TemplateFile)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read template file %q", o.TemplateFile)
+		}
+
+		config.Template = string(data)
+	}
+
+	// Check the template can be used to generate a payload before saving it.
+
+	_, err = config.Payload(notify.Event{Type: notify.EventTest, Message: "Test notification."})
+
+	if err != nil {
+		return err
+	}
+
+	err = notify.SaveConfig(config)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Notifications configured, run `educates cluster notify test` to send a test notification.")
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterNotifyConfigureCmd() *cobra.Command {
+	var o ClusterNotifyConfigureOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "configure",
+		Short: "Configure webhook for notifications",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.URL,
+		"url",
+		"",
+		"URL of the webhook to send notifications to",
+	)
+	c.Flags().StringVar(
+		&o.Template,
+		"template",
+		"",
+		"Go template for the notification payload",
+	)
+	c.Flags().StringVar(
+		&o.TemplateFile,
+		"template-file",
+		"",
+		"file holding Go template for the notification payload",
+	)
+	c.Flags().StringSliceVar(
+		&o.Events,
+		"event",
+		[]string{},
+		"type of event to send notifications for, defaults to all (can be specified multiple times)",
+	)
+
+	c.MarkFlagRequired("url")
+	c.MarkFlagsMutuallyExclusive("template", "template-file")
+
+	c.RegisterFlagCompletionFunc("event", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return notify.EventTypes, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/notify"
+)
+
+type ClusterNotifyRemoveOptions struct {
+}
+
+func (o *ClusterNotifyRemoveOptions) Run(ctx context.Context) error {
+	err := notify.DeleteConfig()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Notifications disabled.")
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterNotifyRemoveCmd() *cobra.Command {
+	var o ClusterNotifyRemoveOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "remove",
+		Short: "Remove webhook configuration for notifications",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	return withAuditLogging(c)
+}
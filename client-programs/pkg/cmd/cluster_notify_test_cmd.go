@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/notify"
+)
+
+type ClusterNotifyTestOptions struct {
+}
+
+func (o *ClusterNotifyTestOptions) Run(ctx context.Context) error {
+	config, err := notify.LoadConfig()
+
+	if err != nil {
+		return err
+	}
+
+	if config == nil {
+		return failures.NewValidationError(errors.New("notifications are not configured"), "configure notifications with `educates cluster notify configure`")
+	}
+
+	err = config.Send(notify.Event{Type: notify.EventTest, Message: "Test notification from the Educates CLI."})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Test notification sent.")
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterNotifyTestCmd() *cobra.Command {
+	var o ClusterNotifyTestOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "test",
+		Short: "Send a test notification to the webhook",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	return c
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/notify"
+)
+
+type ClusterNotifyViewOptions struct {
+}
+
+func (o *ClusterNotifyViewOptions) Run(ctx context.Context) error {
+	config, err := notify.LoadConfig()
+
+	if err != nil {
+		return err
+	}
+
+	if config == nil {
+		fmt.Println("Notifications are not configured.")
+		return nil
+	}
+
+	events := "all"
+
+	if len(config.Events) != 0 {
+		events = strings.Join(config.Events, ",")
+	}
+
+	template := config.Template
+
+	if template == "" {
+		template = notify.DefaultTemplate
+	}
+
+	fmt.Printf("URL: %s\n", config.URL)
+	fmt.Printf("Events: %s\n", events)
+	fmt.Printf("Template: %s\n", template)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterNotifyViewCmd() *cobra.Command {
+	var o ClusterNotifyViewOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "view",
+		Short: "View webhook configuration for notifications",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	return c
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/notify"
+)
+
+type ClusterNotifyWatchOptions struct {
+	Kubeconfig string
+	Portal     string
+	Interval   time.Duration
+}
+
+/*
+State of the cluster as of the last check, so that notifications are only
+sent when something changes, rather than on every check.
+*/
+type notifyWatchState struct {
+	failedEnvironments map[string]bool
+	exhaustedCapacity  map[string]bool
+}
+
+func (o *ClusterNotifyWatchOptions) Run(ctx context.Context) error {
+	config, err := notify.LoadConfig()
+
+	if err != nil {
+		return err
+	}
+
+	if config == nil {
+		return failures.NewValidationError(errors.New("notifications are not configured"), "configure notifications with `educates cluster notify configure`")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	state := notifyWatchState{
+		failedEnvironments: map[string]bool{},
+		exhaustedCapacity:  map[string]bool{},
+	}
+
+	fmt.Println("Watching for events, press Ctrl-C to stop.")
+
+	for {
+		events, err := o.check(ctx, dynamicClient, &state)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		}
+
+		for _, event := range events {
+			fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), event.Message)
+
+			err = config.Send(event)
+
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s.\n", err)
+			}
+		}
+
+		if cluster.Sleep(ctx, o.Interval) != nil {
+			return nil
+		}
+	}
+}
+
+func (o *ClusterNotifyWatchOptions) check(ctx context.Context, client dynamic.Interface, state *notifyWatchState) ([]notify.Event, error) {
+	var events []notify.Event
+
+	trainingPortals, err := client.Resource(trainingPortalResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list training portals")
+	}
+
+	environments, err := client.Resource(workshopEnvironmentResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list workshop environments")
+	}
+
+	sessions, err := client.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list workshop sessions")
+	}
+
+	// Report workshop environments which have newly failed.
+
+	environmentWorkshops := map[string]string{}
+
+	failedEnvironments := map[string]bool{}
+
+	for _, item := range environments.Items {
+		portal := item.GetLabels()["training.educates.dev/portal.name"]
+
+		if o.Portal != "" && portal != o.Portal {
+			continue
+		}
+
+		workshop, _, _ := unstructured.NestedString(item.Object, "spec", "workshop", "name")
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		environmentWorkshops[item.GetName()] = workshop
+
+		if phase != "Failed" {
+			continue
+		}
+
+		failedEnvironments[item.GetName()] = true
+
+		if !state.failedEnvironments[item.GetName()] {
+			message, _, _ := unstructured.NestedString(item.Object, "status", "educates", "message")
+
+			text := fmt.Sprintf("Workshop environment %s for workshop %s in training portal %s has failed.", item.GetName(), workshop, portal)
+
+			if message != "" {
+				text = fmt.Sprintf("%s %s", text, message)
+			}
+
+			events = append(events, notify.Event{
+				Type:     notify.EventEnvironmentFailed,
+				Portal:   portal,
+				Workshop: workshop,
+				Message:  text,
+			})
+		}
+	}
+
+	state.failedEnvironments = failedEnvironments
+
+	// Count the active sessions for each training portal and workshop.
+
+	portalSessions := map[string]int64{}
+	workshopSessions := map[string]int64{}
+
+	for _, item := range sessions.Items {
+		labels := item.GetLabels()
+
+		portal := labels["training.educates.dev/portal.name"]
+		environment := labels["training.educates.dev/environment.name"]
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if phase == "Running" || phase == "Allocated" {
+			portalSessions[portal]++
+			workshopSessions[portal+"/"+environmentWorkshops[environment]]++
+		}
+	}
+
+	// Report training portals and workshops which have newly reached their
+	// maximum capacity.
+
+	exhaustedCapacity := map[string]bool{}
+
+	for _, item := range trainingPortals.Items {
+		portal := item.GetName()
+
+		if o.Portal != "" && portal != o.Portal {
+			continue
+		}
+
+		sessionsMaximum, sessionsMaximumExists, _ := unstructured.NestedInt64(item.Object, "spec", "portal", "sessions", "maximum")
+
+		if sessionsMaximumExists && sessionsMaximum > 0 && portalSessions[portal] >= sessionsMaximum {
+			exhaustedCapacity[portal] = true
+
+			if !state.exhaustedCapacity[portal] {
+				events = append(events, notify.Event{
+					Type:    notify.EventCapacityExhausted,
+					Portal:  portal,
+					Message: fmt.Sprintf("Training portal %s has reached its maximum of %d sessions.", portal, sessionsMaximum),
+				})
+			}
+		}
+
+		workshops, _, _ := unstructured.NestedSlice(item.Object, "spec", "workshops")
+
+		for _, entry := range workshops {
+			object, ok := entry.(map[string]interface{})
+
+			if !ok {
+				continue
+			}
+
+			workshop, _ := object["name"].(string)
+			capacity, ok := object["capacity"].(int64)
+
+			if !ok || capacity <= 0 {
+				continue
+			}
+
+			key := portal + "/" + workshop
+
+			if workshopSessions[key] < capacity {
+				continue
+			}
+
+			exhaustedCapacity[key] = true
+
+			if !state.exhaustedCapacity[key] {
+				events = append(events, notify.Event{
+					Type:     notify.EventCapacityExhausted,
+					Portal:   portal,
+					Workshop: workshop,
+					Message:  fmt.Sprintf("Workshop %s in training portal %s has reached its capacity of %d sessions.", workshop, portal, capacity),
+				})
+			}
+		}
+	}
+
+	state.exhaustedCapacity = exhaustedCapacity
+
+	return events, nil
+}
+
+func (p *ProjectInfo) NewClusterNotifyWatchCmd() *cobra.Command {
+	var o ClusterNotifyWatchOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "watch",
+		Short: "Watch for failures and capacity being exhausted",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"",
+		"name of training portal to watch, defaults to all training portals",
+	)
+	c.Flags().DurationVar(
+		&o.Interval,
+		"interval",
+		30*time.Second,
+		"time duration between checks of the cluster",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
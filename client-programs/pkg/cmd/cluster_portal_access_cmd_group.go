@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// Access rules mapping groups onto the workshops members of the group may see
+// and start are recorded as an annotation on the training portal. They are
+// enforced by the training portal when listing workshops in the catalog and
+// when allocating workshop sessions.
+
+const accessRulesAnnotation = "training.educates.dev/access.rules"
+
+func (p *ProjectInfo) NewClusterPortalAccessCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "access",
+		Short: "Manage group access to workshops",
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewClusterPortalAccessGrantCmd(),
+				p.NewClusterPortalAccessRevokeCmd(),
+				p.NewClusterPortalAccessListCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
+
+/*
+Read the access rules from the training portal, returning a map of group names
+to the names of workshops the group has access to.
+*/
+func portalAccessRules(trainingPortal *unstructured.Unstructured) (map[string][]string, error) {
+	rules := map[string][]string{}
+
+	value, found := trainingPortal.GetAnnotations()[accessRulesAnnotation]
+
+	if !found || value == "" {
+		return rules, nil
+	}
+
+	err := json.Unmarshal([]byte(value), &rules)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode access rules for training portal")
+	}
+
+	return rules, nil
+}
+
+/*
+Record the access rules against the training portal. Groups with no workshops
+are dropped and workshop names are kept sorted so the annotation is stable.
+*/
+func setPortalAccessRules(trainingPortal *unstructured.Unstructured, rules map[string][]string) error {
+	for group, workshops := range rules {
+		if len(workshops) == 0 {
+			delete(rules, group)
+			continue
+		}
+
+		sort.Strings(workshops)
+	}
+
+	annotations := trainingPortal.GetAnnotations()
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	if len(rules) == 0 {
+		delete(annotations, accessRulesAnnotation)
+	} else {
+		value, err := json.Marshal(rules)
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode access rules for training portal")
+		}
+
+		annotations[accessRulesAnnotation] = string(value)
+	}
+
+	trainingPortal.SetAnnotations(annotations)
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalAccessGrantOptions struct {
+	Kubeconfig string
+	Portal     string
+	Group      string
+	Workshops  []string
+}
+
+func (o *ClusterPortalAccessGrantOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve training portal")
+	}
+
+	// Only allow access to be granted to workshops hosted by the portal.
+
+	workshops, _, err := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve workshops from training portal")
+	}
+
+	var hosted []string
+
+	for _, item := range workshops {
+		if object, ok := item.(map[string]interface{}); ok {
+			if name, ok := object["name"].(string); ok {
+				hosted = append(hosted, name)
+			}
+		}
+	}
+
+	for _, name := range o.Workshops {
+		if !slices.Contains(hosted, name) {
+			return failures.NewNotFoundError(errors.Errorf("unable to find workshop %s", name), "run `educates cluster workshop list` to see deployed workshops")
+		}
+	}
+
+	rules, err := portalAccessRules(trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	for _, name := range o.Workshops {
+		if !slices.Contains(rules[o.Group], name) {
+			rules[o.Group] = append(rules[o.Group], name)
+		}
+	}
+
+	err = setPortalAccessRules(trainingPortal, rules)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to update training portal in cluster")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalAccessGrantCmd() *cobra.Command {
+	var o ClusterPortalAccessGrantOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "grant",
+		Short: "Grant group access to workshops",
+		Long: `Grant members of a group access to workshops hosted by a training portal.
+
+Once a workshop has been granted to any group, it is only listed in the
+catalog of the training portal for, and can only be started by, members of
+one of the groups it was granted to. Workshops not granted to any group are
+available to all users. Staff are always able to access all workshops.
+
+The group can be a group of the training portal, or a group the user is a
+member of with the identity provider they logged in with. Front ends which
+request workshop sessions using the REST API can supply the groups of the
+user using the "group" query string parameter when requesting a session.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Group,
+		"group",
+		"",
+		"name of the identity provider group",
+	)
+	c.Flags().StringSliceVar(
+		&o.Workshops,
+		"workshop",
+		[]string{},
+		"name of workshop to grant access to (can be specified multiple times)",
+	)
+
+	c.MarkFlagRequired("group")
+	c.MarkFlagRequired("workshop")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalAccessListOptions struct {
+	Kubeconfig string
+	Portal     string
+}
+
+func (o *ClusterPortalAccessListOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve training portal")
+	}
+
+	rules, err := portalAccessRules(trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	if len(rules) == 0 {
+		fmt.Println("No access rules found.")
+		return nil
+	}
+
+	var groups []string
+
+	for group := range rules {
+		groups = append(groups, group)
+	}
+
+	sort.Strings(groups)
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\n", "GROUP", "WORKSHOPS")
+
+	for _, group := range groups {
+		fmt.Fprintf(w, "%s\t%s\n", group, strings.Join(rules[group], ","))
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalAccessListCmd() *cobra.Command {
+	var o ClusterPortalAccessListOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "list",
+		Short: "List group access rules for workshops",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalAccessRevokeOptions struct {
+	Kubeconfig string
+	Portal     string
+	Group      string
+	Workshops  []string
+}
+
+func (o *ClusterPortalAccessRevokeOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve training portal")
+	}
+
+	rules, err := portalAccessRules(trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	// If no workshops are given revoke access to all workshops for the group.
+
+	if len(o.Workshops) == 0 {
+		delete(rules, o.Group)
+	} else {
+		var remaining []string
+
+		for _, name := range rules[o.Group] {
+			if !slices.Contains(o.Workshops, name) {
+				remaining = append(remaining, name)
+			}
+		}
+
+		rules[o.Group] = remaining
+	}
+
+	err = setPortalAccessRules(trainingPortal, rules)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to update training portal in cluster")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalAccessRevokeCmd() *cobra.Command {
+	var o ClusterPortalAccessRevokeOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "revoke",
+		Short: "Revoke group access to workshops",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Group,
+		"group",
+		"",
+		"name of the identity provider group",
+	)
+	c.Flags().StringSliceVar(
+		&o.Workshops,
+		"workshop",
+		[]string{},
+		"name of workshop to revoke access to, all workshops if not set (can be specified multiple times)",
+	)
+
+	c.MarkFlagRequired("group")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+
+	return withAuditLogging(c)
+}
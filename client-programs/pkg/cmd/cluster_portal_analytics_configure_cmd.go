@@ -0,0 +1,477 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/analytics"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalAnalyticsConfigureOptions struct {
+	Kubeconfig  string
+	Portal      string
+	URL         string
+	Token       string
+	Events      []string
+	Tail        bool
+	TailAddress string
+}
+
+/*
+Configuration of the analytics webhook for a training portal. An empty URL
+means no webhook is configured.
+*/
+type analyticsWebhook struct {
+	URL    string
+	Token  string
+	Events []string
+}
+
+func analyticsWebhookSecretName(portal string) string {
+	return fmt.Sprintf("%s-analytics-webhook", portal)
+}
+
+/*
+Read the analytics webhook configuration for a training portal. The access
+token is held in a secret rather than in the training portal resource.
+*/
+func readAnalyticsWebhook(ctx context.Context, clusterConfig *cluster.ClusterConfig, trainingPortal *unstructured.Unstructured) (analyticsWebhook, error) {
+	var webhook analyticsWebhook
+
+	webhook.URL, _, _ = unstructured.NestedString(trainingPortal.Object, "spec", "analytics", "webhook", "url")
+	webhook.Events, _, _ = unstructured.NestedStringSlice(trainingPortal.Object, "spec", "analytics", "webhook", "events")
+
+	if webhook.URL == "" {
+		return webhook, nil
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return webhook, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	secret, err := client.CoreV1().Secrets("educates-secrets").Get(ctx, analyticsWebhookSecretName(trainingPortal.GetName()), metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return webhook, nil
+	}
+
+	if err != nil {
+		return webhook, errors.Wrap(err, "unable to retrieve analytics webhook secret")
+	}
+
+	webhook.Token = string(secret.Data["token"])
+
+	return webhook, nil
+}
+
+func getTrainingPortal(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string) (*unstructured.Unstructured, error) {
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := dynamicClient.Resource(trainingPortalResource).Get(ctx, portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return nil, failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve training portal")
+	}
+
+	return trainingPortal, nil
+}
+
+/*
+Update the analytics webhook for a training portal. The access token is
+stored in a secret rather than in the training portal resource where it
+would be visible to anyone able to read the resource. The environment of
+the existing portal deployment is also updated so the change takes effect
+straight away. Changing the environment restarts the portal.
+*/
+func applyAnalyticsWebhook(ctx context.Context, clusterConfig *cluster.ClusterConfig, trainingPortal *unstructured.Unstructured, webhook analyticsWebhook) error {
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	portal := trainingPortal.GetName()
+
+	// An empty token is stored where none is supplied so a token from any
+	// prior configuration isn't used.
+
+	secretName := analyticsWebhookSecretName(portal)
+
+	err = applyPortalSecret(ctx, clusterConfig, trainingPortal, secretName, map[string][]byte{
+		"token": []byte(webhook.Token),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+
+	if webhook.URL != "" {
+		settings := map[string]interface{}{
+			"url":    webhook.URL,
+			"token":  nil,
+			"events": nil,
+		}
+
+		if len(webhook.Events) != 0 {
+			settings["events"] = webhook.Events
+		}
+
+		value = settings
+	}
+
+	// A merge patch is used, with a null value removing the webhook settings,
+	// or any which are not supplied. Other analytics settings are preserved.
+	// Any token added to the training portal resource by older versions is
+	// also removed.
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"analytics": map[string]interface{}{
+				"webhook": value,
+			},
+		},
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate training portal patch")
+	}
+
+	_, err = dynamicClient.Resource(trainingPortalResource).Patch(ctx, portal, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal in cluster %q", portal)
+	}
+
+	return patchPortalDeploymentEnv(ctx, clusterConfig, portal, []interface{}{
+		portalValueEnv("ANALYTICS_WEBHOOK_URL", webhook.URL),
+		portalSecretEnv("ANALYTICS_WEBHOOK_TOKEN", secretName, "token"),
+		portalValueEnv("ANALYTICS_WEBHOOK_EVENTS", strings.Join(webhook.Events, ",")),
+	})
+}
+
+func (o *ClusterPortalAnalyticsConfigureOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Tail && o.URL != "" {
+		return failures.NewValidationError(errors.New("--url cannot be used with --tail"), "when tailing events the local receiver is used as the webhook")
+	}
+
+	if !o.Tail && o.URL == "" {
+		return failures.NewValidationError(errors.New("no webhook URL supplied"), "supply the webhook URL using --url, or use --tail to receive events locally")
+	}
+
+	if o.URL != "" {
+		if parsed, err := url.Parse(o.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return failures.NewValidationError(errors.Errorf("invalid webhook URL %q", o.URL), "webhook URL must be a http or https URL")
+		}
+	}
+
+	for _, pattern := range o.Events {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return failures.NewValidationError(errors.Errorf("invalid event pattern %q", pattern), "event patterns use shell wildcards, such as Session/*")
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	if o.Tail {
+		previous, err := readAnalyticsWebhook(ctx, clusterConfig, trainingPortal)
+
+		if err != nil {
+			return err
+		}
+
+		return o.tail(ctx, clusterConfig, trainingPortal, previous)
+	}
+
+	webhook := analyticsWebhook{
+		URL:    o.URL,
+		Token:  o.Token,
+		Events: o.Events,
+	}
+
+	if err = applyAnalyticsWebhook(ctx, clusterConfig, trainingPortal, webhook); err != nil {
+		return err
+	}
+
+	fmt.Printf("Configured analytics webhook for training portal %s.\n", o.Portal)
+
+	return nil
+}
+
+/*
+Run a local receiver for analytics events, configuring the training portal
+to send events to it until interrupted, at which point the prior webhook
+configuration for the training portal is restored.
+*/
+func (o *ClusterPortalAnalyticsConfigureOptions) tail(ctx context.Context, clusterConfig *cluster.ClusterConfig, trainingPortal *unstructured.Unstructured, previous analyticsWebhook) error {
+	return receiveAnalyticsEvents(ctx, clusterConfig, trainingPortal, previous, o.TailAddress, o.Token, o.Events, printAnalyticsEvent)
+}
+
+/*
+Receive analytics events until interrupted, passing each to the supplied
+function. If a cluster config is given the training portal is configured to
+send events to the receiver, with the prior webhook configuration being
+restored when done. An access token is generated if none is supplied.
+*/
+func receiveAnalyticsEvents(ctx context.Context, clusterConfig *cluster.ClusterConfig, trainingPortal *unstructured.Unstructured, previous analyticsWebhook, address string, token string, events []string, received func(event analytics.Event)) error {
+	generated := false
+
+	if token == "" {
+		data := make([]byte, 16)
+
+		if _, err := rand.Read(data); err != nil {
+			return errors.Wrap(err, "unable to generate access token")
+		}
+
+		token = hex.EncodeToString(data)
+
+		generated = true
+	}
+
+	listener, err := net.Listen("tcp", address)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to listen on %s", address)
+	}
+
+	defer listener.Close()
+
+	// The training portal runs in the cluster, so must be given an address
+	// for the local host it can reach, rather than a loopback address.
+
+	hostIP, err := config.HostIP()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to determine host IP address")
+	}
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	receiverURL := fmt.Sprintf("http://%s/", net.JoinHostPort(hostIP, port))
+
+	server := http.Server{
+		Handler: analyticsReceiver(token, received),
+	}
+
+	if clusterConfig != nil {
+		webhook := analyticsWebhook{
+			URL:    receiverURL,
+			Token:  token,
+			Events: events,
+		}
+
+		if err = applyAnalyticsWebhook(ctx, clusterConfig, trainingPortal, webhook); err != nil {
+			return err
+		}
+
+		// The previous webhook is restored using a separate context, as the
+		// receiver normally stops because the command was interrupted, but
+		// with a time limit so a second interrupt isn't needed.
+
+		defer func() {
+			restoreCtx, cancel := context.WithTimeout(context.Background(), portalSecretWaitTimeout)
+			defer cancel()
+
+			if err := applyAnalyticsWebhook(restoreCtx, clusterConfig, trainingPortal, previous); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: unable to restore analytics webhook: %s.\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Restored analytics webhook for training portal %s.\n", trainingPortal.GetName())
+			}
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(ctx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Receiving analytics events on %s, press Ctrl-C to stop.\n", receiverURL)
+
+	if generated && clusterConfig == nil {
+		fmt.Fprintf(os.Stderr, "Access token: %s\n", token)
+	}
+
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(err, "unable to receive analytics events")
+	}
+
+	return nil
+}
+
+/*
+Return a HTTP handler for receiving analytics events sent by a training
+portal to the analytics webhook, which must supply the access token as a
+bearer token.
+*/
+func analyticsReceiver(token string, received func(event analytics.Event)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		value := strings.TrimPrefix(header, "Bearer ")
+
+		if value == header || subtle.ConstantTimeCompare([]byte(value), []byte(token)) != 1 {
+			http.Error(w, "invalid or missing access token", http.StatusUnauthorized)
+			return
+		}
+
+		var event analytics.Event
+
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Event.Name == "" {
+			http.Error(w, "invalid event", http.StatusBadRequest)
+			return
+		}
+
+		received(event)
+	})
+}
+
+/*
+Print an analytics event received from a training portal as a single line,
+with the name of the event, and the session, user and workshop it relates to,
+followed by any event specific data.
+*/
+func printAnalyticsEvent(event analytics.Event) {
+	fields := []string{event.Event.Timestamp, event.Event.Name}
+
+	for _, field := range []struct{ key, value string }{
+		{"session", event.Event.Session},
+		{"user", event.Event.User},
+		{"workshop", event.Event.Workshop},
+	} {
+		if field.value != "" {
+			fields = append(fields, fmt.Sprintf("%s=%s", field.key, field.value))
+		}
+	}
+
+	if len(event.Event.Data) != 0 {
+		if jsonData, err := json.Marshal(event.Event.Data); err == nil {
+			fields = append(fields, string(jsonData))
+		}
+	}
+
+	fmt.Println(strings.Join(fields, " "))
+}
+
+func (p *ProjectInfo) NewClusterPortalAnalyticsConfigureCmd() *cobra.Command {
+	var o ClusterPortalAnalyticsConfigureOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "configure",
+		Short: "Configure analytics webhook for portal",
+		Long: `Configure analytics webhook for portal.
+
+Configures the training portal to send analytics events for users, sessions
+and workshops to a webhook. The events sent can be restricted using --event,
+which can be given more than once, with the name of the event being matched
+against shell wildcard patterns such as "Session/*". If --token is given it
+is sent by the training portal as a bearer token in the Authorization header.
+The token is stored in a secret rather than in the training portal resource.
+
+If --tail is given, a local receiver for the events is run instead, and the
+training portal is configured to send events to it, with each event printed
+as it arrives. When stopped, the prior webhook configuration is restored.
+The training portal must be able to reach the local host on the port given
+by --tail-address for events to be received.
+
+Changing the webhook configuration restarts the training portal.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().StringVar(
+		&o.URL,
+		"url",
+		"",
+		"URL of the webhook to send analytics events to",
+	)
+	c.Flags().StringVar(
+		&o.Token,
+		"token",
+		"",
+		"bearer token to send with analytics events",
+	)
+	c.Flags().StringArrayVar(
+		&o.Events,
+		"event",
+		[]string{},
+		"pattern for names of analytics events to send (can be specified multiple times)",
+	)
+	c.Flags().BoolVar(
+		&o.Tail,
+		"tail",
+		false,
+		"run a local receiver for analytics events and print them as they arrive",
+	)
+	c.Flags().StringVar(
+		&o.TailAddress,
+		"tail-address",
+		"0.0.0.0:10091",
+		"address to listen on for analytics events when using --tail",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
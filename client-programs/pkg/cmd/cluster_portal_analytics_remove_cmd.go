@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type ClusterPortalAnalyticsRemoveOptions struct {
+	Kubeconfig string
+	Portal     string
+}
+
+func (o *ClusterPortalAnalyticsRemoveOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	webhook, err := readAnalyticsWebhook(ctx, clusterConfig, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	if webhook.URL == "" {
+		fmt.Println("No analytics webhook configured.")
+		return nil
+	}
+
+	if err = applyAnalyticsWebhook(ctx, clusterConfig, trainingPortal, analyticsWebhook{}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed analytics webhook for training portal %s.\n", o.Portal)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalAnalyticsRemoveCmd() *cobra.Command {
+	var o ClusterPortalAnalyticsRemoveOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "remove",
+		Short: "Remove analytics webhook for portal",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type ClusterPortalAnalyticsViewOptions struct {
+	Kubeconfig string
+	Portal     string
+}
+
+func (o *ClusterPortalAnalyticsViewOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	webhook, err := readAnalyticsWebhook(ctx, clusterConfig, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	if webhook.URL == "" {
+		fmt.Println("No analytics webhook configured.")
+		return nil
+	}
+
+	fmt.Println("URL:", webhook.URL)
+
+	// Only show enough of the token to be able to tell which it is.
+
+	if token := webhook.Token; token != "" {
+		if len(token) > 4 {
+			token = strings.Repeat("*", len(token)-4) + token[len(token)-4:]
+		} else {
+			token = strings.Repeat("*", len(token))
+		}
+
+		fmt.Println("Token:", token)
+	}
+
+	if len(webhook.Events) != 0 {
+		fmt.Println("Events:", strings.Join(webhook.Events, ", "))
+	} else {
+		fmt.Println("Events: *")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalAnalyticsViewCmd() *cobra.Command {
+	var o ClusterPortalAnalyticsViewOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "view",
+		Short: "View analytics webhook for portal",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Client for the REST API of a training portal. Access is made using the robot
+account credentials recorded in the status of the TrainingPortal resource.
+*/
+type TrainingPortalClient struct {
+	URL          string
+	ClientId     string
+	ClientSecret string
+	AccessToken  string
+	ExpiresIn    int
+	RefreshToken string
+}
+
+/*
+Login to the training portal using the robot account credentials and obtain an
+access token for subsequent API requests. The caller should call Logout() when
+done so that the access token is revoked.
+*/
+func NewTrainingPortalClient(ctx context.Context, trainingPortal *unstructured.Unstructured) (*TrainingPortalClient, error) {
+	portalUrl, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
+
+	return NewTrainingPortalClientForURL(ctx, trainingPortal, portalUrl)
+}
+
+/*
+Login to the training portal using the robot account credentials, but with
+the training portal accessed at the supplied URL rather than that recorded in
+the status of the TrainingPortal resource, such as when the ingress for the
+training portal can't be reached and port forwarding is used instead.
+*/
+func NewTrainingPortalClientForURL(ctx context.Context, trainingPortal *unstructured.Unstructured, portalUrl string) (*TrainingPortalClient, error) {
+	clientId, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "clients", "robot", "id")
+	clientSecret, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "clients", "robot", "secret")
+
+	username, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "credentials", "robot", "username")
+	password, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "credentials", "robot", "password")
+
+	if portalUrl == "" {
+		return nil, errors.New("invalid URL endpoint in training portal")
+	}
+
+	if username == "" || password == "" {
+		return nil, errors.New("invalid credentials in training portal")
+	}
+
+	form := url.Values{}
+
+	form.Add("grant_type", "password")
+	form.Add("username", username)
+	form.Add("password", password)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/oauth2/token/", portalUrl), strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "malformed request for training portal")
+	}
+
+	credentials := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", clientId, clientSecret)))
+
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Authorization", fmt.Sprintf("Basic %s", credentials))
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return nil, failures.NewConnectionError(errors.Wrapf(err, "cannot connect to training portal"), failures.PortalHint)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return nil, errors.New("cannot login to training portal")
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read response to token request")
+	}
+
+	type AuthDetails struct {
+		AccessToken  string `json:"access_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		TokenType    string `json:"token_type"`
+		Scope        string `json:"scope"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	var auth AuthDetails
+
+	err = json.Unmarshal(resBody, &auth)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot decode auth details")
+	}
+
+	return &TrainingPortalClient{
+		URL:          portalUrl,
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		AccessToken:  auth.AccessToken,
+		ExpiresIn:    auth.ExpiresIn,
+		RefreshToken: auth.RefreshToken,
+	}, nil
+}
+
+/*
+Revoke the access token for the training portal. Callers which are done with
+the training portal, and can do nothing about a failure, can ignore the error.
+*/
+func (c *TrainingPortalClient) Logout(ctx context.Context) error {
+	form := url.Values{}
+
+	form.Add("token", c.AccessToken)
+	form.Add("client_id", c.ClientId)
+	form.Add("client_secret", c.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/oauth2/revoke-token/", c.URL), strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return errors.Wrapf(err, "malformed request for training portal")
+	}
+
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.AccessToken))
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrapf(err, "cannot connect to training portal"), failures.PortalHint)
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return errors.Errorf("cannot revoke access token for training portal, status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+/*
+Make a request against the training portal REST API. The path is relative to
+the root URL of the training portal. The response body is returned along with
+the HTTP status code, with it being up to the caller to interpret the status.
+*/
+func (c *TrainingPortalClient) Request(ctx context.Context, method string, path string, body io.Reader) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s%s", c.URL, path), body)
+
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "malformed request for training portal")
+	}
+
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.AccessToken))
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return 0, nil, failures.NewConnectionError(errors.Wrapf(err, "cannot connect to training portal"), failures.PortalHint)
+	}
+
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return res.StatusCode, nil, errors.Wrapf(err, "cannot read response from training portal")
+	}
+
+	return res.StatusCode, resBody, nil
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+// Default issuers for identity providers which use the same issuer for all
+// customers. Other providers use an issuer specific to the organization, so
+// it must be supplied explicitly.
+
+var authProviderIssuers = map[string]string{
+	"okta":     "",
+	"azure":    "",
+	"keycloak": "",
+	"auth0":    "",
+	"google":   "https://accounts.google.com",
+	"oidc":     "",
+}
+
+type ClusterPortalAuthConfigureOptions struct {
+	Kubeconfig   string
+	Portal       string
+	Provider     string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	GroupsClaim  string
+	IndexUrl     string
+}
+
+type openIDConfiguration struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+/*
+Fetch and validate the OpenID Connect discovery document for an issuer.
+*/
+func fetchOpenIDConfiguration(issuer string) (*openIDConfiguration, error) {
+	discoveryUrl := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	res, err := client.Get(discoveryUrl)
+
+	if err != nil {
+		return nil, failures.NewConnectionError(errors.Wrapf(err, "unable to fetch discovery endpoint %s", discoveryUrl), "check the issuer URL is correct and reachable")
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, failures.NewValidationError(errors.Errorf("discovery endpoint %s returned status %d", discoveryUrl, res.StatusCode), "check the issuer URL is correct")
+	}
+
+	var config openIDConfiguration
+
+	err = json.NewDecoder(res.Body).Decode(&config)
+
+	if err != nil {
+		return nil, failures.NewValidationError(errors.Wrapf(err, "invalid discovery document at %s", discoveryUrl), "")
+	}
+
+	if strings.TrimSuffix(config.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, failures.NewValidationError(errors.Errorf("issuer %q in discovery document does not match %q", config.Issuer, issuer), "")
+	}
+
+	if config.AuthorizationEndpoint == "" || config.TokenEndpoint == "" || config.JwksUri == "" {
+		return nil, failures.NewValidationError(errors.Errorf("discovery document at %s is missing required endpoints", discoveryUrl), "")
+	}
+
+	return &config, nil
+}
+
+func oidcClientSecretName(portal string) string {
+	return fmt.Sprintf("%s-oidc-client", portal)
+}
+
+func (o *ClusterPortalAuthConfigureOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	defaultIssuer, ok := authProviderIssuers[o.Provider]
+
+	if !ok {
+		return failures.NewValidationError(errors.Errorf("unsupported identity provider %q", o.Provider), "supported providers are okta, azure, keycloak, auth0, google and oidc")
+	}
+
+	if o.Issuer == "" {
+		o.Issuer = defaultIssuer
+	}
+
+	if o.Issuer == "" {
+		return failures.NewValidationError(errors.Errorf("issuer URL is required for provider %q", o.Provider), "supply the issuer URL using --issuer")
+	}
+
+	discovery, err := fetchOpenIDConfiguration(o.Issuer)
+
+	if err != nil {
+		return err
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	// The client secret is stored in a secret rather than in the training
+	// portal resource where it would be visible to anyone able to read the
+	// resource. An empty client secret is stored where none is supplied so a
+	// secret from any prior configuration isn't used.
+
+	secretName := oidcClientSecretName(o.Portal)
+
+	err = applyPortalSecret(ctx, clusterConfig, trainingPortal, secretName, map[string][]byte{
+		"client-secret": []byte(o.ClientSecret),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	settings := map[string]interface{}{
+		"provider": o.Provider,
+		"issuer":   discovery.Issuer,
+		"clientId": o.ClientId,
+	}
+
+	if o.GroupsClaim != "" {
+		settings["groupsClaim"] = o.GroupsClaim
+	}
+
+	portalSettings := map[string]interface{}{
+		"authentication": map[string]interface{}{
+			"oidc": settings,
+		},
+	}
+
+	if o.IndexUrl != "" {
+		portalSettings["index"] = o.IndexUrl
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"portal": portalSettings,
+		},
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate training portal patch")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	_, err = dynamicClient.Resource(trainingPortalResource).Patch(ctx, o.Portal, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to update training portal in cluster")
+	}
+
+	env := []interface{}{
+		portalValueEnv("OIDC_PROVIDER", o.Provider),
+		portalValueEnv("OIDC_ISSUER", discovery.Issuer),
+		portalValueEnv("OIDC_CLIENT_ID", o.ClientId),
+		portalValueEnv("OIDC_GROUPS_CLAIM", o.GroupsClaim),
+		portalSecretEnv("OIDC_CLIENT_SECRET", secretName, "client-secret"),
+	}
+
+	if o.IndexUrl != "" {
+		env = append(env, portalValueEnv("PORTAL_INDEX", o.IndexUrl))
+	}
+
+	if err = patchPortalDeploymentEnv(ctx, clusterConfig, o.Portal, env); err != nil {
+		return err
+	}
+
+	fmt.Printf("Configured %s identity provider with issuer %s for portal %s.\n", o.Provider, discovery.Issuer, o.Portal)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalAuthConfigureCmd() *cobra.Command {
+	var o ClusterPortalAuthConfigureOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "configure",
+		Short: "Configure identity provider for portal",
+		Long: `Configure an OpenID Connect identity provider for a training portal.
+
+The discovery endpoint of the issuer is checked to ensure it is valid before
+any changes are made. The identity provider settings are then recorded against
+the training portal, with any client secret stored in a separate secret, and
+the training portal offers users the option of logging in using the identity
+provider. The redirect URI to register with the identity provider is:
+
+    https://<portal-hostname>/accounts/oidc/callback/
+
+Groups the user is a member of are read from the claim given by --groups-claim
+and can be used with "educates cluster portal access" to control which
+workshops users can see. Registration of local accounts is not changed. If an
+index URL is given, users accessing the training portal directly are
+redirected to it.
+
+Changing the identity provider configuration restarts the training portal.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Provider,
+		"provider",
+		"oidc",
+		"type of identity provider (okta, azure, keycloak, auth0, google or oidc)",
+	)
+	c.Flags().StringVar(
+		&o.Issuer,
+		"issuer",
+		"",
+		"issuer URL for the identity provider",
+	)
+	c.Flags().StringVar(
+		&o.ClientId,
+		"client-id",
+		"",
+		"client ID registered with the identity provider",
+	)
+	c.Flags().StringVar(
+		&o.ClientSecret,
+		"client-secret",
+		"",
+		"client secret registered with the identity provider",
+	)
+	c.Flags().StringVar(
+		&o.GroupsClaim,
+		"groups-claim",
+		"groups",
+		"claim in the ID token holding groups the user is a member of",
+	)
+	c.Flags().StringVar(
+		&o.IndexUrl,
+		"index-url",
+		"",
+		"URL users accessing the training portal directly are redirected to",
+	)
+
+	c.MarkFlagRequired("client-id")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalAuthViewOptions struct {
+	Kubeconfig string
+	Portal     string
+}
+
+func (o *ClusterPortalAuthViewOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve training portal")
+	}
+
+	oidc, found, _ := unstructured.NestedStringMap(trainingPortal.Object, "spec", "portal", "authentication", "oidc")
+
+	if !found || oidc["issuer"] == "" {
+		fmt.Println("No identity provider configured.")
+		return nil
+	}
+
+	index, _, _ := unstructured.NestedString(trainingPortal.Object, "spec", "portal", "index")
+
+	provider := oidc["provider"]
+
+	if provider == "" {
+		provider = "oidc"
+	}
+
+	groupsClaim := oidc["groupsClaim"]
+
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+
+	fmt.Println("Provider:", provider)
+	fmt.Println("Issuer:", oidc["issuer"])
+	fmt.Println("Client ID:", oidc["clientId"])
+	fmt.Println("Client Secret:", "educates-secrets/"+oidcClientSecretName(o.Portal))
+	fmt.Println("Groups Claim:", groupsClaim)
+
+	if index != "" {
+		fmt.Println("Index URL:", index)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalAuthViewCmd() *cobra.Command {
+	var o ClusterPortalAuthViewOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "view",
+		Short: "View identity provider for portal",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
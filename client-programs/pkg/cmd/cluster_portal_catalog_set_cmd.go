@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+var catalogDifficulties = []string{"beginner", "intermediate", "advanced", "extreme"}
+
+type ClusterPortalCatalogSetOptions struct {
+	Kubeconfig    string
+	Portal        string
+	Workshop      string
+	Position      int
+	Category      string
+	Tags          []string
+	Difficulty    string
+	Featured      bool
+	Hidden        bool
+	Clear         bool
+	positionSet   bool
+	categorySet   bool
+	tagsSet       bool
+	difficultySet bool
+	featuredSet   bool
+	hiddenSet     bool
+}
+
+func (o *ClusterPortalCatalogSetOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.difficultySet && o.Difficulty != "" && !containsString(catalogDifficulties, o.Difficulty) {
+		return failures.NewValidationError(errors.Errorf("invalid difficulty %q", o.Difficulty), "difficulty must be one of beginner, intermediate, advanced or extreme")
+	}
+
+	if o.positionSet && o.Position < 1 {
+		return failures.NewValidationError(errors.Errorf("invalid position %d", o.Position), "position must be 1 or more")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	index := -1
+
+	for i, item := range workshops {
+		if entry, ok := item.(map[string]interface{}); ok && entry["name"] == o.Workshop {
+			index = i
+		}
+	}
+
+	if index == -1 {
+		return failures.NewNotFoundError(errors.Errorf("workshop %q not found in training portal %q", o.Workshop, o.Portal), "run `educates cluster portal catalog view` to see workshops in the training portal")
+	}
+
+	entry := workshops[index].(map[string]interface{})
+
+	catalog, _, _ := unstructured.NestedMap(entry, "catalog")
+
+	if catalog == nil || o.Clear {
+		catalog = map[string]interface{}{}
+	}
+
+	// Empty values remove a setting, so it reverts to what the workshop
+	// definition gives, rather than being stored in the training portal.
+
+	setString := func(key string, value string) {
+		if value == "" {
+			delete(catalog, key)
+		} else {
+			catalog[key] = value
+		}
+	}
+
+	setBool := func(key string, value bool) {
+		if !value {
+			delete(catalog, key)
+		} else {
+			catalog[key] = true
+		}
+	}
+
+	if o.categorySet {
+		setString("category", o.Category)
+	}
+
+	if o.difficultySet {
+		setString("difficulty", o.Difficulty)
+	}
+
+	if o.tagsSet {
+		var tags []interface{}
+
+		for _, tag := range o.Tags {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+
+		if len(tags) == 0 {
+			delete(catalog, "tags")
+		} else {
+			catalog["tags"] = tags
+		}
+	}
+
+	if o.featuredSet {
+		setBool("featured", o.Featured)
+	}
+
+	if o.hiddenSet {
+		setBool("hidden", o.Hidden)
+	}
+
+	if len(catalog) == 0 {
+		delete(entry, "catalog")
+	} else {
+		entry["catalog"] = catalog
+	}
+
+	// The order workshops are shown in the catalog is the order they are
+	// listed in the training portal, so move the workshop within the list.
+
+	if o.positionSet {
+		position := o.Position - 1
+
+		if position >= len(workshops) {
+			position = len(workshops) - 1
+		}
+
+		workshops = append(workshops[:index], workshops[index+1:]...)
+		workshops = append(workshops[:position], append([]interface{}{entry}, workshops[position:]...)...)
+	}
+
+	unstructured.SetNestedSlice(trainingPortal.Object, workshops, "spec", "workshops")
+
+	_, err = dynamicClient.Resource(trainingPortalResource).Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal %q in cluster", o.Portal)
+	}
+
+	fmt.Printf("Updated catalog settings for workshop %s in portal %s.\n", o.Workshop, o.Portal)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalCatalogSetCmd() *cobra.Command {
+	var o ClusterPortalCatalogSetOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "set",
+		Short: "Set catalog settings for workshop in portal",
+		Long: `Set catalog settings for a workshop in a portal.
+
+Updates how a workshop is shown in the workshop catalog of a training portal.
+Only the settings given are changed, so the command can be run repeatedly
+from automation to organize a large catalog.
+
+Workshops are shown in the order they are listed in the training portal, and
+--position moves the workshop to that position in the list, counting from 1.
+Workshops with a category given by --category are shown under a heading for
+the category, with categories in the order they first appear. Workshops
+marked using --featured are shown first within their category, and workshops
+marked using --hidden are not listed in the catalog, although they can still
+be started using a direct link to the workshop. The difficulty and tags given
+by --difficulty and --tag replace those from the workshop definition. Pass
+an empty value, or false for flags, to remove a setting, or use --clear to
+remove all settings before applying any others given.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			o.positionSet = cmd.Flags().Changed("position")
+			o.categorySet = cmd.Flags().Changed("category")
+			o.tagsSet = cmd.Flags().Changed("tag")
+			o.difficultySet = cmd.Flags().Changed("difficulty")
+			o.featuredSet = cmd.Flags().Changed("featured")
+			o.hiddenSet = cmd.Flags().Changed("hidden")
+
+			return o.Run(cmd.Context())
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().StringVarP(
+		&o.Workshop,
+		"workshop",
+		"w",
+		"",
+		"name of the workshop in the training portal",
+	)
+	c.Flags().IntVar(
+		&o.Position,
+		"position",
+		0,
+		"position of the workshop in the catalog, counting from 1",
+	)
+	c.Flags().StringVar(
+		&o.Category,
+		"category",
+		"",
+		"category to group the workshop under",
+	)
+	c.Flags().StringSliceVar(
+		&o.Tags,
+		"tag",
+		nil,
+		"tag to show for the workshop (can be specified multiple times)",
+	)
+	c.Flags().StringVar(
+		&o.Difficulty,
+		"difficulty",
+		"",
+		"difficulty of the workshop, one of beginner, intermediate, advanced or extreme",
+	)
+	c.Flags().BoolVar(
+		&o.Featured,
+		"featured",
+		false,
+		"show the workshop first within its category",
+	)
+	c.Flags().BoolVar(
+		&o.Hidden,
+		"hidden",
+		false,
+		"don't list the workshop in the catalog",
+	)
+	c.Flags().BoolVar(
+		&o.Clear,
+		"clear",
+		false,
+		"remove existing catalog settings for the workshop",
+	)
+
+	c.MarkFlagRequired("workshop")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("difficulty", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return catalogDifficulties, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type ClusterPortalCatalogViewOptions struct {
+	Kubeconfig string
+	Portal     string
+}
+
+func (o *ClusterPortalCatalogViewOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, cluster.NewClusterConfig(o.Kubeconfig), o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	if len(workshops) == 0 {
+		fmt.Println("No workshops found.")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "POSITION", "NAME", "CATEGORY", "DIFFICULTY", "TAGS", "FLAGS")
+
+	for i, item := range workshops {
+		entry, ok := item.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		name, _, _ := unstructured.NestedString(entry, "name")
+		category, _, _ := unstructured.NestedString(entry, "catalog", "category")
+		difficulty, _, _ := unstructured.NestedString(entry, "catalog", "difficulty")
+		tags, _, _ := unstructured.NestedStringSlice(entry, "catalog", "tags")
+		featured, _, _ := unstructured.NestedBool(entry, "catalog", "featured")
+		hidden, _, _ := unstructured.NestedBool(entry, "catalog", "hidden")
+
+		var flags []string
+
+		if featured {
+			flags = append(flags, "featured")
+		}
+
+		if hidden {
+			flags = append(flags, "hidden")
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, name, category, difficulty, strings.Join(tags, ","), strings.Join(flags, ","))
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalCatalogViewCmd() *cobra.Command {
+	var o ClusterPortalCatalogViewOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "view",
+		Short: "View catalog settings for workshops in portal",
+		Long: `View catalog settings for workshops in a portal.
+
+Lists the workshops in a training portal in the order they are shown in the
+workshop catalog, along with the category, difficulty, tags and flags set for
+them. Where no difficulty or tags are set for a workshop in the portal, those
+from the workshop definition are shown in the catalog.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalCleanOptions struct {
+	Kubeconfig       string
+	Portal           string
+	AccountRetention time.Duration
+	SessionRetention time.Duration
+	DryRun           bool
+}
+
+func (o *ClusterPortalCleanOptions) Run(ctx context.Context) error {
+	if o.AccountRetention < 0 {
+		return failures.NewValidationError(errors.Errorf("invalid account retention period %s", o.AccountRetention), "")
+	}
+
+	if o.SessionRetention < 0 {
+		return failures.NewValidationError(errors.Errorf("invalid session retention period %s", o.SessionRetention), "")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, cluster.NewClusterConfig(o.Kubeconfig), o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"accounts": int64(o.AccountRetention.Seconds()),
+		"sessions": int64(o.SessionRetention.Seconds()),
+		"dry_run":  o.DryRun,
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode cleanup details")
+	}
+
+	status, resBody, err := portalClient.Request(ctx, "POST", "/workshops/cleanup/", bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	if status != 200 {
+		return errors.Errorf("unable to clean training portal, training portal returned status %d", status)
+	}
+
+	var result struct {
+		Accounts     int `json:"accounts"`
+		Sessions     int `json:"sessions"`
+		Environments int `json:"environments"`
+		Compacted    int `json:"compacted"`
+	}
+
+	if err = json.Unmarshal(resBody, &result); err != nil {
+		return errors.Wrap(err, "unable to decode response from training portal")
+	}
+
+	if o.DryRun {
+		fmt.Printf("Dry run of cleaning training portal %s, no changes made:\n", o.Portal)
+	} else {
+		fmt.Printf("Cleaned training portal %s:\n", o.Portal)
+	}
+
+	fmt.Printf("  Anonymous accounts deleted: %d\n", result.Accounts)
+	fmt.Printf("  Session records deleted: %d\n", result.Sessions)
+	fmt.Printf("  Workshop environment records deleted: %d\n", result.Environments)
+	fmt.Printf("  Session progress records compacted: %d\n", result.Compacted)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalCleanCmd() *cobra.Command {
+	var o ClusterPortalCleanOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "clean",
+		Short: "Purge stale records from training portal",
+		Long: `Purge stale records from a training portal.
+
+The training portal periodically deletes records of stopped workshop sessions
+and anonymous user accounts once they are 36 hours old. This command deletes
+them immediately, using the retention periods given, so that a long lived
+training portal doesn't accumulate records between events. The following are
+deleted:
+
+* Anonymous user accounts created before the account retention period which
+  have no active workshop sessions.
+* Records of workshop sessions which have stopped, or which belong to a
+  workshop environment which has been deleted, and which were last in use
+  before the session retention period.
+* Records of workshop environments which have been deleted and which no
+  longer have any workshop session records.
+
+The progress recorded for remaining stopped workshop sessions is compacted,
+keeping only the number of attempts at each task and whether it was passed.
+
+Use --dry-run to see what would be deleted without making any changes. Any
+results for workshop sessions needed should be retrieved first using the
+"educates cluster workshop results" command.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().DurationVar(
+		&o.AccountRetention,
+		"account-retention",
+		36*time.Hour,
+		"how long to keep anonymous user accounts",
+	)
+	c.Flags().DurationVar(
+		&o.SessionRetention,
+		"session-retention",
+		36*time.Hour,
+		"how long to keep records of stopped workshop sessions",
+	)
+	c.Flags().BoolVar(
+		&o.DryRun,
+		"dry-run",
+		false,
+		"report what would be deleted without making changes",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -23,8 +23,23 @@ func (p *ProjectInfo) NewClusterPortalCmdGroup() *cobra.Command {
 				p.NewClusterPortalCreateCmd(),
 				p.NewClusterPortalListCmd(),
 				p.NewClusterPortalOpenCmd(),
+				p.NewClusterPortalProxyCmd(),
+				p.NewClusterPortalLogsCmd(),
+				p.NewClusterPortalDatabaseCmd(),
 				p.NewClusterPortalDeleteCmd(),
 				p.NewClusterPortalPasswordCmd(),
+				p.NewClusterPortalTokenCmd(),
+				p.NewClusterPortalPackageCmd(),
+				p.NewClusterPortalMaintenanceCmd(),
+				p.NewClusterPortalCleanCmd(),
+				p.NewClusterPortalLoadtestCmd(),
+				p.NewClusterPortalUsersCmdGroup(),
+				p.NewClusterPortalAuthCmdGroup(),
+				p.NewClusterPortalAccessCmdGroup(),
+				p.NewClusterPortalCodesCmdGroup(),
+				p.NewClusterPortalAnalyticsCmdGroup(),
+				p.NewClusterPortalCatalogCmdGroup(),
+				p.NewClusterPortalLtiCmdGroup(),
 			},
 		},
 	}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+func (p *ProjectInfo) NewClusterPortalCodesCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "codes",
+		Short: "Manage access codes for portals",
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewClusterPortalCodesGenerateCmd(),
+				p.NewClusterPortalCodesRevokeCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
+
+// Access codes for a training portal are held in a secret in the secrets
+// namespace for Educates. Each code can be redeemed once on the training
+// portal, up until the time the code expires, with an account being created
+// for the user who redeemed it.
+
+type portalAccessCode struct {
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+}
+
+func portalAccessCodesSecretName(portal string) string {
+	return fmt.Sprintf("%s-access-codes", portal)
+}
+
+func readPortalAccessCodes(ctx context.Context, client *kubernetes.Clientset, portal string) (map[string]portalAccessCode, error) {
+	codes := map[string]portalAccessCode{}
+
+	secret, err := client.CoreV1().Secrets("educates-secrets").Get(ctx, portalAccessCodesSecretName(portal), metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return codes, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read access codes from cluster")
+	}
+
+	if data, ok := secret.Data["codes.json"]; ok {
+		err = json.Unmarshal(data, &codes)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decode access codes")
+		}
+	}
+
+	return codes, nil
+}
+
+/*
+Write the access codes for a training portal. The secret holding them is
+copied into the namespace for the training portal, where the training portal
+reads it when a user redeems an access code.
+*/
+func writePortalAccessCodes(ctx context.Context, clusterConfig *cluster.ClusterConfig, trainingPortal *unstructured.Unstructured, codes map[string]portalAccessCode) error {
+	data, err := json.Marshal(codes)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode access codes")
+	}
+
+	return applyPortalSecret(ctx, clusterConfig, trainingPortal, portalAccessCodesSecretName(trainingPortal.GetName()), map[string][]byte{
+		"codes.json": data,
+	})
+}
+
+/*
+Generate a random access code. Characters which are easily confused with each
+other when read from a printed sheet are excluded.
+*/
+func randomAccessCode() (string, error) {
+	chars := []rune("23456789ABCDEFGHJKLMNPQRSTUVWXYZ")
+
+	code := make([]rune, 0, 9)
+
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			code = append(code, '-')
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+
+		if err != nil {
+			return "", errors.Wrap(err, "unable to generate random access code")
+		}
+
+		code = append(code, chars[n.Int64()])
+	}
+
+	return string(code), nil
+}
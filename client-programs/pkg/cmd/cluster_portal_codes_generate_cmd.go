@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalCodesGenerateOptions struct {
+	Kubeconfig string
+	Portal     string
+	Count      uint
+	Expires    time.Duration
+	OutputFile string
+}
+
+func (o *ClusterPortalCodesGenerateOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Count == 0 {
+		return failures.NewValidationError(errors.New("count of access codes must be greater than zero"), "")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	codes, err := readPortalAccessCodes(ctx, client, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	expires := now.Add(o.Expires)
+
+	var generated []string
+
+	for uint(len(generated)) < o.Count {
+		code, err := randomAccessCode()
+
+		if err != nil {
+			return err
+		}
+
+		if _, exists := codes[code]; exists {
+			continue
+		}
+
+		codes[code] = portalAccessCode{Created: now, Expires: expires}
+
+		generated = append(generated, code)
+	}
+
+	err = writePortalAccessCodes(ctx, clusterConfig, trainingPortal, codes)
+
+	if err != nil {
+		return err
+	}
+
+	// Output the generated codes as CSV so they can be imported into a
+	// spreadsheet or mail merge for distribution to event attendees.
+
+	var out io.Writer = os.Stdout
+
+	if o.OutputFile != "" {
+		file, err := os.Create(o.OutputFile)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create output file %s", o.OutputFile)
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	w := csv.NewWriter(out)
+
+	w.Write([]string{"code", "portal", "expires"})
+
+	for _, code := range generated {
+		w.Write([]string{code, o.Portal, expires.Format(time.RFC3339)})
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+func (p *ProjectInfo) NewClusterPortalCodesGenerateCmd() *cobra.Command {
+	var o ClusterPortalCodesGenerateOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "generate",
+		Short: "Generate single use access codes for an event",
+		Long: `Generate single use access codes for an event.
+
+The access codes are output as CSV for distribution to event attendees. An
+attendee redeems an access code on the login page of the training portal, or
+by visiting /workshops/access/code/, with an account being created for them
+and them being logged in. Once redeemed an access code cannot be used again.
+Access codes which have not been redeemed can no longer be used once they
+expire or after they have been revoked.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().UintVar(
+		&o.Count,
+		"count",
+		1,
+		"number of access codes to generate",
+	)
+	c.Flags().DurationVar(
+		&o.Expires,
+		"expires",
+		8*time.Hour,
+		"time duration after which the access codes expire",
+	)
+	c.Flags().StringVarP(
+		&o.OutputFile,
+		"output",
+		"o",
+		"",
+		"file to write access codes to as CSV instead of stdout",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalCodesRevokeOptions struct {
+	Kubeconfig string
+	Portal     string
+	All        bool
+	Expired    bool
+}
+
+func (o *ClusterPortalCodesRevokeOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if len(args) == 0 && !o.All && !o.Expired {
+		return failures.NewValidationError(errors.New("no access codes specified"), "supply the access codes to revoke, or use --all or --expired")
+	}
+
+	if o.All {
+		err = confirmAction(fmt.Sprintf("revoke all access codes for training portal %q", o.Portal))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	codes, err := readPortalAccessCodes(ctx, client, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	count := len(codes)
+
+	now := time.Now()
+
+	for code, details := range codes {
+		if o.All || (o.Expired && details.Expires.Before(now)) {
+			delete(codes, code)
+		}
+	}
+
+	for _, code := range args {
+		if _, exists := codes[code]; !exists && !o.All {
+			return failures.NewNotFoundError(errors.Errorf("unknown access code %s", code), "")
+		}
+
+		delete(codes, code)
+	}
+
+	err = writePortalAccessCodes(ctx, clusterConfig, trainingPortal, codes)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Revoked %d access codes.\n", count-len(codes))
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalCodesRevokeCmd() *cobra.Command {
+	var o ClusterPortalCodesRevokeOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ArbitraryArgs,
+		Use:   "revoke [CODE...]",
+		Short: "Revoke access codes for an event",
+		RunE:  func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().BoolVar(
+		&o.All,
+		"all",
+		false,
+		"revoke all access codes for the training portal",
+	)
+	c.Flags().BoolVar(
+		&o.Expired,
+		"expired",
+		false,
+		"revoke only access codes which have expired",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
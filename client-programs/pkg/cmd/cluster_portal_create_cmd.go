@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -14,6 +15,7 @@ import (
 
 type ClusterConfigViewOptions struct {
 	Kubeconfig   string
+	Fleet        string
 	Portal       string
 	Capacity     uint
 	Password     string
@@ -21,32 +23,39 @@ type ClusterConfigViewOptions struct {
 	CookieDomain string
 }
 
-func (o *ClusterConfigViewOptions) Run(isPasswordSet bool) error {
-	var err error
-
+func (o *ClusterConfigViewOptions) Run(ctx context.Context, isPasswordSet bool) error {
 	// Ensure have portal name.
 
 	if o.Portal == "" {
 		o.Portal = "educates-cli"
 	}
 
-	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+	// Apply the change to each cluster in the fleet if one was given,
+	// otherwise to the cluster for the current kubeconfig context.
 
-	dynamicClient, err := clusterConfig.GetDynamicClient()
+	create := func(clusterConfig *cluster.ClusterConfig) error {
+		dynamicClient, err := clusterConfig.GetDynamicClient()
 
-	if err != nil {
-		return errors.Wrapf(err, "unable to create Kubernetes client")
-	}
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
 
-	// Update the training portal, creating it if necessary.
+		// Update the training portal, creating it if necessary.
 
-	err = createTrainingPortal(dynamicClient, o.Portal, o.Capacity, o.Password, isPasswordSet, o.ThemeName, o.CookieDomain)
+		err = createTrainingPortal(ctx, dynamicClient, o.Portal, o.Capacity, o.Password, isPasswordSet, o.ThemeName, o.CookieDomain)
 
-	if err != nil {
-		return err
+		if err != nil {
+			return err
+		}
+
+		return nil
 	}
 
-	return nil
+	if o.Fleet != "" {
+		return applyToFleet(o.Fleet, o.Kubeconfig, create)
+	}
+
+	return create(cluster.NewClusterConfig(o.Kubeconfig))
 }
 
 func (p *ProjectInfo) NewClusterPortalCreateCmd() *cobra.Command {
@@ -59,7 +68,7 @@ func (p *ProjectInfo) NewClusterPortalCreateCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			isPasswordSet := cmd.Flags().Lookup("password").Changed
 
-			return o.Run(isPasswordSet)
+			return o.Run(cmd.Context(), isPasswordSet)
 		},
 	}
 
@@ -69,6 +78,12 @@ func (p *ProjectInfo) NewClusterPortalCreateCmd() *cobra.Command {
 		"",
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
+	c.Flags().StringVar(
+		&o.Fleet,
+		"fleet",
+		"",
+		"name of fleet of clusters to apply the change to instead of a single cluster",
+	)
 	c.Flags().StringVarP(
 		&o.Portal,
 		"portal",
@@ -101,13 +116,15 @@ func (p *ProjectInfo) NewClusterPortalCreateCmd() *cobra.Command {
 		"override cookie domain used by training portal and workshops",
 	)
 
-	return c
+	c.RegisterFlagCompletionFunc("fleet", completeFleetNames)
+
+	return withAuditLogging(c)
 }
 
-func createTrainingPortal(client dynamic.Interface, portal string, capacity uint, password string, isPasswordSet bool, themeName string, cookieDomain string) error {
+func createTrainingPortal(ctx context.Context, client dynamic.Interface, portal string, capacity uint, password string, isPasswordSet bool, themeName string, cookieDomain string) error {
 	trainingPortalClient := client.Resource(trainingPortalResource)
 
-	_, err := trainingPortalClient.Get(context.TODO(), portal, metav1.GetOptions{})
+	_, err := trainingPortalClient.Get(ctx, portal, metav1.GetOptions{})
 
 	if err != nil {
 		if !k8serrors.IsNotFound(err) {
@@ -120,7 +137,7 @@ func createTrainingPortal(client dynamic.Interface, portal string, capacity uint
 	trainingPortal := &unstructured.Unstructured{}
 
 	if !isPasswordSet {
-		password = randomPassword(12)
+		password = training.RandomPassword(12)
 	}
 
 	trainingPortal.SetUnstructuredContent(map[string]interface{}{
@@ -169,7 +186,7 @@ func createTrainingPortal(client dynamic.Interface, portal string, capacity uint
 		},
 	})
 
-	_, err = trainingPortalClient.Create(context.TODO(), trainingPortal, metav1.CreateOptions{FieldManager: "educates-cli"})
+	_, err = trainingPortalClient.Create(ctx, trainingPortal, metav1.CreateOptions{FieldManager: "educates-cli"})
 
 	if err != nil {
 		return errors.Wrapf(err, "unable to create training portal %q in cluster", portal)
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Script run in the training portal container to execute an SQL statement read
+from stdin against the database, outputting any rows as tab separated values
+with a header line. The database URI is passed as an argument so it can be
+opened read only. As opening the database read only doesn't prevent another
+database being attached and changed, when read only the connection is also
+set to only allow queries, and attaching databases or changing that setting
+is denied.
+*/
+const portalDatabaseQueryScript = `import sqlite3, sys
+db = sqlite3.connect(sys.argv[1], uri=True)
+def authorize(action, arg1, arg2, database, trigger):
+    if action in (sqlite3.SQLITE_ATTACH, sqlite3.SQLITE_DETACH):
+        return sqlite3.SQLITE_DENY
+    if action == sqlite3.SQLITE_PRAGMA and arg1.lower() == "query_only":
+        return sqlite3.SQLITE_DENY
+    return sqlite3.SQLITE_OK
+if sys.argv[2] == "ro":
+    db.execute("PRAGMA query_only = ON")
+    db.set_authorizer(authorize)
+cursor = db.execute(sys.stdin.read())
+if cursor.description:
+    print("\t".join(column[0] for column in cursor.description))
+    for row in cursor:
+        print("\t".join("" if value is None else str(value) for value in row))
+db.commit()
+db.close()
+`
+
+type ClusterPortalDatabaseOptions struct {
+	Kubeconfig string
+	Portal     string
+	Query      string
+	ReadWrite  bool
+	Output     string
+}
+
+func (o *ClusterPortalDatabaseOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.ReadWrite && o.Query == "" {
+		return failures.NewValidationError(errors.New("changes can only be made to the database using a query"), "supply the SQL statement to run with `--query`")
+	}
+
+	if o.Query != "" && o.Output != "" {
+		return failures.NewValidationError(errors.New("only one of --query and --output can be given"), "")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	pod, err := trainingPortalPod(ctx, client, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	// The database for the training portal is an SQLite database held in
+	// the container for the training portal, so there is no database server
+	// to connect to. Queries are instead run in the container, opening the
+	// database read only unless changes are to be made.
+
+	if o.Query != "" {
+		mode := "ro"
+
+		if o.ReadWrite {
+			err = confirmAction(fmt.Sprintf("run statement which may change the database for training portal %q", o.Portal))
+
+			if err != nil {
+				return err
+			}
+
+			mode = "rw"
+		}
+
+		uri := fmt.Sprintf("file:%s/%s?mode=%s", portalDataDirectory, portalDatabaseFile, mode)
+
+		command := []string{"python3", "-c", portalDatabaseQueryScript, uri, mode}
+
+		err = clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, "portal", command, strings.NewReader(o.Query), os.Stdout)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to query database for training portal %q", o.Portal)
+		}
+
+		return nil
+	}
+
+	// Otherwise take a consistent copy of the database, the same as when
+	// making a backup, and open it locally.
+
+	script := fmt.Sprintf("import sqlite3,sys; s=sqlite3.connect('%s/%s'); d=sqlite3.connect('/tmp/snapshot.sqlite3'); s.backup(d); d.close(); s.close()", portalDataDirectory, portalDatabaseFile)
+
+	command := []string{"sh", "-c", fmt.Sprintf("python3 -c \"%s\" && cat /tmp/snapshot.sqlite3 && rm -f /tmp/snapshot.sqlite3", script)}
+
+	var database bytes.Buffer
+
+	if err = clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, "portal", command, nil, &database); err != nil {
+		return errors.Wrapf(err, "unable to copy database for training portal %q", o.Portal)
+	}
+
+	if o.Output != "" {
+		if err = os.WriteFile(o.Output, database.Bytes(), 0o600); err != nil {
+			return errors.Wrapf(err, "unable to write database to %s", o.Output)
+		}
+
+		fmt.Printf("Copy of database for training portal %q written to %s.\n", o.Portal, o.Output)
+
+		return nil
+	}
+
+	commandPath, err := exec.LookPath("sqlite3")
+
+	if err != nil {
+		return failures.NewNotFoundError(errors.New("unable to find sqlite3 command"), "install sqlite3, or save a copy of the database with `--output`")
+	}
+
+	file, err := os.CreateTemp("", "educates-portal-*.sqlite3")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary file for database")
+	}
+
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(database.Bytes())
+
+	file.Close()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to write temporary file for database")
+	}
+
+	fmt.Printf("Opening copy of database for training portal %q, changes will not be saved.\n", o.Portal)
+
+	shell := exec.Command(commandPath, "-readonly", "-header", file.Name())
+
+	shell.Stdin = os.Stdin
+	shell.Stdout = os.Stdout
+	shell.Stderr = os.Stderr
+
+	if err = shell.Run(); err != nil {
+		return errors.Wrap(err, "sqlite3 command failed")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalDatabaseCmd() *cobra.Command {
+	var o ClusterPortalDatabaseOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "database",
+		Short: "Access database for training portal",
+		Long: `Access the database for the training portal.
+
+The training portal keeps its state, such as registered users and allocated
+workshop sessions, in an SQLite database within the container for the
+training portal. With --query, the SQL statement given is run against the
+database in the container and any rows returned are output as tab separated
+values. The database is opened read only unless --read-write is given, in
+which case confirmation is required before the statement is run.
+
+Without --query, a consistent copy of the database is taken and opened with
+the local sqlite3 command in read only mode, or written to the file given by
+--output, so it can be inspected using other tools. Changes made to the copy
+do not affect the training portal.
+
+Statements run against the database, including those which only read from
+it, are recorded in the audit log.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().StringVarP(
+		&o.Query,
+		"query",
+		"q",
+		"",
+		"SQL statement to run against the database",
+	)
+	c.Flags().BoolVar(
+		&o.ReadWrite,
+		"read-write",
+		false,
+		"allow the SQL statement to make changes to the database",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"",
+		"file to write a copy of the database to instead of opening it",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -2,20 +2,23 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type ClusterPortalDeleteOptions struct {
 	Kubeconfig string
+	Fleet      string
 	Portal     string
 }
 
-func (o *ClusterPortalDeleteOptions) Run() error {
+func (o *ClusterPortalDeleteOptions) Run(ctx context.Context) error {
 	var err error
 
 	// Ensure have portal name.
@@ -24,6 +27,47 @@ func (o *ClusterPortalDeleteOptions) Run() error {
 		o.Portal = "educates-cli"
 	}
 
+	// Apply the change to each cluster in the fleet if one was given,
+	// otherwise to the cluster for the current kubeconfig context.
+
+	remove := func(clusterConfig *cluster.ClusterConfig) error {
+		dynamicClient, err := clusterConfig.GetDynamicClient()
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
+
+		trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+		err = trainingPortalClient.Delete(ctx, o.Portal, metav1.DeleteOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "unable to delete portal")
+		}
+
+		return nil
+	}
+
+	// When applying the change to a fleet confirm the deletion once up front
+	// rather than for each cluster.
+
+	if o.Fleet != "" {
+		err = confirmAction(fmt.Sprintf("delete training portal %q from all clusters in fleet %q", o.Portal, o.Fleet))
+
+		if err != nil {
+			return err
+		}
+
+		return applyToFleet(o.Fleet, o.Kubeconfig, remove)
+	}
+
+	// For a single cluster check the training portal exists before asking
+	// for confirmation.
+
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	dynamicClient, err := clusterConfig.GetDynamicClient()
@@ -32,21 +76,19 @@ func (o *ClusterPortalDeleteOptions) Run() error {
 		return errors.Wrapf(err, "unable to create Kubernetes client")
 	}
 
-	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
-
-	_, err = trainingPortalClient.Get(context.TODO(), o.Portal, metav1.GetOptions{})
+	_, err = dynamicClient.Resource(trainingPortalResource).Get(ctx, o.Portal, metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
-		return errors.New("no portal found")
+		return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
 	}
 
-	err = trainingPortalClient.Delete(context.TODO(), o.Portal, metav1.DeleteOptions{})
+	err = confirmAction(fmt.Sprintf("delete training portal %q and all its workshop sessions", o.Portal))
 
 	if err != nil {
-		return errors.Wrap(err, "unable to delete portal")
+		return err
 	}
 
-	return nil
+	return remove(clusterConfig)
 }
 
 func (p *ProjectInfo) NewClusterPortalDeleteCmd() *cobra.Command {
@@ -56,7 +98,7 @@ func (p *ProjectInfo) NewClusterPortalDeleteCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "delete",
 		Short: "Delete portal from Kubernetes",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -65,6 +107,12 @@ func (p *ProjectInfo) NewClusterPortalDeleteCmd() *cobra.Command {
 		"",
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
+	c.Flags().StringVar(
+		&o.Fleet,
+		"fleet",
+		"",
+		"name of fleet of clusters to apply the change to instead of a single cluster",
+	)
 	c.Flags().StringVarP(
 		&o.Portal,
 		"portal",
@@ -73,5 +121,8 @@ func (p *ProjectInfo) NewClusterPortalDeleteCmd() *cobra.Command {
 		"name to be used for training portal and workshop name prefixes",
 	)
 
-	return c
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("fleet", completeFleetNames)
+
+	return withAuditLogging(c)
 }
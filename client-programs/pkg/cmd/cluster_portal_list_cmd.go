@@ -18,7 +18,7 @@ type ClusterPortalListOptions struct {
 	Kubeconfig string
 }
 
-func (o *ClusterPortalListOptions) Run() error {
+func (o *ClusterPortalListOptions) Run(ctx context.Context) error {
 	var err error
 
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
@@ -31,7 +31,7 @@ func (o *ClusterPortalListOptions) Run() error {
 
 	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
 
-	trainingPortals, err := trainingPortalClient.List(context.TODO(), metav1.ListOptions{})
+	trainingPortals, err := trainingPortalClient.List(ctx, metav1.ListOptions{})
 
 	if k8serrors.IsNotFound(err) {
 		fmt.Println("No portals found.")
@@ -71,7 +71,7 @@ func (p *ProjectInfo) NewClusterPortalListCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "list",
 		Short: "Output list of portals",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -0,0 +1,560 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalLoadtestOptions struct {
+	Kubeconfig string
+	Portal     string
+	Workshop   string
+	Sessions   int
+	Ramp       string
+	Timeout    time.Duration
+	Keep       bool
+	Output     string
+}
+
+/*
+Outcome of creating a single workshop session during a load test. Allocation
+latency is the time from the request being made until the session is running,
+and access latency the time taken to respond when the session URL is visited.
+*/
+type loadtestSession struct {
+	User              string        `json:"user"`
+	Session           string        `json:"session,omitempty"`
+	AllocationLatency time.Duration `json:"allocationLatency,omitempty"`
+	AccessLatency     time.Duration `json:"accessLatency,omitempty"`
+	Error             string        `json:"error,omitempty"`
+}
+
+type loadtestLatencies struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	Max  time.Duration `json:"max"`
+}
+
+type loadtestReport struct {
+	Workshop    string             `json:"workshop"`
+	Requested   int                `json:"requested"`
+	Succeeded   int                `json:"succeeded"`
+	Failed      int                `json:"failed"`
+	FailureRate float64            `json:"failureRate"`
+	Duration    time.Duration      `json:"duration"`
+	Allocation  *loadtestLatencies `json:"allocation,omitempty"`
+	Access      *loadtestLatencies `json:"access,omitempty"`
+	Sessions    []loadtestSession  `json:"sessions"`
+}
+
+/*
+Parse a ramp rate given as a count per unit of time, such as "2/s", "30/m" or
+"5/10s", returning the interval between requests.
+*/
+func parseRampRate(ramp string) (time.Duration, error) {
+	count, unit, found := strings.Cut(ramp, "/")
+
+	if !found {
+		return 0, failures.NewValidationError(errors.Errorf("invalid ramp rate %q", ramp), "ramp rate must be given as a count per unit of time, such as 2/s or 30/m")
+	}
+
+	n, err := strconv.Atoi(count)
+
+	if err != nil || n <= 0 {
+		return 0, failures.NewValidationError(errors.Errorf("invalid count in ramp rate %q", ramp), "count must be a positive integer")
+	}
+
+	if unit == "s" || unit == "m" || unit == "h" {
+		unit = "1" + unit
+	}
+
+	period, err := time.ParseDuration(unit)
+
+	if err != nil || period <= 0 {
+		return 0, failures.NewValidationError(errors.Errorf("invalid period in ramp rate %q", ramp), "period must be s, m, h or a duration such as 10s")
+	}
+
+	return period / time.Duration(n), nil
+}
+
+func summariseLatencies(values []time.Duration) *loadtestLatencies {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var total time.Duration
+
+	for _, value := range values {
+		total += value
+	}
+
+	percentile := func(p int) time.Duration {
+		index := (len(values)*p+99)/100 - 1
+
+		if index < 0 {
+			index = 0
+		}
+
+		return values[index]
+	}
+
+	return &loadtestLatencies{
+		Min:  values[0],
+		Mean: total / time.Duration(len(values)),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P95:  percentile(95),
+		Max:  values[len(values)-1],
+	}
+}
+
+func (o *ClusterPortalLoadtestOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Sessions <= 0 {
+		return failures.NewValidationError(errors.New("number of sessions must be greater than zero"), "")
+	}
+
+	if o.Output != "text" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are text and json")
+	}
+
+	interval, err := parseRampRate(o.Ramp)
+
+	if err != nil {
+		return err
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, o.Workshop)
+
+	if err != nil {
+		return err
+	}
+
+	if environment == nil {
+		return failures.NewNotFoundError(errors.Errorf("no workshop environment for workshop %q in training portal %q", o.Workshop, o.Portal), "run `educates cluster workshop list` to see deployed workshops")
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	// Give each session its own user so the training portal allocates a new
+	// session for every request, rather than returning the existing session
+	// for the robot account.
+
+	suffix := make([]byte, 3)
+
+	if _, err = rand.Read(suffix); err != nil {
+		return errors.Wrap(err, "unable to generate user names")
+	}
+
+	prefix := fmt.Sprintf("loadtest-%s", hex.EncodeToString(suffix))
+
+	if err = confirmAction(fmt.Sprintf("create %d sessions for workshop %q in training portal %q", o.Sessions, o.Workshop, o.Portal)); err != nil {
+		return err
+	}
+
+	// Don't follow redirects when visiting the session URL, as only the
+	// response of the training portal to activating the session is of
+	// interest, not the workshop dashboard it redirects to.
+
+	accessClient := &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	results := make([]loadtestSession, o.Sessions)
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+
+	completed := 0
+
+	started := time.Now()
+
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+launch:
+	for i := 0; i < o.Sessions; i++ {
+		if i != 0 {
+			select {
+			case <-ctx.Done():
+				break launch
+			case <-ticker.C:
+			}
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			result := o.exercise(ctx, portalClient, accessClient, environment.GetName(), fmt.Sprintf("%s-%d", prefix, i+1))
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			results[i] = result
+
+			completed++
+
+			if o.Output == "text" {
+				if result.Error != "" {
+					fmt.Fprintf(os.Stderr, "[%d/%d] %s: failed: %s\n", completed, o.Sessions, result.User, result.Error)
+				} else {
+					fmt.Fprintf(os.Stderr, "[%d/%d] %s: session %s running after %s\n", completed, o.Sessions, result.User, result.Session, result.AllocationLatency.Round(time.Millisecond))
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	// When interrupted, don't report on a partial load test, but still clean
+	// up the sessions which were created.
+
+	if ctx.Err() != nil {
+		if !o.Keep {
+			terminateLoadtestSessions(ctx, portalClient, results)
+		}
+
+		return ctx.Err()
+	}
+
+	report := loadtestReport{
+		Workshop:  o.Workshop,
+		Requested: o.Sessions,
+		Duration:  time.Since(started),
+		Sessions:  results,
+	}
+
+	var allocation, access []time.Duration
+
+	for _, result := range results {
+		if result.Error != "" {
+			report.Failed++
+			continue
+		}
+
+		report.Succeeded++
+
+		allocation = append(allocation, result.AllocationLatency)
+		access = append(access, result.AccessLatency)
+	}
+
+	report.FailureRate = float64(report.Failed) / float64(report.Requested)
+	report.Allocation = summariseLatencies(allocation)
+	report.Access = summariseLatencies(access)
+
+	// Terminate the sessions created unless asked to keep them, so the
+	// capacity of the workshop is available again.
+
+	if !o.Keep {
+		terminateLoadtestSessions(ctx, portalClient, results)
+	}
+
+	if o.Output == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode load test report")
+		}
+
+		fmt.Println(string(data))
+	} else {
+		printLoadtestReport(&report)
+	}
+
+	if report.Failed != 0 {
+		return errors.Errorf("%d of %d sessions failed", report.Failed, report.Requested)
+	}
+
+	return nil
+}
+
+/*
+Terminate the sessions which were created by the load test.
+*/
+func terminateLoadtestSessions(ctx context.Context, portalClient *TrainingPortalClient, results []loadtestSession) {
+	for _, result := range results {
+		if result.Session != "" {
+			status, _, err := portalClient.Request(ctx, "GET", fmt.Sprintf("/workshops/session/%s/terminate/", url.PathEscape(result.Session)), nil)
+
+			if err != nil || status != 200 {
+				fmt.Fprintf(os.Stderr, "Warning: unable to terminate session %s.\n", result.Session)
+			}
+		}
+	}
+}
+
+/*
+Request a session for a user, wait for it to be running and then visit the
+session URL as the user would.
+*/
+func (o *ClusterPortalLoadtestOptions) exercise(ctx context.Context, portalClient *TrainingPortalClient, accessClient *http.Client, environment string, user string) loadtestSession {
+	result := loadtestSession{User: user}
+
+	fail := func(format string, args ...interface{}) loadtestSession {
+		result.Error = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	requested := time.Now()
+
+	// Ask the training portal to keep the session for the whole timeout
+	// while waiting for it to be activated, as it may take a while to be
+	// allocated when many sessions are being created.
+
+	query := url.Values{}
+
+	query.Add("user", user)
+	query.Add("index_url", portalClient.URL)
+	query.Add("timeout", strconv.Itoa(int(o.Timeout.Seconds())))
+
+	status, body, err := portalClient.Request(ctx, "POST", fmt.Sprintf("/workshops/environment/%s/request/?%s", url.PathEscape(environment), query.Encode()), strings.NewReader("{}"))
+
+	if err != nil {
+		return fail("%s", err)
+	}
+
+	if status != 200 {
+		return fail("session request returned status %d: %s", status, strings.TrimSpace(string(body)))
+	}
+
+	var session struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+
+	if err = json.Unmarshal(body, &session); err != nil {
+		return fail("unable to decode session request response: %s", err)
+	}
+
+	result.Session = session.Name
+
+	deadline := requested.Add(o.Timeout)
+
+	for {
+		status, body, err = portalClient.Request(ctx, "GET", fmt.Sprintf("/workshops/session/%s/schedule/", url.PathEscape(session.Name)), nil)
+
+		if err == nil && status == 200 {
+			var schedule struct {
+				Status string `json:"status"`
+			}
+
+			if json.Unmarshal(body, &schedule) == nil {
+				if schedule.Status == "RUNNING" {
+					break
+				}
+
+				if schedule.Status == "STOPPING" || schedule.Status == "STOPPED" {
+					return fail("session %s stopped while starting", session.Name)
+				}
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fail("session %s was not running within %s", session.Name, o.Timeout)
+		}
+
+		if cluster.Sleep(ctx, 2*time.Second) != nil {
+			return fail("interrupted waiting for session %s to be running", session.Name)
+		}
+	}
+
+	result.AllocationLatency = time.Since(requested)
+
+	sessionURL := session.URL
+
+	if !strings.HasPrefix(sessionURL, "http") {
+		sessionURL = portalClient.URL + sessionURL
+	}
+
+	accessed := time.Now()
+
+	res, err := accessClient.Get(sessionURL)
+
+	if err != nil {
+		return fail("unable to access session %s: %s", session.Name, err)
+	}
+
+	res.Body.Close()
+
+	result.AccessLatency = time.Since(accessed)
+
+	if res.StatusCode >= 400 {
+		return fail("accessing session %s returned status %d", session.Name, res.StatusCode)
+	}
+
+	return result
+}
+
+func printLoadtestReport(report *loadtestReport) {
+	fmt.Printf("Workshop:     %s\n", report.Workshop)
+	fmt.Printf("Sessions:     %d requested, %d succeeded, %d failed\n", report.Requested, report.Succeeded, report.Failed)
+	fmt.Printf("Failure rate: %.1f%%\n", report.FailureRate*100)
+	fmt.Printf("Duration:     %s\n", report.Duration.Round(time.Second))
+
+	printLatencies := func(label string, latencies *loadtestLatencies) {
+		if latencies == nil {
+			return
+		}
+
+		round := func(value time.Duration) time.Duration { return value.Round(time.Millisecond) }
+
+		fmt.Printf("%s min %s, mean %s, p50 %s, p90 %s, p95 %s, max %s\n", label, round(latencies.Min), round(latencies.Mean), round(latencies.P50), round(latencies.P90), round(latencies.P95), round(latencies.Max))
+	}
+
+	printLatencies("Allocation:  ", report.Allocation)
+	printLatencies("Access:      ", report.Access)
+
+	var failures []loadtestSession
+
+	for _, session := range report.Sessions {
+		if session.Error != "" {
+			failures = append(failures, session)
+		}
+	}
+
+	if len(failures) != 0 {
+		fmt.Println()
+		fmt.Println("Failures:")
+
+		for _, session := range failures {
+			fmt.Printf("  %s: %s\n", session.User, session.Error)
+		}
+	}
+}
+
+func (p *ProjectInfo) NewClusterPortalLoadtestCmd() *cobra.Command {
+	var o ClusterPortalLoadtestOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "loadtest",
+		Short: "Load test training portal by creating many sessions",
+		Long: `Load test a training portal by creating many workshop sessions.
+
+Uses the REST API of the training portal to request sessions for a workshop
+at a controlled rate, as if many users were starting the workshop at once,
+such as at the start of a big event. Each session is requested for a new user,
+then once it is running its URL is visited the same as a user's browser
+would. Use "--ramp" to set the rate sessions are requested, such as "2/s" or
+"30/m".
+
+Reports the failure rate, and the latency from a session being requested to
+it running, together with how long the training portal took to respond when
+the session URL was visited. Sessions are terminated once the load test has
+finished unless "--keep" is used. The users created for the load test remain
+in the training portal. The workshop must have enough capacity for the number
+of sessions requested, otherwise requests beyond the capacity will fail.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Workshop,
+		"workshop",
+		"",
+		"name of the workshop to create sessions for",
+	)
+	c.Flags().IntVar(
+		&o.Sessions,
+		"sessions",
+		10,
+		"number of sessions to create",
+	)
+	c.Flags().StringVar(
+		&o.Ramp,
+		"ramp",
+		"1/s",
+		"rate at which sessions are requested, such as 2/s or 30/m",
+	)
+	c.Flags().DurationVar(
+		&o.Timeout,
+		"timeout",
+		10*time.Minute,
+		"maximum time to wait for each session to be running",
+	)
+	c.Flags().BoolVar(
+		&o.Keep,
+		"keep",
+		false,
+		"keep the sessions running after the load test rather than terminating them",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"text",
+		"output format, one of text or json",
+	)
+
+	c.MarkFlagRequired("workshop")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type ClusterPortalLogsOptions struct {
+	Kubeconfig string
+	Portal     string
+	Follow     bool
+	Previous   bool
+	Tail       int64
+	Since      time.Duration
+}
+
+func (o *ClusterPortalLogsOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	pod, err := trainingPortalPod(ctx, client, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	options := &apiv1.PodLogOptions{Container: "portal", Follow: o.Follow, Previous: o.Previous}
+
+	if o.Tail >= 0 {
+		options.TailLines = &o.Tail
+	}
+
+	if o.Since > 0 {
+		seconds := int64(o.Since.Seconds())
+		options.SinceSeconds = &seconds
+	}
+
+	reader, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).Stream(ctx)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to get logs for training portal %q", o.Portal)
+	}
+
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		fmt.Println(scanner.Text())
+	}
+
+	return scanner.Err()
+}
+
+func (p *ProjectInfo) NewClusterPortalLogsCmd() *cobra.Command {
+	var o ClusterPortalLogsOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "logs",
+		Short: "Output logs for training portal",
+		Long: `Output logs for the training portal.
+
+Outputs the logs of the Django application for the training portal, which
+record requests made to the training portal, including registration of users
+and allocation of workshop sessions, along with any errors which occurred.
+The pod for the training portal is found automatically, so there is no need
+to know which namespace it was deployed to.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().BoolVarP(
+		&o.Follow,
+		"follow",
+		"f",
+		false,
+		"follow the logs as they are written",
+	)
+	c.Flags().BoolVar(
+		&o.Previous,
+		"previous",
+		false,
+		"output logs for the previous instance of the training portal if it restarted",
+	)
+	c.Flags().Int64Var(
+		&o.Tail,
+		"tail",
+		-1,
+		"number of lines from the end of the logs to output, all if negative",
+	)
+	c.Flags().DurationVar(
+		&o.Since,
+		"since",
+		0,
+		"only output logs written within this duration, e.g. 1h",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
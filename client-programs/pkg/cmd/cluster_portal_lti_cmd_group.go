@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+func (p *ProjectInfo) NewClusterPortalLtiCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "lti",
+		Short: "Manage LTI integration for portals",
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewClusterPortalLtiConfigureCmd(),
+				p.NewClusterPortalLtiViewCmd(),
+				p.NewClusterPortalLtiJwksCmd(),
+				p.NewClusterPortalLtiRegistrationCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
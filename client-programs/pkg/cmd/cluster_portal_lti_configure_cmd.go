@@ -0,0 +1,529 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Endpoints of an LMS acting as an LTI 1.3 platform. The training portal is
+the LTI tool, with the LMS being the OpenID Connect provider for launches.
+*/
+type ltiPlatformEndpoints struct {
+	Issuer        string
+	AuthEndpoint  string
+	TokenEndpoint string
+	JwksEndpoint  string
+}
+
+// Canvas cloud uses the same issuer and endpoints for all institutions. For
+// Moodle the issuer and endpoints are derived from the URL of the site. Any
+// other LMS must have the endpoints supplied explicitly.
+
+var ltiPlatforms = map[string]func(string) ltiPlatformEndpoints{
+	"canvas": func(_ string) ltiPlatformEndpoints {
+		return ltiPlatformEndpoints{
+			Issuer:        "https://canvas.instructure.com",
+			AuthEndpoint:  "https://sso.canvaslms.com/api/lti/authorize_redirect",
+			TokenEndpoint: "https://sso.canvaslms.com/login/oauth2/token",
+			JwksEndpoint:  "https://sso.canvaslms.com/api/lti/security/jwks",
+		}
+	},
+	"moodle": func(site string) ltiPlatformEndpoints {
+		if site == "" {
+			return ltiPlatformEndpoints{}
+		}
+
+		return ltiPlatformEndpoints{
+			Issuer:        site,
+			AuthEndpoint:  site + "/mod/lti/auth.php",
+			TokenEndpoint: site + "/mod/lti/token.php",
+			JwksEndpoint:  site + "/mod/lti/certs.php",
+		}
+	},
+	"generic": func(_ string) ltiPlatformEndpoints {
+		return ltiPlatformEndpoints{}
+	},
+}
+
+var ltiPlatformNames = []string{"canvas", "moodle", "generic"}
+
+type ClusterPortalLtiConfigureOptions struct {
+	Kubeconfig    string
+	Portal        string
+	Platform      string
+	PlatformUrl   string
+	Issuer        string
+	ClientId      string
+	DeploymentIds []string
+	AuthEndpoint  string
+	TokenEndpoint string
+	JwksEndpoint  string
+	GradePassback bool
+	RotateKey     bool
+}
+
+func ltiKeySecretName(portal string) string {
+	return fmt.Sprintf("%s-lti-key", portal)
+}
+
+/*
+Return the key used by the training portal to sign messages it sends to the
+LMS, such as requests for access tokens for grade pass-back. The key is held
+in the secrets namespace for Educates, with a new key being generated if one
+doesn't exist or rotation is requested.
+*/
+func ltiToolKey(ctx context.Context, client *kubernetes.Clientset, portal string, rotate bool) (*rsa.PrivateKey, error) {
+	secretName := ltiKeySecretName(portal)
+
+	secret, err := client.CoreV1().Secrets("educates-secrets").Get(ctx, secretName, metav1.GetOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "unable to retrieve secret %q", secretName)
+	}
+
+	if err == nil && !rotate {
+		return decodeLtiToolKey(secret)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate private key")
+	}
+
+	namespacesClient := client.CoreV1().Namespaces()
+
+	_, err = namespacesClient.Get(ctx, "educates-secrets", metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		namespaceObj := apiv1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "educates-secrets",
+			},
+		}
+
+		namespacesClient.Create(ctx, &namespaceObj, metav1.CreateOptions{})
+	}
+
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	patch := applycorev1.Secret(secretName, "educates-secrets").WithType(apiv1.SecretTypeOpaque).WithData(map[string][]byte{
+		"private-key": privateKey,
+		"key-id":      []byte(ltiKeyId(&key.PublicKey)),
+	}).WithLabels(map[string]string{
+		"training.educates.dev/portal.name": portal,
+	})
+
+	_, err = client.CoreV1().Secrets("educates-secrets").Apply(ctx, patch, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to update secret in cluster %q", secretName)
+	}
+
+	return key, nil
+}
+
+func decodeLtiToolKey(secret *apiv1.Secret) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(secret.Data["private-key"])
+
+	if block == nil {
+		return nil, errors.Errorf("no private key found in secret %q", secret.Name)
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid private key in secret %q", secret.Name)
+	}
+
+	return key, nil
+}
+
+/*
+Return the key ID for a public key, derived from the key itself so that it
+changes whenever the key is rotated.
+*/
+func ltiKeyId(key *rsa.PublicKey) string {
+	digest := sha256.Sum256(x509.MarshalPKCS1PublicKey(key))
+
+	return hex.EncodeToString(digest[:8])
+}
+
+func ltiPublicJWK(key *rsa.PublicKey) map[string]interface{} {
+	return map[string]interface{}{
+		"kty": "RSA",
+		"alg": "RS256",
+		"use": "sig",
+		"kid": ltiKeyId(key),
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func (o *ClusterPortalLtiConfigureOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	platformDefaults, ok := ltiPlatforms[o.Platform]
+
+	if !ok {
+		return failures.NewValidationError(errors.Errorf("unsupported LMS platform %q", o.Platform), "supported platforms are canvas, moodle and generic")
+	}
+
+	endpoints := platformDefaults(strings.TrimSuffix(o.PlatformUrl, "/"))
+
+	if o.Issuer != "" {
+		endpoints.Issuer = o.Issuer
+	}
+
+	if o.AuthEndpoint != "" {
+		endpoints.AuthEndpoint = o.AuthEndpoint
+	}
+
+	if o.TokenEndpoint != "" {
+		endpoints.TokenEndpoint = o.TokenEndpoint
+	}
+
+	if o.JwksEndpoint != "" {
+		endpoints.JwksEndpoint = o.JwksEndpoint
+	}
+
+	for _, field := range []struct{ name, value string }{
+		{"issuer", endpoints.Issuer},
+		{"auth-endpoint", endpoints.AuthEndpoint},
+		{"token-endpoint", endpoints.TokenEndpoint},
+		{"jwks-endpoint", endpoints.JwksEndpoint},
+	} {
+		name, value := field.name, field.value
+
+		if value == "" {
+			hint := fmt.Sprintf("supply the URL using --%s", name)
+
+			if o.Platform == "moodle" {
+				hint = "supply the URL of the Moodle site using --platform-url"
+			}
+
+			return failures.NewValidationError(errors.Errorf("no %s given for platform %q", strings.ReplaceAll(name, "-", " "), o.Platform), hint)
+		}
+
+		if parsed, err := url.Parse(value); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return failures.NewValidationError(errors.Errorf("invalid %s %q", strings.ReplaceAll(name, "-", " "), value), "LTI platform URLs must use https")
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve training portal")
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	key, err := ltiToolKey(ctx, client, o.Portal, o.RotateKey)
+
+	if err != nil {
+		return err
+	}
+
+	annotations := trainingPortal.GetAnnotations()
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations["training.educates.dev/lti.platform"] = o.Platform
+	annotations["training.educates.dev/lti.issuer"] = endpoints.Issuer
+	annotations["training.educates.dev/lti.client-id"] = o.ClientId
+	annotations["training.educates.dev/lti.deployment-ids"] = strings.Join(o.DeploymentIds, ",")
+	annotations["training.educates.dev/lti.auth-endpoint"] = endpoints.AuthEndpoint
+	annotations["training.educates.dev/lti.token-endpoint"] = endpoints.TokenEndpoint
+	annotations["training.educates.dev/lti.jwks-endpoint"] = endpoints.JwksEndpoint
+	annotations["training.educates.dev/lti.grade-passback"] = strconv.FormatBool(o.GradePassback)
+	annotations["training.educates.dev/lti.key"] = "educates-secrets/" + ltiKeySecretName(o.Portal)
+
+	trainingPortal.SetAnnotations(annotations)
+
+	_, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to update training portal in cluster")
+	}
+
+	fmt.Printf("Configured %s LTI platform with issuer %s for portal %s.\n", o.Platform, endpoints.Issuer, o.Portal)
+	fmt.Printf("Tool key ID: %s\n", ltiKeyId(&key.PublicKey))
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalLtiConfigureCmd() *cobra.Command {
+	var o ClusterPortalLtiConfigureOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "configure",
+		Short: "Configure LTI platform for portal",
+		Long: `Configure an LTI 1.3 platform, such as Canvas or Moodle, for a training portal.
+
+The training portal is registered as an LTI tool with the LMS, allowing the
+LMS to launch workshops and, where grade pass-back is enabled, to receive a
+score when a workshop is completed. The client ID and deployment IDs are
+those assigned by the LMS when the tool is registered. For Canvas cloud the
+platform endpoints are known, for Moodle they are derived from the URL of the
+site given by --platform-url, otherwise they must be supplied explicitly.
+
+A key pair for the tool is generated the first time it is needed, and stored
+in a secret. Use --rotate-key to replace it. The LMS
+must then be given the new public key, which can be output using the jwks or
+registration commands.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Platform,
+		"platform",
+		"canvas",
+		"type of LMS platform (canvas, moodle or generic)",
+	)
+	c.Flags().StringVar(
+		&o.PlatformUrl,
+		"platform-url",
+		"",
+		"URL of the LMS site, used to derive platform endpoints for Moodle",
+	)
+	c.Flags().StringVar(
+		&o.Issuer,
+		"issuer",
+		"",
+		"issuer identifier for the LMS platform",
+	)
+	c.Flags().StringVar(
+		&o.ClientId,
+		"client-id",
+		"",
+		"client ID assigned to the tool by the LMS platform",
+	)
+	c.Flags().StringArrayVar(
+		&o.DeploymentIds,
+		"deployment-id",
+		[]string{},
+		"deployment ID of the tool in the LMS platform (can be specified multiple times)",
+	)
+	c.Flags().StringVar(
+		&o.AuthEndpoint,
+		"auth-endpoint",
+		"",
+		"OpenID Connect authorization endpoint of the LMS platform",
+	)
+	c.Flags().StringVar(
+		&o.TokenEndpoint,
+		"token-endpoint",
+		"",
+		"OAuth2 access token endpoint of the LMS platform",
+	)
+	c.Flags().StringVar(
+		&o.JwksEndpoint,
+		"jwks-endpoint",
+		"",
+		"URL of the public keyset of the LMS platform",
+	)
+	c.Flags().BoolVar(
+		&o.GradePassback,
+		"grade-passback",
+		false,
+		"report a score to the LMS platform when a workshop is completed",
+	)
+	c.Flags().BoolVar(
+		&o.RotateKey,
+		"rotate-key",
+		false,
+		"replace the key pair used by the tool",
+	)
+
+	c.MarkFlagRequired("client-id")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("platform", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return ltiPlatformNames, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return withAuditLogging(c)
+}
+
+/*
+LTI platform configuration recorded against a training portal, along with
+the public key of the tool and the URL of the training portal.
+*/
+type ltiConfiguration struct {
+	Platform      string
+	Issuer        string
+	ClientId      string
+	DeploymentIds []string
+	Endpoints     ltiPlatformEndpoints
+	GradePassback bool
+	PublicKey     *rsa.PublicKey
+	PortalURL     string
+}
+
+func loadLtiConfiguration(ctx context.Context, kubeconfig string, portal string) (*ltiConfiguration, error) {
+	clusterConfig := cluster.NewClusterConfig(kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, portal)
+
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := trainingPortal.GetAnnotations()
+
+	if annotations["training.educates.dev/lti.platform"] == "" {
+		return nil, failures.NewNotFoundError(errors.Errorf("no LTI platform configured for portal %q", portal), "run `educates cluster portal lti configure` to configure an LTI platform")
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	secretName := ltiKeySecretName(portal)
+
+	secret, err := client.CoreV1().Secrets("educates-secrets").Get(ctx, secretName, metav1.GetOptions{})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to retrieve secret %q", secretName)
+	}
+
+	key, err := decodeLtiToolKey(secret)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var deploymentIds []string
+
+	if value := annotations["training.educates.dev/lti.deployment-ids"]; value != "" {
+		deploymentIds = strings.Split(value, ",")
+	}
+
+	portalURL, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
+
+	return &ltiConfiguration{
+		Platform:      annotations["training.educates.dev/lti.platform"],
+		Issuer:        annotations["training.educates.dev/lti.issuer"],
+		ClientId:      annotations["training.educates.dev/lti.client-id"],
+		DeploymentIds: deploymentIds,
+		Endpoints: ltiPlatformEndpoints{
+			Issuer:        annotations["training.educates.dev/lti.issuer"],
+			AuthEndpoint:  annotations["training.educates.dev/lti.auth-endpoint"],
+			TokenEndpoint: annotations["training.educates.dev/lti.token-endpoint"],
+			JwksEndpoint:  annotations["training.educates.dev/lti.jwks-endpoint"],
+		},
+		GradePassback: annotations["training.educates.dev/lti.grade-passback"] == "true",
+		PublicKey:     &key.PublicKey,
+		PortalURL:     strings.TrimSuffix(portalURL, "/"),
+	}, nil
+}
+
+/*
+Return the URLs of the tool endpoints of the training portal which the LMS
+needs to know about when the tool is registered.
+*/
+func (c *ltiConfiguration) toolEndpoints() (loginURL string, launchURL string, err error) {
+	if c.PortalURL == "" {
+		return "", "", errors.New("training portal is not yet available")
+	}
+
+	return c.PortalURL + "/lti/login/", c.PortalURL + "/lti/launch/", nil
+}
+
+/*
+Return the public key of the tool, generating a key pair if the training
+portal doesn't have one yet, along with the URL of the training portal. This
+allows what the LMS needs to register the tool to be generated before the
+LMS platform has been configured for the training portal.
+*/
+func ltiToolIdentity(ctx context.Context, kubeconfig string, portal string) (*rsa.PublicKey, string, error) {
+	clusterConfig := cluster.NewClusterConfig(kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, portal)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	key, err := ltiToolKey(ctx, client, portal, false)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	portalURL, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
+
+	return &key.PublicKey, strings.TrimSuffix(portalURL, "/"), nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type ClusterPortalLtiJwksOptions struct {
+	Kubeconfig string
+	Portal     string
+}
+
+func (o *ClusterPortalLtiJwksOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	publicKey, _, err := ltiToolIdentity(ctx, o.Kubeconfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	jwks := map[string]interface{}{
+		"keys": []interface{}{ltiPublicJWK(publicKey)},
+	}
+
+	jsonData, err := json.MarshalIndent(jwks, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate JWKS")
+	}
+
+	fmt.Println(string(jsonData))
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalLtiJwksCmd() *cobra.Command {
+	var o ClusterPortalLtiJwksOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "jwks",
+		Short: "Output public keyset of LTI tool for portal",
+		Long: `Output public keyset of LTI tool for portal.
+
+Outputs the public key of the training portal as an LTI tool, in the JSON Web
+Key Set format expected by an LMS. This can be used when registering the tool
+with an LMS which accepts a keyset rather than a URL for one.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
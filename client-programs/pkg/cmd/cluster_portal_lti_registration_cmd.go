@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+// Scopes for the LTI Assignment and Grade Services, which are required for
+// the tool to report scores back to the LMS.
+
+var ltiGradeScopes = []string{
+	"https://purl.imsglobal.org/spec/lti-ags/scope/lineitem",
+	"https://purl.imsglobal.org/spec/lti-ags/scope/result.readonly",
+	"https://purl.imsglobal.org/spec/lti-ags/scope/score",
+}
+
+type ClusterPortalLtiRegistrationOptions struct {
+	Kubeconfig    string
+	Portal        string
+	Platform      string
+	Title         string
+	Description   string
+	Workshop      string
+	GradePassback bool
+}
+
+func (o *ClusterPortalLtiRegistrationOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if !containsString(ltiPlatformNames, o.Platform) {
+		return failures.NewValidationError(errors.Errorf("unsupported LMS platform %q", o.Platform), "supported platforms are canvas, moodle and generic")
+	}
+
+	publicKey, portalURL, err := ltiToolIdentity(ctx, o.Kubeconfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	if portalURL == "" {
+		return errors.Errorf("training portal %q is not yet available", o.Portal)
+	}
+
+	parsedURL, err := url.Parse(portalURL)
+
+	if err != nil {
+		return errors.Wrapf(err, "invalid URL %q for training portal", portalURL)
+	}
+
+	loginURL := portalURL + "/lti/login/"
+	launchURL := portalURL + "/lti/launch/"
+
+	var scopes []string
+
+	if o.GradePassback {
+		scopes = ltiGradeScopes
+	}
+
+	customFields := map[string]string{}
+
+	if o.Workshop != "" {
+		customFields["workshop"] = o.Workshop
+	}
+
+	var registration map[string]interface{}
+
+	switch o.Platform {
+	case "canvas":
+		// Canvas developer key configuration, which can be pasted in when
+		// creating an LTI key using the "Paste JSON" method.
+
+		registration = map[string]interface{}{
+			"title":               o.Title,
+			"description":         o.Description,
+			"oidc_initiation_url": loginURL,
+			"target_link_uri":     launchURL,
+			"scopes":              append([]string{}, scopes...),
+			"public_jwk":          ltiPublicJWK(publicKey),
+			"custom_fields":       customFields,
+			"extensions": []interface{}{
+				map[string]interface{}{
+					"domain":        parsedURL.Host,
+					"tool_id":       "educates-" + o.Portal,
+					"platform":      "canvas.instructure.com",
+					"privacy_level": "public",
+					"settings": map[string]interface{}{
+						"text": o.Title,
+						"placements": []interface{}{
+							map[string]interface{}{
+								"placement":       "link_selection",
+								"message_type":    "LtiResourceLinkRequest",
+								"target_link_uri": launchURL,
+								"text":            o.Title,
+							},
+							map[string]interface{}{
+								"placement":       "assignment_selection",
+								"message_type":    "LtiResourceLinkRequest",
+								"target_link_uri": launchURL,
+								"text":            o.Title,
+							},
+						},
+					},
+				},
+			},
+		}
+
+	case "moodle":
+		// Values for the fields of the form used to manually configure an
+		// external tool in Moodle.
+
+		publicKeyData, err := x509.MarshalPKIXPublicKey(publicKey)
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode public key")
+		}
+
+		var customParameters []string
+
+		for name, value := range customFields {
+			customParameters = append(customParameters, fmt.Sprintf("%s=%s", name, value))
+		}
+
+		gradeService := "Do not use this service"
+
+		if o.GradePassback {
+			gradeService = "Use this service for grade sync and column management"
+		}
+
+		registration = map[string]interface{}{
+			"tool_name":                             o.Title,
+			"tool_url":                              launchURL,
+			"tool_description":                      o.Description,
+			"lti_version":                           "LTI 1.3",
+			"public_key_type":                       "RSA key",
+			"public_key":                            string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyData})),
+			"initiate_login_url":                    loginURL,
+			"redirection_uris":                      launchURL,
+			"custom_parameters":                     strings.Join(customParameters, "\n"),
+			"ims_lti_assignment_and_grade_services": gradeService,
+		}
+
+	default:
+		// Client metadata as defined by the LTI Dynamic Registration
+		// specification, for an LMS which supports registering tools
+		// from it.
+
+		registration = map[string]interface{}{
+			"application_type":           "web",
+			"response_types":             []string{"id_token"},
+			"grant_types":                []string{"implicit", "client_credentials"},
+			"initiate_login_uri":         loginURL,
+			"redirect_uris":              []string{launchURL},
+			"client_name":                o.Title,
+			"jwks":                       map[string]interface{}{"keys": []interface{}{ltiPublicJWK(publicKey)}},
+			"token_endpoint_auth_method": "private_key_jwt",
+			"scope":                      strings.Join(scopes, " "),
+			"https://purl.imsglobal.org/spec/lti-tool-configuration": map[string]interface{}{
+				"domain":            parsedURL.Host,
+				"description":       o.Description,
+				"target_link_uri":   launchURL,
+				"custom_parameters": customFields,
+				"claims":            []string{"iss", "sub", "name", "given_name", "family_name", "email"},
+				"messages": []interface{}{
+					map[string]interface{}{
+						"type":            "LtiResourceLinkRequest",
+						"target_link_uri": launchURL,
+						"label":           o.Title,
+					},
+				},
+			},
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(registration, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate registration")
+	}
+
+	fmt.Println(string(jsonData))
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalLtiRegistrationCmd() *cobra.Command {
+	var o ClusterPortalLtiRegistrationOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "registration",
+		Short: "Output registration of LTI tool for portal",
+		Long: `Output registration of LTI tool for portal.
+
+Outputs the details an LMS administrator needs to register the training
+portal as an LTI 1.3 tool. For Canvas this is the JSON configuration for a
+developer key. For Moodle it is the values to enter when manually configuring
+an external tool. Otherwise it is the client metadata defined by the LTI
+Dynamic Registration specification. The client ID and deployment ID assigned
+by the LMS should then be supplied using "educates cluster portal lti
+configure".
+
+Use --workshop to have the LMS pass the name of a workshop to launch, rather
+than users being shown the workshops catalog, and --grade-passback to request
+the scopes needed to report scores back to the LMS.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Platform,
+		"platform",
+		"canvas",
+		"type of LMS platform (canvas, moodle or generic)",
+	)
+	c.Flags().StringVar(
+		&o.Title,
+		"title",
+		"Educates",
+		"title of the tool as shown in the LMS",
+	)
+	c.Flags().StringVar(
+		&o.Description,
+		"description",
+		"Interactive workshops hosted by Educates.",
+		"description of the tool as shown in the LMS",
+	)
+	c.Flags().StringVar(
+		&o.Workshop,
+		"workshop",
+		"",
+		"name of workshop for the LMS to launch",
+	)
+	c.Flags().BoolVar(
+		&o.GradePassback,
+		"grade-passback",
+		false,
+		"request scopes for reporting scores to the LMS",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("workshop", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("platform", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return ltiPlatformNames, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return c
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type ClusterPortalLtiViewOptions struct {
+	Kubeconfig string
+	Portal     string
+}
+
+func (o *ClusterPortalLtiViewOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	config, err := loadLtiConfiguration(ctx, o.Kubeconfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Platform:", config.Platform)
+	fmt.Println("Issuer:", config.Issuer)
+	fmt.Println("Client ID:", config.ClientId)
+
+	if len(config.DeploymentIds) != 0 {
+		fmt.Println("Deployment IDs:", strings.Join(config.DeploymentIds, ", "))
+	}
+
+	fmt.Println("Auth Endpoint:", config.Endpoints.AuthEndpoint)
+	fmt.Println("Token Endpoint:", config.Endpoints.TokenEndpoint)
+	fmt.Println("JWKS Endpoint:", config.Endpoints.JwksEndpoint)
+	fmt.Println("Grade Pass-back:", config.GradePassback)
+	fmt.Println("Tool Key ID:", ltiKeyId(config.PublicKey))
+
+	if loginURL, launchURL, err := config.toolEndpoints(); err == nil {
+		fmt.Println("Login URL:", loginURL)
+		fmt.Println("Launch URL:", launchURL)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalLtiViewCmd() *cobra.Command {
+	var o ClusterPortalLtiViewOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "view",
+		Short: "View LTI platform for portal",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalMaintenanceOptions struct {
+	Kubeconfig string
+	Portal     string
+	Mode       string
+	Message    string
+}
+
+func (o *ClusterPortalMaintenanceOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Mode != "" && o.Mode != "on" && o.Mode != "off" {
+		return failures.NewValidationError(errors.Errorf("unsupported maintenance mode %q", o.Mode), "maintenance mode must be one of on or off")
+	}
+
+	if o.Message != "" && o.Mode != "on" {
+		return failures.NewValidationError(errors.New("message can only be supplied when turning on maintenance mode"), "")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, cluster.NewClusterConfig(o.Kubeconfig), o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	var status int
+	var resBody []byte
+
+	if o.Mode == "" {
+		status, resBody, err = portalClient.Request(ctx, "GET", "/workshops/maintenance/", nil)
+	} else {
+		var body []byte
+
+		body, err = json.Marshal(map[string]interface{}{
+			"enabled": o.Mode == "on",
+			"message": o.Message,
+		})
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode maintenance details")
+		}
+
+		status, resBody, err = portalClient.Request(ctx, "POST", "/workshops/maintenance/", bytes.NewReader(body))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if status != 200 {
+		return errors.Errorf("unable to update maintenance mode, training portal returned status %d", status)
+	}
+
+	var result struct {
+		Enabled bool   `json:"enabled"`
+		Message string `json:"message"`
+	}
+
+	if err = json.Unmarshal(resBody, &result); err != nil {
+		return errors.Wrap(err, "unable to decode response from training portal")
+	}
+
+	if !result.Enabled {
+		fmt.Printf("Maintenance mode is off for training portal %s.\n", o.Portal)
+
+		return nil
+	}
+
+	fmt.Printf("Maintenance mode is on for training portal %s.\n", o.Portal)
+
+	if result.Message != "" {
+		fmt.Printf("Message: %s\n", result.Message)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalMaintenanceCmd() *cobra.Command {
+	var o ClusterPortalMaintenanceOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "maintenance [on|off]",
+		Short: "Control maintenance mode of training portal",
+		Long: `Control maintenance mode of a training portal.
+
+While in maintenance mode, a banner is shown on all pages of the training
+portal, including the login page, along with any message supplied, and only
+staff users can start new workshop sessions. Existing workshop sessions keep
+running and users can still return to them. The training portal does not
+need to be restarted for the change to take effect.
+
+When run without "on" or "off", reports whether the training portal is in
+maintenance mode.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				o.Mode = args[0]
+			}
+
+			return o.Run(cmd.Context())
+		},
+		ValidArgs: []string{"on", "off"},
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Message,
+		"message",
+		"",
+		"message to display in the maintenance banner",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
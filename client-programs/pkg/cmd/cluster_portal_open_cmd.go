@@ -2,13 +2,11 @@ package cmd
 
 import (
 	"context"
-	"fmt"
-	"os/exec"
-	"runtime"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -18,9 +16,10 @@ type ClusterPortalOpenOptions struct {
 	Kubeconfig string
 	Admin      bool
 	Portal     string
+	Browser    WebBrowserOptions
 }
 
-func (o *ClusterPortalOpenOptions) Run() error {
+func (o *ClusterPortalOpenOptions) Run(ctx context.Context) error {
 	var err error
 
 	// Ensure have portal name.
@@ -39,10 +38,10 @@ func (o *ClusterPortalOpenOptions) Run() error {
 
 	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
 
-	trainingPortal, err := trainingPortalClient.Get(context.TODO(), o.Portal, metav1.GetOptions{})
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
-		return errors.New("no workshops deployed")
+		return failures.NewNotFoundError(errors.New("no workshops deployed"), "deploy a workshop with `educates cluster workshop deploy`")
 	}
 
 	url, found, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
@@ -55,18 +54,11 @@ func (o *ClusterPortalOpenOptions) Run() error {
 		url = url + "/admin"
 	}
 
-	switch runtime.GOOS {
-	case "linux":
-		err = exec.Command("xdg-open", url).Start()
-	case "windows":
-		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
-	case "darwin":
-		err = exec.Command("open", url).Start()
-	default:
-		err = fmt.Errorf("unsupported platform")
+	o.Browser.Shortener = func(longURL string) (string, error) {
+		return shortenURL(ctx, clusterConfig, longURL)
 	}
 
-	return err
+	return o.Browser.Open(url)
 }
 
 func (p *ProjectInfo) NewClusterPortalOpenCmd() *cobra.Command {
@@ -76,7 +68,7 @@ func (p *ProjectInfo) NewClusterPortalOpenCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "open",
 		Short: "Open training portal in web browser",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -99,5 +91,10 @@ func (p *ProjectInfo) NewClusterPortalOpenCmd() *cobra.Command {
 		"name to be used for training portal and workshop name prefixes",
 	)
 
+	o.Browser.AddFlags(c)
+	o.Browser.AddShortenFlag(c)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
 	return c
 }
@@ -0,0 +1,399 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+// Placeholder substituted for the training portal password when generating
+// a Helm chart so that it can be replaced with a template expression once the
+// resource has been converted to YAML.
+
+const helmPasswordPlaceholder = "__EDUCATES_PORTAL_PASSWORD__"
+
+type ClusterPortalPackageOptions struct {
+	Kubeconfig string
+	Portal     string
+	Format     string
+	OutputDir  string
+	Version    string
+}
+
+func (o *ClusterPortalPackageOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Format != "helm" && o.Format != "carvel" {
+		return failures.NewValidationError(errors.Errorf("unsupported package format %q", o.Format), "supported formats are helm and carvel")
+	}
+
+	if o.OutputDir == "" {
+		o.OutputDir = o.Portal
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query training portal %q in cluster", o.Portal)
+	}
+
+	cleanExportedResource(trainingPortal)
+
+	// Capture the workshop definitions for all workshops hosted by the
+	// training portal.
+
+	portalWorkshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	var workshops []*unstructured.Unstructured
+
+	for _, item := range portalWorkshops {
+		object, ok := item.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		name, _ := object["name"].(string)
+
+		workshop, err := dynamicClient.Resource(workshopResource).Get(ctx, name, metav1.GetOptions{})
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to query workshop %q in cluster", name)
+		}
+
+		cleanExportedResource(workshop)
+
+		workshops = append(workshops, workshop)
+	}
+
+	if o.Format == "helm" {
+		err = writeHelmChart(o.OutputDir, o.Version, trainingPortal, workshops)
+	} else {
+		err = writeCarvelPackage(o.OutputDir, o.Version, trainingPortal, workshops)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Package for training portal %q written to %s.\n", o.Portal, o.OutputDir)
+
+	return nil
+}
+
+/*
+Remove fields from a resource retrieved from the cluster which are specific
+to that cluster, so that it can be created afresh in a different cluster.
+*/
+func cleanExportedResource(object *unstructured.Unstructured) {
+	object.SetResourceVersion("")
+	object.SetUID("")
+	object.SetGeneration(0)
+	object.SetCreationTimestamp(metav1.Time{})
+	object.SetManagedFields(nil)
+	object.SetOwnerReferences(nil)
+	object.SetFinalizers(nil)
+
+	annotations := object.GetAnnotations()
+
+	delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+	delete(annotations, "kopf.zalando.org/last-handled-configuration")
+
+	object.SetAnnotations(annotations)
+
+	delete(object.Object, "status")
+}
+
+/*
+Write out a Helm chart for installing the training portal and workshops. The
+training portal password can be overridden using the chart values. Any Helm
+template delimiters appearing in the resources are escaped so they come out
+unchanged when the chart is rendered.
+*/
+func writeHelmChart(directory string, version string, trainingPortal *unstructured.Unstructured, workshops []*unstructured.Unstructured) error {
+	templatesDir := filepath.Join(directory, "templates")
+
+	err := os.MkdirAll(templatesDir, os.ModePerm)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create chart directory %q", templatesDir)
+	}
+
+	chartData := fmt.Sprintf(`apiVersion: v2
+name: %s
+description: Educates training portal %s and its workshops.
+type: application
+version: %s
+`, trainingPortal.GetName(), trainingPortal.GetName(), version)
+
+	err = os.WriteFile(filepath.Join(directory, "Chart.yaml"), []byte(chartData), 0644)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to write chart metadata")
+	}
+
+	valuesData := `portal:
+  # Password for accessing the training portal. If not set the password the
+  # training portal was packaged with is used.
+  password: ""
+`
+
+	err = os.WriteFile(filepath.Join(directory, "values.yaml"), []byte(valuesData), 0644)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to write chart values")
+	}
+
+	password, _, _ := unstructured.NestedString(trainingPortal.Object, "spec", "portal", "password")
+
+	trainingPortal = trainingPortal.DeepCopy()
+
+	unstructured.SetNestedField(trainingPortal.Object, helmPasswordPlaceholder, "spec", "portal", "password")
+
+	escape := func(data []byte) string {
+		return strings.ReplaceAll(string(data), "{{", "{{`{{`}}")
+	}
+
+	trainingPortalData, err := yaml.Marshal(trainingPortal.Object)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate training portal manifest")
+	}
+
+	passwordExpression := fmt.Sprintf("{{ .Values.portal.password | default %q | quote }}", password)
+
+	err = os.WriteFile(filepath.Join(templatesDir, "trainingportal.yaml"), []byte(strings.Replace(escape(trainingPortalData), helmPasswordPlaceholder, passwordExpression, 1)), 0644)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to write training portal manifest")
+	}
+
+	for _, workshop := range workshops {
+		workshopData, err := yaml.Marshal(workshop.Object)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to generate manifest for workshop %q", workshop.GetName())
+		}
+
+		err = os.WriteFile(filepath.Join(templatesDir, fmt.Sprintf("workshop-%s.yaml", workshop.GetName())), []byte(escape(workshopData)), 0644)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to write manifest for workshop %q", workshop.GetName())
+		}
+	}
+
+	return nil
+}
+
+/*
+Write out a kapp-controller Package, with the resources included inline so
+no separate image bundle is required, along with a PackageInstall for
+installing it. The training portal password can be overridden using the
+package data values.
+*/
+func writeCarvelPackage(directory string, version string, trainingPortal *unstructured.Unstructured, workshops []*unstructured.Unstructured) error {
+	err := os.MkdirAll(directory, os.ModePerm)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create package directory %q", directory)
+	}
+
+	packageName := fmt.Sprintf("%s.portals.educates.dev", trainingPortal.GetName())
+
+	files := map[string]interface{}{}
+
+	trainingPortalData, err := yaml.Marshal(trainingPortal.Object)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate training portal manifest")
+	}
+
+	files["trainingportal.yaml"] = string(trainingPortalData)
+
+	for _, workshop := range workshops {
+		workshopData, err := yaml.Marshal(workshop.Object)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to generate manifest for workshop %q", workshop.GetName())
+		}
+
+		files[fmt.Sprintf("workshop-%s.yaml", workshop.GetName())] = string(workshopData)
+	}
+
+	files["schema.yaml"] = `#@data/values-schema
+---
+portal:
+  #@schema/desc "Password for accessing the training portal."
+  password: ""
+`
+
+	files["overlay.yaml"] = `#@ load("@ytt:overlay", "overlay")
+#@ load("@ytt:data", "data")
+
+#@ if data.values.portal.password:
+#@overlay/match by=overlay.subset({"kind": "TrainingPortal"})
+---
+spec:
+  portal:
+    password: #@ data.values.portal.password
+#@ end
+`
+
+	packageResource := map[string]interface{}{
+		"apiVersion": "data.packaging.carvel.dev/v1alpha1",
+		"kind":       "Package",
+		"metadata": map[string]interface{}{
+			"name": fmt.Sprintf("%s.%s", packageName, version),
+		},
+		"spec": map[string]interface{}{
+			"refName": packageName,
+			"version": version,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"fetch": []interface{}{
+						map[string]interface{}{
+							"inline": map[string]interface{}{
+								"paths": files,
+							},
+						},
+					},
+					"template": []interface{}{
+						map[string]interface{}{
+							"ytt": map[string]interface{}{
+								"paths": []interface{}{"."},
+							},
+						},
+					},
+					"deploy": []interface{}{
+						map[string]interface{}{
+							"kapp": map[string]interface{}{},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	packageInstallResource := map[string]interface{}{
+		"apiVersion": "packaging.carvel.dev/v1alpha1",
+		"kind":       "PackageInstall",
+		"metadata": map[string]interface{}{
+			"name":      trainingPortal.GetName(),
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"serviceAccountName": "default",
+			"packageRef": map[string]interface{}{
+				"refName": packageName,
+				"versionSelection": map[string]interface{}{
+					"constraints": version,
+				},
+			},
+		},
+	}
+
+	for name, resource := range map[string]interface{}{"package.yaml": packageResource, "packageinstall.yaml": packageInstallResource} {
+		data, err := yaml.Marshal(resource)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to generate %s", name)
+		}
+
+		err = os.WriteFile(filepath.Join(directory, name), data, 0644)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to write %s", name)
+		}
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalPackageCmd() *cobra.Command {
+	var o ClusterPortalPackageOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "package",
+		Short: "Package portal and workshops for installing elsewhere",
+		Long: `Package portal and workshops for installing elsewhere.
+
+Captures the training portal and the definitions of the workshops it hosts as
+a Helm chart, or as a kapp-controller Package and PackageInstall, so that the
+same training setup can be installed into other clusters using standard
+tooling. The training portal password can be overridden when installing the
+package. The PackageInstall is created in the default namespace using the
+default service account, which must be given permissions to create the
+resources, and should be edited to suit the target cluster.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().StringVar(
+		&o.Format,
+		"format",
+		"helm",
+		"format of the package to create (helm or carvel)",
+	)
+	c.Flags().StringVarP(
+		&o.OutputDir,
+		"output",
+		"o",
+		"",
+		"directory to write the package to, defaults to the portal name",
+	)
+	c.Flags().StringVar(
+		&o.Version,
+		"version",
+		"0.1.0",
+		"version number to give the package",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
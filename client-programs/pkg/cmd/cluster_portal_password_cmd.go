@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -20,7 +21,7 @@ type ClusterPortalPasswordOptions struct {
 	Portal     string
 }
 
-func (o *ClusterPortalPasswordOptions) Run() error {
+func (o *ClusterPortalPasswordOptions) Run(ctx context.Context) error {
 	var err error
 
 	// Ensure have portal name.
@@ -39,10 +40,10 @@ func (o *ClusterPortalPasswordOptions) Run() error {
 
 	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
 
-	trainingPortal, err := trainingPortalClient.Get(context.TODO(), o.Portal, metav1.GetOptions{})
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
-		return errors.New("no workshops deployed")
+		return failures.NewNotFoundError(errors.New("no workshops deployed"), "deploy a workshop with `educates cluster workshop deploy`")
 	}
 
 	if o.Admin {
@@ -81,7 +82,7 @@ func (p *ProjectInfo) NewClusterPortalPasswordCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "password",
 		Short: "View credentials for training portal",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -104,5 +105,7 @@ func (p *ProjectInfo) NewClusterPortalPasswordCmd() *cobra.Command {
 		"name to be used for training portal and workshop name prefixes",
 	)
 
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
 	return c
 }
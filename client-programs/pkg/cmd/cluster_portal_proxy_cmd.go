@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalProxyOptions struct {
+	Kubeconfig  string
+	Portal      string
+	Address     string
+	Port        int
+	InjectToken bool
+	Admin       bool
+	Browser     WebBrowserOptions
+}
+
+func (o *ClusterPortalProxyOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Port < 0 || o.Port > 65535 {
+		return failures.NewValidationError(errors.Errorf("invalid port %d", o.Port), "port must be between 0 and 65535")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve training portal")
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	pod, err := trainingPortalPod(ctx, client, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	// When injecting an access token, the port forward is made to a free
+	// port on the loopback interface, with a reverse proxy listening on the
+	// requested address and port which adds the access token to requests.
+
+	forwardAddress, forwardPort := o.Address, o.Port
+
+	if o.InjectToken {
+		forwardAddress, forwardPort = "127.0.0.1", 0
+	}
+
+	stopChannel := make(chan struct{})
+
+	defer close(stopChannel)
+
+	localPort, errorChannel, err := clusterConfig.ForwardPort(pod.Namespace, pod.Name, forwardAddress, forwardPort, 8080, stopChannel)
+
+	if err != nil {
+		return err
+	}
+
+	serverErrors := make(chan error, 1)
+
+	if o.InjectToken {
+		target, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", localPort))
+
+		portalClient, err := NewTrainingPortalClientForURL(ctx, trainingPortal, target.String())
+
+		if err != nil {
+			return err
+		}
+
+		defer portalClient.Logout(ctx)
+
+		listener, err := net.Listen("tcp", net.JoinHostPort(o.Address, strconv.Itoa(o.Port)))
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to listen on %s", net.JoinHostPort(o.Address, strconv.Itoa(o.Port)))
+		}
+
+		defer listener.Close()
+
+		localPort = listener.Addr().(*net.TCPAddr).Port
+
+		proxy := httputil.NewSingleHostReverseProxy(target)
+
+		director := proxy.Director
+
+		proxy.Director = func(req *http.Request) {
+			director(req)
+
+			if req.Header.Get("Authorization") == "" {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", portalClient.AccessToken))
+			}
+		}
+
+		server := http.Server{
+			Handler: proxy,
+		}
+
+		go func() {
+			<-ctx.Done()
+			server.Shutdown(ctx)
+		}()
+
+		go func() {
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				serverErrors <- err
+			}
+		}()
+	}
+
+	localURL := fmt.Sprintf("http://%s", net.JoinHostPort(o.Address, strconv.Itoa(localPort)))
+
+	fmt.Printf("Forwarding %s to training portal %q, press Ctrl-C to stop.\n", localURL, o.Portal)
+
+	if o.InjectToken {
+		fmt.Println("Requests without an Authorization header are sent using the robot account access token.")
+	}
+
+	if o.Admin {
+		localURL = localURL + "/admin"
+	}
+
+	if err = o.Browser.Open(localURL); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+	case err = <-errorChannel:
+		return errors.Wrap(err, "port forward to training portal failed")
+	case err = <-serverErrors:
+		return errors.Wrap(err, "unable to serve proxy for training portal")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalProxyCmd() *cobra.Command {
+	var o ClusterPortalProxyOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "proxy",
+		Short: "Forward local port to training portal",
+		Long: `Forward a local port to the training portal.
+
+Forwards a port on the local machine to the pod for the training portal,
+using the Kubernetes API, for when the ingress for the training portal can't
+be reached from the local machine, such as when the cluster is only
+accessible through a bastion host. A free port is selected if no port is
+given. The local URL for the training portal is opened in a web browser, and
+connections are forwarded until the command is interrupted.
+
+When --inject-token is used, a reverse proxy is run on the local port which
+adds an access token for the robot account of the training portal to any
+request which doesn't already supply an Authorization header, so that tools
+can use the training portal REST API without logging in. The access token is
+revoked when the command exits. As anyone able to connect to the local port
+can then use the REST API, the proxy should only listen on the loopback
+interface.
+
+Note that workshop sessions are still accessed using the ingress for the
+session, so only the training portal itself is reachable through the proxy.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().StringVar(
+		&o.Address,
+		"address",
+		"127.0.0.1",
+		"local address to listen on for connections",
+	)
+	c.Flags().IntVar(
+		&o.Port,
+		"port",
+		0,
+		"local port to listen on for connections, or 0 to select a free port",
+	)
+	c.Flags().BoolVar(
+		&o.InjectToken,
+		"inject-token",
+		false,
+		"add robot account access token to requests for the REST API",
+	)
+	c.Flags().BoolVar(
+		&o.Admin,
+		"admin",
+		false,
+		"open URL for admin login instead of workshops catalog",
+	)
+
+	o.Browser.AddFlags(c)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
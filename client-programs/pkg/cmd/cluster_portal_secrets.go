@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+// NOTE: Settings for a training portal which need to be kept secret, such as
+// client secrets and access tokens, are never added to the training portal
+// resource. They are held in a secret in the secrets namespace for Educates,
+// which is copied into the namespace the training portal runs in, so that the
+// portal deployment can reference it.
+
+const portalSecretWaitTimeout = 30 * time.Second
+
+/*
+Store secret settings for a training portal. A secret copier, owned by the
+training portal so it is deleted with it, copies the secret into the
+namespace for the training portal. If the training portal is running, this
+waits for the copy to be updated so the portal sees the new settings when it
+is restarted.
+*/
+func applyPortalSecret(ctx context.Context, clusterConfig *cluster.ClusterConfig, trainingPortal *unstructured.Unstructured, secretName string, data map[string][]byte) error {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	portal := trainingPortal.GetName()
+
+	namespacesClient := client.CoreV1().Namespaces()
+
+	_, err = namespacesClient.Get(ctx, "educates-secrets", metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		namespaceObj := apiv1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "educates-secrets",
+			},
+		}
+
+		namespacesClient.Create(ctx, &namespaceObj, metav1.CreateOptions{})
+	}
+
+	patch := applycorev1.Secret(secretName, "educates-secrets").WithType(apiv1.SecretTypeOpaque).WithData(data).WithLabels(map[string]string{
+		"training.educates.dev/portal.name": portal,
+	})
+
+	_, err = client.CoreV1().Secrets("educates-secrets").Apply(ctx, patch, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update secret in cluster %q", secretName)
+	}
+
+	secretCopier := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "secrets.educates.dev/v1beta1",
+		"kind":       "SecretCopier",
+		"metadata": map[string]interface{}{
+			"name": portalSecretCopierName(secretName),
+			"labels": map[string]interface{}{
+				"training.educates.dev/portal.name": portal,
+			},
+			"ownerReferences": []interface{}{
+				map[string]interface{}{
+					"apiVersion":         trainingPortal.GetAPIVersion(),
+					"kind":               trainingPortal.GetKind(),
+					"name":               portal,
+					"uid":                string(trainingPortal.GetUID()),
+					"blockOwnerDeletion": true,
+				},
+			},
+		},
+		"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"sourceSecret": map[string]interface{}{
+						"name":      secretName,
+						"namespace": "educates-secrets",
+					},
+					"targetNamespaces": map[string]interface{}{
+						"nameSelector": map[string]interface{}{
+							"matchNames": []interface{}{portal + "-ui"},
+						},
+					},
+				},
+			},
+		},
+	}}
+
+	_, err = dynamicClient.Resource(secretCopierResource).Apply(ctx, secretCopier.GetName(), secretCopier, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update secret copier in cluster %q", secretCopier.GetName())
+	}
+
+	// The namespace for the training portal only exists once the portal has
+	// been deployed. If it doesn't exist yet the secret will be copied into
+	// it when it is created.
+
+	_, err = namespacesClient.Get(ctx, portal+"-ui", metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	err = wait.PollImmediateWithContext(ctx, time.Second, portalSecretWaitTimeout, func(ctx context.Context) (bool, error) {
+		secret, err := client.CoreV1().Secrets(portal+"-ui").Get(ctx, secretName, metav1.GetOptions{})
+
+		if err != nil {
+			return false, nil
+		}
+
+		for key, value := range data {
+			if !bytes.Equal(secret.Data[key], value) {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	})
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: secret %s has not yet been copied to training portal %s.\n", secretName, portal)
+	}
+
+	return nil
+}
+
+func portalSecretCopierName(secretName string) string {
+	return fmt.Sprintf("educates-portal-%s", secretName)
+}
+
+/*
+Return an environment variable for the training portal deployment which
+takes its value from a key of a secret copied into the portal namespace. The
+secret is optional, with the variable not being set if it doesn't exist. Any
+literal value the variable previously had is removed.
+*/
+func portalSecretEnv(name string, secretName string, key string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":  name,
+		"value": nil,
+		"valueFrom": map[string]interface{}{
+			"secretKeyRef": map[string]interface{}{
+				"name":     secretName,
+				"key":      key,
+				"optional": true,
+			},
+		},
+	}
+}
+
+/*
+Return an environment variable for the training portal deployment with a
+literal value. Any reference to a secret the variable previously had is
+removed.
+*/
+func portalValueEnv(name string, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":      name,
+		"value":     value,
+		"valueFrom": nil,
+	}
+}
+
+/*
+Update environment variables of the existing training portal deployment. The
+training portal resource is only read when the portal is first created, so
+this is needed for changes to settings to take effect straight away.
+Changing the environment restarts the portal.
+*/
+func patchPortalDeploymentEnv(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string, env []interface{}) error {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	// A strategic merge patch matches the container and environment variables
+	// by name, so only the variables given are changed.
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name": "portal",
+							"env":  env,
+						},
+					},
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate training portal deployment patch")
+	}
+
+	_, err = client.AppsV1().Deployments(portal+"-ui").Patch(ctx, "training-portal", types.StrategicMergePatchType, patch, metav1.PatchOptions{FieldManager: "educates-cli"})
+
+	if k8serrors.IsNotFound(err) {
+		fmt.Fprintf(os.Stderr, "Warning: training portal %s has not been deployed yet, settings will apply when it is.\n", portal)
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal deployment for %q", portal)
+	}
+
+	return nil
+}
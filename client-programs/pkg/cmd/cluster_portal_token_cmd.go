@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalTokenOptions struct {
+	Kubeconfig string
+	Portal     string
+	Output     string
+	Revoke     string
+}
+
+func (o *ClusterPortalTokenOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Output != "token" && o.Output != "env" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are token, env and json")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.New("no portal found"), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve training portal")
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	// When revoking a token we are given, the token we just obtained to
+	// authenticate also needs to be revoked.
+
+	if o.Revoke != "" {
+		defer portalClient.Logout(ctx)
+
+		revokeClient := *portalClient
+		revokeClient.AccessToken = o.Revoke
+
+		if err := revokeClient.Logout(ctx); err != nil {
+			return err
+		}
+
+		fmt.Println("Access token revoked.")
+
+		return nil
+	}
+
+	expires := time.Now().Add(time.Duration(portalClient.ExpiresIn) * time.Second).UTC().Format(time.RFC3339)
+
+	switch o.Output {
+	case "token":
+		fmt.Println(portalClient.AccessToken)
+	case "env":
+		fmt.Printf("EDUCATES_PORTAL_URL=%s\n", portalClient.URL)
+		fmt.Printf("EDUCATES_PORTAL_TOKEN=%s\n", portalClient.AccessToken)
+		fmt.Printf("EDUCATES_PORTAL_TOKEN_EXPIRES=%s\n", expires)
+	case "json":
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"url":         portalClient.URL,
+			"accessToken": portalClient.AccessToken,
+			"expiresIn":   portalClient.ExpiresIn,
+			"expires":     expires,
+		}, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode access token details")
+		}
+
+		fmt.Println(string(data))
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalTokenCmd() *cobra.Command {
+	var o ClusterPortalTokenOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "token",
+		Short: "Obtain access token for portal REST API",
+		Long: `Obtain a short lived access token for the training portal REST API.
+
+The robot account credentials of the training portal are used to obtain the
+access token. The token can be output on its own, as an env file which can be
+used to set environment variables, or as JSON.
+
+How long the access token is valid for is set by the training portal, which
+issues all access tokens with the same lifetime, so it can't be chosen when
+the token is obtained. The expiry time is included in the env and JSON
+output. A token which is no longer needed should be revoked using the
+--revoke option rather than waiting for it to expire.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"token",
+		"output format for the access token (token, env or json)",
+	)
+	c.Flags().StringVar(
+		&o.Revoke,
+		"revoke",
+		"",
+		"revoke the supplied access token instead of obtaining a new one",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
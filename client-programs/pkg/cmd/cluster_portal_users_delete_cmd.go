@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterPortalUsersDeleteOptions struct {
+	Kubeconfig string
+	Portal     string
+	File       string
+	All        bool
+}
+
+func (o *ClusterPortalUsersDeleteOptions) Run(ctx context.Context, names []string) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.File != "" {
+		file, err := os.Open(o.File)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to open %s", o.File)
+		}
+
+		defer file.Close()
+
+		users, err := readPortalUsers(file)
+
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			if user.Username != "" {
+				names = append(names, user.Username)
+			} else {
+				names = append(names, user.Email)
+			}
+		}
+	}
+
+	if len(names) == 0 && !o.All {
+		return failures.NewValidationError(errors.New("no users given to delete"), "supply the names of users, a CSV file using --file, or use --all")
+	}
+
+	if len(names) != 0 && o.All {
+		return failures.NewValidationError(errors.New("names of users cannot be given with --all"), "")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, cluster.NewClusterConfig(o.Kubeconfig), o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	if o.All {
+		users, err := listPortalUsers(ctx, portalClient)
+
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			names = append(names, user.Username)
+		}
+
+		if len(names) == 0 {
+			fmt.Println("No users found.")
+			return nil
+		}
+	}
+
+	if err = confirmAction(fmt.Sprintf("delete %d users from portal %s", len(names), o.Portal)); err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, name := range names {
+		status, _, err := portalClient.Request(ctx, "DELETE", fmt.Sprintf("/workshops/user/%s/delete/", url.PathEscape(name)), nil)
+
+		if err != nil {
+			return err
+		}
+
+		switch status {
+		case 200:
+			fmt.Printf("Deleted user %s.\n", name)
+		case 404:
+			fmt.Fprintf(os.Stderr, "Warning: no user %q found.\n", name)
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: unable to delete user %q, training portal returned status %d.\n", name, status)
+			failed++
+		}
+	}
+
+	if failed != 0 {
+		return errors.Errorf("unable to delete %d users", failed)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalUsersDeleteCmd() *cobra.Command {
+	var o ClusterPortalUsersDeleteOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ArbitraryArgs,
+		Use:   "delete [NAME...]",
+		Short: "Delete user accounts from portal",
+		Long: `Delete user accounts from portal.
+
+Deletes the named user accounts from the training portal. The users can also
+be given as a CSV file using --file, in the same format as accepted by the
+import command, so that accounts created for a class can be removed once the
+class is over. Use --all to delete all user accounts, other than those the
+training portal creates for its own use.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.File,
+		"file",
+		"",
+		"CSV file listing the users to delete",
+	)
+	c.Flags().BoolVar(
+		&o.All,
+		"all",
+		false,
+		"delete all user accounts",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
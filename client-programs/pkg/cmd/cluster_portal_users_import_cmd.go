@@ -0,0 +1,471 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+/*
+Details of a user account as accepted by the users endpoint of the training
+portal REST API.
+*/
+type portalUserEntry struct {
+	Username  string   `json:"username,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	FirstName string   `json:"first_name,omitempty"`
+	LastName  string   `json:"last_name,omitempty"`
+	Password  string   `json:"password,omitempty"`
+	SSO       bool     `json:"sso,omitempty"`
+	Staff     *bool    `json:"staff,omitempty"`
+	Groups    []string `json:"groups,omitempty"`
+}
+
+type portalUsersImportResult struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Errors  []struct {
+		Username string `json:"username"`
+		Error    string `json:"error"`
+	} `json:"errors"`
+}
+
+/*
+Return whether a group is one the training portal manages itself, which users
+can't be added to by importing them.
+*/
+func isReservedPortalGroup(group string) bool {
+	return group == "robots" || group == "anonymous" || strings.HasPrefix(group, "idp:")
+}
+
+var portalUserColumns = []string{"username", "email", "name", "first_name", "last_name", "password", "sso", "staff", "groups"}
+
+/*
+Read user accounts from a CSV file. The first row must be a header naming the
+columns, which can be given in any order. Only a username or email is
+required for each user, with the email being used as the username if no
+username is given. A full name is split into first and last names if they
+aren't given separately. Groups are separated by semicolons.
+*/
+func readPortalUsers(reader io.Reader) ([]portalUserEntry, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+
+	if err != nil {
+		return nil, failures.NewValidationError(errors.Wrap(err, "unable to parse CSV file"), "")
+	}
+
+	if len(records) == 0 {
+		return nil, failures.NewValidationError(errors.New("no header row in CSV file"), fmt.Sprintf("columns can be %s", strings.Join(portalUserColumns, ", ")))
+	}
+
+	columns := map[string]int{}
+
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+
+		if !containsString(portalUserColumns, name) {
+			return nil, failures.NewValidationError(errors.Errorf("unknown column %q in CSV file", records[0][i]), fmt.Sprintf("columns can be %s", strings.Join(portalUserColumns, ", ")))
+		}
+
+		columns[name] = i
+	}
+
+	if _, found := columns["username"]; !found {
+		if _, found := columns["email"]; !found {
+			return nil, failures.NewValidationError(errors.New("no username or email column in CSV file"), "")
+		}
+	}
+
+	var users []portalUserEntry
+
+	for row, record := range records[1:] {
+		value := func(name string) string {
+			if i, found := columns[name]; found && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		flag := func(name string) (bool, error) {
+			switch strings.ToLower(value(name)) {
+			case "", "no", "n":
+				return false, nil
+			case "yes", "y":
+				return true, nil
+			}
+
+			result, err := strconv.ParseBool(value(name))
+
+			if err != nil {
+				return false, failures.NewValidationError(errors.Errorf("invalid value %q for %s on line %d of CSV file", value(name), name, row+2), "")
+			}
+
+			return result, nil
+		}
+
+		user := portalUserEntry{
+			Username:  value("username"),
+			Email:     value("email"),
+			FirstName: value("first_name"),
+			LastName:  value("last_name"),
+			Password:  value("password"),
+		}
+
+		if user.Username == "" && user.Email == "" {
+			return nil, failures.NewValidationError(errors.Errorf("no username or email on line %d of CSV file", row+2), "")
+		}
+
+		if strings.HasPrefix(user.Username, "idp:") || (user.Username == "" && strings.HasPrefix(user.Email, "idp:")) {
+			return nil, failures.NewValidationError(errors.Errorf("reserved username on line %d of CSV file", row+2), "usernames starting with idp: are used for users who login using an identity provider")
+		}
+
+		if name := value("name"); name != "" && user.FirstName == "" && user.LastName == "" {
+			parts := strings.Fields(name)
+
+			user.FirstName = strings.Join(parts[:len(parts)-1], " ")
+			user.LastName = parts[len(parts)-1]
+
+			if user.FirstName == "" {
+				user.FirstName, user.LastName = user.LastName, ""
+			}
+		}
+
+		if user.SSO, err = flag("sso"); err != nil {
+			return nil, err
+		}
+
+		// The training portal doesn't permit staff access to be granted, nor
+		// existing staff accounts to be changed, so the column is only
+		// accepted where it says a user isn't staff.
+
+		if _, found := columns["staff"]; found {
+			staff, err := flag("staff")
+
+			if err != nil {
+				return nil, err
+			}
+
+			if staff {
+				return nil, failures.NewValidationError(errors.Errorf("staff access cannot be granted on line %d of CSV file", row+2), "staff access can only be granted using the admin pages of the training portal")
+			}
+
+			user.Staff = &staff
+		}
+
+		for _, group := range strings.Split(value("groups"), ";") {
+			if group = strings.TrimSpace(group); group != "" {
+				if isReservedPortalGroup(group) {
+					return nil, failures.NewValidationError(errors.Errorf("reserved group %q on line %d of CSV file", group, row+2), "the robots and anonymous groups, and groups starting with idp:, are managed by the training portal")
+				}
+
+				user.Groups = append(user.Groups, group)
+			}
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+type ClusterPortalUsersImportOptions struct {
+	Kubeconfig        string
+	Portal            string
+	SSO               bool
+	GeneratePasswords bool
+	CredentialsFile   string
+	BatchSize         int
+	DryRun            bool
+}
+
+func (o *ClusterPortalUsersImportOptions) Run(ctx context.Context, path string) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.BatchSize <= 0 {
+		return failures.NewValidationError(errors.Errorf("invalid batch size %d", o.BatchSize), "batch size must be greater than zero")
+	}
+
+	var reader io.Reader = os.Stdin
+
+	if path != "-" {
+		file, err := os.Open(path)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to open %s", path)
+		}
+
+		defer file.Close()
+
+		reader = file
+	}
+
+	users, err := readPortalUsers(reader)
+
+	if err != nil {
+		return err
+	}
+
+	// Users are either linked to a single sign on identity provider, in which
+	// case they can't login to the training portal directly, or are given an
+	// initial password, which can be generated if not supplied.
+
+	var generated []portalUserEntry
+
+	for i := range users {
+		if o.SSO {
+			users[i].SSO = true
+		}
+
+		if users[i].SSO {
+			users[i].Password = ""
+		} else if users[i].Password == "" && o.GeneratePasswords {
+			users[i].Password = training.RandomPassword(12)
+			generated = append(generated, users[i])
+		}
+	}
+
+	if o.DryRun {
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "USERNAME", "EMAIL", "NAME", "LOGIN", "GROUPS")
+
+		for _, user := range users {
+			username := user.Username
+
+			if username == "" {
+				username = user.Email
+			}
+
+			login := "password"
+
+			if user.SSO {
+				login = "sso"
+			} else if user.Password == "" {
+				login = "none"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", username, user.Email, strings.TrimSpace(user.FirstName+" "+user.LastName), login, strings.Join(user.Groups, ";"))
+		}
+
+		return nil
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, cluster.NewClusterConfig(o.Kubeconfig), o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	// Users are sent in batches so a large class doesn't result in a single
+	// request which takes longer than the training portal allows.
+
+	created, updated, failed := 0, 0, 0
+
+	for start := 0; start < len(users); start += o.BatchSize {
+		end := start + o.BatchSize
+
+		if end > len(users) {
+			end = len(users)
+		}
+
+		body, err := json.Marshal(map[string]interface{}{"users": users[start:end]})
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode user details")
+		}
+
+		status, resBody, err := portalClient.Request(ctx, "POST", "/workshops/users/", bytes.NewReader(body))
+
+		if err != nil {
+			return err
+		}
+
+		if status != 200 {
+			return errors.Errorf("unable to import users, training portal returned status %d", status)
+		}
+
+		var result portalUsersImportResult
+
+		if err = json.Unmarshal(resBody, &result); err != nil {
+			return errors.Wrap(err, "unable to decode response from training portal")
+		}
+
+		for _, item := range result.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: unable to import user %q: %s.\n", item.Username, item.Error)
+		}
+
+		created += len(result.Created)
+		updated += len(result.Updated)
+		failed += len(result.Errors)
+	}
+
+	if len(generated) != 0 {
+		if err = writePortalUserCredentials(o.CredentialsFile, generated); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Created %d users and updated %d users in portal %s.\n", created, updated, o.Portal)
+
+	if failed != 0 {
+		return errors.Errorf("unable to import %d users", failed)
+	}
+
+	return nil
+}
+
+/*
+Write usernames and generated passwords as CSV so they can be distributed to
+users. If no file is given they are written to stdout.
+*/
+func writePortalUserCredentials(path string, users []portalUserEntry) error {
+	var out io.Writer = os.Stdout
+
+	if path != "" && path != "-" {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create %s", path)
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	w := csv.NewWriter(out)
+
+	w.Write([]string{"username", "email", "password"})
+
+	for _, user := range users {
+		username := user.Username
+
+		if username == "" {
+			username = user.Email
+		}
+
+		w.Write([]string{username, user.Email, user.Password})
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return errors.Wrap(err, "unable to write credentials")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalUsersImportCmd() *cobra.Command {
+	var o ClusterPortalUsersImportOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "import FILE",
+		Short: "Import user accounts into portal",
+		Long: `Import user accounts into portal.
+
+Creates or updates user accounts in the training portal from a CSV file, so
+accounts for a large class can be prepared in advance. The first row of the
+file must name the columns, which can be any of username, email, name,
+first_name, last_name, password, sso, staff and groups. Each user must have a
+username or email, with the email being used as the username if no username
+is given. Multiple groups are separated by semicolons. Use "-" to read the
+CSV file from stdin.
+
+Users can't be added to the robots or anonymous groups, or to groups starting
+with "idp:", as these are managed by the training portal. Usernames starting
+with "idp:" are likewise reserved for users who login using an identity
+provider. Staff access can't be granted, and existing staff and administrator
+accounts can't be changed, except by using the admin pages of the training
+portal.
+
+Users marked as sso, or all users if --sso is given, are linked to the single
+sign on identity provider configured for the training portal and can't login
+to the training portal directly. Other users without a password can only
+login once one is set, unless --generate-passwords is given, in which case
+passwords are generated for them and output as CSV for distribution to users.
+
+Existing users are updated with any details supplied, with their password
+only being changed if a new one is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args[0]) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().BoolVar(
+		&o.SSO,
+		"sso",
+		false,
+		"link all users to the single sign on identity provider",
+	)
+	c.Flags().BoolVar(
+		&o.GeneratePasswords,
+		"generate-passwords",
+		false,
+		"generate passwords for users where no password is given",
+	)
+	c.Flags().StringVar(
+		&o.CredentialsFile,
+		"credentials-file",
+		"",
+		"file to write generated passwords to as CSV, defaults to stdout",
+	)
+	c.Flags().IntVar(
+		&o.BatchSize,
+		"batch-size",
+		100,
+		"maximum number of users to send to the training portal in one request",
+	)
+	c.Flags().BoolVar(
+		&o.DryRun,
+		"dry-run",
+		false,
+		"display the users which would be imported without importing them",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type portalUserDetails struct {
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	Staff     bool       `json:"staff"`
+	SSO       bool       `json:"sso"`
+	Active    bool       `json:"active"`
+	Joined    time.Time  `json:"joined"`
+	LastLogin *time.Time `json:"last_login"`
+	Groups    []string   `json:"groups"`
+}
+
+/*
+Return the user accounts of a training portal, excluding the accounts the
+training portal creates for its own use.
+*/
+func listPortalUsers(ctx context.Context, portalClient *TrainingPortalClient) ([]portalUserDetails, error) {
+	status, resBody, err := portalClient.Request(ctx, "GET", "/workshops/users/", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if status != 200 {
+		return nil, errors.Errorf("unable to list users, training portal returned status %d", status)
+	}
+
+	var result struct {
+		Users []portalUserDetails `json:"users"`
+	}
+
+	if err = json.Unmarshal(resBody, &result); err != nil {
+		return nil, errors.Wrap(err, "unable to decode response from training portal")
+	}
+
+	return result.Users, nil
+}
+
+type ClusterPortalUsersListOptions struct {
+	Kubeconfig string
+	Portal     string
+	Output     string
+}
+
+func (o *ClusterPortalUsersListOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Output != "table" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are table and json")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, cluster.NewClusterConfig(o.Kubeconfig), o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	users, err := listPortalUsers(ctx, portalClient)
+
+	if err != nil {
+		return err
+	}
+
+	if o.Output == "json" {
+		jsonData, err := json.MarshalIndent(users, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode users")
+		}
+
+		fmt.Println(string(jsonData))
+
+		return nil
+	}
+
+	if len(users) == 0 {
+		fmt.Println("No users found.")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "USERNAME", "EMAIL", "NAME", "LOGIN", "STAFF", "LAST LOGIN")
+
+	for _, user := range users {
+		login := "password"
+
+		if user.SSO {
+			login = "sso"
+		}
+
+		lastLogin := "-"
+
+		if user.LastLogin != nil {
+			lastLogin = user.LastLogin.Local().Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n", user.Username, user.Email, strings.TrimSpace(user.FirstName+" "+user.LastName), login, user.Staff, lastLogin)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterPortalUsersListCmd() *cobra.Command {
+	var o ClusterPortalUsersListOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "list",
+		Short: "List user accounts in portal",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"table",
+		"output format, one of table or json",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+func (p *ProjectInfo) NewClusterSecretsCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage secrets used by workshops",
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewAdminSecretsAddCmdGroup(),
+				p.NewClusterSecretsListCmd(),
+				p.NewAdminSecretsSyncCmd(),
+				p.NewClusterSecretsRemoveCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
+
+/*
+Read all secrets held in the local secrets cache. Files which cannot be read
+or decoded are skipped.
+*/
+func readCachedSecrets() map[string]*apiv1.Secret {
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	secretsCacheDir := filepath.Join(configFileDir, "secrets")
+
+	secrets := map[string]*apiv1.Secret{}
+
+	files, err := os.ReadDir(secretsCacheDir)
+
+	if err != nil {
+		return secrets
+	}
+
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".yaml") {
+			name := strings.TrimSuffix(f.Name(), ".yaml")
+			fullPath := filepath.Join(secretsCacheDir, f.Name())
+
+			yamlData, err := os.ReadFile(fullPath)
+
+			if err != nil {
+				continue
+			}
+
+			decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder()
+			secretObj := &apiv1.Secret{}
+			err = runtime.DecodeInto(decoder, yamlData, secretObj)
+
+			if err != nil {
+				continue
+			}
+
+			secrets[name] = secretObj
+		}
+	}
+
+	return secrets
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+type ClusterSecretsListOptions struct {
+	Kubeconfig string
+}
+
+func (o *ClusterSecretsListOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	cachedSecrets := readCachedSecrets()
+
+	secretsClient := client.CoreV1().Secrets("educates-secrets")
+
+	clusterSecrets, err := secretsClient.List(ctx, metav1.ListOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrap(err, "unable to read secrets from cluster")
+	}
+
+	types := map[string]string{}
+	inCluster := map[string]bool{}
+
+	for name, secret := range cachedSecrets {
+		types[name] = string(secret.Type)
+	}
+
+	if clusterSecrets != nil {
+		for _, secret := range clusterSecrets.Items {
+			// Skip service account tokens automatically created by older
+			// versions of Kubernetes as they are not of interest.
+
+			if secret.Type == "kubernetes.io/service-account-token" {
+				continue
+			}
+
+			types[secret.Name] = string(secret.Type)
+			inCluster[secret.Name] = true
+		}
+	}
+
+	if len(types) == 0 {
+		fmt.Println("No secrets found.")
+		return nil
+	}
+
+	var names []string
+
+	for name := range types {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\n", "NAME", "TYPE", "STATUS")
+
+	for _, name := range names {
+		_, cached := cachedSecrets[name]
+
+		var status string
+
+		switch {
+		case cached && inCluster[name]:
+			status = "Synced"
+		case cached:
+			status = "Not Synced"
+		default:
+			status = "Cluster Only"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, types[name], status)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterSecretsListCmd() *cobra.Command {
+	var o ClusterSecretsListOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "list",
+		Short: "List secrets in the cache and cluster",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	return c
+}
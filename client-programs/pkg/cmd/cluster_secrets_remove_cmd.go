@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterSecretsRemoveOptions struct {
+	Kubeconfig string
+}
+
+func (o *ClusterSecretsRemoveOptions) Run(ctx context.Context, name string) error {
+	var err error
+	var matched bool
+
+	if matched, err = regexp.MatchString("^[a-z0-9]([.a-z0-9-]+)?[a-z0-9]$", name); err != nil {
+		return errors.Wrapf(err, "regex match on secret name failed")
+	}
+
+	if !matched {
+		return failures.NewValidationError(errors.Errorf("invalid secret name %q", name), "")
+	}
+
+	err = confirmAction(fmt.Sprintf("remove secret %q from the cache and cluster", name))
+
+	if err != nil {
+		return err
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	secretsClient := client.CoreV1().Secrets("educates-secrets")
+
+	err = secretsClient.Delete(ctx, name, metav1.DeleteOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to delete secret %q from cluster", name)
+	}
+
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	secretsCacheDir := filepath.Join(configFileDir, "secrets")
+
+	secretFilePath := filepath.Join(secretsCacheDir, name+".yaml")
+
+	err = os.Remove(secretFilePath)
+
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to remove secret file %s", secretFilePath)
+	}
+
+	return deleteSecretCredentials(name)
+}
+
+func (p *ProjectInfo) NewClusterSecretsRemoveCmd() *cobra.Command {
+	var o ClusterSecretsRemoveOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "remove NAME",
+		Short: "Remove secret from the cache and cluster",
+		RunE:  func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args[0]) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	return withAuditLogging(c)
+}
@@ -23,6 +23,10 @@ func (p *ProjectInfo) NewClusterSessionCmdGroup() *cobra.Command {
 				p.NewClusterSessionListCmd(),
 				p.NewClusterSessionStatusCmd(),
 				p.NewClusterSessionExtendCmd(),
+				p.NewClusterSessionSnapshotCmd(),
+				p.NewClusterSessionExportFilesCmd(),
+				p.NewClusterSessionCopyCmd(),
+				p.NewClusterSessionRecordingsCmdGroup(),
 				p.NewClusterSessionTerminateCmd(),
 				// p.NewClusterSessionConnectCmd(),
 			},
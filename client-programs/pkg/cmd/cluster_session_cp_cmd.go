@@ -0,0 +1,489 @@
+package cmd
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
+)
+
+type ClusterSessionCopyOptions struct {
+	Kubeconfig string
+	Container  string
+}
+
+func (o *ClusterSessionCopyOptions) Run(ctx context.Context, source string, destination string) error {
+	sourceSession, sourcePath := splitSessionPath(source)
+	destinationSession, destinationPath := splitSessionPath(destination)
+
+	if (sourceSession == "") == (destinationSession == "") {
+		return failures.NewValidationError(errors.New("exactly one of the source and destination must be a path in a session"), "give the path in the session as SESSION:/path")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	if destinationSession != "" {
+		pod, err := runningSessionPod(ctx, clusterConfig, destinationSession)
+
+		if err != nil {
+			return err
+		}
+
+		return o.copyToSession(ctx, clusterConfig, pod, sourcePath, sessionAbsolutePath(destinationPath))
+	}
+
+	pod, err := runningSessionPod(ctx, clusterConfig, sourceSession)
+
+	if err != nil {
+		return err
+	}
+
+	return o.copyFromSession(ctx, clusterConfig, pod, sessionAbsolutePath(sourcePath), destinationPath)
+}
+
+/*
+Copy a local file or directory into the session, as a tar archive streamed
+to tar running in the workshop container. Where the destination is an
+existing directory, what is copied is placed within it, otherwise the top of
+what is copied is renamed to the last component of the destination path.
+*/
+func (o *ClusterSessionCopyOptions) copyToSession(ctx context.Context, clusterConfig *cluster.ClusterConfig, pod *apiv1.Pod, source string, destination string) error {
+	if _, err := os.Lstat(source); err != nil {
+		return failures.NewNotFoundError(errors.Errorf("local path %q does not exist", source), "")
+	}
+
+	if clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, o.Container, []string{"test", "-d", destination}, nil, io.Discard) == nil {
+		destination = path.Join(destination, filepath.Base(filepath.Clean(source)))
+	}
+
+	reader, writer := io.Pipe()
+
+	go func() {
+		writer.CloseWithError(writeLocalArchive(writer, source, path.Base(destination)))
+	}()
+
+	command := []string{"sh", "-c", fmt.Sprintf("mkdir -p '%[1]s' && tar -C '%[1]s' -xmf -", strings.ReplaceAll(path.Dir(destination), "'", `'\''`))}
+
+	if err := clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, o.Container, command, reader, io.Discard); err != nil {
+		reader.CloseWithError(err)
+
+		return errors.Wrapf(err, "unable to copy %q to session", source)
+	}
+
+	return nil
+}
+
+/*
+Copy a file or directory out of the session, as a tar archive streamed from
+tar running in the workshop container. Where the destination is an existing
+directory, what is copied is placed within it, otherwise the top of what is
+copied is renamed to the destination path.
+*/
+func (o *ClusterSessionCopyOptions) copyFromSession(ctx context.Context, clusterConfig *cluster.ClusterConfig, pod *apiv1.Pod, source string, destination string) error {
+	if info, err := os.Stat(destination); err == nil && info.IsDir() {
+		destination = filepath.Join(destination, path.Base(source))
+	}
+
+	reader, writer := io.Pipe()
+
+	command := []string{"tar", "-C", path.Dir(source), "-cf", "-", path.Base(source)}
+
+	go func() {
+		writer.CloseWithError(clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, o.Container, command, nil, writer))
+	}()
+
+	err := extractSessionArchive(reader, path.Base(source), destination)
+
+	reader.Close()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to copy %q from session", source)
+	}
+
+	return nil
+}
+
+/*
+Split an argument into the name of a session and the path within it, where
+given as SESSION:/path. Where there is no session name, the whole argument is
+a local path. A single letter before the separator is treated as a Windows
+drive letter rather than a session name.
+*/
+func splitSessionPath(value string) (string, string) {
+	index := strings.Index(value, ":")
+
+	if index <= 1 || strings.ContainsAny(value[:index], `/\`) {
+		return "", value
+	}
+
+	return value[:index], value[index+1:]
+}
+
+/*
+Resolve a path in a session, with relative paths being relative to the home
+directory of the workshop user.
+*/
+func sessionAbsolutePath(value string) string {
+	if !path.IsAbs(value) {
+		value = path.Join("/home/eduk8s", value)
+	}
+
+	return path.Clean(value)
+}
+
+/*
+Find the running pod for a workshop session, looking up the workshop
+environment the session belongs to.
+*/
+func runningSessionPod(ctx context.Context, clusterConfig *cluster.ClusterConfig, name string) (*apiv1.Pod, error) {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	session, err := dynamicClient.Resource(workshopSessionResource).Get(ctx, name, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return nil, failures.NewNotFoundError(errors.Errorf("no session found with name %q", name), "list sessions with `educates cluster session list`")
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to query workshop session %q", name)
+	}
+
+	namespace := session.GetLabels()["training.educates.dev/environment.name"]
+
+	pod, err := workshopSessionPod(ctx, client, namespace, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if pod == nil {
+		return nil, failures.NewNotFoundError(errors.Errorf("no running pod found for session %q", name), "check the status of the session with `educates cluster session status`")
+	}
+
+	return pod, nil
+}
+
+/*
+Write a local file or directory to a tar archive, with the top of it given
+the name supplied. Files other than regular files, directories and symbolic
+links are skipped.
+*/
+func writeLocalArchive(out io.Writer, source string, name string) error {
+	tarWriter := tar.NewWriter(out)
+
+	source = filepath.Clean(source)
+
+	err := filepath.Walk(source, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(source, file)
+
+		if err != nil {
+			return err
+		}
+
+		link := ""
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+
+		if err != nil {
+			return err
+		}
+
+		header.Name = path.Join(name, filepath.ToSlash(relPath))
+		header.Mode = int64(platform.FileMode(file, info).Perm())
+
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err = tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		input, err := os.Open(file)
+
+		if err != nil {
+			return err
+		}
+
+		defer input.Close()
+
+		_, err = io.Copy(tarWriter, input)
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tarWriter.Close()
+}
+
+/*
+Extract a tar archive copied from a session, renaming the top of it, being
+the name given, to the destination path. Entries which would be written
+outside of the destination, and entries other than regular files,
+directories and symbolic links, are skipped. The contents of a session are
+controlled by the workshop user, so symbolic links which resolve outside of
+the destination are skipped, and nothing is ever written through a symbolic
+link, as a link could otherwise be used to write files anywhere.
+*/
+func extractSessionArchive(archive io.Reader, name string, destination string) error {
+	tarReader := tar.NewReader(archive)
+
+	destination = filepath.Clean(destination)
+
+	for {
+		header, err := tarReader.Next()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		entry := path.Clean(header.Name)
+
+		if entry != name && !strings.HasPrefix(entry, name+"/") {
+			continue
+		}
+
+		target := filepath.Join(destination, filepath.FromSlash(strings.TrimPrefix(entry, name)))
+
+		if !withinDirectory(destination, target) {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if !extractPathSafe(destination, target) {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s which would be written through a symbolic link.\n", entry)
+				continue
+			}
+
+			if err = os.MkdirAll(target, os.FileMode(header.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if !extractPathSafe(destination, filepath.Dir(target)) {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s which would be written through a symbolic link.\n", entry)
+				continue
+			}
+
+			if err = os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return err
+			}
+
+			// An existing symbolic link is replaced rather than the file it
+			// points at being written.
+
+			if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				if err = os.Remove(target); err != nil {
+					return err
+				}
+			}
+
+			output, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
+
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(output, tarReader)
+
+			output.Close()
+
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// The link is resolved relative to where it is written, which
+			// for the top of the archive is the destination and not a
+			// directory of the same name as in the session.
+
+			if filepath.IsAbs(header.Linkname) || path.IsAbs(header.Linkname) || !linkWithinDirectory(destination, filepath.Dir(target), header.Linkname) {
+				fmt.Fprintf(os.Stderr, "Warning: skipping symbolic link %s which points outside of the copy.\n", entry)
+				continue
+			}
+
+			if !extractPathSafe(destination, filepath.Dir(target)) {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s which would be written through a symbolic link.\n", entry)
+				continue
+			}
+
+			if err = os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return err
+			}
+
+			os.Remove(target)
+
+			if err = os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+/*
+Check whether a path is the directory given or is within it.
+*/
+func withinDirectory(directory string, target string) bool {
+	relPath, err := filepath.Rel(directory, target)
+
+	if err != nil {
+		return false
+	}
+
+	return relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator)) && !filepath.IsAbs(relPath)
+}
+
+/*
+Check whether a relative symbolic link created in the directory given by
+linkDirectory resolves within the directory given. The link is followed one
+element at a time, with any element which is itself an existing symbolic
+link being rejected, as following it could lead anywhere.
+*/
+func linkWithinDirectory(directory string, linkDirectory string, linkname string) bool {
+	current := linkDirectory
+
+	for _, part := range strings.Split(filepath.ToSlash(linkname), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+		default:
+			current = filepath.Join(current, part)
+		}
+
+		if !withinDirectory(directory, current) {
+			return false
+		}
+
+		if info, err := os.Lstat(current); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Check that no part of a path below the directory given, including the path
+itself, is an existing symbolic link, so that writing to the path can't
+follow a link out of the directory.
+*/
+func extractPathSafe(directory string, target string) bool {
+	relPath, err := filepath.Rel(directory, target)
+
+	if err != nil {
+		return false
+	}
+
+	if relPath == "." {
+		return true
+	}
+
+	current := directory
+
+	for _, part := range strings.Split(relPath, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+
+		if err != nil {
+			return true
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *ProjectInfo) NewClusterSessionCopyCmd() *cobra.Command {
+	var o ClusterSessionCopyOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(2),
+		Use:   "cp SOURCE DESTINATION",
+		Short: "Copy files to or from workshop session",
+		Long: `Copy files to or from a running workshop session.
+
+Copies a file or directory between the local machine and the workshop
+container of a session, in the same way as "kubectl cp", but with the
+namespace and pod for the session being worked out from the name of the
+session. The path in the session is given as SESSION:/path, with relative
+paths being relative to the home directory of the workshop user. For
+example:
+
+  educates cluster session cp exercises labs-w01-s001:exercises
+  educates cluster session cp labs-w01-s001:exercises/solution.yaml .
+
+Where the destination is an existing directory, what is copied is placed
+within it, otherwise it is written to the destination path, replacing any
+file of the same name. Files are copied using tar, which must be available
+in the workshop container.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args[0], args[1]) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Container,
+		"container",
+		"c",
+		"workshop",
+		"name of the container in the session pod to copy files to or from",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	return withAuditLogging(c)
+}
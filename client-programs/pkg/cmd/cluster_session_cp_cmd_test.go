@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
+)
+
+type testArchiveEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	content  string
+}
+
+func newTestArchive(t *testing.T, entries []testArchiveEntry) *bytes.Buffer {
+	t.Helper()
+
+	var buffer bytes.Buffer
+
+	tarWriter := tar.NewWriter(&buffer)
+
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+		}
+
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tarWriter.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &buffer
+}
+
+func TestExtractSessionArchive(t *testing.T) {
+	root := t.TempDir()
+
+	destination := filepath.Join(root, "copy")
+
+	archive := newTestArchive(t, []testArchiveEntry{
+		{name: "exercises/", typeflag: tar.TypeDir},
+		{name: "exercises/README.md", typeflag: tar.TypeReg, content: "# Exercises\n"},
+		{name: "exercises/solution/", typeflag: tar.TypeDir},
+		{name: "exercises/solution/app.yaml", typeflag: tar.TypeReg, content: "kind: Deployment\n"},
+		{name: "other/file.txt", typeflag: tar.TypeReg, content: "other\n"},
+	})
+
+	if err := extractSessionArchive(archive, "exercises", destination); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for file, want := range map[string]string{
+		"README.md":         "# Exercises\n",
+		"solution/app.yaml": "kind: Deployment\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(destination, filepath.FromSlash(file)))
+
+		if err != nil {
+			t.Errorf("expected %s to be extracted: %v", file, err)
+			continue
+		}
+
+		if string(data) != want {
+			t.Errorf("expected %s to contain %q but got %q", file, want, data)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "other")); !os.IsNotExist(err) {
+		t.Errorf("expected entries outside of the copy to be skipped")
+	}
+}
+
+func TestExtractSessionArchiveHostileLinks(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("creating symbolic links requires extra privileges on Windows")
+	}
+
+	tests := []struct {
+		name    string
+		entries []testArchiveEntry
+	}{
+		{
+			name: "link out of the destination then file written through it",
+			entries: []testArchiveEntry{
+				{name: "exercises/", typeflag: tar.TypeDir},
+				{name: "exercises/f", typeflag: tar.TypeSymlink, linkname: "../.bashrc"},
+				{name: "exercises/f", typeflag: tar.TypeReg, content: "hostile\n"},
+			},
+		},
+		{
+			name: "link out of the destination then directory written through it",
+			entries: []testArchiveEntry{
+				{name: "exercises/d", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "exercises/d/.bashrc", typeflag: tar.TypeReg, content: "hostile\n"},
+			},
+		},
+		{
+			name: "absolute link",
+			entries: []testArchiveEntry{
+				{name: "exercises/d", typeflag: tar.TypeSymlink, linkname: "/"},
+				{name: "exercises/d/.bashrc", typeflag: tar.TypeReg, content: "hostile\n"},
+			},
+		},
+		{
+			name: "link within the destination used as a directory",
+			entries: []testArchiveEntry{
+				{name: "exercises/sub/", typeflag: tar.TypeDir},
+				{name: "exercises/d", typeflag: tar.TypeSymlink, linkname: "sub"},
+				{name: "exercises/d/.bashrc", typeflag: tar.TypeReg, content: "hostile\n"},
+			},
+		},
+		{
+			name: "chained links resolving outside of the destination",
+			entries: []testArchiveEntry{
+				{name: "exercises/p/q/", typeflag: tar.TypeDir},
+				{name: "exercises/p/q/b", typeflag: tar.TypeSymlink, linkname: "../.."},
+				{name: "exercises/p/q/a", typeflag: tar.TypeSymlink, linkname: "b/../.bashrc"},
+				{name: "exercises/p/q/b/../.bashrc", typeflag: tar.TypeReg, content: "hostile\n"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+
+			outside := filepath.Join(root, ".bashrc")
+
+			if err := os.WriteFile(outside, []byte("original\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			destination := filepath.Join(root, "copy")
+
+			if err := extractSessionArchive(newTestArchive(t, tt.entries), "exercises", destination); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := os.ReadFile(outside)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != "original\n" {
+				t.Errorf("expected file outside of the copy to be unchanged but got %q", data)
+			}
+
+			items, err := os.ReadDir(root)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, item := range items {
+				if item.Name() != ".bashrc" && item.Name() != "copy" {
+					t.Errorf("unexpected file %s written outside of the copy", item.Name())
+				}
+			}
+
+			// Any symbolic links which were created must really resolve to
+			// somewhere within the copy.
+
+			resolvedDestination, err := filepath.EvalSymlinks(destination)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			filepath.Walk(destination, func(path string, info os.FileInfo, err error) error {
+				if err == nil && info.Mode()&os.ModeSymlink != 0 {
+					resolved, err := filepath.EvalSymlinks(path)
+
+					if err == nil && !withinDirectory(resolvedDestination, resolved) {
+						t.Errorf("symbolic link %s resolves outside of the copy to %s", path, resolved)
+					}
+				}
+
+				return nil
+			})
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Directories in the workshop container which are held on the persistent volume
+for a session, when it has one. Each is held on the volume under the same
+path, relative to the root of the volume.
+*/
+var sessionVolumeDirectories = []string{
+	"/home/eduk8s",
+	"/opt/assets",
+	"/opt/packages",
+	"/opt/git/repositories",
+}
+
+/*
+Location the persistent volume for a session is mounted in the temporary pod
+used to export files after the session has ended.
+*/
+const volumeFilesDirectory = "/opt/files"
+
+type ClusterSessionExportFilesOptions struct {
+	Kubeconfig  string
+	Name        string
+	Environment string
+	Path        string
+	Output      string
+	HelperImage string
+	Timeout     time.Duration
+}
+
+func (o *ClusterSessionExportFilesOptions) Run(ctx context.Context) error {
+	directory := path.Clean(o.Path)
+
+	if !path.IsAbs(directory) {
+		return failures.NewValidationError(errors.Errorf("path %q is not an absolute path", o.Path), "")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	// Files can still be exported after a session has ended if they were
+	// kept on a persistent volume, so the session not existing isn't an
+	// error. The workshop environment is then worked out from the name of
+	// the session if not supplied.
+
+	environmentName := o.Environment
+
+	session, err := dynamicClient.Resource(workshopSessionResource).Get(ctx, o.Name, metav1.GetOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to query workshop session %q", o.Name)
+	}
+
+	if err == nil {
+		environmentName = session.GetLabels()["training.educates.dev/environment.name"]
+	} else {
+		session = nil
+
+		if environmentName == "" {
+			if index := strings.LastIndex(o.Name, "-"); index > 0 {
+				environmentName = o.Name[:index]
+			}
+		}
+	}
+
+	environment, err := dynamicClient.Resource(workshopEnvironmentResource).Get(ctx, environmentName, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) || environmentName == "" {
+		return failures.NewNotFoundError(errors.Errorf("no workshop environment found for session %q", o.Name), "list sessions with `educates cluster session list`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query workshop environment %q", environmentName)
+	}
+
+	resources, _, _ := unstructured.NestedMap(environment.Object, "status", "educates", "workshop", "spec", "session", "resources")
+
+	// Where the session is still running, the files are copied from the
+	// workshop container. Otherwise a temporary pod mounting the directory
+	// on the persistent volume for the session is used.
+
+	var pod *apiv1.Pod
+
+	if session != nil {
+		if pod, err = workshopSessionPod(ctx, client, environmentName, o.Name); err != nil {
+			return err
+		}
+	}
+
+	container := "workshop"
+
+	if pod == nil {
+		onVolume := false
+
+		for _, item := range sessionVolumeDirectories {
+			if directory == item || strings.HasPrefix(directory, item+"/") {
+				onVolume = true
+			}
+		}
+
+		// Where the platform created the persistent volume for the session,
+		// it is named after the session namespace, which is the same as the
+		// name of the session, and is deleted when the session is.
+
+		volumeName, _, _ := unstructured.NestedString(resources, "volume", "name")
+		volumeSubPath, _, _ := unstructured.NestedString(resources, "volume", "subPath")
+
+		if volumeName == "" {
+			if storage, _, _ := unstructured.NestedString(resources, "storage"); storage != "" && session != nil {
+				volumeName = o.Name
+			}
+		}
+
+		if volumeName == "" || !onVolume {
+			return failures.NewNotFoundError(errors.Errorf("no running pod found for session %q", o.Name), "files are only kept after a session ends if the workshop was deployed with --retain-session-files")
+		}
+
+		for _, variable := range []string{"$(session_name)", "$(session_namespace)"} {
+			volumeName = strings.ReplaceAll(volumeName, variable, o.Name)
+			volumeSubPath = strings.ReplaceAll(volumeSubPath, variable, o.Name)
+		}
+
+		volumeSubPath = path.Join(volumeSubPath, strings.TrimPrefix(directory, "/"))
+
+		if strings.Contains(volumeName+volumeSubPath, "$(") {
+			return failures.NewValidationError(errors.Errorf("persistent volume for session %q uses session variables", o.Name), "files can only be exported from a running session")
+		}
+
+		if pod, err = createSessionVolumePod(ctx, client, environmentName, o.Name, "files", volumeName, volumeSubPath, volumeFilesDirectory, o.HelperImage, o.Timeout); err != nil {
+			return err
+		}
+
+		defer client.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, *metav1.NewDeleteOptions(0))
+
+		directory = volumeFilesDirectory
+		container = "files"
+	}
+
+	var out io.Writer = os.Stdout
+
+	output := o.Output
+
+	if output == "" {
+		output = fmt.Sprintf("%s-files.tar.gz", o.Name)
+	}
+
+	if output != "-" {
+		file, err := os.Create(output)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create archive file %q", output)
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	command := []string{"sh", "-c", fmt.Sprintf("[ -d %[1]s ] || { echo 'directory %[1]s does not exist' 1>&2; exit 1; }; tar -C %[1]s -czf - .", directory)}
+
+	if err = clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, container, command, nil, out); err != nil {
+		if output != "-" {
+			os.Remove(output)
+		}
+
+		return errors.Wrapf(err, "unable to export files for session %q", o.Name)
+	}
+
+	if output != "-" {
+		fmt.Printf("Exported files from %s in session %s to %s.\n", path.Clean(o.Path), o.Name, output)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterSessionExportFilesCmd() *cobra.Command {
+	var o ClusterSessionExportFilesOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "export-files [NAME]",
+		Short: "Export files from workshop session",
+		Long: `Export files from a workshop session.
+
+Archives a directory in the workshop container of a session, by default the
+home directory of the workshop user, as a gzip compressed tar file, so that
+the files an attendee worked on can be given to them for download.
+
+While the session is running, the files are copied from the workshop
+container. If the workshop was deployed with --session-storage and
+--retain-session-files, the home directory of each session is kept on a
+persistent volume shared by the sessions of the workshop environment, and
+files can also be exported after the session has ended, so long as the
+workshop environment still exists. In that case a temporary pod is run in
+the workshop environment to read the files.
+
+The archive is written to a file named after the session in the current
+directory unless --output is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				o.Name = args[0]
+			}
+
+			if o.Name == "" {
+				return failures.NewValidationError(errors.New("no workshop session name given"), "give the name of the session as an argument or using --name")
+			}
+
+			return o.Run(cmd.Context())
+		},
+		ValidArgsFunction: completeWorkshopSessionNames,
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the workshop session to export files from",
+	)
+	c.Flags().StringVar(
+		&o.Environment,
+		"environment",
+		"",
+		"name of the workshop environment if the session has ended",
+	)
+	c.Flags().StringVar(
+		&o.Path,
+		"path",
+		"/home/eduk8s",
+		"directory in the session to export",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"",
+		"file to write the archive to, or - for stdout",
+	)
+	c.Flags().StringVar(
+		&o.HelperImage,
+		"helper-image",
+		"docker.io/library/busybox:latest",
+		"image for the pod used to read files after the session has ended",
+	)
+	c.Flags().DurationVar(
+		&o.Timeout,
+		"timeout",
+		2*time.Minute,
+		"how long to wait for the pod used to read files to start",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopSessionNames)
+
+	return c
+}
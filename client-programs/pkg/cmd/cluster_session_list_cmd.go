@@ -9,10 +9,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type ClusterSessionListOptions struct {
@@ -21,9 +21,9 @@ type ClusterSessionListOptions struct {
 	Environment string
 }
 
-var workshopSessionResource = schema.GroupVersionResource{Group: "training.educates.dev", Version: "v1beta1", Resource: "workshopsessions"}
+var workshopSessionResource = training.WorkshopSessionResource
 
-func (o *ClusterSessionListOptions) Run() error {
+func (o *ClusterSessionListOptions) Run(ctx context.Context) error {
 	var err error
 
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
@@ -36,7 +36,7 @@ func (o *ClusterSessionListOptions) Run() error {
 
 	workshopSessionClient := dynamicClient.Resource(workshopSessionResource)
 
-	workshopSessions, err := workshopSessionClient.List(context.TODO(), metav1.ListOptions{})
+	workshopSessions, err := workshopSessionClient.List(ctx, metav1.ListOptions{})
 
 	if k8serrors.IsNotFound(err) {
 		fmt.Println("No sessions found.")
@@ -98,7 +98,7 @@ func (p *ProjectInfo) NewClusterSessionListCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "list",
 		Short: "Output list of sessions",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -122,5 +122,7 @@ func (p *ProjectInfo) NewClusterSessionListCmd() *cobra.Command {
 		"name of the workshop environment to filter",
 	)
 
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
 	return c
 }
@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Location of terminal recordings in the workshop container when they aren't
+being written to a persistent volume, and the location the persistent volume
+is mounted when they are.
+*/
+const (
+	sessionRecordingsDirectory = "/home/eduk8s/.local/share/workshop/recordings"
+	volumeRecordingsDirectory  = "/opt/recordings"
+)
+
+type ClusterSessionRecordingsDownloadOptions struct {
+	Kubeconfig  string
+	Name        string
+	Environment string
+	Output      string
+	HelperImage string
+	Timeout     time.Duration
+}
+
+func (o *ClusterSessionRecordingsDownloadOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	// Recordings can still be downloaded after a session has ended if they
+	// were written to a persistent volume, so the session not existing isn't
+	// an error. The workshop environment is then worked out from the name of
+	// the session if not supplied, as session names are the name of the
+	// workshop environment with the session ID appended.
+
+	environmentName := o.Environment
+
+	session, err := dynamicClient.Resource(workshopSessionResource).Get(ctx, o.Name, metav1.GetOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to query workshop session %q", o.Name)
+	}
+
+	if err == nil {
+		environmentName = session.GetLabels()["training.educates.dev/environment.name"]
+	} else {
+		session = nil
+
+		if environmentName == "" {
+			if index := strings.LastIndex(o.Name, "-"); index > 0 {
+				environmentName = o.Name[:index]
+			}
+		}
+	}
+
+	environment, err := dynamicClient.Resource(workshopEnvironmentResource).Get(ctx, environmentName, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) || environmentName == "" {
+		return failures.NewNotFoundError(errors.Errorf("no workshop environment found for session %q", o.Name), "list sessions with `educates cluster session list`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query workshop environment %q", environmentName)
+	}
+
+	recording, _, _ := unstructured.NestedMap(environment.Object, "status", "educates", "workshop", "spec", "session", "applications", "terminal", "recording")
+
+	if enabled, _ := recording["enabled"].(bool); !enabled {
+		return failures.NewValidationError(errors.Errorf("terminal recording is not enabled for workshop environment %q", environmentName), "enable it with `session.applications.terminal.recording.enabled` in the workshop definition")
+	}
+
+	volumeName, _, _ := unstructured.NestedString(recording, "volume", "name")
+
+	archive, err := os.CreateTemp("", "educates-recordings-*.tar")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary file for recordings")
+	}
+
+	defer os.Remove(archive.Name())
+
+	defer archive.Close()
+
+	// Where the session is still running, the recordings are copied from
+	// the workshop container. Otherwise a temporary pod mounting the
+	// persistent volume for the recordings is used.
+
+	var pod *apiv1.Pod
+
+	if session != nil {
+		if pod, err = workshopSessionPod(ctx, client, environmentName, o.Name); err != nil {
+			return err
+		}
+	}
+
+	directory := sessionRecordingsDirectory
+	container := "workshop"
+
+	if volumeName != "" {
+		directory = volumeRecordingsDirectory
+	}
+
+	if pod == nil {
+		if volumeName == "" {
+			return failures.NewNotFoundError(errors.Errorf("no running pod found for session %q", o.Name), "recordings are only kept after a session ends if written to a persistent volume")
+		}
+
+		volumeSubPath, _, _ := unstructured.NestedString(recording, "volume", "subPath")
+
+		if volumeSubPath != "" {
+			volumeSubPath = path.Join(volumeSubPath, o.Name)
+		} else {
+			volumeSubPath = o.Name
+		}
+
+		if strings.Contains(volumeName+volumeSubPath, "$(") {
+			return failures.NewValidationError(errors.Errorf("persistent volume for recordings of session %q uses session variables", o.Name), "recordings can only be downloaded from a running session")
+		}
+
+		if pod, err = createSessionVolumePod(ctx, client, environmentName, o.Name, "recordings", volumeName, volumeSubPath, volumeRecordingsDirectory, o.HelperImage, o.Timeout); err != nil {
+			return err
+		}
+
+		defer client.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, *metav1.NewDeleteOptions(0))
+
+		container = "recordings"
+	}
+
+	command := []string{"sh", "-c", fmt.Sprintf("[ -d %[1]s ] || exit 0; tar -C %[1]s -cf - .", directory)}
+
+	if err = clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, container, command, nil, archive); err != nil {
+		return errors.Wrapf(err, "unable to copy recordings for session %q", o.Name)
+	}
+
+	if _, err = archive.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "unable to read recordings for session")
+	}
+
+	outputDirectory := filepath.Join(o.Output, o.Name)
+
+	count, err := extractRecordings(archive, outputDirectory)
+
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		fmt.Printf("No terminal recordings found for session %s.\n", o.Name)
+
+		return nil
+	}
+
+	fmt.Printf("Downloaded %d terminal recordings for session %s to %s.\n", count, o.Name, outputDirectory)
+
+	return nil
+}
+
+/*
+Find the running pod for a workshop session. The pod for the session is
+created in the workshop environment namespace and named after the session.
+Returns nil if there is no running pod for the session.
+*/
+func workshopSessionPod(ctx context.Context, client *kubernetes.Clientset, namespace string, name string) (*apiv1.Pod, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list pods in namespace %s", namespace)
+	}
+
+	for i := range pods.Items {
+		if strings.HasPrefix(pods.Items[i].Name, name+"-") && pods.Items[i].Status.Phase == apiv1.PodRunning {
+			return &pods.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+/*
+Create a temporary pod mounting a directory of a persistent volume used by a
+session, such as that holding its terminal recordings, and wait for it to be
+running. The purpose is used in naming the pod and its container. As the
+persistent volume is written to by the workshop user, the pod runs as the
+same user.
+*/
+func createSessionVolumePod(ctx context.Context, client *kubernetes.Clientset, namespace string, session string, purpose string, claim string, subPath string, mountPath string, image string, timeout time.Duration) (*apiv1.Pod, error) {
+	user := int64(1001)
+	nonRoot := true
+	escalation := false
+
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%s", session, purpose, utilrand.String(5)),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"training.educates.dev/component":    purpose,
+				"training.educates.dev/session.name": session,
+			},
+		},
+		Spec: apiv1.PodSpec{
+			RestartPolicy: apiv1.RestartPolicyNever,
+			SecurityContext: &apiv1.PodSecurityContext{
+				RunAsUser:    &user,
+				RunAsNonRoot: &nonRoot,
+				SeccompProfile: &apiv1.SeccompProfile{
+					Type: apiv1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []apiv1.Container{
+				{
+					Name:    purpose,
+					Image:   image,
+					Command: []string{"sleep", "3600"},
+					SecurityContext: &apiv1.SecurityContext{
+						AllowPrivilegeEscalation: &escalation,
+						Capabilities: &apiv1.Capabilities{
+							Drop: []apiv1.Capability{"ALL"},
+						},
+					},
+					VolumeMounts: []apiv1.VolumeMount{
+						{
+							Name:      purpose,
+							MountPath: mountPath,
+							SubPath:   subPath,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []apiv1.Volume{
+				{
+					Name: purpose,
+					VolumeSource: apiv1.VolumeSource{
+						PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{
+							ClaimName: claim,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	pod, err := client.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create pod for reading %s of session %q", purpose, session)
+	}
+
+	err = cluster.PollImmediate(ctx, 2*time.Second, timeout, func() (bool, error) {
+		current, err := client.CoreV1().Pods(namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+
+		if err != nil {
+			return false, err
+		}
+
+		if current.Status.Phase == apiv1.PodFailed {
+			return false, errors.Errorf("pod for reading %s of session %q failed", purpose, session)
+		}
+
+		return current.Status.Phase == apiv1.PodRunning, nil
+	})
+
+	if err != nil {
+		client.CoreV1().Pods(namespace).Delete(context.Background(), pod.Name, *metav1.NewDeleteOptions(0))
+
+		if err == wait.ErrWaitTimeout {
+			return nil, failures.NewTimeoutError(errors.Errorf("timed out waiting for pod for reading %s of session %q", purpose, session), fmt.Sprintf("the persistent volume for %s may need to support ReadWriteMany access", purpose))
+		}
+
+		return nil, err
+	}
+
+	return pod, nil
+}
+
+/*
+Extract the terminal recordings from a tar archive into the directory given,
+returning the number of recordings extracted. Only regular files are
+extracted, and they are always written directly into the directory.
+*/
+func extractRecordings(archive io.Reader, directory string) (int, error) {
+	tarReader := tar.NewReader(archive)
+
+	count := 0
+
+	for {
+		header, err := tarReader.Next()
+
+		if err == io.EOF {
+			return count, nil
+		}
+
+		if err != nil {
+			return count, errors.Wrap(err, "unable to read recordings for session")
+		}
+
+		name := path.Base(path.Clean(header.Name))
+
+		if header.Typeflag != tar.TypeReg || name == "." || name == ".." || name == "/" {
+			continue
+		}
+
+		if count == 0 {
+			if err = os.MkdirAll(directory, os.ModePerm); err != nil {
+				return count, errors.Wrapf(err, "unable to create directory %q", directory)
+			}
+		}
+
+		target, err := os.Create(filepath.Join(directory, name))
+
+		if err != nil {
+			return count, errors.Wrapf(err, "unable to save recording %q", name)
+		}
+
+		_, err = io.Copy(target, tarReader)
+
+		target.Close()
+
+		if err != nil {
+			return count, errors.Wrapf(err, "unable to save recording %q", name)
+		}
+
+		count++
+	}
+}
+
+func (p *ProjectInfo) NewClusterSessionRecordingsDownloadCmd() *cobra.Command {
+	var o ClusterSessionRecordingsDownloadOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "download",
+		Short: "Download terminal recordings for session",
+		Long: `Download terminal recordings for a workshop session.
+
+Terminal recordings are made when enabled for a workshop by setting
+session.applications.terminal.recording.enabled in the workshop definition.
+A separate recording is made each time the shell for a terminal is started,
+in the asciicast format used by asciinema, so recordings can be replayed
+with "asciinema play" or converted into demos with any tool which supports
+the format.
+
+By default, recordings are kept in the home directory of the workshop user
+and can only be downloaded while the session is running. If a persistent
+volume claim for recordings is given using
+session.applications.terminal.recording.volume, they are written to a
+directory for the session on that volume instead, and can also be downloaded
+after the session has ended, so long as the workshop environment still
+exists. In that case a temporary pod is run in the workshop environment to
+read the recordings, which requires the persistent volume to support
+ReadWriteMany access if it is still mounted by other sessions.
+
+Recordings are saved in a directory named after the session under the output
+directory.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the workshop session to download recordings for",
+	)
+	c.Flags().StringVar(
+		&o.Environment,
+		"environment",
+		"",
+		"name of the workshop environment if the session has ended",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		".",
+		"directory to save the recordings under",
+	)
+	c.Flags().StringVar(
+		&o.HelperImage,
+		"helper-image",
+		"docker.io/library/busybox:latest",
+		"image for the pod used to read recordings after the session has ended",
+	)
+	c.Flags().DurationVar(
+		&o.Timeout,
+		"timeout",
+		2*time.Minute,
+		"how long to wait for the pod used to read recordings to start",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	c.MarkFlagRequired("name")
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopSessionNames)
+
+	return c
+}
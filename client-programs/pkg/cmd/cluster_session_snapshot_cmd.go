@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterSessionSnapshotOptions struct {
+	Kubeconfig string
+	Name       string
+	Image      string
+	BaseImage  string
+	Include    []string
+	Exclude    []string
+	Insecure   bool
+}
+
+func (o *ClusterSessionSnapshotOptions) Run(ctx context.Context) error {
+	imageRef, err := name.ParseReference(o.Image, o.nameOptions()...)
+
+	if err != nil {
+		return failures.NewValidationError(errors.Wrapf(err, "invalid image reference %q", o.Image), "")
+	}
+
+	for _, path := range o.Include {
+		if !strings.HasPrefix(path, "/") {
+			return failures.NewValidationError(errors.Errorf("included path %q is not an absolute path", path), "")
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	pod, err := runningSessionPod(ctx, clusterConfig, o.Name)
+
+	if err != nil {
+		return err
+	}
+
+	// Build on top of the image the session is running, unless another is
+	// given, using the same architecture as the node the session runs on.
+
+	baseImage := o.BaseImage
+
+	if baseImage == "" {
+		for _, container := range pod.Spec.Containers {
+			if container.Name == "workshop" {
+				baseImage = container.Image
+			}
+		}
+	}
+
+	if baseImage == "" {
+		return failures.NewNotFoundError(errors.Errorf("no workshop container found in pod %s", pod.Name), "")
+	}
+
+	baseRef, err := name.ParseReference(baseImage, o.nameOptions()...)
+
+	if err != nil {
+		return errors.Wrapf(err, "invalid base image reference %q", baseImage)
+	}
+
+	platform := v1.Platform{OS: "linux", Architecture: "amd64"}
+
+	if node, err := client.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{}); err == nil {
+		platform.Architecture = node.Status.NodeInfo.Architecture
+	}
+
+	// Capture the home directory of the workshop user, and any other paths
+	// which were asked for, such as where packages have been installed.
+
+	layerFile, err := os.CreateTemp("", "educates-snapshot-*.tar")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary file for snapshot")
+	}
+
+	defer os.Remove(layerFile.Name())
+
+	command := []string{"tar", "-C", "/", "-cf", "-"}
+
+	for _, path := range o.Exclude {
+		command = append(command, "--exclude", strings.TrimPrefix(path, "/"))
+	}
+
+	command = append(command, "home/eduk8s")
+
+	for _, path := range o.Include {
+		command = append(command, strings.TrimPrefix(path, "/"))
+	}
+
+	fmt.Printf("Capturing files from session %s ...\n", o.Name)
+
+	err = clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, "workshop", command, nil, layerFile)
+
+	layerFile.Close()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to capture files from session %q", o.Name)
+	}
+
+	layer, err := tarball.LayerFromFile(layerFile.Name())
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create image layer from snapshot")
+	}
+
+	remoteOptions := []remote.Option{
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithTransport(http.DefaultTransport),
+		remote.WithPlatform(platform),
+	}
+
+	fmt.Printf("Building image from %s ...\n", baseRef.Name())
+
+	base, err := remote.Image(baseRef, remoteOptions...)
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrapf(err, "unable to fetch base image %q", baseRef.Name()), "use `--base-image` if the image the session runs is not accessible from this machine")
+	}
+
+	image, err := mutate.AppendLayers(base, layer)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to add snapshot to image")
+	}
+
+	fmt.Printf("Pushing image %s ...\n", imageRef.Name())
+
+	if err = remote.Write(imageRef, image, remoteOptions...); err != nil {
+		return failures.NewConnectionError(errors.Wrapf(err, "unable to push image %q", imageRef.Name()), "check you are logged in to the registry with `docker login`")
+	}
+
+	digest, err := image.Digest()
+
+	if err == nil {
+		fmt.Printf("Pushed image %s@%s.\n", imageRef.Context().Name(), digest)
+	}
+
+	return nil
+}
+
+func (o *ClusterSessionSnapshotOptions) nameOptions() []name.Option {
+	if o.Insecure {
+		return []name.Option{name.Insecure}
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterSessionSnapshotCmd() *cobra.Command {
+	var o ClusterSessionSnapshotOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "snapshot",
+		Short: "Snapshot workshop session into new image",
+		Long: `Snapshot workshop session into new image.
+
+Captures the home directory of the workshop user in a running workshop
+session, along with any other directories given, such as where additional
+packages have been installed, and adds them as a new layer on top of the
+image the session is running. The resulting image is pushed to the image
+registry, and can be used as the workshop base image for the workshop, so
+that changes made interactively in a session can be frozen for use in later
+sessions.
+
+Credentials for the image registry are taken from the Docker config file, as
+set up by "docker login".`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the workshop session to snapshot",
+	)
+	c.Flags().StringVar(
+		&o.Image,
+		"image",
+		"",
+		"image reference to push the snapshot to",
+	)
+	c.Flags().StringVar(
+		&o.BaseImage,
+		"base-image",
+		"",
+		"image to add the snapshot to, instead of the image the session is running",
+	)
+	c.Flags().StringSliceVar(
+		&o.Include,
+		"include",
+		[]string{},
+		"additional directory in the session to include in the snapshot",
+	)
+	c.Flags().StringSliceVar(
+		&o.Exclude,
+		"exclude",
+		[]string{"/home/eduk8s/.cache"},
+		"directory in the session to exclude from the snapshot",
+	)
+	c.Flags().BoolVar(
+		&o.Insecure,
+		"insecure",
+		false,
+		"allow access to image registries over plain HTTP",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	c.MarkFlagRequired("name")
+	c.MarkFlagRequired("image")
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopSessionNames)
+
+	return withAuditLogging(c)
+}
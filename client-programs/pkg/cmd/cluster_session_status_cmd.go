@@ -24,7 +24,7 @@ type ClusterSessionStatusOptions struct {
 	Name       string
 }
 
-func (o *ClusterSessionStatusOptions) Run() error {
+func (o *ClusterSessionStatusOptions) Run(ctx context.Context) error {
 	var err error
 
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
@@ -37,7 +37,7 @@ func (o *ClusterSessionStatusOptions) Run() error {
 
 	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
 
-	trainingPortal, err := trainingPortalClient.Get(context.TODO(), o.Portal, metav1.GetOptions{})
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
 		fmt.Println("No session found.")
@@ -188,10 +188,11 @@ func (p *ProjectInfo) NewClusterSessionStatusCmd() *cobra.Command {
 	var o ClusterSessionStatusOptions
 
 	var c = &cobra.Command{
-		Args:  cobra.ExactArgs(1),
-		Use:   "status",
-		Short: "Output status of session",
-		RunE:  func(_ *cobra.Command, args []string) error { o.Name = args[0]; return o.Run() },
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeWorkshopSessionNames,
+		Use:               "status",
+		Short:             "Output status of session",
+		RunE:              func(cmd *cobra.Command, args []string) error { o.Name = args[0]; return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -208,5 +209,7 @@ func (p *ProjectInfo) NewClusterSessionStatusCmd() *cobra.Command {
 		"name of the training portal",
 	)
 
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
 	return c
 }
@@ -24,7 +24,7 @@ type ClusterSessionTerminateOptions struct {
 	Name       string
 }
 
-func (o *ClusterSessionTerminateOptions) Run() error {
+func (o *ClusterSessionTerminateOptions) Run(ctx context.Context) error {
 	var err error
 
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
@@ -37,7 +37,7 @@ func (o *ClusterSessionTerminateOptions) Run() error {
 
 	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
 
-	trainingPortal, err := trainingPortalClient.Get(context.TODO(), o.Portal, metav1.GetOptions{})
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
 		fmt.Println("No session found.")
@@ -188,10 +188,11 @@ func (p *ProjectInfo) NewClusterSessionTerminateCmd() *cobra.Command {
 	var o ClusterSessionTerminateOptions
 
 	var c = &cobra.Command{
-		Args:  cobra.ExactArgs(1),
-		Use:   "terminate",
-		Short: "Terminate running session",
-		RunE:  func(_ *cobra.Command, args []string) error { o.Name = args[0]; return o.Run() },
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeWorkshopSessionNames,
+		Use:               "terminate",
+		Short:             "Terminate running session",
+		RunE:              func(cmd *cobra.Command, args []string) error { o.Name = args[0]; return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -208,5 +209,7 @@ func (p *ProjectInfo) NewClusterSessionTerminateCmd() *cobra.Command {
 		"name of the training portal",
 	)
 
-	return c
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
 }
@@ -0,0 +1,461 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+// Label added to workshops applied by a sync so that workshops which have
+// since been removed from the source can be identified and pruned.
+
+const syncPortalLabel = "training.educates.dev/sync.portal"
+
+type ClusterSyncOptions struct {
+	Kubeconfig string
+	Portal     string
+	GitURL     string
+	GitRef     string
+	Directory  string
+	Path       string
+	Prune      bool
+	Interval   time.Duration
+}
+
+func (o *ClusterSyncOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if (o.GitURL == "") == (o.Directory == "") {
+		return failures.NewValidationError(errors.New("exactly one of --from-git or --from-dir must be supplied"), "")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	// When an interval is given keep reconciling the cluster against the
+	// source until interrupted, reporting but otherwise ignoring failures so
+	// that a transient problem doesn't stop the sync.
+
+	if o.Interval == 0 {
+		return o.sync(ctx, dynamicClient)
+	}
+
+	for {
+		err = o.sync(ctx, dynamicClient)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		}
+
+		if cluster.Sleep(ctx, o.Interval) != nil {
+			return nil
+		}
+	}
+}
+
+func (o *ClusterSyncOptions) sync(ctx context.Context, client dynamic.Interface) error {
+	var err error
+
+	directory := o.Directory
+	source := o.Directory
+
+	if o.GitURL != "" {
+		cloneDir, err := os.MkdirTemp("", "educates-sync-")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to create temporary directory for checkout")
+		}
+
+		defer os.RemoveAll(cloneDir)
+
+		err = gitClone(o.GitURL, o.GitRef, cloneDir)
+
+		if err != nil {
+			return err
+		}
+
+		directory = cloneDir
+		source = o.GitURL
+	}
+
+	directory = filepath.Join(directory, o.Path)
+
+	workshops, trainingPortal, err := loadSyncResources(directory)
+
+	if err != nil {
+		return err
+	}
+
+	if trainingPortal != nil {
+		if trainingPortal.GetName() == "" {
+			trainingPortal.SetName(o.Portal)
+		}
+
+		o.Portal = trainingPortal.GetName()
+	}
+
+	// Apply the workshop definitions, labelling them so they can later be
+	// pruned if removed from the source.
+
+	desired := map[string]bool{}
+
+	for _, workshop := range workshops {
+		labels := workshop.GetLabels()
+
+		if labels == nil {
+			labels = map[string]string{}
+		}
+
+		labels[syncPortalLabel] = o.Portal
+
+		workshop.SetLabels(labels)
+
+		annotations := workshop.GetAnnotations()
+
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations["training.educates.dev/source"] = source
+
+		workshop.SetAnnotations(annotations)
+
+		err = training.UpdateWorkshopResource(ctx, client, workshop)
+
+		if err != nil {
+			return err
+		}
+
+		desired[workshop.GetName()] = true
+
+		fmt.Printf("Workshop %q synced.\n", workshop.GetName())
+	}
+
+	// Work out which previously synced workshops no longer exist in the
+	// source.
+
+	var pruned []string
+
+	if o.Prune {
+		workshopList, err := client.Resource(workshopResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", syncPortalLabel, o.Portal)})
+
+		if err != nil {
+			return errors.Wrap(err, "unable to list workshops in cluster")
+		}
+
+		for _, item := range workshopList.Items {
+			if !desired[item.GetName()] {
+				pruned = append(pruned, item.GetName())
+			}
+		}
+	}
+
+	// Update the training portal so that it hosts the workshops. Where the
+	// source supplies the training portal it is authoritative, otherwise the
+	// existing training portal is updated, or created if necessary.
+
+	trainingPortalClient := client.Resource(trainingPortalResource)
+
+	existingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+	var trainingPortalExists = true
+
+	if k8serrors.IsNotFound(err) {
+		trainingPortalExists = false
+	} else if err != nil {
+		return errors.Wrapf(err, "unable to query training portal %q in cluster", o.Portal)
+	}
+
+	if trainingPortal == nil {
+		if trainingPortalExists {
+			trainingPortal = existingPortal
+		} else {
+			portalDefaults, err := config.NewPortalDefaultsConfigFromFile("")
+
+			if err != nil {
+				return err
+			}
+
+			trainingPortal = training.NewTrainingPortal(o.Portal, portalDefaults)
+		}
+	} else if trainingPortalExists {
+		trainingPortal.SetResourceVersion(existingPortal.GetResourceVersion())
+	}
+
+	portalWorkshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	var updatedWorkshops []interface{}
+
+	listed := map[string]bool{}
+
+	for _, item := range portalWorkshops {
+		if object, ok := item.(map[string]interface{}); ok {
+			name, _ := object["name"].(string)
+
+			if containsString(pruned, name) {
+				continue
+			}
+
+			listed[name] = true
+
+			updatedWorkshops = append(updatedWorkshops, object)
+		}
+	}
+
+	unstructured.SetNestedSlice(trainingPortal.Object, updatedWorkshops, "spec", "workshops")
+
+	for _, workshop := range workshops {
+		if listed[workshop.GetName()] {
+			continue
+		}
+
+		err = training.AddWorkshopToTrainingPortal(trainingPortal, true, workshop, &training.WorkshopSettings{Capacity: 1, Orphaned: "5m", Overdue: "2m"})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if trainingPortalExists {
+		_, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+	} else {
+		_, err = trainingPortalClient.Create(ctx, trainingPortal, metav1.CreateOptions{FieldManager: "educates-cli"})
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal %q in cluster", o.Portal)
+	}
+
+	fmt.Printf("Training portal %q synced.\n", o.Portal)
+
+	// Delete the workshops which were pruned now that the training portal
+	// no longer references them.
+
+	for _, name := range pruned {
+		err = client.Resource(workshopResource).Delete(ctx, name, metav1.DeleteOptions{})
+
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "unable to delete workshop %q", name)
+		}
+
+		fmt.Printf("Workshop %q pruned.\n", name)
+	}
+
+	return nil
+}
+
+/*
+Make a shallow clone of a Git repository into the target directory.
+*/
+func gitClone(url string, ref string, directory string) error {
+	commandPath, err := exec.LookPath("git")
+
+	if err != nil {
+		return failures.NewValidationError(errors.Wrap(err, "unable to find git program"), "install git to sync from a Git repository")
+	}
+
+	commandArgs := []string{"clone", "--quiet", "--depth", "1"}
+
+	if ref != "" {
+		commandArgs = append(commandArgs, "--branch", ref)
+	}
+
+	commandArgs = append(commandArgs, url, directory)
+
+	output, err := exec.Command(commandPath, commandArgs...).CombinedOutput()
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Errorf("unable to clone Git repository %q: %s", url, strings.TrimSpace(string(output))), "")
+	}
+
+	return nil
+}
+
+/*
+Read the workshop and training portal resources from the YAML files in a
+directory. Files may hold multiple resources. Resources of other types are
+ignored, but only a single training portal is allowed.
+*/
+func loadSyncResources(directory string) ([]*unstructured.Unstructured, *unstructured.Unstructured, error) {
+	var workshops []*unstructured.Unstructured
+	var trainingPortal *unstructured.Unstructured
+
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		file, err := os.Open(path)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		decoder := yaml.NewYAMLOrJSONDecoder(file, 4096)
+
+		for {
+			object := &unstructured.Unstructured{}
+
+			err = decoder.Decode(&object.Object)
+
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to parse %q", path)
+			}
+
+			if object.Object == nil || object.GetAPIVersion() != "training.educates.dev/v1beta1" {
+				continue
+			}
+
+			switch object.GetKind() {
+			case "Workshop":
+				workshops = append(workshops, object)
+			case "TrainingPortal":
+				if trainingPortal != nil {
+					return failures.NewValidationError(errors.Errorf("more than one training portal found in %q", directory), "")
+				}
+
+				trainingPortal = object
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "unable to read resources from %q", directory)
+	}
+
+	return workshops, trainingPortal, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *ProjectInfo) NewClusterSyncCmd() *cobra.Command {
+	var o ClusterSyncOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "sync",
+		Short: "Reconcile workshops in Kubernetes with a Git repository",
+		Long: `Reconcile workshops in Kubernetes with a Git repository.
+
+Reads workshop definitions, and optionally a training portal definition, from
+YAML files in a directory of a Git repository and reconciles the cluster to
+match. Workshops are created or updated and added to the training portal, and
+workshops previously synced which have since been removed from the repository
+are pruned. Where a training portal definition is supplied it replaces the
+existing training portal, otherwise the existing training portal is updated.
+
+Use --interval to keep running, syncing the cluster periodically.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal if not defined by the source",
+	)
+	c.Flags().StringVar(
+		&o.GitURL,
+		"from-git",
+		"",
+		"URL of Git repository holding the resources to sync",
+	)
+	c.Flags().StringVar(
+		&o.GitRef,
+		"ref",
+		"",
+		"branch or tag of the Git repository to sync from",
+	)
+	c.Flags().StringVar(
+		&o.Directory,
+		"from-dir",
+		"",
+		"local directory holding the resources to sync instead of a Git repository",
+	)
+	c.Flags().StringVar(
+		&o.Path,
+		"path",
+		"",
+		"path of the directory within the source holding the resources",
+	)
+	c.Flags().BoolVar(
+		&o.Prune,
+		"prune",
+		true,
+		"delete previously synced workshops no longer in the source",
+	)
+	c.Flags().DurationVar(
+		&o.Interval,
+		"interval",
+		0,
+		"keep running and sync again after this time duration",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,806 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+var workshopEnvironmentResource = training.WorkshopEnvironmentResource
+
+type ClusterTopOptions struct {
+	Kubeconfig string
+	Portal     string
+	Interval   time.Duration
+}
+
+const (
+	topViewPortals = iota
+	topViewWorkshops
+	topViewSessions
+	topViewEvents
+)
+
+var topViewNames = []string{"Portals", "Workshops", "Sessions", "Events"}
+
+type topRow struct {
+	Columns []string
+	Portal  string
+	URL     string
+	Object  *unstructured.Unstructured
+	Event   *apiv1.Event
+}
+
+type topSnapshot struct {
+	Time  time.Time
+	Views [][]topRow
+	Error error
+}
+
+type topState struct {
+	View      int
+	Selected  []int
+	Describe  []string
+	Offset    int
+	Confirm   string
+	Message   string
+	Snapshot  *topSnapshot
+	Headers   [][]string
+	Dynamic   dynamic.Interface
+	Client    *kubernetes.Clientset
+	Interval  time.Duration
+	Portal    string
+	Refreshed chan *topSnapshot
+}
+
+func (o *ClusterTopOptions) Run(ctx context.Context) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("interactive dashboard requires a terminal")
+	}
+
+	if o.Interval < time.Second {
+		o.Interval = time.Second
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	state := &topState{
+		Selected: make([]int, len(topViewNames)),
+		Headers: [][]string{
+			{"NAME", "CAPACITY", "ACTIVE", "WORKSHOPS", "URL"},
+			{"PORTAL", "NAME", "ENVIRONMENT", "CAPACITY", "RESERVED", "ACTIVE", "STATUS"},
+			{"NAME", "PORTAL", "ENVIRONMENT", "STATUS", "AGE"},
+			{"AGE", "TYPE", "NAMESPACE", "OBJECT", "REASON", "MESSAGE"},
+		},
+		Dynamic:   dynamicClient,
+		Client:    client,
+		Interval:  o.Interval,
+		Portal:    o.Portal,
+		Refreshed: make(chan *topSnapshot, 1),
+	}
+
+	// Put the terminal into raw mode so we can read single key presses and
+	// switch to the alternate screen buffer so the original contents of the
+	// terminal are restored when the dashboard exits.
+
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+
+	if err != nil {
+		return errors.Wrap(err, "unable to configure terminal")
+	}
+
+	fmt.Print("\033[?1049h\033[?25l")
+
+	defer func() {
+		fmt.Print("\033[?25h\033[?1049l")
+		term.Restore(int(os.Stdin.Fd()), oldState)
+	}()
+
+	// The read of stdin can't be interrupted, so the reader stops when it
+	// next gets a key press after the dashboard has exited, rather than
+	// being left blocked trying to deliver it.
+
+	keys := make(chan []byte)
+
+	stopped := make(chan struct{})
+
+	defer close(stopped)
+
+	go func() {
+		buffer := make([]byte, 16)
+
+		for {
+			n, err := os.Stdin.Read(buffer)
+
+			if err != nil {
+				close(keys)
+				return
+			}
+
+			key := make([]byte, n)
+			copy(key, buffer[:n])
+
+			select {
+			case keys <- key:
+			case <-stopped:
+				return
+			}
+		}
+	}()
+
+	go state.refresh(ctx)
+
+	ticker := time.NewTicker(o.Interval)
+
+	defer ticker.Stop()
+
+	state.render()
+
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			if done := state.handleKey(ctx, key); done {
+				return nil
+			}
+		case snapshot := <-state.Refreshed:
+			state.Snapshot = snapshot
+		case <-ticker.C:
+			go state.refresh(ctx)
+		case <-ctx.Done():
+			// Returning runs the deferred restore of the terminal, so it
+			// isn't left in raw mode if the CLI is sent a signal.
+
+			return ctx.Err()
+		}
+
+		state.render()
+	}
+}
+
+func (s *topState) refresh(ctx context.Context) {
+	snapshot := collectTopSnapshot(ctx, s.Dynamic, s.Client, s.Portal)
+
+	// Discard the result if an earlier refresh is still waiting to be
+	// consumed, as the next tick will trigger another refresh anyway.
+
+	select {
+	case s.Refreshed <- snapshot:
+	default:
+	}
+}
+
+func (s *topState) rows() []topRow {
+	if s.Snapshot == nil {
+		return nil
+	}
+
+	return s.Snapshot.Views[s.View]
+}
+
+func (s *topState) current() *topRow {
+	rows := s.rows()
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	if s.Selected[s.View] >= len(rows) {
+		s.Selected[s.View] = len(rows) - 1
+	}
+
+	return &rows[s.Selected[s.View]]
+}
+
+func (s *topState) handleKey(ctx context.Context, key []byte) bool {
+	input := string(key)
+
+	// When awaiting confirmation of a destructive action, any key other than
+	// "y" cancels the action.
+
+	if s.Confirm != "" {
+		name := s.Confirm
+
+		s.Confirm = ""
+
+		if input == "y" || input == "Y" {
+			row := s.current()
+
+			if row != nil && row.Object != nil && row.Object.GetName() == name {
+				if err := terminateTopSession(ctx, s.Dynamic, row.Portal, name); err != nil {
+					s.Message = fmt.Sprintf("Terminate failed: %v", err)
+				} else {
+					s.Message = fmt.Sprintf("Terminated session %s.", name)
+				}
+
+				go s.refresh(ctx)
+			}
+		} else {
+			s.Message = "Terminate cancelled."
+		}
+
+		return false
+	}
+
+	// When describing an item, keys scroll the description or return back
+	// to the list view.
+
+	if s.Describe != nil {
+		switch input {
+		case "q", "\033", "d", "\r":
+			s.Describe = nil
+			s.Offset = 0
+		case "j", "\033[B":
+			if s.Offset < len(s.Describe)-1 {
+				s.Offset++
+			}
+		case "k", "\033[A":
+			if s.Offset > 0 {
+				s.Offset--
+			}
+		case "\x03":
+			return true
+		}
+
+		return false
+	}
+
+	s.Message = ""
+
+	switch input {
+	case "q", "\x03":
+		return true
+	case "\t", "\033[C", "l":
+		s.View = (s.View + 1) % len(topViewNames)
+	case "\033[Z", "\033[D", "h":
+		s.View = (s.View + len(topViewNames) - 1) % len(topViewNames)
+	case "1", "2", "3", "4":
+		s.View = int(input[0] - '1')
+	case "j", "\033[B":
+		if s.Selected[s.View] < len(s.rows())-1 {
+			s.Selected[s.View]++
+		}
+	case "k", "\033[A":
+		if s.Selected[s.View] > 0 {
+			s.Selected[s.View]--
+		}
+	case "r":
+		s.Message = "Refreshing ..."
+		go s.refresh(ctx)
+	case "d", "\r":
+		if row := s.current(); row != nil {
+			s.Describe = describeTopRow(row)
+			s.Offset = 0
+		}
+	case "o":
+		if row := s.current(); row != nil {
+			if row.URL == "" {
+				s.Message = "No URL available for selected item."
+			} else if err := openWebBrowser(row.URL); err != nil {
+				s.Message = fmt.Sprintf("Unable to open browser: %v", err)
+			} else {
+				s.Message = fmt.Sprintf("Opened %s", row.URL)
+			}
+		}
+	case "t":
+		if row := s.current(); row != nil {
+			if s.View != topViewSessions || row.Object == nil {
+				s.Message = "Only workshop sessions can be terminated."
+			} else {
+				s.Confirm = row.Object.GetName()
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *topState) render() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	if height < 8 {
+		height = 8
+	}
+
+	var lines []string
+
+	status := "Loading ..."
+
+	if s.Snapshot != nil {
+		status = s.Snapshot.Time.Format("15:04:05")
+
+		if s.Snapshot.Error != nil {
+			status = fmt.Sprintf("%s (error: %v)", status, s.Snapshot.Error)
+		}
+	}
+
+	lines = append(lines, fmt.Sprintf("\033[1mEducates\033[0m - refresh %s - updated %s", s.Interval, status))
+
+	var tabs []string
+
+	for i, name := range topViewNames {
+		label := fmt.Sprintf(" %d:%s ", i+1, name)
+
+		if s.Snapshot != nil {
+			label = fmt.Sprintf(" %d:%s(%d) ", i+1, name, len(s.Snapshot.Views[i]))
+		}
+
+		if i == s.View {
+			label = "\033[7m" + label + "\033[0m"
+		}
+
+		tabs = append(tabs, label)
+	}
+
+	lines = append(lines, strings.Join(tabs, " "), "")
+
+	bodyHeight := height - len(lines) - 2
+
+	if s.Describe != nil {
+		end := s.Offset + bodyHeight
+
+		if end > len(s.Describe) {
+			end = len(s.Describe)
+		}
+
+		for _, line := range s.Describe[s.Offset:end] {
+			lines = append(lines, truncateTopLine(line, width))
+		}
+	} else {
+		rows := s.rows()
+
+		table := [][]string{s.Headers[s.View]}
+
+		for _, row := range rows {
+			table = append(table, row.Columns)
+		}
+
+		formatted := formatTopTable(table)
+
+		lines = append(lines, "\033[1m"+truncateTopLine(formatted[0], width)+"\033[0m")
+
+		selected := s.Selected[s.View]
+
+		start := 0
+
+		if selected >= bodyHeight-1 {
+			start = selected - bodyHeight + 2
+		}
+
+		for i := start; i < len(rows) && i-start < bodyHeight-1; i++ {
+			line := truncateTopLine(formatted[i+1], width)
+
+			if i == selected {
+				line = "\033[7m" + line + strings.Repeat(" ", maxInt(0, width-len(line))) + "\033[0m"
+			}
+
+			lines = append(lines, line)
+		}
+
+		if len(rows) == 0 && s.Snapshot != nil {
+			lines = append(lines, "No resources found.")
+		}
+	}
+
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+
+	footer := "q:quit  tab/1-4:view  j/k:select  d:describe  o:open  t:terminate  r:refresh"
+
+	if s.Describe != nil {
+		footer = "esc/q:back  j/k:scroll"
+	}
+
+	if s.Confirm != "" {
+		footer = fmt.Sprintf("\033[1mTerminate session %s? [y/N]\033[0m", s.Confirm)
+	} else if s.Message != "" {
+		footer = s.Message
+	}
+
+	lines = append(lines[:height-1], truncateTopLine(footer, width))
+
+	var output strings.Builder
+
+	output.WriteString("\033[H")
+
+	for i, line := range lines {
+		output.WriteString(line)
+		output.WriteString("\033[K")
+
+		if i != len(lines)-1 {
+			output.WriteString("\r\n")
+		}
+	}
+
+	fmt.Print(output.String())
+}
+
+func collectTopSnapshot(ctx context.Context, dynamicClient dynamic.Interface, client *kubernetes.Clientset, portalName string) *topSnapshot {
+	snapshot := &topSnapshot{
+		Time:  time.Now(),
+		Views: make([][]topRow, len(topViewNames)),
+	}
+
+	trainingPortals, err := dynamicClient.Resource(trainingPortalResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		snapshot.Error = err
+		return snapshot
+	}
+
+	environments, err := dynamicClient.Resource(workshopEnvironmentResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		snapshot.Error = err
+		return snapshot
+	}
+
+	sessions, err := dynamicClient.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		snapshot.Error = err
+		return snapshot
+	}
+
+	// Work out the number of active sessions for each workshop environment
+	// and which namespaces are associated with Educates resources, so we can
+	// later filter the events to just those of interest.
+
+	namespaces := map[string]bool{"educates": true}
+
+	activeSessions := map[string]int{}
+	portalSessions := map[string]int{}
+
+	for i := range sessions.Items {
+		item := &sessions.Items[i]
+		labels := item.GetLabels()
+
+		portal := labels["training.educates.dev/portal.name"]
+		environment := labels["training.educates.dev/environment.name"]
+
+		if portalName != "" && portal != portalName {
+			continue
+		}
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if phase == "Running" || phase == "Allocated" {
+			activeSessions[environment]++
+			portalSessions[portal]++
+		}
+
+		namespaces[item.GetName()] = true
+
+		url, _, _ := unstructured.NestedString(item.Object, "status", "educates", "url")
+
+		snapshot.Views[topViewSessions] = append(snapshot.Views[topViewSessions], topRow{
+			Columns: []string{item.GetName(), portal, environment, phase, formatTopAge(item.GetCreationTimestamp().Time)},
+			Portal:  portal,
+			URL:     url,
+			Object:  item,
+		})
+	}
+
+	environmentStatus := map[string]string{}
+	environmentNames := map[string]string{}
+
+	for i := range environments.Items {
+		item := &environments.Items[i]
+
+		portal := item.GetLabels()["training.educates.dev/portal.name"]
+		workshop, _, _ := unstructured.NestedString(item.Object, "spec", "workshop", "name")
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if portalName != "" && portal != portalName {
+			continue
+		}
+
+		// Environments for a workshop which have been replaced but which are
+		// still draining of sessions will be stopping, so prefer the one
+		// which is actually running.
+
+		key := portal + "/" + workshop
+
+		if _, exists := environmentNames[key]; !exists || phase == "Running" {
+			environmentNames[key] = item.GetName()
+			environmentStatus[key] = phase
+		}
+
+		namespaces[item.GetName()] = true
+	}
+
+	for i := range trainingPortals.Items {
+		item := &trainingPortals.Items[i]
+		name := item.GetName()
+
+		if portalName != "" && name != portalName {
+			continue
+		}
+
+		namespaces[name+"-ui"] = true
+
+		url, _, _ := unstructured.NestedString(item.Object, "status", "educates", "url")
+
+		sessionsMaximum, sessionsMaximumExists, _ := unstructured.NestedInt64(item.Object, "spec", "portal", "sessions", "maximum")
+
+		capacity := ""
+
+		if sessionsMaximumExists {
+			capacity = fmt.Sprintf("%d", sessionsMaximum)
+		}
+
+		workshops, _, _ := unstructured.NestedSlice(item.Object, "spec", "workshops")
+
+		snapshot.Views[topViewPortals] = append(snapshot.Views[topViewPortals], topRow{
+			Columns: []string{name, capacity, fmt.Sprintf("%d", portalSessions[name]), fmt.Sprintf("%d", len(workshops)), url},
+			Portal:  name,
+			URL:     url,
+			Object:  item,
+		})
+
+		for _, entry := range workshops {
+			object, ok := entry.(map[string]interface{})
+
+			if !ok {
+				continue
+			}
+
+			workshop, _ := object["name"].(string)
+
+			workshopCapacity := capacity
+
+			if value, exists := object["capacity"]; exists {
+				workshopCapacity = fmt.Sprintf("%v", value)
+			}
+
+			reserved := ""
+
+			if value, exists := object["reserved"]; exists {
+				reserved = fmt.Sprintf("%v", value)
+			}
+
+			key := name + "/" + workshop
+			environment := environmentNames[key]
+
+			snapshot.Views[topViewWorkshops] = append(snapshot.Views[topViewWorkshops], topRow{
+				Columns: []string{name, workshop, environment, workshopCapacity, reserved, fmt.Sprintf("%d", activeSessions[environment]), environmentStatus[key]},
+				Portal:  name,
+				URL:     url,
+				Object:  &unstructured.Unstructured{Object: object},
+			})
+		}
+	}
+
+	// Collect recent events for Educates resources or which occurred in a
+	// namespace associated with Educates.
+
+	events, err := client.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		snapshot.Error = err
+		return snapshot
+	}
+
+	var recentEvents []*apiv1.Event
+
+	for i := range events.Items {
+		event := &events.Items[i]
+
+		if namespaces[event.Namespace] || strings.HasPrefix(event.InvolvedObject.APIVersion, "training.educates.dev/") {
+			recentEvents = append(recentEvents, event)
+		}
+	}
+
+	sort.Slice(recentEvents, func(i, j int) bool {
+		return eventTimestamp(recentEvents[i]).After(eventTimestamp(recentEvents[j]))
+	})
+
+	for _, event := range recentEvents {
+		object := fmt.Sprintf("%s/%s", strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name)
+
+		snapshot.Views[topViewEvents] = append(snapshot.Views[topViewEvents], topRow{
+			Columns: []string{formatTopAge(eventTimestamp(event)), event.Type, event.Namespace, object, event.Reason, strings.TrimSpace(event.Message)},
+			Event:   event,
+		})
+	}
+
+	return snapshot
+}
+
+func eventTimestamp(event *apiv1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+
+	return event.CreationTimestamp.Time
+}
+
+func describeTopRow(row *topRow) []string {
+	var data []byte
+	var err error
+
+	if row.Event != nil {
+		data, err = yaml.Marshal(row.Event)
+	} else {
+		data, err = yaml.Marshal(row.Object.Object)
+	}
+
+	if err != nil {
+		return []string{fmt.Sprintf("Unable to describe item: %v", err)}
+	}
+
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
+
+func terminateTopSession(ctx context.Context, dynamicClient dynamic.Interface, portal string, name string) error {
+	trainingPortal, err := dynamicClient.Resource(trainingPortalResource).Get(ctx, portal, metav1.GetOptions{})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to retrieve training portal %q", portal)
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	statusCode, _, err := portalClient.Request(ctx, "GET", fmt.Sprintf("/workshops/session/%s/terminate/", name), nil)
+
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusOK {
+		return errors.Errorf("training portal returned status %d", statusCode)
+	}
+
+	return nil
+}
+
+func formatTopTable(table [][]string) []string {
+	var widths []int
+
+	for _, row := range table {
+		for i, column := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+
+			if len(column) > widths[i] {
+				widths[i] = len(column)
+			}
+		}
+	}
+
+	var lines []string
+
+	for _, row := range table {
+		var line strings.Builder
+
+		for i, column := range row {
+			line.WriteString(column)
+
+			if i != len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-len(column)+3))
+			}
+		}
+
+		lines = append(lines, line.String())
+	}
+
+	return lines
+}
+
+func formatTopAge(timestamp time.Time) string {
+	if timestamp.IsZero() {
+		return ""
+	}
+
+	age := time.Since(timestamp)
+
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
+
+func truncateTopLine(line string, width int) string {
+	if len(line) > width {
+		return line[:width]
+	}
+
+	return line
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func (p *ProjectInfo) NewClusterTopCmd() *cobra.Command {
+	var o ClusterTopOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "top",
+		Short: "Interactive dashboard for portals, workshops and sessions",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"",
+		"name of the training portal to restrict the view to",
+	)
+	c.Flags().DurationVar(
+		&o.Interval,
+		"refresh",
+		5*time.Second,
+		"interval between refreshes of the dashboard",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
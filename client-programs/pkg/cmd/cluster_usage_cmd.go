@@ -0,0 +1,455 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterUsageOptions struct {
+	Kubeconfig      string
+	Portal          string
+	Since           time.Duration
+	Prometheus      string
+	PrometheusToken string
+	Output          string
+}
+
+/*
+Resource consumption for a workshop environment over the time window. This
+includes the workshop environment namespace and the namespaces of all
+sessions created from it. CPU usage is only available from Prometheus.
+*/
+type environmentUsage struct {
+	Environment          string
+	Portal               string
+	Workshop             string
+	PodHours             float64
+	CPURequestCoreHours  float64
+	MemoryRequestGBHours float64
+	CPUUsageCoreHours    float64
+	CPUUsageAvailable    bool
+}
+
+/*
+Queries made against Prometheus. The metrics are those exported by
+kube-state-metrics and cAdvisor. Values are sampled over the window using a
+subquery and summed, then scaled by the step to give hours.
+*/
+const (
+	usageQueryStep = 5 * time.Minute
+
+	usageQueryPodHours       = `sum by (namespace) (sum_over_time((count by (namespace) (kube_pod_status_phase{phase="Running"} == 1))[%s:%s]))`
+	usageQueryCPURequests    = `sum by (namespace) (sum_over_time((sum by (namespace) (kube_pod_container_resource_requests{resource="cpu"}))[%s:%s]))`
+	usageQueryMemoryRequests = `sum by (namespace) (sum_over_time((sum by (namespace) (kube_pod_container_resource_requests{resource="memory"}))[%s:%s]))`
+	usageQueryCPUUsage       = `sum by (namespace) (increase(container_cpu_usage_seconds_total{container!=""}[%s]))`
+)
+
+func (o *ClusterUsageOptions) Run(ctx context.Context) error {
+	if o.Output != "table" && o.Output != "csv" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are table and csv")
+	}
+
+	if o.Since <= 0 {
+		return failures.NewValidationError(errors.New("time window must be greater than zero"), "specify the time window using --since, for example --since 8h")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	environments, err := dynamicClient.Resource(workshopEnvironmentResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to list workshop environments")
+	}
+
+	var usage []*environmentUsage
+
+	for _, item := range environments.Items {
+		portal := item.GetLabels()["training.educates.dev/portal.name"]
+
+		if o.Portal != "" && portal != o.Portal {
+			continue
+		}
+
+		workshop, _, _ := unstructured.NestedString(item.Object, "spec", "workshop", "name")
+
+		usage = append(usage, &environmentUsage{
+			Environment: item.GetName(),
+			Portal:      portal,
+			Workshop:    workshop,
+		})
+	}
+
+	if len(usage) == 0 {
+		fmt.Println("No workshop environments found.")
+		return nil
+	}
+
+	if o.Prometheus != "" {
+		err = o.usageFromPrometheus(usage)
+	} else {
+		err = o.usageFromPods(ctx, client, usage)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Environment < usage[j].Environment
+	})
+
+	if o.Output == "csv" {
+		return writeUsageCSV(os.Stdout, usage)
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "ENVIRONMENT", "PORTAL", "WORKSHOP", "POD-HOURS", "CPU-REQUESTED", "MEMORY-REQUESTED", "CPU-USED")
+
+	for _, item := range usage {
+		cpuUsage := "-"
+
+		if item.CPUUsageAvailable {
+			cpuUsage = fmt.Sprintf("%.2f", item.CPUUsageCoreHours)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%.2f\t%.2f\t%s\n", item.Environment, item.Portal, item.Workshop, item.PodHours, item.CPURequestCoreHours, item.MemoryRequestGBHours, cpuUsage)
+	}
+
+	return nil
+}
+
+/*
+Session namespaces are named after the session, which in turn is named by
+appending a suffix to the name of the workshop environment.
+*/
+func usageForNamespace(usage []*environmentUsage, namespace string) *environmentUsage {
+	for _, item := range usage {
+		if namespace == item.Environment || strings.HasPrefix(namespace, item.Environment+"-") {
+			return item
+		}
+	}
+
+	return nil
+}
+
+/*
+Calculate usage from the pods which still exist in the cluster. Pods for
+sessions which have already been deleted are not counted, so this will under
+report usage for past sessions, and actual CPU used is not available.
+*/
+func (o *ClusterUsageOptions) usageFromPods(ctx context.Context, client *kubernetes.Clientset, usage []*environmentUsage) error {
+	pods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to list pods")
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-o.Since)
+
+	for _, pod := range pods.Items {
+		item := usageForNamespace(usage, pod.Namespace)
+
+		if item == nil || pod.Status.StartTime == nil {
+			continue
+		}
+
+		start := pod.Status.StartTime.Time
+
+		if start.Before(windowStart) {
+			start = windowStart
+		}
+
+		end := now
+
+		if pod.Status.Phase == apiv1.PodSucceeded || pod.Status.Phase == apiv1.PodFailed {
+			end = podFinishTime(&pod)
+		}
+
+		if !end.After(start) {
+			continue
+		}
+
+		hours := end.Sub(start).Hours()
+
+		item.PodHours += hours
+
+		for _, container := range pod.Spec.Containers {
+			item.CPURequestCoreHours += float64(container.Resources.Requests.Cpu().MilliValue()) / 1000 * hours
+			item.MemoryRequestGBHours += float64(container.Resources.Requests.Memory().Value()) / (1 << 30) * hours
+		}
+	}
+
+	return nil
+}
+
+func podFinishTime(pod *apiv1.Pod) time.Time {
+	var finished time.Time
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil && status.State.Terminated.FinishedAt.After(finished) {
+			finished = status.State.Terminated.FinishedAt.Time
+		}
+	}
+
+	return finished
+}
+
+func (o *ClusterUsageOptions) usageFromPrometheus(usage []*environmentUsage) error {
+	window := formatPrometheusDuration(o.Since)
+	step := formatPrometheusDuration(usageQueryStep)
+
+	scale := usageQueryStep.Hours()
+
+	queries := []struct {
+		query string
+		apply func(*environmentUsage, float64)
+	}{
+		{fmt.Sprintf(usageQueryPodHours, window, step), func(item *environmentUsage, value float64) {
+			item.PodHours += value * scale
+		}},
+		{fmt.Sprintf(usageQueryCPURequests, window, step), func(item *environmentUsage, value float64) {
+			item.CPURequestCoreHours += value * scale
+		}},
+		{fmt.Sprintf(usageQueryMemoryRequests, window, step), func(item *environmentUsage, value float64) {
+			item.MemoryRequestGBHours += value / (1 << 30) * scale
+		}},
+		{fmt.Sprintf(usageQueryCPUUsage, window), func(item *environmentUsage, value float64) {
+			item.CPUUsageCoreHours += value / 3600
+		}},
+	}
+
+	for _, query := range queries {
+		results, err := o.queryPrometheus(query.query)
+
+		if err != nil {
+			return err
+		}
+
+		for namespace, value := range results {
+			if item := usageForNamespace(usage, namespace); item != nil {
+				query.apply(item, value)
+			}
+		}
+	}
+
+	// When cAdvisor metrics are being collected, environments without any
+	// CPU usage still have usage available, it is just zero.
+
+	for _, item := range usage {
+		item.CPUUsageAvailable = true
+	}
+
+	return nil
+}
+
+/*
+Make an instant query against the Prometheus HTTP API, returning the value of
+each result keyed by namespace.
+*/
+func (o *ClusterUsageOptions) queryPrometheus(query string) (map[string]float64, error) {
+	endpoint := strings.TrimSuffix(o.Prometheus, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed Prometheus URL")
+	}
+
+	if o.PrometheusToken != "" {
+		req.Header.Set("Authorization", "Bearer "+o.PrometheusToken)
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return nil, failures.NewConnectionError(errors.Wrap(err, "unable to query Prometheus"), "check the Prometheus URL is correct and reachable")
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read response from Prometheus")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("query to Prometheus failed with status %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+
+	var response struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Value  []interface{}     `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, errors.Wrap(err, "unable to parse response from Prometheus")
+	}
+
+	if response.Status != "success" {
+		return nil, errors.Errorf("query to Prometheus failed: %s", response.Error)
+	}
+
+	results := map[string]float64{}
+
+	for _, result := range response.Data.Result {
+		if len(result.Value) != 2 {
+			continue
+		}
+
+		text, _ := result.Value[1].(string)
+
+		value, err := strconv.ParseFloat(text, 64)
+
+		if err != nil {
+			continue
+		}
+
+		results[result.Metric["namespace"]] = value
+	}
+
+	return results, nil
+}
+
+func formatPrometheusDuration(duration time.Duration) string {
+	return fmt.Sprintf("%ds", int64(duration.Seconds()))
+}
+
+func writeUsageCSV(out io.Writer, usage []*environmentUsage) error {
+	w := csv.NewWriter(out)
+
+	w.Write([]string{"environment", "portal", "workshop", "pod_hours", "cpu_requested_core_hours", "memory_requested_gib_hours", "cpu_used_core_hours"})
+
+	for _, item := range usage {
+		cpuUsage := ""
+
+		if item.CPUUsageAvailable {
+			cpuUsage = strconv.FormatFloat(item.CPUUsageCoreHours, 'f', 4, 64)
+		}
+
+		w.Write([]string{
+			item.Environment,
+			item.Portal,
+			item.Workshop,
+			strconv.FormatFloat(item.PodHours, 'f', 4, 64),
+			strconv.FormatFloat(item.CPURequestCoreHours, 'f', 4, 64),
+			strconv.FormatFloat(item.MemoryRequestGBHours, 'f', 4, 64),
+			cpuUsage,
+		})
+	}
+
+	w.Flush()
+
+	return errors.Wrap(w.Error(), "unable to write CSV output")
+}
+
+func (p *ProjectInfo) NewClusterUsageCmd() *cobra.Command {
+	var o ClusterUsageOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "usage",
+		Short: "Summarize resource usage of workshop environments",
+		Long: `Summarize resource usage of workshop environments.
+
+Reports pod hours, and CPU and memory requested, for each workshop
+environment over a time window, counting the workshop environment namespace
+and the namespaces of all sessions created from it. Output can be in CSV
+format for use in chargeback of shared training clusters.
+
+When a Prometheus server is supplied, usage is calculated from metrics
+collected by kube-state-metrics and cAdvisor, including CPU actually used.
+Otherwise usage is calculated from pods still in the cluster, which will not
+include sessions which have already been deleted.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"",
+		"name of training portal to report on, defaults to all training portals",
+	)
+	c.Flags().DurationVar(
+		&o.Since,
+		"since",
+		24*time.Hour,
+		"time window to report usage over, ending now",
+	)
+	c.Flags().StringVar(
+		&o.Prometheus,
+		"prometheus",
+		"",
+		"URL of Prometheus server to query for usage metrics",
+	)
+	c.Flags().StringVar(
+		&o.PrometheusToken,
+		"prometheus-token",
+		"",
+		"bearer token for authenticating with the Prometheus server",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"table",
+		"output format, one of table or csv",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
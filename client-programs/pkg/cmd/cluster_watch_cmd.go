@@ -0,0 +1,471 @@
+package cmd
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWatchOptions struct {
+	Kubeconfig    string
+	Portal        string
+	GitURL        string
+	Branch        string
+	Path          string
+	WorkshopFile  string
+	Repository    string
+	Interval      time.Duration
+	Listen        string
+	WebhookSecret string
+}
+
+/*
+Return the commit at the head of a branch of a remote Git repository.
+*/
+func gitRemoteHead(url string, branch string) (string, error) {
+	commandPath, err := exec.LookPath("git")
+
+	if err != nil {
+		return "", failures.NewValidationError(errors.Wrap(err, "unable to find git program"), "install git to watch a Git repository")
+	}
+
+	output, err := exec.Command(commandPath, "ls-remote", url, "refs/heads/"+branch).CombinedOutput()
+
+	if err != nil {
+		return "", failures.NewConnectionError(errors.Errorf("unable to query Git repository %q: %s", url, strings.TrimSpace(string(output))), "")
+	}
+
+	fields := strings.Fields(string(output))
+
+	if len(fields) == 0 {
+		return "", failures.NewNotFoundError(errors.Errorf("no branch %q in Git repository %q", branch, url), "")
+	}
+
+	return fields[0], nil
+}
+
+/*
+Find the workshop directories under a directory, being those holding a
+workshop definition file. Directories within a workshop directory are not
+searched.
+*/
+func findWorkshopDirectories(root string, workshopFile string) ([]string, error) {
+	var directories []string
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if _, err := os.Stat(filepath.Join(path, workshopFile)); err == nil {
+			directories = append(directories, path)
+
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to search %q for workshops", root)
+	}
+
+	return directories, nil
+}
+
+/*
+Calculate a digest of the files in a directory, so changes to the contents
+can be detected without needing the history of the Git repository.
+*/
+func directoryDigest(root string) (string, error) {
+	var paths []string
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if entry.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to read %q", root)
+	}
+
+	sort.Strings(paths)
+
+	hash := sha256.New()
+
+	for _, path := range paths {
+		relPath, _ := filepath.Rel(root, path)
+
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to read %q", path)
+		}
+
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(relPath), len(data))
+		hash.Write(data)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+/*
+Check whether a webhook request is authorized by the shared secret. Both the
+HMAC signature sent by GitHub and Gitea, and the token sent by GitLab, are
+accepted.
+*/
+func validWebhookRequest(r *http.Request, body []byte, secret string) bool {
+	if secret == "" {
+		return true
+	}
+
+	if token := r.Header.Get("X-Gitlab-Token"); token != "" {
+		return hmac.Equal([]byte(token), []byte(secret))
+	}
+
+	signature := strings.TrimPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+
+	if signature == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+
+	mac.Write(body)
+
+	return hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+func (o *ClusterWatchOptions) Run(ctx context.Context, p *ProjectInfo) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Interval <= 0 && o.Listen == "" {
+		return failures.NewValidationError(errors.New("one of --interval or --listen must be supplied"), "")
+	}
+
+	// Requests received on the webhook only trigger a check of the Git
+	// repository, with the checks themselves being made one at a time by
+	// the main loop. A pending trigger absorbs any further requests.
+
+	triggers := make(chan struct{}, 1)
+
+	if o.Listen != "" {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, 10*1024*1024))
+
+			if err != nil {
+				http.Error(w, "unable to read request", http.StatusBadRequest)
+				return
+			}
+
+			if !validWebhookRequest(r, body, o.WebhookSecret) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			select {
+			case triggers <- struct{}{}:
+			default:
+			}
+
+			w.WriteHeader(http.StatusAccepted)
+		})
+
+		go func() {
+			if err := http.ListenAndServe(o.Listen, handler); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: unable to listen for webhooks on %s: %s\n", o.Listen, err)
+				os.Exit(1)
+			}
+		}()
+
+		fmt.Printf("Listening for webhooks on %s.\n", o.Listen)
+	}
+
+	var ticks <-chan time.Time
+
+	if o.Interval > 0 {
+		ticker := time.NewTicker(o.Interval)
+
+		defer ticker.Stop()
+
+		ticks = ticker.C
+	}
+
+	lastCommit := ""
+	digests := map[string]string{}
+
+	// Keep running until interrupted, reporting but otherwise ignoring
+	// failures so that a transient problem or a broken commit doesn't stop
+	// later changes being deployed.
+
+	for {
+		commit, err := o.check(ctx, p, lastCommit, digests)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		} else {
+			lastCommit = commit
+		}
+
+		select {
+		case <-ticks:
+		case <-triggers:
+		}
+	}
+}
+
+/*
+Check whether the branch being watched has changed since the last commit
+deployed, and if it has redeploy the workshops whose files have changed.
+The digests of the workshop directories are updated as each is deployed. The
+commit is only returned once all changed workshops have been deployed, so
+any failures are retried on the next check.
+*/
+func (o *ClusterWatchOptions) check(ctx context.Context, p *ProjectInfo, lastCommit string, digests map[string]string) (string, error) {
+	commit, err := gitRemoteHead(o.GitURL, o.Branch)
+
+	if err != nil {
+		return "", err
+	}
+
+	if commit == lastCommit {
+		return commit, nil
+	}
+
+	cloneDir, err := os.MkdirTemp("", "educates-watch-")
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create temporary directory for checkout")
+	}
+
+	defer os.RemoveAll(cloneDir)
+
+	if err = gitClone(o.GitURL, o.Branch, cloneDir); err != nil {
+		return "", err
+	}
+
+	directories, err := findWorkshopDirectories(filepath.Join(cloneDir, o.Path), o.WorkshopFile)
+
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Checking %d workshops at commit %s.\n", len(directories), commit)
+
+	failed := 0
+
+	for _, directory := range directories {
+		relPath, _ := filepath.Rel(cloneDir, directory)
+
+		digest, err := directoryDigest(directory)
+
+		if err != nil {
+			return "", err
+		}
+
+		if digests[relPath] == digest {
+			continue
+		}
+
+		fmt.Printf("Deploying workshop from %q.\n", relPath)
+
+		if err = o.deploy(ctx, p, directory); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: unable to deploy workshop from %q: %s\n", relPath, err)
+
+			failed++
+
+			continue
+		}
+
+		digests[relPath] = digest
+	}
+
+	if failed != 0 {
+		return "", errors.Errorf("failed to deploy %d workshops at commit %s", failed, commit)
+	}
+
+	return commit, nil
+}
+
+/*
+Publish the workshop files if the workshop definition says where to publish
+them, then deploy the workshop to the training portal. This runs the same
+commands as would be used to do this manually, with their default options,
+so that they are recorded in the audit log in the same way.
+*/
+func (o *ClusterWatchOptions) deploy(ctx context.Context, p *ProjectInfo, directory string) error {
+	workshopFileData, err := os.ReadFile(filepath.Join(directory, o.WorkshopFile))
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read workshop definition")
+	}
+
+	workshop := &unstructured.Unstructured{}
+
+	if err = yaml.Unmarshal(workshopFileData, &workshop.Object); err != nil {
+		return errors.Wrap(err, "unable to parse workshop definition")
+	}
+
+	if image, _, _ := unstructured.NestedString(workshop.Object, "spec", "publish", "image"); image != "" {
+		publishCmd := p.nestedCommand(p.NewWorkshopPublishCmd(), "workshop")
+
+		if err = runNestedCommand(ctx, publishCmd, directory, "--image-repository", o.Repository, "--workshop-file", o.WorkshopFile); err != nil {
+			return err
+		}
+	}
+
+	deployArgs := []string{"--file", directory, "--portal", o.Portal, "--image-repository", o.Repository, "--workshop-file", o.WorkshopFile}
+
+	if o.Kubeconfig != "" {
+		deployArgs = append(deployArgs, "--kubeconfig", o.Kubeconfig)
+	}
+
+	deployCmd := p.nestedCommand(p.NewClusterWorkshopDeployCmd(), "cluster", "workshop")
+
+	return runNestedCommand(ctx, deployCmd, deployArgs...)
+}
+
+func (p *ProjectInfo) NewClusterWatchCmd() *cobra.Command {
+	var o ClusterWatchOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "watch",
+		Short: "Redeploy workshops when a Git repository changes",
+		Long: `Redeploy workshops when a Git repository changes.
+
+Watches a branch of a Git repository holding the files for one or more
+workshops, and when a new commit is pushed to the branch republishes and
+redeploys to the training portal each workshop whose files have changed.
+Workshop directories are those holding a workshop definition file. Workshop
+files are only published where the workshop definition says where they are
+to be published, and the same defaults are used as when publishing and
+deploying the workshop with "educates workshop publish" and "educates cluster
+workshop deploy". All workshops are deployed when first started.
+
+The branch is checked for new commits at the time interval given by
+--interval. Use --listen to also receive webhook requests from the Git
+hosting service when changes are pushed, so they are deployed immediately.
+Webhook requests can be verified using a shared secret given by
+--webhook-secret, supporting both the signature sent by GitHub and Gitea,
+and the secret token sent by GitLab.
+
+Runs until interrupted. Failures are reported but otherwise ignored, with
+workshops which failed to deploy being retried at the next check.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context(), p) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.GitURL,
+		"git-repo",
+		"",
+		"URL of Git repository holding the workshops",
+	)
+	c.Flags().StringVar(
+		&o.Branch,
+		"branch",
+		"main",
+		"branch of the Git repository to watch",
+	)
+	c.Flags().StringVar(
+		&o.Path,
+		"path",
+		"",
+		"path of the directory within the Git repository holding the workshops",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file within a workshop directory",
+	)
+	c.Flags().StringVar(
+		&o.Repository,
+		"image-repository",
+		"localhost:5001",
+		"the address of the image repository",
+	)
+	c.Flags().DurationVar(
+		&o.Interval,
+		"interval",
+		time.Minute,
+		"time duration between checks of the Git repository, zero to disable",
+	)
+	c.Flags().StringVar(
+		&o.Listen,
+		"listen",
+		"",
+		"address to listen on for webhook requests, such as :8080",
+	)
+	c.Flags().StringVar(
+		&o.WebhookSecret,
+		"webhook-secret",
+		"",
+		"shared secret used to verify webhook requests",
+	)
+
+	c.MarkFlagRequired("git-repo")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+/*
+Reserved sessions for a workshop are auto-scaled by a cron job run by the
+scheduler for training portals. Each time it runs it counts the users which
+are waiting for a session, being sessions allocated to a user which are not
+yet ready, along with how many sessions were allocated since it last ran,
+and sets the number of reserved sessions to cover that demand. The number of
+reserved sessions is increased straight away, but reduced only by one each
+time so a lull in demand doesn't immediately remove the reserve. The number
+of allocated sessions seen is recorded in an annotation on the cron job.
+*/
+const autoscaleScript = `set -e
+environment=$(kubectl get workshopenvironments -l training.educates.dev/portal.name=%[1]s -o jsonpath='{range .items[*]}{.metadata.name}{" "}{.spec.workshop.name}{" "}{.status.educates.phase}{"\n"}{end}' | awk '$2=="%[2]s" && $3=="Running" {print $1}' | tail -1)
+if [ -z "$environment" ]; then
+  exit 0
+fi
+index=$(kubectl get trainingportal %[1]s -o jsonpath='{range .spec.workshops[*]}{.name}{"\n"}{end}' | grep -nx %[2]s | cut -d: -f1 || true)
+if [ -z "$index" ]; then
+  exit 0
+fi
+index=$((index-1))
+allocated=$(kubectl get workshopsessions -l training.educates.dev/environment.name=$environment -o jsonpath='{range .items[*]}{.metadata.name}{" "}{.status.educates.phase}{"\n"}{end}' | awk '$2=="Allocated" {print $1}')
+ready=$(kubectl get deployments -n $environment -l training.educates.dev/application=workshop -o jsonpath='{range .items[*]}{.metadata.name}{" "}{.status.readyReplicas}{"\n"}{end}' | awk '$2>0 {print $1}')
+count=0
+waiting=0
+for session in $allocated; do
+  count=$((count+1))
+  if ! echo "$ready" | grep -qx "$session"; then
+    waiting=$((waiting+1))
+  fi
+done
+previous=$(kubectl get cronjob -n %[5]s $CRONJOB_NAME -o jsonpath='{.metadata.annotations.training\.educates\.dev/autoscale-allocated}')
+previous=${previous:-$count}
+arrivals=$((count-previous))
+if [ $arrivals -lt 0 ]; then
+  arrivals=0
+fi
+current=$(kubectl get trainingportal %[1]s -o jsonpath="{.spec.workshops[$index].reserved}")
+current=${current:-0}
+target=$((arrivals+waiting))
+if [ $target -lt $current ]; then
+  target=$((current-1))
+fi
+if [ $target -lt %[3]d ]; then
+  target=%[3]d
+fi
+if [ $target -gt %[4]d ]; then
+  target=%[4]d
+fi
+if [ $target -ne $current ]; then
+  kubectl patch trainingportal %[1]s --type=json -p "[{\"op\":\"test\",\"path\":\"/spec/workshops/$index/name\",\"value\":\"%[2]s\"},{\"op\":\"add\",\"path\":\"/spec/workshops/$index/reserved\",\"value\":$target}]"
+fi
+kubectl annotate cronjob -n %[5]s $CRONJOB_NAME --overwrite training.educates.dev/autoscale-allocated=$count
+echo "allocated=$count waiting=$waiting arrivals=$arrivals reserved=$current->$target"
+`
+
+/*
+Start auto-scaling the reserved sessions for a workshop in a training portal
+within the given bounds, checking demand at the given interval in minutes.
+Any reserve schedule for the workshop is removed as the two would conflict.
+*/
+func scheduleAutoscaler(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string, workshop string, minimum uint, maximum uint, interval int) error {
+	image, err := prepareScheduler(ctx, clusterConfig, "")
+
+	if err != nil {
+		return err
+	}
+
+	if err := deleteReserveSchedule(ctx, clusterConfig, portal, workshop); err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf(autoscaleScript, portal, workshop, minimum, maximum, scheduleNamespace)
+
+	annotations := map[string]interface{}{
+		"training.educates.dev/autoscale-min": strconv.FormatUint(uint64(minimum), 10),
+		"training.educates.dev/autoscale-max": strconv.FormatUint(uint64(maximum), 10),
+	}
+
+	schedule := fmt.Sprintf("*/%d * * * *", interval)
+
+	return applyScheduleCronJob(ctx, clusterConfig, image, scheduleCronJobName("autoscale", portal, workshop), "autoscale", portal, workshop, schedule, "Etc/UTC", annotations, script)
+}
+
+/*
+Stop auto-scaling the reserved sessions for a workshop in a training portal,
+returning whether it had been enabled.
+*/
+func deleteAutoscaler(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string, workshop string) (bool, error) {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return false, err
+	}
+
+	err = client.BatchV1().CronJobs(scheduleNamespace).Delete(ctx, scheduleCronJobName("autoscale", portal, workshop), metav1.DeleteOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to disable auto-scaling for workshop %q", workshop)
+	}
+
+	return true, nil
+}
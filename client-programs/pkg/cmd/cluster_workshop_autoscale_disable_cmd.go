@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWorkshopAutoscaleDisableOptions struct {
+	Name       string
+	Kubeconfig string
+	Portal     string
+}
+
+func (o *ClusterWorkshopAutoscaleDisableOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	found, err := deleteAutoscaler(ctx, clusterConfig, o.Portal, o.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return failures.NewNotFoundError(errors.Errorf("auto-scaling is not enabled for workshop %q in training portal %q", o.Name, o.Portal), "")
+	}
+
+	fmt.Printf("Auto-scaling of reserved sessions for workshop %q disabled.\n", o.Name)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopAutoscaleDisableCmd() *cobra.Command {
+	var o ClusterWorkshopAutoscaleDisableOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "disable",
+		Short: "Disable auto-scaling of reserved sessions",
+		Long: `Disable auto-scaling of reserved sessions for a workshop.
+
+Removes the job which adjusts the number of reserved sessions for the
+workshop. The number of reserved sessions last set for the workshop in the
+training portal is left as is, and can be changed by deploying the workshop
+again with the --reserved option.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the deployed workshop",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+
+	c.MarkFlagRequired("name")
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWorkshopAutoscaleEnableOptions struct {
+	Name       string
+	Kubeconfig string
+	Portal     string
+	Minimum    uint
+	Maximum    uint
+	Interval   time.Duration
+}
+
+func (o *ClusterWorkshopAutoscaleEnableOptions) Run(ctx context.Context) error {
+	if o.Maximum == 0 {
+		return failures.NewValidationError(errors.New("maximum number of reserved sessions must be greater than zero"), "set the maximum with --max")
+	}
+
+	if o.Minimum > o.Maximum {
+		return failures.NewValidationError(errors.Errorf("minimum of %d reserved sessions is greater than the maximum of %d", o.Minimum, o.Maximum), "")
+	}
+
+	if o.Interval < time.Minute || o.Interval >= time.Hour || o.Interval%time.Minute != 0 {
+		return failures.NewValidationError(errors.Errorf("invalid interval %s", o.Interval), "give the interval as a whole number of minutes less than an hour, e.g. 2m")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	// The workshop needs to be hosted by the training portal as the number
+	// of reserved sessions is set on its entry in the training portal.
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	var entry map[string]interface{}
+
+	for _, item := range workshops {
+		if object, ok := item.(map[string]interface{}); ok && object["name"] == o.Name {
+			entry = object
+		}
+	}
+
+	if entry == nil {
+		return failures.NewNotFoundError(errors.Errorf("workshop %q is not hosted by training portal %q", o.Name, o.Portal), "list workshops with `educates cluster workshop list`")
+	}
+
+	if capacity, found, _ := unstructured.NestedInt64(entry, "capacity"); found && int64(o.Maximum) > capacity {
+		fmt.Fprintf(os.Stderr, "Warning: maximum of %d reserved sessions is more than the capacity of %d for the workshop.\n", o.Maximum, capacity)
+	}
+
+	err = scheduleAutoscaler(ctx, clusterConfig, o.Portal, o.Name, o.Minimum, o.Maximum, int(o.Interval/time.Minute))
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Auto-scaling of reserved sessions for workshop %q enabled, between %d and %d sessions.\n", o.Name, o.Minimum, o.Maximum)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopAutoscaleEnableCmd() *cobra.Command {
+	var o ClusterWorkshopAutoscaleEnableOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "enable",
+		Short: "Enable auto-scaling of reserved sessions",
+		Long: `Enable auto-scaling of reserved sessions for a workshop.
+
+Deploys a job to the cluster which periodically checks how many users are
+waiting for sessions of the workshop, being users who have been allocated a
+session which is not yet ready, along with how many sessions were allocated
+since it last checked, and adjusts the number of reserved sessions for the
+workshop in the training portal to match. The number of reserved sessions
+is increased as soon as demand is seen, but reduced by only one session
+each time demand is checked, and is always kept within the bounds given.
+
+Any reserve schedule set when deploying the workshop is removed, as it would
+conflict with the changes made when auto-scaling. Running the command again
+replaces the bounds and interval previously set.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the deployed workshop",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().UintVar(
+		&o.Minimum,
+		"min",
+		0,
+		"minimum number of reserved sessions to keep",
+	)
+	c.Flags().UintVar(
+		&o.Maximum,
+		"max",
+		0,
+		"maximum number of reserved sessions to create",
+	)
+	c.Flags().DurationVar(
+		&o.Interval,
+		"interval",
+		time.Minute,
+		"how often to check demand for sessions, in whole minutes",
+	)
+
+	c.MarkFlagRequired("name")
+	c.MarkFlagRequired("max")
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
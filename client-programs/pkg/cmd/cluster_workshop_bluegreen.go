@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Strategies for updating a workshop already deployed to a training portal.
+With "in-place" the workshop definition is updated directly, with the
+training portal only replacing the workshop environment if configured to do
+so. With "blue-green" a canary session is first started using the updated
+workshop definition, with the workshop environment only being replaced with
+one for the updated workshop if the canary session starts.
+*/
+var deploymentStrategies = []string{"in-place", "blue-green"}
+
+/*
+Determine whether deploying the workshop definition would change the
+workshop used by the current workshop environment for it. A dry run of the
+update is used so that the generation the workshop definition would have can
+be compared against that recorded by the workshop environment.
+*/
+func workshopDefinitionChanged(ctx context.Context, client dynamic.Interface, workshop *unstructured.Unstructured, environment *unstructured.Unstructured) (bool, error) {
+	workshopBytes, err := runtime.Encode(unstructured.UnstructuredJSONScheme, workshop)
+
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to check workshop definition in cluster %q", workshop.GetName())
+	}
+
+	options := metav1.ApplyOptions{FieldManager: "educates-cli", Force: true, DryRun: []string{metav1.DryRunAll}}
+
+	updated, err := client.Resource(workshopResource).Patch(ctx, workshop.GetName(), types.ApplyPatchType, workshopBytes, options.ToPatchOptions())
+
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to check workshop definition in cluster %q", workshop.GetName())
+	}
+
+	uid, _, _ := unstructured.NestedString(environment.Object, "status", "educates", "workshop", "uid")
+	generation, _, _ := unstructured.NestedInt64(environment.Object, "status", "educates", "workshop", "generation")
+
+	return string(updated.GetUID()) != uid || updated.GetGeneration() != generation, nil
+}
+
+/*
+Start a canary session for the updated workshop definition and wait for the
+workshop container of the session to be ready. The canary session is run in
+a standalone workshop environment, using a temporary copy of the workshop
+definition, so the training portal and any existing sessions aren't affected.
+The temporary resources are deleted when done, whether or not the canary
+session started.
+*/
+func runWorkshopCanary(ctx context.Context, client *kubernetes.Clientset, dynamicClient dynamic.Interface, workshop *unstructured.Unstructured, timeout time.Duration) error {
+	suffix := utilrand.String(5)
+
+	candidate := workshop.DeepCopy()
+
+	candidate.SetName(fmt.Sprintf("%s-canary-%s", workshop.GetName(), suffix))
+	candidate.SetResourceVersion("")
+
+	labels := candidate.GetLabels()
+
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	labels["training.educates.dev/canary"] = "true"
+
+	candidate.SetLabels(labels)
+
+	candidate, err := dynamicClient.Resource(workshopResource).Create(ctx, candidate, metav1.CreateOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create canary workshop for %q", workshop.GetName())
+	}
+
+	// The canary resources are deleted using a separate context so they are
+	// still cleaned up when the deploy has been interrupted.
+
+	defer dynamicClient.Resource(workshopResource).Delete(context.Background(), candidate.GetName(), metav1.DeleteOptions{})
+
+	environmentName := fmt.Sprintf("educates-canary-%s", suffix)
+
+	environment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "training.educates.dev/v1beta1",
+			"kind":       "WorkshopEnvironment",
+			"metadata": map[string]interface{}{
+				"name": environmentName,
+				"labels": map[string]interface{}{
+					"training.educates.dev/canary": "true",
+				},
+			},
+			"spec": map[string]interface{}{
+				"workshop": map[string]interface{}{
+					"name": candidate.GetName(),
+				},
+				"request": map[string]interface{}{
+					"enabled": false,
+				},
+			},
+		},
+	}
+
+	if _, err = dynamicClient.Resource(workshopEnvironmentResource).Create(ctx, environment, metav1.CreateOptions{FieldManager: "educates-cli"}); err != nil {
+		return errors.Wrapf(err, "unable to create canary workshop environment for %q", workshop.GetName())
+	}
+
+	defer dynamicClient.Resource(workshopEnvironmentResource).Delete(context.Background(), environmentName, metav1.DeleteOptions{})
+
+	fmt.Printf("Starting canary session for workshop %s.\n", workshop.GetName())
+
+	deadline := time.Now().Add(timeout)
+
+	if err = waitForCanaryPhase(ctx, dynamicClient, workshopEnvironmentResource, environmentName, time.Until(deadline)); err != nil {
+		return errors.Wrapf(err, "canary workshop environment for %q failed", workshop.GetName())
+	}
+
+	sessionName := fmt.Sprintf("%s-canary", environmentName)
+
+	session := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "training.educates.dev/v1beta1",
+			"kind":       "WorkshopSession",
+			"metadata": map[string]interface{}{
+				"name": sessionName,
+				"labels": map[string]interface{}{
+					"training.educates.dev/canary":           "true",
+					"training.educates.dev/environment.name": environmentName,
+				},
+			},
+			"spec": map[string]interface{}{
+				"workshop": map[string]interface{}{
+					"name": candidate.GetName(),
+				},
+				"environment": map[string]interface{}{
+					"name": environmentName,
+				},
+				"session": map[string]interface{}{
+					"id": "canary",
+				},
+			},
+		},
+	}
+
+	if _, err = dynamicClient.Resource(workshopSessionResource).Create(ctx, session, metav1.CreateOptions{FieldManager: "educates-cli"}); err != nil {
+		return errors.Wrapf(err, "unable to create canary workshop session for %q", workshop.GetName())
+	}
+
+	defer dynamicClient.Resource(workshopSessionResource).Delete(context.Background(), sessionName, metav1.DeleteOptions{})
+
+	if err = waitForCanaryPhase(ctx, dynamicClient, workshopSessionResource, sessionName, time.Until(deadline)); err != nil {
+		return errors.Wrapf(err, "canary workshop session for %q failed", workshop.GetName())
+	}
+
+	// The session is only marked as running once its resources are created,
+	// so also wait for the workshop container to pass its readiness check.
+
+	err = cluster.PollImmediate(ctx, 2*time.Second, time.Until(deadline), func() (bool, error) {
+		deployment, err := client.AppsV1().Deployments(environmentName).Get(ctx, sessionName, metav1.GetOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		return deployment.Status.ReadyReplicas > 0, nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return failures.NewTimeoutError(errors.Errorf("timed out waiting for canary workshop session for %q to be ready", workshop.GetName()), "the training portal has not been changed")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to check canary workshop session for %q", workshop.GetName())
+	}
+
+	fmt.Printf("Canary session for workshop %s started successfully.\n", workshop.GetName())
+
+	return nil
+}
+
+/*
+Wait for a canary workshop environment or session to be running, failing
+immediately if the session manager reports it has failed.
+*/
+func waitForCanaryPhase(ctx context.Context, client dynamic.Interface, resource schema.GroupVersionResource, name string, timeout time.Duration) error {
+	var failed bool
+	var message string
+
+	err := cluster.PollImmediate(ctx, 2*time.Second, timeout, func() (bool, error) {
+		object, err := client.Resource(resource).Get(ctx, name, metav1.GetOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		phase, _, _ := unstructured.NestedString(object.Object, "status", "educates", "phase")
+
+		if phase == "Failed" {
+			failed = true
+
+			message, _, _ = unstructured.NestedString(object.Object, "status", "educates", "message")
+
+			return true, nil
+		}
+
+		return phase == "Running", nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return failures.NewTimeoutError(errors.Errorf("timed out waiting for %s to be running", name), "the training portal has not been changed")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if failed {
+		if message == "" {
+			message = fmt.Sprintf("%s failed to start", name)
+		}
+
+		return errors.New(message)
+	}
+
+	return nil
+}
+
+/*
+Switch the training portal over to a new workshop environment for the
+updated workshop definition. Where the training portal doesn't already
+replace workshop environments when a workshop definition changes, it is asked
+to replace the existing workshop environment. Sessions already allocated to
+users in the existing workshop environment are left running until they end,
+with the workshop environment then being deleted.
+*/
+func switchWorkshopEnvironment(ctx context.Context, client dynamic.Interface, portal string, workshop string, previous string, timeout time.Duration) error {
+	trainingPortal, err := client.Resource(trainingPortalResource).Get(ctx, portal, metav1.GetOptions{})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve training portal")
+	}
+
+	if updates, _, _ := unstructured.NestedBool(trainingPortal.Object, "spec", "portal", "updates", "workshop"); !updates {
+		portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+		if err != nil {
+			return err
+		}
+
+		defer portalClient.Logout(ctx)
+
+		status, body, err := portalClient.Request(ctx, "POST", fmt.Sprintf("/workshops/environment/%s/replace/", url.PathEscape(previous)), nil)
+
+		if err != nil {
+			return err
+		}
+
+		if status != 200 {
+			return errors.Errorf("unable to replace workshop environment %q: %s", previous, string(body))
+		}
+	}
+
+	current, err := waitForReplacementEnvironment(ctx, client, portal, workshop, previous, timeout)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Workshop %s switched from workshop environment %s to %s, existing sessions are left to finish.\n", workshop, previous, current)
+
+	return nil
+}
+
+/*
+Wait for the workshop environment for a workshop in a training portal to be
+replaced by a new workshop environment which is running, returning the name
+of the new workshop environment.
+*/
+func waitForReplacementEnvironment(ctx context.Context, client dynamic.Interface, portal string, workshop string, previous string, timeout time.Duration) (string, error) {
+	var current string
+
+	err := cluster.PollImmediate(ctx, 2*time.Second, timeout, func() (bool, error) {
+		environment, err := workshopEnvironmentForPortal(ctx, client, portal, workshop)
+
+		if err != nil || environment == nil || environment.GetName() == previous {
+			return false, err
+		}
+
+		current = environment.GetName()
+
+		phase, _, _ := unstructured.NestedString(environment.Object, "status", "educates", "phase")
+
+		return phase == "Running", nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return "", failures.NewTimeoutError(errors.Errorf("timed out waiting for workshop environment for %q to replace %q", workshop, previous), "check the status of the workshop with `educates cluster workshop describe`")
+	}
+
+	return current, err
+}
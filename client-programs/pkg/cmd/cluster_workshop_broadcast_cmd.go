@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWorkshopBroadcastOptions struct {
+	Kubeconfig string
+	Portal     string
+	Name       string
+	Duration   time.Duration
+	Clear      bool
+}
+
+func (o *ClusterWorkshopBroadcastOptions) Run(ctx context.Context, args []string) error {
+	var message string
+
+	if len(args) != 0 {
+		message = strings.TrimSpace(args[0])
+	}
+
+	if o.Clear && message != "" {
+		return failures.NewValidationError(errors.New("a message cannot be given when clearing the message"), "")
+	}
+
+	if !o.Clear && message == "" {
+		return failures.NewValidationError(errors.New("no message given to broadcast"), "give the message as an argument, or use --clear to remove the last message")
+	}
+
+	if o.Duration < 0 {
+		return failures.NewValidationError(errors.Errorf("invalid duration %s for message", o.Duration), "")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	// Work out the workshop environments the message is sent to, being that
+	// for the named workshop, or those for all workshops of the portal.
+
+	var environments []*unstructured.Unstructured
+
+	if o.Name != "" {
+		environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, o.Name)
+
+		if err != nil {
+			return err
+		}
+
+		if environment == nil {
+			return failures.NewNotFoundError(errors.Errorf("no workshop %q deployed to training portal %q", o.Name, o.Portal), "list deployed workshops with `educates cluster workshop list`")
+		}
+
+		environments = append(environments, environment)
+	} else {
+		environmentsList, err := dynamicClient.Resource(workshopEnvironmentResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("training.educates.dev/portal.name=%s", o.Portal)})
+
+		if err != nil {
+			return errors.Wrap(err, "unable to list workshop environments")
+		}
+
+		for i := range environmentsList.Items {
+			item := &environmentsList.Items[i]
+
+			phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+			if item.GetDeletionTimestamp() == nil && phase != "Stopping" {
+				environments = append(environments, item)
+			}
+		}
+
+		if len(environments) == 0 {
+			return failures.NewNotFoundError(errors.Errorf("no workshops deployed to training portal %q", o.Portal), "")
+		}
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	type BroadcastDetails struct {
+		Message  string `json:"message"`
+		Duration int64  `json:"duration"`
+	}
+
+	data, err := json.Marshal(BroadcastDetails{Message: message, Duration: int64(o.Duration.Seconds())})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode broadcast message")
+	}
+
+	for _, environment := range environments {
+		workshop, _, _ := unstructured.NestedString(environment.Object, "spec", "workshop", "name")
+
+		status, body, err := portalClient.Request(ctx, "POST", fmt.Sprintf("/workshops/environment/%s/broadcast/", url.PathEscape(environment.GetName())), bytes.NewReader(data))
+
+		if err != nil {
+			return err
+		}
+
+		if status != 200 {
+			return errors.Errorf("unable to broadcast message to workshop %q: %s", workshop, string(body))
+		}
+
+		if o.Clear {
+			fmt.Printf("Message cleared for workshop %s.\n", workshop)
+
+			continue
+		}
+
+		count, err := allocatedSessionsCount(ctx, dynamicClient, environment.GetName())
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Message sent to workshop %s (%d active sessions).\n", workshop, count)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopBroadcastCmd() *cobra.Command {
+	var o ClusterWorkshopBroadcastOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "broadcast [MESSAGE]",
+		Short: "Send message to users of deployed workshops",
+		Long: `Send a message to users of workshops deployed to a training portal.
+
+The message is shown in a banner in the workshop dashboard of all active
+sessions of the workshop given by --name, or of all workshops deployed to the
+training portal if no workshop is given. For example, to let users know about
+a break in a class, use:
+
+  educates cluster workshop broadcast "Break until 2pm"
+
+Users who start a session after the message is sent will also see it, until
+the time given by --duration has passed or a new message is sent. Use a
+duration of 0 for the message to be shown until replaced, or --clear to
+remove the last message without sending a new one.
+
+Workshop sessions can also be configured to warn users before their session
+expires, using the --expiry-warning option when deploying the workshop.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the deployed workshop, all workshops if not given",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().DurationVar(
+		&o.Duration,
+		"duration",
+		time.Hour,
+		"how long the message is shown for, 0 until replaced",
+	)
+	c.Flags().BoolVar(
+		&o.Clear,
+		"clear",
+		false,
+		"remove the last message sent",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
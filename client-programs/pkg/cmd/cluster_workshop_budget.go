@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Resource budgets for session namespaces provided by the platform. Each sets
+a resource quota and limit range on the session namespace. The "custom"
+budget leaves it to the workshop to supply its own.
+*/
+var namespaceBudgets = []string{
+	"small",
+	"medium",
+	"large",
+	"x-large",
+	"xx-large",
+	"xxx-large",
+	"custom",
+}
+
+/*
+Apply a resource budget to the session namespaces of a workshop. Where a file
+of resource quotas and limit ranges is given, the budget is set to "custom"
+and the resources are added to the session objects of the workshop, replacing
+any already defined for the session namespace.
+*/
+func applyNamespaceBudget(workshop *unstructured.Unstructured, budget string, quotaResources []*unstructured.Unstructured) error {
+	if budget == "" && quotaResources == nil {
+		return nil
+	}
+
+	if quotaResources != nil {
+		if budget != "" && budget != "custom" {
+			return failures.NewValidationError(errors.Errorf("namespace budget %q cannot be used with a namespace quota file", budget), "")
+		}
+
+		budget = "custom"
+	}
+
+	if !containsString(namespaceBudgets, budget) {
+		return failures.NewValidationError(errors.Errorf("unknown namespace budget %q", budget), fmt.Sprintf("supported budgets are %s", strings.Join(namespaceBudgets, ", ")))
+	}
+
+	unstructured.SetNestedField(workshop.Object, budget, "spec", "session", "namespaces", "budget")
+
+	if quotaResources == nil {
+		return nil
+	}
+
+	objects, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "session", "objects")
+
+	var retained []interface{}
+
+	for _, item := range objects {
+		if object, ok := item.(map[string]interface{}); ok {
+			resource := unstructured.Unstructured{Object: object}
+
+			if isQuotaResource(&resource) && (resource.GetNamespace() == "" || resource.GetNamespace() == "$(session_namespace)") {
+				continue
+			}
+		}
+
+		retained = append(retained, item)
+	}
+
+	for _, resource := range quotaResources {
+		resource = resource.DeepCopy()
+
+		resource.SetNamespace("$(session_namespace)")
+
+		retained = append(retained, resource.Object)
+	}
+
+	return unstructured.SetNestedSlice(workshop.Object, retained, "spec", "session", "objects")
+}
+
+/*
+Load the resource quotas and limit ranges to apply to session namespaces.
+*/
+func loadNamespaceQuotaResources(file string) ([]*unstructured.Unstructured, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't read namespace quota file %s", file)
+	}
+
+	resources, err := parseResourceDocuments(data, file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resources) == 0 {
+		return nil, failures.NewValidationError(errors.Errorf("no resources found in namespace quota file %s", file), "")
+	}
+
+	for _, resource := range resources {
+		if !isQuotaResource(resource) {
+			return nil, failures.NewValidationError(errors.Errorf("namespace quota file %s contains %s %q", file, resource.GetKind(), resource.GetName()), "only ResourceQuota and LimitRange resources can be given")
+		}
+	}
+
+	return resources, nil
+}
+
+func isQuotaResource(resource *unstructured.Unstructured) bool {
+	gvk := resource.GroupVersionKind()
+
+	return gvk.Group == "" && (gvk.Kind == "ResourceQuota" || gvk.Kind == "LimitRange")
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+type ClusterWorkshopCloneOptions struct {
+	Kubeconfig   string
+	Portal       string
+	Title        string
+	Description  string
+	Capacity     uint
+	Reserved     uint
+	Initial      uint
+	Environ      []string
+	WorkshopOnly bool
+
+	capacitySet bool
+	reservedSet bool
+	initialSet  bool
+}
+
+func (o *ClusterWorkshopCloneOptions) Run(ctx context.Context, source string, name string) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	for _, value := range o.Environ {
+		if !strings.Contains(value, "=") {
+			return failures.NewValidationError(errors.Errorf("invalid environment variable %q", value), "environment variables must be given as name=value")
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	workshopsClient := dynamicClient.Resource(workshopResource)
+
+	workshop, err := workshopsClient.Get(ctx, source, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("no workshop found with name %q", source), "list workshops with `educates cluster workshop list`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query workshop definition in cluster %q", source)
+	}
+
+	_, err = workshopsClient.Get(ctx, name, metav1.GetOptions{})
+
+	if err == nil {
+		return failures.NewValidationError(errors.Errorf("workshop %q already exists in the cluster", name), "choose a different name for the copy of the workshop")
+	} else if !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to query workshop definition in cluster %q", name)
+	}
+
+	// Where the workshop is to be added to the training portal, find the
+	// entry for the original workshop as the copy uses the same settings.
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	var trainingPortal *unstructured.Unstructured
+
+	var entry map[string]interface{}
+
+	if !o.WorkshopOnly {
+		trainingPortal, err = trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			return failures.NewNotFoundError(errors.Errorf("no training portal found with name %q", o.Portal), "use --workshop-only to copy only the workshop definition")
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to query training portal %q in cluster", o.Portal)
+		}
+
+		workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+		for _, item := range workshops {
+			if object, ok := item.(map[string]interface{}); ok {
+				if object["name"] == name {
+					return failures.NewValidationError(errors.Errorf("training portal %q already hosts a workshop named %q", o.Portal, name), "")
+				}
+
+				if object["name"] == source {
+					entry = runtime.DeepCopyJSON(object)
+				}
+			}
+		}
+
+		if entry == nil {
+			return failures.NewNotFoundError(errors.Errorf("workshop %q is not hosted by training portal %q", source, o.Portal), "use --workshop-only to copy only the workshop definition")
+		}
+	}
+
+	// Copy the workshop definition, dropping details which are specific to
+	// the original resource in the cluster.
+
+	clone := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": workshop.GetAPIVersion(),
+		"kind":       workshop.GetKind(),
+		"spec":       runtime.DeepCopyJSONValue(workshop.Object["spec"]),
+	}}
+
+	clone.SetName(name)
+	clone.SetLabels(workshop.GetLabels())
+
+	annotations := workshop.GetAnnotations()
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+
+	annotations["training.educates.dev/cloned-from"] = source
+
+	clone.SetAnnotations(annotations)
+
+	if o.Title != "" {
+		unstructured.SetNestedField(clone.Object, o.Title, "spec", "title")
+	}
+
+	if o.Description != "" {
+		unstructured.SetNestedField(clone.Object, o.Description, "spec", "description")
+	}
+
+	if err = training.UpdateWorkshopResource(ctx, dynamicClient, clone); err != nil {
+		return err
+	}
+
+	if o.WorkshopOnly {
+		fmt.Printf("Copied workshop %s to %s.\n", source, name)
+
+		return nil
+	}
+
+	// Add the entry for the copy to the training portal, applying any
+	// overrides to the settings of the original workshop.
+
+	entry["name"] = name
+
+	if o.capacitySet {
+		if o.Capacity != 0 {
+			entry["capacity"] = int64(o.Capacity)
+		} else {
+			delete(entry, "capacity")
+		}
+	}
+
+	if o.reservedSet {
+		entry["reserved"] = int64(o.Reserved)
+	}
+
+	if o.initialSet {
+		entry["initial"] = int64(o.Initial)
+	}
+
+	if len(o.Environ) != 0 {
+		environ, _, _ := unstructured.NestedSlice(entry, "env")
+
+		for _, value := range o.Environ {
+			parts := strings.SplitN(value, "=", 2)
+
+			var found = false
+
+			for _, item := range environ {
+				if variable, ok := item.(map[string]interface{}); ok && variable["name"] == parts[0] {
+					variable["value"] = parts[1]
+					found = true
+				}
+			}
+
+			if !found {
+				environ = append(environ, map[string]interface{}{"name": parts[0], "value": parts[1]})
+			}
+		}
+
+		entry["env"] = environ
+	}
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	unstructured.SetNestedSlice(trainingPortal.Object, append(workshops, entry), "spec", "workshops")
+
+	_, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal %q in cluster", o.Portal)
+	}
+
+	fmt.Printf("Copied workshop %s to %s in portal %s.\n", source, name, o.Portal)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopCloneCmd() *cobra.Command {
+	var o ClusterWorkshopCloneOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(2),
+		Use:   "clone SOURCE NAME",
+		Short: "Copy deployed workshop under a new name",
+		Long: `Copy deployed workshop under a new name.
+
+Creates a copy of a workshop definition deployed to the cluster under a new
+name, and adds it to the training portal using the same settings as the
+original workshop. This can be used to run variants of a workshop side by
+side, such as to compare changes to the content, or to stage the next
+revision of a workshop alongside the current one. The title and description
+of the copy, and its capacity and environment variables in the training
+portal, can be overridden. Remove the copy with "educates cluster workshop
+delete" when no longer required.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.capacitySet = cmd.Flags().Changed("capacity")
+			o.reservedSet = cmd.Flags().Changed("reserved")
+			o.initialSet = cmd.Flags().Changed("initial")
+
+			return o.Run(cmd.Context(), args[0], args[1])
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return completeWorkshopNames(cmd, args, toComplete)
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Title,
+		"title",
+		"",
+		"title for the copy of the workshop",
+	)
+	c.Flags().StringVar(
+		&o.Description,
+		"description",
+		"",
+		"description for the copy of the workshop",
+	)
+	c.Flags().UintVar(
+		&o.Capacity,
+		"capacity",
+		0,
+		"maximum number of current sessions for the copy of the workshop",
+	)
+	c.Flags().UintVar(
+		&o.Reserved,
+		"reserved",
+		0,
+		"number of backup workshop sessions for the copy of the workshop",
+	)
+	c.Flags().UintVar(
+		&o.Initial,
+		"initial",
+		0,
+		"number of workshop sessions to create for the copy of the workshop",
+	)
+	c.Flags().StringSliceVarP(
+		&o.Environ,
+		"env",
+		"e",
+		[]string{},
+		"environment variable overrides for the copy of the workshop",
+	)
+	c.Flags().BoolVar(
+		&o.WorkshopOnly,
+		"workshop-only",
+		false,
+		"copy only the workshop definition without adding it to the training portal",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -20,11 +20,27 @@ func (p *ProjectInfo) NewClusterWorkshopCmdGroup() *cobra.Command {
 		{
 			Message: "Available Commands:",
 			Commands: []*cobra.Command{
+				p.NewClusterWorkshopValidateClusterCmd(),
 				p.NewClusterWorkshopDeployCmd(),
 				p.NewClusterWorkshopListCmd(),
+				p.NewClusterWorkshopDescribeCmd(),
+				p.NewClusterWorkshopLogsCmd(),
+				p.NewClusterWorkshopResultsCmd(),
+				p.NewClusterWorkshopEnvCmd(),
+				p.NewClusterWorkshopExtensionsCmdGroup(),
+				p.NewClusterWorkshopOpenCmd(),
+				p.NewClusterWorkshopURLCmd(),
+				p.NewClusterWorkshopHandoffCmd(),
 				p.NewClusterWorkshopServeCmd(),
 				p.NewClusterWorkshopRequestCmd(),
 				p.NewClusterWorkshopUpdateCmd(),
+				p.NewClusterWorkshopRefreshCmd(),
+				p.NewClusterWorkshopAutoscaleCmdGroup(),
+				p.NewClusterWorkshopPauseCmd(),
+				p.NewClusterWorkshopResumeCmd(),
+				p.NewClusterWorkshopBroadcastCmd(),
+				p.NewClusterWorkshopCloneCmd(),
+				p.NewClusterWorkshopMoveCmd(),
 				p.NewClusterWorkshopDeleteCmd(),
 			},
 		},
@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/preflight"
+)
+
+/*
+Return the checks for whether a cluster provides everything a workshop needs,
+given the configuration the training platform was installed with. These use
+the same framework as the pre-flight checks run before installing Educates.
+*/
+func workshopCompatibilityChecks(workshop *unstructured.Unstructured, platformConfig *config.TrainingPlatformConfig) []preflight.Check {
+	return []preflight.Check{
+		{
+			Name:         "Ingress",
+			NeedsCluster: true,
+			Run: func(ctx context.Context, env *preflight.Environment) (preflight.Status, string) {
+				return checkWorkshopIngress(ctx, env, workshop, platformConfig)
+			},
+		},
+		{
+			Name:         "Storage",
+			NeedsCluster: true,
+			Run: func(ctx context.Context, env *preflight.Environment) (preflight.Status, string) {
+				return checkWorkshopStorage(ctx, env, workshop, platformConfig)
+			},
+		},
+		{
+			Name:         "Security policy",
+			NeedsCluster: true,
+			Run: func(ctx context.Context, env *preflight.Environment) (preflight.Status, string) {
+				return checkWorkshopSecurity(env, workshop, platformConfig)
+			},
+		},
+		{
+			Name: "Applications",
+			Run: func(ctx context.Context, env *preflight.Environment) (preflight.Status, string) {
+				return checkWorkshopApplications(workshop, platformConfig)
+			},
+		},
+		{
+			Name:         "GPUs",
+			NeedsCluster: true,
+			Run: func(ctx context.Context, env *preflight.Environment) (preflight.Status, string) {
+				return checkWorkshopGPUNodes(ctx, env, workshop)
+			},
+		},
+		{
+			Name:         "Resource types",
+			NeedsCluster: true,
+			Run: func(ctx context.Context, env *preflight.Environment) (preflight.Status, string) {
+				return checkWorkshopResourceTypes(env, workshop)
+			},
+		},
+	}
+}
+
+/*
+Check ingresses can be created for workshop sessions. Sessions always need
+host names under the ingress domain, with any ingress class the platform was
+configured to use needing to exist.
+*/
+func checkWorkshopIngress(ctx context.Context, env *preflight.Environment, workshop *unstructured.Unstructured, platformConfig *config.TrainingPlatformConfig) (preflight.Status, string) {
+	ingress := platformConfig.ClusterIngress
+
+	if ingress.Domain == "" {
+		return preflight.StatusFailed, "no ingress domain configured for the training platform"
+	}
+
+	if ingress.Class != "" {
+		_, err := env.Client.NetworkingV1().IngressClasses().Get(ctx, ingress.Class, metav1.GetOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			return preflight.StatusFailed, fmt.Sprintf("ingress class %q does not exist", ingress.Class)
+		}
+
+		if err != nil {
+			return preflight.StatusFailed, fmt.Sprintf("unable to retrieve ingress class %q: %s", ingress.Class, err)
+		}
+	}
+
+	ingresses, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "session", "ingresses")
+
+	message := fmt.Sprintf("host names under %s", ingress.Domain)
+
+	if len(ingresses) != 0 {
+		message = fmt.Sprintf("%d session ingresses under %s", len(ingresses), ingress.Domain)
+	}
+
+	if ingress.TLSCertificateRef.Name == "" && ingress.TLSCertificate.Certificate == "" && ingress.Protocol != "https" {
+		return preflight.StatusWarning, message + ", but no TLS certificate so sessions use insecure connections"
+	}
+
+	return preflight.StatusPassed, message
+}
+
+/*
+Return the reasons why sessions of a workshop need persistent volumes.
+*/
+func workshopStorageRequirements(workshop *unstructured.Unstructured) []string {
+	var reasons []string
+
+	if size, _, _ := unstructured.NestedString(workshop.Object, "spec", "session", "resources", "storage"); size != "" {
+		reasons = append(reasons, "session storage")
+	}
+
+	if workshopExtensionEnabled(workshop, "docker") {
+		reasons = append(reasons, "docker")
+	}
+
+	if workshopExtensionEnabled(workshop, "registry") {
+		reasons = append(reasons, "image registry")
+	}
+
+	for _, path := range [][]string{{"spec", "environment", "objects"}, {"spec", "session", "objects"}} {
+		objects, _, _ := unstructured.NestedSlice(workshop.Object, path...)
+
+		for _, object := range objects {
+			if item, ok := object.(map[string]interface{}); ok && item["kind"] == "PersistentVolumeClaim" {
+				if className, _, _ := unstructured.NestedString(item, "spec", "storageClassName"); className == "" {
+					reasons = append(reasons, "persistent volume claims")
+
+					break
+				}
+			}
+		}
+	}
+
+	return reasons
+}
+
+/*
+Check persistent volumes can be provisioned where the workshop needs them,
+which requires either the storage class the platform was configured with,
+or a default storage class.
+*/
+func checkWorkshopStorage(ctx context.Context, env *preflight.Environment, workshop *unstructured.Unstructured, platformConfig *config.TrainingPlatformConfig) (preflight.Status, string) {
+	reasons := workshopStorageRequirements(workshop)
+
+	if len(reasons) == 0 {
+		return preflight.StatusPassed, "no persistent volumes required"
+	}
+
+	required := strings.Join(reasons, ", ")
+
+	if class := platformConfig.ClusterStorage.Class; class != "" {
+		_, err := env.Client.StorageV1().StorageClasses().Get(ctx, class, metav1.GetOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			return preflight.StatusFailed, fmt.Sprintf("storage class %q for %s does not exist", class, required)
+		}
+
+		if err != nil {
+			return preflight.StatusFailed, fmt.Sprintf("unable to retrieve storage class %q: %s", class, err)
+		}
+
+		return preflight.StatusPassed, fmt.Sprintf("storage class %q for %s", class, required)
+	}
+
+	classes, err := env.Client.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return preflight.StatusFailed, fmt.Sprintf("unable to list storage classes: %s", err)
+	}
+
+	for _, item := range classes.Items {
+		if item.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" || item.Annotations["storageclass.beta.kubernetes.io/is-default-class"] == "true" {
+			return preflight.StatusPassed, fmt.Sprintf("default storage class %q for %s", item.Name, required)
+		}
+	}
+
+	return preflight.StatusFailed, fmt.Sprintf("no default storage class for %s", required)
+}
+
+/*
+Check the cluster can run workshop sessions with the security policy they
+need. Privileged containers are needed where the session namespaces use the
+privileged policy, or a docker daemon which isn't rootless is enabled, and
+can't be run where workshop pods use a container runtime class, such as for
+a sandboxed runtime.
+*/
+func checkWorkshopSecurity(env *preflight.Environment, workshop *unstructured.Unstructured, platformConfig *config.TrainingPlatformConfig) (preflight.Status, string) {
+	policy, _, _ := unstructured.NestedString(workshop.Object, "spec", "session", "namespaces", "security", "policy")
+
+	if policy == "" {
+		policy = "restricted"
+	}
+
+	var reasons []string
+
+	if policy == "privileged" {
+		reasons = append(reasons, "privileged security policy")
+	}
+
+	if workshopExtensionEnabled(workshop, "docker") && !platformConfig.DockerDaemon.Rootless {
+		reasons = append(reasons, "docker daemon")
+	}
+
+	if len(reasons) == 0 {
+		return preflight.StatusPassed, fmt.Sprintf("%s security policy", policy)
+	}
+
+	required := strings.Join(reasons, ", ")
+
+	if class := platformConfig.ClusterRuntime.Class; class != "" {
+		return preflight.StatusFailed, fmt.Sprintf("privileged containers for %s cannot run with container runtime class %q", required, class)
+	}
+
+	if platformConfig.ClusterSecurity.PolicyEngine == "security-context-constraints" {
+		resources, err := env.Client.Discovery().ServerResourcesForGroupVersion("security.openshift.io/v1")
+
+		found := false
+
+		if err == nil {
+			for _, item := range resources.APIResources {
+				if item.Name == "securitycontextconstraints" {
+					found = true
+				}
+			}
+		}
+
+		if !found {
+			return preflight.StatusFailed, fmt.Sprintf("privileged containers for %s need security context constraints which the cluster does not support", required)
+		}
+	}
+
+	return preflight.StatusPassed, fmt.Sprintf("privileged containers for %s", required)
+}
+
+/*
+Check each of the applications enabled for the workshop can be used with
+how the training platform was installed.
+*/
+func checkWorkshopApplications(workshop *unstructured.Unstructured, platformConfig *config.TrainingPlatformConfig) (preflight.Status, string) {
+	var enabled []string
+	var problems []string
+
+	for _, extension := range workshopExtensions {
+		if !workshopExtensionEnabled(workshop, extension.Name) {
+			continue
+		}
+
+		enabled = append(enabled, extension.Name)
+
+		if extension.Check != nil {
+			if err := extension.Check(platformConfig); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+	}
+
+	if len(problems) != 0 {
+		return preflight.StatusFailed, strings.Join(problems, ", ")
+	}
+
+	if len(enabled) == 0 {
+		return preflight.StatusPassed, "no applications enabled"
+	}
+
+	return preflight.StatusPassed, strings.Join(enabled, ", ")
+}
+
+/*
+Check nodes with GPUs exist where sessions of the workshop need them. As GPU
+node pools are often scaled up on demand this is only a warning.
+*/
+func checkWorkshopGPUNodes(ctx context.Context, env *preflight.Environment, workshop *unstructured.Unstructured) (preflight.Status, string) {
+	count, gpuType := workshopGPUs(workshop)
+
+	if count == 0 {
+		return preflight.StatusPassed, "no GPUs required"
+	}
+
+	nodes, err := env.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return preflight.StatusFailed, fmt.Sprintf("unable to list nodes: %s", err)
+	}
+
+	var largest int64
+
+	for _, node := range nodes.Items {
+		if quantity, found := node.Status.Allocatable[apiv1.ResourceName(gpuType)]; found && quantity.Value() > largest {
+			largest = quantity.Value()
+		}
+	}
+
+	if largest == 0 {
+		return preflight.StatusWarning, fmt.Sprintf("no nodes have allocatable %s", gpuType)
+	}
+
+	if count > largest {
+		return preflight.StatusWarning, fmt.Sprintf("%d of %s needed for each session but no node has more than %d", count, gpuType, largest)
+	}
+
+	return preflight.StatusPassed, fmt.Sprintf("%d of %s for each session", count, gpuType)
+}
+
+/*
+Check the cluster provides the resource types of all objects the workshop
+creates for the workshop environment, sessions and session requests, such
+as custom resources for operators the workshop relies on.
+*/
+func checkWorkshopResourceTypes(env *preflight.Environment, workshop *unstructured.Unstructured) (preflight.Status, string) {
+	types := map[string]map[string]bool{}
+
+	for _, path := range [][]string{{"spec", "environment", "objects"}, {"spec", "session", "objects"}, {"spec", "request", "objects"}} {
+		objects, _, _ := unstructured.NestedSlice(workshop.Object, path...)
+
+		for _, object := range objects {
+			if item, ok := object.(map[string]interface{}); ok {
+				apiVersion, _ := item["apiVersion"].(string)
+				kind, _ := item["kind"].(string)
+
+				if apiVersion == "" || kind == "" {
+					continue
+				}
+
+				if types[apiVersion] == nil {
+					types[apiVersion] = map[string]bool{}
+				}
+
+				types[apiVersion][kind] = true
+			}
+		}
+	}
+
+	if len(types) == 0 {
+		return preflight.StatusPassed, "no resource objects"
+	}
+
+	var missing []string
+
+	total := 0
+
+	for apiVersion, kinds := range types {
+		served := map[string]bool{}
+
+		resources, err := env.Client.Discovery().ServerResourcesForGroupVersion(apiVersion)
+
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return preflight.StatusFailed, fmt.Sprintf("unable to query resource types for %s: %s", apiVersion, err)
+		}
+
+		if err == nil {
+			for _, item := range resources.APIResources {
+				served[item.Kind] = true
+			}
+		}
+
+		for kind := range kinds {
+			total++
+
+			if !served[kind] {
+				missing = append(missing, fmt.Sprintf("%s/%s", apiVersion, kind))
+			}
+		}
+	}
+
+	if len(missing) != 0 {
+		sort.Strings(missing)
+
+		return preflight.StatusFailed, fmt.Sprintf("resource types not provided by the cluster: %s", strings.Join(missing, ", "))
+	}
+
+	return preflight.StatusPassed, fmt.Sprintf("%d resource types provided by the cluster", total)
+}
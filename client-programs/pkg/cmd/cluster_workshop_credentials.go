@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Credentials for downloading workshop content from private Git repositories.
+Either the name of an existing secret can be given, which would usually have
+been added using `educates cluster secrets add`, or an access token or SSH
+private key supplied, in which case a secret is created for the workshop.
+*/
+type GitCredentialsFlags struct {
+	Secret            string
+	Username          string
+	Token             string
+	SSHKeyFile        string
+	SSHKnownHostsFile string
+}
+
+func (f *GitCredentialsFlags) isSet() bool {
+	return f.Secret != "" || f.Token != "" || f.SSHKeyFile != ""
+}
+
+/*
+Ensure the secret holding the Git credentials exists in the secrets namespace
+for Educates, then update the workshop definition so that the secret is copied
+into the workshop namespace and referenced by any Git sources used to download
+workshop content.
+*/
+func injectGitCredentials(ctx context.Context, client *kubernetes.Clientset, workshop *unstructured.Unstructured, credentials GitCredentialsFlags) error {
+	var err error
+
+	if !credentials.isSet() {
+		return nil
+	}
+
+	if credentials.Secret != "" && (credentials.Token != "" || credentials.SSHKeyFile != "") {
+		return failures.NewValidationError(errors.New("git secret cannot be combined with a git token or SSH key"), "")
+	}
+
+	if credentials.Token != "" && credentials.SSHKeyFile != "" {
+		return failures.NewValidationError(errors.New("git token and SSH key cannot both be supplied"), "")
+	}
+
+	secretName := credentials.Secret
+
+	if secretName != "" {
+		// Make sure any secret registered in the local secrets cache has been
+		// copied to the cluster. If the secret isn't in the cache it must
+		// already exist in the cluster.
+
+		err = SyncSecretsToCluster(ctx, client)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = client.CoreV1().Secrets("educates-secrets").Get(ctx, secretName, metav1.GetOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			return failures.NewNotFoundError(errors.Errorf("git secret %q does not exist", secretName), "add the secret with `educates cluster secrets add git`")
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "unable to read secrets from cluster")
+		}
+	} else {
+		secretName = fmt.Sprintf("%s-git-credentials", workshop.GetName())
+
+		var secretType apiv1.SecretType
+		var secretData map[string][]byte
+
+		if credentials.Token != "" {
+			username := credentials.Username
+
+			if username == "" {
+				username = "git"
+			}
+
+			secretType = apiv1.SecretTypeBasicAuth
+
+			secretData = map[string][]byte{
+				"username": []byte(username),
+				"password": []byte(credentials.Token),
+			}
+		} else {
+			privateKey, err := os.ReadFile(credentials.SSHKeyFile)
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to read SSH key file %q", credentials.SSHKeyFile)
+			}
+
+			secretType = apiv1.SecretTypeSSHAuth
+
+			secretData = map[string][]byte{
+				"ssh-privatekey": privateKey,
+			}
+
+			if credentials.SSHKnownHostsFile != "" {
+				knownHosts, err := os.ReadFile(credentials.SSHKnownHostsFile)
+
+				if err != nil {
+					return errors.Wrapf(err, "unable to read SSH known hosts file %q", credentials.SSHKnownHostsFile)
+				}
+
+				secretData["ssh-knownhosts"] = knownHosts
+			}
+		}
+
+		namespacesClient := client.CoreV1().Namespaces()
+
+		_, err = namespacesClient.Get(ctx, "educates-secrets", metav1.GetOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			namespaceObj := apiv1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "educates-secrets",
+				},
+			}
+
+			namespacesClient.Create(ctx, &namespaceObj, metav1.CreateOptions{})
+		}
+
+		patch := applycorev1.Secret(secretName, "educates-secrets").WithType(secretType).WithData(secretData).WithLabels(map[string]string{
+			"training.educates.dev/workshop.name": workshop.GetName(),
+		})
+
+		_, err = client.CoreV1().Secrets("educates-secrets").Apply(ctx, patch, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to update secret in cluster %q", secretName)
+		}
+	}
+
+	return addGitCredentialsReference(workshop, secretName)
+}
+
+/*
+Update the workshop definition so that the named secret in the secrets
+namespace for Educates is copied into the workshop namespace and referenced
+by any Git sources used to download workshop content.
+*/
+func addGitCredentialsReference(workshop *unstructured.Unstructured, secretName string) error {
+	var err error
+
+	// Add the secret to the list of secrets copied into the workshop
+	// namespace if not already present.
+
+	secrets, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "environment", "secrets")
+
+	found := false
+
+	for _, item := range secrets {
+		if object, ok := item.(map[string]interface{}); ok {
+			if object["name"] == secretName && object["namespace"] == "educates-secrets" {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		secrets = append(secrets, map[string]interface{}{
+			"name":      secretName,
+			"namespace": "educates-secrets",
+		})
+
+		err = unstructured.SetNestedSlice(workshop.Object, secrets, "spec", "environment", "secrets")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to add secret to workshop definition")
+		}
+	}
+
+	// Reference the secret from any Git sources used to download workshop
+	// content which don't already have credentials set.
+
+	files, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "workshop", "files")
+
+	updated := 0
+
+	for _, item := range files {
+		if object, ok := item.(map[string]interface{}); ok {
+			if git, ok := object["git"].(map[string]interface{}); ok {
+				if _, exists := git["secretRef"]; !exists {
+					git["secretRef"] = map[string]interface{}{
+						"name": secretName,
+					}
+
+					updated++
+				}
+			}
+		}
+	}
+
+	if updated == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: workshop has no Git sources for content download needing credentials.\n")
+
+		return nil
+	}
+
+	err = unstructured.SetNestedSlice(workshop.Object, files, "spec", "workshop", "files")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to add secret reference to workshop definition")
+	}
+
+	return nil
+}
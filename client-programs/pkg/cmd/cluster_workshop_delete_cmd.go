@@ -6,24 +6,25 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/hooks"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
-	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/client-go/dynamic"
 )
 
 type ClusterWorkshopDeleteOptions struct {
 	Name            string
 	Path            string
 	Kubeconfig      string
+	Fleet           string
 	Portal          string
 	WorkshopFile    string
 	WorkshopVersion string
 	DataValuesFlags yttcmd.DataValuesFlags
 }
 
-func (o *ClusterWorkshopDeleteOptions) Run() error {
+func (o *ClusterWorkshopDeleteOptions) Run(ctx context.Context) error {
 	var err error
 
 	var name = o.Name
@@ -51,30 +52,74 @@ func (o *ClusterWorkshopDeleteOptions) Run() error {
 
 		var workshop *unstructured.Unstructured
 
-		if workshop, err = loadWorkshopDefinition(o.Name, path, o.Portal, o.WorkshopFile, o.WorkshopVersion, o.DataValuesFlags); err != nil {
+		if workshop, err = training.LoadWorkshopDefinition(&training.DefinitionOptions{
+			Name:            o.Name,
+			Path:            path,
+			Portal:          o.Portal,
+			WorkshopFile:    o.WorkshopFile,
+			WorkshopVersion: o.WorkshopVersion,
+			DataValuesFlags: o.DataValuesFlags,
+		}); err != nil {
 			return err
 		}
 
 		name = workshop.GetName()
 	}
 
-	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+	// Apply the change to each cluster in the fleet if one was given,
+	// otherwise to the cluster for the current kubeconfig context.
 
-	dynamicClient, err := clusterConfig.GetDynamicClient()
+	remove := func(clusterConfig *cluster.ClusterConfig) error {
+		dynamicClient, err := clusterConfig.GetDynamicClient()
 
-	if err != nil {
-		return errors.Wrapf(err, "unable to create Kubernetes client")
-	}
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
+
+		// Remove any scheduled changes for the workshop so it isn't added
+		// back to the training portal later.
+
+		err = deleteWorkshopSchedules(ctx, clusterConfig, o.Portal, name)
+
+		if err != nil {
+			return err
+		}
+
+		// Capture the version of the workshop before it is deleted so it
+		// can be passed to any hooks.
+
+		workshopVersion := ""
+
+		if workshop, err := dynamicClient.Resource(workshopResource).Get(ctx, name, metav1.GetOptions{}); err == nil {
+			workshopVersion, _, _ = unstructured.NestedString(workshop.Object, "spec", "version")
+		}
 
-	// Delete the deployed workshop from the Kubernetes cluster.
+		// Delete the deployed workshop from the Kubernetes cluster.
 
-	err = deleteWorkshopResource(dynamicClient, name, o.Portal)
+		err = training.DeleteWorkshopResource(ctx, dynamicClient, name, o.Portal)
 
-	if err != nil {
-		return err
+		if err != nil {
+			return err
+		}
+
+		// Run any hooks registered for workshop deletions.
+
+		runHooks(ctx, dynamicClient, hooks.Event{
+			Type:            hooks.EventWorkshopDelete,
+			Workshop:        name,
+			WorkshopVersion: workshopVersion,
+			Portal:          o.Portal,
+			Context:         clusterConfig.Context,
+		})
+
+		return nil
 	}
 
-	return nil
+	if o.Fleet != "" {
+		return applyToFleet(o.Fleet, o.Kubeconfig, remove)
+	}
+
+	return remove(cluster.NewClusterConfig(o.Kubeconfig))
 }
 
 func (p *ProjectInfo) NewClusterWorkshopDeleteCmd() *cobra.Command {
@@ -84,7 +129,7 @@ func (p *ProjectInfo) NewClusterWorkshopDeleteCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "delete",
 		Short: "Delete workshop from Kubernetes",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVarP(
@@ -107,6 +152,12 @@ func (p *ProjectInfo) NewClusterWorkshopDeleteCmd() *cobra.Command {
 		"",
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
+	c.Flags().StringVar(
+		&o.Fleet,
+		"fleet",
+		"",
+		"name of fleet of clusters to apply the change to instead of a single cluster",
+	)
 	c.Flags().StringVarP(
 		&o.Portal,
 		"portal",
@@ -167,49 +218,9 @@ func (p *ProjectInfo) NewClusterWorkshopDeleteCmd() *cobra.Command {
 		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
 	)
 
-	return c
-}
-
-func deleteWorkshopResource(client dynamic.Interface, name string, portal string) error {
-	trainingPortalClient := client.Resource(trainingPortalResource)
-
-	trainingPortal, err := trainingPortalClient.Get(context.TODO(), portal, metav1.GetOptions{})
-
-	if k8serrors.IsNotFound(err) {
-		return nil
-	}
-
-	workshops, _, err := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
-
-	if err != nil {
-		return errors.Wrap(err, "unable to retrieve workshops from training portal")
-	}
-
-	var found = false
-
-	var updatedWorkshops []interface{}
-
-	for _, item := range workshops {
-		object := item.(map[string]interface{})
-
-		if object["name"] != name {
-			updatedWorkshops = append(updatedWorkshops, object)
-		} else {
-			found = true
-		}
-	}
-
-	if !found {
-		return nil
-	}
-
-	unstructured.SetNestedSlice(trainingPortal.Object, updatedWorkshops, "spec", "workshops")
-
-	_, err = trainingPortalClient.Update(context.TODO(), trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
-
-	if err != nil {
-		return errors.Wrapf(err, "unable to update training portal %q in cluster", portal)
-	}
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("fleet", completeFleetNames)
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
 
-	return nil
+	return withAuditLogging(c)
 }
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Annotations on a workshop definition declaring what must be in place before
+the workshop is deployed. The first lists other workshops, by the name given
+in their workshop definition, whose workshop environments must be running.
+The second gives the location of a file of shared resources, relative to the
+workshop definition file, which are applied to the cluster and must be ready.
+*/
+const (
+	workshopDependsOnAnnotation = "training.educates.dev/depends-on"
+	workshopResourcesAnnotation = "training.educates.dev/resources"
+)
+
+/*
+Return the names of the workshops a workshop depends on.
+*/
+func workshopDependencies(workshop *unstructured.Unstructured) []string {
+	var names []string
+
+	for _, name := range strings.Split(workshop.GetAnnotations()[workshopDependsOnAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+/*
+Resolve the dependencies of each workshop being deployed to the index of the
+workshop it refers to. Dependencies can be given using the name from the
+workshop definition, or the name of the workshop in the cluster. Where a
+dependency isn't one of the workshops being deployed, -1 is used, and the
+workshop is expected to have already been deployed to the training portal.
+*/
+func resolveWorkshopDependencies(workshops []*unstructured.Unstructured) [][]int {
+	index := map[string]int{}
+
+	for i, workshop := range workshops {
+		index[workshop.GetName()] = i
+
+		if name := workshop.GetAnnotations()["training.educates.dev/workshop"]; name != "" {
+			index[name] = i
+		}
+	}
+
+	dependencies := make([][]int, len(workshops))
+
+	for i, workshop := range workshops {
+		for _, name := range workshopDependencies(workshop) {
+			if j, found := index[name]; found {
+				dependencies[i] = append(dependencies[i], j)
+			} else {
+				dependencies[i] = append(dependencies[i], -1)
+			}
+		}
+	}
+
+	return dependencies
+}
+
+/*
+Order the workshops being deployed into stages, such that a workshop is only
+deployed in a stage after those containing the workshops it depends on.
+Workshops within the same stage can be deployed concurrently.
+*/
+func orderWorkshopDeployment(workshops []*unstructured.Unstructured) ([][]int, error) {
+	dependencies := resolveWorkshopDependencies(workshops)
+
+	placed := make([]bool, len(workshops))
+
+	var stages [][]int
+
+	for remaining := len(workshops); remaining != 0; {
+		var stage []int
+
+		for i := range workshops {
+			if placed[i] {
+				continue
+			}
+
+			ready := true
+
+			for _, j := range dependencies[i] {
+				if j == i {
+					return nil, failures.NewValidationError(errors.Errorf("workshop %q depends on itself", workshops[i].GetName()), "")
+				}
+
+				if j != -1 && !placed[j] {
+					ready = false
+				}
+			}
+
+			if ready {
+				stage = append(stage, i)
+			}
+		}
+
+		if len(stage) == 0 {
+			var names []string
+
+			for i := range workshops {
+				if !placed[i] {
+					names = append(names, workshops[i].GetName())
+				}
+			}
+
+			return nil, failures.NewValidationError(errors.Errorf("circular dependency between workshops %s", strings.Join(names, ", ")), "")
+		}
+
+		for _, i := range stage {
+			placed[i] = true
+		}
+
+		remaining -= len(stage)
+
+		stages = append(stages, stage)
+	}
+
+	return stages, nil
+}
+
+/*
+Load the shared resources declared for a workshop. The location is relative
+to where the workshop definition was loaded from, which can be a local file
+or a HTTP/HTTPS URL.
+*/
+func loadWorkshopSharedResources(workshop *unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	location := workshop.GetAnnotations()[workshopResourcesAnnotation]
+
+	if location == "" {
+		return nil, nil
+	}
+
+	source, err := url.Parse(workshop.GetAnnotations()["training.educates.dev/source"])
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse workshop location")
+	}
+
+	var data []byte
+
+	if source.Scheme == "http" || source.Scheme == "https" {
+		reference, err := url.Parse(location)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse location of shared resources %q", location)
+		}
+
+		resourcesURL := source.ResolveReference(reference).String()
+
+		resp, err := http.Get(resourcesURL)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't download shared resources from %s", resourcesURL)
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("failed to download shared resources from %s", resourcesURL)
+		}
+
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, errors.Wrapf(err, "failed to read shared resources from %s", resourcesURL)
+		}
+	} else {
+		path := location
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(source.Path), path)
+		}
+
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, errors.Wrapf(err, "couldn't read shared resources file %s", path)
+		}
+	}
+
+	return parseResourceDocuments(data, location)
+}
+
+/*
+Parse a file of resources, which can contain multiple YAML documents. Empty
+documents are skipped.
+*/
+func parseResourceDocuments(data []byte, location string) ([]*unstructured.Unstructured, error) {
+	documents := yamlutil.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+
+	var objects []*unstructured.Unstructured
+
+	for {
+		document, err := documents.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't parse resources %q", location)
+		}
+
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+
+		object := &unstructured.Unstructured{}
+
+		if err = yaml.Unmarshal(document, &object.Object); err != nil {
+			return nil, errors.Wrapf(err, "couldn't parse resources %q", location)
+		}
+
+		if len(object.Object) == 0 {
+			continue
+		}
+
+		if object.GetKind() == "" || object.GetName() == "" {
+			return nil, errors.Errorf("resource in %q is missing kind or name", location)
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+/*
+Wait for the workshop environment for a workshop in a training portal to be
+running.
+*/
+func waitForWorkshopEnvironment(ctx context.Context, client dynamic.Interface, portal string, workshop string, timeout time.Duration) error {
+	err := cluster.PollImmediate(ctx, 2*time.Second, timeout, func() (bool, error) {
+		environment, err := workshopEnvironmentForPortal(ctx, client, portal, workshop)
+
+		if err != nil || environment == nil {
+			return false, err
+		}
+
+		phase, _, _ := unstructured.NestedString(environment.Object, "status", "educates", "phase")
+
+		return phase == "Running", nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return failures.NewTimeoutError(errors.Errorf("timed out waiting for workshop environment for %q to be running", workshop), "check the status of the workshop with `educates cluster workshop describe`")
+	}
+
+	return err
+}
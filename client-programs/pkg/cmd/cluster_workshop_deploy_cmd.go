@@ -2,44 +2,84 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
-	"math/rand"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/hooks"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/notify"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
-	k8serrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/dynamic"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
 )
 
 type ClusterWorkshopDeployOptions struct {
 	Name            string
 	Path            string
 	Kubeconfig      string
+	Fleet           string
 	Portal          string
+	PortalDefaults  string
+	Hostname        string
+	NamespaceBudget string
+	NamespaceQuota  string
+	NetworkPolicy   string
+	SessionObjects  []string
+	StartAt         string
+	EndAt           string
+	ReserveSchedule string
 	Capacity        uint
+	Fit             bool
 	Reserved        uint
 	Initial         uint
 	Expires         string
 	Overtime        string
 	Deadline        string
 	Orphaned        string
+	OrphanedWarning string
+	IdleExempt      bool
+	ExpiryWarnings  []string
 	Overdue         string
 	Refresh         string
 	Repository      string
+	RewriteImages   bool
 	Environ         []string
+	NodeSelector    []string
+	Tolerations     []string
+	GPUs            uint
+	GPUType         string
+	GPURuntimeClass string
+	VCluster        bool
+	VClusterVersion string
+	VClusterIngress []string
+	SessionStorage  string
+	RetainFiles     bool
+	StorageClass    string
+	CACertificates  []string
 	WorkshopFile    string
 	WorkshopVersion string
 	DataValuesFlags yttcmd.DataValuesFlags
+	GitCredentials  GitCredentialsFlags
+	OutputManifests string
+	Plan            bool
+	Parallel        int
+	Strategy        string
+	WaitTimeout     time.Duration
+	Atomic          bool
 }
 
-func (o *ClusterWorkshopDeployOptions) Run() error {
+func (o *ClusterWorkshopDeployOptions) Run(ctx context.Context) error {
 	var err error
 
 	var path = o.Path
@@ -59,15 +99,744 @@ func (o *ClusterWorkshopDeployOptions) Run() error {
 		path = "."
 	}
 
-	// Load the workshop definition. The path can be a HTTP/HTTPS URL for a
-	// local file system path for a directory or file.
+	// Load the workshop definitions. The path can be a HTTP/HTTPS URL for a
+	// local file system path for a directory or file. A set of workshops can
+	// be deployed at once where the definition file holds more than one, or
+	// the directory contains a directory for each workshop.
 
-	var workshop *unstructured.Unstructured
+	workshops, err := o.loadWorkshops(path)
 
-	if workshop, err = loadWorkshopDefinition(o.Name, path, o.Portal, o.WorkshopFile, o.WorkshopVersion, o.DataValuesFlags); err != nil {
+	if err != nil {
+		return err
+	}
+
+	// Check that names generated for the workshops don't collide with each
+	// other, as can occur when names are derived from workshop titles.
+
+	if o.Name == "" {
+		names := map[string]bool{}
+
+		for _, workshop := range workshops {
+			if names[workshop.GetName()] {
+				return failures.NewValidationError(errors.Errorf("more than one workshop would be deployed with the name %q", workshop.GetName()), "give the workshops distinct titles, or deploy them separately using --name")
+			}
+
+			names[workshop.GetName()] = true
+		}
+	}
+
+	// Images can only be rewritten to pull from a mirror registry if the
+	// image repository for it was given.
+
+	if o.RewriteImages && o.Repository == "" {
+		return failures.NewValidationError(errors.New("rewriting image references requires an image repository"), "supply the mirror registry using --image-repository")
+	}
+
+	// The image used by cron jobs for scheduled changes is also pulled from
+	// the mirror registry when image references are being rewritten.
+
+	var schedulerMirror string
+
+	if o.RewriteImages {
+		schedulerMirror = o.Repository
+	}
+
+	// Apply any resource budget for session namespaces, overriding that
+	// given in the workshop definitions.
+
+	var quotaResources []*unstructured.Unstructured
+
+	if o.NamespaceQuota != "" {
+		if quotaResources, err = loadNamespaceQuotaResources(o.NamespaceQuota); err != nil {
+			return err
+		}
+	}
+
+	// Load any network policies to apply to session namespaces, replacing
+	// those given in the workshop definitions.
+
+	var policyResources []*unstructured.Unstructured
+
+	if o.NetworkPolicy != "" {
+		if policyResources, err = loadNetworkPolicyResources(o.NetworkPolicy); err != nil {
+			return err
+		}
+	}
+
+	// Load any additional resources to be created for each workshop session.
+
+	sessionObjects, err := loadSessionObjects(o.SessionObjects)
+
+	if err != nil {
+		return err
+	}
+
+	// Load any CA certificates to be trusted by workshop sessions, falling
+	// back to those set in the client config if none were given.
+
+	caCertificates := o.CACertificates
+
+	if len(caCertificates) == 0 {
+		clientConfig, err := config.LoadClientConfig()
+
+		if err != nil {
+			return err
+		}
+
+		caCertificates = clientConfig.WorkshopCACertificates
+	}
+
+	caBundle, err := loadCABundle(caCertificates)
+
+	if err != nil {
+		return err
+	}
+
+	for _, workshop := range workshops {
+		if err = applyNamespaceBudget(workshop, o.NamespaceBudget, quotaResources); err != nil {
+			return err
+		}
+
+		if err = applyNetworkPolicies(workshop, policyResources); err != nil {
+			return err
+		}
+
+		if err = applySessionObjects(workshop, sessionObjects); err != nil {
+			return err
+		}
+
+		if err = applyWorkshopGPUs(workshop, o.GPUs, o.GPUType, o.GPURuntimeClass); err != nil {
+			return err
+		}
+
+		if err = applyWorkshopVCluster(workshop, o.VCluster, o.VClusterVersion, o.VClusterIngress); err != nil {
+			return err
+		}
+
+		if err = applySessionStorage(workshop, o.SessionStorage, o.RetainFiles, o.StorageClass); err != nil {
+			return err
+		}
+
+		if err = applyTrustedCertificates(workshop, caBundle); err != nil {
+			return err
+		}
+
+		if o.RewriteImages {
+			rewrites, err := rewriteWorkshopImages(workshop, o.Repository)
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to rewrite image references for workshop %q", workshop.GetName())
+			}
+
+			printImageRewrites(workshop.GetName(), rewrites)
+		}
+	}
+
+	// Check the update strategy is valid before making any changes to the
+	// cluster.
+
+	if !containsString(deploymentStrategies, o.Strategy) {
+		return failures.NewValidationError(errors.Errorf("unsupported update strategy %q", o.Strategy), "strategy must be in-place or blue-green")
+	}
+
+	if o.Strategy == "blue-green" && (o.StartAt != "" || o.EndAt != "") {
+		return failures.NewValidationError(errors.New("a schedule cannot be used with the blue-green update strategy"), "")
+	}
+
+	// Check any schedule for the number of reserved sessions is valid. The
+	// number of reserved sessions initially set is that which the schedule
+	// says should currently apply.
+
+	var reserveSchedule []reserveScheduleEntry
+
+	if o.ReserveSchedule != "" && o.ReserveSchedule != "none" {
+		if reserveSchedule, err = parseReserveSchedule(o.ReserveSchedule); err != nil {
+			return err
+		}
+
+		for _, entry := range reserveSchedule {
+			if o.Capacity != 0 && entry.Reserved > o.Capacity {
+				return failures.NewValidationError(errors.Errorf("reserved sessions %d in reserve schedule exceeds capacity %d", entry.Reserved, o.Capacity), "")
+			}
+		}
+
+		o.Reserved = currentReservedSessions(reserveSchedule, time.Now())
+	}
+
+	// Check the warning period for idle workshop sessions falls within the
+	// inactivity timeout, as otherwise it would never be reported.
+
+	if o.OrphanedWarning != "" {
+		warning, err := time.ParseDuration(o.OrphanedWarning)
+
+		if err != nil {
+			return failures.NewValidationError(errors.Wrapf(err, "invalid orphaned warning period %q", o.OrphanedWarning), "")
+		}
+
+		orphaned, err := time.ParseDuration(o.Orphaned)
+
+		if err != nil || orphaned == 0 || warning >= orphaned {
+			return failures.NewValidationError(errors.Errorf("orphaned warning period %q must be less than the inactivity timeout %q", o.OrphanedWarning, o.Orphaned), "")
+		}
+	}
+
+	// Check the times before expiry at which users are warned their session
+	// is about to expire are valid durations. These are passed to the
+	// training portal in seconds as it doesn't accept mixed units.
+
+	for i, item := range o.ExpiryWarnings {
+		warning, err := time.ParseDuration(item)
+
+		if err != nil || warning < time.Second {
+			return failures.NewValidationError(errors.Errorf("invalid expiry warning time %q", item), "give the time before expiry as a duration such as 10m")
+		}
+
+		o.ExpiryWarnings[i] = fmt.Sprintf("%ds", int64(warning.Seconds()))
+	}
+
+	// Check any scheduling constraints for workshop sessions are valid before
+	// making any changes to the cluster.
+
+	if _, err = training.WorkshopScheduling(o.NodeSelector, o.Tolerations); err != nil {
+		return err
+	}
+
+	// Where a custom hostname is given for the training portal, check it is
+	// covered by the wildcard ingress domain for the cluster.
+
+	if o.Hostname != "" {
+		if o.Hostname, err = resolvePortalHostname(o.Hostname); err != nil {
+			return err
+		}
+	}
+
+	// Load the defaults to use if the training portal needs to be created.
+
+	portalDefaults, err := config.NewPortalDefaultsConfigFromFile(o.PortalDefaults)
+
+	if err != nil {
 		return err
 	}
 
+	// If asked to output manifests, write out the resources the deploy would
+	// create rather than applying them to the cluster.
+
+	if o.OutputManifests != "" {
+		if o.StartAt != "" || o.EndAt != "" || o.ReserveSchedule != "" {
+			return failures.NewValidationError(errors.New("a schedule cannot be used when outputting manifests"), "")
+		}
+
+		for _, workshop := range workshops {
+			if err = o.writeManifests(workshop, portalDefaults); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// If asked for a plan, output the changes the deploy would make rather
+	// than applying them to the cluster.
+
+	if o.Plan {
+		if len(workshops) != 1 {
+			return failures.NewValidationError(errors.New("a plan can only be output when deploying a single workshop"), "")
+		}
+
+		if o.StartAt != "" || o.EndAt != "" || o.ReserveSchedule != "" {
+			return failures.NewValidationError(errors.New("a schedule cannot be used when outputting a plan"), "")
+		}
+
+		return o.writePlan(ctx, workshops[0], portalDefaults)
+	}
+
+	// Work out when the workshops are to be added to and removed from the
+	// training portal if a schedule is given.
+
+	var startAt, endAt time.Time
+
+	if o.StartAt != "" {
+		if startAt, err = parseScheduleTime(o.StartAt); err != nil {
+			return err
+		}
+
+		if !startAt.After(time.Now()) {
+			return failures.NewValidationError(errors.Errorf("start time %s is not in the future", startAt.Format(time.RFC3339)), "")
+		}
+
+		for _, workshop := range workshops {
+			if len(workshopDependencies(workshop)) != 0 {
+				return failures.NewValidationError(errors.Errorf("workshop %q declares dependencies so cannot be scheduled", workshop.GetName()), "")
+			}
+		}
+	}
+
+	if o.EndAt != "" {
+		if endAt, err = parseScheduleTime(o.EndAt); err != nil {
+			return err
+		}
+
+		if !endAt.After(time.Now()) || (!startAt.IsZero() && !endAt.After(startAt)) {
+			return failures.NewValidationError(errors.Errorf("end time %s is not in the future or is before the start time", endAt.Format(time.RFC3339)), "")
+		}
+	}
+
+	// Work out the order in which workshops need to be deployed where any
+	// declare dependencies on other workshops.
+
+	stages, err := orderWorkshopDeployment(workshops)
+
+	if err != nil {
+		return err
+	}
+
+	// Apply the change to each cluster in the fleet if one was given,
+	// otherwise to the cluster for the current kubeconfig context. Each
+	// cluster gets its own copy of the workshop definitions as injecting
+	// credentials modifies them. Workshops in the same stage are applied
+	// concurrently, but updates to the training portal are made one at a
+	// time as each must see the result of the previous one.
+
+	deploy := func(clusterConfig *cluster.ClusterConfig) error {
+		dynamicClient, err := clusterConfig.GetDynamicClient()
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
+
+		var client *kubernetes.Clientset
+
+		if o.GitCredentials.isSet() || o.GPUs != 0 || o.Strategy == "blue-green" {
+			client, err = clusterConfig.GetClient()
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to create Kubernetes client")
+			}
+		}
+
+		if o.VCluster {
+			if err = checkVClusterSupported(ctx, clusterConfig); err != nil {
+				return err
+			}
+		}
+
+		var portalLock sync.Mutex
+
+		// Keep track of the changes made to the cluster so they can be
+		// reported, or undone, if the deploy doesn't complete.
+
+		changes := &deployChanges{}
+
+		dependencies := resolveWorkshopDependencies(workshops)
+
+		errs := make([]error, len(workshops))
+
+		deployWorkshop := func(i int) error {
+			workshop := workshops[i].DeepCopy()
+
+			// Check a generated name isn't already used by a different
+			// workshop before making any changes.
+
+			if o.Name == "" {
+				if err := training.CheckWorkshopNameAvailable(ctx, dynamicClient, workshop); err != nil {
+					return err
+				}
+			}
+
+			// Wait for the workshop environments of any workshops this one
+			// depends on to be running.
+
+			for j, name := range workshopDependencies(workshop) {
+				if k := dependencies[i][j]; k != -1 {
+					if errs[k] != nil {
+						return errors.Errorf("dependency %q failed to deploy", name)
+					}
+
+					name = workshops[k].GetName()
+				}
+
+				if err := waitForWorkshopEnvironment(ctx, dynamicClient, o.Portal, name, o.WaitTimeout); err != nil {
+					return errors.Wrapf(err, "dependency %q is not ready", name)
+				}
+			}
+
+			// Apply any shared resources the workshop requires and wait for
+			// them to be ready.
+
+			sharedResources, err := loadWorkshopSharedResources(workshop)
+
+			if err != nil {
+				return err
+			}
+
+			if len(sharedResources) != 0 {
+				changes.record(fmt.Sprintf("applied shared resources for workshop %s", workshop.GetName()), nil)
+
+				if err = clusterConfig.ApplyResources(ctx, sharedResources, "educates-cli"); err != nil {
+					return err
+				}
+
+				if err = clusterConfig.WaitForResourcesReady(ctx, sharedResources, o.WaitTimeout); err != nil {
+					return err
+				}
+			}
+
+			// Check the cluster has enough GPUs for the capacity of the
+			// workshop if it requires them.
+
+			if o.GPUs != 0 {
+				if err = checkWorkshopGPUCapacity(ctx, client, workshop, o.Capacity, o.NodeSelector); err != nil {
+					return err
+				}
+			}
+
+			// Inject any credentials required for downloading workshop content.
+
+			if o.GitCredentials.isSet() {
+				err := injectGitCredentials(ctx, client, workshop, o.GitCredentials)
+
+				if err != nil {
+					return err
+				}
+			}
+
+			// When using the blue-green update strategy and the workshop is
+			// already deployed, check a session can be started using the
+			// updated workshop definition before making any changes.
+
+			var previousEnvironment *unstructured.Unstructured
+
+			if o.Strategy == "blue-green" {
+				if previousEnvironment, err = workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, workshop.GetName()); err != nil {
+					return err
+				}
+
+				if previousEnvironment != nil {
+					changed, err := workshopDefinitionChanged(ctx, dynamicClient, workshop, previousEnvironment)
+
+					if err != nil {
+						return err
+					}
+
+					if !changed {
+						previousEnvironment = nil
+					} else if err = runWorkshopCanary(ctx, client, dynamicClient, workshop, o.WaitTimeout); err != nil {
+						return err
+					}
+				}
+			}
+
+			// Update the workshop resource in the Kubernetes cluster.
+
+			if err = changes.saveResource(ctx, dynamicClient, workshopResource, "workshop", workshop.GetName()); err != nil {
+				return err
+			}
+
+			err = training.UpdateWorkshopResource(ctx, dynamicClient, workshop)
+
+			if err != nil {
+				return err
+			}
+
+			// Update the training portal, creating it if necessary. Where
+			// the workshop is to be added at a later time, the entry for it
+			// is instead scheduled to be added.
+
+			if startAt.IsZero() {
+				if err = changes.saveResource(ctx, dynamicClient, trainingPortalResource, "training portal", o.Portal); err != nil {
+					return err
+				}
+			} else {
+				changes.record(fmt.Sprintf("scheduled workshop %s to be added to training portal %s", workshop.GetName(), o.Portal), nil)
+			}
+
+			portalLock.Lock()
+
+			if startAt.IsZero() {
+				err = training.DeployWorkshopResource(ctx, dynamicClient, workshop, o.Portal, portalDefaults, o.Hostname, o.workshopSettings())
+			} else {
+				var trainingPortal *unstructured.Unstructured
+				var trainingPortalExists bool
+
+				trainingPortal, trainingPortalExists, err = training.PrepareTrainingPortal(ctx, dynamicClient, workshop, o.Portal, portalDefaults, o.Hostname, o.workshopSettings())
+
+				if err == nil {
+					err = deployScheduledWorkshopResource(ctx, clusterConfig, schedulerMirror, trainingPortal, trainingPortalExists, workshop.GetName(), startAt)
+				}
+			}
+
+			portalLock.Unlock()
+
+			if err != nil {
+				return err
+			}
+
+			// Switch the training portal over to a new workshop environment
+			// for the updated workshop definition.
+
+			if previousEnvironment != nil {
+				changes.record(fmt.Sprintf("switched workshop %s to a new workshop environment", workshop.GetName()), nil)
+
+				if err = switchWorkshopEnvironment(ctx, dynamicClient, o.Portal, workshop.GetName(), previousEnvironment.GetName(), o.WaitTimeout); err != nil {
+					return err
+				}
+			}
+
+			if !endAt.IsZero() {
+				schedulerImage, err := prepareScheduler(ctx, clusterConfig, schedulerMirror)
+
+				if err != nil {
+					return err
+				}
+
+				changes.record(fmt.Sprintf("scheduled workshop %s to be removed from training portal %s", workshop.GetName(), o.Portal), nil)
+
+				if err = scheduleWorkshopEnd(ctx, clusterConfig, schedulerImage, o.Portal, workshop.GetName(), endAt); err != nil {
+					return err
+				}
+			}
+
+			if o.ReserveSchedule == "none" {
+				changes.record(fmt.Sprintf("deleted schedule of reserved sessions for workshop %s", workshop.GetName()), nil)
+
+				if err = deleteReserveSchedule(ctx, clusterConfig, o.Portal, workshop.GetName()); err != nil {
+					return err
+				}
+			} else if len(reserveSchedule) != 0 {
+				schedulerImage, err := prepareScheduler(ctx, clusterConfig, schedulerMirror)
+
+				if err != nil {
+					return err
+				}
+
+				changes.record(fmt.Sprintf("scheduled reserved sessions for workshop %s", workshop.GetName()), nil)
+
+				if err = scheduleReservedSessions(ctx, clusterConfig, schedulerImage, o.Portal, workshop.GetName(), reserveSchedule); err != nil {
+					return err
+				}
+			}
+
+			// Notify any configured webhook of the deployment.
+
+			target := fmt.Sprintf("training portal %s", o.Portal)
+
+			if clusterConfig.Context != "" {
+				target = fmt.Sprintf("%s in cluster %s", target, clusterConfig.Context)
+			}
+
+			message := fmt.Sprintf("Workshop %s deployed to %s.", workshop.GetName(), target)
+
+			if !startAt.IsZero() {
+				message = fmt.Sprintf("Workshop %s scheduled to be added to %s at %s.", workshop.GetName(), target, startAt.Format(time.RFC3339))
+			}
+
+			sendNotification(notify.Event{
+				Type:     notify.EventDeploy,
+				Portal:   o.Portal,
+				Workshop: workshop.GetName(),
+				Message:  message,
+			})
+
+			// Run any hooks registered for workshop deployments.
+
+			workshopVersion, _, _ := unstructured.NestedString(workshop.Object, "spec", "version")
+
+			runHooks(ctx, dynamicClient, hooks.Event{
+				Type:            hooks.EventWorkshopDeploy,
+				Workshop:        workshop.GetName(),
+				WorkshopVersion: workshopVersion,
+				Portal:          o.Portal,
+				Context:         clusterConfig.Context,
+			})
+
+			return nil
+		}
+
+		for _, stage := range stages {
+			stageErrs := runParallel(len(stage), o.Parallel, func(j int) error {
+				return deployWorkshop(stage[j])
+			})
+
+			for j, err := range stageErrs {
+				errs[stage[j]] = err
+			}
+		}
+
+		var deployErr error
+
+		if len(workshops) == 1 {
+			deployErr = errs[0]
+		} else {
+			failed := 0
+
+			for i, err := range errs {
+				if err != nil {
+					failed++
+
+					fmt.Fprintf(os.Stderr, "%s: failed: %s\n", workshops[i].GetName(), err)
+				} else {
+					fmt.Printf("%s: deployed\n", workshops[i].GetName())
+				}
+			}
+
+			if failed != 0 {
+				deployErr = errors.Errorf("failed to deploy %d of %d workshops", failed, len(workshops))
+			}
+		}
+
+		if deployErr == nil {
+			return nil
+		}
+
+		// Report what had been changed in the cluster when the deploy didn't
+		// complete, or if asked to, undo the changes.
+
+		if o.Atomic {
+			if rollbackErr := changes.rollback(os.Stderr); rollbackErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s.\n", rollbackErr)
+			}
+		} else {
+			changes.report(os.Stderr)
+		}
+
+		if ctx.Err() != nil {
+			hint := ""
+
+			if !o.Atomic {
+				hint = "deploy again to complete the changes, or use --atomic to have them undone"
+			}
+
+			return failures.NewInterruptedError(ctx.Err(), hint)
+		}
+
+		return deployErr
+	}
+
+	if o.Fleet != "" {
+		return applyToFleet(o.Fleet, o.Kubeconfig, deploy)
+	}
+
+	return deploy(cluster.NewClusterConfig(o.Kubeconfig))
+}
+
+/*
+Load the workshop definitions to be deployed. Where the path is a local
+directory which doesn't itself contain the workshop definition file, each
+sub directory which does is treated as a separate workshop. Definitions are
+processed concurrently as this can involve downloads and templating.
+*/
+func (o *ClusterWorkshopDeployOptions) loadWorkshops(path string) ([]*unstructured.Unstructured, error) {
+	var paths []string
+
+	if urlInfo, err := url.Parse(path); err == nil && urlInfo.Scheme != "http" && urlInfo.Scheme != "https" && !filepath.IsAbs(o.WorkshopFile) {
+		if fileInfo, err := os.Stat(path); err == nil && fileInfo.IsDir() {
+			if _, err := os.Stat(filepath.Join(path, o.WorkshopFile)); os.IsNotExist(err) {
+				entries, err := os.ReadDir(path)
+
+				if err != nil {
+					return nil, errors.Wrap(err, "unable to read workshop directory")
+				}
+
+				for _, entry := range entries {
+					if _, err := os.Stat(filepath.Join(path, entry.Name(), o.WorkshopFile)); err == nil && entry.IsDir() {
+						paths = append(paths, filepath.Join(path, entry.Name()))
+					}
+				}
+			}
+		}
+	}
+
+	if len(paths) == 0 {
+		return training.LoadWorkshopDefinitions(&training.DefinitionOptions{
+			Name:            o.Name,
+			Path:            path,
+			Portal:          o.Portal,
+			WorkshopFile:    o.WorkshopFile,
+			WorkshopVersion: o.WorkshopVersion,
+			DataValuesFlags: o.DataValuesFlags,
+		})
+	}
+
+	if o.Name != "" && len(paths) > 1 {
+		return nil, failures.NewValidationError(errors.New("name cannot be supplied when there are multiple workshop definitions"), "")
+	}
+
+	loaded := make([][]*unstructured.Unstructured, len(paths))
+
+	errs := runParallel(len(paths), o.Parallel, func(i int) error {
+		workshops, err := training.LoadWorkshopDefinitions(&training.DefinitionOptions{
+			Name:            o.Name,
+			Path:            paths[i],
+			Portal:          o.Portal,
+			WorkshopFile:    o.WorkshopFile,
+			WorkshopVersion: o.WorkshopVersion,
+			DataValuesFlags: o.DataValuesFlags,
+		})
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to load workshop from %q", paths[i])
+		}
+
+		loaded[i] = workshops
+
+		return nil
+	})
+
+	var workshops []*unstructured.Unstructured
+
+	for i := range paths {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		workshops = append(workshops, loaded[i]...)
+	}
+
+	return workshops, nil
+}
+
+/*
+Call a function for each of a number of items, with no more than the limit
+of calls in progress at the one time. The errors returned by the calls are
+returned in the order of the items.
+*/
+func runParallel(count int, limit int, fn func(int) error) []error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	errs := make([]error, count)
+
+	semaphore := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			errs[i] = fn(i)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+/*
+Output a plan of the changes deploying the workshop would make to the cluster.
+*/
+func (o *ClusterWorkshopDeployOptions) writePlan(ctx context.Context, workshop *unstructured.Unstructured, portalDefaults *config.PortalDefaultsConfig) error {
+	if o.Fleet != "" {
+		return failures.NewValidationError(errors.New("fleet cannot be used when outputting a plan"), "")
+	}
+
 	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 	dynamicClient, err := clusterConfig.GetDynamicClient()
@@ -76,23 +845,63 @@ func (o *ClusterWorkshopDeployOptions) Run() error {
 		return errors.Wrapf(err, "unable to create Kubernetes client")
 	}
 
-	// Update the workshop resource in the Kubernetes cluster.
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	changes, err := planGitCredentials(ctx, client, workshop, o.GitCredentials)
+
+	if err != nil {
+		return err
+	}
 
-	err = updateWorkshopResource(dynamicClient, workshop)
+	workshopChange, err := planWorkshopResource(ctx, dynamicClient, workshop)
 
 	if err != nil {
 		return err
 	}
 
-	// Update the training portal, creating it if necessary.
+	changes = append(changes, workshopChange)
 
-	err = deployWorkshopResource(dynamicClient, workshop, o.Portal, o.Capacity, o.Reserved, o.Initial, o.Expires, o.Overtime, o.Deadline, o.Orphaned, o.Overdue, o.Refresh, o.Repository, o.Environ)
+	trainingPortalChange, err := planTrainingPortal(ctx, dynamicClient, workshop, o.Portal, portalDefaults, o.Hostname, o.workshopSettings())
 
 	if err != nil {
 		return err
 	}
 
-	return nil
+	changes = append(changes, trainingPortalChange)
+
+	return printChangePlan(changes)
+}
+
+/*
+Return the settings for the workshop entry in the training portal.
+*/
+func (o *ClusterWorkshopDeployOptions) workshopSettings() *training.WorkshopSettings {
+	return &training.WorkshopSettings{
+		Capacity: o.Capacity,
+		Fit:      o.Fit,
+		Reserved: o.Reserved,
+		Initial:  o.Initial,
+		Expires:  o.Expires,
+		Overtime: o.Overtime,
+		Deadline: o.Deadline,
+		Orphaned: o.Orphaned,
+		Overdue:  o.Overdue,
+		Refresh:  o.Refresh,
+		Registry: o.Repository,
+		Environ:  o.Environ,
+
+		OrphanedWarning: o.OrphanedWarning,
+		IdleExempt:      o.IdleExempt,
+
+		ExpiryWarnings: o.ExpiryWarnings,
+
+		NodeSelector: o.NodeSelector,
+		Tolerations:  o.Tolerations,
+	}
 }
 
 func (p *ProjectInfo) NewClusterWorkshopDeployCmd() *cobra.Command {
@@ -102,7 +911,154 @@ func (p *ProjectInfo) NewClusterWorkshopDeployCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "deploy",
 		Short: "Deploy workshop to Kubernetes",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Long: `Deploy workshop to Kubernetes.
+
+When no name is supplied, the name for the workshop in the cluster is
+generated from the name of the training portal, the name in the workshop
+definition and a hash of where it was loaded from. To instead derive the name
+from the title of the workshop, so it stays the same when the workshop is
+loaded from a different location, set the "training.educates.dev/naming"
+annotation in the workshop definition to "title". Generated names are
+truncated where necessary to fit the limit of 63 characters, and the deploy
+fails if a generated name is already used by a different workshop.
+
+Where multiple workshops are deployed together, such as the parts of a
+course, a workshop definition can declare that it depends on other workshops
+using the "training.educates.dev/depends-on" annotation, giving a comma
+separated list of workshop names. The workshop will only be deployed once the
+workshop environments for those workshops are running. A workshop definition
+can also declare shared resources it requires, such as a common namespace or
+custom resource definitions, using the "training.educates.dev/resources"
+annotation, giving the location of a file of resources relative to the
+workshop definition file. These are applied to the cluster before the
+workshop is deployed, with the deployment waiting until they are ready.
+
+To stop the sessions of one workshop using up the resources of the cluster
+needed by others, a resource budget can be set for the session namespaces of
+the workshops being deployed. This can be one of the sizes provided by the
+platform, or a custom resource quota and limit range given in a file.
+
+Traffic between session namespaces can be locked down using --network-policy.
+The "strict" profile only allows traffic into a session namespace from pods
+in the same namespace, and from namespaces which aren't session namespaces,
+such as that of the ingress controller. The "open" profile removes any
+network policies for session namespaces from the workshop definition.
+Otherwise give the path to a file of NetworkPolicy resources to apply.
+
+Additional resources can be created for each workshop session, such as role
+bindings or secrets required by an organization, by giving a file of
+resources using --session-objects. These are added to the session objects of
+the workshop definition and can use the same data variables, such as
+$(session_namespace).
+
+Workshop sessions can be pinned to a specific pool of nodes, such as nodes
+with GPUs or spot instances, using --node-selector to give labels the nodes
+must have, and --toleration to allow sessions to run on nodes with matching
+taints. A toleration without a value matches any value for the taint key.
+
+For workshops such as AI/ML workshops which need GPUs, use --gpus to set the
+number of GPUs allocated to each session. Use --gpu-type where the GPUs are
+not exposed by nodes as "nvidia.com/gpu", and --gpu-runtime-class where the
+GPU operator requires a runtime class. A warning is given when the nodes of
+the cluster don't have enough allocatable GPUs for the capacity of the
+workshop.
+
+Workshops which require cluster admin access, such as for teaching how to
+administer Kubernetes, can be run safely on a shared cluster by using
+--vcluster to give each session its own virtual cluster. Users then only have
+access to the virtual cluster and not to the session namespace in the
+underlying cluster. Use --vcluster-ingress-subdomain to allow ingresses
+created in the virtual cluster to be exposed for hosts in a subdomain.
+
+Use --session-storage to give each session a persistent volume of the given
+size for the home directory of the workshop user. The volume is deleted when
+the session ends, unless --retain-session-files is also given, in which case
+a single volume is shared by the sessions of the workshop environment, which
+needs to support ReadWriteMany access, and files can be exported after the
+session has ended using "educates cluster session export-files".
+
+Use --ca-certificate to give PEM files holding the CA certificates of an
+organization, such as for internally signed image registries and Git
+servers, to be added to the trust store of workshop sessions, including the
+download of workshop content and any docker daemon. Where not given, files
+listed under "workshopCACertificates" in the client config file are used.
+
+For disconnected clusters, images can be pulled from a mirror registry by
+giving the mirror using --image-repository and adding --rewrite-images. All
+image references in the workshop definition, including the workshop base
+image, OCI images for workshop content, containers in environment and session
+objects, and docker compose services, are rewritten to the mirror, keeping
+the repository path of the image. A report of the references rewritten is
+output. Short names for workshop base images, such as "base-environment:*",
+are resolved using the image versions of the platform so are not rewritten.
+
+Workshop definitions are processed as ytt templates. Settings from the local
+platform configuration are available to templates as the data values
+"ingressDomain", "ingressClass", "ingressProtocol", "ingressSecret",
+"registryHost" and "registryNamespace", and can be overridden using the data
+value options.
+
+Workshop sessions are terminated if left inactive for the time given by
+--orphaned, with activity detected from the browser window for the session
+being open. Use --orphaned-warning to have the training portal report a
+"Session/Idle" analytics event when that time is close, so users can be
+warned before their session is lost. Workshops where sessions are expected to
+be left unattended for long periods, such as capture the flag exercises, can
+be exempted from termination due to inactivity using --idle-exempt, which
+also overrides any default inactivity timeout set for the training portal.
+
+Where workshop sessions have a time limit, users can be warned in the
+workshop dashboard that their session is about to expire using
+--expiry-warning, with the time before expiry the warning is shown, such as
+"--expiry-warning 10m --expiry-warning 2m" to warn 10 minutes and 2 minutes
+before the session expires. Instructors can also send a message to all users
+of a workshop using "educates cluster workshop broadcast".
+
+By default a workshop which is already deployed is updated in place, with
+the training portal only replacing the workshop environment if configured to
+do so. Use --strategy blue-green to avoid a bad update breaking a class which
+is in progress. A canary session is first started using the updated workshop
+definition, in a separate workshop environment outside of the training
+portal. Only if the canary session starts is the workshop updated, with the
+training portal switched over to a new workshop environment. Sessions in the
+existing workshop environment are left running until they end.
+
+Workshops can be scheduled to be added to the training portal at a later
+time, such as just before a class, and removed again afterwards. Times are
+given in local time in the form 2006-01-02T15:04, or as a RFC 3339 time. The
+changes are made by cron jobs created in the "educates-schedules" namespace
+of the cluster, so the CLI doesn't need to be run at the time. The cron jobs
+use the workshop base image of the installed platform, taken from its image
+registry settings or the package repository it was installed from, and when
+--rewrite-images is given, this is also pulled from the mirror registry.
+Removing the workshop with "educates cluster workshop delete" also removes
+any scheduled changes for it.
+
+The number of reserved sessions can also be varied over the week, so that
+sessions are kept warm during class hours and none are reserved overnight,
+using --reserve-schedule with entries of the form "Mon 08:00=20" separated
+by commas, in local time. The number of reserved sessions is initially set
+from the entry most recently passed. Deploying again with a new schedule
+replaces the existing schedule, and "--reserve-schedule none" removes it.
+
+Hooks registered under "hooks" in the client config file are run after each
+workshop is deployed or deleted, so custom automation such as cache warmers
+or announcements can be chained on. A hook gives either a "command", run
+using the shell, or a "url" for a webhook, and optionally the "events" it
+applies to, being "workshop-deploy" or "workshop-delete". Commands are passed
+details of the change in the environment variables EDUCATES_HOOK_EVENT,
+EDUCATES_WORKSHOP_NAME, EDUCATES_WORKSHOP_VERSION, EDUCATES_PORTAL_NAME,
+EDUCATES_PORTAL_URL and EDUCATES_CLUSTER_CONTEXT, while webhooks are sent
+them as a JSON payload. A hook failing is reported as a warning.
+
+If the deploy fails or is interrupted part way through, such as by Ctrl-C,
+requests in progress are cancelled and the changes already made to the
+cluster are listed. Use --atomic to instead undo those changes, restoring
+workshop definitions and the training portal to how they were before the
+deploy, or deleting them if they were created by it. Shared resources,
+schedules and switches to a new workshop environment aren't undone, but are
+reported. Interrupting a second time exits immediately.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVarP(
@@ -125,6 +1081,12 @@ func (p *ProjectInfo) NewClusterWorkshopDeployCmd() *cobra.Command {
 		"",
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
+	c.Flags().StringVar(
+		&o.Fleet,
+		"fleet",
+		"",
+		"name of fleet of clusters to apply the change to instead of a single cluster",
+	)
 	c.Flags().StringVarP(
 		&o.Portal,
 		"portal",
@@ -132,12 +1094,72 @@ func (p *ProjectInfo) NewClusterWorkshopDeployCmd() *cobra.Command {
 		"educates-cli",
 		"name to be used for training portal and workshop name prefixes",
 	)
+	c.Flags().StringVar(
+		&o.PortalDefaults,
+		"portal-defaults",
+		"",
+		"path to file with settings to use if the training portal needs to be created, instead of the local portal defaults config",
+	)
+	c.Flags().StringVar(
+		&o.Hostname,
+		"hostname",
+		"",
+		"hostname prefix, or full hostname under the ingress domain, for accessing the training portal",
+	)
+	c.Flags().StringVar(
+		&o.NamespaceBudget,
+		"namespace-budget",
+		"",
+		"resource budget for session namespaces, overriding that of the workshop definition",
+	)
+	c.Flags().StringVar(
+		&o.NamespaceQuota,
+		"namespace-quota",
+		"",
+		"path to file with ResourceQuota and LimitRange resources to apply to session namespaces",
+	)
+	c.Flags().StringVar(
+		&o.NetworkPolicy,
+		"network-policy",
+		"",
+		"network policy profile for session namespaces (strict or open), or path to file with NetworkPolicy resources",
+	)
+	c.Flags().StringArrayVar(
+		&o.SessionObjects,
+		"session-objects",
+		[]string{},
+		"path to file with additional resources to create for each workshop session (can be specified multiple times)",
+	)
+	c.Flags().StringVar(
+		&o.StartAt,
+		"start-at",
+		"",
+		"time at which to add the workshop to the training portal, instead of immediately",
+	)
+	c.Flags().StringVar(
+		&o.EndAt,
+		"end-at",
+		"",
+		"time at which to remove the workshop from the training portal",
+	)
+	c.Flags().StringVar(
+		&o.ReserveSchedule,
+		"reserve-schedule",
+		"",
+		"weekly schedule for the number of reserved sessions, e.g. \"Mon 08:00=20,Mon 18:00=0\"",
+	)
 	c.Flags().UintVar(
 		&o.Capacity,
 		"capacity",
 		1,
 		"maximum number of current sessions for the workshop",
 	)
+	c.Flags().BoolVar(
+		&o.Fit,
+		"fit",
+		false,
+		"increase maximum sessions for the training portal to fit the capacity of the workshop",
+	)
 	c.Flags().UintVar(
 		&o.Reserved,
 		"reserved",
@@ -174,6 +1196,24 @@ func (p *ProjectInfo) NewClusterWorkshopDeployCmd() *cobra.Command {
 		"5m",
 		"allowed inactive time before workshop is terminated",
 	)
+	c.Flags().StringVar(
+		&o.OrphanedWarning,
+		"orphaned-warning",
+		"",
+		"time before inactive workshop is terminated that a warning event is reported",
+	)
+	c.Flags().BoolVar(
+		&o.IdleExempt,
+		"idle-exempt",
+		false,
+		"never terminate workshop sessions because they are inactive",
+	)
+	c.Flags().StringSliceVar(
+		&o.ExpiryWarnings,
+		"expiry-warning",
+		[]string{},
+		"time before workshop expires that users are warned (can be specified multiple times)",
+	)
 	c.Flags().StringVar(
 		&o.Overdue,
 		"overdue",
@@ -193,6 +1233,78 @@ func (p *ProjectInfo) NewClusterWorkshopDeployCmd() *cobra.Command {
 		[]string{},
 		"environment variable overrides for workshop",
 	)
+	c.Flags().StringArrayVar(
+		&o.NodeSelector,
+		"node-selector",
+		[]string{},
+		"node label key=value which nodes running workshop sessions must have (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.Tolerations,
+		"toleration",
+		[]string{},
+		"node taint key[=value][:effect] which workshop sessions tolerate (can be specified multiple times)",
+	)
+	c.Flags().UintVar(
+		&o.GPUs,
+		"gpus",
+		0,
+		"number of GPUs to allocate to each workshop session",
+	)
+	c.Flags().StringVar(
+		&o.GPUType,
+		"gpu-type",
+		"nvidia.com/gpu",
+		"name of the node resource GPUs are allocated from",
+	)
+	c.Flags().StringVar(
+		&o.GPURuntimeClass,
+		"gpu-runtime-class",
+		"",
+		"runtime class to use for workshop sessions with GPUs",
+	)
+	c.Flags().BoolVar(
+		&o.VCluster,
+		"vcluster",
+		false,
+		"provide each workshop session with its own virtual cluster",
+	)
+	c.Flags().StringVar(
+		&o.VClusterVersion,
+		"vcluster-version",
+		"",
+		"Kubernetes version for virtual clusters of workshop sessions",
+	)
+	c.Flags().StringArrayVar(
+		&o.VClusterIngress,
+		"vcluster-ingress-subdomain",
+		[]string{},
+		"subdomain for which ingresses in virtual clusters are exposed (can be specified multiple times)",
+	)
+	c.Flags().StringVar(
+		&o.SessionStorage,
+		"session-storage",
+		"",
+		"size of persistent volume for the home directory of each workshop session",
+	)
+	c.Flags().BoolVar(
+		&o.RetainFiles,
+		"retain-session-files",
+		false,
+		"keep files of workshop sessions after they end so they can be exported",
+	)
+	c.Flags().StringVar(
+		&o.StorageClass,
+		"session-storage-class",
+		"",
+		"storage class for the volume holding files of workshop sessions when they are kept",
+	)
+	c.Flags().StringSliceVar(
+		&o.CACertificates,
+		"ca-certificate",
+		[]string{},
+		"PEM file with CA certificates to be trusted by workshop sessions",
+	)
 
 	c.Flags().StringVar(
 		&o.WorkshopFile,
@@ -214,6 +1326,12 @@ func (p *ProjectInfo) NewClusterWorkshopDeployCmd() *cobra.Command {
 		"",
 		"the address of the image repository",
 	)
+	c.Flags().BoolVar(
+		&o.RewriteImages,
+		"rewrite-images",
+		false,
+		"rewrite image references in the workshop definition to pull from the image repository",
+	)
 
 	c.Flags().StringArrayVar(
 		&o.DataValuesFlags.EnvFromStrings,
@@ -253,281 +1371,127 @@ func (p *ProjectInfo) NewClusterWorkshopDeployCmd() *cobra.Command {
 		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
 	)
 
-	return c
-}
-
-var trainingPortalResource = schema.GroupVersionResource{Group: "training.educates.dev", Version: "v1beta1", Resource: "trainingportals"}
-
-func deployWorkshopResource(client dynamic.Interface, workshop *unstructured.Unstructured, portal string, capacity uint, reserved uint, initial uint, expires string, overtime string, deadline string, orphaned string, overdue string, refresh string, registry string, environ []string) error {
-	trainingPortalClient := client.Resource(trainingPortalResource)
-
-	trainingPortal, err := trainingPortalClient.Get(context.TODO(), portal, metav1.GetOptions{})
-
-	var trainingPortalExists = true
-
-	if k8serrors.IsNotFound(err) {
-		trainingPortalExists = false
-
-		trainingPortal = &unstructured.Unstructured{}
-
-		trainingPortal.SetUnstructuredContent(map[string]interface{}{
-			"apiVersion": "training.educates.dev/v1beta1",
-			"kind":       "TrainingPortal",
-			"metadata": map[string]interface{}{
-				"name": portal,
-			},
-			"spec": map[string]interface{}{
-				"portal": map[string]interface{}{
-					"password": randomPassword(12),
-					"registration": struct {
-						Type string `json:"type"`
-					}{
-						Type: "anonymous",
-					},
-					"updates": struct {
-						Workshop bool `json:"workshop"`
-					}{
-						Workshop: true,
-					},
-					"sessions": struct {
-						Maximum int64 `json:"maximum"`
-					}{
-						Maximum: 1,
-					},
-					"workshop": map[string]interface{}{
-						"defaults": struct {
-							Reserved int `json:"reserved"`
-						}{
-							Reserved: 0,
-						},
-					},
-				},
-				"workshops": []interface{}{},
-			},
-		})
-	}
-
-	var propertyExists bool
-
-	var sessionsMaximum int64 = 1
-
-	if trainingPortalExists {
-		sessionsMaximum, propertyExists, err = unstructured.NestedInt64(trainingPortal.Object, "spec", "portal", "sessions", "maximum")
-
-		if err == nil && propertyExists {
-			if sessionsMaximum >= 0 && uint(sessionsMaximum) < capacity {
-				capacity = uint(sessionsMaximum)
-			}
-		}
-	} else {
-		capacity = 1
-	}
-
-	if capacity != 0 {
-		if reserved > capacity {
-			reserved = capacity
-		}
-		if initial > capacity {
-			initial = capacity
-		}
-	} else if sessionsMaximum != 0 {
-		if reserved > uint(sessionsMaximum) {
-			reserved = uint(sessionsMaximum)
-		}
-		if initial > uint(sessionsMaximum) {
-			initial = uint(sessionsMaximum)
-		}
-	}
-
-	workshops, _, err := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
-
-	if err != nil {
-		return errors.Wrap(err, "unable to retrieve workshops from training portal")
-	}
-
-	var updatedWorkshops []interface{}
-
-	if expires == "" {
-		duration, propertyExists, err := unstructured.NestedString(workshop.Object, "spec", "duration")
-
-		if err != nil || !propertyExists {
-			expires = "60m"
-		} else {
-			expires = duration
-		}
-	}
-
-	type EnvironDetails struct {
-		Name  string `json:"name"`
-		Value string `json:"value"`
-	}
-
-	var environVariables []EnvironDetails
-
-	for _, value := range environ {
-		parts := strings.SplitN(value, "=", 2)
-		environVariables = append(environVariables, EnvironDetails{
-			Name:  parts[0],
-			Value: parts[1],
-		})
-	}
-
-	var foundWorkshop = false
-
-	for _, item := range workshops {
-		object := item.(map[string]interface{})
-
-		updatedWorkshops = append(updatedWorkshops, object)
-
-		if object["name"] == workshop.GetName() {
-			foundWorkshop = true
-
-			object["reserved"] = int64(reserved)
-			object["initial"] = int64(initial)
-
-			if capacity != 0 {
-				object["capacity"] = int64(capacity)
-			} else {
-				delete(object, "capacity")
-			}
-
-			if expires != "" {
-				object["expires"] = expires
-			} else {
-				delete(object, "expires")
-			}
-
-			if overtime != "" {
-				object["overtime"] = overtime
-			} else {
-				delete(object, "overtime")
-			}
-
-			if deadline != "" {
-				object["deadline"] = deadline
-			} else {
-				delete(object, "deadline")
-			}
-
-			if orphaned != "" {
-				object["orphaned"] = orphaned
-			} else {
-				delete(object, "orphaned")
-			}
-
-			if overdue != "" {
-				object["overdue"] = overdue
-			} else {
-				delete(object, "overdue")
-			}
-
-			if refresh != "" {
-				object["refresh"] = refresh
-			} else {
-				delete(object, "refresh")
-			}
-
-			var tmpEnvironVariables []interface{}
+	c.Flags().StringVar(
+		&o.GitCredentials.Secret,
+		"git-secret",
+		"",
+		"name of secret holding credentials for downloading workshop content from Git",
+	)
+	c.Flags().StringVar(
+		&o.GitCredentials.Username,
+		"git-username",
+		"",
+		"username for downloading workshop content from Git using a token",
+	)
+	c.Flags().StringVar(
+		&o.GitCredentials.Token,
+		"git-token",
+		"",
+		"access token for downloading workshop content from Git",
+	)
+	c.Flags().StringVar(
+		&o.GitCredentials.SSHKeyFile,
+		"git-ssh-key",
+		"",
+		"SSH private key file for downloading workshop content from Git",
+	)
+	c.Flags().StringVar(
+		&o.GitCredentials.SSHKnownHostsFile,
+		"git-ssh-known-hosts",
+		"",
+		"SSH known hosts file for verifying the Git server",
+	)
 
-			for _, item := range environVariables {
-				tmpEnvironVariables = append(tmpEnvironVariables, map[string]interface{}{
-					"name":  item.Name,
-					"value": item.Value,
-				})
-			}
+	c.Flags().StringVar(
+		&o.OutputManifests,
+		"output-manifests",
+		"",
+		"directory to write resource manifests to instead of applying them to the cluster",
+	)
+	c.Flags().BoolVar(
+		&o.Plan,
+		"plan",
+		false,
+		"output the changes which would be made as JSON instead of applying them",
+	)
+	c.Flags().IntVar(
+		&o.Parallel,
+		"parallel",
+		4,
+		"maximum number of workshops to process at the same time when deploying multiple workshops",
+	)
+	c.Flags().DurationVar(
+		&o.WaitTimeout,
+		"wait-timeout",
+		10*time.Minute,
+		"maximum time to wait for dependencies of a workshop, or a canary session, to be ready",
+	)
+	c.Flags().BoolVar(
+		&o.Atomic,
+		"atomic",
+		false,
+		"undo changes made to the cluster if the deploy fails or is interrupted",
+	)
+	c.Flags().StringVar(
+		&o.Strategy,
+		"strategy",
+		"in-place",
+		"strategy for updating a deployed workshop (in-place or blue-green)",
+	)
 
-			object["env"] = tmpEnvironVariables
-		}
-	}
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("fleet", completeFleetNames)
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("namespace-budget", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return namespaceBudgets, cobra.ShellCompDirectiveNoFileComp
+	})
+	c.RegisterFlagCompletionFunc("strategy", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return deploymentStrategies, cobra.ShellCompDirectiveNoFileComp
+	})
+	c.RegisterFlagCompletionFunc("network-policy", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return networkPolicyProfiles, cobra.ShellCompDirectiveDefault
+	})
+	c.RegisterFlagCompletionFunc("vcluster-version", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return vclusterVersions, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	c.MarkFlagsMutuallyExclusive("idle-exempt", "orphaned")
+	c.MarkFlagsMutuallyExclusive("idle-exempt", "orphaned-warning")
+
+	return withAuditLogging(c)
+}
 
-	type RegistryDetails struct {
-		Host      string `json:"host"`
-		Namespace string `json:"namespace,omitempty"`
-	}
+var trainingPortalResource = training.TrainingPortalResource
 
-	type WorkshopDetails struct {
-		Name     string           `json:"name"`
-		Capacity int64            `json:"capacity,omitempty"`
-		Initial  int64            `json:"initial"`
-		Reserved int64            `json:"reserved"`
-		Expires  string           `json:"expires,omitempty"`
-		Overtime string           `json:"overtime,omitempty"`
-		Deadline string           `json:"deadline,omitempty"`
-		Orphaned string           `json:"orphaned,omitempty"`
-		Overdue  string           `json:"overdue,omitempty"`
-		Refresh  string           `json:"refresh,omitempty"`
-		Registry *RegistryDetails `json:"registry,omitempty"`
-		Environ  []EnvironDetails `json:"env"`
-	}
+/*
+Validate a custom hostname for the training portal and return the prefix to
+be used with the ingress domain. The hostname can be given as just the prefix
+or as a full hostname, in which case it must be directly under the wildcard
+ingress domain configured for the cluster so that it resolves and is covered
+by any wildcard TLS certificate.
+*/
+func resolvePortalHostname(hostname string) (string, error) {
+	prefix := strings.ToLower(hostname)
 
-	if !foundWorkshop {
-		workshopDetails := WorkshopDetails{
-			Name:     workshop.GetName(),
-			Initial:  int64(initial),
-			Reserved: int64(reserved),
-			Expires:  expires,
-			Overtime: overtime,
-			Deadline: deadline,
-			Orphaned: orphaned,
-			Overdue:  overdue,
-			Refresh:  refresh,
-			Environ:  environVariables,
-		}
+	if strings.Contains(prefix, ".") {
+		fullConfig, err := config.NewInstallationConfigFromFile("")
 
-		if capacity != 0 {
-			workshopDetails.Capacity = int64(capacity)
+		if err != nil {
+			return "", err
 		}
 
-		if registry != "" {
-			parts := strings.SplitN(registry, "/", 2)
-
-			host := parts[0]
-			var namespace string
-
-			if len(parts) > 1 {
-				namespace = parts[1]
-			}
-
-			registryDetails := RegistryDetails{
-				Host:      host,
-				Namespace: namespace,
-			}
+		domain := strings.ToLower(fullConfig.ClusterIngress.Domain)
 
-			workshopDetails.Registry = &registryDetails
+		if !strings.HasSuffix(prefix, "."+domain) {
+			return "", failures.NewValidationError(errors.Errorf("hostname %q is not under the ingress domain %q", hostname, domain), "supply a hostname prefix, or a hostname ending in the ingress domain")
 		}
 
-		var workshopDetailsMap map[string]interface{}
-
-		data, _ := json.Marshal(workshopDetails)
-		json.Unmarshal(data, &workshopDetailsMap)
-
-		updatedWorkshops = append(updatedWorkshops, workshopDetailsMap)
+		prefix = strings.TrimSuffix(prefix, "."+domain)
 	}
 
-	unstructured.SetNestedSlice(trainingPortal.Object, updatedWorkshops, "spec", "workshops")
-
-	if trainingPortalExists {
-		_, err = trainingPortalClient.Update(context.TODO(), trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
-	} else {
-		_, err = trainingPortalClient.Create(context.TODO(), trainingPortal, metav1.CreateOptions{FieldManager: "educates-cli"})
+	if errs := validation.IsDNS1123Label(prefix); len(errs) != 0 {
+		return "", failures.NewValidationError(errors.Errorf("invalid hostname prefix %q: %s", prefix, strings.Join(errs, ", ")), "the hostname must be a single label directly under the ingress domain")
 	}
 
-	if err != nil {
-		return errors.Wrapf(err, "unable to update training portal %q in cluster", portal)
-	}
-
-	return nil
-}
-
-func randomPassword(length int) string {
-	rand.Seed(time.Now().UnixNano())
-
-	chars := []rune("!#%+23456789:=?@ABCDEFGHJKLMNPRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
-
-	var b strings.Builder
-
-	for i := 0; i < length; i++ {
-		b.WriteRune(chars[rand.Intn(len(chars))])
-	}
-	return b.String()
+	return prefix, nil
 }
@@ -0,0 +1,492 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+const (
+	sourceWorkshopDefinition = "workshop definition"
+	sourcePortalEntry        = "training portal workshop entry"
+	sourcePortalDefault      = "training portal defaults"
+	sourceLegacyDefault      = "training portal (deprecated setting)"
+	sourceBuiltinDefault     = "built-in default"
+)
+
+type ClusterWorkshopDescribeOptions struct {
+	Name       string
+	Kubeconfig string
+	Portal     string
+}
+
+/*
+An effective setting for a workshop and where its value came from.
+*/
+type workshopSetting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+func (o *ClusterWorkshopDescribeOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := dynamicClient.Resource(trainingPortalResource).Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("no portal found with name %q", o.Portal), "list training portals with `educates cluster portal list`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query training portal %q", o.Portal)
+	}
+
+	var entry map[string]interface{}
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	for _, item := range workshops {
+		if object, ok := item.(map[string]interface{}); ok && object["name"] == o.Name {
+			entry = object
+			break
+		}
+	}
+
+	if entry == nil {
+		return failures.NewNotFoundError(errors.Errorf("workshop %q is not deployed to training portal %q", o.Name, o.Portal), "list deployed workshops with `educates cluster workshop list`")
+	}
+
+	workshop, err := dynamicClient.Resource(workshopResource).Get(ctx, o.Name, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("no workshop definition found with name %q", o.Name), "the workshop definition may have been deleted, deploy it again with `educates cluster workshop deploy`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query workshop %q", o.Name)
+	}
+
+	environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, o.Name)
+
+	if err != nil {
+		return err
+	}
+
+	title, _, _ := unstructured.NestedString(workshop.Object, "spec", "title")
+	version, _, _ := unstructured.NestedString(workshop.Object, "spec", "version")
+
+	fmt.Printf("Workshop:     %s\n", o.Name)
+
+	if title != "" {
+		fmt.Printf("Title:        %s\n", title)
+	}
+
+	if version != "" {
+		fmt.Printf("Version:      %s\n", version)
+	}
+
+	if source := workshop.GetAnnotations()["training.educates.dev/source"]; source != "" {
+		fmt.Printf("Source:       %s\n", source)
+	}
+
+	fmt.Printf("Portal:       %s\n", o.Portal)
+
+	if environment != nil {
+		phase, _, _ := unstructured.NestedString(environment.Object, "status", "educates", "phase")
+
+		fmt.Printf("Environment:  %s (%s)\n", environment.GetName(), strings.ToLower(phase))
+	} else {
+		fmt.Printf("Environment:  none\n")
+	}
+
+	fmt.Println()
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	fmt.Fprintf(w, "%s\t%s\t%s\n", "SETTING", "VALUE", "SOURCE")
+
+	for _, setting := range effectiveWorkshopSettings(workshop, trainingPortal, entry) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Name, setting.Value, setting.Source)
+	}
+
+	w.Flush()
+
+	variables := effectiveWorkshopVariables(trainingPortal, entry)
+
+	if len(variables) != 0 {
+		fmt.Println()
+
+		w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", "VARIABLE", "VALUE", "SOURCE")
+
+		for _, setting := range variables {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Name, setting.Value, setting.Source)
+		}
+
+		w.Flush()
+	}
+
+	fmt.Println()
+
+	fmt.Printf("Session URL:  %s\n", workshopSessionURLPattern(ctx, dynamicClient, trainingPortal, environment))
+
+	return nil
+}
+
+/*
+Find the current workshop environment for a workshop in a training portal,
+ignoring any in the process of being replaced or deleted.
+*/
+func workshopEnvironmentForPortal(ctx context.Context, client dynamic.Interface, portal string, workshop string) (*unstructured.Unstructured, error) {
+	environments, err := client.Resource(workshopEnvironmentResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("training.educates.dev/portal.name=%s", portal)})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list workshop environments")
+	}
+
+	for i := range environments.Items {
+		item := &environments.Items[i]
+
+		name, _, _ := unstructured.NestedString(item.Object, "spec", "workshop", "name")
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if name == workshop && item.GetDeletionTimestamp() == nil && phase != "Stopping" {
+			return item, nil
+		}
+	}
+
+	return nil, nil
+}
+
+/*
+Work out the effective settings for a workshop in the same way as the
+training portal does when creating the workshop environment. Settings from
+the workshop entry in the training portal take precedence, followed by the
+workshop defaults for the training portal, then deprecated training portal
+level settings, and finally the built-in defaults.
+*/
+func effectiveWorkshopSettings(workshop *unstructured.Unstructured, trainingPortal *unstructured.Unstructured, entry map[string]interface{}) []workshopSetting {
+	var settings []workshopSetting
+
+	portalValue := func(name string, builtin interface{}) (interface{}, string) {
+		if value, found, _ := unstructured.NestedFieldNoCopy(trainingPortal.Object, "spec", "portal", "workshop", "defaults", name); found && value != nil {
+			return value, sourcePortalDefault
+		}
+
+		if name != "overtime" && name != "deadline" {
+			if value, found, _ := unstructured.NestedFieldNoCopy(trainingPortal.Object, "spec", "portal", name); found && value != nil {
+				return value, sourceLegacyDefault
+			}
+		}
+
+		return builtin, sourceBuiltinDefault
+	}
+
+	entryValue := func(name string, builtin interface{}) (interface{}, string) {
+		if value, found := entry[name]; found && value != nil {
+			return value, sourcePortalEntry
+		}
+
+		return portalValue(name, builtin)
+	}
+
+	add := func(name string, value interface{}, source string) {
+		settings = append(settings, workshopSetting{Name: name, Value: fmt.Sprint(value), Source: source})
+	}
+
+	// Session counts, which are clamped to the capacity of the workshop.
+
+	sessionsMaximum, _, _ := unstructured.NestedInt64(trainingPortal.Object, "spec", "portal", "sessions", "maximum")
+
+	capacityValue, capacitySource := entryValue("capacity", sessionsMaximum)
+
+	if capacitySource == sourceBuiltinDefault {
+		capacitySource = "training portal maximum sessions"
+	}
+
+	capacity := training.IntegerValue(capacityValue)
+
+	if capacity < 0 {
+		capacity = 0
+	}
+
+	reservedValue, reservedSource := entryValue("reserved", int64(1))
+
+	reserved := training.IntegerValue(reservedValue)
+
+	if reserved > capacity {
+		reserved = capacity
+	}
+
+	if reserved < 0 {
+		reserved = 0
+	}
+
+	initialValue, initialSource := entryValue("initial", nil)
+
+	var initial int64
+
+	if initialValue == nil {
+		initial = reserved
+		initialSource = "same as reserved"
+	} else {
+		initial = training.IntegerValue(initialValue)
+	}
+
+	if initial > capacity {
+		initial = capacity
+	}
+
+	if initial < 0 {
+		initial = 0
+	}
+
+	if initial != 0 && initial < reserved {
+		initial = reserved
+	}
+
+	add("capacity", capacity, capacitySource)
+	add("reserved", reserved, reservedSource)
+	add("initial", initial, initialSource)
+
+	// Durations controlling the lifetime of sessions. A deadline of zero
+	// is replaced with the expiration time.
+
+	values := map[string]interface{}{}
+
+	for _, name := range []string{"expires", "overtime", "deadline", "orphaned", "overdue", "refresh"} {
+		value, source := entryValue(name, "0")
+
+		if name == "deadline" && fmt.Sprint(value) == "0" {
+			value, source = values["expires"], "same as expires"
+		}
+
+		values[name] = value
+
+		add(name, value, source)
+	}
+
+	if registry, source := entryValue("registry", nil); registry != nil {
+		if object, ok := registry.(map[string]interface{}); ok && len(object) != 0 {
+			add("registry", object["host"], source)
+		}
+	}
+
+	if updates, found, _ := unstructured.NestedBool(trainingPortal.Object, "spec", "portal", "updates", "workshop"); found {
+		add("updates", updates, sourcePortalDefault)
+	} else {
+		add("updates", false, sourceBuiltinDefault)
+	}
+
+	// Settings which only come from the workshop definition.
+
+	if duration, found, _ := unstructured.NestedString(workshop.Object, "spec", "duration"); found {
+		add("duration", duration, sourceWorkshopDefinition)
+	}
+
+	if image, found, _ := unstructured.NestedString(workshop.Object, "spec", "workshop", "image"); found {
+		add("image", image, sourceWorkshopDefinition)
+	} else {
+		add("image", "base-environment:*", sourceBuiltinDefault)
+	}
+
+	if memory, found, _ := unstructured.NestedString(workshop.Object, "spec", "session", "resources", "memory"); found {
+		add("memory", memory, sourceWorkshopDefinition)
+	}
+
+	if budget, found, _ := unstructured.NestedString(workshop.Object, "spec", "session", "namespaces", "budget"); found {
+		add("budget", budget, sourceWorkshopDefinition)
+	}
+
+	if applications, found, _ := unstructured.NestedMap(workshop.Object, "spec", "session", "applications"); found {
+		var enabled []string
+
+		for name := range applications {
+			if value, _, _ := unstructured.NestedBool(applications, name, "enabled"); value {
+				enabled = append(enabled, name)
+			}
+		}
+
+		sort.Strings(enabled)
+
+		if len(enabled) != 0 {
+			add("applications", strings.Join(enabled, ","), sourceWorkshopDefinition)
+		}
+	}
+
+	return settings
+}
+
+/*
+Merge the environment variables from the workshop entry in the training
+portal with the defaults for the training portal. Those for the workshop
+entry take precedence where the same variable is set in both.
+*/
+func effectiveWorkshopVariables(trainingPortal *unstructured.Unstructured, entry map[string]interface{}) []workshopSetting {
+	var variables []workshopSetting
+
+	seen := map[string]bool{}
+
+	add := func(items []interface{}, source string) {
+		for _, item := range items {
+			object, ok := item.(map[string]interface{})
+
+			if !ok {
+				continue
+			}
+
+			name, _ := object["name"].(string)
+
+			if name == "" || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+
+			value := ""
+
+			if object["value"] != nil {
+				value = fmt.Sprint(object["value"])
+			}
+
+			variables = append(variables, workshopSetting{Name: name, Value: value, Source: source})
+		}
+	}
+
+	if items, ok := entry["env"].([]interface{}); ok {
+		add(items, sourcePortalEntry)
+	}
+
+	if items, found, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "portal", "workshop", "defaults", "env"); found {
+		add(items, sourcePortalDefault)
+	}
+
+	return variables
+}
+
+/*
+Describe the form of the URL for sessions of the workshop. Sessions are
+named after the workshop environment with a session number appended, and
+are accessed using a hostname made from the session name under the ingress
+domain.
+*/
+func workshopSessionURLPattern(ctx context.Context, client dynamic.Interface, trainingPortal *unstructured.Unstructured, environment *unstructured.Unstructured) string {
+	environmentName := "<environment>"
+
+	if environment != nil {
+		environmentName = environment.GetName()
+
+		// Where a session already exists use its URL as the most accurate
+		// indication of the ingress domain and protocol.
+
+		sessions, err := client.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("training.educates.dev/environment.name=%s", environmentName)})
+
+		if err == nil {
+			for _, item := range sessions.Items {
+				sessionURL, _, _ := unstructured.NestedString(item.Object, "status", "educates", "url")
+
+				if parsed, err := url.Parse(sessionURL); err == nil && parsed.Host != "" {
+					if _, domain, found := strings.Cut(parsed.Host, "."); found {
+						return fmt.Sprintf("%s://%s-<id>.%s", parsed.Scheme, environmentName, domain)
+					}
+				}
+			}
+		}
+	}
+
+	portalURL, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
+
+	if parsed, err := url.Parse(portalURL); err == nil && parsed.Host != "" {
+		if _, domain, found := strings.Cut(parsed.Host, "."); found {
+			return fmt.Sprintf("%s://%s-<id>.%s", parsed.Scheme, environmentName, domain)
+		}
+	}
+
+	return fmt.Sprintf("<protocol>://%s-<id>.<ingress-domain>", environmentName)
+}
+
+func (p *ProjectInfo) NewClusterWorkshopDescribeCmd() *cobra.Command {
+	var o ClusterWorkshopDescribeOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "describe",
+		Short: "Describe effective configuration of deployed workshop",
+		Long: `Describe effective configuration of deployed workshop.
+
+Shows the settings in effect for a workshop deployed to a training portal,
+and where each came from. Settings for the number of sessions and their
+lifetime are taken from the workshop entry in the training portal, falling
+back to the workshop defaults of the training portal and then built-in
+defaults, the same as the training portal does when creating the workshop
+environment. Environment variables from the workshop entry are merged with
+those given in the workshop defaults. Settings which can only be given in
+the workshop definition are also shown, along with the form of the URL used
+to access workshop sessions.
+
+Note that the defaults of the training portal are only applied when a
+workshop environment is first created, so changes to them do not affect an
+existing workshop environment until it is replaced.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the deployed workshop",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+
+	c.MarkFlagRequired("name")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+
+	return c
+}
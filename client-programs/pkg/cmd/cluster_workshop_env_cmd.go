@@ -0,0 +1,475 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const (
+	sourceTrainingPortal = "training portal"
+	sourcePlatform       = "platform"
+)
+
+const maskedValue = "********"
+
+// Names of environment variables whose values are masked as they are likely
+// to hold credentials.
+var secretVariablePattern = regexp.MustCompile(`(?i)(PASSWORD|SECRET|TOKEN|CREDENTIAL|PRIVATE_KEY|API_KEY)`)
+
+/*
+An environment variable for the workshop container of a session and where it
+was set from.
+*/
+type sessionVariable struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+type ClusterWorkshopEnvOptions struct {
+	Kubeconfig string
+	Portal     string
+	Name       string
+	Session    string
+	Output     string
+}
+
+func (o *ClusterWorkshopEnvOptions) Run(ctx context.Context) error {
+	if o.Output != "table" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are table and json")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, o.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if environment == nil {
+		return failures.NewNotFoundError(errors.Errorf("no workshop %q deployed to training portal %q", o.Name, o.Portal), "list deployed workshops with `educates cluster workshop list`")
+	}
+
+	// The environment variables are taken from the deployment for a session
+	// so that they are exactly what the session receives, with any session
+	// variables in values already substituted.
+
+	session, err := o.workshopSession(ctx, dynamicClient, environment.GetName())
+
+	if err != nil {
+		return err
+	}
+
+	sessionId, _, _ := unstructured.NestedString(session.Object, "spec", "session", "id")
+
+	deployment, err := client.AppsV1().Deployments(environment.GetName()).Get(ctx, fmt.Sprintf("%s-%s", environment.GetName(), sessionId), metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("workshop session %q has not been deployed yet", session.GetName()), "wait for the session to start, or give another session with --session")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query deployment for workshop session %q", session.GetName())
+	}
+
+	var container *apiv1.Container
+
+	for i := range deployment.Spec.Template.Spec.Containers {
+		if deployment.Spec.Template.Spec.Containers[i].Name == "workshop" {
+			container = &deployment.Spec.Template.Spec.Containers[i]
+			break
+		}
+	}
+
+	if container == nil {
+		return errors.Errorf("no workshop container found in deployment for workshop session %q", session.GetName())
+	}
+
+	// Work out where variables were set from. Variables from the training
+	// portal workshop entry, and those the training portal adds for its own
+	// use, are passed in the workshop session, and both override those from
+	// the workshop definition. Anything else was added by the platform.
+
+	workshopVariables := envVariableNames(environment.Object, "status", "educates", "workshop", "spec", "session", "env")
+	sessionVariables := envVariableNames(session.Object, "spec", "session", "env")
+
+	portalVariables := map[string]bool{}
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	for _, item := range workshops {
+		if object, ok := item.(map[string]interface{}); ok && object["name"] == o.Name {
+			portalVariables = envVariableNames(object, "env")
+			break
+		}
+	}
+
+	resolver := envValueResolver{client: client, namespace: environment.GetName()}
+
+	var variables []sessionVariable
+
+	defined := map[string]bool{}
+
+	for _, env := range container.Env {
+		source := sourcePlatform
+
+		switch {
+		case portalVariables[env.Name]:
+			source = sourcePortalEntry
+		case sessionVariables[env.Name]:
+			source = sourceTrainingPortal
+		case workshopVariables[env.Name]:
+			source = sourceWorkshopDefinition
+		}
+
+		value, secret := resolver.value(ctx, env)
+
+		if secret || secretVariablePattern.MatchString(env.Name) {
+			value = maskedValue
+		}
+
+		variables = append(variables, sessionVariable{Name: env.Name, Value: value, Source: source})
+
+		defined[env.Name] = true
+	}
+
+	// Variables from config maps and secrets given by envFrom are overridden
+	// by those set explicitly, and when from a secret are always masked.
+
+	for _, envFrom := range container.EnvFrom {
+		fromVariables, err := resolver.envFromValues(ctx, envFrom)
+
+		if err != nil {
+			return err
+		}
+
+		for _, variable := range fromVariables {
+			if !defined[variable.Name] {
+				variables = append(variables, variable)
+
+				defined[variable.Name] = true
+			}
+		}
+	}
+
+	if o.Output == "json" {
+		jsonData, err := json.MarshalIndent(variables, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode environment variables")
+		}
+
+		fmt.Println(string(jsonData))
+
+		return nil
+	}
+
+	fmt.Printf("Session:  %s\n", session.GetName())
+	fmt.Println()
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\n", "VARIABLE", "VALUE", "SOURCE")
+
+	for _, variable := range variables {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", variable.Name, strings.ReplaceAll(variable.Value, "\n", `\n`), variable.Source)
+	}
+
+	return nil
+}
+
+/*
+Return the workshop session to report environment variables for, being the
+one given, or otherwise the first running session for the environment.
+*/
+func (o *ClusterWorkshopEnvOptions) workshopSession(ctx context.Context, client dynamic.Interface, environmentName string) (*unstructured.Unstructured, error) {
+	sessionsClient := client.Resource(workshopSessionResource)
+
+	if o.Session != "" {
+		session, err := sessionsClient.Get(ctx, o.Session, metav1.GetOptions{})
+
+		if k8serrors.IsNotFound(err) {
+			return nil, failures.NewNotFoundError(errors.Errorf("no workshop session found with name %q", o.Session), "list sessions with `educates cluster session list`")
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to query workshop session %q", o.Session)
+		}
+
+		if session.GetLabels()["training.educates.dev/environment.name"] != environmentName {
+			return nil, failures.NewValidationError(errors.Errorf("workshop session %q is not for workshop %q", o.Session, o.Name), "")
+		}
+
+		return session, nil
+	}
+
+	sessions, err := sessionsClient.List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("training.educates.dev/environment.name=%s", environmentName)})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list workshop sessions")
+	}
+
+	sort.Slice(sessions.Items, func(i, j int) bool {
+		return sessions.Items[i].GetCreationTimestamp().Time.Before(sessions.Items[j].GetCreationTimestamp().Time)
+	})
+
+	var candidate *unstructured.Unstructured
+
+	for i := range sessions.Items {
+		item := &sessions.Items[i]
+
+		if item.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if phase == "Running" {
+			return item, nil
+		}
+
+		if candidate == nil {
+			candidate = item
+		}
+	}
+
+	if candidate == nil {
+		return nil, failures.NewNotFoundError(errors.Errorf("no workshop sessions exist for workshop %q", o.Name), "environment variables are resolved for each session, request a session with `educates cluster workshop request`")
+	}
+
+	return candidate, nil
+}
+
+/*
+Return the names of the environment variables in a list of environment
+variables found at the given path in an object.
+*/
+func envVariableNames(object map[string]interface{}, fields ...string) map[string]bool {
+	names := map[string]bool{}
+
+	items, _, _ := unstructured.NestedSlice(object, fields...)
+
+	for _, item := range items {
+		if env, ok := item.(map[string]interface{}); ok {
+			if name, ok := env["name"].(string); ok {
+				names[name] = true
+			}
+		}
+	}
+
+	return names
+}
+
+/*
+Resolves the values of environment variables for a container which are given
+by reference to config maps, secrets or fields of the pod.
+*/
+type envValueResolver struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+/*
+Return the value of an environment variable and whether it came from a
+secret. Values which can't be resolved are described by where they would
+come from instead.
+*/
+func (r *envValueResolver) value(ctx context.Context, env apiv1.EnvVar) (string, bool) {
+	if env.ValueFrom == nil {
+		return env.Value, false
+	}
+
+	switch {
+	case env.ValueFrom.SecretKeyRef != nil:
+		return "", true
+	case env.ValueFrom.ConfigMapKeyRef != nil:
+		ref := env.ValueFrom.ConfigMapKeyRef
+
+		configMap, err := r.client.CoreV1().ConfigMaps(r.namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+
+		if err == nil {
+			if value, found := configMap.Data[ref.Key]; found {
+				return value, false
+			}
+		}
+
+		return fmt.Sprintf("(config map %s/%s)", ref.Name, ref.Key), false
+	case env.ValueFrom.FieldRef != nil:
+		return fmt.Sprintf("(field %s)", env.ValueFrom.FieldRef.FieldPath), false
+	case env.ValueFrom.ResourceFieldRef != nil:
+		return fmt.Sprintf("(resource %s)", env.ValueFrom.ResourceFieldRef.Resource), false
+	}
+
+	return "", false
+}
+
+/*
+Return the environment variables set from a config map or secret, with the
+values from a secret masked.
+*/
+func (r *envValueResolver) envFromValues(ctx context.Context, envFrom apiv1.EnvFromSource) ([]sessionVariable, error) {
+	var data map[string]string
+	var source string
+	var secret bool
+	var optional bool
+
+	switch {
+	case envFrom.ConfigMapRef != nil:
+		source = fmt.Sprintf("config map %s", envFrom.ConfigMapRef.Name)
+		optional = envFrom.ConfigMapRef.Optional != nil && *envFrom.ConfigMapRef.Optional
+
+		configMap, err := r.client.CoreV1().ConfigMaps(r.namespace).Get(ctx, envFrom.ConfigMapRef.Name, metav1.GetOptions{})
+
+		if err != nil && !(k8serrors.IsNotFound(err) && optional) {
+			return nil, errors.Wrapf(err, "unable to query config map %q", envFrom.ConfigMapRef.Name)
+		}
+
+		if err == nil {
+			data = configMap.Data
+		}
+	case envFrom.SecretRef != nil:
+		source = fmt.Sprintf("secret %s", envFrom.SecretRef.Name)
+		optional = envFrom.SecretRef.Optional != nil && *envFrom.SecretRef.Optional
+		secret = true
+
+		secretObj, err := r.client.CoreV1().Secrets(r.namespace).Get(ctx, envFrom.SecretRef.Name, metav1.GetOptions{})
+
+		if err != nil && !(k8serrors.IsNotFound(err) && optional) {
+			return nil, errors.Wrapf(err, "unable to query secret %q", envFrom.SecretRef.Name)
+		}
+
+		if err == nil {
+			data = map[string]string{}
+
+			for key := range secretObj.Data {
+				data[key] = ""
+			}
+		}
+	}
+
+	var keys []string
+
+	for key := range data {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var variables []sessionVariable
+
+	for _, key := range keys {
+		value := data[key]
+
+		if secret || secretVariablePattern.MatchString(key) {
+			value = maskedValue
+		}
+
+		variables = append(variables, sessionVariable{Name: envFrom.Prefix + key, Value: value, Source: source})
+	}
+
+	return variables, nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopEnvCmd() *cobra.Command {
+	var o ClusterWorkshopEnvOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "env",
+		Short: "Show environment of deployed workshop sessions",
+		Long: `Show the environment variables a session of a deployed workshop receives.
+
+The environment variables are those set for the workshop container of a
+session, including variables from the workshop definition, overrides given
+in the workshop entry of the training portal, variables added by the
+training portal, and variables injected by the platform. The source of each
+variable is shown so the effect of overrides can be seen.
+
+As session variables in values are substituted separately for each session,
+the variables are shown for an existing session, being that given by
+--session, or otherwise a running session for the workshop. Values which are
+likely to be credentials, or which come from secrets, are masked.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the workshop",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Session,
+		"session",
+		"",
+		"name of the workshop session to show the environment for",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"table",
+		"output format, one of table or json",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	c.MarkFlagRequired("name")
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+/*
+Optional applications which can be enabled for workshop sessions. Where an
+application can't be used with how the platform was installed, the check
+returns an error describing why.
+*/
+type workshopExtension struct {
+	Name        string
+	Description string
+	Check       func(platformConfig *config.TrainingPlatformConfig) error
+}
+
+var workshopExtensions = []workshopExtension{
+	{
+		Name:        "editor",
+		Description: "VS Code based editor embedded in the workshop dashboard",
+	},
+	{
+		Name:        "console",
+		Description: "Kubernetes web console for the session namespace",
+	},
+	{
+		Name:        "docker",
+		Description: "Docker daemon for building and running container images",
+		Check: func(platformConfig *config.TrainingPlatformConfig) error {
+			if platformConfig.ClusterRuntime.Class != "" {
+				return errors.Errorf("docker daemon cannot run with container runtime class %q", platformConfig.ClusterRuntime.Class)
+			}
+
+			return nil
+		},
+	},
+	{
+		Name:        "registry",
+		Description: "Image registry for the workshop session",
+		Check: func(platformConfig *config.TrainingPlatformConfig) error {
+			if platformConfig.ClusterIngress.Domain == "" {
+				return errors.New("image registry requires an ingress domain for the cluster")
+			}
+
+			return nil
+		},
+	},
+	{
+		Name:        "vcluster",
+		Description: "Virtual Kubernetes cluster for the workshop session",
+		Check: func(platformConfig *config.TrainingPlatformConfig) error {
+			if platformConfig.ClusterRuntime.Class != "" {
+				return errors.Errorf("virtual cluster cannot run with container runtime class %q", platformConfig.ClusterRuntime.Class)
+			}
+
+			return nil
+		},
+	},
+	{
+		Name:        "slides",
+		Description: "Presentation slides included with the workshop content",
+	},
+	{
+		Name:        "files",
+		Description: "Downloading of files from the workshop session",
+	},
+	{
+		Name:        "uploads",
+		Description: "Uploading of files to the workshop session",
+	},
+	{
+		Name:        "examiner",
+		Description: "Test scripts used to verify workshop tasks are completed",
+	},
+	{
+		Name:        "git",
+		Description: "Git server for the workshop session",
+	},
+	{
+		Name:        "webdav",
+		Description: "WebDAV access to files in the workshop session",
+	},
+}
+
+func lookupWorkshopExtension(name string) (workshopExtension, bool) {
+	for _, extension := range workshopExtensions {
+		if extension.Name == name {
+			return extension, true
+		}
+	}
+
+	return workshopExtension{}, false
+}
+
+func workshopExtensionNames() []string {
+	var names []string
+
+	for _, extension := range workshopExtensions {
+		names = append(names, extension.Name)
+	}
+
+	return names
+}
+
+func workshopExtensionEnabled(workshop *unstructured.Unstructured, name string) bool {
+	enabled, _, _ := unstructured.NestedBool(workshop.Object, "spec", "session", "applications", name, "enabled")
+
+	return enabled
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+)
+
+type ClusterWorkshopExtensionsEnableOptions struct {
+	Name       string
+	Extensions []string
+	Kubeconfig string
+}
+
+func (o *ClusterWorkshopExtensionsEnableOptions) Run(ctx context.Context) error {
+	// Validate the extensions before making any changes so that either all
+	// or none of them are enabled.
+
+	for _, name := range o.Extensions {
+		if _, found := lookupWorkshopExtension(name); !found {
+			return failures.NewValidationError(errors.Errorf("unknown workshop extension %q", name), fmt.Sprintf("supported extensions are %s", strings.Join(workshopExtensionNames(), ", ")))
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	workshopsClient := dynamicClient.Resource(workshopResource)
+
+	workshop, err := workshopsClient.Get(ctx, o.Name, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("no workshop found with name %q", o.Name), "list workshops with `educates cluster workshop list`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query workshop definition in cluster %q", o.Name)
+	}
+
+	platformConfig, err := operators.InstalledConfig(ctx, clusterConfig)
+
+	if err != nil {
+		return err
+	}
+
+	if platformConfig == nil {
+		return failures.NewNotFoundError(errors.New("platform is not installed in the cluster"), "install the platform with `educates admin platform deploy`")
+	}
+
+	applications := map[string]interface{}{}
+
+	for _, name := range o.Extensions {
+		extension, _ := lookupWorkshopExtension(name)
+
+		if extension.Check != nil {
+			if err := extension.Check(platformConfig); err != nil {
+				return failures.NewValidationError(errors.Wrapf(err, "workshop extension %q is not supported by the cluster", name), "")
+			}
+		}
+
+		if workshopExtensionEnabled(workshop, name) {
+			fmt.Printf("Extension %s is already enabled for workshop %s.\n", name, o.Name)
+			continue
+		}
+
+		applications[name] = map[string]interface{}{"enabled": true}
+	}
+
+	if len(applications) == 0 {
+		return nil
+	}
+
+	// Use a merge patch so that any other configuration for the applications
+	// in the workshop definition is preserved.
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"session": map[string]interface{}{
+				"applications": applications,
+			},
+		},
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate workshop definition patch")
+	}
+
+	_, err = workshopsClient.Patch(ctx, o.Name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update workshop definition in cluster %q", o.Name)
+	}
+
+	for _, name := range o.Extensions {
+		if _, found := applications[name]; found {
+			fmt.Printf("Enabled extension %s for workshop %s.\n", name, o.Name)
+		}
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopExtensionsEnableCmd() *cobra.Command {
+	var o ClusterWorkshopExtensionsEnableOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "enable",
+		Short: "Enable optional applications for workshop",
+		Long: `Enable optional applications for workshop.
+
+Enables optional applications, such as the embedded editor, Kubernetes web
+console, Docker daemon or presentation slides, in the workshop definition of
+a deployed workshop. A check is first made that the applications can be used
+with how Educates was installed in the cluster.
+
+The change only applies to workshop sessions created after the workshop
+definition is updated. Existing workshop sessions are not affected.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the deployed workshop",
+	)
+	c.Flags().StringSliceVarP(
+		&o.Extensions,
+		"extension",
+		"e",
+		[]string{},
+		"name of the optional application to enable",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	c.MarkFlagRequired("name")
+	c.MarkFlagRequired("extension")
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("extension", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return workshopExtensionNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return withAuditLogging(c)
+}
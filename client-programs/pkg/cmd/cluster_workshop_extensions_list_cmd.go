@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+)
+
+type ClusterWorkshopExtensionsListOptions struct {
+	Name       string
+	Kubeconfig string
+}
+
+func (o *ClusterWorkshopExtensionsListOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	workshop, err := dynamicClient.Resource(workshopResource).Get(ctx, o.Name, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("no workshop found with name %q", o.Name), "list workshops with `educates cluster workshop list`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query workshop definition in cluster %q", o.Name)
+	}
+
+	platformConfig, err := operators.InstalledConfig(ctx, clusterConfig)
+
+	if err != nil {
+		return err
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintln(w, "NAME\tENABLED\tSUPPORTED\tDESCRIPTION")
+
+	for _, extension := range workshopExtensions {
+		enabled := "no"
+
+		if workshopExtensionEnabled(workshop, extension.Name) {
+			enabled = "yes"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", extension.Name, enabled, workshopExtensionSupport(extension, platformConfig), extension.Description)
+	}
+
+	return nil
+}
+
+/*
+Summarise whether an extension can be used with how the platform was
+installed. Where installation details aren't available the support is shown
+as unknown.
+*/
+func workshopExtensionSupport(extension workshopExtension, platformConfig *config.TrainingPlatformConfig) string {
+	if extension.Check == nil {
+		return "yes"
+	}
+
+	if platformConfig == nil {
+		return "unknown"
+	}
+
+	if err := extension.Check(platformConfig); err != nil {
+		return "no"
+	}
+
+	return "yes"
+}
+
+func (p *ProjectInfo) NewClusterWorkshopExtensionsListCmd() *cobra.Command {
+	var o ClusterWorkshopExtensionsListOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "list",
+		Short: "List optional applications for workshop",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the deployed workshop",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	c.MarkFlagRequired("name")
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+
+	return c
+}
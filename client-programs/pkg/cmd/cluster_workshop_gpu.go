@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Request GPUs for the sessions of a workshop, overriding any request given in
+the workshop definition. The type is the name of the extended resource the
+GPU device plugin exposes on nodes, and the runtime class is only needed for
+GPU operators which rely on one to expose devices to containers.
+*/
+func applyWorkshopGPUs(workshop *unstructured.Unstructured, gpus uint, gpuType string, runtimeClass string) error {
+	if gpus == 0 {
+		if runtimeClass != "" {
+			return failures.NewValidationError(errors.New("a GPU runtime class can only be given when requesting GPUs"), "use --gpus to set the number of GPUs for each session")
+		}
+
+		return nil
+	}
+
+	if gpuType == "" || !strings.Contains(gpuType, "/") {
+		return failures.NewValidationError(errors.Errorf("invalid GPU type %q", gpuType), "GPU type must be the name of a node resource, such as nvidia.com/gpu")
+	}
+
+	gpu := map[string]interface{}{
+		"count": int64(gpus),
+		"type":  gpuType,
+	}
+
+	if runtimeClass != "" {
+		gpu["runtimeClass"] = runtimeClass
+	}
+
+	return unstructured.SetNestedMap(workshop.Object, gpu, "spec", "session", "resources", "gpu")
+}
+
+/*
+Return the number of GPUs requested for each session of a workshop and the
+node resource they are allocated from.
+*/
+func workshopGPUs(workshop *unstructured.Unstructured) (int64, string) {
+	count, _, _ := unstructured.NestedInt64(workshop.Object, "spec", "session", "resources", "gpu", "count")
+	gpuType, _, _ := unstructured.NestedString(workshop.Object, "spec", "session", "resources", "gpu", "type")
+
+	if gpuType == "" {
+		gpuType = "nvidia.com/gpu"
+	}
+
+	return count, gpuType
+}
+
+/*
+Check that the nodes of the cluster sessions can be scheduled on have enough
+allocatable GPUs for the capacity of the workshop. As GPU node pools are often
+scaled up on demand this only results in warnings.
+*/
+func checkWorkshopGPUCapacity(ctx context.Context, client kubernetes.Interface, workshop *unstructured.Unstructured, capacity uint, nodeSelector []string) error {
+	count, gpuType := workshopGPUs(workshop)
+
+	if count == 0 {
+		return nil
+	}
+
+	selector := labels.Set{}
+
+	for _, item := range nodeSelector {
+		if parts := strings.SplitN(item, "=", 2); len(parts) == 2 {
+			selector[parts[0]] = parts[1]
+		}
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to list nodes in cluster")
+	}
+
+	var allocatable, largest int64
+
+	for _, node := range nodes.Items {
+		if quantity, found := node.Status.Allocatable[apiv1.ResourceName(gpuType)]; found {
+			allocatable += quantity.Value()
+
+			if quantity.Value() > largest {
+				largest = quantity.Value()
+			}
+		}
+	}
+
+	if allocatable == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: no nodes in the cluster have allocatable %s for workshop %q, sessions cannot be scheduled until GPU nodes are available.\n", gpuType, workshop.GetName())
+
+		return nil
+	}
+
+	if count > largest {
+		fmt.Fprintf(os.Stderr, "Warning: workshop %q requests %d of %s for each session but no node has more than %d allocatable.\n", workshop.GetName(), count, gpuType, largest)
+	}
+
+	sessions := int64(capacity)
+
+	if sessions == 0 {
+		sessions = 1
+	}
+
+	if required := sessions * count; required > allocatable {
+		fmt.Fprintf(os.Stderr, "Warning: capacity of %d for workshop %q requires %d of %s but only %d is allocatable in the cluster.\n", sessions, workshop.GetName(), required, gpuType, allocatable)
+	}
+
+	return nil
+}
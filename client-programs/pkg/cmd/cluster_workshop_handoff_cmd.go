@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWorkshopHandoffOptions struct {
+	Name       string
+	Kubeconfig string
+	Portal     string
+	User       string
+	Email      string
+	FirstName  string
+	LastName   string
+	Params     []string
+	IndexURL   string
+	Expires    time.Duration
+	Output     string
+	Shorten    bool
+}
+
+/*
+Details of the workshop session handed off to a user.
+*/
+type workshopHandoff struct {
+	Workshop string    `json:"workshop"`
+	Session  string    `json:"session"`
+	User     string    `json:"user"`
+	URL      string    `json:"url"`
+	Expires  time.Time `json:"expires"`
+}
+
+func (o *ClusterWorkshopHandoffOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Output != "text" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are text and json")
+	}
+
+	if o.Expires < time.Minute {
+		return failures.NewValidationError(errors.Errorf("invalid expiry %s", o.Expires), "the URL must be valid for at least one minute")
+	}
+
+	if o.IndexURL != "" {
+		if parsed, err := url.Parse(o.IndexURL); err != nil || !parsed.IsAbs() {
+			return failures.NewValidationError(errors.Errorf("invalid index URL %q", o.IndexURL), "index URL must be an absolute URL")
+		}
+	}
+
+	type requestParam struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	var params []requestParam
+
+	for _, item := range o.Params {
+		parts := strings.SplitN(item, "=", 2)
+
+		if len(parts) != 2 {
+			return failures.NewValidationError(errors.Errorf("invalid parameter format %s", item), "parameters must be given as name=value")
+		}
+
+		params = append(params, requestParam{Name: parts[0], Value: parts[1]})
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, o.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if environment == nil {
+		return failures.NewNotFoundError(errors.Errorf("unable to find workshop %q in training portal %q", o.Name, o.Portal), "run `educates cluster workshop list` to see deployed workshops")
+	}
+
+	portalURL, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
+
+	// The training portal requires somewhere to send the user when the
+	// workshop session ends, so default to the workshops catalog.
+
+	indexURL := o.IndexURL
+
+	if indexURL == "" {
+		indexURL = fmt.Sprintf("%s/workshops/catalog/", portalURL)
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	// The timeout given to the training portal is how long the workshop
+	// session is held for the user waiting for the URL to be visited. If the
+	// user already has a session for the workshop, that session is returned
+	// instead of a new session being created.
+
+	query := url.Values{}
+
+	query.Set("index_url", indexURL)
+	query.Set("timeout", fmt.Sprintf("%d", int64(o.Expires.Seconds())))
+
+	if o.User != "" {
+		query.Set("user", o.User)
+	}
+
+	if o.Email != "" {
+		query.Set("email", o.Email)
+	}
+
+	if o.FirstName != "" {
+		query.Set("first_name", o.FirstName)
+	}
+
+	if o.LastName != "" {
+		query.Set("last_name", o.LastName)
+	}
+
+	var body *bytes.Buffer
+
+	if len(params) != 0 {
+		data, err := json.Marshal(map[string]interface{}{"parameters": params})
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode request parameters")
+		}
+
+		body = bytes.NewBuffer(data)
+	}
+
+	path := fmt.Sprintf("/workshops/environment/%s/request/?%s", url.PathEscape(environment.GetName()), query.Encode())
+
+	var statusCode int
+	var resBody []byte
+
+	if body != nil {
+		statusCode, resBody, err = portalClient.Request(ctx, "POST", path, body)
+	} else {
+		statusCode, resBody, err = portalClient.Request(ctx, "GET", path, nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if statusCode == http.StatusServiceUnavailable {
+		return failures.NewNotFoundError(errors.Errorf("no workshop sessions available for workshop %q", o.Name), "increase the capacity of the workshop, or try again later")
+	}
+
+	if statusCode != http.StatusOK {
+		return errors.Errorf("training portal returned status %d: %s", statusCode, strings.TrimSpace(string(resBody)))
+	}
+
+	var session struct {
+		Name string `json:"name"`
+		User string `json:"user"`
+		URL  string `json:"url"`
+	}
+
+	if err = json.Unmarshal(resBody, &session); err != nil {
+		return errors.Wrap(err, "unable to decode response from training portal")
+	}
+
+	handoff := workshopHandoff{
+		Workshop: o.Name,
+		Session:  session.Name,
+		User:     session.User,
+		URL:      portalURL + session.URL,
+		Expires:  time.Now().Add(o.Expires).UTC().Truncate(time.Second),
+	}
+
+	if o.Shorten {
+		if handoff.URL, err = shortenURL(ctx, clusterConfig, handoff.URL); err != nil {
+			return errors.Wrap(err, "unable to generate short link")
+		}
+	}
+
+	if o.Output == "json" {
+		data, err := json.MarshalIndent(handoff, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode workshop session details")
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	fmt.Printf("Session: %s\n", handoff.Session)
+	fmt.Printf("User:    %s\n", handoff.User)
+	fmt.Printf("URL:     %s\n", handoff.URL)
+	fmt.Printf("Expires: %s\n", handoff.Expires.Format(time.RFC3339))
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopHandoffCmd() *cobra.Command {
+	var o ClusterWorkshopHandoffOptions
+
+	var c = &cobra.Command{
+		Args:              cobra.ExactArgs(1),
+		Use:               "handoff NAME",
+		Short:             "Create URL taking user straight to workshop session",
+		ValidArgsFunction: completeWorkshopNames,
+		Long: `Create a URL taking a user straight into a workshop session.
+
+Uses the REST API of the training portal to allocate a workshop session for
+the workshop to the user given, creating the user if they don't already
+exist, and prints a URL which logs the user in and takes them straight to the
+workshop session. Where the user already has a workshop session for the
+workshop, the URL for that session is printed instead. The URL contains an
+access token for the workshop session and no other credentials are required,
+so it can be included in an email or as a deep link from a learning
+management system.
+
+The workshop session is held for the user until the URL is visited, or until
+the expiry given by --expires, after which the workshop session is released
+and the URL no longer works. The URL only ever gives access to the one
+workshop session, and stops working when the workshop session ends. Because
+a workshop session is held for each URL until it expires, the expiry should
+be kept as short as practical, and the startup timeout for the workshop
+should be longer than the expiry.
+
+If no user is given, a new anonymous user is created for each URL. Use
+--param to pass request parameters for the workshop session, and --shorten
+to print a short link for the URL.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Name = args[0]
+
+			return o.Run(cmd.Context())
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.User,
+		"user",
+		"",
+		"username of the user the workshop session is for",
+	)
+	c.Flags().StringVar(
+		&o.Email,
+		"email",
+		"",
+		"email address to record for the user",
+	)
+	c.Flags().StringVar(
+		&o.FirstName,
+		"first-name",
+		"",
+		"first name to record for the user",
+	)
+	c.Flags().StringVar(
+		&o.LastName,
+		"last-name",
+		"",
+		"last name to record for the user",
+	)
+	c.Flags().StringArrayVar(
+		&o.Params,
+		"param",
+		[]string{},
+		"set request parameter data value, as string, (format name=value)",
+	)
+	c.Flags().StringVar(
+		&o.IndexURL,
+		"index-url",
+		"",
+		"URL users are returned to when the workshop session ends",
+	)
+	c.Flags().DurationVar(
+		&o.Expires,
+		"expires",
+		time.Hour,
+		"how long the workshop session is held waiting for the URL to be visited",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"text",
+		"output format, one of text or json",
+	)
+	c.Flags().BoolVar(
+		&o.Shorten,
+		"shorten",
+		false,
+		"print a short link for the URL",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
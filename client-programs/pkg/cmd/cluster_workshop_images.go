@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+/*
+An image reference in a workshop definition which was rewritten to pull from
+a mirror registry.
+*/
+type imageRewrite struct {
+	Location string
+	From     string
+	To       string
+}
+
+/*
+Return the reference for an image when pulled from a mirror registry. The
+registry host of the image is replaced by the mirror and the repository path
+is kept, with images from Docker Hub given their full path, so "ubuntu:22.04"
+becomes "mirror/library/ubuntu:22.04". Images given using session variables,
+short names for workshop base images resolved by the platform, and images
+already pulled from the mirror are left alone.
+*/
+func mirrorImageReference(image string, mirror string) (string, bool) {
+	mirror = strings.TrimSuffix(mirror, "/")
+
+	if image == "" || strings.HasPrefix(image, "$(") || strings.HasSuffix(image, ":*") {
+		return image, false
+	}
+
+	if strings.HasPrefix(image, mirror+"/") {
+		return image, false
+	}
+
+	parts := strings.SplitN(image, "/", 2)
+
+	var repository string
+
+	if len(parts) == 1 {
+		repository = "library/" + image
+	} else if strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost" {
+		repository = parts[1]
+
+		if parts[0] == "docker.io" && !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	} else {
+		repository = image
+	}
+
+	return mirror + "/" + repository, true
+}
+
+/*
+Rewrite all references to images in a workshop definition to pull from a
+mirror registry, so a workshop can be deployed to a cluster without access to
+the registries the images are usually pulled from. This covers the workshop
+base image, OCI images workshop content is downloaded from, containers in
+environment and session objects, and services run using docker compose. The
+rewrites made are returned so they can be reported.
+*/
+func rewriteWorkshopImages(workshop *unstructured.Unstructured, mirror string) ([]imageRewrite, error) {
+	var rewrites []imageRewrite
+
+	err := visitWorkshopImages(workshop, func(location string, image string) string {
+		mirrored, changed := mirrorImageReference(image, mirror)
+
+		if changed {
+			rewrites = append(rewrites, imageRewrite{Location: location, From: image, To: mirrored})
+		}
+
+		return mirrored
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rewrites, nil
+}
+
+/*
+Call a function for each reference to an image in a workshop definition,
+with the location of the reference, replacing the reference with the image
+returned by the function.
+*/
+func visitWorkshopImages(workshop *unstructured.Unstructured, rewrite func(string, string) string) error {
+	if image, found, _ := unstructured.NestedString(workshop.Object, "spec", "workshop", "image"); found {
+		if err := unstructured.SetNestedField(workshop.Object, rewrite("spec.workshop.image", image), "spec", "workshop", "image"); err != nil {
+			return err
+		}
+	}
+
+	if files, found, _ := unstructured.NestedSlice(workshop.Object, "spec", "workshop", "files"); found {
+		rewriteFilesImages("spec.workshop.files", files, rewrite)
+
+		if err := unstructured.SetNestedSlice(workshop.Object, files, "spec", "workshop", "files"); err != nil {
+			return err
+		}
+	}
+
+	if packages, found, _ := unstructured.NestedSlice(workshop.Object, "spec", "workshop", "packages"); found {
+		for i, item := range packages {
+			if item, ok := item.(map[string]interface{}); ok {
+				if files, ok := item["files"].([]interface{}); ok {
+					rewriteFilesImages(fmt.Sprintf("spec.workshop.packages[%d].files", i), files, rewrite)
+				}
+			}
+		}
+
+		if err := unstructured.SetNestedSlice(workshop.Object, packages, "spec", "workshop", "packages"); err != nil {
+			return err
+		}
+	}
+
+	for _, fields := range [][]string{{"spec", "environment", "objects"}, {"spec", "session", "objects"}} {
+		objects, found, _ := unstructured.NestedSlice(workshop.Object, fields...)
+
+		if !found {
+			continue
+		}
+
+		for i, object := range objects {
+			rewriteContainerImages(fmt.Sprintf("%s[%d]", strings.Join(fields, "."), i), object, rewrite)
+		}
+
+		if err := unstructured.SetNestedSlice(workshop.Object, objects, fields...); err != nil {
+			return err
+		}
+	}
+
+	services, found, _ := unstructured.NestedMap(workshop.Object, "spec", "session", "applications", "docker", "compose", "services")
+
+	if found {
+		var names []string
+
+		for name := range services {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			if service, ok := services[name].(map[string]interface{}); ok {
+				if image, ok := service["image"].(string); ok {
+					service["image"] = rewrite(fmt.Sprintf("spec.session.applications.docker.compose.services.%s.image", name), image)
+				}
+			}
+		}
+
+		if err := unstructured.SetNestedMap(workshop.Object, services, "spec", "session", "applications", "docker", "compose", "services"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Rewrite OCI images and imgpkg bundles used as sources for downloading files.
+*/
+func rewriteFilesImages(location string, files []interface{}, rewrite func(string, string) string) {
+	for i, item := range files {
+		source, ok := item.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		if image, ok := source["image"].(map[string]interface{}); ok {
+			if url, ok := image["url"].(string); ok {
+				image["url"] = rewrite(fmt.Sprintf("%s[%d].image.url", location, i), url)
+			}
+		}
+
+		if bundle, ok := source["imgpkgBundle"].(map[string]interface{}); ok {
+			if image, ok := bundle["image"].(string); ok {
+				bundle["image"] = rewrite(fmt.Sprintf("%s[%d].imgpkgBundle.image", location, i), image)
+			}
+		}
+	}
+}
+
+/*
+Rewrite images for containers anywhere within a resource definition, the
+same as containerImages finds them.
+*/
+func rewriteContainerImages(location string, value interface{}, rewrite func(string, string) string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		var keys []string
+
+		for key := range value {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			item := value[key]
+
+			if key == "containers" || key == "initContainers" {
+				containers, _ := item.([]interface{})
+
+				for i, container := range containers {
+					if container, ok := container.(map[string]interface{}); ok {
+						if image, ok := container["image"].(string); ok {
+							container["image"] = rewrite(fmt.Sprintf("%s.%s[%d].image", location, key, i), image)
+						}
+					}
+				}
+			} else {
+				rewriteContainerImages(location+"."+key, item, rewrite)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			rewriteContainerImages(fmt.Sprintf("%s[%d]", location, i), item, rewrite)
+		}
+	}
+}
+
+func printImageRewrites(workshop string, rewrites []imageRewrite) {
+	if len(rewrites) == 0 {
+		fmt.Printf("No image references to rewrite for workshop %s.\n", workshop)
+		return
+	}
+
+	fmt.Printf("Rewrote %d image references for workshop %s:\n", len(rewrites), workshop)
+
+	for _, rewrite := range rewrites {
+		fmt.Printf("  %s: %s -> %s\n", rewrite.Location, rewrite.From, rewrite.To)
+	}
+}
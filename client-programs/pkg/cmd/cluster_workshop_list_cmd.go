@@ -19,7 +19,7 @@ type ClusterWorkshopsListOptions struct {
 	Portal     string
 }
 
-func (o *ClusterWorkshopsListOptions) Run() error {
+func (o *ClusterWorkshopsListOptions) Run(ctx context.Context) error {
 	var err error
 
 	// Ensure have portal name.
@@ -38,7 +38,7 @@ func (o *ClusterWorkshopsListOptions) Run() error {
 
 	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
 
-	trainingPortal, err := trainingPortalClient.Get(context.TODO(), o.Portal, metav1.GetOptions{})
+	trainingPortal, err := trainingPortalClient.Get(ctx, o.Portal, metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
 		fmt.Println("No workshops found.")
@@ -81,7 +81,7 @@ func (o *ClusterWorkshopsListOptions) Run() error {
 			capacityField = fmt.Sprintf("%d", sessionsMaximum)
 		}
 
-		workshop, err := workshopsClient.Get(context.TODO(), name, metav1.GetOptions{})
+		workshop, err := workshopsClient.Get(ctx, name, metav1.GetOptions{})
 
 		source := ""
 
@@ -106,7 +106,7 @@ func (p *ProjectInfo) NewClusterWorkshopListCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "list",
 		Short: "List workshops deployed to Kubernetes",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVar(
@@ -123,5 +123,7 @@ func (p *ProjectInfo) NewClusterWorkshopListCmd() *cobra.Command {
 		"name to be used for training portal and workshop name prefixes",
 	)
 
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
 	return c
 }
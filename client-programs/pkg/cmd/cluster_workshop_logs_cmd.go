@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWorkshopLogsOptions struct {
+	Name        string
+	Kubeconfig  string
+	Portal      string
+	Environment bool
+	Session     string
+	Container   string
+	Follow      bool
+	Previous    bool
+	Tail        int64
+}
+
+func (o *ClusterWorkshopLogsOptions) Run(ctx context.Context) error {
+	if o.Environment == (o.Session != "") {
+		return failures.NewValidationError(errors.New("either the environment or a session must be selected"), "supply one of `--environment` or `--session`")
+	}
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, o.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if environment == nil {
+		return failures.NewNotFoundError(errors.Errorf("no workshop environment found for workshop %q in portal %q", o.Name, o.Portal), "list workshops with `educates cluster workshop list`")
+	}
+
+	// The deployments and pods for sessions, as well as those for shared
+	// components of the workshop environment, are created in the workshop
+	// environment namespace. Pods for a session are named after the session.
+
+	namespace := environment.GetName()
+
+	sessions, err := dynamicClient.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("training.educates.dev/environment.name=%s", namespace)})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to list workshop sessions")
+	}
+
+	var sessionNames []string
+
+	for _, item := range sessions.Items {
+		sessionNames = append(sessionNames, item.GetName())
+	}
+
+	if o.Session != "" && !containsString(sessionNames, o.Session) {
+		return failures.NewNotFoundError(errors.Errorf("no session found with name %q for workshop %q", o.Session, o.Name), "list sessions with `educates cluster session list`")
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to list pods in namespace %s", namespace)
+	}
+
+	var selected []apiv1.Pod
+
+	for _, pod := range pods.Items {
+		owner := ""
+
+		for _, session := range sessionNames {
+			if strings.HasPrefix(pod.Name, session+"-") {
+				owner = session
+				break
+			}
+		}
+
+		if (o.Environment && owner == "") || (o.Session != "" && owner == o.Session) {
+			selected = append(selected, pod)
+		}
+	}
+
+	if len(selected) == 0 {
+		return failures.NewNotFoundError(errors.Errorf("no pods found in namespace %s", namespace), "")
+	}
+
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+
+	return o.streamLogs(ctx, client, namespace, selected)
+}
+
+/*
+Output the logs of the containers of the pods, prefixing each line with the
+pod and container it came from. When following the logs, they are streamed
+from all containers concurrently.
+*/
+func (o *ClusterWorkshopLogsOptions) streamLogs(ctx context.Context, client *kubernetes.Clientset, namespace string, pods []apiv1.Pod) error {
+	type source struct {
+		pod       string
+		container string
+	}
+
+	var sources []source
+
+	for _, pod := range pods {
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			if o.Container == "" || o.Container == container.Name {
+				sources = append(sources, source{pod.Name, container.Name})
+			}
+		}
+	}
+
+	if len(sources) == 0 {
+		return failures.NewNotFoundError(errors.Errorf("no container named %q found", o.Container), "")
+	}
+
+	var outputLock sync.Mutex
+
+	stream := func(s source) error {
+		options := &apiv1.PodLogOptions{Container: s.container, Follow: o.Follow, Previous: o.Previous}
+
+		if o.Tail >= 0 {
+			options.TailLines = &o.Tail
+		}
+
+		reader, err := client.CoreV1().Pods(namespace).GetLogs(s.pod, options).Stream(ctx)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to get logs for container %s of pod %s", s.container, s.pod)
+		}
+
+		defer reader.Close()
+
+		scanner := bufio.NewScanner(reader)
+
+		for scanner.Scan() {
+			outputLock.Lock()
+			fmt.Printf("[%s/%s] %s\n", s.pod, s.container, scanner.Text())
+			outputLock.Unlock()
+		}
+
+		return scanner.Err()
+	}
+
+	limit := 1
+
+	if o.Follow {
+		limit = len(sources)
+	}
+
+	errs := runParallel(len(sources), limit, func(i int) error {
+		return stream(sources[i])
+	})
+
+	failed := 0
+
+	for _, err := range errs {
+		if err != nil {
+			failed++
+
+			fmt.Fprintf(os.Stderr, "Warning: %s.\n", err)
+		}
+	}
+
+	if failed == len(sources) {
+		return errors.New("unable to get logs for any containers")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopLogsCmd() *cobra.Command {
+	var o ClusterWorkshopLogsOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "logs",
+		Short: "Output logs for deployed workshop",
+		Long: `Output logs for deployed workshop.
+
+Outputs the logs of containers for a workshop deployed to a training portal.
+With "--environment", logs are output for the shared components of the
+workshop environment, such as deployments and jobs created from the
+environment objects of the workshop and any image registry for the
+environment, which is useful when debugging why a workshop environment
+failed to be set up. With "--session", logs are output for the pods of the
+named workshop session.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the deployed workshop",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().BoolVar(
+		&o.Environment,
+		"environment",
+		false,
+		"output logs for the shared components of the workshop environment",
+	)
+	c.Flags().StringVar(
+		&o.Session,
+		"session",
+		"",
+		"name of the workshop session to output logs for",
+	)
+	c.Flags().StringVarP(
+		&o.Container,
+		"container",
+		"c",
+		"",
+		"only output logs for containers with this name",
+	)
+	c.Flags().BoolVarP(
+		&o.Follow,
+		"follow",
+		"f",
+		false,
+		"follow the logs as they are written",
+	)
+	c.Flags().BoolVar(
+		&o.Previous,
+		"previous",
+		false,
+		"output logs for the previous instance of containers which have restarted",
+	)
+	c.Flags().Int64Var(
+		&o.Tail,
+		"tail",
+		-1,
+		"number of lines from the end of the logs to output, all if negative",
+	)
+
+	c.MarkFlagRequired("name")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("session", completeWorkshopSessionNames)
+
+	return c
+}
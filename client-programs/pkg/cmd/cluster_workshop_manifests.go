@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+/*
+Write out the workshop and training portal resources which deploying the
+workshop would create, so they can be committed to a repository managed using
+a GitOps tool such as Flux or Argo CD, instead of applying them to a cluster.
+If the directory already holds the training portal resource from rendering a
+prior workshop, the workshop is added to it, just as when the training portal
+already exists in the cluster.
+*/
+func (o *ClusterWorkshopDeployOptions) writeManifests(workshop *unstructured.Unstructured, portalDefaults *config.PortalDefaultsConfig) error {
+	var err error
+
+	if o.Fleet != "" {
+		return failures.NewValidationError(errors.New("fleet cannot be used when outputting manifests"), "")
+	}
+
+	// Secrets holding credentials cannot be created when outputting
+	// manifests, so only a reference to an existing secret is allowed.
+
+	if o.GitCredentials.Token != "" || o.GitCredentials.SSHKeyFile != "" {
+		return failures.NewValidationError(errors.New("git token or SSH key cannot be used when outputting manifests"), "add the credentials with `educates cluster secrets add git` and use --git-secret")
+	}
+
+	if o.GitCredentials.Secret != "" {
+		err = addGitCredentialsReference(workshop, o.GitCredentials.Secret)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.MkdirAll(o.OutputManifests, os.ModePerm)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create manifests directory %q", o.OutputManifests)
+	}
+
+	trainingPortalFile := filepath.Join(o.OutputManifests, fmt.Sprintf("trainingportal-%s.yaml", o.Portal))
+
+	var trainingPortal *unstructured.Unstructured
+
+	var trainingPortalExists = true
+
+	trainingPortalData, err := os.ReadFile(trainingPortalFile)
+
+	if os.IsNotExist(err) {
+		trainingPortalExists = false
+
+		trainingPortal = training.NewTrainingPortal(o.Portal, portalDefaults)
+	} else if err != nil {
+		return errors.Wrapf(err, "unable to read training portal manifest %q", trainingPortalFile)
+	} else {
+		trainingPortal = &unstructured.Unstructured{}
+
+		err = yaml.Unmarshal(trainingPortalData, &trainingPortal.Object)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse training portal manifest %q", trainingPortalFile)
+		}
+	}
+
+	err = training.AddWorkshopToTrainingPortal(trainingPortal, trainingPortalExists, workshop, o.workshopSettings())
+
+	if err != nil {
+		return err
+	}
+
+	training.SetTrainingPortalHostname(trainingPortal, o.Hostname)
+
+	workshopFile := filepath.Join(o.OutputManifests, fmt.Sprintf("workshop-%s.yaml", workshop.GetName()))
+
+	err = writeManifest(workshopFile, workshop)
+
+	if err != nil {
+		return err
+	}
+
+	err = writeManifest(trainingPortalFile, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func writeManifest(file string, object *unstructured.Unstructured) error {
+	data, err := yaml.Marshal(object.Object)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to generate manifest for %s %q", object.GetKind(), object.GetName())
+	}
+
+	err = os.WriteFile(file, data, 0644)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write manifest %q", file)
+	}
+
+	fmt.Printf("Wrote %s.\n", file)
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+type ClusterWorkshopMoveOptions struct {
+	Kubeconfig string
+	FromPortal string
+	ToPortal   string
+	Copy       bool
+	Drain      bool
+	Timeout    time.Duration
+}
+
+/*
+Count the workshop sessions allocated to users in a workshop environment.
+*/
+func allocatedSessionsCount(ctx context.Context, dynamicClient dynamic.Interface, environment string) (int, error) {
+	sessions, err := dynamicClient.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("training.educates.dev/environment.name=%s", environment)})
+
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to list workshop sessions")
+	}
+
+	count := 0
+
+	for _, item := range sessions.Items {
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if phase == "Allocated" && item.GetDeletionTimestamp() == nil {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (o *ClusterWorkshopMoveOptions) Run(ctx context.Context, name string) error {
+	if o.FromPortal == o.ToPortal {
+		return failures.NewValidationError(errors.New("source and target training portals are the same"), "use --to-portal to give a different training portal")
+	}
+
+	if o.Copy && o.Drain {
+		return failures.NewValidationError(errors.New("sessions can only be drained when moving a workshop"), "")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	sourcePortal, err := getTrainingPortal(ctx, clusterConfig, o.FromPortal)
+
+	if err != nil {
+		return err
+	}
+
+	targetPortal, err := getTrainingPortal(ctx, clusterConfig, o.ToPortal)
+
+	if err != nil {
+		return err
+	}
+
+	// The entry for the workshop is copied as is, so the capacity, expiry
+	// and other settings for the workshop are the same in the target portal.
+	// The workshop definition itself is shared by both training portals.
+
+	var entry map[string]interface{}
+
+	workshops, _, _ := unstructured.NestedSlice(sourcePortal.Object, "spec", "workshops")
+
+	for _, item := range workshops {
+		if object, ok := item.(map[string]interface{}); ok && object["name"] == name {
+			entry = runtime.DeepCopyJSON(object)
+		}
+	}
+
+	if entry == nil {
+		return failures.NewNotFoundError(errors.Errorf("workshop %q is not hosted by training portal %q", name, o.FromPortal), "list workshops with `educates cluster workshop list`")
+	}
+
+	targetWorkshops, _, _ := unstructured.NestedSlice(targetPortal.Object, "spec", "workshops")
+
+	for _, item := range targetWorkshops {
+		if object, ok := item.(map[string]interface{}); ok && object["name"] == name {
+			return failures.NewValidationError(errors.Errorf("training portal %q already hosts a workshop named %q", o.ToPortal, name), "")
+		}
+	}
+
+	unstructured.SetNestedSlice(targetPortal.Object, append(targetWorkshops, entry), "spec", "workshops")
+
+	_, err = dynamicClient.Resource(trainingPortalResource).Update(ctx, targetPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal %q in cluster", o.ToPortal)
+	}
+
+	if o.Copy {
+		fmt.Printf("Copied workshop %s from portal %s to portal %s.\n", name, o.FromPortal, o.ToPortal)
+
+		return nil
+	}
+
+	// When draining, the workshop is paused in the source portal so no new
+	// workshop sessions are started there, while users already doing the
+	// workshop are left to finish before it is removed.
+
+	if o.Drain {
+		environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.FromPortal, name)
+
+		if err != nil {
+			return err
+		}
+
+		if environment != nil {
+			if err = setWorkshopPaused(ctx, o.Kubeconfig, o.FromPortal, name, true); err != nil {
+				return err
+			}
+
+			var deadline time.Time
+
+			if o.Timeout != 0 {
+				deadline = time.Now().Add(o.Timeout)
+			}
+
+			reported := -1
+
+			for {
+				count, err := allocatedSessionsCount(ctx, dynamicClient, environment.GetName())
+
+				if err != nil {
+					return err
+				}
+
+				if count == 0 {
+					break
+				}
+
+				if count != reported {
+					fmt.Printf("Waiting for %d workshop sessions in portal %s to finish.\n", count, o.FromPortal)
+
+					reported = count
+				}
+
+				if !deadline.IsZero() && time.Now().After(deadline) {
+					return failures.NewValidationError(errors.Errorf("timed out waiting for %d workshop sessions in portal %q to finish", count, o.FromPortal), "the workshop is now in both portals and paused in the source portal, run the command again to continue")
+				}
+
+				if err = cluster.Sleep(ctx, 10*time.Second); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Remove any scheduled changes for the workshop in the source portal so
+	// it isn't added back later.
+
+	if err = deleteWorkshopSchedules(ctx, clusterConfig, o.FromPortal, name); err != nil {
+		return err
+	}
+
+	if err = training.DeleteWorkshopResource(ctx, dynamicClient, name, o.FromPortal); err != nil {
+		return err
+	}
+
+	fmt.Printf("Moved workshop %s from portal %s to portal %s.\n", name, o.FromPortal, o.ToPortal)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopMoveCmd() *cobra.Command {
+	var o ClusterWorkshopMoveOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "move NAME",
+		Short: "Move deployed workshop to another portal",
+		Long: `Move deployed workshop to another portal.
+
+Transfers a workshop from one training portal to another, keeping the
+capacity, expiry, catalog and other settings the workshop has in the source
+portal. The workshop definition already deployed to the cluster is used by
+the target portal, so the workshop doesn't need to be deployed again from its
+source. Use --copy to add the workshop to the target portal while leaving it
+in the source portal.
+
+When the workshop is removed from the source portal, workshop sessions for
+it in that portal are cleaned up in the same way as when a workshop is
+deleted. Use --drain to instead pause the workshop in the source portal once
+added to the target portal, so new workshop sessions can only be started from
+the target portal, and wait for workshop sessions already allocated to users
+in the source portal to finish before removing it. If waiting times out, the
+workshop is left in both portals, and the command can be run again to finish
+moving the workshop.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args[0]) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVar(
+		&o.FromPortal,
+		"from-portal",
+		"educates-cli",
+		"name of the training portal hosting the workshop",
+	)
+	c.Flags().StringVar(
+		&o.ToPortal,
+		"to-portal",
+		"",
+		"name of the training portal to move the workshop to",
+	)
+	c.Flags().BoolVar(
+		&o.Copy,
+		"copy",
+		false,
+		"add the workshop to the target portal without removing it from the source portal",
+	)
+	c.Flags().BoolVar(
+		&o.Drain,
+		"drain",
+		false,
+		"wait for workshop sessions in the source portal to finish before removing it",
+	)
+	c.Flags().DurationVar(
+		&o.Timeout,
+		"timeout",
+		0,
+		"maximum time to wait for workshop sessions to finish, no limit if 0",
+	)
+
+	c.MarkFlagRequired("to-portal")
+
+	c.RegisterFlagCompletionFunc("from-portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("to-portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Network policy profiles for session namespaces. The "strict" profile only
+allows traffic into a session namespace from pods in the same namespace, and
+from namespaces which aren't session namespaces, such as those of the
+workshop environment and ingress controller, blocking traffic between the
+sessions of different users. The "open" profile removes any network policies
+for the session namespace from the workshop definition. Any other value is
+taken to be a file of network policies.
+*/
+var networkPolicyProfiles = []string{"strict", "open"}
+
+/*
+Load the network policies to apply to the session namespaces of a workshop
+for the given profile.
+*/
+func loadNetworkPolicyResources(profile string) ([]*unstructured.Unstructured, error) {
+	switch profile {
+	case "open":
+		return []*unstructured.Unstructured{}, nil
+
+	case "strict":
+		resource := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "networking.k8s.io/v1",
+				"kind":       "NetworkPolicy",
+				"metadata": map[string]interface{}{
+					"name": "educates-cli-strict",
+				},
+				"spec": map[string]interface{}{
+					"podSelector": map[string]interface{}{},
+					"policyTypes": []interface{}{"Ingress"},
+					"ingress": []interface{}{
+						map[string]interface{}{
+							"from": []interface{}{
+								map[string]interface{}{
+									"podSelector": map[string]interface{}{},
+								},
+								map[string]interface{}{
+									"namespaceSelector": map[string]interface{}{
+										"matchExpressions": []interface{}{
+											map[string]interface{}{
+												"key":      "training.educates.dev/component",
+												"operator": "NotIn",
+												"values":   []interface{}{"session"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		return []*unstructured.Unstructured{resource}, nil
+	}
+
+	data, err := os.ReadFile(profile)
+
+	if err != nil {
+		if os.IsNotExist(err) && !strings.ContainsAny(profile, "./") {
+			return nil, failures.NewValidationError(errors.Errorf("unknown network policy profile %q", profile), fmt.Sprintf("supported profiles are %s, or the path to a file of network policies", strings.Join(networkPolicyProfiles, ", ")))
+		}
+
+		return nil, errors.Wrapf(err, "couldn't read network policy file %s", profile)
+	}
+
+	resources, err := parseResourceDocuments(data, profile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resources) == 0 {
+		return nil, failures.NewValidationError(errors.Errorf("no resources found in network policy file %s", profile), "")
+	}
+
+	for _, resource := range resources {
+		if !isNetworkPolicyResource(resource) {
+			return nil, failures.NewValidationError(errors.Errorf("network policy file %s contains %s %q", profile, resource.GetKind(), resource.GetName()), "only NetworkPolicy resources can be given")
+		}
+	}
+
+	return resources, nil
+}
+
+/*
+Apply network policies to the session namespaces of a workshop, replacing
+any already defined for the session namespace in the session objects of the
+workshop.
+*/
+func applyNetworkPolicies(workshop *unstructured.Unstructured, policyResources []*unstructured.Unstructured) error {
+	if policyResources == nil {
+		return nil
+	}
+
+	objects, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "session", "objects")
+
+	var retained []interface{}
+
+	for _, item := range objects {
+		if object, ok := item.(map[string]interface{}); ok {
+			resource := unstructured.Unstructured{Object: object}
+
+			if isNetworkPolicyResource(&resource) && (resource.GetNamespace() == "" || resource.GetNamespace() == "$(session_namespace)") {
+				continue
+			}
+		}
+
+		retained = append(retained, item)
+	}
+
+	for _, resource := range policyResources {
+		resource = resource.DeepCopy()
+
+		resource.SetNamespace("$(session_namespace)")
+
+		retained = append(retained, resource.Object)
+	}
+
+	if retained == nil {
+		unstructured.RemoveNestedField(workshop.Object, "spec", "session", "objects")
+
+		return nil
+	}
+
+	return unstructured.SetNestedSlice(workshop.Object, retained, "spec", "session", "objects")
+}
+
+func isNetworkPolicyResource(resource *unstructured.Unstructured) bool {
+	gvk := resource.GroupVersionKind()
+
+	return gvk.Group == "networking.k8s.io" && gvk.Kind == "NetworkPolicy"
+}
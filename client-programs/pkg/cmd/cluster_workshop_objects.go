@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Load additional resources to be created for each workshop session. The files
+can use the same data variables as the session objects of a workshop, such
+as $(session_namespace), as they are expanded by the session manager.
+*/
+func loadSessionObjects(files []string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't read session objects file %s", file)
+		}
+
+		resources, err := parseResourceDocuments(data, file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resources) == 0 {
+			return nil, failures.NewValidationError(errors.Errorf("no resources found in session objects file %s", file), "")
+		}
+
+		objects = append(objects, resources...)
+	}
+
+	return objects, nil
+}
+
+/*
+Add resources to the session objects of a workshop. Where the workshop
+already defines a resource with the same kind, name and namespace, it is
+replaced so the resource isn't created twice.
+*/
+func applySessionObjects(workshop *unstructured.Unstructured, sessionObjects []*unstructured.Unstructured) error {
+	if len(sessionObjects) == 0 {
+		return nil
+	}
+
+	sameResource := func(a *unstructured.Unstructured, b *unstructured.Unstructured) bool {
+		return a.GroupVersionKind().GroupKind() == b.GroupVersionKind().GroupKind() && a.GetName() == b.GetName() && a.GetNamespace() == b.GetNamespace()
+	}
+
+	objects, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "session", "objects")
+
+	var retained []interface{}
+
+	for _, item := range objects {
+		if object, ok := item.(map[string]interface{}); ok {
+			resource := &unstructured.Unstructured{Object: object}
+
+			replaced := false
+
+			for _, sessionObject := range sessionObjects {
+				if sameResource(resource, sessionObject) {
+					replaced = true
+				}
+			}
+
+			if replaced {
+				continue
+			}
+		}
+
+		retained = append(retained, item)
+	}
+
+	for _, resource := range sessionObjects {
+		retained = append(retained, resource.DeepCopy().Object)
+	}
+
+	return unstructured.SetNestedSlice(workshop.Object, retained, "spec", "session", "objects")
+}
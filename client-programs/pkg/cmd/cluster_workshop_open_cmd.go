@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWorkshopOpenOptions struct {
+	Name       string
+	Kubeconfig string
+	Portal     string
+	Start      bool
+	Browser    WebBrowserOptions
+}
+
+func (o *ClusterWorkshopOpenOptions) Run(ctx context.Context) error {
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := dynamicClient.Resource(trainingPortalResource).Get(ctx, o.Portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("unable to find training portal %q", o.Portal), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to retrieve training portal %q", o.Portal)
+	}
+
+	portalURL, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
+
+	if portalURL == "" {
+		return errors.New("workshops not available")
+	}
+
+	environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, o.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if environment == nil {
+		return failures.NewNotFoundError(errors.Errorf("unable to find workshop %q in training portal %q", o.Name, o.Portal), "run `educates cluster workshop list` to see deployed workshops")
+	}
+
+	// The training portal redirects to the login page if the user isn't
+	// already logged in, returning to the requested page afterwards.
+
+	url := fmt.Sprintf("%s/workshops/catalog/", portalURL)
+
+	if o.Start {
+		url = fmt.Sprintf("%s/workshops/environment/%s/request/", portalURL, environment.GetName())
+	}
+
+	o.Browser.Shortener = func(longURL string) (string, error) {
+		return shortenURL(ctx, clusterConfig, longURL)
+	}
+
+	return o.Browser.Open(url)
+}
+
+func (p *ProjectInfo) NewClusterWorkshopOpenCmd() *cobra.Command {
+	var o ClusterWorkshopOpenOptions
+
+	var c = &cobra.Command{
+		Args:              cobra.ExactArgs(1),
+		Use:               "open NAME",
+		Short:             "Open workshop in web browser",
+		ValidArgsFunction: completeWorkshopNames,
+		Long: `Open workshop in web browser.
+
+Opens the workshops catalog of the training portal the workshop is deployed
+to. If --start is given, a workshop session is instead requested for the
+workshop, with the web browser being taken straight to the session.
+
+Where a web browser can't be opened, such as when logged in to a remote
+machine, the URL is printed instead. Use --qr-code to also print a QR code
+for the URL, so it can be opened on another device. Use --shorten to print a
+short link for the URL instead, which is easier to type during a live event.
+See "educates cluster workshop url --help" for how short links are created.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Name = args[0]
+
+			return o.Run(cmd.Context())
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().BoolVar(
+		&o.Start,
+		"start",
+		false,
+		"request a workshop session and open it instead of the workshops catalog",
+	)
+
+	o.Browser.AddFlags(c)
+	o.Browser.AddShortenFlag(c)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWorkshopPauseOptions struct {
+	Kubeconfig string
+	Portal     string
+	Name       string
+}
+
+/*
+Pause or resume the workshop environment for a workshop deployed to a
+training portal, using the REST API of the training portal.
+*/
+func setWorkshopPaused(ctx context.Context, kubeconfig string, portal string, name string, paused bool) error {
+	// Ensure have portal name.
+
+	if portal == "" {
+		portal = "educates-cli"
+	}
+
+	clusterConfig := cluster.NewClusterConfig(kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, portal)
+
+	if err != nil {
+		return err
+	}
+
+	environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, portal, name)
+
+	if err != nil {
+		return err
+	}
+
+	if environment == nil {
+		return failures.NewNotFoundError(errors.Errorf("no workshop %q deployed to training portal %q", name, portal), "list deployed workshops with `educates cluster workshop list`")
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	action := "pause"
+
+	if !paused {
+		action = "resume"
+	}
+
+	status, body, err := portalClient.Request(ctx, "POST", fmt.Sprintf("/workshops/environment/%s/%s/", url.PathEscape(environment.GetName()), action), nil)
+
+	if err != nil {
+		return err
+	}
+
+	if status != 200 {
+		return errors.Errorf("unable to %s workshop %q: %s", action, name, string(body))
+	}
+
+	if paused {
+		fmt.Printf("Workshop %s paused, it is hidden from the catalog and no new sessions will be allocated.\n", name)
+	} else {
+		fmt.Printf("Workshop %s resumed.\n", name)
+	}
+
+	return nil
+}
+
+func (o *ClusterWorkshopPauseOptions) Run(ctx context.Context) error {
+	return setWorkshopPaused(ctx, o.Kubeconfig, o.Portal, o.Name, true)
+}
+
+func (p *ProjectInfo) NewClusterWorkshopPauseCmd() *cobra.Command {
+	var o ClusterWorkshopPauseOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "pause",
+		Short: "Pause workshop deployed to training portal",
+		Long: `Pause a workshop deployed to a training portal.
+
+Hides the workshop from the catalog of the training portal and stops new
+workshop sessions being allocated for it, without deleting the workshop
+environment. Users who already have a session for the workshop can continue
+to use it until it expires, and sessions kept in reserve are left running so
+they are ready when the workshop is resumed. Use "educates cluster workshop
+resume" to make the workshop available again, such as between course
+modules or after fixing broken content.
+
+Whether a workshop is paused is kept by the training portal, and is carried
+over if the workshop environment is replaced when the workshop definition is
+updated.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the deployed workshop",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	c.MarkFlagRequired("name")
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+/*
+Machine readable description of the changes a command would make to the
+cluster. The layout follows that of plans output by Terraform, so that the
+same tooling can be used to review and gate on the changes.
+*/
+type changePlan struct {
+	FormatVersion   string           `json:"format_version"`
+	ResourceChanges []resourceChange `json:"resource_changes"`
+}
+
+type resourceChange struct {
+	Kind      string         `json:"kind"`
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace,omitempty"`
+	Change    resourceDetail `json:"change"`
+}
+
+type resourceDetail struct {
+	Actions []string               `json:"actions"`
+	Before  map[string]interface{} `json:"before"`
+	After   map[string]interface{} `json:"after"`
+}
+
+/*
+Describe the change from the existing state of a resource to the state it
+would have after the change. Either may be nil, for a resource being created
+or deleted. Fields managed by the cluster are removed before comparing.
+*/
+func newResourceChange(kind string, name string, namespace string, before *unstructured.Unstructured, after *unstructured.Unstructured) resourceChange {
+	change := resourceChange{Kind: kind, Name: name, Namespace: namespace}
+
+	if before != nil {
+		before = before.DeepCopy()
+
+		cleanExportedResource(before)
+
+		change.Change.Before = before.Object
+	}
+
+	if after != nil {
+		after = after.DeepCopy()
+
+		cleanExportedResource(after)
+
+		change.Change.After = after.Object
+	}
+
+	switch {
+	case before == nil && after == nil:
+		change.Change.Actions = []string{"no-op"}
+	case before == nil:
+		change.Change.Actions = []string{"create"}
+	case after == nil:
+		change.Change.Actions = []string{"delete"}
+	case reflect.DeepEqual(change.Change.Before, change.Change.After):
+		change.Change.Actions = []string{"no-op"}
+	default:
+		change.Change.Actions = []string{"update"}
+	}
+
+	return change
+}
+
+func printChangePlan(changes []resourceChange) error {
+	plan := changePlan{FormatVersion: "1.0", ResourceChanges: changes}
+
+	data, err := json.MarshalIndent(&plan, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate plan")
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
+
+/*
+Work out the change to the secret holding credentials for downloading
+workshop content, and reference the secret from the workshop definition.
+Values in the secret are not included in the plan.
+*/
+func planGitCredentials(ctx context.Context, client *kubernetes.Clientset, workshop *unstructured.Unstructured, credentials GitCredentialsFlags) ([]resourceChange, error) {
+	var changes []resourceChange
+
+	if !credentials.isSet() {
+		return nil, nil
+	}
+
+	secretName := credentials.Secret
+
+	if secretName == "" {
+		secretName = fmt.Sprintf("%s-git-credentials", workshop.GetName())
+
+		secretType := "kubernetes.io/basic-auth"
+
+		if credentials.SSHKeyFile != "" {
+			secretType = "kubernetes.io/ssh-auth"
+		}
+
+		desired := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name":      secretName,
+				"namespace": "educates-secrets",
+			},
+			"type": secretType,
+		}}
+
+		var existing *unstructured.Unstructured
+
+		secret, err := client.CoreV1().Secrets("educates-secrets").Get(ctx, secretName, metav1.GetOptions{})
+
+		if err == nil {
+			existing = &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata": map[string]interface{}{
+					"name":      secretName,
+					"namespace": "educates-secrets",
+				},
+				"type": string(secret.Type),
+			}}
+		} else if !k8serrors.IsNotFound(err) {
+			return nil, errors.Wrap(err, "unable to read secrets from cluster")
+		}
+
+		change := newResourceChange("Secret", secretName, "educates-secrets", existing, desired)
+
+		// Values in the secret aren't compared, so always report an existing
+		// secret as being updated.
+
+		if existing != nil {
+			change.Change.Actions = []string{"update"}
+		}
+
+		changes = append(changes, change)
+	}
+
+	err := addGitCredentialsReference(workshop, secretName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+/*
+Work out the change to the workshop definition in the cluster. A dry run of
+the update is made so that the state after the change is exactly what the
+cluster would store.
+*/
+func planWorkshopResource(ctx context.Context, client dynamic.Interface, workshop *unstructured.Unstructured) (resourceChange, error) {
+	workshopsClient := client.Resource(workshopResource)
+
+	before, err := workshopsClient.Get(ctx, workshop.GetName(), metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		before = nil
+	} else if err != nil {
+		return resourceChange{}, errors.Wrapf(err, "unable to query workshop definition in cluster %q", workshop.GetName())
+	}
+
+	workshopBytes, err := runtime.Encode(unstructured.UnstructuredJSONScheme, workshop)
+
+	if err != nil {
+		return resourceChange{}, errors.Wrapf(err, "unable to encode workshop definition %q", workshop.GetName())
+	}
+
+	after, err := workshopsClient.Patch(ctx, workshop.GetName(), types.ApplyPatchType, workshopBytes, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true, DryRun: []string{metav1.DryRunAll}}.ToPatchOptions())
+
+	if err != nil {
+		return resourceChange{}, errors.Wrapf(err, "unable to plan update of workshop definition in cluster %q", workshop.GetName())
+	}
+
+	return newResourceChange("Workshop", workshop.GetName(), "", before, after), nil
+}
+
+/*
+Work out the change to the training portal when the workshop is deployed. As
+with the workshop definition a dry run of the update is made.
+*/
+func planTrainingPortal(ctx context.Context, client dynamic.Interface, workshop *unstructured.Unstructured, portal string, portalDefaults *config.PortalDefaultsConfig, hostname string, settings *training.WorkshopSettings) (resourceChange, error) {
+	trainingPortalClient := client.Resource(trainingPortalResource)
+
+	before, err := trainingPortalClient.Get(ctx, portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		before = nil
+	} else if err != nil {
+		return resourceChange{}, errors.Wrapf(err, "unable to query training portal %q in cluster", portal)
+	}
+
+	trainingPortal, trainingPortalExists, err := training.PrepareTrainingPortal(ctx, client, workshop, portal, portalDefaults, hostname, settings)
+
+	if err != nil {
+		return resourceChange{}, err
+	}
+
+	var after *unstructured.Unstructured
+
+	if trainingPortalExists {
+		after, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli", DryRun: []string{metav1.DryRunAll}})
+	} else {
+		after, err = trainingPortalClient.Create(ctx, trainingPortal, metav1.CreateOptions{FieldManager: "educates-cli", DryRun: []string{metav1.DryRunAll}})
+	}
+
+	if err != nil {
+		return resourceChange{}, errors.Wrapf(err, "unable to plan update of training portal %q in cluster", portal)
+	}
+
+	return newResourceChange("TrainingPortal", portal, "", before, after), nil
+}
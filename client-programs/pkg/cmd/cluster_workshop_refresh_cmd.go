@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWorkshopRefreshOptions struct {
+	Kubeconfig string
+	Portal     string
+	All        bool
+	Parallel   int
+	Timeout    time.Duration
+}
+
+func (o *ClusterWorkshopRefreshOptions) Run(ctx context.Context, names []string) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.All == (len(names) != 0) {
+		return failures.NewValidationError(errors.New("either workshop names or --all must be given"), "")
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	if o.All {
+		workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+		for _, item := range workshops {
+			if entry, ok := item.(map[string]interface{}); ok {
+				if name, ok := entry["name"].(string); ok && name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+
+		if len(names) == 0 {
+			return failures.NewNotFoundError(errors.Errorf("no workshops deployed to training portal %q", o.Portal), "deploy a workshop with `educates cluster workshop deploy`")
+		}
+	}
+
+	// Workshop environments are replaced using the REST API of the training
+	// portal, which works whether or not the training portal is configured
+	// to replace workshop environments when a workshop definition changes.
+	// The replacement downloads the workshop content again, so picks up any
+	// newly published version of it.
+
+	refresh := func(i int) error {
+		name := names[i]
+
+		environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, name)
+
+		if err != nil {
+			return err
+		}
+
+		if environment == nil {
+			return failures.NewNotFoundError(errors.Errorf("no workshop %q deployed to training portal %q", name, o.Portal), "list deployed workshops with `educates cluster workshop list`")
+		}
+
+		portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+		if err != nil {
+			return err
+		}
+
+		status, body, err := portalClient.Request(ctx, "POST", fmt.Sprintf("/workshops/environment/%s/replace/", url.PathEscape(environment.GetName())), nil)
+
+		portalClient.Logout(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if status != 200 {
+			return errors.Errorf("unable to replace workshop environment %q: %s", environment.GetName(), string(body))
+		}
+
+		current, err := waitForReplacementEnvironment(ctx, dynamicClient, o.Portal, name, environment.GetName(), o.Timeout)
+
+		if err != nil {
+			return err
+		}
+
+		if len(names) == 1 {
+			fmt.Printf("Workshop %s refreshed, workshop environment %s replaced by %s.\n", name, environment.GetName(), current)
+		}
+
+		return nil
+	}
+
+	errs := runParallel(len(names), o.Parallel, refresh)
+
+	if len(names) == 1 {
+		return errs[0]
+	}
+
+	failed := 0
+
+	for i, err := range errs {
+		if err != nil {
+			failed++
+
+			fmt.Fprintf(os.Stderr, "%s: failed: %s\n", names[i], err)
+		} else {
+			fmt.Printf("%s: refreshed\n", names[i])
+		}
+	}
+
+	if failed != 0 {
+		return errors.Errorf("failed to refresh %d of %d workshops", failed, len(names))
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopRefreshCmd() *cobra.Command {
+	var o ClusterWorkshopRefreshOptions
+
+	var c = &cobra.Command{
+		Args:              cobra.ArbitraryArgs,
+		Use:               "refresh [NAME...]",
+		Short:             "Recreate workshop environments for workshops",
+		ValidArgsFunction: completeWorkshopNames,
+		Long: `Recreate the workshop environments for deployed workshops.
+
+Asks the training portal to replace the workshop environment for each of the
+named workshops, or for all workshops deployed to the training portal if
+--all is given, and waits for the new workshop environments to be running.
+As the workshop content is downloaded again by the new workshop environment,
+this can be used to pick up newly published workshop content where the
+workshop definition itself hasn't changed, such as when the content is
+published to the same image tag.
+
+Sessions already allocated to users in the existing workshop environment are
+left running until they end, with the workshop environment then being
+deleted. Sessions kept in reserve are created again in the new workshop
+environment. When refreshing several workshops, up to --parallel workshops
+are refreshed at the same time.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().BoolVar(
+		&o.All,
+		"all",
+		false,
+		"refresh all workshops deployed to the training portal",
+	)
+	c.Flags().IntVar(
+		&o.Parallel,
+		"parallel",
+		4,
+		"maximum number of workshops to refresh at the same time",
+	)
+	c.Flags().DurationVar(
+		&o.Timeout,
+		"timeout",
+		10*time.Minute,
+		"maximum time to wait for each new workshop environment to be running",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
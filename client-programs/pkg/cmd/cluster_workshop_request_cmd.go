@@ -10,14 +10,14 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
-	"runtime"
 	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,7 +39,7 @@ type ClusterWorkshopRequestOptions struct {
 	DataValuesFlags yttcmd.DataValuesFlags
 }
 
-func (o *ClusterWorkshopRequestOptions) Run() error {
+func (o *ClusterWorkshopRequestOptions) Run(ctx context.Context) error {
 	var err error
 
 	var name = o.Name
@@ -52,7 +52,7 @@ func (o *ClusterWorkshopRequestOptions) Run() error {
 		parts := strings.SplitN(item, "=", 2)
 
 		if len(parts) != 2 {
-			return errors.Errorf("invalid parameter format %s", item)
+			return failures.NewValidationError(errors.Errorf("invalid parameter format %s", item), "parameters must be given as name=value")
 		}
 
 		params[parts[0]] = parts[1]
@@ -62,7 +62,7 @@ func (o *ClusterWorkshopRequestOptions) Run() error {
 		parts := strings.SplitN(item, "=", 2)
 
 		if len(parts) != 2 {
-			return errors.Errorf("invalid parameter format %s", item)
+			return failures.NewValidationError(errors.Errorf("invalid parameter format %s", item), "parameters must be given as name=value")
 		}
 
 		content, err := os.ReadFile(parts[1])
@@ -111,7 +111,14 @@ func (o *ClusterWorkshopRequestOptions) Run() error {
 
 		var workshop *unstructured.Unstructured
 
-		if workshop, err = loadWorkshopDefinition(o.Name, path, o.Portal, o.WorkshopFile, o.WorkshopVersion, o.DataValuesFlags); err != nil {
+		if workshop, err = training.LoadWorkshopDefinition(&training.DefinitionOptions{
+			Name:            o.Name,
+			Path:            path,
+			Portal:          o.Portal,
+			WorkshopFile:    o.WorkshopFile,
+			WorkshopVersion: o.WorkshopVersion,
+			DataValuesFlags: o.DataValuesFlags,
+		}); err != nil {
 			return err
 		}
 
@@ -128,7 +135,7 @@ func (o *ClusterWorkshopRequestOptions) Run() error {
 
 	// Request the workshop from the training portal.
 
-	err = requestWorkshop(dynamicClient, name, o.Portal, params, o.IndexUrl)
+	err = requestWorkshop(ctx, dynamicClient, name, o.Portal, params, o.IndexUrl)
 
 	if err != nil {
 		return err
@@ -144,7 +151,7 @@ func (p *ProjectInfo) NewClusterWorkshopRequestCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "request",
 		Short: "Request workshop in Kubernetes",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVarP(
@@ -254,16 +261,19 @@ func (p *ProjectInfo) NewClusterWorkshopRequestCmd() *cobra.Command {
 		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
 	)
 
-	return c
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+
+	return withAuditLogging(c)
 }
 
-func requestWorkshop(client dynamic.Interface, name string, portal string, params map[string]string, indexUrl string) error {
+func requestWorkshop(ctx context.Context, client dynamic.Interface, name string, portal string, params map[string]string, indexUrl string) error {
 	trainingPortalClient := client.Resource(trainingPortalResource)
 
-	trainingPortal, err := trainingPortalClient.Get(context.TODO(), portal, metav1.GetOptions{})
+	trainingPortal, err := trainingPortalClient.Get(ctx, portal, metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
-		return errors.Wrap(err, "unable to retrieve training portal")
+		return failures.NewNotFoundError(errors.Wrap(err, "unable to retrieve training portal"), "run `educates cluster portal list` to see available training portals")
 	}
 
 	workshops, _, err := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
@@ -283,7 +293,7 @@ func requestWorkshop(client dynamic.Interface, name string, portal string, param
 	}
 
 	if !foundWorkshop {
-		return errors.Wrapf(err, "unable to find workshop %s", name)
+		return failures.NewNotFoundError(errors.Errorf("unable to find workshop %s", name), "run `educates cluster workshop list` to see deployed workshops")
 	}
 
 	// Login to the training portal.
@@ -440,7 +450,7 @@ func requestWorkshop(client dynamic.Interface, name string, portal string, param
 	}
 
 	if environmentName == "" {
-		return errors.Errorf("cannot find workshop environment for workshop %s", name)
+		return failures.NewNotFoundError(errors.Errorf("cannot find workshop environment for workshop %s", name), "the workshop may still be starting up, run `educates cluster workshop list` to check its status")
 	}
 
 	// Now request the workshop from the required workshop environment.
@@ -524,16 +534,7 @@ func requestWorkshop(client dynamic.Interface, name string, portal string, param
 
 	fmt.Println(workshopUrl)
 
-	switch runtime.GOOS {
-	case "linux":
-		err = exec.Command("xdg-open", workshopUrl).Start()
-	case "windows":
-		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", workshopUrl).Start()
-	case "darwin":
-		err = exec.Command("open", workshopUrl).Start()
-	default:
-		err = fmt.Errorf("unsupported platform")
-	}
+	err = openWebBrowser(workshopUrl)
 
 	if err != nil {
 		return errors.Wrap(err, "unable to open web browser on workshop")
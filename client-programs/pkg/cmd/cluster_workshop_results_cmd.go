@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Progress of a user through a workshop as recorded by the training portal.
+Tasks are the examiner tests in the workshop instructions which the user has
+run, keyed by the name of the test.
+*/
+type workshopResult struct {
+	Session     string     `json:"session"`
+	Environment string     `json:"environment"`
+	User        string     `json:"user"`
+	Email       string     `json:"email"`
+	State       string     `json:"state"`
+	Started     *time.Time `json:"started"`
+	Pages       struct {
+		Furthest int `json:"furthest"`
+		Total    int `json:"total"`
+	} `json:"pages"`
+	Finished *time.Time `json:"finished"`
+	Tasks    map[string]struct {
+		Passed   bool   `json:"passed"`
+		Attempts int    `json:"attempts"`
+		Page     string `json:"page"`
+	} `json:"tasks"`
+}
+
+/*
+Return the number of tasks passed by the user.
+*/
+func (r *workshopResult) passedTasks() int {
+	count := 0
+
+	for _, task := range r.Tasks {
+		if task.Passed {
+			count++
+		}
+	}
+
+	return count
+}
+
+type ClusterWorkshopResultsOptions struct {
+	Kubeconfig string
+	Portal     string
+	Name       string
+	Output     string
+}
+
+func (o *ClusterWorkshopResultsOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Output != "table" && o.Output != "csv" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are table, csv and json")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, cluster.NewClusterConfig(o.Kubeconfig), o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	portalClient, err := NewTrainingPortalClient(ctx, trainingPortal)
+
+	if err != nil {
+		return err
+	}
+
+	defer portalClient.Logout(ctx)
+
+	status, resBody, err := portalClient.Request(ctx, "GET", fmt.Sprintf("/workshops/workshop/%s/results/", url.PathEscape(o.Name)), nil)
+
+	if err != nil {
+		return err
+	}
+
+	if status != 200 {
+		return errors.Errorf("unable to retrieve results for workshop %q, training portal returned status %d", o.Name, status)
+	}
+
+	var response struct {
+		Results []workshopResult `json:"results"`
+	}
+
+	if err = json.Unmarshal(resBody, &response); err != nil {
+		return errors.Wrap(err, "unable to decode response from training portal")
+	}
+
+	results := response.Results
+
+	switch o.Output {
+	case "json":
+		jsonData, err := json.MarshalIndent(results, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode results")
+		}
+
+		fmt.Println(string(jsonData))
+
+		return nil
+	case "csv":
+		return writeWorkshopResultsCSV(os.Stdout, results)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No results found.")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "USER", "SESSION", "STATE", "PAGES", "FINISHED", "TASKS PASSED")
+
+	for _, result := range results {
+		finished := "-"
+
+		if result.Finished != nil {
+			finished = result.Finished.Local().Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\t%d/%d\n", result.User, result.Session, result.State, result.Pages.Furthest, result.Pages.Total, finished, result.passedTasks(), len(result.Tasks))
+	}
+
+	return nil
+}
+
+/*
+Write the results as CSV, with a row for each session. There is a column for
+each examiner test run by any user, holding whether the user passed the test
+and how many attempts they made, so that the results can be imported into a
+spreadsheet for grading.
+*/
+func writeWorkshopResultsCSV(out io.Writer, results []workshopResult) error {
+	taskNames := map[string]bool{}
+
+	for _, result := range results {
+		for name := range result.Tasks {
+			taskNames[name] = true
+		}
+	}
+
+	var tasks []string
+
+	for name := range taskNames {
+		tasks = append(tasks, name)
+	}
+
+	sort.Strings(tasks)
+
+	w := csv.NewWriter(out)
+
+	header := []string{"user", "email", "session", "environment", "state", "started", "pages_viewed", "pages_total", "finished", "tasks_passed", "tasks_attempted"}
+
+	for _, name := range tasks {
+		header = append(header, name+":passed", name+":attempts")
+	}
+
+	if err := w.Write(header); err != nil {
+		return errors.Wrap(err, "unable to write results")
+	}
+
+	formatTime := func(value *time.Time) string {
+		if value == nil {
+			return ""
+		}
+
+		return value.UTC().Format(time.RFC3339)
+	}
+
+	for _, result := range results {
+		row := []string{
+			result.User,
+			result.Email,
+			result.Session,
+			result.Environment,
+			result.State,
+			formatTime(result.Started),
+			strconv.Itoa(result.Pages.Furthest),
+			strconv.Itoa(result.Pages.Total),
+			formatTime(result.Finished),
+			strconv.Itoa(result.passedTasks()),
+			strconv.Itoa(len(result.Tasks)),
+		}
+
+		for _, name := range tasks {
+			task, found := result.Tasks[name]
+
+			if !found {
+				row = append(row, "", "0")
+				continue
+			}
+
+			row = append(row, strconv.FormatBool(task.Passed), strconv.Itoa(task.Attempts))
+		}
+
+		if err := w.Write(row); err != nil {
+			return errors.Wrap(err, "unable to write results")
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+func (p *ProjectInfo) NewClusterWorkshopResultsCmd() *cobra.Command {
+	var o ClusterWorkshopResultsOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "results NAME",
+		Short: "Show results of users for deployed workshop",
+		Long: `Show results of users for a deployed workshop.
+
+Shows the progress recorded by the training portal for each user who has
+been allocated a session for the workshop. This includes the furthest page of
+the workshop instructions the user reached, whether they finished the
+workshop, and which examiner tests they have run and whether they passed.
+Use "-o csv" to export the results for grading, with a column for whether
+each examiner test was passed.
+
+Results are reported by the workshop session as the user works through the
+workshop, so a knowledgeable user could falsify them. They are kept for as
+long as the training portal keeps the record of the session, which is up to
+36 hours after the session has ended.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Name = args[0]
+
+			return o.Run(cmd.Context())
+		},
+		ValidArgsFunction: completeWorkshopNames,
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"table",
+		"output format, one of table, csv or json",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+)
+
+type ClusterWorkshopResumeOptions struct {
+	Kubeconfig string
+	Portal     string
+	Name       string
+}
+
+func (o *ClusterWorkshopResumeOptions) Run(ctx context.Context) error {
+	return setWorkshopPaused(ctx, o.Kubeconfig, o.Portal, o.Name, false)
+}
+
+func (p *ProjectInfo) NewClusterWorkshopResumeCmd() *cobra.Command {
+	var o ClusterWorkshopResumeOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "resume",
+		Short: "Resume paused workshop deployed to training portal",
+		Long: `Resume a paused workshop deployed to a training portal.
+
+Shows the workshop in the catalog of the training portal again and allows
+new workshop sessions to be allocated for it, after it was paused using
+"educates cluster workshop pause".`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name of the deployed workshop",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+
+	c.MarkFlagRequired("name")
+
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return withAuditLogging(c)
+}
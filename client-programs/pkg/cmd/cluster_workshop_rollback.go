@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// NOTE: Rolling back is done after the deploy has been interrupted, when the
+// context of the command has already been cancelled, so a separate context
+// with its own timeout is used.
+
+const rollbackTimeout = 2 * time.Minute
+
+/*
+Record of a change made to the cluster when deploying workshops. Where the
+change can be undone, the function for undoing it is held with it.
+*/
+type deployChange struct {
+	Description string
+	Undo        func(ctx context.Context) error
+}
+
+/*
+Changes made to the cluster by a deploy, in the order they were made, so that
+what was done can be reported, or undone, if the deploy fails or is
+interrupted part way through.
+*/
+type deployChanges struct {
+	mutex   sync.Mutex
+	changes []deployChange
+	saved   map[string]bool
+}
+
+/*
+Record a change which was made to the cluster. The undo function can be nil
+where the change isn't able to be undone.
+*/
+func (d *deployChanges) record(description string, undo func(ctx context.Context) error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.changes = append(d.changes, deployChange{Description: description, Undo: undo})
+}
+
+/*
+Save the current state of a resource before it is created or updated. If the
+resource doesn't exist undoing the change deletes it, otherwise its spec,
+labels and annotations are restored. Only the first change to a resource is
+recorded, as undoing that restores it to how it was before the deploy.
+*/
+func (d *deployChanges) saveResource(ctx context.Context, client dynamic.Interface, resource schema.GroupVersionResource, kind string, name string) error {
+	key := fmt.Sprintf("%s/%s", resource.Resource, name)
+
+	d.mutex.Lock()
+	saved := d.saved[key]
+	d.mutex.Unlock()
+
+	if saved {
+		return nil
+	}
+
+	resourceClient := client.Resource(resource)
+
+	previous, err := resourceClient.Get(ctx, name, metav1.GetOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to query %s %q in cluster", kind, name)
+	}
+
+	var description string
+	var undo func(ctx context.Context) error
+
+	if err != nil {
+		description = fmt.Sprintf("created %s %s", kind, name)
+
+		undo = func(ctx context.Context) error {
+			err := resourceClient.Delete(ctx, name, metav1.DeleteOptions{})
+
+			if err != nil && !k8serrors.IsNotFound(err) {
+				return errors.Wrapf(err, "unable to delete %s %q", kind, name)
+			}
+
+			return nil
+		}
+	} else {
+		description = fmt.Sprintf("updated %s %s", kind, name)
+
+		undo = func(ctx context.Context) error {
+			current, err := resourceClient.Get(ctx, name, metav1.GetOptions{})
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to query %s %q", kind, name)
+			}
+
+			current.SetLabels(previous.GetLabels())
+			current.SetAnnotations(previous.GetAnnotations())
+
+			if spec, found := previous.Object["spec"]; found {
+				current.Object["spec"] = spec
+			} else {
+				unstructured.RemoveNestedField(current.Object, "spec")
+			}
+
+			if _, err = resourceClient.Update(ctx, current, metav1.UpdateOptions{FieldManager: "educates-cli"}); err != nil {
+				return errors.Wrapf(err, "unable to restore %s %q", kind, name)
+			}
+
+			return nil
+		}
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.saved == nil {
+		d.saved = map[string]bool{}
+	}
+
+	if !d.saved[key] {
+		d.saved[key] = true
+		d.changes = append(d.changes, deployChange{Description: description, Undo: undo})
+	}
+
+	return nil
+}
+
+/*
+Output the changes which were made to the cluster. This is used where a
+deploy failed and the changes are being left in place.
+*/
+func (d *deployChanges) report(w io.Writer) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(d.changes) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "Deploy did not complete, changes made to the cluster were:")
+
+	for _, change := range d.changes {
+		fmt.Fprintf(w, "  %s\n", change.Description)
+	}
+
+	fmt.Fprintln(w, "Use --atomic to undo changes when a deploy does not complete.")
+}
+
+/*
+Undo the changes which were made to the cluster, in the reverse order to
+which they were made. Changes which can't be undone are reported, as are any
+which failed to be undone.
+*/
+func (d *deployChanges) rollback(w io.Writer) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	failed := 0
+
+	for i := len(d.changes) - 1; i >= 0; i-- {
+		change := d.changes[i]
+
+		if change.Undo == nil {
+			fmt.Fprintf(w, "Not undone: %s\n", change.Description)
+			continue
+		}
+
+		if err := change.Undo(ctx); err != nil {
+			failed++
+
+			fmt.Fprintf(w, "Failed to undo: %s: %s\n", change.Description, err)
+			continue
+		}
+
+		fmt.Fprintf(w, "Undone: %s\n", change.Description)
+	}
+
+	if failed != 0 {
+		return errors.Errorf("failed to undo %d changes made by deploy", failed)
+	}
+
+	return nil
+}
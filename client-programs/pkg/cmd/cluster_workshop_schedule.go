@@ -0,0 +1,653 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+)
+
+/*
+Scheduled changes to training portals are made by cron jobs in a namespace
+created for the purpose. Each cron job fires once, at the scheduled time,
+using kubectl to add or remove the workshop entry in the training portal,
+and then deletes itself. Cron jobs for a reserve schedule instead recur each
+week, adjusting the number of reserved sessions for the workshop. The cron
+jobs run kubectl from the workshop base image of the installed platform, so
+that they can run wherever workshop sessions can.
+*/
+const (
+	scheduleNamespace      = "educates-schedules"
+	scheduleServiceAccount = "educates-scheduler"
+	scheduleImageName      = "base-environment"
+)
+
+/*
+Parse the time given for a scheduled change. This can be a RFC 3339 time, or
+a date and time without a time zone, which is taken to be in local time.
+*/
+func parseScheduleTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04"} {
+		var when time.Time
+		var err error
+
+		if layout == time.RFC3339 {
+			when, err = time.Parse(layout, value)
+		} else {
+			when, err = time.ParseInLocation(layout, value, time.Local)
+		}
+
+		if err == nil {
+			return when, nil
+		}
+	}
+
+	return time.Time{}, failures.NewValidationError(errors.Errorf("invalid schedule time %q", value), "use the form 2006-01-02T15:04 for local time, or a RFC 3339 time")
+}
+
+/*
+Determine the image used to run the cron jobs for scheduled changes. This is
+the workshop base image the session manager would use, so is taken from the
+image versions or image registry in the configuration of the installed
+platform, and otherwise is pulled from the package repository the platform
+was installed from, which for a platform bundle is where it was relocated
+to. If a mirror registry is given, the image is rewritten to pull from it.
+*/
+func schedulerImage(ctx context.Context, clusterConfig *cluster.ClusterConfig, mirror string) (string, error) {
+	packageImage, err := operators.InstalledPackage(ctx, clusterConfig)
+
+	if err != nil {
+		return "", err
+	}
+
+	index := strings.LastIndex(packageImage, "/")
+
+	if index == -1 || !strings.Contains(packageImage[index:], ":") {
+		return "", failures.NewNotFoundError(errors.New("training platform is not installed in the cluster"), failures.PlatformHint)
+	}
+
+	repository := packageImage[:index]
+	version := packageImage[strings.LastIndex(packageImage, ":")+1:]
+
+	image := fmt.Sprintf("%s/educates-%s:%s", repository, scheduleImageName, version)
+
+	platformConfig, err := operators.InstalledConfig(ctx, clusterConfig)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s, using %s for scheduled changes.\n", err, image)
+	} else if platformConfig != nil {
+		if registry := platformConfig.ImageRegistry.Host; registry != "" {
+			if registry == "localhost" {
+				registry = "registry.default.svc.cluster.local"
+			}
+
+			if platformConfig.ImageRegistry.Namespace != "" {
+				registry = fmt.Sprintf("%s/%s", registry, platformConfig.ImageRegistry.Namespace)
+			}
+
+			image = fmt.Sprintf("%s/educates-%s:%s", registry, scheduleImageName, version)
+		}
+
+		for _, item := range platformConfig.ImageVersions {
+			if item.Name == scheduleImageName && item.Image != "" {
+				image = item.Image
+			}
+		}
+	}
+
+	if mirror != "" {
+		image, _ = mirrorImageReference(image, mirror)
+	}
+
+	return image, nil
+}
+
+/*
+Ensure the namespace, service account and permissions used by scheduled
+changes exist, returning the image to be used by the cron jobs.
+*/
+func prepareScheduler(ctx context.Context, clusterConfig *cluster.ClusterConfig, mirror string) (string, error) {
+	image, err := schedulerImage(ctx, clusterConfig, mirror)
+
+	if err != nil {
+		return "", err
+	}
+
+	objects := []*unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name": scheduleNamespace,
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ServiceAccount",
+			"metadata": map[string]interface{}{
+				"name":      scheduleServiceAccount,
+				"namespace": scheduleNamespace,
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRole",
+			"metadata": map[string]interface{}{
+				"name": scheduleServiceAccount,
+			},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"apiGroups": []interface{}{"training.educates.dev"},
+					"resources": []interface{}{"trainingportals"},
+					"verbs":     []interface{}{"get", "patch"},
+				},
+				map[string]interface{}{
+					"apiGroups": []interface{}{"training.educates.dev"},
+					"resources": []interface{}{"workshopenvironments", "workshopsessions"},
+					"verbs":     []interface{}{"get", "list"},
+				},
+				map[string]interface{}{
+					"apiGroups": []interface{}{"apps"},
+					"resources": []interface{}{"deployments"},
+					"verbs":     []interface{}{"list"},
+				},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRoleBinding",
+			"metadata": map[string]interface{}{
+				"name": scheduleServiceAccount,
+			},
+			"roleRef": map[string]interface{}{
+				"apiGroup": "rbac.authorization.k8s.io",
+				"kind":     "ClusterRole",
+				"name":     scheduleServiceAccount,
+			},
+			"subjects": []interface{}{
+				map[string]interface{}{
+					"kind":      "ServiceAccount",
+					"name":      scheduleServiceAccount,
+					"namespace": scheduleNamespace,
+				},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "Role",
+			"metadata": map[string]interface{}{
+				"name":      scheduleServiceAccount,
+				"namespace": scheduleNamespace,
+			},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"apiGroups": []interface{}{"batch"},
+					"resources": []interface{}{"cronjobs"},
+					"verbs":     []interface{}{"get", "patch", "delete"},
+				},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "RoleBinding",
+			"metadata": map[string]interface{}{
+				"name":      scheduleServiceAccount,
+				"namespace": scheduleNamespace,
+			},
+			"roleRef": map[string]interface{}{
+				"apiGroup": "rbac.authorization.k8s.io",
+				"kind":     "Role",
+				"name":     scheduleServiceAccount,
+			},
+			"subjects": []interface{}{
+				map[string]interface{}{
+					"kind":      "ServiceAccount",
+					"name":      scheduleServiceAccount,
+					"namespace": scheduleNamespace,
+				},
+			},
+		}},
+	}
+
+	if err = clusterConfig.ApplyResources(ctx, objects, "educates-cli"); err != nil {
+		return "", err
+	}
+
+	return image, nil
+}
+
+/*
+Schedule the workshop entry to be added to the training portal at the given
+time. The entry is worked out when scheduling so it has the settings given to
+the deploy command. If an entry for the workshop already exists when the job
+runs, it is left alone.
+*/
+func scheduleWorkshopStart(ctx context.Context, clusterConfig *cluster.ClusterConfig, image string, portal string, entry map[string]interface{}, when time.Time) error {
+	name, _ := entry["name"].(string)
+
+	patch, err := json.Marshal([]interface{}{
+		map[string]interface{}{"op": "add", "path": "/spec/workshops/-", "value": entry},
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to generate training portal patch for workshop %q", name)
+	}
+
+	script := fmt.Sprintf(`set -e
+if ! kubectl get trainingportal %[1]s -o jsonpath='{range .spec.workshops[*]}{.name}{"\n"}{end}' | grep -qx %[2]s; then
+  kubectl patch trainingportal %[1]s --type=json -p '%[3]s'
+fi
+kubectl delete cronjob -n %[4]s "$CRONJOB_NAME" --ignore-not-found
+`, portal, name, strings.ReplaceAll(string(patch), "'", `'\''`), scheduleNamespace)
+
+	return createScheduleCronJob(ctx, clusterConfig, image, "start", portal, name, when, script)
+}
+
+/*
+Schedule the workshop entry to be removed from the training portal at the
+given time. The index of the entry is checked as part of the patch so that
+the wrong entry can't be removed if the list changes in the meantime.
+*/
+func scheduleWorkshopEnd(ctx context.Context, clusterConfig *cluster.ClusterConfig, image string, portal string, name string, when time.Time) error {
+	script := fmt.Sprintf(`set -e
+index=$(kubectl get trainingportal %[1]s -o jsonpath='{range .spec.workshops[*]}{.name}{"\n"}{end}' | grep -nx %[2]s | cut -d: -f1 || true)
+if [ -n "$index" ]; then
+  index=$((index-1))
+  kubectl patch trainingportal %[1]s --type=json -p "[{\"op\":\"test\",\"path\":\"/spec/workshops/$index/name\",\"value\":\"%[2]s\"},{\"op\":\"remove\",\"path\":\"/spec/workshops/$index\"}]"
+fi
+kubectl delete cronjob -n %[3]s "$CRONJOB_NAME" --ignore-not-found
+`, portal, name, scheduleNamespace)
+
+	return createScheduleCronJob(ctx, clusterConfig, image, "end", portal, name, when, script)
+}
+
+func createScheduleCronJob(ctx context.Context, clusterConfig *cluster.ClusterConfig, image string, action string, portal string, workshop string, when time.Time, script string) error {
+	when = when.UTC()
+
+	schedule := fmt.Sprintf("%d %d %d %d *", when.Minute(), when.Hour(), when.Day(), int(when.Month()))
+
+	annotations := map[string]interface{}{
+		"training.educates.dev/scheduled-at": when.Format(time.RFC3339),
+	}
+
+	return applyScheduleCronJob(ctx, clusterConfig, image, scheduleCronJobName(action, portal, workshop), action, portal, workshop, schedule, "Etc/UTC", annotations, script)
+}
+
+func applyScheduleCronJob(ctx context.Context, clusterConfig *cluster.ClusterConfig, image string, cronJobName string, action string, portal string, workshop string, schedule string, timeZone string, annotations map[string]interface{}, script string) error {
+	cronJob := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata": map[string]interface{}{
+			"name":      cronJobName,
+			"namespace": scheduleNamespace,
+			"labels": map[string]interface{}{
+				"training.educates.dev/portal.name":   portal,
+				"training.educates.dev/workshop.name": workshop,
+				"training.educates.dev/schedule":      action,
+			},
+			"annotations": annotations,
+		},
+		"spec": map[string]interface{}{
+			"schedule":          schedule,
+			"timeZone":          timeZone,
+			"concurrencyPolicy": "Forbid",
+			"jobTemplate": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"backoffLimit": int64(3),
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"serviceAccountName": scheduleServiceAccount,
+							"restartPolicy":      "OnFailure",
+							"containers": []interface{}{
+								map[string]interface{}{
+									"name":    "scheduler",
+									"image":   image,
+									"command": []interface{}{"/bin/sh", "-c", script},
+									"env": []interface{}{
+										map[string]interface{}{
+											"name":  "CRONJOB_NAME",
+											"value": cronJobName,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+
+	if err := clusterConfig.ApplyResources(ctx, []*unstructured.Unstructured{cronJob}, "educates-cli"); err != nil {
+		return errors.Wrapf(err, "unable to schedule %s of workshop %q", action, workshop)
+	}
+
+	return nil
+}
+
+/*
+Generate the name of the cron job for a scheduled change. Names of cron jobs
+are limited to 52 characters, so where too long the name is truncated and a
+hash added to keep it unique.
+*/
+func scheduleCronJobName(action string, portal string, workshop string) string {
+	name := fmt.Sprintf("%s-%s-%s", portal, workshop, action)
+
+	if len(name) > 52 {
+		h := sha1.New()
+
+		io.WriteString(h, name)
+
+		hv := fmt.Sprintf("%x", h.Sum(nil))
+
+		name = fmt.Sprintf("%s-%s", strings.TrimRight(name[:44], "-."), hv[len(hv)-7:])
+	}
+
+	return name
+}
+
+/*
+Remove any scheduled changes for a workshop in a training portal.
+*/
+func deleteWorkshopSchedules(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string, workshop string) error {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return err
+	}
+
+	err = client.BatchV1().CronJobs(scheduleNamespace).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("training.educates.dev/portal.name=%s,training.educates.dev/workshop.name=%s", portal, workshop),
+	})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to remove scheduled changes for workshop %q", workshop)
+	}
+
+	return nil
+}
+
+/*
+Update the training portal with any changes made when preparing it for the
+workshop, other than the entry for the workshop itself, and schedule the
+entry to be added at the given time. The training portal is created if it
+doesn't exist so that it is ready for when the workshop is added.
+*/
+func deployScheduledWorkshopResource(ctx context.Context, clusterConfig *cluster.ClusterConfig, mirror string, trainingPortal *unstructured.Unstructured, trainingPortalExists bool, workshop string, when time.Time) error {
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	var entry map[string]interface{}
+
+	var retained []interface{}
+
+	for _, item := range workshops {
+		if object, ok := item.(map[string]interface{}); ok && object["name"] == workshop {
+			entry = object
+			continue
+		}
+
+		retained = append(retained, item)
+	}
+
+	if entry == nil {
+		return errors.Errorf("no entry for workshop %q in training portal %q", workshop, trainingPortal.GetName())
+	}
+
+	if retained == nil {
+		retained = []interface{}{}
+	}
+
+	unstructured.SetNestedSlice(trainingPortal.Object, retained, "spec", "workshops")
+
+	trainingPortalClient := dynamicClient.Resource(trainingPortalResource)
+
+	if trainingPortalExists {
+		_, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+	} else {
+		_, err = trainingPortalClient.Create(ctx, trainingPortal, metav1.CreateOptions{FieldManager: "educates-cli"})
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal %q in cluster", trainingPortal.GetName())
+	}
+
+	image, err := prepareScheduler(ctx, clusterConfig, mirror)
+
+	if err != nil {
+		return err
+	}
+
+	return scheduleWorkshopStart(ctx, clusterConfig, image, trainingPortal.GetName(), entry, when)
+}
+
+/*
+A change to the number of reserved sessions for a workshop, made at the same
+day and time each week.
+*/
+type reserveScheduleEntry struct {
+	Weekday  time.Weekday
+	Hour     int
+	Minute   int
+	Reserved uint
+}
+
+/*
+Parse a schedule for the number of reserved sessions, given as a comma
+separated list of entries of the form "Mon 08:00=20", with the day and time
+being in local time. The entries are returned ordered by time of the week.
+*/
+func parseReserveSchedule(value string) ([]reserveScheduleEntry, error) {
+	var entries []reserveScheduleEntry
+
+	hint := `use entries of the form "Mon 08:00=20" separated by commas`
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+
+		if item == "" {
+			continue
+		}
+
+		when, count, found := strings.Cut(item, "=")
+
+		fields := strings.Fields(when)
+
+		if !found || len(fields) != 2 {
+			return nil, failures.NewValidationError(errors.Errorf("invalid reserve schedule entry %q", item), hint)
+		}
+
+		weekday := -1
+
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(fields[0], day.String()[:3]) || strings.EqualFold(fields[0], day.String()) {
+				weekday = int(day)
+			}
+		}
+
+		clock, err := time.Parse("15:04", fields[1])
+
+		if weekday < 0 || err != nil {
+			return nil, failures.NewValidationError(errors.Errorf("invalid day or time in reserve schedule entry %q", item), hint)
+		}
+
+		reserved, err := strconv.ParseUint(strings.TrimSpace(count), 10, 32)
+
+		if err != nil {
+			return nil, failures.NewValidationError(errors.Errorf("invalid number of reserved sessions in reserve schedule entry %q", item), hint)
+		}
+
+		entry := reserveScheduleEntry{
+			Weekday:  time.Weekday(weekday),
+			Hour:     clock.Hour(),
+			Minute:   clock.Minute(),
+			Reserved: uint(reserved),
+		}
+
+		for _, existing := range entries {
+			if existing.Weekday == entry.Weekday && existing.Hour == entry.Hour && existing.Minute == entry.Minute {
+				return nil, failures.NewValidationError(errors.Errorf("duplicate time in reserve schedule entry %q", item), "")
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return nil, failures.NewValidationError(errors.New("reserve schedule has no entries"), hint)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].minuteOfWeek() < entries[j].minuteOfWeek()
+	})
+
+	return entries, nil
+}
+
+func (e reserveScheduleEntry) minuteOfWeek() int {
+	return (int(e.Weekday)*24+e.Hour)*60 + e.Minute
+}
+
+/*
+Return the number of reserved sessions the schedule says should apply at the
+given time, being that of the entry most recently passed, wrapping around to
+the last entry of the previous week.
+*/
+func currentReservedSessions(entries []reserveScheduleEntry, now time.Time) uint {
+	minute := (int(now.Weekday())*24+now.Hour())*60 + now.Minute()
+
+	current := entries[len(entries)-1]
+
+	for _, entry := range entries {
+		if entry.minuteOfWeek() <= minute {
+			current = entry
+		}
+	}
+
+	return current.Reserved
+}
+
+/*
+Return the IANA name of the local time zone, so that cron jobs follow local
+time across daylight saving changes, or an empty string if it can't be
+determined.
+*/
+func localTimeZoneName() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" && tz != "Local" {
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz
+		}
+	}
+
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, found := strings.Cut(target, "zoneinfo/"); found {
+			if _, err := time.LoadLocation(name); err == nil {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+/*
+Replace any schedule for the number of reserved sessions for a workshop with
+recurring cron jobs for each entry of the new schedule. Each cron job patches
+the reserved sessions of the workshop entry in the training portal, checking
+the index of the entry as part of the patch. Where the local time zone can't
+be determined, times are converted to UTC using the current offset.
+*/
+func scheduleReservedSessions(ctx context.Context, clusterConfig *cluster.ClusterConfig, image string, portal string, workshop string, entries []reserveScheduleEntry) error {
+	if err := deleteReserveSchedule(ctx, clusterConfig, portal, workshop); err != nil {
+		return err
+	}
+
+	if _, err := deleteAutoscaler(ctx, clusterConfig, portal, workshop); err != nil {
+		return err
+	}
+
+	timeZone := localTimeZoneName()
+
+	if timeZone == "" {
+		timeZone = "Etc/UTC"
+
+		fmt.Fprintf(os.Stderr, "Warning: unable to determine local time zone, reserve schedule will not follow daylight saving changes.\n")
+	}
+
+	now := time.Now()
+
+	for _, entry := range entries {
+		weekday, hour, minute := entry.Weekday, entry.Hour, entry.Minute
+
+		if timeZone == "Etc/UTC" {
+			when := time.Date(now.Year(), now.Month(), now.Day()+int(weekday-now.Weekday()), hour, minute, 0, 0, time.Local).UTC()
+
+			weekday, hour, minute = when.Weekday(), when.Hour(), when.Minute()
+		}
+
+		script := fmt.Sprintf(`set -e
+index=$(kubectl get trainingportal %[1]s -o jsonpath='{range .spec.workshops[*]}{.name}{"\n"}{end}' | grep -nx %[2]s | cut -d: -f1 || true)
+if [ -n "$index" ]; then
+  index=$((index-1))
+  kubectl patch trainingportal %[1]s --type=json -p "[{\"op\":\"test\",\"path\":\"/spec/workshops/$index/name\",\"value\":\"%[2]s\"},{\"op\":\"add\",\"path\":\"/spec/workshops/$index/reserved\",\"value\":%[3]d}]"
+fi
+`, portal, workshop, entry.Reserved)
+
+		action := fmt.Sprintf("reserve-%s-%02d%02d", strings.ToLower(entry.Weekday.String()[:3]), entry.Hour, entry.Minute)
+
+		annotations := map[string]interface{}{
+			"training.educates.dev/reserved": strconv.FormatUint(uint64(entry.Reserved), 10),
+		}
+
+		schedule := fmt.Sprintf("%d %d * * %d", minute, hour, int(weekday))
+
+		if err := applyScheduleCronJob(ctx, clusterConfig, image, scheduleCronJobName(action, portal, workshop), "reserve", portal, workshop, schedule, timeZone, annotations, script); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Remove any schedule for the number of reserved sessions for a workshop in a
+training portal.
+*/
+func deleteReserveSchedule(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string, workshop string) error {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return err
+	}
+
+	err = client.BatchV1().CronJobs(scheduleNamespace).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("training.educates.dev/portal.name=%s,training.educates.dev/workshop.name=%s,training.educates.dev/schedule=reserve", portal, workshop),
+	})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to remove reserve schedule for workshop %q", workshop)
+	}
+
+	return nil
+}
@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/renderer"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 )
 
 func calculateWorkshopRoot(path string) (string, error) {
@@ -31,7 +39,7 @@ func calculateWorkshopRoot(path string) (string, error) {
 	fileInfo, err := os.Stat(path)
 
 	if err != nil || !fileInfo.IsDir() {
-		return "", errors.New("workshop directory does not exist or path is not a directory")
+		return "", failures.NewValidationError(errors.New("workshop directory does not exist or path is not a directory"), "")
 	}
 
 	return path, nil
@@ -43,7 +51,7 @@ func calculateWorkshopRoot(path string) (string, error) {
 // 	if name == "" {
 // 		var workshop *unstructured.Unstructured
 
-// 		if workshop, err = loadWorkshopDefinition(name, path, portal, workshopFile, workshopVersion, dataValuesFlags); err != nil {
+// 		if workshop, err = training.LoadWorkshopDefinition(name, path, portal, workshopFile, workshopVersion, dataValuesFlags); err != nil {
 // 			return "", err
 // 		}
 
@@ -69,10 +77,12 @@ type ClusterWorkshopServeOptions struct {
 	WorkshopFile    string
 	WorkshopVersion string
 	PatchWorkshop   bool
+	IngressPorts    []string
+	Locale          string
 	DataValuesFlags yttcmd.DataValuesFlags
 }
 
-func (o *ClusterWorkshopServeOptions) Run() error {
+func (o *ClusterWorkshopServeOptions) Run(ctx context.Context) error {
 	var err error
 
 	var name = o.Name
@@ -94,7 +104,14 @@ func (o *ClusterWorkshopServeOptions) Run() error {
 
 	var workshop *unstructured.Unstructured
 
-	if workshop, err = loadWorkshopDefinition(name, path, portal, o.WorkshopFile, o.WorkshopVersion, o.DataValuesFlags); err != nil {
+	if workshop, err = training.LoadWorkshopDefinition(&training.DefinitionOptions{
+		Name:            name,
+		Path:            path,
+		Portal:          portal,
+		WorkshopFile:    o.WorkshopFile,
+		WorkshopVersion: o.WorkshopVersion,
+		DataValuesFlags: o.DataValuesFlags,
+	}); err != nil {
 		return err
 	}
 
@@ -102,10 +119,23 @@ func (o *ClusterWorkshopServeOptions) Run() error {
 		name = workshop.GetName()
 	}
 
+	// Work out which session ingresses are to be proxied to services on the
+	// local machine.
+
+	ingresses, err := parseIngressPorts(o.IngressPorts)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = renderer.LocaleContentDirectories(filepath.Join(path, "workshop"), o.Locale); err != nil {
+		return failures.NewValidationError(err, "")
+	}
+
 	// If going to patch hosted workshop, ensure we have an access token.
 
 	if o.PatchWorkshop && token == "" {
-		token = randomPassword(16)
+		token = training.RandomPassword(16)
 	}
 
 	// If patching hosted workshop create an apply the updated configuration.
@@ -135,6 +165,10 @@ func (o *ClusterWorkshopServeOptions) Run() error {
 
 		unstructured.SetNestedField(patchedWorkshop.Object, proxyDefinition, "spec", "session", "applications", "workshop")
 
+		if err = o.patchIngresses(patchedWorkshop, ingresses, token); err != nil {
+			return err
+		}
+
 		clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
 
 		dynamicClient, err := clusterConfig.GetDynamicClient()
@@ -145,7 +179,7 @@ func (o *ClusterWorkshopServeOptions) Run() error {
 
 		// Update the workshop resource in the Kubernetes cluster.
 
-		err = updateWorkshopResource(dynamicClient, patchedWorkshop)
+		err = training.UpdateWorkshopResource(ctx, dynamicClient, patchedWorkshop)
 
 		if err != nil {
 			return err
@@ -162,13 +196,98 @@ func (o *ClusterWorkshopServeOptions) Run() error {
 		if err == nil {
 			// Update the workshop resource in the Kubernetes cluster.
 
-			updateWorkshopResource(dynamicClient, workshop)
+			training.UpdateWorkshopResource(ctx, dynamicClient, workshop)
 		}
 	}
 
 	// Run the proxy server and Hugo server.
 
-	return renderer.RunHugoServer(path, o.Kubeconfig, name, portal, o.LocalHost, o.LocalPort, o.HugoPort, token, o.Files, cleanupFunc)
+	return renderer.RunHugoServer(path, o.Kubeconfig, name, portal, o.LocalHost, o.LocalPort, o.HugoPort, token, o.Files, ingresses, o.Locale, cleanupFunc)
+}
+
+/*
+Parse mappings of session ingress names to ports on the local machine, given
+in the form NAME=PORT.
+*/
+func parseIngressPorts(values []string) (map[string]int, error) {
+	ingresses := map[string]int{}
+
+	for _, value := range values {
+		name, portString, found := strings.Cut(value, "=")
+
+		port, err := strconv.Atoi(portString)
+
+		if !found || name == "" || err != nil || port <= 0 || port > 65535 {
+			return nil, failures.NewValidationError(errors.Errorf("invalid ingress port mapping %q", value), "use the form NAME=PORT")
+		}
+
+		if errs := validation.IsDNS1123Label(name); len(errs) != 0 {
+			return nil, failures.NewValidationError(errors.Errorf("invalid ingress name %q", name), strings.Join(errs, ", "))
+		}
+
+		ingresses[name] = port
+	}
+
+	return ingresses, nil
+}
+
+/*
+Patch the session ingresses of the hosted workshop so those mapped to ports
+on the local machine are proxied to the local proxy, replacing any existing
+ingress of the same name. Headers identify the session, the ingress and the
+access token so the local proxy can route and validate requests.
+*/
+func (o *ClusterWorkshopServeOptions) patchIngresses(workshop *unstructured.Unstructured, ingresses map[string]int, token string) error {
+	if len(ingresses) == 0 {
+		return nil
+	}
+
+	existing, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "session", "ingresses")
+
+	var retained []interface{}
+
+	for _, item := range existing {
+		if object, ok := item.(map[string]interface{}); ok {
+			if _, found := ingresses[fmt.Sprint(object["name"])]; found {
+				continue
+			}
+		}
+
+		retained = append(retained, item)
+	}
+
+	var names []string
+
+	for name := range ingresses {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		retained = append(retained, map[string]interface{}{
+			"name":     name,
+			"protocol": o.ProxyProtocol,
+			"host":     o.ProxyHost,
+			"port":     int64(o.ProxyPort),
+			"headers": []interface{}{
+				map[string]interface{}{
+					"name":  "X-Session-Name",
+					"value": "$(session_name)",
+				},
+				map[string]interface{}{
+					"name":  "X-Ingress-Name",
+					"value": name,
+				},
+				map[string]interface{}{
+					"name":  "X-Access-Token",
+					"value": token,
+				},
+			},
+		})
+	}
+
+	return unstructured.SetNestedSlice(workshop.Object, retained, "spec", "session", "ingresses")
 }
 
 func (p *ProjectInfo) NewClusterWorkshopServeCmd() *cobra.Command {
@@ -178,7 +297,27 @@ func (p *ProjectInfo) NewClusterWorkshopServeCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "serve",
 		Short: "Serve workshop from local system",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Long: `Serve workshop from local system.
+
+Runs a local server for rendering the workshop instructions from the local
+workshop directory, with a proxy in front of it. Using --patch-workshop, the
+hosted workshop is patched so that the instructions shown in sessions are
+proxied to the local server, and reflect changes as they are made.
+
+Instructions which embed dashboards or link to applications using session
+ingresses can be authored against services run on the local machine, by
+mapping the ingress name to a local port using --ingress-port, such as
+"--ingress-port app=8080". Requests for the ingress, identified by the host
+name of the ingress, are proxied by the local proxy to the port, and with
+--patch-workshop the session ingress in the hosted workshop is patched to
+be proxied to the local proxy.
+
+For workshops with instructions in more than one language, use --locale to
+select the locale to render the instructions for. Pages for a locale are
+held in a directory alongside the default content directory named with the
+locale as suffix, such as "workshop/content.fr", with pages missing for the
+locale taken from the default content directory.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVarP(
@@ -281,6 +420,19 @@ func (p *ProjectInfo) NewClusterWorkshopServeCmd() *cobra.Command {
 		"Patch hosted workshop to proxy sessions to local server ",
 	)
 
+	c.Flags().StringArrayVar(
+		&o.IngressPorts,
+		"ingress-port",
+		[]string{},
+		"proxy session ingress to port on local machine, in form NAME=PORT (can be specified multiple times)",
+	)
+	c.Flags().StringVar(
+		&o.Locale,
+		"locale",
+		"",
+		"locale to render the workshop instructions for",
+	)
+
 	c.Flags().StringArrayVar(
 		&o.DataValuesFlags.EnvFromStrings,
 		"data-values-env",
@@ -319,5 +471,8 @@ func (p *ProjectInfo) NewClusterWorkshopServeCmd() *cobra.Command {
 		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
 	)
 
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
+
 	return c
 }
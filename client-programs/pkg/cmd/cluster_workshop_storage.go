@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Name of the persistent volume claim created in the workshop namespace to hold
+the files of each session when they are to be kept after the session ends.
+*/
+const sessionFilesVolumeName = "educates-session-files"
+
+/*
+Give each session of a workshop a persistent volume for the home directory of
+the workshop user. By default the volume is created by the platform for each
+session and is deleted when the session ends. If the files are to be kept, a
+single persistent volume claim is instead created in the workshop namespace,
+with each session using a directory on it named after the session, so that
+the files remain until the workshop environment is deleted. As the volume is
+then shared between sessions, it needs to support ReadWriteMany access.
+*/
+func applySessionStorage(workshop *unstructured.Unstructured, size string, retain bool, storageClass string) error {
+	if size == "" {
+		if retain || storageClass != "" {
+			return failures.NewValidationError(errors.New("session storage options can only be given when enabling session storage"), "use --session-storage to give the size of the volume for each session")
+		}
+
+		return nil
+	}
+
+	if _, err := resource.ParseQuantity(size); err != nil {
+		return failures.NewValidationError(errors.Wrapf(err, "invalid session storage size %q", size), "")
+	}
+
+	if !retain {
+		if storageClass != "" {
+			return failures.NewValidationError(errors.New("a storage class can only be given when keeping session files"), "use --retain-session-files to keep files after the session ends")
+		}
+
+		return unstructured.SetNestedField(workshop.Object, size, "spec", "session", "resources", "storage")
+	}
+
+	if volumeName, _, _ := unstructured.NestedString(workshop.Object, "spec", "session", "resources", "volume", "name"); volumeName != "" && volumeName != sessionFilesVolumeName {
+		return failures.NewValidationError(errors.Errorf("workshop %q already uses persistent volume claim %q for sessions", workshop.GetName(), volumeName), "")
+	}
+
+	claim := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata": map[string]interface{}{
+			"name":      sessionFilesVolumeName,
+			"namespace": "$(workshop_namespace)",
+		},
+		"spec": map[string]interface{}{
+			"accessModes": []interface{}{"ReadWriteMany"},
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{
+					"storage": size,
+				},
+			},
+		},
+	}
+
+	if storageClass != "" {
+		unstructured.SetNestedField(claim, storageClass, "spec", "storageClassName")
+	}
+
+	objects, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "environment", "objects")
+
+	var retained []interface{}
+
+	for _, object := range objects {
+		if item, ok := object.(map[string]interface{}); ok && item["kind"] == "PersistentVolumeClaim" {
+			if name, _, _ := unstructured.NestedString(item, "metadata", "name"); name == sessionFilesVolumeName {
+				continue
+			}
+		}
+
+		retained = append(retained, object)
+	}
+
+	retained = append(retained, claim)
+
+	if err := unstructured.SetNestedSlice(workshop.Object, retained, "spec", "environment", "objects"); err != nil {
+		return err
+	}
+
+	unstructured.RemoveNestedField(workshop.Object, "spec", "session", "resources", "storage")
+
+	return unstructured.SetNestedMap(workshop.Object, map[string]interface{}{
+		"name":    sessionFilesVolumeName,
+		"subPath": "$(session_name)",
+	}, "spec", "session", "resources", "volume")
+}
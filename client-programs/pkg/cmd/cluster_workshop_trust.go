@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/pem"
+	"os"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Name of the secret created in the workshop namespace to hold the CA bundle
+added to the trust store of workshop sessions.
+*/
+const trustedCASecretName = "educates-trusted-ca"
+
+/*
+Read CA certificates from PEM files and combine them into a single bundle.
+Each file must contain at least one certificate, with anything other than
+certificates, such as private keys, being rejected.
+*/
+func loadCABundle(files []string) ([]byte, error) {
+	var bundle bytes.Buffer
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read CA certificate file %q", file)
+		}
+
+		count := 0
+
+		for {
+			var block *pem.Block
+
+			block, data = pem.Decode(data)
+
+			if block == nil {
+				break
+			}
+
+			if block.Type != "CERTIFICATE" {
+				return nil, failures.NewValidationError(errors.Errorf("CA certificate file %q contains a %s", file, block.Type), "only certificates should be included in the CA bundle")
+			}
+
+			pem.Encode(&bundle, block)
+
+			count++
+		}
+
+		if count == 0 {
+			return nil, failures.NewValidationError(errors.Errorf("no certificates found in CA certificate file %q", file), "CA certificates must be in PEM format")
+		}
+	}
+
+	return bundle.Bytes(), nil
+}
+
+/*
+Add a CA bundle to the trust store of the workshop container, the container
+downloading workshop content and any docker daemon of workshop sessions. The
+bundle is held in a secret created in the workshop namespace along with the
+workshop environment, which the workshop definition then references.
+*/
+func applyTrustedCertificates(workshop *unstructured.Unstructured, bundle []byte) error {
+	if len(bundle) == 0 {
+		return nil
+	}
+
+	if name, _, _ := unstructured.NestedString(workshop.Object, "spec", "session", "trust", "caCertificateRef", "name"); name != "" && name != trustedCASecretName {
+		return failures.NewValidationError(errors.Errorf("workshop %q already references CA certificate secret %q", workshop.GetName(), name), "")
+	}
+
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      trustedCASecretName,
+			"namespace": "$(workshop_namespace)",
+		},
+		"stringData": map[string]interface{}{
+			"ca.crt": string(bundle),
+		},
+	}
+
+	objects, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "environment", "objects")
+
+	var retained []interface{}
+
+	for _, object := range objects {
+		if item, ok := object.(map[string]interface{}); ok && item["kind"] == "Secret" {
+			if name, _, _ := unstructured.NestedString(item, "metadata", "name"); name == trustedCASecretName {
+				continue
+			}
+		}
+
+		retained = append(retained, object)
+	}
+
+	retained = append(retained, secret)
+
+	if err := unstructured.SetNestedSlice(workshop.Object, retained, "spec", "environment", "objects"); err != nil {
+		return err
+	}
+
+	return unstructured.SetNestedField(workshop.Object, trustedCASecretName, "spec", "session", "trust", "caCertificateRef", "name")
+}
@@ -2,39 +2,29 @@ package cmd
 
 import (
 	"context"
-	"crypto/sha1"
-	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"os"
-	"path/filepath"
-
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/runtime/serializer"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/dynamic"
-	"k8s.io/kubectl/pkg/scheme"
 )
 
 type ClusterWorkshopUpdateOptions struct {
 	Name            string
 	Path            string
 	Kubeconfig      string
+	Fleet           string
 	Portal          string
 	WorkshopFile    string
 	WorkshopVersion string
 	DataValuesFlags yttcmd.DataValuesFlags
+	GitCredentials  GitCredentialsFlags
+	Plan            bool
 }
 
-func (o *ClusterWorkshopUpdateOptions) Run() error {
+func (o *ClusterWorkshopUpdateOptions) Run(ctx context.Context) error {
 	var err error
 
 	var path = o.Path
@@ -59,27 +49,100 @@ func (o *ClusterWorkshopUpdateOptions) Run() error {
 
 	var workshop *unstructured.Unstructured
 
-	if workshop, err = loadWorkshopDefinition(o.Name, path, o.Portal, o.WorkshopFile, o.WorkshopVersion, o.DataValuesFlags); err != nil {
+	if workshop, err = training.LoadWorkshopDefinition(&training.DefinitionOptions{
+		Name:            o.Name,
+		Path:            path,
+		Portal:          o.Portal,
+		WorkshopFile:    o.WorkshopFile,
+		WorkshopVersion: o.WorkshopVersion,
+		DataValuesFlags: o.DataValuesFlags,
+	}); err != nil {
 		return err
 	}
 
-	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+	// If asked for a plan, output the changes the update would make rather
+	// than applying them to the cluster.
+
+	if o.Plan {
+		if o.Fleet != "" {
+			return failures.NewValidationError(errors.New("fleet cannot be used when outputting a plan"), "")
+		}
+
+		clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+		dynamicClient, err := clusterConfig.GetDynamicClient()
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
+
+		client, err := clusterConfig.GetClient()
 
-	dynamicClient, err := clusterConfig.GetDynamicClient()
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
+
+		changes, err := planGitCredentials(ctx, client, workshop, o.GitCredentials)
 
-	if err != nil {
-		return errors.Wrapf(err, "unable to create Kubernetes client")
+		if err != nil {
+			return err
+		}
+
+		workshopChange, err := planWorkshopResource(ctx, dynamicClient, workshop)
+
+		if err != nil {
+			return err
+		}
+
+		return printChangePlan(append(changes, workshopChange))
 	}
 
-	// Update the workshop resource in the Kubernetes cluster.
+	// Apply the change to each cluster in the fleet if one was given,
+	// otherwise to the cluster for the current kubeconfig context. Each
+	// cluster gets its own copy of the workshop definition as injecting
+	// credentials modifies it.
 
-	err = updateWorkshopResource(dynamicClient, workshop)
+	update := func(clusterConfig *cluster.ClusterConfig) error {
+		workshop := workshop.DeepCopy()
 
-	if err != nil {
-		return err
+		dynamicClient, err := clusterConfig.GetDynamicClient()
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create Kubernetes client")
+		}
+
+		// Inject any credentials required for downloading workshop content.
+
+		if o.GitCredentials.isSet() {
+			client, err := clusterConfig.GetClient()
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to create Kubernetes client")
+			}
+
+			err = injectGitCredentials(ctx, client, workshop, o.GitCredentials)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		// Update the workshop resource in the Kubernetes cluster.
+
+		err = training.UpdateWorkshopResource(ctx, dynamicClient, workshop)
+
+		if err != nil {
+			return err
+		}
+
+		return nil
 	}
 
-	return nil
+	if o.Fleet != "" {
+		return applyToFleet(o.Fleet, o.Kubeconfig, update)
+	}
+
+	return update(cluster.NewClusterConfig(o.Kubeconfig))
 }
 
 func (p *ProjectInfo) NewClusterWorkshopUpdateCmd() *cobra.Command {
@@ -89,7 +152,7 @@ func (p *ProjectInfo) NewClusterWorkshopUpdateCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "update",
 		Short: "Update workshop in Kubernetes",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
 	}
 
 	c.Flags().StringVarP(
@@ -112,6 +175,12 @@ func (p *ProjectInfo) NewClusterWorkshopUpdateCmd() *cobra.Command {
 		"",
 		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
 	)
+	c.Flags().StringVar(
+		&o.Fleet,
+		"fleet",
+		"",
+		"name of fleet of clusters to apply the change to instead of a single cluster",
+	)
 	c.Flags().StringVarP(
 		&o.Portal,
 		"portal",
@@ -172,174 +241,48 @@ func (p *ProjectInfo) NewClusterWorkshopUpdateCmd() *cobra.Command {
 		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
 	)
 
-	return c
-}
-
-func loadWorkshopDefinition(name string, path string, portal string, workshopFile string, workshopVersion string, dataValueFlags yttcmd.DataValuesFlags) (*unstructured.Unstructured, error) {
-	// Parse the workshop location so we can determine if it is a local file
-	// or accessible using a HTTP/HTTPS URL.
-
-	var urlInfo *url.URL
-	var err error
-
-	if urlInfo, err = url.Parse(path); err != nil {
-		return nil, errors.Wrap(err, "unable to parse workshop location")
-	}
-
-	// Check if file system path first (not HTTP/HTTPS) and if so normalize
-	// the path. If it the path references a directory, then extend the path
-	// so we look for the workshop file within that directory.
-
-	if urlInfo.Scheme != "http" && urlInfo.Scheme != "https" {
-		path = filepath.Clean(path)
-
-		if path, err = filepath.Abs(path); err != nil {
-			return nil, errors.Wrap(err, "couldn't convert workshop location to absolute path")
-		}
-
-		if !filepath.IsAbs(workshopFile) {
-			fileInfo, err := os.Stat(path)
-
-			if err != nil {
-				return nil, errors.Wrap(err, "couldn't test if workshop location is a directory")
-			}
-
-			if fileInfo.IsDir() {
-				path = filepath.Join(path, workshopFile)
-			}
-		} else {
-			path = workshopFile
-		}
-	}
-
-	// Read in the workshop definition as raw data ready for parsing.
-
-	var workshopData []byte
-
-	if urlInfo.Scheme != "http" && urlInfo.Scheme != "https" {
-		if workshopData, err = os.ReadFile(path); err != nil {
-			return nil, errors.Wrap(err, "couldn't read workshop definition data file")
-		}
-	} else {
-		var client http.Client
-
-		resp, err := client.Get(path)
-
-		if err != nil {
-			return nil, errors.Wrap(err, "couldn't download workshop definition from host")
-		}
-
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, errors.New("failed to download workshop definition from host")
-		}
-
-		workshopData, err = io.ReadAll(resp.Body)
-
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to read workshop definition from host")
-		}
-	}
-
-	// Process the workshop YAML data in case it contains ytt templating.
-
-	if workshopData, err = processWorkshopDefinition(workshopData, dataValueFlags); err != nil {
-		return nil, errors.Wrap(err, "unable to process workshop definition as template")
-	}
-
-	// Parse the workshop definition.
-
-	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder()
-
-	workshop := &unstructured.Unstructured{}
-
-	err = runtime.DecodeInto(decoder, workshopData, workshop)
-
-	if err != nil {
-		return nil, errors.Wrap(err, "couldn't parse workshop definition")
-	}
-
-	// Verify the type of resource definition.
-
-	if workshop.GetAPIVersion() != "training.educates.dev/v1beta1" || workshop.GetKind() != "Workshop" {
-		return nil, errors.New("invalid type for workshop definition")
-	}
-
-	// Add annotations recording details about original workshop location.
-
-	annotations := workshop.GetAnnotations()
-
-	if annotations == nil {
-		annotations = map[string]string{}
-	}
-
-	annotations["training.educates.dev/workshop"] = workshop.GetName()
-
-	if urlInfo.Scheme != "http" && urlInfo.Scheme != "https" {
-		annotations["training.educates.dev/source"] = fmt.Sprintf("file://%s", path)
-	} else {
-		annotations["training.educates.dev/source"] = path
-	}
-
-	workshop.SetAnnotations(annotations)
-
-	// Update the name for the workshop such that it incorporates a hash of
-	// the workshop location.
-
-	if name == "" {
-		name = generateWorkshopName(path, workshop, portal)
-	}
-
-	workshop.SetName(name)
-
-	// Insert workshop version property if not specified.
-
-	_, found, _ := unstructured.NestedString(workshop.Object, "spec", "version")
-
-	if !found && workshopVersion != "latest" {
-		unstructured.SetNestedField(workshop.Object, workshopVersion, "spec", "version")
-	}
-
-	// Remove the publish section as will not be accurate after publising.
-
-	unstructured.RemoveNestedField(workshop.Object, "spec", "publish")
-
-	return workshop, nil
-}
-
-func generateWorkshopName(path string, workshop *unstructured.Unstructured, portal string) string {
-	name := workshop.GetName()
-
-	h := sha1.New()
-
-	io.WriteString(h, path)
-
-	hv := fmt.Sprintf("%x", h.Sum(nil))
+	c.Flags().StringVar(
+		&o.GitCredentials.Secret,
+		"git-secret",
+		"",
+		"name of secret holding credentials for downloading workshop content from Git",
+	)
+	c.Flags().StringVar(
+		&o.GitCredentials.Username,
+		"git-username",
+		"",
+		"username for downloading workshop content from Git using a token",
+	)
+	c.Flags().StringVar(
+		&o.GitCredentials.Token,
+		"git-token",
+		"",
+		"access token for downloading workshop content from Git",
+	)
+	c.Flags().StringVar(
+		&o.GitCredentials.SSHKeyFile,
+		"git-ssh-key",
+		"",
+		"SSH private key file for downloading workshop content from Git",
+	)
+	c.Flags().StringVar(
+		&o.GitCredentials.SSHKnownHostsFile,
+		"git-ssh-known-hosts",
+		"",
+		"SSH known hosts file for verifying the Git server",
+	)
+	c.Flags().BoolVar(
+		&o.Plan,
+		"plan",
+		false,
+		"output the changes which would be made as JSON instead of applying them",
+	)
 
-	name = fmt.Sprintf("%s--%s-%s", portal, name, hv[len(hv)-7:])
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("fleet", completeFleetNames)
+	c.RegisterFlagCompletionFunc("name", completeWorkshopNames)
 
-	return name
+	return withAuditLogging(c)
 }
 
-var workshopResource = schema.GroupVersionResource{Group: "training.educates.dev", Version: "v1beta1", Resource: "workshops"}
-
-func updateWorkshopResource(client dynamic.Interface, workshop *unstructured.Unstructured) error {
-	workshopsClient := client.Resource(workshopResource)
-
-	// _, err := workshopsClient.Apply(context.TODO(), workshop.GetName(), workshop, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
-
-	workshopBytes, err := runtime.Encode(unstructured.UnstructuredJSONScheme, workshop)
-
-	if err != nil {
-		return errors.Wrapf(err, "unable to update workshop definition in cluster %q", workshop.GetName())
-	}
-
-	_, err = workshopsClient.Patch(context.TODO(), workshop.GetName(), types.ApplyPatchType, workshopBytes, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true}.ToPatchOptions())
-
-	if err != nil {
-		return errors.Wrapf(err, "unable to update workshop definition in cluster %q", workshop.GetName())
-	}
-
-	return nil
-}
+var workshopResource = training.WorkshopResource
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ClusterWorkshopURLOptions struct {
+	Name       string
+	Kubeconfig string
+	Portal     string
+	IndexURL   string
+	Output     string
+	Shorten    bool
+}
+
+/*
+Links which can be given to users for accessing a deployed workshop.
+*/
+type workshopLinks struct {
+	Workshop    string `json:"workshop"`
+	Environment string `json:"environment"`
+	Catalog     string `json:"catalog"`
+	Start       string `json:"start"`
+	Embed       string `json:"embed"`
+}
+
+func (o *ClusterWorkshopURLOptions) Run(ctx context.Context) error {
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Output != "text" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are text and json")
+	}
+
+	if o.IndexURL != "" {
+		if parsed, err := url.Parse(o.IndexURL); err != nil || !parsed.IsAbs() {
+			return failures.NewValidationError(errors.Errorf("invalid index URL %q", o.IndexURL), "index URL must be an absolute URL")
+		}
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, o.Portal)
+
+	if err != nil {
+		return err
+	}
+
+	portalURL, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
+
+	if portalURL == "" {
+		return errors.New("workshops not available")
+	}
+
+	environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, o.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if environment == nil {
+		return failures.NewNotFoundError(errors.Errorf("unable to find workshop %q in training portal %q", o.Name, o.Portal), "run `educates cluster workshop list` to see deployed workshops")
+	}
+
+	// The direct start URL logs in the user automatically where anonymous
+	// access is enabled for the training portal, otherwise the user is sent
+	// to the login page first. When an index URL is given, the user is
+	// returned there when the workshop session ends.
+
+	startURL := fmt.Sprintf("%s/workshops/environment/%s/create/", portalURL, url.PathEscape(environment.GetName()))
+
+	if o.IndexURL != "" {
+		startURL = fmt.Sprintf("%s?%s", startURL, url.Values{"index_url": []string{o.IndexURL}}.Encode())
+	}
+
+	links := workshopLinks{
+		Workshop:    o.Name,
+		Environment: environment.GetName(),
+		Catalog:     fmt.Sprintf("%s/workshops/catalog/", portalURL),
+		Start:       startURL,
+		Embed:       fmt.Sprintf("<iframe src=\"%s\" width=\"100%%\" height=\"800\" allow=\"clipboard-read; clipboard-write\"></iframe>", html.EscapeString(startURL)),
+	}
+
+	// Short links are only generated for the links users need to type in,
+	// with the embed snippet keeping the full URL as it is copied instead.
+
+	if o.Shorten {
+		if links.Catalog, err = shortenURL(ctx, clusterConfig, links.Catalog); err != nil {
+			return errors.Wrap(err, "unable to generate short link")
+		}
+
+		if links.Start, err = shortenURL(ctx, clusterConfig, links.Start); err != nil {
+			return errors.Wrap(err, "unable to generate short link")
+		}
+	}
+
+	if o.Output == "json" {
+		data, err := json.MarshalIndent(links, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode workshop links")
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	fmt.Printf("Catalog: %s\n", links.Catalog)
+	fmt.Printf("Start:   %s\n", links.Start)
+	fmt.Printf("Embed:   %s\n", links.Embed)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopURLCmd() *cobra.Command {
+	var o ClusterWorkshopURLOptions
+
+	var c = &cobra.Command{
+		Args:              cobra.ExactArgs(1),
+		Use:               "url NAME",
+		Short:             "Print links for accessing deployed workshop",
+		ValidArgsFunction: completeWorkshopNames,
+		Long: `Print links for accessing a deployed workshop.
+
+Prints the URL of the workshops catalog of the training portal the workshop
+is deployed to, the URL which starts a workshop session for the workshop
+directly, and an HTML snippet for embedding the workshop session in another
+web page using an iframe. These can be included in invitations for a class
+or in the pages of a learning management system.
+
+If anonymous access is enabled for the training portal, users following the
+direct start URL are logged in automatically, otherwise they are sent to the
+login page first. Use --index-url to give the page users are returned to when
+the workshop session ends. Embedding the workshop session in another web page
+requires the site to be listed in the frame ancestors for the training portal,
+or in the website styling of the training platform.
+
+Use --shorten to print short links for the catalog and start URLs, as the
+generated host names are long and error prone to type. If a shortener
+endpoint is set under "shortLinks" in the client config file, it is called
+with the URL to shorten, either in place of "{url}" in the endpoint or as the
+"url" query string parameter, and should return the short link as the body
+of the response. Otherwise a redirector is deployed to the "educates-links"
+namespace of the cluster, with short links using the host name "links" under
+the ingress domain of the platform.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Name = args[0]
+
+			return o.Run(cmd.Context())
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name of the training portal",
+	)
+	c.Flags().StringVar(
+		&o.IndexURL,
+		"index-url",
+		"",
+		"URL users are returned to when the workshop session ends",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"text",
+		"output format, one of text or json",
+	)
+	c.Flags().BoolVar(
+		&o.Shorten,
+		"shorten",
+		false,
+		"print short links for the catalog and start URLs",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/preflight"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+type ClusterWorkshopValidateClusterOptions struct {
+	Name            string
+	Path            string
+	Kubeconfig      string
+	Portal          string
+	WorkshopFile    string
+	WorkshopVersion string
+	DataValuesFlags yttcmd.DataValuesFlags
+}
+
+func (o *ClusterWorkshopValidateClusterOptions) Run(ctx context.Context) error {
+	var path = o.Path
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if path == "" {
+		path = "."
+	}
+
+	workshop, err := training.LoadWorkshopDefinition(&training.DefinitionOptions{
+		Name:            o.Name,
+		Path:            path,
+		Portal:          o.Portal,
+		WorkshopFile:    o.WorkshopFile,
+		WorkshopVersion: o.WorkshopVersion,
+		DataValuesFlags: o.DataValuesFlags,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	// The checks are made against the configuration the training platform
+	// was installed with, so Educates needs to be installed.
+
+	platformConfig, err := operators.InstalledConfig(ctx, clusterConfig)
+
+	if err != nil {
+		return err
+	}
+
+	if platformConfig == nil {
+		return failures.NewNotFoundError(errors.New("unable to determine configuration of the training platform"), failures.PlatformHint)
+	}
+
+	env := preflight.Environment{
+		Client: client,
+	}
+
+	results := preflight.RunChecks(ctx, &env, workshopCompatibilityChecks(workshop, platformConfig))
+
+	preflight.Report(os.Stdout, results)
+
+	if preflight.Failed(results) {
+		return failures.NewValidationError(errors.Errorf("workshop %q is not compatible with the cluster", workshop.GetName()), "fix the problems reported before deploying the workshop")
+	}
+
+	fmt.Printf("Workshop %q is compatible with the cluster.\n", workshop.GetName())
+
+	return nil
+}
+
+func (p *ProjectInfo) NewClusterWorkshopValidateClusterCmd() *cobra.Command {
+	var o ClusterWorkshopValidateClusterOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "validate-cluster",
+		Short: "Check cluster provides what a workshop needs",
+		Long: `Check the cluster provides everything a workshop needs.
+
+Loads the workshop definition and checks the cluster, and the configuration
+the training platform was installed with, for each capability workshop
+sessions would need. This covers the ingress domain and ingress class used
+for session ingresses, storage classes for any persistent volumes, support
+for privileged containers where the workshop uses the privileged security
+policy or a docker daemon, whether the applications enabled for the workshop
+can be used, nodes with GPUs where requested, and that the cluster provides
+the resource types, such as custom resources, of any objects created for the
+workshop environment or sessions.
+
+Problems which would cause workshop sessions to fail are reported as
+failures, with problems which may be resolved later, such as GPU nodes not
+yet being available, reported as warnings. Nothing is deployed to the
+cluster.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"name to be used for the workshop definition, generated if not set",
+	)
+	c.Flags().StringVarP(
+		&o.Path,
+		"file",
+		"f",
+		".",
+		"path to local workshop directory, definition file, or URL for workshop definition file",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+
+	c.Flags().StringVar(
+		&o.WorkshopVersion,
+		"workshop-version",
+		"latest",
+		"version of the workshop being published",
+	)
+
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.EnvFromStrings,
+		"data-values-env",
+		nil,
+		"Extract data values (as strings) from prefixed env vars (format: PREFIX for PREFIX_all__key1=str) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.EnvFromYAML,
+		"data-values-env-yaml",
+		nil,
+		"Extract data values (parsed as YAML) from prefixed env vars (format: PREFIX for PREFIX_all__key1=true) (can be specified multiple times)",
+	)
+
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.KVsFromStrings,
+		"data-value",
+		nil,
+		"Set specific data value to given value, as string (format: all.key1.subkey=123) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.KVsFromYAML,
+		"data-value-yaml",
+		nil,
+		"Set specific data value to given value, parsed as YAML (format: all.key1.subkey=true) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.KVsFromFiles,
+		"data-value-file",
+		nil,
+		"Set specific data value to contents of a file (format: [@lib1:]all.key1.subkey={file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.FromFiles,
+		"data-values-file",
+		nil,
+		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
+	)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+)
+
+/*
+Kubernetes versions which virtual clusters for workshop sessions can run.
+These must match the k3s images bundled with the session manager.
+*/
+var vclusterVersions = []string{"1.22", "1.23", "1.24", "1.25"}
+
+/*
+Enable a virtual cluster for each session of a workshop. Workshop users are
+given cluster admin access to the virtual cluster rather than access to the
+session namespace of the underlying cluster, so workshops requiring cluster
+admin access can be run on a shared cluster. Where ingress subdomains are
+given, ingresses created in the virtual cluster for hosts in those
+subdomains are synced to the underlying cluster.
+*/
+func applyWorkshopVCluster(workshop *unstructured.Unstructured, enabled bool, version string, subdomains []string) error {
+	if !enabled {
+		if version != "" || len(subdomains) != 0 {
+			return failures.NewValidationError(errors.New("virtual cluster options can only be given when enabling a virtual cluster"), "use --vcluster to enable a virtual cluster for each session")
+		}
+
+		return nil
+	}
+
+	if version != "" && !containsString(vclusterVersions, version) {
+		return failures.NewValidationError(errors.Errorf("unsupported virtual cluster version %q", version), fmt.Sprintf("supported versions are %s", strings.Join(vclusterVersions, ", ")))
+	}
+
+	vcluster, _, _ := unstructured.NestedMap(workshop.Object, "spec", "session", "applications", "vcluster")
+
+	if vcluster == nil {
+		vcluster = map[string]interface{}{}
+	}
+
+	vcluster["enabled"] = true
+
+	if version != "" {
+		vcluster["version"] = version
+	}
+
+	if len(subdomains) != 0 {
+		var items []interface{}
+
+		for _, subdomain := range subdomains {
+			items = append(items, subdomain)
+		}
+
+		vcluster["ingress"] = map[string]interface{}{
+			"enabled":    true,
+			"subdomains": items,
+		}
+	}
+
+	return unstructured.SetNestedMap(workshop.Object, vcluster, "spec", "session", "applications", "vcluster")
+}
+
+/*
+Check that virtual clusters can be used with how the platform was installed
+in the cluster. Where installation details aren't available the check is
+skipped.
+*/
+func checkVClusterSupported(ctx context.Context, clusterConfig *cluster.ClusterConfig) error {
+	platformConfig, err := operators.InstalledConfig(ctx, clusterConfig)
+
+	if err != nil || platformConfig == nil {
+		return err
+	}
+
+	extension, _ := lookupWorkshopExtension("vcluster")
+
+	if err := extension.Check(platformConfig); err != nil {
+		return failures.NewValidationError(errors.Wrap(err, "virtual clusters are not supported by the cluster"), "")
+	}
+
+	return nil
+}
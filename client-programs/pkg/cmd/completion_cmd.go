@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Create Cobra command object for generating shell completion scripts.
+*/
+func (p *ProjectInfo) NewCompletionCmd() *cobra.Command {
+	var c = &cobra.Command{
+		Args:                  cobra.ExactValidArgs(1),
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		DisableFlagsInUseLine: true,
+		Use:                   "completion bash|zsh|fish|powershell",
+		Short:                 "Generate shell completion script",
+		Long: `Generate the completion script for the specified shell.
+
+To load completions for the current bash shell session run:
+
+    source <(educates completion bash)
+
+To load completions for every new zsh session run once:
+
+    educates completion zsh > "${fpath[1]}/_educates"
+
+To load completions for every new fish session run once:
+
+    educates completion fish > ~/.config/fish/completions/educates.fish
+
+To load completions for the current PowerShell session run:
+
+    educates completion powershell | Out-String | Invoke-Expression`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(os.Stdout, true)
+			case "zsh":
+				return root.GenZshCompletion(os.Stdout)
+			case "fish":
+				return root.GenFishCompletion(os.Stdout, true)
+			case "powershell":
+				return root.GenPowerShellCompletionWithDesc(os.Stdout)
+			}
+
+			return failures.NewValidationError(errors.Errorf("unsupported shell %q", args[0]), "")
+		},
+	}
+
+	return c
+}
+
+/*
+Create a dynamic client for use in shell completion, using any kubeconfig file
+which has been supplied on the command line being completed.
+*/
+func completionDynamicClient(cmd *cobra.Command) (dynamic.Interface, error) {
+	kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
+
+	return cluster.NewClusterConfig(kubeconfig).GetDynamicClient()
+}
+
+/*
+Determine the name of the training portal for use in shell completion, using
+the default portal name if not supplied on the command line being completed.
+*/
+func completionPortalName(cmd *cobra.Command) string {
+	portal, _ := cmd.Flags().GetString("portal")
+
+	if portal == "" {
+		portal = "educates-cli"
+	}
+
+	return portal
+}
+
+/*
+Complete names of training portals which exist in the target cluster.
+*/
+func completeTrainingPortalNames(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dynamicClient, err := completionDynamicClient(cmd)
+
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	trainingPortals, err := dynamicClient.Resource(trainingPortalResource).List(cmd.Context(), metav1.ListOptions{})
+
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+
+	for _, item := range trainingPortals.Items {
+		if strings.HasPrefix(item.GetName(), toComplete) {
+			names = append(names, item.GetName())
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+/*
+Complete names of workshops which have been added to the training portal.
+*/
+func completeWorkshopNames(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dynamicClient, err := completionDynamicClient(cmd)
+
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	trainingPortal, err := dynamicClient.Resource(trainingPortalResource).Get(cmd.Context(), completionPortalName(cmd), metav1.GetOptions{})
+
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	workshops, _, err := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+
+	for _, item := range workshops {
+		if object, ok := item.(map[string]interface{}); ok {
+			if name, ok := object["name"].(string); ok && strings.HasPrefix(name, toComplete) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+/*
+Complete names of workshop sessions associated with the training portal. Only
+a single session name is accepted as argument so stop completing after that.
+*/
+func completeWorkshopSessionNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	dynamicClient, err := completionDynamicClient(cmd)
+
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	workshopSessions, err := dynamicClient.Resource(workshopSessionResource).List(cmd.Context(), metav1.ListOptions{
+		LabelSelector: "training.educates.dev/portal.name=" + completionPortalName(cmd),
+	})
+
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+
+	for _, item := range workshopSessions.Items {
+		if strings.HasPrefix(item.GetName(), toComplete) {
+			names = append(names, item.GetName())
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+type ConfigDefaultsListOptions struct{}
+
+func (o *ConfigDefaultsListOptions) Run() error {
+	clientConfig, err := config.LoadClientConfig()
+
+	if err != nil {
+		return err
+	}
+
+	type flagDefault struct {
+		key    string
+		value  string
+		source string
+	}
+
+	var defaults []flagDefault
+
+	for commandKey, flags := range clientConfig.Defaults {
+		for name, value := range flags {
+			defaults = append(defaults, flagDefault{commandKey + "." + name, value, "config"})
+		}
+	}
+
+	// The key for a default given by an environment variable can't be
+	// recovered from the name of the variable, so the name is shown.
+
+	for _, item := range os.Environ() {
+		name, value, _ := strings.Cut(item, "=")
+
+		if strings.HasPrefix(name, flagDefaultEnvPrefix) {
+			defaults = append(defaults, flagDefault{name, value, "env"})
+		}
+	}
+
+	if len(defaults) == 0 {
+		fmt.Println("No defaults set.")
+		return nil
+	}
+
+	sort.Slice(defaults, func(i, j int) bool { return defaults[i].key < defaults[j].key })
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\n", "KEY", "VALUE", "SOURCE")
+
+	for _, item := range defaults {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", item.key, item.value, item.source)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewConfigDefaultsListCmd() *cobra.Command {
+	var o ConfigDefaultsListOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "list",
+		Short: "List default values for command flags",
+		Long: `List default values for command flags.
+
+Lists the defaults saved in the client config file, along with defaults given
+using environment variables, which are shown by the name of the environment
+variable.`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+
+	return c
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ConfigDefaultsSetOptions struct{}
+
+func (o *ConfigDefaultsSetOptions) Run(root *cobra.Command, key string, value string) error {
+	cmd, flag, err := lookupFlagDefault(root, key)
+
+	if err != nil {
+		return err
+	}
+
+	// Check the value is valid for the type of flag now, rather than the
+	// error only being reported the next time the command is run.
+
+	if err = flag.Value.Set(value); err != nil {
+		return failures.NewValidationError(errors.Wrapf(err, "invalid value for --%s", flag.Name), "")
+	}
+
+	clientConfig, err := config.LoadClientConfig()
+
+	if err != nil {
+		return err
+	}
+
+	commandKey := flagDefaultsCommandKey(cmd)
+
+	if clientConfig.Defaults == nil {
+		clientConfig.Defaults = config.DefaultsConfig{}
+	}
+
+	if clientConfig.Defaults[commandKey] == nil {
+		clientConfig.Defaults[commandKey] = map[string]string{}
+	}
+
+	clientConfig.Defaults[commandKey][flag.Name] = value
+
+	if err = config.SaveClientConfig(clientConfig); err != nil {
+		return err
+	}
+
+	fmt.Printf("Default for --%s of %q set to %q.\n", flag.Name, strings.ReplaceAll(commandKey, ".", " "), value)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewConfigDefaultsSetCmd() *cobra.Command {
+	var o ConfigDefaultsSetOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(2),
+		Use:   "set KEY VALUE",
+		Short: "Set default value for command flag",
+		Long: `Set the default value for a command flag.
+
+Sets the value used for a flag of a command when the flag isn't given on the
+command line. The key is the path of the command, without "educates", and the
+name of the flag, separated by dots. For example, to have workshops deployed
+with a capacity of 10 unless overridden, use:
+
+  educates config defaults set cluster.workshop.deploy.capacity 10
+
+The value is given as it would be on the command line, with multiple values
+for flags which can be specified multiple times separated by commas. Command
+aliases, such as "deploy-workshop", have their own defaults.
+
+Defaults are saved in the client config file for the CLI. A default can also
+be given by setting an environment variable, which takes precedence over the
+client config file, named by prefixing the key with EDUCATES_DEFAULT and
+replacing dots and dashes with underscores, such as
+EDUCATES_DEFAULT_CLUSTER_WORKSHOP_DEPLOY_CAPACITY.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Root(), args[0], args[1]) },
+	}
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type ConfigDefaultsUnsetOptions struct{}
+
+func (o *ConfigDefaultsUnsetOptions) Run(key string) error {
+	clientConfig, err := config.LoadClientConfig()
+
+	if err != nil {
+		return err
+	}
+
+	// The key isn't checked against the commands of the CLI, so defaults
+	// for commands or flags which no longer exist can still be removed.
+
+	index := strings.LastIndex(key, ".")
+
+	if index <= 0 {
+		return failures.NewValidationError(errors.Errorf("invalid key %q", key), "")
+	}
+
+	commandKey, name := key[:index], strings.TrimPrefix(key[index+1:], "--")
+
+	if _, found := clientConfig.Defaults[commandKey][name]; !found {
+		return failures.NewNotFoundError(errors.Errorf("no default set for %q", key), "run `educates config defaults list` to see the defaults set")
+	}
+
+	delete(clientConfig.Defaults[commandKey], name)
+
+	if len(clientConfig.Defaults[commandKey]) == 0 {
+		delete(clientConfig.Defaults, commandKey)
+	}
+
+	if err = config.SaveClientConfig(clientConfig); err != nil {
+		return err
+	}
+
+	fmt.Printf("Default for %s removed.\n", key)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewConfigDefaultsUnsetCmd() *cobra.Command {
+	var o ConfigDefaultsUnsetOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "unset KEY",
+		Short: "Remove default value for command flag",
+		Long: `Remove the default value for a command flag.
+
+Removes a default saved in the client config file, so the flag reverts to
+its built-in default. Defaults given using environment variables need to be
+removed by unsetting the environment variable.`,
+		RunE: func(_ *cobra.Command, args []string) error { return o.Run(args[0]) },
+	}
+
+	return withAuditLogging(c)
+}
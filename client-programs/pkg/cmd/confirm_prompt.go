@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+/*
+Global options controlling whether the user may be prompted for input. These
+are bound to persistent flags on the root command.
+*/
+var interactionOptions struct {
+	AssumeYes      bool
+	NonInteractive bool
+}
+
+/*
+Ask the user to confirm a destructive action before it is performed. If the
+user has supplied --yes the action is confirmed without prompting. If prompts
+have been disabled using --non-interactive, or stdin is not a terminal, an
+error is returned rather than blocking waiting on input which will never come.
+*/
+func confirmAction(message string) error {
+	if interactionOptions.AssumeYes {
+		return nil
+	}
+
+	if interactionOptions.NonInteractive {
+		return errors.Errorf("confirmation required to %s, rerun with --yes to proceed", message)
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.Errorf("confirmation required to %s but stdin is not a terminal, rerun with --yes to proceed", message)
+	}
+
+	fmt.Fprintf(os.Stderr, "Are you sure you want to %s? [y/N]: ", message)
+
+	response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read confirmation response")
+	}
+
+	response = strings.ToLower(strings.TrimSpace(response))
+
+	if response != "y" && response != "yes" {
+		return errors.New("action cancelled")
+	}
+
+	return nil
+}
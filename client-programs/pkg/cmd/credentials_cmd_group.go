@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+func (p *ProjectInfo) NewCredentialsCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "credentials",
+		Short: "Tools for managing credentials stored by the CLI",
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewCredentialsListCmd(),
+				p.NewCredentialsMigrateCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
+
+/*
+A credential held in plaintext in the config files of the CLI, which has not
+been moved into a credentials backend.
+*/
+type plaintextCredential struct {
+	Key      string
+	Location string
+	Value    string
+}
+
+/*
+Find credentials held in plaintext in the default installation config and the
+local secrets cache. Values of secrets are not filled in as they are moved
+using the secret itself.
+*/
+func findPlaintextCredentials() ([]plaintextCredential, error) {
+	var found []plaintextCredential
+
+	data, err := os.ReadFile(config.DefaultValuesFile())
+
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "failed to read default config file %s", config.DefaultValuesFile())
+	}
+
+	if len(data) != 0 {
+		var installationConfig config.InstallationConfig
+
+		if err := yaml.Unmarshal(data, &installationConfig); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse default config file %s", config.DefaultValuesFile())
+		}
+
+		for key, value := range installationConfig.Credentials() {
+			if *value != "" {
+				found = append(found, plaintextCredential{Key: config.InstallationCredentialsPrefix + key, Location: config.DefaultValuesFile(), Value: *value})
+			}
+		}
+	}
+
+	secrets, err := readCachedSecretFiles()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for name, secret := range secrets {
+		if _, stored := secret.ObjectMeta.Annotations[credentialsRefAnnotation]; stored || !isCredentialSecret(secret) {
+			continue
+		}
+
+		found = append(found, plaintextCredential{Key: secretCredentialsPrefix + name, Location: "secrets cache"})
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Key < found[j].Key })
+
+	return found, nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+type CredentialsListOptions struct{}
+
+func (o *CredentialsListOptions) Run() error {
+	store, err := config.OpenCredentialsStore()
+
+	if err != nil {
+		return err
+	}
+
+	var stored []string
+
+	if store != nil {
+		if stored, err = store.List(); err != nil {
+			return err
+		}
+	}
+
+	plaintext, err := findPlaintextCredentials()
+
+	if err != nil {
+		return err
+	}
+
+	if len(stored) == 0 && len(plaintext) == 0 {
+		fmt.Println("No credentials found.")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\n", "KEY", "STORED IN")
+
+	for _, key := range stored {
+		fmt.Fprintf(w, "%s\t%s\n", key, store.Name())
+	}
+
+	for _, credential := range plaintext {
+		fmt.Fprintf(w, "%s\t%s (plaintext)\n", credential.Key, credential.Location)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewCredentialsListCmd() *cobra.Command {
+	var o CredentialsListOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "list",
+		Short: "List credentials stored by the CLI",
+		Long: `List credentials stored by the CLI.
+
+Lists the credentials held in the configured credentials backend, as well as
+those still held in plaintext in the default installation config or the
+local secrets cache. The values of the credentials are not displayed. Use
+"educates credentials migrate" to move plaintext credentials into the
+credentials backend.`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+
+	return c
+}
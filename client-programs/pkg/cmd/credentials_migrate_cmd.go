@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/credentials"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type CredentialsMigrateOptions struct {
+	Backend      string
+	VaultAddress string
+	VaultMount   string
+	VaultPath    string
+	SOPSFile     string
+}
+
+func (o *CredentialsMigrateOptions) Run() error {
+	clientConfig, err := config.LoadClientConfig()
+
+	if err != nil {
+		return err
+	}
+
+	current := clientConfig.Credentials
+	target := current
+
+	if o.Backend != "" {
+		if !slices.Contains(credentials.Backends, o.Backend) {
+			return failures.NewValidationError(errors.Errorf("unknown credentials backend %q", o.Backend), fmt.Sprintf("backend must be one of %s", strings.Join(credentials.Backends, ", ")))
+		}
+
+		target.Backend = o.Backend
+	}
+
+	if !target.Enabled() {
+		return failures.NewValidationError(errors.New("no credentials backend to migrate to"), "supply the backend using --to")
+	}
+
+	if o.VaultAddress != "" {
+		target.Vault.Address = o.VaultAddress
+	}
+
+	if o.VaultMount != "" {
+		target.Vault.Mount = o.VaultMount
+	}
+
+	if o.VaultPath != "" {
+		target.Vault.Path = o.VaultPath
+	}
+
+	if o.SOPSFile != "" {
+		target.SOPS.File = o.SOPSFile
+	}
+
+	targetStore, err := credentials.Open(target)
+
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := findPlaintextCredentials()
+
+	if err != nil {
+		return err
+	}
+
+	// Credentials already held in a different backend are copied across
+	// and only deleted from the old backend once the client config has
+	// been updated to use the new backend.
+
+	var currentStore credentials.Store
+	var storedKeys []string
+
+	if current.Enabled() && current != target {
+		if currentStore, err = credentials.Open(current); err != nil {
+			return errors.Wrapf(err, "unable to open existing %s credentials backend", current.Backend)
+		}
+
+		if storedKeys, err = currentStore.List(); err != nil {
+			return err
+		}
+	}
+
+	if len(plaintext) == 0 && len(storedKeys) == 0 && current == target {
+		fmt.Printf("No credentials to migrate to %s credentials backend.\n", target.Backend)
+		return nil
+	}
+
+	if err = confirmAction(fmt.Sprintf("move %d credentials to %s credentials backend", len(plaintext)+len(storedKeys), target.Backend)); err != nil {
+		return err
+	}
+
+	for _, key := range storedKeys {
+		value, found, err := currentStore.Get(key)
+
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			continue
+		}
+
+		if err = targetStore.Set(key, value); err != nil {
+			return err
+		}
+	}
+
+	var installationKeys []string
+
+	for _, credential := range plaintext {
+		if strings.HasPrefix(credential.Key, config.InstallationCredentialsPrefix) {
+			if err = targetStore.Set(credential.Key, credential.Value); err != nil {
+				return err
+			}
+
+			installationKeys = append(installationKeys, strings.TrimPrefix(credential.Key, config.InstallationCredentialsPrefix))
+		}
+	}
+
+	if len(plaintext) != 0 {
+		secrets, err := readCachedSecretFiles()
+
+		if err != nil {
+			return err
+		}
+
+		for _, credential := range plaintext {
+			secret, found := secrets[strings.TrimPrefix(credential.Key, secretCredentialsPrefix)]
+
+			if !strings.HasPrefix(credential.Key, secretCredentialsPrefix) || !found {
+				continue
+			}
+
+			if err = storeSecretCredentials(targetStore, secret); err != nil {
+				return err
+			}
+
+			if err = writeCachedSecretFile(secret); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(installationKeys) != 0 {
+		if err = config.RemoveDefaultValuesCredentials(installationKeys); err != nil {
+			return err
+		}
+	}
+
+	clientConfig.Credentials = target
+
+	if err = config.SaveClientConfig(clientConfig); err != nil {
+		return err
+	}
+
+	for _, key := range storedKeys {
+		if err = currentStore.Delete(key); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to delete %s from %s credentials backend: %s.\n", key, current.Backend, err)
+		}
+	}
+
+	fmt.Printf("Moved %d credentials to %s credentials backend.\n", len(plaintext)+len(storedKeys), target.Backend)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewCredentialsMigrateCmd() *cobra.Command {
+	var o CredentialsMigrateOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "migrate",
+		Short: "Move stored credentials into a credentials backend",
+		Long: `Move stored credentials into a credentials backend.
+
+Moves training portal admin and robot passwords and the image registry proxy
+cache password from the default installation config, and the data of image
+registry, Git and other credential secrets from the local secrets cache, into
+a credentials backend. Credentials already held in a different backend are
+moved to the new backend. The client config is then updated so credentials
+added later are also stored in the backend.
+
+The backends which can be used are:
+
+  file      a single file readable only by the user
+  keychain  the OS keychain, using "security" on macOS or "secret-tool" on Linux
+  vault     a Vault KV version 2 secrets engine, using VAULT_ADDR and VAULT_TOKEN
+  sops      a YAML file encrypted using SOPS, which must be version 3.8 or later
+
+Without --to, any plaintext credentials are moved into the backend already
+configured. Any comments in the default installation config are lost when the
+credentials are removed from it.`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+
+	c.Flags().StringVar(
+		&o.Backend,
+		"to",
+		"",
+		fmt.Sprintf("credentials backend to move credentials to, one of %s", strings.Join(credentials.Backends, ", ")),
+	)
+	c.Flags().StringVar(
+		&o.VaultAddress,
+		"vault-address",
+		"",
+		"address of Vault server instead of $VAULT_ADDR",
+	)
+	c.Flags().StringVar(
+		&o.VaultMount,
+		"vault-mount",
+		"",
+		"mount path of the Vault KV secrets engine, defaults to secret",
+	)
+	c.Flags().StringVar(
+		&o.VaultPath,
+		"vault-path",
+		"",
+		"path within the Vault KV secrets engine to store credentials, defaults to educates",
+	)
+	c.Flags().StringVar(
+		&o.SOPSFile,
+		"sops-file",
+		"",
+		"location of SOPS encrypted file to store credentials in",
+	)
+
+	c.RegisterFlagCompletionFunc("to", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return credentials.Backends, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/credentials"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+// When the data of a secret in the local secrets cache is held in the
+// credentials backend, the cached secret holds no data and instead has this
+// annotation giving the key the data is stored under.
+
+const credentialsRefAnnotation = "training.educates.dev/credentials-ref"
+
+const secretCredentialsPrefix = "secret/"
+
+type secretCredentials struct {
+	Data       map[string][]byte `json:"data,omitempty"`
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+/*
+Whether a cached secret holds credentials which should be stored in the
+credentials backend. TLS certificates and CA certificates are left in the
+secrets cache as they are looked up by ingress domain from the cache.
+*/
+func isCredentialSecret(secret *apiv1.Secret) bool {
+	if secret.Type == apiv1.SecretTypeTLS {
+		return false
+	}
+
+	if _, found := secret.Data["ca.crt"]; found && len(secret.Data) == 1 {
+		return false
+	}
+
+	return len(secret.Data) != 0 || len(secret.StringData) != 0
+}
+
+/*
+Move the data of a secret into the credentials backend, leaving a reference to
+it in the annotations of the secret.
+*/
+func storeSecretCredentials(store credentials.Store, secret *apiv1.Secret) error {
+	data, err := json.Marshal(secretCredentials{Data: secret.Data, StringData: secret.StringData})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode secret data")
+	}
+
+	key := secretCredentialsPrefix + secret.ObjectMeta.Name
+
+	if err := store.Set(key, string(data)); err != nil {
+		return err
+	}
+
+	if secret.ObjectMeta.Annotations == nil {
+		secret.ObjectMeta.Annotations = map[string]string{}
+	}
+
+	secret.ObjectMeta.Annotations[credentialsRefAnnotation] = key
+
+	secret.Data = nil
+	secret.StringData = nil
+
+	return nil
+}
+
+/*
+Restore the data of a cached secret from the credentials backend, if it was
+stored there.
+*/
+func resolveSecretCredentials(secret *apiv1.Secret) error {
+	key, found := secret.ObjectMeta.Annotations[credentialsRefAnnotation]
+
+	if !found {
+		return nil
+	}
+
+	store, err := config.OpenCredentialsStore()
+
+	if err != nil {
+		return err
+	}
+
+	if store == nil {
+		return failures.NewValidationError(errors.Errorf("data for secret %q is held in a credentials backend but none is configured", secret.ObjectMeta.Name), "configure the credentials backend in the client config")
+	}
+
+	value, found, err := store.Get(key)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return failures.NewNotFoundError(errors.Errorf("data for secret %q not found in %s credentials backend", secret.ObjectMeta.Name, store.Name()), "add the secret again with `educates admin secrets add`")
+	}
+
+	var stored secretCredentials
+
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		return errors.Wrapf(err, "unable to decode data for secret %q", secret.ObjectMeta.Name)
+	}
+
+	secret.Data = stored.Data
+	secret.StringData = stored.StringData
+
+	delete(secret.ObjectMeta.Annotations, credentialsRefAnnotation)
+
+	return nil
+}
+
+/*
+Remove the data of a cached secret from the credentials backend, if one is
+configured.
+*/
+func deleteSecretCredentials(name string) error {
+	store, err := config.OpenCredentialsStore()
+
+	if err != nil || store == nil {
+		return err
+	}
+
+	return store.Delete(secretCredentialsPrefix + name)
+}
+
+/*
+Read all secrets in the local secrets cache without resolving any data held
+in the credentials backend, returning them by file name.
+*/
+func readCachedSecretFiles() (map[string]*apiv1.Secret, error) {
+	secretsCacheDir := filepath.Join(xdg.DataHome, "educates", "secrets")
+
+	secrets := map[string]*apiv1.Secret{}
+
+	files, err := os.ReadDir(secretsCacheDir)
+
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read secrets cache directory %q", secretsCacheDir)
+	}
+
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".yaml") {
+			continue
+		}
+
+		fullPath := filepath.Join(secretsCacheDir, f.Name())
+
+		yamlData, err := os.ReadFile(fullPath)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read secret file %q", fullPath)
+		}
+
+		decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder()
+		secretObj := &apiv1.Secret{}
+
+		if err = runtime.DecodeInto(decoder, yamlData, secretObj); err != nil {
+			return nil, errors.Wrapf(err, "unable to read secret file %q", fullPath)
+		}
+
+		secrets[strings.TrimSuffix(f.Name(), ".yaml")] = secretObj
+	}
+
+	return secrets, nil
+}
+
+/*
+Return the YAML for a cached secret with any data held in the credentials
+backend restored.
+*/
+func resolveCachedSecretFile(yamlData []byte) ([]byte, error) {
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder()
+	secretObj := &apiv1.Secret{}
+
+	if err := runtime.DecodeInto(decoder, yamlData, secretObj); err != nil {
+		return nil, errors.Wrap(err, "unable to decode secret")
+	}
+
+	if err := resolveSecretCredentials(secretObj); err != nil {
+		return nil, err
+	}
+
+	secretData, err := json.MarshalIndent(secretObj, "", "    ")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate secret data")
+	}
+
+	return yaml.JSONToYAML(secretData)
+}
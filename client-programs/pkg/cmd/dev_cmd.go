@@ -0,0 +1,558 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/local"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+/*
+Directories of a workshop copied into the workshop session when changed, the
+same as those included in the workshop files by default.
+*/
+var devSyncDirectories = []string{"workshop", "exercises"}
+
+type DevOptions struct {
+	Path            string
+	Kubeconfig      string
+	Portal          string
+	Session         string
+	WorkshopFile    string
+	WorkshopVersion string
+	Locale          string
+	Interval        time.Duration
+	SkipLogs        bool
+	DataValuesFlags yttcmd.DataValuesFlags
+}
+
+/*
+Modification time and size of a file, used to detect when it has changed.
+*/
+type devFileState struct {
+	modTime int64
+	size    int64
+}
+
+func (o *DevOptions) Run(ctx context.Context) error {
+	var err error
+
+	// Ensure have portal name.
+
+	if o.Portal == "" {
+		o.Portal = "educates-cli"
+	}
+
+	if o.Interval < 100*time.Millisecond {
+		return failures.NewValidationError(errors.Errorf("invalid interval %s", o.Interval), "the interval must be at least 100ms")
+	}
+
+	path, err := calculateWorkshopRoot(o.Path)
+
+	if err != nil {
+		return err
+	}
+
+	workshopFile := o.WorkshopFile
+
+	if filepath.IsAbs(workshopFile) {
+		if workshopFile, err = filepath.Rel(path, workshopFile); err != nil {
+			return errors.Wrap(err, "unable to determine location of workshop definition")
+		}
+	}
+
+	workshopFile = filepath.ToSlash(filepath.Clean(workshopFile))
+
+	// The workshop needs to have been deployed already, as it is the workshop
+	// session where the changes are checked.
+
+	workshop, err := o.validate(path)
+
+	if err != nil {
+		return err
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	_, err = dynamicClient.Resource(training.WorkshopResource).Get(ctx, workshop.GetName(), metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return failures.NewNotFoundError(errors.Errorf("workshop %q has not been deployed", workshop.GetName()), "deploy the workshop first using `educates cluster workshop deploy`")
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query workshop %q", workshop.GetName())
+	}
+
+	fmt.Printf("Watching %s for changes to workshop %q, press Ctrl-C to stop.\n", path, workshop.GetName())
+
+	state, err := scanDevFiles(path)
+
+	if err != nil {
+		return err
+	}
+
+	// Sync everything on startup as the content may have changed since the
+	// workshop session was created.
+
+	logging := o.sync(ctx, clusterConfig, dynamicClient, path, workshop.GetName(), devSyncDirectories, "")
+
+	// Keep running until interrupted, reporting but otherwise ignoring
+	// failures so that a broken change doesn't stop later changes being
+	// checked.
+
+	for {
+		if cluster.Sleep(ctx, o.Interval) != nil {
+			return nil
+		}
+
+		current, err := scanDevFiles(path)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			continue
+		}
+
+		changed := changedDevFiles(state, current)
+
+		state = current
+
+		if len(changed) == 0 {
+			continue
+		}
+
+		if len(changed) == 1 {
+			fmt.Printf("Changed %s at %s.\n", changed[0], time.Now().Format("15:04:05"))
+		} else {
+			fmt.Printf("Changed %s and %d other files at %s.\n", changed[0], len(changed)-1, time.Now().Format("15:04:05"))
+		}
+
+		workshop, err = o.validate(path)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			continue
+		}
+
+		var directories []string
+
+		for _, name := range changed {
+			if name == workshopFile {
+				// Changes to the workshop definition only apply to workshop
+				// sessions created after the change.
+
+				if err = training.UpdateWorkshopResource(ctx, dynamicClient, workshop); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+				} else {
+					fmt.Println("Updated workshop definition in cluster, changes apply to new workshop sessions.")
+				}
+			}
+
+			top := strings.SplitN(name, "/", 2)[0]
+
+			for _, directory := range devSyncDirectories {
+				if top == directory && !containsString(directories, directory) {
+					directories = append(directories, directory)
+				}
+			}
+		}
+
+		if len(directories) != 0 {
+			logging = o.sync(ctx, clusterConfig, dynamicClient, path, workshop.GetName(), directories, logging)
+		}
+	}
+}
+
+/*
+Check the workshop definition can be loaded and the pages of instructions
+rendered, reporting as warnings any problems found in the pages. Returns the
+workshop definition.
+*/
+func (o *DevOptions) validate(path string) (*unstructured.Unstructured, error) {
+	workshop, err := training.LoadWorkshopDefinition(&training.DefinitionOptions{
+		Path:            path,
+		Portal:          o.Portal,
+		WorkshopFile:    o.WorkshopFile,
+		WorkshopVersion: o.WorkshopVersion,
+		DataValuesFlags: o.DataValuesFlags,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	instructions, err := local.LoadWorkshop(path, workshop, o.Locale)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, warning := range instructions.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", warning)
+	}
+
+	problems := 0
+
+	for _, page := range instructions.Pages {
+		_, warnings, err := local.RenderPage(page.File, instructions.Classic, instructions.Variables)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: page %s: %s\n", page.Name, err)
+
+			problems++
+
+			continue
+		}
+
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: page %s: %s.\n", page.Name, warning)
+		}
+	}
+
+	if problems == 0 {
+		fmt.Printf("Workshop definition and %d pages of instructions are valid.\n", len(instructions.Pages))
+	}
+
+	return workshop, nil
+}
+
+/*
+Copy the directories into the workshop session, rebuilding the workshop
+instructions if they were included. The name of the pod whose logs are being
+output is passed in, and logs are output for the pod synced to if different,
+with the name of that pod being returned.
+*/
+func (o *DevOptions) sync(ctx context.Context, clusterConfig *cluster.ClusterConfig, dynamicClient dynamic.Interface, path string, workshop string, directories []string, logging string) string {
+	session, pod, err := o.sessionPod(ctx, clusterConfig, dynamicClient, workshop)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return logging
+	}
+
+	if pod == nil {
+		fmt.Println("No workshop session to sync changes to, request a workshop session to see changes.")
+		return logging
+	}
+
+	if !o.SkipLogs && pod.Name != logging {
+		go o.tailLogs(ctx, clusterConfig, pod)
+
+		logging = pod.Name
+	}
+
+	rebuild := false
+
+	for _, directory := range directories {
+		source := filepath.Join(path, directory)
+
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+
+		reader, writer := io.Pipe()
+
+		go func() {
+			writer.CloseWithError(writeLocalArchive(writer, source, directory))
+		}()
+
+		command := []string{"tar", "-C", "/home/eduk8s", "-xmf", "-"}
+
+		if err := clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, "workshop", command, reader, io.Discard); err != nil {
+			reader.CloseWithError(err)
+
+			fmt.Fprintf(os.Stderr, "Error: unable to copy %s to session %s: %s\n", directory, session, err)
+
+			return logging
+		}
+
+		if directory == "workshop" {
+			rebuild = true
+		}
+	}
+
+	// Rebuilding the instructions is done using the same script as when the
+	// workshop session starts, run with a login shell so the environment of
+	// the workshop session is set up. Errors from Hugo are included in the
+	// error returned.
+
+	if rebuild {
+		command := []string{"bash", "-l", "-c", "rebuild-content"}
+
+		if err := clusterConfig.ExecInPod(ctx, pod.Namespace, pod.Name, "workshop", command, nil, io.Discard); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: unable to rebuild workshop instructions in session %s: %s\n", session, err)
+
+			return logging
+		}
+	}
+
+	fmt.Printf("Synced %s to session %s, reload the workshop dashboard to see changes.\n", strings.Join(directories, " and "), session)
+
+	return logging
+}
+
+/*
+Find the workshop session changes are synced to and its pod. Where no session
+was given, the session most recently allocated for the workshop is used.
+*/
+func (o *DevOptions) sessionPod(ctx context.Context, clusterConfig *cluster.ClusterConfig, dynamicClient dynamic.Interface, workshop string) (string, *apiv1.Pod, error) {
+	if o.Session != "" {
+		pod, err := runningSessionPod(ctx, clusterConfig, o.Session)
+
+		return o.Session, pod, err
+	}
+
+	environment, err := workshopEnvironmentForPortal(ctx, dynamicClient, o.Portal, workshop)
+
+	if err != nil || environment == nil {
+		return "", nil, err
+	}
+
+	sessions, err := dynamicClient.Resource(workshopSessionResource).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("training.educates.dev/environment.name=%s", environment.GetName())})
+
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to list workshop sessions")
+	}
+
+	var latest *unstructured.Unstructured
+
+	for i := range sessions.Items {
+		item := &sessions.Items[i]
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "educates", "phase")
+
+		if phase != "Allocated" || item.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		if latest == nil || item.GetCreationTimestamp().After(latest.GetCreationTimestamp().Time) {
+			latest = item
+		}
+	}
+
+	if latest == nil {
+		return "", nil, nil
+	}
+
+	pod, err := runningSessionPod(ctx, clusterConfig, latest.GetName())
+
+	return latest.GetName(), pod, err
+}
+
+/*
+Output the logs of the workshop container of a workshop session, from when
+called onwards, until the container exits.
+*/
+func (o *DevOptions) tailLogs(ctx context.Context, clusterConfig *cluster.ClusterConfig, pod *apiv1.Pod) {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return
+	}
+
+	now := metav1.NewTime(time.Now())
+
+	options := &apiv1.PodLogOptions{Container: "workshop", Follow: true, SinceTime: &now}
+
+	reader, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).Stream(ctx)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to get logs for %s: %s\n", pod.Name, err)
+		return
+	}
+
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		fmt.Printf("[session] %s\n", scanner.Text())
+	}
+}
+
+/*
+Record the state of the files in a workshop directory, skipping the Git
+repository and generated files for the workshop instructions.
+*/
+func scanDevFiles(root string) (map[string]devFileState, error) {
+	state := map[string]devFileState{}
+
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(root, file)
+
+		if err != nil {
+			return err
+		}
+
+		relPath = filepath.ToSlash(relPath)
+
+		if entry.IsDir() {
+			if relPath == ".git" || relPath == ".devcontainer" || relPath == "workshop/public" || entry.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		info, err := entry.Info()
+
+		if err != nil {
+			return err
+		}
+
+		state[relPath] = devFileState{modTime: info.ModTime().UnixNano(), size: info.Size()}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to scan workshop directory")
+	}
+
+	return state, nil
+}
+
+/*
+Return the files added, changed or removed between two scans, sorted by name.
+*/
+func changedDevFiles(previous map[string]devFileState, current map[string]devFileState) []string {
+	var changed []string
+
+	for name, state := range current {
+		if previousState, exists := previous[name]; !exists || previousState != state {
+			changed = append(changed, name)
+		}
+	}
+
+	for name := range previous {
+		if _, exists := current[name]; !exists {
+			changed = append(changed, name)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+func (p *ProjectInfo) NewDevCmd() *cobra.Command {
+	var o DevOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "dev [PATH]",
+		Short: "Watch workshop and sync changes to session",
+		Long: `Watch a workshop directory and sync changes to a workshop session.
+
+Watches the workshop directory for files being saved, and on each change
+checks the workshop definition can be loaded and the pages of instructions
+rendered, reporting any errors. Changes to the workshop instructions and
+exercises are then copied into a workshop session for the workshop, and the
+instructions rebuilt in the workshop session in the same way as when it
+starts, with any errors from building them reported. Changes to the workshop
+definition are applied to the workshop in the cluster, and take effect for
+new workshop sessions. Output from the workshop session is shown prefixed
+with "[session]", unless --skip-logs is used.
+
+The workshop must first be deployed with "educates cluster workshop deploy".
+Changes are synced to the workshop session given by --session, or otherwise
+to the workshop session for the workshop most recently allocated to a user,
+so open the workshop from the training portal before starting. Files deleted
+locally are not removed from the workshop session. Runs until interrupted.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				o.Path = args[0]
+			}
+
+			return o.Run(cmd.Context())
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"educates-cli",
+		"name to be used for training portal and workshop name prefixes",
+	)
+	c.Flags().StringVar(
+		&o.Session,
+		"session",
+		"",
+		"name of the workshop session to sync changes to",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopVersion,
+		"workshop-version",
+		"latest",
+		"version of the workshop being deployed",
+	)
+	c.Flags().StringVar(
+		&o.Locale,
+		"locale",
+		"",
+		"locale to check the workshop instructions for",
+	)
+	c.Flags().DurationVar(
+		&o.Interval,
+		"interval",
+		time.Second,
+		"how often to check for changes to files",
+	)
+	c.Flags().BoolVar(
+		&o.SkipLogs,
+		"skip-logs",
+		false,
+		"don't output logs from the workshop session",
+	)
+
+	addLocalDataValuesFlags(c, &o.DataValuesFlags)
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+
+	return c
+}
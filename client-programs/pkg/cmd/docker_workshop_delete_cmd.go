@@ -6,7 +6,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"path"
+	"path/filepath"
 
 	"github.com/adrg/xdg"
 	"github.com/docker/docker/client"
@@ -14,6 +14,8 @@ import (
 	"github.com/spf13/cobra"
 	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 )
 
 type DockerWorkshopDeleteOptions struct {
@@ -63,9 +65,9 @@ func (m *DockerWorkshopsManager) DeleteWorkshop(name string, stdout io.Writer, s
 		return errors.Wrap(err, "unable to delete workshop volume")
 	}
 
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	workshopConfigDir := path.Join(configFileDir, "workshops", name)
-	composeConfigDir := path.Join(configFileDir, "compose", name)
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	workshopConfigDir := filepath.Join(configFileDir, "workshops", name)
+	composeConfigDir := filepath.Join(configFileDir, "compose", name)
 
 	os.RemoveAll(workshopConfigDir)
 	os.RemoveAll(composeConfigDir)
@@ -95,7 +97,14 @@ func (o *DockerWorkshopDeleteOptions) Run(cmd *cobra.Command) error {
 
 		var workshop *unstructured.Unstructured
 
-		if workshop, err = loadWorkshopDefinition(o.Name, path, "educates-cli", o.WorkshopFile, o.WorkshopVersion, o.DataValuesFlags); err != nil {
+		if workshop, err = training.LoadWorkshopDefinition(&training.DefinitionOptions{
+			Name:            o.Name,
+			Path:            path,
+			Portal:          "educates-cli",
+			WorkshopFile:    o.WorkshopFile,
+			WorkshopVersion: o.WorkshopVersion,
+			DataValuesFlags: o.DataValuesFlags,
+		}); err != nil {
 			return err
 		}
 
@@ -184,5 +193,5 @@ func (p *ProjectInfo) NewDockerWorkshopDeleteCmd() *cobra.Command {
 		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
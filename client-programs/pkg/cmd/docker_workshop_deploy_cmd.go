@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -29,6 +28,7 @@ import (
 	"sigs.k8s.io/kind/pkg/cmd"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/registry"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 )
 
 type DockerWorkshopDeployOptions struct {
@@ -103,7 +103,13 @@ func (m *DockerWorkshopsManager) DeployWorkshop(o *DockerWorkshopDeployOptions,
 
 	var workshop *unstructured.Unstructured
 
-	if workshop, err = loadWorkshopDefinition("", o.Path, "educates-cli", o.WorkshopFile, o.WorkshopVersion, o.DataValuesFlags); err != nil {
+	if workshop, err = training.LoadWorkshopDefinition(&training.DefinitionOptions{
+		Path:            o.Path,
+		Portal:          "educates-cli",
+		WorkshopFile:    o.WorkshopFile,
+		WorkshopVersion: o.WorkshopVersion,
+		DataValuesFlags: o.DataValuesFlags,
+	}); err != nil {
 		return "", err
 	}
 
@@ -115,8 +121,8 @@ func (m *DockerWorkshopsManager) DeployWorkshop(o *DockerWorkshopDeployOptions,
 
 	originalName := workshop.GetAnnotations()["training.educates.dev/workshop"]
 
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	composeConfigDir := path.Join(configFileDir, "compose", name)
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	composeConfigDir := filepath.Join(configFileDir, "compose", name)
 
 	err = os.MkdirAll(composeConfigDir, os.ModePerm)
 
@@ -139,7 +145,7 @@ func (m *DockerWorkshopsManager) DeployWorkshop(o *DockerWorkshopDeployOptions,
 	}
 
 	if o.Repository == "localhost:5001" {
-		err = registry.DeployRegistry()
+		err = registry.DeployRegistry(ctx)
 
 		if err != nil {
 			return name, errors.Wrap(err, "failed to deploy registry")
@@ -356,7 +362,7 @@ func (m *DockerWorkshopsManager) DeployWorkshop(o *DockerWorkshopDeployOptions,
 		return name, errors.Wrap(err, "failed to generate compose config")
 	}
 
-	composeConfigFilePath := path.Join(composeConfigDir, "docker-compose.yaml")
+	composeConfigFilePath := filepath.Join(composeConfigDir, "docker-compose.yaml")
 
 	composeConfigFile, err := os.OpenFile(composeConfigFilePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
 
@@ -428,16 +434,7 @@ func (o *DockerWorkshopDeployOptions) Run(cmd *cobra.Command) error {
 			break
 		}
 
-		switch runtime.GOOS {
-		case "linux":
-			err = exec.Command("xdg-open", url).Start()
-		case "windows":
-			err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
-		case "darwin":
-			err = exec.Command("open", url).Start()
-		default:
-			err = fmt.Errorf("unsupported platform")
-		}
+		err = openWebBrowser(url)
 
 		if err != nil {
 			return errors.Wrap(err, "unable to open web browser")
@@ -572,7 +569,7 @@ func (p *ProjectInfo) NewDockerWorkshopDeployCmd() *cobra.Command {
 		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
 	)
 
-	return c
+	return withAuditLogging(c)
 }
 
 func generateWorkshopConfig(workshop *unstructured.Unstructured) (string, error) {
@@ -628,7 +625,7 @@ func generateVendirFilesConfig(workshop *unstructured.Unstructured, name string,
 				filesItemPath = "."
 			}
 
-			filesItemPath = filepath.Clean(path.Join("/opt/assets/files", filesItemPath))
+			filesItemPath = filepath.Clean(filepath.Join("/opt/assets/files", filesItemPath))
 
 			filesItem.(map[string]interface{})["path"] = "."
 
@@ -685,7 +682,7 @@ func generateVendirPackagesConfig(workshop *unstructured.Unstructured, name stri
 				continue
 			}
 
-			packagesItemPath := filepath.Clean(path.Join("/opt/packages", tmpName.(string)))
+			packagesItemPath := filepath.Clean(filepath.Join("/opt/packages", tmpName.(string)))
 
 			tmpPackagesFilesItem := tmpPackagesItem["files"]
 
@@ -747,24 +744,10 @@ func generateWorkshopImageName(workshop *unstructured.Unstructured, repository s
 		image = "base-environment:*"
 	}
 
-	defaultImageVersion := strings.TrimSpace(baseImageVersion)
-
 	if workshopImage != "" {
 		image = workshopImage
 	} else {
-		if defaultImageVersion == "latest" {
-			image = strings.ReplaceAll(image, "base-environment:*", fmt.Sprintf("localhost:5001/educates-base-environment:%s", defaultImageVersion))
-			image = strings.ReplaceAll(image, "jdk8-environment:*", fmt.Sprintf("localhost:5001/educates-jdk8-environment:%s", defaultImageVersion))
-			image = strings.ReplaceAll(image, "jdk11-environment:*", fmt.Sprintf("localhost:5001/educates-jdk11-environment:%s", defaultImageVersion))
-			image = strings.ReplaceAll(image, "jdk17-environment:*", fmt.Sprintf("localhost:5001/educates-jdk17-environment:%s", defaultImageVersion))
-			image = strings.ReplaceAll(image, "conda-environment:*", fmt.Sprintf("localhost:5001/educates-conda-environment:%s", defaultImageVersion))
-		} else {
-			image = strings.ReplaceAll(image, "base-environment:*", fmt.Sprintf("ghcr.io/vmware-tanzu-labs/educates-base-environment:%s", defaultImageVersion))
-			image = strings.ReplaceAll(image, "jdk8-environment:*", fmt.Sprintf("ghcr.io/vmware-tanzu-labs/educates-jdk8-environment:%s", defaultImageVersion))
-			image = strings.ReplaceAll(image, "jdk11-environment:*", fmt.Sprintf("ghcr.io/vmware-tanzu-labs/educates-jdk11-environment:%s", defaultImageVersion))
-			image = strings.ReplaceAll(image, "jdk17-environment:*", fmt.Sprintf("ghcr.io/vmware-tanzu-labs/educates-jdk17-environment:%s", defaultImageVersion))
-			image = strings.ReplaceAll(image, "conda-environment:*", fmt.Sprintf("ghcr.io/vmware-tanzu-labs/educates-conda-environment:%s", defaultImageVersion))
-		}
+		image = resolveWorkshopImageAlias(image, baseImageVersion)
 	}
 
 	image = strings.ReplaceAll(image, "$(image_repository)", repository)
@@ -773,6 +756,27 @@ func generateWorkshopImageName(workshop *unstructured.Unstructured, repository s
 	return image, nil
 }
 
+/*
+Resolve the short names for the workshop base images provided with Educates,
+such as "base-environment:*", to the image reference for the version of the
+workshop base images. Any other image reference is returned unchanged.
+*/
+func resolveWorkshopImageAlias(image string, baseImageVersion string) string {
+	baseImageVersion = strings.TrimSpace(baseImageVersion)
+
+	repository := "ghcr.io/vmware-tanzu-labs"
+
+	if baseImageVersion == "latest" {
+		repository = "localhost:5001"
+	}
+
+	for _, name := range []string{"base-environment", "jdk8-environment", "jdk11-environment", "jdk17-environment", "conda-environment"} {
+		image = strings.ReplaceAll(image, fmt.Sprintf("%s:*", name), fmt.Sprintf("%s/educates-%s:%s", repository, name, baseImageVersion))
+	}
+
+	return image
+}
+
 func generateWorkshopVolumeMounts(workshop *unstructured.Unstructured, assets string) ([]composetypes.ServiceVolumeConfig, error) {
 	filesMounts := []composetypes.ServiceVolumeConfig{
 		{
@@ -7,6 +7,8 @@ import (
 	"github.com/spf13/cobra"
 	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 )
 
 type DockerWorkshopLogsOptions struct {
@@ -40,7 +42,14 @@ func (o *DockerWorkshopLogsOptions) Run(cmd *cobra.Command) error {
 
 		var workshop *unstructured.Unstructured
 
-		if workshop, err = loadWorkshopDefinition(o.Name, path, "educates-cli", o.WorkshopFile, o.WorkshopVersion, o.DataValuesFlags); err != nil {
+		if workshop, err = training.LoadWorkshopDefinition(&training.DefinitionOptions{
+			Name:            o.Name,
+			Path:            path,
+			Portal:          "educates-cli",
+			WorkshopFile:    o.WorkshopFile,
+			WorkshopVersion: o.WorkshopVersion,
+			DataValuesFlags: o.DataValuesFlags,
+		}); err != nil {
 			return err
 		}
 
@@ -2,11 +2,8 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"net/http"
-	"os/exec"
-	"runtime"
 	"time"
 
 	"github.com/docker/docker/client"
@@ -14,6 +11,9 @@ import (
 	"github.com/spf13/cobra"
 	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 )
 
 type DockerWorkshopOpenOptions struct {
@@ -22,6 +22,7 @@ type DockerWorkshopOpenOptions struct {
 	WorkshopFile    string
 	WorkshopVersion string
 	DataValuesFlags yttcmd.DataValuesFlags
+	Browser         WebBrowserOptions
 }
 
 func (o *DockerWorkshopOpenOptions) Run() error {
@@ -46,7 +47,14 @@ func (o *DockerWorkshopOpenOptions) Run() error {
 
 		var workshop *unstructured.Unstructured
 
-		if workshop, err = loadWorkshopDefinition(o.Name, path, "educates-cli", o.WorkshopFile, o.WorkshopVersion, o.DataValuesFlags); err != nil {
+		if workshop, err = training.LoadWorkshopDefinition(&training.DefinitionOptions{
+			Name:            o.Name,
+			Path:            path,
+			Portal:          "educates-cli",
+			WorkshopFile:    o.WorkshopFile,
+			WorkshopVersion: o.WorkshopVersion,
+			DataValuesFlags: o.DataValuesFlags,
+		}); err != nil {
 			return err
 		}
 
@@ -66,7 +74,7 @@ func (o *DockerWorkshopOpenOptions) Run() error {
 	container, err := cli.ContainerInspect(ctx, name)
 
 	if err != nil {
-		return errors.New("unable to find workshop")
+		return failures.NewNotFoundError(errors.New("unable to find workshop"), "run `educates docker workshop list` to see running workshops")
 	}
 
 	url, found := container.Config.Labels["training.educates.dev/url"]
@@ -93,22 +101,7 @@ func (o *DockerWorkshopOpenOptions) Run() error {
 		break
 	}
 
-	switch runtime.GOOS {
-	case "linux":
-		err = exec.Command("xdg-open", url).Start()
-	case "windows":
-		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
-	case "darwin":
-		err = exec.Command("open", url).Start()
-	default:
-		err = fmt.Errorf("unsupported platform")
-	}
-
-	if err != nil {
-		return errors.Wrap(err, "unable to open web browser")
-	}
-
-	return nil
+	return o.Browser.Open(url)
 }
 
 func (p *ProjectInfo) NewDockerWorkshopOpenCmd() *cobra.Command {
@@ -188,5 +181,7 @@ func (p *ProjectInfo) NewDockerWorkshopOpenCmd() *cobra.Command {
 		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
 	)
 
+	o.Browser.AddFlags(c)
+
 	return c
 }
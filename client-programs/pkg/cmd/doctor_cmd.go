@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/preflight"
+)
+
+type DoctorOptions struct {
+	Config string
+	Domain string
+}
+
+func (o *DoctorOptions) Run(ctx context.Context) error {
+	fullConfig, err := config.NewInstallationConfigFromFile(o.Config)
+
+	if err != nil {
+		return err
+	}
+
+	if o.Domain != "" {
+		fullConfig.ClusterIngress.Domain = o.Domain
+	}
+
+	env := preflight.Environment{
+		Config: fullConfig,
+	}
+
+	// Only provide the docker client to the checks where the docker daemon
+	// can be reached, so checks needing it are skipped rather than each
+	// reporting the same failure as the check for the docker daemon.
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+
+	if err == nil {
+		defer cli.Close()
+
+		pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+
+		if _, err = cli.Ping(pingCtx); err == nil {
+			env.Docker = cli
+		}
+
+		cancel()
+	}
+
+	results := preflight.RunChecks(ctx, &env, preflight.MachineChecks())
+
+	preflight.Report(os.Stdout, results)
+
+	if preflight.Failed(results) {
+		return failures.NewValidationError(errors.New("problems found with the local machine"), "apply the fixes shown and run `educates doctor` again")
+	}
+
+	fmt.Println("No problems found which would stop Educates working.")
+
+	return nil
+}
+
+func (p *ProjectInfo) NewDoctorCmd() *cobra.Command {
+	var o DoctorOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "doctor",
+		Short: "Diagnose problems with the local machine",
+		Long: `Diagnose problems with the local machine.
+
+Checks the local machine is set up to run Educates in a local Kind cluster,
+covering whether the docker daemon can be reached and is a supported version,
+the memory, CPUs and disk space available to docker, whether other Kind
+clusters are running or the Kind network conflicts with other networks,
+whether host names under the ingress domain resolve, and whether the ports
+80 and 443 are free for the ingress controller.
+
+Problems which would prevent Educates working are reported as failures, with
+problems which may make Educates slow or unreliable reported as warnings. For
+each problem found, instructions for fixing it on the operating system being
+used are shown.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Config,
+		"config",
+		"",
+		"path to the installation config file for Educates",
+	)
+	c.Flags().StringVar(
+		&o.Domain,
+		"domain",
+		"",
+		"wildcard ingress subdomain name for Educates",
+	)
+
+	return c
+}
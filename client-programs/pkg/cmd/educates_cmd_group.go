@@ -1,12 +1,62 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cache"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/httpclient"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/update"
 )
 
+var caCertFiles []string
+
+/*
+Combine the CA certificates given on the command line with those from the
+client config file, and configure HTTP requests to trust them.
+*/
+func configureHTTPClient() error {
+	clientConfig, err := config.LoadClientConfig()
+
+	if err != nil {
+		return err
+	}
+
+	httpclient.CACertFiles = append(append([]string{}, clientConfig.CACertificates...), caCertFiles...)
+
+	return httpclient.Configure()
+}
+
+/*
+Prepare to run a command. Apply any defaults configured for flags of the
+command. Set up trust of any additional CA certificates for requests for
+remote content. Check for version skew between the CLI and the platform
+installed in the cluster for commands which create or modify resources.
+*/
+func (p *ProjectInfo) prepareCommand(cmd *cobra.Command, _ []string) error {
+	if err := applyFlagDefaults(cmd); err != nil {
+		return err
+	}
+
+	if err := configureHTTPClient(); err != nil {
+		return err
+	}
+
+	if needsVersionSkewCheck(cmd) {
+		return checkVersionSkew(cmd.Context(), cmd, p.Version)
+	}
+
+	return nil
+}
+
 /*
 Create root Cobra command group for Educates CLI .
 */
@@ -14,8 +64,90 @@ func (p *ProjectInfo) NewEducatesCmdGroup() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "educates",
 		Short: "Tools for managing Educates",
+
+		PersistentPreRunE: p.prepareCommand,
+
+		// Let the user know when a newer version of the CLI is available.
+		// This is only done when attached to a terminal so as not to mess
+		// up output being captured by scripts.
+
+		PersistentPostRun: func(cmd *cobra.Command, _ []string) {
+			switch cmd.Name() {
+			case "update", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+				return
+			}
+
+			if !term.IsTerminal(int(os.Stderr.Fd())) {
+				return
+			}
+
+			if version := update.NewerVersionAvailable(p.Version); version != "" {
+				fmt.Fprintf(os.Stderr, "\nA newer version %s of the Educates CLI is available, run `educates update` to update.\n", version)
+			}
+		},
 	}
 
+	// Replace the default completion command generated by Cobra with our own
+	// so that it can be placed in the command groups below.
+
+	c.CompletionOptions.DisableDefaultCmd = true
+
+	c.PersistentFlags().BoolVarP(
+		&interactionOptions.AssumeYes,
+		"yes",
+		"y",
+		false,
+		"automatically answer yes to any confirmation prompts",
+	)
+	c.PersistentFlags().BoolVar(
+		&interactionOptions.NonInteractive,
+		"non-interactive",
+		false,
+		"never prompt for input, failing if confirmation is required",
+	)
+	c.PersistentFlags().BoolVar(
+		&strictVersionCheck,
+		"strict-version-check",
+		false,
+		"fail rather than warn when the CLI and installed platform versions differ",
+	)
+	c.PersistentFlags().DurationVar(
+		&cluster.RequestTimeout,
+		"request-timeout",
+		0,
+		"timeout for each request to the Kubernetes API server, zero means no timeout",
+	)
+	c.PersistentFlags().IntVar(
+		&cluster.RequestRetries,
+		"request-retries",
+		cluster.RequestRetries,
+		"number of times to retry requests to the Kubernetes API server on transient failures",
+	)
+	c.PersistentFlags().BoolVar(
+		&cache.Disabled,
+		"no-cache",
+		false,
+		"do not use cached cluster discovery data or downloads, fetching them again",
+	)
+	c.PersistentFlags().DurationVar(
+		&cache.TTL,
+		"cache-ttl",
+		cache.DefaultTTL,
+		"how long cached cluster discovery data and downloads are used for",
+	)
+	c.PersistentFlags().StringArrayVar(
+		&caCertFiles,
+		"ca-cert",
+		[]string{},
+		"additional CA certificate file to trust for remote downloads and image registries",
+	)
+	c.PersistentFlags().BoolVar(
+		&auditEvents,
+		"audit-events",
+		false,
+		"also record changes in the audit log as Kubernetes events",
+	)
+
 	// Use a command group as it allows us to dictate the order in which they
 	// are displayed in the help message, as otherwise they are displayed in
 	// sort order.
@@ -31,6 +163,9 @@ func (p *ProjectInfo) NewEducatesCmdGroup() *cobra.Command {
 			Commands: []*cobra.Command{
 				overrideCommandName(p.NewAdminClusterCreateCmd(), "create-cluster"),
 				overrideCommandName(p.NewAdminClusterDeleteCmd(), "delete-cluster"),
+				withVersionSkewCheck(p.NewClusterSyncCmd()),
+				withVersionSkewCheck(p.NewClusterWatchCmd()),
+				withVersionSkewCheck(p.NewClusterTopCmd()),
 			},
 		},
 		{
@@ -39,15 +174,16 @@ func (p *ProjectInfo) NewEducatesCmdGroup() *cobra.Command {
 				overrideCommandName(p.NewWorkshopNewCmd(), "new-workshop"),
 				overrideCommandName(p.NewWorkshopPublishCmd(), "publish-workshop"),
 				overrideCommandName(p.NewWorkshopExportCmd(), "export-workshop"),
-				overrideCommandName(p.NewClusterWorkshopDeployCmd(), "deploy-workshop"),
-				overrideCommandName(p.NewClusterWorkshopListCmd(), "list-workshops"),
-				overrideCommandName(p.NewClusterWorkshopRequestCmd(), "request-workshop"),
-				overrideCommandName(p.NewClusterWorkshopServeCmd(), "serve-workshop"),
-				overrideCommandName(p.NewClusterWorkshopUpdateCmd(), "update-workshop"),
-				overrideCommandName(p.NewClusterWorkshopDeleteCmd(), "delete-workshop"),
-				overrideCommandName(p.NewClusterPortalOpenCmd(), "browse-workshops"),
-				overrideCommandName(p.NewClusterPortalPasswordCmd(), "view-credentials"),
-				overrideCommandName(p.NewClusterSessionListCmd(), "list-sessions"),
+				withVersionSkewCheck(overrideCommandName(p.NewClusterWorkshopDeployCmd(), "deploy-workshop")),
+				withVersionSkewCheck(overrideCommandName(p.NewClusterWorkshopListCmd(), "list-workshops")),
+				withVersionSkewCheck(overrideCommandName(p.NewClusterWorkshopRequestCmd(), "request-workshop")),
+				withVersionSkewCheck(overrideCommandName(p.NewClusterWorkshopServeCmd(), "serve-workshop")),
+				withVersionSkewCheck(p.NewDevCmd()),
+				withVersionSkewCheck(overrideCommandName(p.NewClusterWorkshopUpdateCmd(), "update-workshop")),
+				withVersionSkewCheck(overrideCommandName(p.NewClusterWorkshopDeleteCmd(), "delete-workshop")),
+				withVersionSkewCheck(overrideCommandName(p.NewClusterPortalOpenCmd(), "browse-workshops")),
+				withVersionSkewCheck(overrideCommandName(p.NewClusterPortalPasswordCmd(), "view-credentials")),
+				withVersionSkewCheck(overrideCommandName(p.NewClusterSessionListCmd(), "list-sessions")),
 			},
 		},
 		{
@@ -56,10 +192,28 @@ func (p *ProjectInfo) NewEducatesCmdGroup() *cobra.Command {
 				p.NewProjectCmdGroup(),
 				p.NewWorkshopCmdGroup(),
 				p.NewTemplateCmdGroup(),
-				p.NewClusterCmdGroup(),
+				withVersionSkewCheck(p.NewClusterCmdGroup()),
+				withVersionSkewCheck(p.NewEventCmdGroup()),
+				p.NewCloudCmdGroup(),
 				p.NewDockerCmdGroup(),
+				p.NewLocalCmdGroup(),
 				p.NewTunnelCmdGroup(),
+				p.NewApiCmdGroup(),
+				p.NewAnalyticsCmdGroup(),
 				p.NewAdminCmdGroup(),
+				p.NewAuditCmdGroup(),
+				p.NewCredentialsCmdGroup(),
+				p.NewConfigCmdGroup(),
+			},
+		},
+		{
+			Message: "Utility Commands:",
+			Commands: []*cobra.Command{
+				p.NewCompletionCmd(),
+				p.NewProjectVersionCmd(),
+				p.NewDoctorCmd(),
+				p.NewUpdateCmd(),
+				p.NewStatsCmd(),
 			},
 		},
 	}
@@ -68,5 +222,57 @@ func (p *ProjectInfo) NewEducatesCmdGroup() *cobra.Command {
 
 	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
 
+	// Record the outcome of any commands which make changes in the audit log.
+
+	enableAuditLogging(c)
+
+	// Record usage of commands if the user has opted in to doing so.
+
+	enableTelemetry(c, p.Version)
+
+	return c
+}
+
+/*
+Prepare a fresh instance of a CLI command to be run from within another
+command, such as for an API request. The command is placed under groups with
+the same names as on the command line, so that it is recorded in the audit
+log and telemetry with the same command path, has flag defaults applied, and
+is wrapped in the same way as when run from the command line.
+*/
+func (p *ProjectInfo) nestedCommand(c *cobra.Command, groups ...string) *cobra.Command {
+	parent := &cobra.Command{Use: "educates", PersistentPreRunE: p.prepareCommand}
+
+	for _, name := range groups {
+		group := &cobra.Command{Use: name}
+
+		parent.AddCommand(group)
+
+		parent = group
+	}
+
+	parent.AddCommand(c)
+
+	enableAuditLogging(c)
+
+	enableTelemetry(c, p.Version)
+
 	return c
 }
+
+/*
+Run a command prepared using nestedCommand with the given arguments. Cobra
+always executes a command from the root, so the arguments are set on the root
+command along with the path to the command.
+*/
+func runNestedCommand(ctx context.Context, c *cobra.Command, args ...string) error {
+	root := c.Root()
+
+	path := strings.Fields(c.CommandPath())[1:]
+
+	root.SetArgs(append(path, args...))
+	root.SilenceUsage = true
+	root.SilenceErrors = true
+
+	return root.ExecuteContext(ctx)
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+)
+
+func (p *ProjectInfo) NewEventCmdGroup() *cobra.Command {
+	var c = &cobra.Command{
+		Use:   "event",
+		Short: "Manage training events",
+		Long: `Manage training events.
+
+An event manifest is a YAML file describing everything needed for running a
+training day: the training portal, the workshops it hosts along with their
+capacities, access codes for attendees and the window during which the
+workshops are available. For example:
+
+    name: workshop-day
+    portal:
+      hostname: workshops
+      password:
+        policy: fixed
+        value: letmein
+    schedule:
+      start: 2026-11-12T09:00:00+01:00
+      end: 2026-11-12T17:00:00+01:00
+    accessCodes:
+      count: 50
+      output: codes.csv
+    workshops:
+    - path: lab-kubernetes-fundamentals
+      capacity: 50
+      reserved: 5
+      expires: 60m
+
+The name of the training portal defaults to the name of the event, and the
+maximum number of sessions for it to the combined capacity of the workshops.
+Other portal settings are the same as for the portal defaults config used
+when deploying a workshop. Relative paths are resolved against the directory
+holding the event manifest.`,
+	}
+
+	// Use a command group as it allows us to dictate the order in which they
+	// are displayed in the help message, as otherwise they are displayed in
+	// sort order.
+
+	commandGroups := templates.CommandGroups{
+		{
+			Message: "Available Commands:",
+			Commands: []*cobra.Command{
+				p.NewEventDeployCmd(),
+				p.NewEventTeardownCmd(),
+			},
+		},
+	}
+
+	commandGroups.Add(c)
+
+	templates.ActsAsRootCommand(c, []string{"options"}, commandGroups...)
+
+	return c
+}
+
+/*
+Return the names of the workshops associated with a training portal, being
+those listed in the training portal and those with scheduled changes for it,
+such as workshops which are yet to be added to the training portal.
+*/
+func eventWorkshopNames(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string) ([]string, error) {
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	found := map[string]bool{}
+
+	trainingPortal, err := dynamicClient.Resource(trainingPortalResource).Get(ctx, portal, metav1.GetOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "unable to query training portal %q in cluster", portal)
+	}
+
+	if err == nil {
+		workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+		for _, item := range workshops {
+			if entry, ok := item.(map[string]interface{}); ok {
+				if name, ok := entry["name"].(string); ok && name != "" {
+					found[name] = true
+				}
+			}
+		}
+	}
+
+	cronJobs, err := client.BatchV1().CronJobs(scheduleNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "training.educates.dev/portal.name=" + portal,
+	})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "unable to query scheduled changes for training portal %q", portal)
+	}
+
+	if err == nil {
+		for _, cronJob := range cronJobs.Items {
+			if name := cronJob.Labels["training.educates.dev/workshop.name"]; name != "" {
+				found[name] = true
+			}
+		}
+	}
+
+	var names []string
+
+	for name := range found {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -0,0 +1,437 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+type EventDeployOptions struct {
+	Kubeconfig  string
+	WaitTimeout time.Duration
+}
+
+func (o *EventDeployOptions) Run(ctx context.Context, file string) error {
+	var err error
+
+	event, err := config.LoadEventConfig(file)
+
+	if err != nil {
+		return err
+	}
+
+	portal := event.Portal.Name
+
+	// Work out the window during which the workshops are available. Once
+	// the event has started the workshops are added to the training portal
+	// straight away, so that the event can be deployed again to make
+	// changes while it is running.
+
+	var startAt, endAt string
+
+	now := time.Now()
+
+	if event.Schedule.End != "" {
+		end, err := parseScheduleTime(event.Schedule.End)
+
+		if err != nil {
+			return err
+		}
+
+		if !end.After(now) {
+			return failures.NewValidationError(errors.Errorf("event %q ended at %s", event.Name, end.Format(time.RFC3339)), "update the schedule in the event manifest, or tear down the event")
+		}
+
+		endAt = event.Schedule.End
+	}
+
+	if event.Schedule.Start != "" {
+		start, err := parseScheduleTime(event.Schedule.Start)
+
+		if err != nil {
+			return err
+		}
+
+		if start.After(now) {
+			startAt = event.Schedule.Start
+		}
+	}
+
+	hostname := event.Portal.Hostname
+
+	if hostname != "" {
+		if hostname, err = resolvePortalHostname(hostname); err != nil {
+			return err
+		}
+	}
+
+	// Load all the workshop definitions before making any changes so that
+	// problems with any of them are found up front.
+
+	var deployments []*ClusterWorkshopDeployOptions
+
+	desired := map[string]bool{}
+
+	for _, workshop := range event.Workshops {
+		deployment := o.workshopDeployOptions(portal, hostname, workshop, startAt, endAt)
+
+		workshops, err := deployment.loadWorkshops(deployment.Path)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to load workshop from %q", workshop.Path)
+		}
+
+		for _, item := range workshops {
+			if desired[item.GetName()] {
+				return failures.NewValidationError(errors.Errorf("more than one workshop would be deployed with the name %q", item.GetName()), "give the workshops distinct names in the event manifest")
+			}
+
+			desired[item.GetName()] = true
+		}
+
+		deployments = append(deployments, deployment)
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	// Find any workshops from a previous deployment of the event which are
+	// no longer listed in the event manifest.
+
+	existing, err := eventWorkshopNames(ctx, clusterConfig, portal)
+
+	if err != nil {
+		return err
+	}
+
+	// Update the training portal, creating it if necessary.
+
+	if err = applyEventPortal(ctx, dynamicClient, event, hostname); err != nil {
+		return err
+	}
+
+	fmt.Printf("Training portal %q deployed.\n", portal)
+
+	// Deploy each of the workshops. Any scheduled changes from a previous
+	// deployment of the event are removed first so that they match the
+	// schedule in the event manifest.
+
+	for _, name := range existing {
+		if desired[name] {
+			if err = deleteWorkshopSchedules(ctx, clusterConfig, portal, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, deployment := range deployments {
+		if err = deployment.Run(ctx); err != nil {
+			return errors.Wrapf(err, "unable to deploy workshop from %q", event.Workshops[i].Path)
+		}
+	}
+
+	var names []string
+
+	for name := range desired {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if startAt != "" {
+			fmt.Printf("Workshop %q scheduled.\n", name)
+		} else {
+			fmt.Printf("Workshop %q deployed.\n", name)
+		}
+	}
+
+	// Remove workshops from the training portal which are no longer listed
+	// in the event manifest.
+
+	for _, name := range existing {
+		if desired[name] {
+			continue
+		}
+
+		if err = deleteWorkshopSchedules(ctx, clusterConfig, portal, name); err != nil {
+			return err
+		}
+
+		if err = training.DeleteWorkshopResource(ctx, dynamicClient, name, portal); err != nil {
+			return err
+		}
+
+		fmt.Printf("Workshop %q pruned.\n", name)
+	}
+
+	// Issue access codes for attendees last, as the output of the codes may
+	// be going to stdout.
+
+	var codesExpire time.Time
+
+	if endAt != "" {
+		codesExpire, _ = parseScheduleTime(endAt)
+	} else {
+		codesExpire = now.Add(event.AccessCodes.Expires)
+	}
+
+	return issueEventAccessCodes(ctx, clusterConfig, portal, event.AccessCodes, codesExpire.UTC().Truncate(time.Second))
+}
+
+/*
+Return the options for deploying a workshop for an event, using the same
+defaults as when deploying the workshop using the CLI.
+*/
+func (o *EventDeployOptions) workshopDeployOptions(portal string, hostname string, workshop config.EventWorkshopConfig, startAt string, endAt string) *ClusterWorkshopDeployOptions {
+	orphaned := workshop.Orphaned
+
+	if orphaned == "" {
+		orphaned = "5m"
+	}
+
+	return &ClusterWorkshopDeployOptions{
+		Name:            workshop.Name,
+		Path:            workshop.Path,
+		Kubeconfig:      o.Kubeconfig,
+		Portal:          portal,
+		Hostname:        hostname,
+		StartAt:         startAt,
+		EndAt:           endAt,
+		Capacity:        workshop.Capacity,
+		Reserved:        workshop.Reserved,
+		Initial:         workshop.Initial,
+		Expires:         workshop.Expires,
+		Overtime:        workshop.Overtime,
+		Deadline:        workshop.Deadline,
+		Orphaned:        orphaned,
+		Overdue:         "2m",
+		Environ:         workshop.Environ,
+		WorkshopFile:    "resources/workshop.yaml",
+		WorkshopVersion: "latest",
+		Parallel:        4,
+		Strategy:        "in-place",
+		WaitTimeout:     o.WaitTimeout,
+	}
+}
+
+/*
+Create or update the training portal for an event. When the training portal
+already exists, any random password it was given is retained.
+*/
+func applyEventPortal(ctx context.Context, client dynamic.Interface, event *config.EventConfig, hostname string) error {
+	portal := event.Portal.Name
+
+	trainingPortalClient := client.Resource(trainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, portal, metav1.GetOptions{})
+
+	var trainingPortalExists = true
+
+	if k8serrors.IsNotFound(err) {
+		trainingPortalExists = false
+
+		trainingPortal = training.NewTrainingPortal(portal, &event.Portal.PortalDefaultsConfig)
+	} else if err != nil {
+		return errors.Wrapf(err, "unable to query training portal %q in cluster", portal)
+	}
+
+	if trainingPortalExists {
+		settings := event.Portal.PortalDefaultsConfig
+
+		unstructured.SetNestedField(trainingPortal.Object, settings.Registration.Type, "spec", "portal", "registration", "type")
+		unstructured.SetNestedField(trainingPortal.Object, settings.Sessions.Maximum, "spec", "portal", "sessions", "maximum")
+		unstructured.SetNestedField(trainingPortal.Object, settings.Updates.Workshop, "spec", "portal", "updates", "workshop")
+
+		switch settings.Password.Policy {
+		case config.PasswordPolicyFixed:
+			unstructured.SetNestedField(trainingPortal.Object, settings.Password.Value, "spec", "portal", "password")
+		case config.PasswordPolicyNone:
+			unstructured.RemoveNestedField(trainingPortal.Object, "spec", "portal", "password")
+		case config.PasswordPolicyRandom:
+			if password, _, _ := unstructured.NestedString(trainingPortal.Object, "spec", "portal", "password"); password == "" {
+				unstructured.SetNestedField(trainingPortal.Object, training.RandomPassword(settings.Password.Length), "spec", "portal", "password")
+			}
+		}
+	}
+
+	training.SetTrainingPortalHostname(trainingPortal, hostname)
+
+	if trainingPortalExists {
+		_, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+	} else {
+		_, err = trainingPortalClient.Create(ctx, trainingPortal, metav1.CreateOptions{FieldManager: "educates-cli"})
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal %q in cluster", portal)
+	}
+
+	return nil
+}
+
+/*
+Ensure the required number of unexpired access codes exist for an event,
+generating more if necessary, and output them as CSV. The expiry of existing
+codes is updated so that all codes expire at the same time.
+*/
+func issueEventAccessCodes(ctx context.Context, clusterConfig *cluster.ClusterConfig, portal string, settings config.EventAccessCodesConfig, expires time.Time) error {
+	if settings.Count == 0 {
+		return nil
+	}
+
+	trainingPortal, err := getTrainingPortal(ctx, clusterConfig, portal)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	codes, err := readPortalAccessCodes(ctx, client, portal)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var available []string
+
+	for code, details := range codes {
+		if details.Expires.After(now) {
+			details.Expires = expires
+			codes[code] = details
+
+			available = append(available, code)
+		}
+	}
+
+	sort.Strings(available)
+
+	if uint(len(available)) > settings.Count {
+		fmt.Fprintf(os.Stderr, "Warning: %d access codes already issued, more than the %d requested.\n", len(available), settings.Count)
+	}
+
+	for uint(len(available)) < settings.Count {
+		code, err := randomAccessCode()
+
+		if err != nil {
+			return err
+		}
+
+		if _, exists := codes[code]; exists {
+			continue
+		}
+
+		codes[code] = portalAccessCode{Created: now, Expires: expires}
+
+		available = append(available, code)
+	}
+
+	err = writePortalAccessCodes(ctx, clusterConfig, trainingPortal, codes)
+
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+
+	if settings.Output != "" {
+		file, err := os.Create(settings.Output)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create output file %s", settings.Output)
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	w := csv.NewWriter(out)
+
+	w.Write([]string{"code", "portal", "expires"})
+
+	for _, code := range available {
+		w.Write([]string{code, portal, expires.Format(time.RFC3339)})
+	}
+
+	w.Flush()
+
+	if err = w.Error(); err != nil {
+		return err
+	}
+
+	if settings.Output != "" {
+		fmt.Printf("Access codes written to %s.\n", settings.Output)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewEventDeployCmd() *cobra.Command {
+	var o EventDeployOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "deploy FILE",
+		Short: "Deploy the portal and workshops for an event",
+		Long: `Deploy the training portal and workshops for an event.
+
+Reconciles the cluster with the event manifest. The training portal is
+created or updated, each workshop is deployed to it with the capacity given,
+and any workshops deployed by a previous run which are no longer listed are
+removed from the training portal. Where the event has a start time in the
+future the workshops are scheduled to be added to the training portal at
+that time, and where it has an end time they are scheduled to be removed at
+that time. Deploying the event again after it has started adds the workshops
+immediately, so changes can still be made while the event is running.
+
+If access codes are requested, codes are generated so that the required
+number are available, with all codes set to expire at the end of the event,
+and the codes are written as CSV to the output file given in the event
+manifest, or to stdout.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args[0]) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().DurationVar(
+		&o.WaitTimeout,
+		"wait-timeout",
+		10*time.Minute,
+		"maximum time to wait for dependencies of a workshop to be ready",
+	)
+
+	return withAuditLogging(c)
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+type EventTeardownOptions struct {
+	Kubeconfig    string
+	KeepWorkshops bool
+}
+
+func (o *EventTeardownOptions) Run(ctx context.Context, file string) error {
+	var err error
+
+	event, err := config.LoadEventConfig(file)
+
+	if err != nil {
+		return err
+	}
+
+	portal := event.Portal.Name
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	names, err := eventWorkshopNames(ctx, clusterConfig, portal)
+
+	if err != nil {
+		return err
+	}
+
+	err = confirmAction(fmt.Sprintf("tear down event %q, deleting training portal %q and all its workshop sessions", event.Name, portal))
+
+	if err != nil {
+		return err
+	}
+
+	// Remove scheduled changes first so workshops aren't added back to the
+	// training portal while it is being deleted.
+
+	for _, name := range names {
+		if err = deleteWorkshopSchedules(ctx, clusterConfig, portal, name); err != nil {
+			return err
+		}
+	}
+
+	// Deleting the training portal also deletes the workshop environments
+	// and workshop sessions for it.
+
+	err = dynamicClient.Resource(trainingPortalResource).Delete(ctx, portal, metav1.DeleteOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to delete training portal %q", portal)
+	}
+
+	if err == nil {
+		fmt.Printf("Training portal %q deleted.\n", portal)
+	}
+
+	err = client.CoreV1().Secrets("educates-secrets").Delete(ctx, portalAccessCodesSecretName(portal), metav1.DeleteOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to delete access codes for training portal %q", portal)
+	}
+
+	// Delete the workshop definitions unless they are still used by another
+	// training portal.
+
+	if o.KeepWorkshops {
+		return nil
+	}
+
+	trainingPortals, err := dynamicClient.Resource(trainingPortalResource).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to list training portals in cluster")
+	}
+
+	inUse := map[string]bool{}
+
+	for _, item := range trainingPortals.Items {
+		if item.GetName() == portal {
+			continue
+		}
+
+		workshops, _, _ := unstructured.NestedSlice(item.Object, "spec", "workshops")
+
+		for _, workshop := range workshops {
+			if entry, ok := workshop.(map[string]interface{}); ok {
+				if name, ok := entry["name"].(string); ok {
+					inUse[name] = true
+				}
+			}
+		}
+	}
+
+	for _, name := range names {
+		if inUse[name] {
+			fmt.Printf("Workshop %q retained as used by another training portal.\n", name)
+
+			continue
+		}
+
+		err = dynamicClient.Resource(workshopResource).Delete(ctx, name, metav1.DeleteOptions{})
+
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "unable to delete workshop %q", name)
+		}
+
+		if err == nil {
+			fmt.Printf("Workshop %q deleted.\n", name)
+		}
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewEventTeardownCmd() *cobra.Command {
+	var o EventTeardownOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "teardown FILE",
+		Short: "Remove the portal and workshops for an event",
+		Long: `Remove the training portal and workshops for an event.
+
+Deletes the training portal named by the event manifest, along with all its
+workshop sessions, any scheduled changes and the access codes for the event.
+The workshop definitions the training portal hosted are also deleted unless
+another training portal still uses them, or --keep-workshops is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args[0]) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().BoolVar(
+		&o.KeepWorkshops,
+		"keep-workshops",
+		false,
+		"do not delete the workshop definitions hosted by the training portal",
+	)
+
+	return withAuditLogging(c)
+}
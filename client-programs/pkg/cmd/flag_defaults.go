@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const flagDefaultEnvPrefix = "EDUCATES_DEFAULT_"
+
+/*
+Return the key used for defaults of flags for a command. This is the path of
+the command without the name of the CLI itself, with the words separated by
+dots, so "educates cluster workshop deploy" becomes "cluster.workshop.deploy".
+*/
+func flagDefaultsCommandKey(cmd *cobra.Command) string {
+	return strings.Join(strings.Fields(cmd.CommandPath())[1:], ".")
+}
+
+/*
+Return the name of the environment variable which can be used to give the
+default for a flag, such as "EDUCATES_DEFAULT_CLUSTER_WORKSHOP_DEPLOY_CAPACITY"
+for the --capacity flag of "educates cluster workshop deploy".
+*/
+func flagDefaultEnvName(key string) string {
+	return flagDefaultEnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+/*
+Find the command and flag a key for a flag default refers to, where the key
+is the command key followed by a dot and the name of the flag.
+*/
+func lookupFlagDefault(root *cobra.Command, key string) (*cobra.Command, *pflag.Flag, error) {
+	index := strings.LastIndex(key, ".")
+
+	if index <= 0 || index == len(key)-1 {
+		return nil, nil, failures.NewValidationError(errors.Errorf("invalid key %q", key), "key must be the command path and flag name separated by dots, such as cluster.workshop.deploy.capacity")
+	}
+
+	path, name := key[:index], strings.TrimPrefix(key[index+1:], "--")
+
+	cmd, rest, err := root.Find(strings.Split(path, "."))
+
+	if err != nil || len(rest) != 0 || cmd == root || flagDefaultsCommandKey(cmd) != path {
+		return nil, nil, failures.NewNotFoundError(errors.Errorf("no command %q", strings.ReplaceAll(path, ".", " ")), "key must start with the full path of the command, such as cluster.workshop.deploy")
+	}
+
+	flag := cmd.Flags().Lookup(name)
+
+	if flag == nil {
+		flag = cmd.InheritedFlags().Lookup(name)
+	}
+
+	if flag == nil || name == "help" {
+		return nil, nil, failures.NewNotFoundError(errors.Errorf("command %q has no flag --%s", strings.ReplaceAll(path, ".", " "), name), fmt.Sprintf("run `educates %s --help` to see the flags for the command", strings.ReplaceAll(path, ".", " ")))
+	}
+
+	return cmd, flag, nil
+}
+
+// Annotation Cobra adds to flags which can't be used together, listing the
+// names of the flags in each group separated by spaces.
+
+const mutuallyExclusiveFlagsAnnotation = "cobra_annotation_mutually_exclusive"
+
+/*
+Return whether a flag can't be used together with another flag which has
+already been set, either on the command line or from a default.
+*/
+func exclusiveFlagSet(cmd *cobra.Command, flag *pflag.Flag) bool {
+	for _, group := range flag.Annotations[mutuallyExclusiveFlagsAnnotation] {
+		for _, name := range strings.Fields(group) {
+			if other := cmd.Flags().Lookup(name); other != nil && other != flag && other.Changed {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+/*
+Set flags not given on the command line to the defaults for the command.
+Defaults from environment variables take precedence over those from the
+client config file. Flags set from defaults are treated the same as if they
+had been given on the command line, so satisfy flags which are required. A
+default isn't applied where a flag it can't be used together with is set, so
+a default doesn't stop the other flag being given on the command line.
+*/
+func applyFlagDefaults(cmd *cobra.Command) error {
+	clientConfig, err := config.LoadClientConfig()
+
+	if err != nil {
+		return err
+	}
+
+	commandKey := flagDefaultsCommandKey(cmd)
+
+	if commandKey == "" {
+		return nil
+	}
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || flag.Name == "help" {
+			return
+		}
+
+		source := flagDefaultEnvName(commandKey + "." + flag.Name)
+
+		value, found := os.LookupEnv(source)
+
+		if !found {
+			source = config.ClientConfigFile()
+
+			value, found = clientConfig.Defaults[commandKey][flag.Name]
+		}
+
+		if !found || exclusiveFlagSet(cmd, flag) {
+			return
+		}
+
+		if setErr := cmd.Flags().Set(flag.Name, value); setErr != nil {
+			err = failures.NewValidationError(errors.Wrapf(setErr, "invalid default for --%s from %s", flag.Name, source), "change the default with `educates config defaults set`")
+		}
+	})
+
+	return err
+}
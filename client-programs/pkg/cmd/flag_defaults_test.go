@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestFlagDefaultsSkipExclusiveFlags(t *testing.T) {
+	isolateClientConfig(t)
+
+	t.Setenv("EDUCATES_DEFAULT_CLUSTER_WORKSHOP_DEPLOY_ORPHANED", "10m")
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+
+	tests := []struct {
+		name     string
+		args     []string
+		orphaned string
+	}{
+		{"default applied", []string{"--name=lab-sample"}, "10m"},
+		{"default skipped", []string{"--name=lab-sample", "--idle-exempt"}, "5m"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProjectInfo("0.0.1")
+
+			c := p.nestedCommand(p.NewClusterWorkshopDeployCmd(), "cluster", "workshop")
+
+			var orphaned string
+
+			c.RunE = func(cmd *cobra.Command, _ []string) error {
+				orphaned, _ = cmd.Flags().GetString("orphaned")
+				return nil
+			}
+
+			args := append([]string{"--kubeconfig=" + kubeconfig}, test.args...)
+
+			if err := runNestedCommand(context.Background(), c, args...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if orphaned != test.orphaned {
+				t.Fatalf("expected --orphaned of %q, got %q", test.orphaned, orphaned)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/hooks"
+)
+
+/*
+Run any hooks registered in the client config for an event. As with
+notifications, a failure of a hook is reported as a warning only, so that it
+doesn't cause the operation which has already been completed to fail. The
+URL of the training portal is filled in for the event if not already known.
+*/
+func runHooks(ctx context.Context, client dynamic.Interface, event hooks.Event) {
+	clientConfig, err := config.LoadClientConfig()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", err)
+
+		return
+	}
+
+	if len(clientConfig.Hooks) == 0 {
+		return
+	}
+
+	if event.PortalURL == "" {
+		event.PortalURL = trainingPortalURL(ctx, client, event.Portal)
+	}
+
+	for _, hook := range clientConfig.Hooks {
+		if err := hook.Run(event); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s.\n", err)
+		}
+	}
+}
+
+/*
+Return the URL of a training portal for passing to hooks, or an empty string
+if it isn't known yet, such as when the training portal has only just been
+created.
+*/
+func trainingPortalURL(ctx context.Context, client dynamic.Interface, portal string) string {
+	trainingPortal, err := client.Resource(trainingPortalResource).Get(ctx, portal, metav1.GetOptions{})
+
+	if err != nil {
+		return ""
+	}
+
+	url, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
+
+	return url
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/local"
+)
+
+type LocalCheckOptions struct {
+	Path            string
+	WorkshopFile    string
+	WorkshopVersion string
+	Locale          string
+	DataValuesFlags yttcmd.DataValuesFlags
+}
+
+func (o *LocalCheckOptions) Run() error {
+	workshop, err := loadLocalWorkshop(o.Path, o.WorkshopFile, o.WorkshopVersion, o.Locale, o.DataValuesFlags)
+
+	if err != nil {
+		return err
+	}
+
+	warnings := workshop.Warnings
+
+	for _, page := range workshop.Pages {
+		_, pageWarnings, err := local.RenderPage(page.File, workshop.Classic, workshop.Variables)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to render page %s", page.Name)
+		}
+
+		for _, warning := range pageWarnings {
+			warnings = append(warnings, fmt.Sprintf("page %s: %s", page.Name, warning))
+		}
+	}
+
+	fmt.Printf("Workshop %s has %d pages of instructions.\n", workshop.Name, len(workshop.Pages))
+
+	if len(warnings) == 0 {
+		fmt.Println("All features used by the workshop are available when running locally.")
+
+		return nil
+	}
+
+	fmt.Println("Features used by the workshop which are not available when running locally:")
+
+	for _, warning := range warnings {
+		fmt.Printf("  - %s\n", warning)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewLocalCheckCmd() *cobra.Command {
+	var o LocalCheckOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "check [PATH]",
+		Short: "Check workshop can be run on the local machine",
+		Long: `Check a workshop can be run on the local machine.
+
+Renders all pages of the workshop instructions from a local workshop
+directory, the same as "educates local run" would, and reports any features
+used by the workshop which are not available when running locally, such as
+session applications other than the terminal, clickable actions for them,
+and data variables which only have a value in a workshop session.`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				o.Path = args[0]
+			}
+
+			return o.Run()
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopVersion,
+		"workshop-version",
+		"latest",
+		"version of the workshop definition",
+	)
+	c.Flags().StringVar(
+		&o.Locale,
+		"locale",
+		"",
+		"locale to check the workshop instructions for",
+	)
+
+	addLocalDataValuesFlags(c, &o.DataValuesFlags)
+
+	return c
+}
@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/local"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+type LocalRunOptions struct {
+	Path            string
+	Address         string
+	Port            int
+	WorkshopFile    string
+	WorkshopVersion string
+	Locale          string
+	DataValuesFlags yttcmd.DataValuesFlags
+	Browser         WebBrowserOptions
+}
+
+/*
+Load a workshop from a local workshop directory for running locally. Only a
+local directory can be used as the workshop instructions are read directly
+from it, rather than from a published workshop image.
+*/
+func loadLocalWorkshop(path string, workshopFile string, workshopVersion string, locale string, dataValuesFlags yttcmd.DataValuesFlags) (*local.Workshop, error) {
+	// If path not provided assume the current working directory.
+
+	if path == "" {
+		path = "."
+	}
+
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, failures.NewValidationError(errors.Errorf("workshop directory %q does not exist", path), "workshops can only be run locally from a local workshop directory")
+	}
+
+	workshop, err := training.LoadWorkshopDefinition(&training.DefinitionOptions{
+		Path:            path,
+		Portal:          "educates-cli",
+		WorkshopFile:    workshopFile,
+		WorkshopVersion: workshopVersion,
+		DataValuesFlags: dataValuesFlags,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return local.LoadWorkshop(path, workshop, locale)
+}
+
+func (o *LocalRunOptions) Run(ctx context.Context) error {
+	if o.Port < 0 || o.Port > 65535 {
+		return failures.NewValidationError(errors.Errorf("invalid port %d", o.Port), "port must be between 0 and 65535")
+	}
+
+	// Clickable actions run commands in the local shell, so the web server
+	// is only ever run on the loopback interface.
+
+	if ip := net.ParseIP(o.Address); o.Address != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return failures.NewValidationError(errors.Errorf("invalid address %q", o.Address), "address must be for the loopback interface")
+	}
+
+	workshop, err := loadLocalWorkshop(o.Path, o.WorkshopFile, o.WorkshopVersion, o.Locale, o.DataValuesFlags)
+
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range workshop.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", warning)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(o.Address, strconv.Itoa(o.Port)))
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to listen on %s", net.JoinHostPort(o.Address, strconv.Itoa(o.Port)))
+	}
+
+	defer listener.Close()
+
+	// Where a local terminal can't be started, such as when output is being
+	// redirected, the workshop instructions are still served, with commands
+	// being copied to the clipboard when clicked on instead.
+
+	terminal, err := local.StartTerminal(workshop.HomeDirectory(), workshop.Environ)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to start local terminal, commands will be copied to the clipboard instead: %s.\n", err)
+	}
+
+	server, err := local.NewServer(workshop, terminal, listener.Addr().String())
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create workshop server")
+	}
+
+	httpServer := http.Server{
+		Handler: server,
+	}
+
+	serverErrors := make(chan error, 1)
+
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			serverErrors <- err
+		}
+	}()
+
+	defer httpServer.Shutdown(context.TODO())
+
+	if terminal != nil {
+		fmt.Printf("Serving workshop %s, exit the shell to stop.\n", workshop.Name)
+	} else {
+		fmt.Printf("Serving workshop %s, press Ctrl-C to stop.\n", workshop.Name)
+	}
+
+	if err = o.Browser.Open(server.URL()); err != nil {
+		return err
+	}
+
+	if terminal != nil {
+		// The shell is stopped if the command is interrupted by a signal
+		// or the web server fails. Ctrl-C typed in the terminal is passed
+		// through to the shell rather than stopping the command.
+
+		stopped := make(chan struct{})
+
+		go func() {
+			select {
+			case <-ctx.Done():
+			case serverErr := <-serverErrors:
+				serverErrors <- serverErr
+			}
+
+			close(stopped)
+		}()
+
+		if err = terminal.Attach(stopped); err != nil {
+			return err
+		}
+
+		select {
+		case err = <-serverErrors:
+		default:
+		}
+	} else {
+		select {
+		case <-ctx.Done():
+		case err = <-serverErrors:
+		}
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to serve workshop instructions")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewLocalRunCmd() *cobra.Command {
+	var o LocalRunOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "run [PATH]",
+		Short: "Run workshop on the local machine",
+		Long: `Run a workshop on the local machine.
+
+Runs a workshop from a local workshop directory entirely from the CLI,
+without needing Docker or a Kubernetes cluster. The workshop instructions are
+rendered by the CLI and opened in a web browser, and a shell is started in
+the current terminal, in the exercises directory of the workshop if it has
+one. Clicking on commands in the workshop instructions runs them in the
+shell. Exiting the shell stops the workshop.
+
+Only features of a workshop which make sense on the local machine are
+supported. Any session applications other than the terminal, clickable
+actions for them, and AsciiDoc instructions are reported as warnings when the
+workshop is started. Use "educates local check" to see these warnings for all
+pages of a workshop before running it.
+
+If standard input and output are not a terminal, no shell is started, and
+commands are copied to the clipboard when clicked on instead.
+
+For workshops with instructions in more than one language, use --locale to
+select the locale to show the instructions for. Pages for a locale are held
+in a directory alongside the default content directory named with the
+locale as suffix, such as "workshop/content.fr", with pages missing for the
+locale taken from the default content directory.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				o.Path = args[0]
+			}
+
+			return o.Run(cmd.Context())
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.Address,
+		"address",
+		"127.0.0.1",
+		"local address to listen on for connections",
+	)
+	c.Flags().IntVar(
+		&o.Port,
+		"port",
+		0,
+		"local port to listen on for connections, or 0 to select a free port",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopVersion,
+		"workshop-version",
+		"latest",
+		"version of the workshop definition",
+	)
+	c.Flags().StringVar(
+		&o.Locale,
+		"locale",
+		"",
+		"locale to show the workshop instructions for",
+	)
+
+	addLocalDataValuesFlags(c, &o.DataValuesFlags)
+
+	o.Browser.AddFlags(c)
+
+	return c
+}
+
+func addLocalDataValuesFlags(c *cobra.Command, dataValuesFlags *yttcmd.DataValuesFlags) {
+	c.Flags().StringArrayVar(
+		&dataValuesFlags.EnvFromStrings,
+		"data-values-env",
+		nil,
+		"Extract data values (as strings) from prefixed env vars (format: PREFIX for PREFIX_all__key1=str) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&dataValuesFlags.EnvFromYAML,
+		"data-values-env-yaml",
+		nil,
+		"Extract data values (parsed as YAML) from prefixed env vars (format: PREFIX for PREFIX_all__key1=true) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&dataValuesFlags.KVsFromStrings,
+		"data-value",
+		nil,
+		"Set specific data value to given value, as string (format: all.key1.subkey=123) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&dataValuesFlags.KVsFromYAML,
+		"data-value-yaml",
+		nil,
+		"Set specific data value to given value, parsed as YAML (format: all.key1.subkey=true) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&dataValuesFlags.KVsFromFiles,
+		"data-value-file",
+		nil,
+		"Set specific data value to contents of a file (format: [@lib1:]all.key1.subkey={file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&dataValuesFlags.FromFiles,
+		"data-values-file",
+		nil,
+		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
+	)
+}
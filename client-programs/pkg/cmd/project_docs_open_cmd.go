@@ -1,10 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-	"os/exec"
-	"runtime"
-
 	"github.com/spf13/cobra"
 )
 
@@ -17,22 +13,9 @@ func (p *ProjectInfo) NewProjectDocsOpenCmd() *cobra.Command {
 		Use:   "open",
 		Short: "Open browser on project documentation",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			var err error
-
-			const url = "https://docs.educates.dev/"
-
-			switch runtime.GOOS {
-			case "linux":
-				err = exec.Command("xdg-open", url).Start()
-			case "windows":
-				err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
-			case "darwin":
-				err = exec.Command("open", url).Start()
-			default:
-				err = fmt.Errorf("unsupported platform")
-			}
+			var browser WebBrowserOptions
 
-			return err
+			return browser.Open("https://docs.educates.dev/")
 		},
 	}
 
@@ -1,22 +1,321 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/update"
 )
 
+// Images for platform components built by Educates, whose tags are expected
+// to match the installed version of the platform.
+
+const educatesImagePrefix = "ghcr.io/vmware-tanzu-labs/educates-"
+
+type ProjectVersionOptions struct {
+	Kubeconfig   string
+	CheckCluster bool
+	Output       string
+}
+
+/*
+An image used by a running component of the training platform. The digest is
+that of the image the container is actually running, as reported by the
+container runtime.
+*/
+type componentImage struct {
+	Component string `json:"component"`
+	Namespace string `json:"namespace"`
+	Image     string `json:"image"`
+	Tag       string `json:"tag"`
+	Digest    string `json:"digest,omitempty"`
+	Mismatch  bool   `json:"mismatch"`
+}
+
+type versionInventory struct {
+	CLIVersion      string           `json:"cliVersion"`
+	PlatformVersion string           `json:"platformVersion,omitempty"`
+	LatestVersion   string           `json:"latestVersion,omitempty"`
+	UpdateAvailable bool             `json:"updateAvailable"`
+	Components      []componentImage `json:"components,omitempty"`
+	Problems        []string         `json:"problems,omitempty"`
+}
+
+func (o *ProjectVersionOptions) Run(ctx context.Context, cliVersion string) error {
+	if o.Output != "text" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are text and json")
+	}
+
+	inventory := versionInventory{CLIVersion: cliVersion}
+
+	if !o.CheckCluster {
+		if o.Output == "json" {
+			return printVersionInventory(&inventory)
+		}
+
+		fmt.Println(cliVersion)
+
+		return nil
+	}
+
+	clusterConfig := cluster.NewClusterConfig(o.Kubeconfig)
+
+	platformVersion, err := operators.InstalledVersion(ctx, clusterConfig)
+
+	if err != nil {
+		return err
+	}
+
+	if platformVersion == "" {
+		return failures.NewNotFoundError(errors.New("training platform is not installed in the cluster"), failures.PlatformHint)
+	}
+
+	inventory.PlatformVersion = platformVersion
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	if inventory.Components, err = platformComponentImages(ctx, client, platformVersion); err != nil {
+		return err
+	}
+
+	if update.CompareVersions(cliVersion, platformVersion) != 0 {
+		inventory.Problems = append(inventory.Problems, fmt.Sprintf("Educates CLI version %s differs from installed platform version %s", cliVersion, platformVersion))
+	}
+
+	for _, component := range inventory.Components {
+		if component.Mismatch {
+			inventory.Problems = append(inventory.Problems, fmt.Sprintf("component %s is running image version %s rather than platform version %s", component.Component, component.Tag, platformVersion))
+		}
+	}
+
+	// Failing to look up the latest release, such as when there is no
+	// internet access, shouldn't stop the inventory being reported.
+
+	if release, err := update.LatestRelease(5 * time.Second); err == nil {
+		inventory.LatestVersion = release.TagName
+
+		inventory.UpdateAvailable = update.CompareVersions(cliVersion, release.TagName) < 0 || update.CompareVersions(platformVersion, release.TagName) < 0
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: unable to determine latest release of Educates: %s.\n", err)
+	}
+
+	if o.Output == "json" {
+		return printVersionInventory(&inventory)
+	}
+
+	fmt.Printf("CLI version:      %s\n", inventory.CLIVersion)
+	fmt.Printf("Platform version: %s\n", inventory.PlatformVersion)
+
+	if inventory.LatestVersion != "" {
+		if inventory.UpdateAvailable {
+			fmt.Printf("Latest release:   %s (update available)\n", inventory.LatestVersion)
+		} else {
+			fmt.Printf("Latest release:   %s\n", inventory.LatestVersion)
+		}
+	}
+
+	fmt.Println()
+
+	if len(inventory.Components) == 0 {
+		fmt.Println("No running platform components found.")
+	} else {
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "COMPONENT", "NAMESPACE", "IMAGE", "DIGEST", "STATUS")
+
+		for _, component := range inventory.Components {
+			status := "-"
+
+			if strings.HasPrefix(component.Image, educatesImagePrefix) {
+				status = "ok"
+
+				if component.Mismatch {
+					status = "mismatch"
+				}
+			}
+
+			digest := component.Digest
+
+			if digest == "" {
+				digest = "-"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s:%s\t%s\t%s\n", component.Component, component.Namespace, component.Image, component.Tag, digest, status)
+		}
+
+		w.Flush()
+	}
+
+	if len(inventory.Problems) != 0 {
+		fmt.Println()
+
+		for _, problem := range inventory.Problems {
+			fmt.Fprintf(os.Stderr, "Warning: %s.\n", problem)
+		}
+	}
+
+	return nil
+}
+
+func printVersionInventory(inventory *versionInventory) error {
+	data, err := json.MarshalIndent(inventory, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode version inventory")
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
+
+/*
+Find the images used by the running pods of the training platform operators
+and of each training portal.
+*/
+func platformComponentImages(ctx context.Context, client kubernetes.Interface, platformVersion string) ([]componentImage, error) {
+	var images []componentImage
+
+	seen := map[string]bool{}
+
+	add := func(component string, namespace string, image string, imageID string) {
+		repository, tag := image, "latest"
+
+		if index := strings.LastIndex(image, "@"); index != -1 {
+			repository, tag = image[:index], image[index+1:]
+		} else if index := strings.LastIndex(image, ":"); index != -1 && !strings.Contains(image[index:], "/") {
+			repository, tag = image[:index], image[index+1:]
+		}
+
+		var digest string
+
+		if index := strings.LastIndex(imageID, "@"); index != -1 {
+			digest = imageID[index+1:]
+		}
+
+		key := strings.Join([]string{component, namespace, image, digest}, "|")
+
+		if seen[key] {
+			return
+		}
+
+		seen[key] = true
+
+		images = append(images, componentImage{
+			Component: component,
+			Namespace: namespace,
+			Image:     repository,
+			Tag:       tag,
+			Digest:    digest,
+			Mismatch:  strings.HasPrefix(repository, educatesImagePrefix) && update.CompareVersions(tag, platformVersion) != 0,
+		})
+	}
+
+	operatorPods, err := client.CoreV1().Pods("educates").List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list pods for training platform operators")
+	}
+
+	portalPods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{LabelSelector: "deployment=training-portal,training.educates.dev/component=portal"})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list pods for training portals")
+	}
+
+	for _, pod := range append(operatorPods.Items, portalPods.Items...) {
+		component := pod.Labels["deployment"]
+
+		if component == "" {
+			component = pod.Name
+		}
+
+		if portal := pod.Labels["training.educates.dev/portal.name"]; component == "training-portal" && portal != "" {
+			component = fmt.Sprintf("%s/%s", component, portal)
+		}
+
+		imageIDs := map[string]string{}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			imageIDs[status.Name] = status.ImageID
+		}
+
+		for _, container := range pod.Spec.Containers {
+			add(component, pod.Namespace, container.Image, imageIDs[container.Name])
+		}
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		if images[i].Component != images[j].Component {
+			return images[i].Component < images[j].Component
+		}
+
+		return images[i].Image < images[j].Image
+	})
+
+	return images, nil
+}
+
 /*
 Create Cobra command object for displaying Educates version.
 */
 func (p *ProjectInfo) NewProjectVersionCmd() *cobra.Command {
+	var o ProjectVersionOptions
+
 	var c = &cobra.Command{
 		Args:  cobra.NoArgs,
 		Use:   "version",
 		Short: "Display the version of Educates being used",
-		RunE: func(cmd *cobra.Command, _ []string) error {
-			cmd.Println(p.Version)
-			return nil
-		},
+		Long: `Display the version of Educates being used.
+
+With --check-cluster, the version of the training platform installed in the
+cluster is also shown, together with the image and digest for each running
+component of the platform, including each training portal. Components which
+are running images for a different version to the installed platform are
+flagged as a mismatch, as is a CLI version which differs from the platform.
+The latest release of Educates is looked up to report whether an update is
+available.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context(), p.Version) },
 	}
 
+	c.Flags().BoolVar(
+		&o.CheckCluster,
+		"check-cluster",
+		false,
+		"also list the installed platform version and component images in the cluster",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"text",
+		"output format, one of text or json",
+	)
+
 	return c
 }
@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+)
+
+/*
+Namespace and name of the resources for the redirector deployed to the
+cluster for short links, when no external shortener is configured.
+*/
+const (
+	shortLinksNamespace = "educates-links"
+	shortLinksName      = "educates-links"
+	shortLinksImage     = "docker.io/nginxinc/nginx-unprivileged:stable-alpine"
+)
+
+/*
+Return a short link for a URL, using the shortener endpoint from the client
+config if one is set, otherwise the redirector in the cluster.
+*/
+func shortenURL(ctx context.Context, clusterConfig *cluster.ClusterConfig, longURL string) (string, error) {
+	clientConfig, err := config.LoadClientConfig()
+
+	if err != nil {
+		return "", err
+	}
+
+	if clientConfig.ShortLinks.Endpoint != "" {
+		return shortenURLWithEndpoint(clientConfig.ShortLinks.Endpoint, longURL)
+	}
+
+	return shortenURLWithRedirector(ctx, clusterConfig, longURL)
+}
+
+func shortenURLWithEndpoint(endpoint string, longURL string) (string, error) {
+	requestURL := strings.ReplaceAll(endpoint, "{url}", url.QueryEscape(longURL))
+
+	if requestURL == endpoint {
+		separator := "?"
+
+		if strings.Contains(endpoint, "?") {
+			separator = "&"
+		}
+
+		requestURL = endpoint + separator + url.Values{"url": []string{longURL}}.Encode()
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	res, err := client.Get(requestURL)
+
+	if err != nil {
+		return "", failures.NewConnectionError(errors.Wrap(err, "unable to contact short link service"), "check the shortLinks endpoint in the client config")
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 4096))
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to read response from short link service")
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return "", errors.Errorf("short link service returned status %d", res.StatusCode)
+	}
+
+	shortURL := strings.TrimSpace(string(body))
+
+	if parsed, err := url.Parse(shortURL); err != nil || !parsed.IsAbs() {
+		return "", errors.Errorf("short link service returned invalid URL %q", shortURL)
+	}
+
+	return shortURL, nil
+}
+
+/*
+Generate the code for the short link for a URL. The code is derived from the
+URL so that asking for a short link for the same URL again gives the same
+short link.
+*/
+func shortLinkCode(longURL string) string {
+	sum := sha256.Sum256([]byte(longURL))
+
+	return strings.ToLower(base32.StdEncoding.EncodeToString(sum[:5]))
+}
+
+/*
+Add a redirect for a URL to the redirector for short links in the cluster,
+deploying the redirector if necessary. Redirects are held in a config map
+and served by nginx, exposed using an ingress with host name "links" under
+the ingress domain of the platform. The deployment is restarted whenever the
+set of redirects changes so nginx picks up the new configuration.
+*/
+func shortenURLWithRedirector(ctx context.Context, clusterConfig *cluster.ClusterConfig, longURL string) (string, error) {
+	platformConfig, err := operators.InstalledConfig(ctx, clusterConfig)
+
+	if err != nil {
+		return "", err
+	}
+
+	if platformConfig == nil || platformConfig.ClusterIngress.Domain == "" {
+		return "", failures.NewNotFoundError(errors.New("unable to determine ingress domain for short links"), failures.PlatformHint)
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	redirects := map[string]string{}
+
+	configMap, err := client.CoreV1().ConfigMaps(shortLinksNamespace).Get(ctx, shortLinksName, metav1.GetOptions{})
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return "", errors.Wrapf(err, "unable to query short links")
+	}
+
+	if err == nil {
+		for key, value := range configMap.Data {
+			if key != "default.conf" {
+				redirects[key] = value
+			}
+		}
+	}
+
+	ingress := platformConfig.ClusterIngress
+
+	host := fmt.Sprintf("links.%s", ingress.Domain)
+
+	protocol := ingress.Protocol
+
+	tlsSecret := ingress.TLSCertificateRef.Name
+
+	if tlsSecret == "" && ingress.TLSCertificate.Certificate != "" {
+		tlsSecret = fmt.Sprintf("%s-tls", ingress.Domain)
+	}
+
+	if protocol == "" {
+		protocol = "http"
+
+		if tlsSecret != "" {
+			protocol = "https"
+		}
+	}
+
+	code := shortLinkCode(longURL)
+
+	shortURL := fmt.Sprintf("%s://%s/%s", protocol, host, code)
+
+	if redirects[code] == longURL {
+		return shortURL, nil
+	}
+
+	redirects[code] = longURL
+
+	objects := shortLinksResources(redirects, host, ingress.Class, tlsSecret)
+
+	if tlsSecret != "" {
+		objects = append(objects, shortLinksSecretCopier(tlsSecret, ingress.TLSCertificateRef.Namespace))
+	}
+
+	if err = clusterConfig.ApplyResources(ctx, objects, "educates-cli"); err != nil {
+		return "", err
+	}
+
+	if err = clusterConfig.WaitForResourcesReady(ctx, objects, 2*time.Minute); err != nil {
+		return "", err
+	}
+
+	return shortURL, nil
+}
+
+/*
+Generate the nginx configuration for the redirects. Characters in the URLs
+which nginx would treat specially in a quoted string are percent encoded.
+*/
+func shortLinksNginxConfig(redirects map[string]string) string {
+	var codes []string
+
+	for code := range redirects {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	escaper := strings.NewReplacer(`"`, "%22", `\`, "%5C", "$", "%24")
+
+	var builder strings.Builder
+
+	builder.WriteString("server {\n    listen 8080;\n")
+
+	for _, code := range codes {
+		fmt.Fprintf(&builder, "    location = /%s { return 302 \"%s\"; }\n", code, escaper.Replace(redirects[code]))
+	}
+
+	builder.WriteString("    location / { return 404; }\n}\n")
+
+	return builder.String()
+}
+
+func shortLinksResources(redirects map[string]string, host string, ingressClass string, tlsSecret string) []*unstructured.Unstructured {
+	data := map[string]interface{}{}
+
+	for code, value := range redirects {
+		data[code] = value
+	}
+
+	nginxConfig := shortLinksNginxConfig(redirects)
+
+	data["default.conf"] = nginxConfig
+
+	labels := map[string]interface{}{
+		"app": shortLinksName,
+	}
+
+	ingressSpec := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"host": host,
+				"http": map[string]interface{}{
+					"paths": []interface{}{
+						map[string]interface{}{
+							"path":     "/",
+							"pathType": "Prefix",
+							"backend": map[string]interface{}{
+								"service": map[string]interface{}{
+									"name": shortLinksName,
+									"port": map[string]interface{}{
+										"number": int64(8080),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if ingressClass != "" {
+		ingressSpec["ingressClassName"] = ingressClass
+	}
+
+	if tlsSecret != "" {
+		ingressSpec["tls"] = []interface{}{
+			map[string]interface{}{
+				"hosts":      []interface{}{host},
+				"secretName": tlsSecret,
+			},
+		}
+	}
+
+	return []*unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name": shortLinksNamespace,
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      shortLinksName,
+				"namespace": shortLinksNamespace,
+			},
+			"data": data,
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      shortLinksName,
+				"namespace": shortLinksNamespace,
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(1),
+				"selector": map[string]interface{}{
+					"matchLabels": labels,
+				},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": labels,
+						"annotations": map[string]interface{}{
+							"educates.dev/links-checksum": fmt.Sprintf("%x", sha256.Sum256([]byte(nginxConfig))),
+						},
+					},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":  "nginx",
+								"image": shortLinksImage,
+								"ports": []interface{}{
+									map[string]interface{}{
+										"containerPort": int64(8080),
+									},
+								},
+								"volumeMounts": []interface{}{
+									map[string]interface{}{
+										"name":      "config",
+										"mountPath": "/etc/nginx/conf.d/default.conf",
+										"subPath":   "default.conf",
+									},
+								},
+							},
+						},
+						"volumes": []interface{}{
+							map[string]interface{}{
+								"name": "config",
+								"configMap": map[string]interface{}{
+									"name": shortLinksName,
+								},
+							},
+						},
+					},
+				},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":      shortLinksName,
+				"namespace": shortLinksNamespace,
+			},
+			"spec": map[string]interface{}{
+				"selector": labels,
+				"ports": []interface{}{
+					map[string]interface{}{
+						"port":       int64(8080),
+						"targetPort": int64(8080),
+					},
+				},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "Ingress",
+			"metadata": map[string]interface{}{
+				"name":      shortLinksName,
+				"namespace": shortLinksNamespace,
+			},
+			"spec": ingressSpec,
+		}},
+	}
+}
+
+/*
+Copy the wildcard certificate for the ingress domain into the namespace for
+the redirector so the ingress for it can use secure connections.
+*/
+func shortLinksSecretCopier(secretName string, secretNamespace string) *unstructured.Unstructured {
+	if secretNamespace == "" {
+		secretNamespace = "educates"
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "secrets.educates.dev/v1beta1",
+		"kind":       "SecretCopier",
+		"metadata": map[string]interface{}{
+			"name": shortLinksName,
+		},
+		"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"sourceSecret": map[string]interface{}{
+						"name":      secretName,
+						"namespace": secretNamespace,
+					},
+					"targetNamespaces": map[string]interface{}{
+						"nameSelector": map[string]interface{}{
+							"matchNames": []interface{}{shortLinksNamespace},
+						},
+					},
+				},
+			},
+		},
+	}}
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/telemetry"
+)
+
+type StatsOptions struct {
+	Since   time.Duration
+	Output  string
+	Enable  bool
+	Disable bool
+	Export  bool
+	Clear   bool
+}
+
+/*
+Summary of the runs of a single command.
+*/
+type commandStats struct {
+	Command  string         `json:"command"`
+	Runs     int            `json:"runs"`
+	Failures map[string]int `json:"failures,omitempty"`
+	Median   float64        `json:"median"`
+	Slowest  float64        `json:"slowest"`
+
+	durations []float64
+	failed    int
+}
+
+func (o *StatsOptions) Run() error {
+	if o.Output != "table" && o.Output != "json" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are table and json")
+	}
+
+	if o.Enable && o.Disable {
+		return failures.NewValidationError(errors.New("only one of --enable and --disable can be given"), "")
+	}
+
+	clientConfig, err := config.LoadClientConfig()
+
+	if err != nil {
+		return err
+	}
+
+	if o.Enable || o.Disable {
+		clientConfig.Telemetry.Enabled = o.Enable
+
+		if err = config.SaveClientConfig(clientConfig); err != nil {
+			return err
+		}
+
+		if o.Enable {
+			fmt.Printf("Recording of command usage enabled, records are kept in %s.\n", telemetry.LogFile())
+		} else {
+			fmt.Println("Recording of command usage disabled.")
+		}
+
+		return nil
+	}
+
+	if o.Clear {
+		if err = telemetry.Clear(); err != nil {
+			return err
+		}
+
+		fmt.Println("Recorded command usage cleared.")
+
+		return nil
+	}
+
+	entries, err := telemetry.Read()
+
+	if err != nil {
+		return err
+	}
+
+	var selected []telemetry.Entry
+
+	for _, entry := range entries {
+		if o.Since != 0 && time.Since(entry.Time) > o.Since {
+			continue
+		}
+
+		selected = append(selected, entry)
+	}
+
+	if o.Export {
+		if clientConfig.Telemetry.Endpoint == "" {
+			return failures.NewValidationError(errors.New("no telemetry endpoint configured"), "set telemetry.endpoint in the client config")
+		}
+
+		if err = telemetry.Send(clientConfig.Telemetry.Endpoint, selected); err != nil {
+			return err
+		}
+
+		fmt.Printf("Exported %d records of command usage.\n", len(selected))
+
+		return nil
+	}
+
+	stats := summariseTelemetry(selected)
+
+	if o.Output == "json" {
+		if stats == nil {
+			stats = []*commandStats{}
+		}
+
+		data, err := json.MarshalIndent(stats, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode command usage")
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	if len(stats) == 0 {
+		if !clientConfig.Telemetry.Enabled {
+			fmt.Println("No command usage recorded, enable recording with `educates stats --enable`.")
+		} else {
+			fmt.Println("No command usage recorded.")
+		}
+
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintln(w, "COMMAND\tRUNS\tFAILED\tMEDIAN\tSLOWEST\tFAILURES")
+
+	for _, item := range stats {
+		var categories []string
+
+		for category, count := range item.Failures {
+			categories = append(categories, fmt.Sprintf("%s=%d", category, count))
+		}
+
+		sort.Strings(categories)
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", item.Command, item.Runs, item.failed, formatSeconds(item.Median), formatSeconds(item.Slowest), strings.Join(categories, ","))
+	}
+
+	return nil
+}
+
+/*
+Group entries by command, ordered with the commands which fail most often
+first, then by those run most often, so that where users hit problems stands
+out.
+*/
+func summariseTelemetry(entries []telemetry.Entry) []*commandStats {
+	byCommand := map[string]*commandStats{}
+
+	for _, entry := range entries {
+		item, exists := byCommand[entry.Command]
+
+		if !exists {
+			item = &commandStats{Command: entry.Command, Failures: map[string]int{}}
+			byCommand[entry.Command] = item
+		}
+
+		item.Runs++
+		item.durations = append(item.durations, entry.Duration)
+
+		if entry.Result != "success" {
+			item.failed++
+			item.Failures[entry.Category]++
+		}
+	}
+
+	var stats []*commandStats
+
+	for _, item := range byCommand {
+		sort.Float64s(item.durations)
+
+		item.Median = item.durations[len(item.durations)/2]
+		item.Slowest = item.durations[len(item.durations)-1]
+
+		stats = append(stats, item)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].failed != stats[j].failed {
+			return stats[i].failed > stats[j].failed
+		}
+
+		if stats[i].Runs != stats[j].Runs {
+			return stats[i].Runs > stats[j].Runs
+		}
+
+		return stats[i].Command < stats[j].Command
+	})
+
+	return stats
+}
+
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(10 * time.Millisecond).String()
+}
+
+func (p *ProjectInfo) NewStatsCmd() *cobra.Command {
+	var o StatsOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "stats",
+		Short: "Summarise recorded usage of the CLI",
+		Long: `Summarise recorded usage of the CLI.
+
+When enabled with --enable, each command run records how long it took and,
+where it failed, the category of the failure, such as a validation error or
+a connection failure. No arguments, flag values or error messages are
+recorded. Records are kept locally, and a summary of how often each command
+was run, how often it failed and how long it took is output.
+
+Where a telemetry endpoint is set in the client config, --export sends the
+records as JSON to that endpoint, along with a random ID for this
+installation of the CLI, and with times rounded down to the hour, so that
+maintainers and platform teams can see where users hit problems.`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+
+	c.Flags().DurationVar(
+		&o.Since,
+		"since",
+		0,
+		"only include commands run within this duration, e.g. 24h",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"table",
+		"output format, either table or json",
+	)
+	c.Flags().BoolVar(
+		&o.Enable,
+		"enable",
+		false,
+		"start recording usage of the CLI",
+	)
+	c.Flags().BoolVar(
+		&o.Disable,
+		"disable",
+		false,
+		"stop recording usage of the CLI",
+	)
+	c.Flags().BoolVar(
+		&o.Export,
+		"export",
+		false,
+		"send recorded usage to the telemetry endpoint in the client config",
+	)
+	c.Flags().BoolVar(
+		&o.Clear,
+		"clear",
+		false,
+		"discard all recorded usage",
+	)
+
+	return c
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/telemetry"
+)
+
+/*
+Wrap the run function of all commands so that how long the command took and
+the category of any failure is recorded in the telemetry log. Nothing is
+recorded unless telemetry has been enabled in the client config.
+*/
+func enableTelemetry(c *cobra.Command, version string) {
+	for _, child := range c.Commands() {
+		enableTelemetry(child, version)
+	}
+
+	if c.RunE == nil {
+		return
+	}
+
+	switch c.Name() {
+	case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return
+	}
+
+	runE := c.RunE
+
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		started := time.Now()
+
+		err := runE(cmd, args)
+
+		recordTelemetryEntry(cmd, version, started, err)
+
+		return err
+	}
+}
+
+func recordTelemetryEntry(cmd *cobra.Command, version string, started time.Time, err error) {
+	clientConfig, configErr := config.LoadClientConfig()
+
+	if configErr != nil || !clientConfig.Telemetry.Enabled {
+		return
+	}
+
+	entry := telemetry.Entry{
+		Time:     started.UTC(),
+		Command:  cmd.CommandPath(),
+		Duration: time.Since(started).Seconds(),
+		Result:   "success",
+		Version:  version,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+	}
+
+	if err != nil {
+		entry.Result = "failure"
+		entry.Category = failures.Classify(err).Kind.String()
+	}
+
+	if recordErr := telemetry.Record(entry); recordErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", recordErr)
+	}
+}
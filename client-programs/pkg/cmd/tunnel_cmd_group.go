@@ -8,7 +8,7 @@ import (
 func (p *ProjectInfo) NewTunnelCmdGroup() *cobra.Command {
 	var c = &cobra.Command{
 		Use:   "tunnel",
-		Short: "Tools for connecting to workshop containers",
+		Short: "Tools for tunnelling connections to workshops and clusters",
 	}
 
 	// Use a command group as it allows us to dictate the order in which they
@@ -20,6 +20,7 @@ func (p *ProjectInfo) NewTunnelCmdGroup() *cobra.Command {
 			Message: "Available Commands:",
 			Commands: []*cobra.Command{
 				p.NewTunnelConnectCmd(),
+				p.NewTunnelExposeCmd(),
 			},
 		},
 	}
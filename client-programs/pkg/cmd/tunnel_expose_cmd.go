@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/tunnel"
+)
+
+type TunnelExposeOptions struct {
+	Server           string
+	IdentityFile     string
+	KnownHostsFile   string
+	SkipHostKeyCheck bool
+	LocalAddress     string
+	Forwards         []string
+	KeepAlive        time.Duration
+}
+
+func (o *TunnelExposeOptions) Run(ctx context.Context) error {
+	// Work out the user and address of the remote server, which can
+	// include a port if SSH is not running on the standard port.
+
+	user := os.Getenv("USER")
+	server := o.Server
+
+	if index := strings.LastIndex(server, "@"); index != -1 {
+		user, server = server[:index], server[index+1:]
+	}
+
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "22")
+	}
+
+	if user == "" {
+		return failures.NewValidationError(errors.New("no user given for remote server"), "supply the server as `user@host`")
+	}
+
+	// The ingress for the local cluster is exposed on the address it was
+	// configured to listen on, defaulting to the IP address of the host.
+
+	fullConfig, err := config.NewInstallationConfigFromFile("")
+
+	if err != nil {
+		return err
+	}
+
+	localAddress := o.LocalAddress
+
+	if localAddress == "" {
+		localAddress = fullConfig.LocalKindCluster.ListenAddress
+	}
+
+	if localAddress == "" {
+		if localAddress, err = config.HostIP(); err != nil {
+			localAddress = "127.0.0.1"
+		}
+	}
+
+	var forwards []tunnel.Forward
+
+	for _, value := range o.Forwards {
+		remotePort, localPort, found := strings.Cut(value, ":")
+
+		if !found {
+			localPort = remotePort
+		}
+
+		forwards = append(forwards, tunnel.Forward{
+			RemoteAddress: net.JoinHostPort("0.0.0.0", remotePort),
+			LocalAddress:  net.JoinHostPort(localAddress, localPort),
+		})
+	}
+
+	// Attendees access workshops using host names under the ingress domain
+	// of the cluster, so these must resolve to the remote server. Domains
+	// which map to an IP address, such as nip.io, will not work unless they
+	// embed the address of the remote server.
+
+	domain := fullConfig.ClusterIngress.Domain
+
+	if strings.HasSuffix(domain, ".nip.io") && strings.HasPrefix(domain, localAddress+".") {
+		fmt.Fprintf(os.Stderr, "Warning: ingress domain %s resolves to the local machine, configure a domain which resolves to the remote server with `educates admin config edit`.\n", domain)
+	}
+
+	if fullConfig.ClusterIngress.TLSCertificate.Certificate == "" && fullConfig.ClusterIngress.TLSCertificateRef.Name == "" {
+		fmt.Fprintf(os.Stderr, "Warning: no wildcard TLS certificate configured for ingress domain %s, workshops will only be accessible over HTTP.\n", domain)
+	}
+
+	tunnelConfig := &tunnel.ReverseTunnelConfig{
+		Server:           server,
+		User:             user,
+		IdentityFile:     o.IdentityFile,
+		KnownHostsFile:   o.KnownHostsFile,
+		SkipHostKeyCheck: o.SkipHostKeyCheck,
+		Forwards:         forwards,
+		KeepAlive:        o.KeepAlive,
+		RetryDelay:       5 * time.Second,
+	}
+
+	fmt.Printf("Workshops will be accessible under the domain %s via %s.\n", domain, server)
+	fmt.Printf("Press Ctrl-C to stop the tunnel.\n")
+
+	return tunnel.Run(ctx, tunnelConfig)
+}
+
+func (p *ProjectInfo) NewTunnelExposeCmd() *cobra.Command {
+	var o TunnelExposeOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "expose",
+		Short: "Expose local cluster via remote server",
+		Long: `Expose local cluster via remote server.
+
+Opens an SSH reverse tunnel to a remote server, forwarding the HTTP and HTTPS
+ports on the remote server to the ingress controller of the local Educates
+cluster. This allows a trainer to run workshops from their own machine for
+remote attendees.
+
+For this to work, the ingress domain of the local cluster must be one for
+which a wildcard DNS entry resolves to the remote server, and the SSH server
+must be allowing remote port forwarding on all interfaces. For workshops to
+be accessible over a secure connection, a wildcard TLS certificate for the
+ingress domain must also be configured for the cluster. TLS connections are
+passed through the tunnel as is, and terminated by the ingress controller.
+
+Forwarding the standard HTTP and HTTPS ports requires logging in to the
+remote server as a user permitted to listen on privileged ports.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context()) },
+	}
+
+	c.Flags().StringVar(
+		&o.Server,
+		"server",
+		"",
+		"remote server to expose cluster via, given as [user@]host[:port]",
+	)
+	c.Flags().StringVarP(
+		&o.IdentityFile,
+		"identity",
+		"i",
+		"",
+		"private key file used to authenticate with the remote server",
+	)
+	c.Flags().StringVar(
+		&o.KnownHostsFile,
+		"known-hosts",
+		"",
+		"known hosts file used to verify the remote server, defaults to $HOME/.ssh/known_hosts",
+	)
+	c.Flags().BoolVar(
+		&o.SkipHostKeyCheck,
+		"insecure-skip-host-key-check",
+		false,
+		"don't verify the host key of the remote server",
+	)
+	c.Flags().StringVar(
+		&o.LocalAddress,
+		"local-address",
+		"",
+		"address the ingress controller of the local cluster listens on",
+	)
+	c.Flags().StringSliceVar(
+		&o.Forwards,
+		"forward",
+		[]string{"80:80", "443:443"},
+		"ports to forward, given as remote-port:local-port",
+	)
+	c.Flags().DurationVar(
+		&o.KeepAlive,
+		"keep-alive",
+		30*time.Second,
+		"interval between keep alive requests sent to the remote server",
+	)
+
+	c.MarkFlagRequired("server")
+
+	return c
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/update"
+)
+
+type UpdateOptions struct {
+	Kubeconfig     string
+	Version        string
+	Latest         bool
+	Check          bool
+	AllowDowngrade bool
+}
+
+func (o *UpdateOptions) Run(ctx context.Context, currentVersion string) error {
+	var err error
+
+	// Work out which version to update to. An explicit version takes
+	// precedence, otherwise match the version of Educates installed in the
+	// cluster so the CLI doesn't get ahead of the platform. If the platform
+	// isn't installed, or asked to ignore it, use the latest release.
+
+	targetVersion := o.Version
+
+	if targetVersion == "" && !o.Latest {
+		installedVersion, err := operators.InstalledVersion(ctx, cluster.NewClusterConfig(o.Kubeconfig))
+
+		if err != nil {
+			fmt.Printf("Unable to determine installed platform version, using latest release.\n")
+		} else if installedVersion != "" {
+			fmt.Printf("Matching installed platform version %s.\n", installedVersion)
+
+			targetVersion = installedVersion
+		}
+	}
+
+	var release *update.Release
+
+	if targetVersion != "" {
+		release, err = update.ReleaseForVersion(targetVersion, 30*time.Second)
+	} else {
+		release, err = update.LatestRelease(30 * time.Second)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to determine release to update to")
+	}
+
+	if release.TagName == currentVersion {
+		fmt.Printf("Educates CLI is already at version %s.\n", currentVersion)
+
+		return nil
+	}
+
+	// Matching the installed platform version can mean going back to an
+	// older version of the CLI, which is only done when asked for.
+
+	downgrade := update.CompareVersions(release.TagName, currentVersion) < 0
+
+	if o.Check {
+		if downgrade {
+			fmt.Printf("Educates CLI version %s is available, which is older than the current version %s.\n", release.TagName, currentVersion)
+		} else {
+			fmt.Printf("Educates CLI version %s is available (current version %s).\n", release.TagName, currentVersion)
+		}
+
+		return nil
+	}
+
+	if downgrade {
+		if o.Version == "" && !o.AllowDowngrade {
+			return failures.NewValidationError(errors.Errorf("installed platform version %s is older than Educates CLI version %s", release.TagName, currentVersion), "use --allow-downgrade to downgrade the CLI to match the platform, or --latest to update to the latest release")
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: Educates CLI version %s is older than the current version %s.\n", release.TagName, currentVersion)
+	}
+
+	err = confirmAction(fmt.Sprintf("replace Educates CLI version %s with version %s", currentVersion, release.TagName))
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Downloading Educates CLI version %s.\n", release.TagName)
+
+	binary, err := update.DownloadBinary(release)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to download Educates CLI")
+	}
+
+	executable, err := update.ReplaceExecutable(binary)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to update Educates CLI")
+	}
+
+	fmt.Printf("Updated %s to version %s.\n", executable, release.TagName)
+
+	return nil
+}
+
+/*
+Create Cobra command object for updating the Educates CLI binary.
+*/
+func (p *ProjectInfo) NewUpdateCmd() *cobra.Command {
+	var o UpdateOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "update",
+		Short: "Update the Educates CLI to a newer version",
+		Long: `Update the Educates CLI to a newer version.
+
+By default the CLI is updated to match the version of Educates installed in
+the target cluster, or to the latest release if the platform isn't installed.
+If the installed platform version is older than the CLI, the CLI is only
+downgraded to match it when --allow-downgrade is given.
+
+The downloaded binary is verified against the checksums published with the
+release before replacing the existing binary. This only detects a corrupted
+download. The checksums are downloaded from the same release as the binary
+and are not signed, so this does not protect against the release assets
+themselves having been replaced, and verifying signatures of releases is not
+supported.
+
+Other commands will display a notice when a newer version is available. Set
+the ` + update.DisableNoticeEnvVar + ` environment variable to suppress it.`,
+		RunE: func(cmd *cobra.Command, _ []string) error { return o.Run(cmd.Context(), p.Version) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVar(
+		&o.Version,
+		"version",
+		"",
+		"specific version of the Educates CLI to update to",
+	)
+	c.Flags().BoolVar(
+		&o.Latest,
+		"latest",
+		false,
+		"update to the latest release rather than the installed platform version",
+	)
+	c.Flags().BoolVar(
+		&o.Check,
+		"check",
+		false,
+		"only check whether a different version is available",
+	)
+	c.Flags().BoolVar(
+		&o.AllowDowngrade,
+		"allow-downgrade",
+		false,
+		"allow updating to an older version to match the installed platform version",
+	)
+
+	return withAuditLogging(c)
+}
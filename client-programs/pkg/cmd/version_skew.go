@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cache"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/operators"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/update"
+)
+
+var customResourceDefinitionResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// Annotation added to commands which interact with Educates resources in the
+// cluster and which should therefore check for version skew before running.
+
+const versionSkewCheckAnnotation = "educates.dev/version-skew-check"
+
+/*
+Global option controlling whether version skew results in a failure instead
+of a warning. This is bound to a persistent flag on the root command.
+*/
+var strictVersionCheck bool
+
+/*
+Mark a command as needing to check for version skew before it is run. The
+check applies to the command and all its sub commands.
+*/
+func withVersionSkewCheck(c *cobra.Command) *cobra.Command {
+	if c.Annotations == nil {
+		c.Annotations = map[string]string{}
+	}
+
+	c.Annotations[versionSkewCheckAnnotation] = "true"
+
+	return c
+}
+
+func needsVersionSkewCheck(c *cobra.Command) bool {
+	for ; c != nil; c = c.Parent() {
+		if c.Annotations[versionSkewCheckAnnotation] == "true" {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Compare the version of the CLI with the version of Educates installed in the
+cluster, and check that the custom resource versions the CLI creates are
+served by the cluster. Any problems are reported as a warning, or returned as
+an error if strict version checking was requested. If the installed version
+cannot be determined the check is skipped, leaving it to the command itself
+to report any problems accessing the cluster.
+*/
+func checkVersionSkew(ctx context.Context, cmd *cobra.Command, cliVersion string) error {
+	kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
+
+	clusterConfig := cluster.NewClusterConfig(kubeconfig)
+
+	installedVersion, err := cachedInstalledVersion(ctx, clusterConfig)
+
+	if err != nil || installedVersion == "" {
+		return nil
+	}
+
+	var problems []string
+
+	switch update.CompareMinorVersions(cliVersion, installedVersion) {
+	case 1:
+		problems = append(problems, fmt.Sprintf("Educates CLI version %s is newer than installed platform version %s, resources may be created with fields the operator doesn't understand", cliVersion, installedVersion))
+	case -1:
+		problems = append(problems, fmt.Sprintf("Educates CLI version %s is older than installed platform version %s, run `educates update` to match the platform", cliVersion, installedVersion))
+	}
+
+	served, err := clusterConfig.ServedResources("training.educates.dev/v1beta1")
+
+	if err == nil {
+		for _, name := range []string{"trainingportals", "workshops"} {
+			if !containsString(served, name) {
+				problems = append(problems, fmt.Sprintf("custom resource %s.training.educates.dev in the cluster does not serve version v1beta1 used by the Educates CLI", name))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if strictVersionCheck {
+		return failures.NewValidationError(errors.New(problems[0]), "rerun without --strict-version-check to proceed anyway")
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", problem)
+	}
+
+	return nil
+}
+
+/*
+Return the version of Educates installed in the cluster, using the cached
+value if available, so the check doesn't slow down every command.
+*/
+func cachedInstalledVersion(ctx context.Context, clusterConfig *cluster.ClusterConfig) (string, error) {
+	key, err := clusterConfig.CacheKey()
+
+	if err != nil {
+		return "", err
+	}
+
+	var installedVersion string
+
+	if cache.GetJSON("versions", key, &installedVersion) {
+		return installedVersion, nil
+	}
+
+	installedVersion, err = operators.InstalledVersion(ctx, clusterConfig)
+
+	if err != nil {
+		return "", err
+	}
+
+	cache.PutJSON("versions", key, installedVersion)
+
+	return installedVersion, nil
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/qrcode"
+)
+
+/*
+Open the supplied URL in a web browser. If the BROWSER environment variable
+is set, it is used in the same way as by other command line tools, as a list
+of commands separated by colons, which are tried in turn. Any "%s" in a
+command is replaced with the URL, otherwise the URL is added as the last
+argument. When BROWSER is not set, the default web browser for the local
+system is used.
+*/
+func openWebBrowser(url string) error {
+	if value := os.Getenv("BROWSER"); value != "" {
+		var err error
+
+		for _, command := range strings.Split(value, string(os.PathListSeparator)) {
+			args := strings.Fields(command)
+
+			if len(args) == 0 {
+				continue
+			}
+
+			if strings.Contains(command, "%s") {
+				for i := range args {
+					args[i] = strings.ReplaceAll(args[i], "%s", url)
+				}
+			} else {
+				args = append(args, url)
+			}
+
+			if err = exec.Command(args[0], args[1:]...).Start(); err == nil {
+				return nil
+			}
+		}
+
+		if err == nil {
+			err = errors.New("no command given in BROWSER environment variable")
+		}
+
+		return err
+	}
+
+	return platform.Open(url)
+}
+
+/*
+Options for commands which open a URL in a web browser. Instead of opening a
+web browser, the URL can be printed, optionally along with a QR code so it
+can be scanned by another device. The URL is also printed when it isn't
+possible to open a web browser, such as when logged in to a remote machine.
+Where a shortener is supplied, a short link can be printed in place of the
+URL, with the web browser still being opened on the full URL.
+*/
+type WebBrowserOptions struct {
+	PrintURL  bool
+	QRCode    bool
+	Shorten   bool
+	Shortener func(string) (string, error)
+}
+
+func (o *WebBrowserOptions) AddFlags(c *cobra.Command) {
+	c.Flags().BoolVar(
+		&o.PrintURL,
+		"print-url",
+		false,
+		"print the URL instead of opening a web browser",
+	)
+	c.Flags().BoolVar(
+		&o.QRCode,
+		"qr-code",
+		false,
+		"print the URL and a QR code for it instead of opening a web browser",
+	)
+}
+
+/*
+Add the option for printing a short link for the URL. Only commands which
+supply a shortener should add the option.
+*/
+func (o *WebBrowserOptions) AddShortenFlag(c *cobra.Command) {
+	c.Flags().BoolVar(
+		&o.Shorten,
+		"shorten",
+		false,
+		"print a short link in place of the URL when printing it",
+	)
+}
+
+func (o *WebBrowserOptions) Open(url string) error {
+	if !o.PrintURL && !o.QRCode {
+		err := openWebBrowser(url)
+
+		if err == nil {
+			return nil
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: unable to open web browser: %s.\n", err)
+	}
+
+	if o.Shorten && o.Shortener != nil {
+		shortURL, err := o.Shortener(url)
+
+		if err != nil {
+			return errors.Wrap(err, "unable to generate short link")
+		}
+
+		url = shortURL
+	}
+
+	fmt.Println(url)
+
+	if o.QRCode {
+		code, err := qrcode.Encode(url)
+
+		if err != nil {
+			return errors.Wrap(err, "unable to generate QR code")
+		}
+
+		return code.WriteTerminal(os.Stdout)
+	}
+
+	return nil
+}
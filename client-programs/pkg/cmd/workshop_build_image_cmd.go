@@ -0,0 +1,547 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/kubectl/pkg/scheme"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/registry"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+type WorkshopBuildImageOptions struct {
+	Image           string
+	Repository      string
+	WorkshopFile    string
+	WorkshopVersion string
+	ImageVersion    string
+	Dockerfile      string
+	Builder         string
+	Descriptor      string
+	SkipPush        bool
+	SkipUpdate      bool
+}
+
+func (o *WorkshopBuildImageOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	var directory string
+
+	if len(args) != 0 {
+		directory = filepath.Clean(args[0])
+	} else {
+		directory = "."
+	}
+
+	if directory, err = filepath.Abs(directory); err != nil {
+		return errors.Wrap(err, "couldn't convert workshop directory to absolute path")
+	}
+
+	fileInfo, err := os.Stat(directory)
+
+	if err != nil || !fileInfo.IsDir() {
+		return failures.NewValidationError(errors.New("workshop directory does not exist or path is not a directory"), "")
+	}
+
+	workshopFilePath := o.WorkshopFile
+
+	if !filepath.IsAbs(workshopFilePath) {
+		workshopFilePath = filepath.Join(directory, workshopFilePath)
+	}
+
+	workshopFileInfo, err := os.Stat(workshopFilePath)
+
+	if err != nil {
+		return errors.Wrapf(err, "cannot open workshop definition %q", workshopFilePath)
+	}
+
+	workshopFileData, err := os.ReadFile(workshopFilePath)
+
+	if err != nil {
+		return errors.Wrapf(err, "cannot open workshop definition %q", workshopFilePath)
+	}
+
+	// Process the workshop YAML data for ytt templating so can read the
+	// settings for building the image.
+
+	processedData, err := training.ProcessWorkshopDefinition(workshopFileData, yttcmd.DataValuesFlags{})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to process workshop definition as template")
+	}
+
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder()
+
+	workshop := &unstructured.Unstructured{}
+
+	if err = runtime.DecodeInto(decoder, processedData, workshop); err != nil {
+		return errors.Wrap(err, "couldn't parse workshop definition")
+	}
+
+	if workshop.GetAPIVersion() != "training.educates.dev/v1beta1" || workshop.GetKind() != "Workshop" {
+		return errors.New("invalid type for workshop definition")
+	}
+
+	// Work out the name of the image, tagging it with the version of the
+	// workshop so each version of the workshop has its own image.
+
+	workshopVersion := o.WorkshopVersion
+
+	if version, found, _ := unstructured.NestedString(workshop.Object, "spec", "version"); found && version != "" {
+		workshopVersion = version
+	}
+
+	image := o.Image
+
+	if image == "" {
+		image, _, _ = unstructured.NestedString(workshop.Object, "spec", "publish", "build", "image")
+	}
+
+	if image == "" {
+		image = fmt.Sprintf("$(image_repository)/%s-image:$(workshop_version)", workshop.GetName())
+	}
+
+	image = strings.ReplaceAll(image, "$(image_repository)", o.Repository)
+	image = strings.ReplaceAll(image, "$(workshop_version)", workshopVersion)
+
+	// The base image defaults to the workshop base image given in the
+	// workshop definition, so long as it is one of those provided with
+	// Educates, as it will be replaced by the image being built.
+
+	baseImage, _, _ := unstructured.NestedString(workshop.Object, "spec", "publish", "build", "baseImage")
+
+	if baseImage == "" {
+		baseImage, _, _ = unstructured.NestedString(workshop.Object, "spec", "workshop", "image")
+
+		if resolveWorkshopImageAlias(baseImage, o.ImageVersion) == baseImage {
+			baseImage = ""
+		}
+	}
+
+	if baseImage == "" {
+		baseImage = "base-environment:*"
+	}
+
+	baseImage = resolveWorkshopImageAlias(baseImage, o.ImageVersion)
+	baseImage = strings.ReplaceAll(baseImage, "$(image_repository)", o.Repository)
+
+	if o.Repository == "localhost:5001" {
+		if err = registry.DeployRegistry(ctx); err != nil {
+			return errors.Wrap(err, "failed to deploy registry")
+		}
+	}
+
+	// Build the image using Cloud Native Buildpacks if a builder has been
+	// given, else build it from a Dockerfile.
+
+	builder := o.Builder
+
+	if builder == "" {
+		builder, _, _ = unstructured.NestedString(workshop.Object, "spec", "publish", "build", "builder")
+	}
+
+	fmt.Printf("Building workshop image %s\n", image)
+
+	if builder != "" {
+		err = o.buildWithBuildpacks(directory, image, baseImage, builder)
+	} else {
+		err = o.buildWithDockerfile(workshop, directory, image, baseImage)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if o.SkipUpdate {
+		return nil
+	}
+
+	// Update the workshop definition to use the image which was built.
+
+	updatedData, err := setWorkshopImageReference(workshopFileData, image)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update workshop definition %q", workshopFilePath)
+	}
+
+	if !bytes.Equal(updatedData, workshopFileData) {
+		if err = os.WriteFile(workshopFilePath, updatedData, workshopFileInfo.Mode()); err != nil {
+			return errors.Wrapf(err, "unable to write workshop definition %q", workshopFilePath)
+		}
+
+		fmt.Printf("Updated workshop image in %s\n", workshopFilePath)
+	}
+
+	return nil
+}
+
+/*
+Build the workshop image from the Dockerfile in the workshop directory, or if
+there isn't one, from a generated Dockerfile installing the packages listed
+in the workshop definition on top of the base image, then push it.
+*/
+func (o *WorkshopBuildImageOptions) buildWithDockerfile(workshop *unstructured.Unstructured, directory string, image string, baseImage string) error {
+	dockerfile := o.Dockerfile
+
+	if dockerfile == "" {
+		dockerfile, _, _ = unstructured.NestedString(workshop.Object, "spec", "publish", "build", "dockerfile")
+	}
+
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(directory, dockerfile)
+	}
+
+	if _, err := os.Stat(dockerfile); err != nil {
+		packages, _, _ := unstructured.NestedStringSlice(workshop.Object, "spec", "publish", "build", "packages")
+
+		if len(packages) == 0 {
+			return failures.NewValidationError(errors.Errorf("no Dockerfile found at %q and no packages listed in workshop definition", dockerfile), "add a Dockerfile to the workshop directory, list packages to install in spec.publish.build.packages, or use --builder to build using buildpacks")
+		}
+
+		tempDir, err := os.MkdirTemp("", "educates-build-image")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to create temporary working directory")
+		}
+
+		defer os.RemoveAll(tempDir)
+
+		dockerfile = filepath.Join(tempDir, "Dockerfile")
+
+		if err = os.WriteFile(dockerfile, generateWorkshopDockerfile(baseImage, packages), 0644); err != nil {
+			return errors.Wrap(err, "unable to write generated Dockerfile")
+		}
+	}
+
+	if err := runDockerCommand(os.Stdout, os.Stderr, "build", "--file", dockerfile, "--build-arg", fmt.Sprintf("BASE_IMAGE=%s", baseImage), "--tag", image, directory); err != nil {
+		return errors.Wrap(err, "unable to build workshop image")
+	}
+
+	if !o.SkipPush {
+		fmt.Printf("Pushing workshop image %s\n", image)
+
+		if err := runDockerCommand(os.Stdout, os.Stderr, "push", image); err != nil {
+			return errors.Wrap(err, "unable to push workshop image")
+		}
+	}
+
+	return nil
+}
+
+/*
+Build the workshop image using Cloud Native Buildpacks by running the "pack"
+command. Packages and runtimes to include are given by the project descriptor
+in the workshop directory, with the base image used as the run image so that
+the image still has everything needed for a workshop session. When the image
+is to be pushed "pack" publishes it directly to the image repository.
+*/
+func (o *WorkshopBuildImageOptions) buildWithBuildpacks(directory string, image string, baseImage string, builder string) error {
+	args := []string{"build", image, "--builder", builder, "--run-image", baseImage, "--path", directory}
+
+	descriptor := o.Descriptor
+
+	if descriptor != "" {
+		if !filepath.IsAbs(descriptor) {
+			descriptor = filepath.Join(directory, descriptor)
+		}
+
+		args = append(args, "--descriptor", descriptor)
+	}
+
+	if !o.SkipPush {
+		args = append(args, "--publish")
+	}
+
+	packCommand := exec.Command("pack", args...)
+
+	packCommand.Stdout = os.Stdout
+	packCommand.Stderr = os.Stderr
+
+	if err := packCommand.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return failures.NewValidationError(errors.New("the pack command is required to build using buildpacks"), "install pack from https://buildpacks.io/docs/tools/pack/")
+		}
+
+		return errors.Wrap(err, "unable to build workshop image using buildpacks")
+	}
+
+	return nil
+}
+
+/*
+Generate a Dockerfile installing system packages on top of a workshop base
+image. Packages are installed as root, with the image then reverting to the
+user workshop sessions run as.
+*/
+func generateWorkshopDockerfile(baseImage string, packages []string) []byte {
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "ARG BASE_IMAGE=%s\n\n", baseImage)
+	fmt.Fprintf(&buffer, "FROM ${BASE_IMAGE}\n\n")
+	fmt.Fprintf(&buffer, "USER root\n\n")
+	fmt.Fprintf(&buffer, "RUN dnf install -y --setopt=tsflags=nodocs %s && \\\n", strings.Join(packages, " "))
+	fmt.Fprintf(&buffer, "    dnf clean -y --enablerepo='*' all\n\n")
+	fmt.Fprintf(&buffer, "USER 1001\n")
+
+	return buffer.Bytes()
+}
+
+/*
+Run a docker command, passing through its output.
+*/
+func runDockerCommand(stdout io.Writer, stderr io.Writer, args ...string) error {
+	dockerCommand := exec.Command("docker", args...)
+
+	dockerCommand.Stdout = stdout
+	dockerCommand.Stderr = stderr
+
+	return dockerCommand.Run()
+}
+
+/*
+Set the workshop image in the workshop definition. The definition is edited
+as a YAML document tree so comments, including any ytt annotations, are kept.
+*/
+func setWorkshopImageReference(workshopFileData []byte, image string) ([]byte, error) {
+	return updateWorkshopDefinition(workshopFileData, func(root *yaml.Node) {
+		yamlSetString(yamlMappingEntry(yamlMappingEntry(root, "spec"), "workshop"), "image", image)
+	})
+}
+
+/*
+Apply a change to each workshop definition in a YAML file, passing the root
+mapping of the workshop definition to the update function, and returning
+the file with the change made.
+*/
+func updateWorkshopDefinition(workshopFileData []byte, update func(root *yaml.Node)) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(workshopFileData))
+
+	var documents []*yaml.Node
+
+	for {
+		var document yaml.Node
+
+		err := decoder.Decode(&document)
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't parse workshop definition")
+		}
+
+		documents = append(documents, &document)
+	}
+
+	updated := false
+
+	for _, document := range documents {
+		if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+
+		root := document.Content[0]
+
+		if kind := yamlMappingValue(root, "kind"); kind == nil || kind.Value != "Workshop" {
+			continue
+		}
+
+		update(root)
+
+		updated = true
+	}
+
+	if !updated {
+		return nil, errors.New("no workshop definition found")
+	}
+
+	var buffer bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buffer)
+
+	encoder.SetIndent(2)
+
+	for _, document := range documents {
+		if err := encoder.Encode(document); err != nil {
+			return nil, errors.Wrap(err, "couldn't convert workshop definition back to YAML")
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrap(err, "couldn't convert workshop definition back to YAML")
+	}
+
+	return buffer.Bytes(), nil
+}
+
+/*
+Set a key in a YAML mapping to a string value, adding the key if there is no
+such key.
+*/
+func yamlSetString(node *yaml.Node, key string, value string) {
+	entry := yamlMappingValue(node, key)
+
+	if entry == nil {
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+		)
+	} else {
+		entry.Kind = yaml.ScalarNode
+		entry.Tag = "!!str"
+		entry.Value = value
+		entry.Content = nil
+	}
+}
+
+/*
+Return the value for a key in a YAML mapping, or nil if there is no such key.
+*/
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+/*
+Return the mapping for a key in a YAML mapping, adding it if there is no such
+key or the value isn't a mapping.
+*/
+func yamlMappingEntry(node *yaml.Node, key string) *yaml.Node {
+	value := yamlMappingValue(node, key)
+
+	if value == nil {
+		value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	} else if value.Kind != yaml.MappingNode {
+		value.Kind = yaml.MappingNode
+		value.Tag = "!!map"
+		value.Value = ""
+		value.Content = nil
+	}
+
+	return value
+}
+
+func (p *ProjectInfo) NewWorkshopBuildImageCmd() *cobra.Command {
+	var o WorkshopBuildImageOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "build-image [PATH]",
+		Short: "Build custom workshop image for workshop",
+		Long: `Build a custom workshop image for a workshop.
+
+Builds a custom workshop base image using the Dockerfile in the workshop
+directory, tags it with the version of the workshop and pushes it to the
+image repository. The workshop definition is then updated so the workshop
+uses the image. The Dockerfile is passed the workshop base image to build on
+as the BASE_IMAGE build argument.
+
+If there is no Dockerfile, one is generated which installs the system
+packages listed in spec.publish.build.packages of the workshop definition on
+top of the workshop base image. The workshop base image defaults to that the
+workshop already uses if it is one provided with Educates, but can be set
+using spec.publish.build.baseImage. The name of the image can be set using
+spec.publish.build.image, which, like the name of the image for the workshop
+files, can include $(image_repository) and $(workshop_version).
+
+As an alternative to a Dockerfile, the image can be built using Cloud Native
+Buildpacks by giving the builder to use with --builder, or setting it using
+spec.publish.build.builder. The packages and runtimes to include are then
+declared in the buildpacks project descriptor for the workshop, project.toml
+in the workshop directory by default, and the workshop base image is used as
+the run image. Building using buildpacks requires the "pack" command.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
+	}
+
+	c.Flags().StringVar(
+		&o.Image,
+		"image",
+		"",
+		"name of the workshop image to build",
+	)
+	c.Flags().StringVar(
+		&o.Repository,
+		"image-repository",
+		"localhost:5001",
+		"the address of the image repository",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopVersion,
+		"workshop-version",
+		"latest",
+		"version of the workshop the image is for",
+	)
+	c.Flags().StringVar(
+		&o.ImageVersion,
+		"image-version",
+		p.Version,
+		"version of workshop base image to build on",
+	)
+	c.Flags().StringVar(
+		&o.Dockerfile,
+		"dockerfile",
+		"",
+		"location of the Dockerfile to build the image from",
+	)
+	c.Flags().StringVar(
+		&o.Builder,
+		"builder",
+		"",
+		"buildpacks builder image to build the image with instead of a Dockerfile",
+	)
+	c.Flags().StringVar(
+		&o.Descriptor,
+		"descriptor",
+		"",
+		"location of the buildpacks project descriptor, defaults to project.toml",
+	)
+	c.Flags().BoolVar(
+		&o.SkipPush,
+		"skip-push",
+		false,
+		"build the image but don't push it to the image repository",
+	)
+	c.Flags().BoolVar(
+		&o.SkipUpdate,
+		"skip-update",
+		false,
+		"don't update the workshop image in the workshop definition",
+	)
+
+	c.MarkFlagsMutuallyExclusive("builder", "dockerfile")
+
+	return c
+}
@@ -24,8 +24,16 @@ func (p *ProjectInfo) NewWorkshopCmdGroup() *cobra.Command {
 			Message: "Available Commands:",
 			Commands: []*cobra.Command{
 				p.NewWorkshopNewCmd(),
+				p.NewWorkshopImportCmd(),
+				p.NewWorkshopConvertCmd(),
 				p.NewWorkshopPublishCmd(),
+				p.NewWorkshopPublishContentCmd(),
+				p.NewWorkshopBuildImageCmd(),
+				p.NewWorkshopDevcontainerCmd(),
+				p.NewWorkshopImagesCmd(),
+				p.NewWorkshopDiffCmd(),
 				p.NewWorkshopExportCmd(),
+				p.NewWorkshopExportBackstageCmd(),
 			},
 		},
 	}
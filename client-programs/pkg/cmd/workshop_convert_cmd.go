@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/markup"
+)
+
+type WorkshopConvertOptions struct {
+	To     string
+	DryRun bool
+}
+
+func (o *WorkshopConvertOptions) Run(directory string) error {
+	var err error
+
+	var convert func(string) (string, []string)
+
+	var fromExtension, toExtension string
+
+	switch o.To {
+	case "asciidoc":
+		convert = markup.MarkdownToAsciidoc
+		fromExtension, toExtension = ".md", ".adoc"
+	case "markdown":
+		convert = markup.AsciidocToMarkdown
+		fromExtension, toExtension = ".adoc", ".md"
+	default:
+		return failures.NewValidationError(errors.Errorf("unsupported format %q", o.To), "format must be asciidoc or markdown")
+	}
+
+	if directory, err = filepath.Abs(filepath.Clean(directory)); err != nil {
+		return errors.Wrapf(err, "could not convert path name %q to absolute path", directory)
+	}
+
+	// Only the classic renderer supports AsciiDoc, so workshops using Hugo
+	// for rendering instructions can't be converted.
+
+	contentDirectory := filepath.Join(directory, "workshop", "content")
+
+	if _, err = os.Stat(contentDirectory); err != nil {
+		return failures.NewValidationError(errors.Errorf("no workshop instructions found in %q", directory), "run the command from the workshop directory or supply its path")
+	}
+
+	if _, err = os.Stat(filepath.Join(directory, "workshop", "config.yaml")); err == nil {
+		return failures.NewValidationError(errors.New("workshop instructions rendered using Hugo cannot be converted"), "only workshops using the classic renderer support AsciiDoc")
+	}
+
+	var pages []string
+
+	err = filepath.WalkDir(contentDirectory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() && filepath.Ext(path) == fromExtension {
+			pages = append(pages, path)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to read workshop instructions in %q", contentDirectory)
+	}
+
+	if len(pages) == 0 {
+		fmt.Printf("No pages to convert to %s in %s.\n", o.To, contentDirectory)
+
+		return nil
+	}
+
+	// Check there are no pages already in the target format which would be
+	// overwritten, before converting any pages.
+
+	for _, page := range pages {
+		target := strings.TrimSuffix(page, fromExtension) + toExtension
+
+		if _, err = os.Stat(target); err == nil {
+			return failures.NewValidationError(errors.Errorf("page %q already exists", target), "")
+		}
+	}
+
+	for _, page := range pages {
+		target := strings.TrimSuffix(page, fromExtension) + toExtension
+
+		name, _ := filepath.Rel(contentDirectory, page)
+
+		data, err := os.ReadFile(page)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read page %q", page)
+		}
+
+		content, warnings := convert(string(data))
+
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s.\n", name, warning)
+		}
+
+		if o.DryRun {
+			fmt.Printf("Would convert %s.\n", name)
+			continue
+		}
+
+		if err = os.WriteFile(target, []byte(content), 0664); err != nil {
+			return errors.Wrapf(err, "unable to write page %q", target)
+		}
+
+		if err = os.Remove(page); err != nil {
+			return errors.Wrapf(err, "unable to remove page %q", page)
+		}
+
+		fmt.Printf("Converted %s.\n", name)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewWorkshopConvertCmd() *cobra.Command {
+	var o WorkshopConvertOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "convert [PATH]",
+		Short: "Convert format of workshop instructions",
+		Long: `Convert format of workshop instructions.
+
+Converts the pages of workshop instructions between Markdown and AsciiDoc,
+for workshops using the classic renderer. Each page in the workshop/content
+directory is converted and replaces the original page. Code blocks for
+clickable actions are converted to the form used by the target format, so
+that they continue to work. Headings, lists, emphasis, links, images, quotes
+and code blocks are converted. Anything else, such as tables, is left as is
+and reported in a warning so it can be converted by hand.
+
+The path should be the workshop directory, defaulting to the current working
+directory.`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			directory := "."
+
+			if len(args) > 0 {
+				directory = args[0]
+			}
+
+			return o.Run(directory)
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.To,
+		"to",
+		"",
+		"format to convert workshop instructions to (asciidoc or markdown)",
+	)
+	c.Flags().BoolVar(
+		&o.DryRun,
+		"dry-run",
+		false,
+		"report the pages which would be converted without converting them",
+	)
+
+	c.MarkFlagRequired("to")
+
+	c.RegisterFlagCompletionFunc("to", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{"asciidoc", "markdown"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return c
+}
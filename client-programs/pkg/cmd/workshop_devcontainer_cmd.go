@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+/*
+Script run when the dev container is created. The workshop files are linked
+into the home directory, where they would be downloaded to in a workshop
+session, and the same script a workshop session runs on startup is then used
+to run the workshop setup scripts, so terminals in the dev container see the
+same environment as in a workshop session.
+*/
+const devcontainerSetupScript = `#!/bin/bash
+
+# Generated by "educates workshop devcontainer". Run this script again after
+# changing workshop setup scripts to rerun them.
+
+SOURCE_DIR=$(cd $(dirname $0)/.. && pwd)
+
+for name in workshop exercises README.md; do
+    if [ -e "$SOURCE_DIR/$name" ]; then
+        rm -rf "$HOME/$name"
+        ln -s "$SOURCE_DIR/$name" "$HOME/$name"
+    fi
+done
+
+mkdir -p $HOME/.local/share/workshop
+
+rebuild-workshop > $HOME/.local/share/workshop/devcontainer-setup.log 2>&1
+
+if [ -f $HOME/.local/share/workshop/setup-scripts.failed ]; then
+    echo "Workshop setup scripts failed, see $HOME/.local/share/workshop/setup-scripts.log."
+    exit 1
+fi
+
+echo "Workshop setup scripts completed."
+`
+
+type WorkshopDevcontainerOptions struct {
+	WorkshopFile    string
+	WorkshopVersion string
+	Repository      string
+	ImageVersion    string
+	Force           bool
+}
+
+/*
+Subset of the dev container configuration format used for workshops.
+*/
+type devcontainerConfig struct {
+	Name                string            `json:"name"`
+	Image               string            `json:"image"`
+	RemoteUser          string            `json:"remoteUser"`
+	UpdateRemoteUserUID bool              `json:"updateRemoteUserUID"`
+	ContainerEnv        map[string]string `json:"containerEnv"`
+	Mounts              []string          `json:"mounts,omitempty"`
+	PostCreateCommand   string            `json:"postCreateCommand"`
+}
+
+func (o *WorkshopDevcontainerOptions) Run(args []string) error {
+	var err error
+
+	var directory string
+
+	if len(args) != 0 {
+		directory = filepath.Clean(args[0])
+	} else {
+		directory = "."
+	}
+
+	if directory, err = filepath.Abs(directory); err != nil {
+		return errors.Wrap(err, "couldn't convert workshop directory to absolute path")
+	}
+
+	fileInfo, err := os.Stat(directory)
+
+	if err != nil || !fileInfo.IsDir() {
+		return failures.NewValidationError(errors.New("workshop directory does not exist or path is not a directory"), "")
+	}
+
+	configDir := filepath.Join(directory, ".devcontainer")
+	configFile := filepath.Join(configDir, "devcontainer.json")
+	scriptFile := filepath.Join(configDir, "setup-workshop.sh")
+
+	if !o.Force {
+		if _, err := os.Stat(configFile); err == nil {
+			return failures.NewValidationError(errors.Errorf("dev container configuration %s already exists", configFile), "use --force to replace it")
+		}
+	}
+
+	workshop, err := training.LoadWorkshopDefinition(&training.DefinitionOptions{
+		Path:            directory,
+		Portal:          "educates-cli",
+		WorkshopFile:    o.WorkshopFile,
+		WorkshopVersion: o.WorkshopVersion,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	image, err := generateWorkshopImageName(workshop, o.Repository, o.ImageVersion, "", o.WorkshopVersion)
+
+	if err != nil {
+		return err
+	}
+
+	containerName := workshop.GetAnnotations()["training.educates.dev/workshop"]
+
+	if title, _, _ := unstructured.NestedString(workshop.Object, "spec", "title"); title != "" {
+		containerName = title
+	}
+
+	// The environment is that used when deploying the workshop to docker,
+	// along with that set for the workshop session by the definition.
+
+	environ, err := generateWorkshopEnvironment(workshop, o.Repository, "127.0.0.1", 10081)
+
+	if err != nil {
+		return err
+	}
+
+	env, _, _ := unstructured.NestedSlice(workshop.Object, "spec", "session", "env")
+
+	for _, item := range env {
+		if entry, ok := item.(map[string]interface{}); ok {
+			if name, ok := entry["name"].(string); ok {
+				if _, ok := entry["value"]; !ok {
+					fmt.Fprintf(os.Stderr, "Warning: environment variable %s is not set as it doesn't have a value\n", name)
+					continue
+				}
+
+				environ = append(environ, fmt.Sprintf("%s=%v", name, entry["value"]))
+			}
+		}
+	}
+
+	config := devcontainerConfig{
+		Name:                containerName,
+		Image:               image,
+		RemoteUser:          "eduk8s",
+		UpdateRemoteUserUID: true,
+		ContainerEnv:        map[string]string{},
+		PostCreateCommand:   "bash .devcontainer/setup-workshop.sh",
+	}
+
+	var variables []string
+
+	for _, item := range environ {
+		parts := strings.SplitN(item, "=", 2)
+
+		if strings.Contains(parts[1], "$(") {
+			variables = append(variables, parts[0])
+		}
+
+		config.ContainerEnv[parts[0]] = parts[1]
+	}
+
+	sort.Strings(variables)
+
+	for _, variable := range variables {
+		fmt.Fprintf(os.Stderr, "Warning: environment variable %s references session data variables which will not be expanded\n", variable)
+	}
+
+	dockerEnabled, _, _ := unstructured.NestedBool(workshop.Object, "spec", "session", "applications", "docker", "enabled")
+
+	if dockerEnabled {
+		socket := "/var/run/docker.sock"
+
+		if runtime.GOOS != "linux" {
+			socket = "/var/run/docker.sock.raw"
+		}
+
+		config.Mounts = append(config.Mounts, fmt.Sprintf("source=%s,target=/var/run/docker/docker.sock,type=bind,readonly", socket))
+	}
+
+	data, err := json.MarshalIndent(&config, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate dev container configuration")
+	}
+
+	if err = os.MkdirAll(configDir, os.ModePerm); err != nil {
+		return errors.Wrapf(err, "unable to create directory %s", configDir)
+	}
+
+	if err = os.WriteFile(configFile, append(data, '\n'), 0o644); err != nil {
+		return errors.Wrapf(err, "unable to write dev container configuration %s", configFile)
+	}
+
+	if err = os.WriteFile(scriptFile, []byte(devcontainerSetupScript), 0o755); err != nil {
+		return errors.Wrapf(err, "unable to write dev container setup script %s", scriptFile)
+	}
+
+	fmt.Printf("Dev container configuration using %s written to %s.\n", image, configDir)
+
+	return nil
+}
+
+func (p *ProjectInfo) NewWorkshopDevcontainerCmd() *cobra.Command {
+	var o WorkshopDevcontainerOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "devcontainer [PATH]",
+		Short: "Generate dev container configuration for workshop",
+		Long: `Generate dev container configuration for a workshop.
+
+Writes a .devcontainer directory to the workshop directory holding a
+devcontainer.json file, so the workshop can be opened in VS Code, or another
+editor supporting dev containers, using the same workshop image and tools as
+a workshop session. Where a custom workshop image has been built for the
+workshop using "educates workshop build-image", that image is used.
+
+When the dev container is created, the workshop files are linked into the
+home directory as they would be in a workshop session, and the workshop setup
+scripts are run using the same script a workshop session runs on startup, so
+terminals in the dev container have the same environment as those attendees
+will see. After changing setup scripts, run .devcontainer/setup-workshop.sh
+again to rerun them.
+
+The environment variables set in the dev container are those set when the
+workshop is deployed to docker, along with those given in the workshop
+definition. Session data variables in the values of environment variables
+are not expanded, and session applications other than terminals, such as the
+workshop dashboard, are not started. Use --force to replace any existing dev
+container configuration.`,
+		RunE: func(_ *cobra.Command, args []string) error { return o.Run(args) },
+	}
+
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopVersion,
+		"workshop-version",
+		"latest",
+		"version of the workshop",
+	)
+	c.Flags().StringVar(
+		&o.Repository,
+		"image-repository",
+		"localhost:5001",
+		"the address of the image repository",
+	)
+	c.Flags().StringVar(
+		&o.ImageVersion,
+		"image-version",
+		p.Version,
+		"version of workshop base images to use",
+	)
+	c.Flags().BoolVar(
+		&o.Force,
+		"force",
+		false,
+		"replace existing dev container configuration",
+	)
+
+	return c
+}
@@ -0,0 +1,572 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k14s/difflib"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	imgpkgcmd "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/cmd"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+type WorkshopDiffOptions struct {
+	Path          string
+	Image         string
+	Repository    string
+	WorkshopFile  string
+	Summary       bool
+	Context       int
+	RegistryFlags imgpkgcmd.RegistryFlags
+}
+
+/*
+Work out the image reference for a published version of the workshop. A
+version containing a "/" is taken to be a full image reference. Otherwise
+the image name is that given by --image, or from the publish section of the
+local workshop definition, with the version as the image tag.
+*/
+func (o *WorkshopDiffOptions) workshopImage(version string) (string, error) {
+	if strings.Contains(version, "/") {
+		return version, nil
+	}
+
+	image := o.Image
+
+	if image == "" {
+		workshopFilePath := o.WorkshopFile
+
+		if !filepath.IsAbs(workshopFilePath) {
+			workshopFilePath = filepath.Join(o.Path, workshopFilePath)
+		}
+
+		definition, err := loadWorkshopDiffDefinition(workshopFilePath)
+
+		if err != nil {
+			return "", err
+		}
+
+		spec, _ := definition["spec"].(map[string]interface{})
+		publish, _ := spec["publish"].(map[string]interface{})
+
+		image, _ = publish["image"].(string)
+	}
+
+	if image == "" {
+		return "", failures.NewValidationError(errors.New("cannot find image name for published workshop"), "use --image to give the name of the workshop files image")
+	}
+
+	image = strings.ReplaceAll(image, "$(image_repository)", o.Repository)
+
+	if strings.Contains(image, "$(workshop_version)") {
+		return strings.ReplaceAll(image, "$(workshop_version)", version), nil
+	}
+
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		image = image[:index]
+	}
+
+	return image + ":" + version, nil
+}
+
+/*
+Load a workshop definition, processing it as a template, so it can be
+compared. Values are converted via JSON so the result can be compared with
+the same functions as used for resources from the cluster.
+*/
+func loadWorkshopDiffDefinition(file string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open workshop definition %q", file)
+	}
+
+	if data, err = training.ProcessWorkshopDefinition(data, yttcmd.DataValuesFlags{}); err != nil {
+		return nil, errors.Wrapf(err, "unable to process workshop definition %q as template", file)
+	}
+
+	var definition map[string]interface{}
+
+	if err = yaml.Unmarshal(data, &definition); err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse workshop definition %q", file)
+	}
+
+	return definition, nil
+}
+
+/*
+Return the paths of all files in a directory, relative to the directory.
+*/
+func workshopDiffFiles(directory string) (map[string]string, error) {
+	files := map[string]string{}
+
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(directory, path)
+
+		if err != nil {
+			return err
+		}
+
+		files[filepath.ToSlash(relPath)] = path
+
+		return nil
+	})
+
+	return files, err
+}
+
+/*
+Read the contents of a file for comparison. For symbolic links the target of
+the link is compared rather than what it points at.
+*/
+func readWorkshopDiffFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+
+		return []byte("-> " + target + "\n"), err
+	}
+
+	return os.ReadFile(path)
+}
+
+func diffLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+/*
+Write the differences between two sets of lines in unified diff format, with
+the number of lines of context given around each change.
+*/
+func writeUnifiedDiff(out io.Writer, fromName string, toName string, from []string, to []string, context int) {
+	records := difflib.Diff(from, to)
+
+	// Line numbers in the old and new files of the line before each record.
+
+	fromLines := make([]int, len(records)+1)
+	toLines := make([]int, len(records)+1)
+
+	for i, record := range records {
+		fromLines[i+1], toLines[i+1] = fromLines[i], toLines[i]
+
+		if record.Delta != difflib.RightOnly {
+			fromLines[i+1]++
+		}
+
+		if record.Delta != difflib.LeftOnly {
+			toLines[i+1]++
+		}
+	}
+
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(records); {
+		if records[i].Delta == difflib.Common {
+			i++
+			continue
+		}
+
+		// Extend the hunk over further changes separated by no more than
+		// twice the context, so their context lines don't overlap.
+
+		last := i
+
+		for j := i + 1; j < len(records); j++ {
+			if records[j].Delta == difflib.Common {
+				continue
+			}
+
+			if j-last-1 > 2*context {
+				break
+			}
+
+			last = j
+		}
+
+		start := i - context
+
+		if start < 0 {
+			start = 0
+		}
+
+		stop := last + 1 + context
+
+		if stop > len(records) {
+			stop = len(records)
+		}
+
+		fromCount := fromLines[stop] - fromLines[start]
+		toCount := toLines[stop] - toLines[start]
+
+		fromStart := fromLines[start] + 1
+		toStart := toLines[start] + 1
+
+		if fromCount == 0 {
+			fromStart--
+		}
+
+		if toCount == 0 {
+			toStart--
+		}
+
+		fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+
+		for _, record := range records[start:stop] {
+			fmt.Fprintf(out, "%s%s\n", record.Delta, record.Payload)
+		}
+
+		i = stop
+	}
+}
+
+/*
+Return whether a file holds the workshop instructions or their configuration,
+as opposed to the other workshop files. Instructions built before publishing
+are generated files, so aren't shown line by line.
+*/
+func isWorkshopInstructionsFile(path string) bool {
+	return strings.HasPrefix(path, "workshop/") && !strings.HasPrefix(path, "workshop/public/")
+}
+
+func (o *WorkshopDiffOptions) Run(fromVersion string, toVersion string) error {
+	var err error
+
+	if o.Path, err = filepath.Abs(o.Path); err != nil {
+		return errors.Wrap(err, "couldn't convert workshop directory to absolute path")
+	}
+
+	fromImage, err := o.workshopImage(fromVersion)
+
+	if err != nil {
+		return err
+	}
+
+	toImage, err := o.workshopImage(toVersion)
+
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "educates-diff")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary working directory")
+	}
+
+	defer os.RemoveAll(tempDir)
+
+	fromDir := filepath.Join(tempDir, "from")
+	toDir := filepath.Join(tempDir, "to")
+
+	for _, item := range []struct{ image, dir string }{{fromImage, fromDir}, {toImage, toDir}} {
+		fmt.Fprintf(os.Stderr, "Pulling %s\n", item.image)
+
+		if err = training.PullWorkshopFiles(item.image, item.dir, o.RegistryFlags); err != nil {
+			return err
+		}
+	}
+
+	fromFiles, err := workshopDiffFiles(fromDir)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to read files for %s", fromImage)
+	}
+
+	toFiles, err := workshopDiffFiles(toDir)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to read files for %s", toImage)
+	}
+
+	var paths []string
+
+	for path := range fromFiles {
+		paths = append(paths, path)
+	}
+
+	for path := range toFiles {
+		if _, found := fromFiles[path]; !found {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	// Changes to the workshop definition are shown first, as a summary of
+	// the fields changed and then the changes to the normalized definition,
+	// so differences in formatting and ordering of fields aren't shown.
+
+	fmt.Printf("Workshop definition (%s):\n\n", o.WorkshopFile)
+
+	workshopFile := filepath.ToSlash(o.WorkshopFile)
+
+	var fromDefinition, toDefinition map[string]interface{}
+
+	if fromFiles[workshopFile] != "" {
+		if fromDefinition, err = loadWorkshopDiffDefinition(fromFiles[workshopFile]); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+		}
+	}
+
+	if toFiles[workshopFile] != "" {
+		if toDefinition, err = loadWorkshopDiffDefinition(toFiles[workshopFile]); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+		}
+	}
+
+	fields := diffFields("spec", toDefinition["spec"], fromDefinition["spec"])
+
+	if len(fields) == 0 {
+		fmt.Println("  No changes.")
+	}
+
+	for _, field := range fields {
+		switch field.Change {
+		case "missing":
+			fmt.Printf("  + %s (added)\n", field.Path)
+		case "extra":
+			fmt.Printf("  - %s (removed)\n", field.Path)
+		default:
+			fmt.Printf("  ~ %s\n", field.Path)
+		}
+	}
+
+	if len(fields) != 0 && !o.Summary {
+		fromData, _ := yaml.Marshal(fromDefinition["spec"])
+		toData, _ := yaml.Marshal(toDefinition["spec"])
+
+		fmt.Println()
+
+		writeUnifiedDiff(os.Stdout, fromVersion+"/spec", toVersion+"/spec", diffLines(fromData), diffLines(toData), o.Context)
+	}
+
+	// List the files added, deleted or modified, followed by the changes to
+	// each of the files for the workshop instructions.
+
+	fmt.Printf("\nFiles:\n\n")
+
+	var changed []string
+
+	for _, path := range paths {
+		fromData, err := readWorkshopDiffFile(fromFiles[path])
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read %s", path)
+		}
+
+		toData, err := readWorkshopDiffFile(toFiles[path])
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read %s", path)
+		}
+
+		switch {
+		case fromFiles[path] == "":
+			fmt.Printf("  A %s\n", path)
+		case toFiles[path] == "":
+			fmt.Printf("  D %s\n", path)
+		case !bytes.Equal(fromData, toData):
+			fmt.Printf("  M %s\n", path)
+		default:
+			continue
+		}
+
+		changed = append(changed, path)
+	}
+
+	if len(changed) == 0 {
+		fmt.Println("  No changes.")
+	}
+
+	if o.Summary {
+		return nil
+	}
+
+	header := false
+
+	for _, path := range changed {
+		if !isWorkshopInstructionsFile(path) {
+			continue
+		}
+
+		if !header {
+			fmt.Printf("\nInstructions:\n")
+
+			header = true
+		}
+
+		fromData, _ := readWorkshopDiffFile(fromFiles[path])
+		toData, _ := readWorkshopDiffFile(toFiles[path])
+
+		fmt.Println()
+
+		if bytes.IndexByte(fromData, 0) != -1 || bytes.IndexByte(toData, 0) != -1 {
+			fmt.Printf("Binary file %s differs\n", path)
+			continue
+		}
+
+		fromName, toName := fromVersion+"/"+path, toVersion+"/"+path
+
+		if fromFiles[path] == "" {
+			fromName = "/dev/null"
+		}
+
+		if toFiles[path] == "" {
+			toName = "/dev/null"
+		}
+
+		writeUnifiedDiff(os.Stdout, fromName, toName, diffLines(fromData), diffLines(toData), o.Context)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewWorkshopDiffCmd() *cobra.Command {
+	var o WorkshopDiffOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.ExactArgs(2),
+		Use:   "diff OLD NEW",
+		Short: "Show changes between published workshop versions",
+		Long: `Show changes between published versions of a workshop.
+
+Pulls the workshop files for two published versions of a workshop and shows
+what changed between them, to help with reviewing a new revision of a
+workshop before it is used. The versions are the tags the workshop was
+published with, such as "v1.2.0", with the image name taken from the publish
+section of the workshop definition in the current directory, or the directory
+given by --path, unless given by --image. A full image reference can also be
+given in place of either version.
+
+Changes to the workshop definition are shown first, as a list of the fields
+added, removed or changed, followed by a diff of the definition with fields
+in a consistent order. This is followed by a list of the workshop files
+added (A), deleted (D) or modified (M), and then a diff for each of the files
+for the workshop instructions. Use --summary to only show the lists of
+fields and files changed.`,
+		RunE: func(_ *cobra.Command, args []string) error { return o.Run(args[0], args[1]) },
+	}
+
+	c.Flags().StringVar(
+		&o.Path,
+		"path",
+		".",
+		"path to local workshop directory holding the workshop definition",
+	)
+	c.Flags().StringVar(
+		&o.Image,
+		"image",
+		"",
+		"name of the workshop files image artifact",
+	)
+	c.Flags().StringVar(
+		&o.Repository,
+		"image-repository",
+		"localhost:5001",
+		"the address of the image repository",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+	c.Flags().BoolVar(
+		&o.Summary,
+		"summary",
+		false,
+		"only list the fields and files changed",
+	)
+	c.Flags().IntVar(
+		&o.Context,
+		"context",
+		3,
+		"number of lines of context to show around changes",
+	)
+
+	c.Flags().StringSliceVar(
+		&o.RegistryFlags.CACertPaths,
+		"registry-ca-cert-path",
+		nil,
+		"Add CA certificates for registry API",
+	)
+	c.Flags().BoolVar(
+		&o.RegistryFlags.VerifyCerts,
+		"registry-verify-certs",
+		true,
+		"Set whether to verify server's certificate chain and host name",
+	)
+	c.Flags().BoolVar(
+		&o.RegistryFlags.Insecure,
+		"registry-insecure",
+		false,
+		"Allow the use of http when interacting with registries",
+	)
+
+	c.Flags().StringVar(
+		&o.RegistryFlags.Username,
+		"registry-username",
+		"",
+		"Set username for registry authentication",
+	)
+	c.Flags().StringVar(
+		&o.RegistryFlags.Password,
+		"registry-password",
+		"",
+		"Set password for registry authentication",
+	)
+	c.Flags().StringVar(
+		&o.RegistryFlags.Token,
+		"registry-token",
+		"",
+		"Set token for registry authentication",
+	)
+	c.Flags().BoolVar(
+		&o.RegistryFlags.Anon,
+		"registry-anon",
+		false,
+		"Set anonymous for registry authentication",
+	)
+
+	c.Flags().DurationVar(
+		&o.RegistryFlags.ResponseHeaderTimeout,
+		"registry-response-header-timeout",
+		30*time.Second,
+		"Maximum time to allow a request to wait for a server's response headers from the registry (ms|s|m|h)",
+	)
+	c.Flags().IntVar(
+		&o.RegistryFlags.RetryCount,
+		"registry-retry-count",
+		5,
+		"Set the number of times to retry pulling from the registry in case of an error",
+	)
+
+	return c
+}
@@ -0,0 +1,410 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+type WorkshopExportBackstageOptions struct {
+	FilesExportOptions
+	Kind       string
+	Owner      string
+	Lifecycle  string
+	System     string
+	PortalURL  string
+	Portal     string
+	Kubeconfig string
+	Output     string
+}
+
+var backstageKinds = []string{"component", "template"}
+
+func (o *WorkshopExportBackstageOptions) Run(ctx context.Context, args []string) error {
+	if !containsString(backstageKinds, o.Kind) {
+		return failures.NewValidationError(errors.Errorf("invalid kind %q", o.Kind), fmt.Sprintf("kind must be one of %s", strings.Join(backstageKinds, ", ")))
+	}
+
+	directory, err := workshopDirectory(args)
+
+	if err != nil {
+		return err
+	}
+
+	workshop, err := o.loadWorkshop(directory)
+
+	if err != nil {
+		return err
+	}
+
+	// The URL of the training portal can be given explicitly, otherwise it
+	// is looked up from the training portal in the cluster, if a training
+	// portal was named.
+
+	portalURL := o.PortalURL
+
+	if portalURL == "" && o.Portal != "" {
+		if portalURL, err = lookupPortalURL(ctx, o.Kubeconfig, o.Portal); err != nil {
+			return err
+		}
+	}
+
+	entity := backstageEntity(workshop, o.Kind, o.Owner, o.Lifecycle, o.System, strings.TrimSuffix(portalURL, "/"))
+
+	data, err := yaml.Marshal(entity)
+
+	if err != nil {
+		return errors.Wrap(err, "couldn't convert catalog descriptor to YAML")
+	}
+
+	if o.Output == "" || o.Output == "-" {
+		fmt.Print(string(data))
+
+		return nil
+	}
+
+	if err = os.WriteFile(o.Output, data, 0644); err != nil {
+		return errors.Wrapf(err, "unable to write catalog descriptor to %s", o.Output)
+	}
+
+	return nil
+}
+
+/*
+Return the URL of a training portal deployed to the cluster.
+*/
+func lookupPortalURL(ctx context.Context, kubeconfig string, portal string) (string, error) {
+	dynamicClient, err := cluster.NewClusterConfig(kubeconfig).GetDynamicClient()
+
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to create Kubernetes client")
+	}
+
+	trainingPortal, err := dynamicClient.Resource(trainingPortalResource).Get(ctx, portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return "", failures.NewNotFoundError(errors.Errorf("unable to find training portal %q", portal), "run `educates cluster portal list` to see available training portals")
+	}
+
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to retrieve training portal %q", portal)
+	}
+
+	portalURL, _, _ := unstructured.NestedString(trainingPortal.Object, "status", "educates", "url")
+
+	if portalURL == "" {
+		return "", errors.Errorf("training portal %q is not yet available", portal)
+	}
+
+	return portalURL, nil
+}
+
+var (
+	backstageTagInvalidChars = regexp.MustCompile(`[^a-z0-9:+#-]+`)
+	backstageTagDashes       = regexp.MustCompile(`-+`)
+)
+
+/*
+Convert workshop tags to Backstage tags, which are restricted to lowercase
+words separated by dashes.
+*/
+func backstageTags(tags []string) []string {
+	var result []string
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		tag = backstageTagInvalidChars.ReplaceAllString(strings.ReplaceAll(tag, " ", "-"), "")
+		tag = strings.Trim(backstageTagDashes.ReplaceAllString(tag, "-"), "-")
+
+		if len(tag) > 63 {
+			tag = strings.Trim(tag[:63], "-")
+		}
+
+		if tag != "" && !containsString(result, tag) {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+/*
+Generate a Backstage catalog entity describing the workshop. As a component
+the workshop is listed in the software catalog, linking to the training
+portal. As a template it is listed with the software templates, with the
+output of running it being a link for starting the workshop.
+*/
+func backstageEntity(workshop *unstructured.Unstructured, kind string, owner string, lifecycle string, system string, portalURL string) map[string]interface{} {
+	name := workshop.GetName()
+
+	title, _, _ := unstructured.NestedString(workshop.Object, "spec", "title")
+	description, _, _ := unstructured.NestedString(workshop.Object, "spec", "description")
+	difficulty, _, _ := unstructured.NestedString(workshop.Object, "spec", "difficulty")
+	duration, _, _ := unstructured.NestedString(workshop.Object, "spec", "duration")
+	vendor, _, _ := unstructured.NestedString(workshop.Object, "spec", "vendor")
+	sourceURL, _, _ := unstructured.NestedString(workshop.Object, "spec", "url")
+	version, _, _ := unstructured.NestedString(workshop.Object, "spec", "version")
+	tags, _, _ := unstructured.NestedStringSlice(workshop.Object, "spec", "tags")
+	authors, _, _ := unstructured.NestedStringSlice(workshop.Object, "spec", "authors")
+
+	metadata := map[string]interface{}{
+		"name": name,
+	}
+
+	if title != "" {
+		metadata["title"] = title
+	}
+
+	if description != "" {
+		metadata["description"] = description
+	}
+
+	if tags := backstageTags(tags); len(tags) != 0 {
+		metadata["tags"] = tags
+	}
+
+	annotations := map[string]interface{}{
+		"educates.dev/workshop": name,
+	}
+
+	for key, value := range map[string]string{
+		"educates.dev/difficulty": difficulty,
+		"educates.dev/duration":   duration,
+		"educates.dev/vendor":     vendor,
+		"educates.dev/version":    version,
+		"educates.dev/authors":    strings.Join(authors, ", "),
+	} {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+
+	metadata["annotations"] = annotations
+
+	var links []interface{}
+
+	if portalURL != "" {
+		links = append(links, map[string]interface{}{
+			"url":   portalURL + "/workshops/catalog/",
+			"title": "Training portal",
+			"icon":  "dashboard",
+		})
+	}
+
+	if sourceURL != "" {
+		links = append(links, map[string]interface{}{
+			"url":   sourceURL,
+			"title": "Workshop source",
+			"icon":  "github",
+		})
+	}
+
+	if len(links) != 0 {
+		metadata["links"] = links
+	}
+
+	entity := map[string]interface{}{
+		"metadata": metadata,
+	}
+
+	if kind == "template" {
+		spec := map[string]interface{}{
+			"type":       "training",
+			"owner":      owner,
+			"parameters": []interface{}{},
+			"steps": []interface{}{
+				map[string]interface{}{
+					"id":     "log",
+					"name":   "Workshop",
+					"action": "debug:log",
+					"input": map[string]interface{}{
+						"message": fmt.Sprintf("Open the training portal to start workshop %s.", name),
+					},
+				},
+			},
+		}
+
+		if portalURL != "" {
+			spec["output"] = map[string]interface{}{
+				"links": []interface{}{
+					map[string]interface{}{
+						"title": "Start workshop",
+						"url":   portalURL + "/workshops/catalog/",
+					},
+				},
+			}
+		}
+
+		entity["apiVersion"] = "scaffolder.backstage.io/v1beta3"
+		entity["kind"] = "Template"
+		entity["spec"] = spec
+	} else {
+		spec := map[string]interface{}{
+			"type":      "training",
+			"owner":     owner,
+			"lifecycle": lifecycle,
+		}
+
+		if system != "" {
+			spec["system"] = system
+		}
+
+		entity["apiVersion"] = "backstage.io/v1alpha1"
+		entity["kind"] = "Component"
+		entity["spec"] = spec
+	}
+
+	return entity
+}
+
+func (p *ProjectInfo) NewWorkshopExportBackstageCmd() *cobra.Command {
+	var o WorkshopExportBackstageOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "export-backstage [PATH]",
+		Short: "Export Backstage catalog descriptor for workshop",
+		Long: `Export Backstage catalog descriptor for workshop.
+
+Generates a catalog-info.yaml file describing the workshop, so that it can be
+listed in a Backstage developer portal. The title, description and tags of
+the workshop are used for the catalog entity, with other workshop metadata
+added as annotations. By default the workshop is described as a Component,
+use --kind template to instead describe it as a software Template.
+
+If the URL of the training portal hosting the workshop is given using
+--portal-url, or a training portal in the cluster is named using --portal,
+the catalog entity links to the training portal.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
+	}
+
+	c.Flags().StringVar(
+		&o.Kind,
+		"kind",
+		"component",
+		"kind of catalog entity to generate, one of component or template",
+	)
+	c.Flags().StringVar(
+		&o.Owner,
+		"owner",
+		"",
+		"owner of the catalog entity, such as group:default/training",
+	)
+	c.Flags().StringVar(
+		&o.Lifecycle,
+		"lifecycle",
+		"production",
+		"lifecycle of the catalog entity when generating a component",
+	)
+	c.Flags().StringVar(
+		&o.System,
+		"system",
+		"",
+		"system the catalog entity belongs to when generating a component",
+	)
+	c.Flags().StringVar(
+		&o.PortalURL,
+		"portal-url",
+		"",
+		"URL of the training portal hosting the workshop",
+	)
+	c.Flags().StringVarP(
+		&o.Portal,
+		"portal",
+		"p",
+		"",
+		"name of training portal in the cluster to look up the URL for",
+	)
+	c.Flags().StringVar(
+		&o.Kubeconfig,
+		"kubeconfig",
+		"",
+		"kubeconfig file to use instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"",
+		"file to write the catalog descriptor to, defaults to stdout",
+	)
+
+	c.Flags().StringVar(
+		&o.Repository,
+		"image-repository",
+		"localhost:5001",
+		"the address of the image repository",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+
+	c.Flags().StringVar(
+		&o.WorkshopVersion,
+		"workshop-version",
+		"latest",
+		"version of the workshop being published",
+	)
+
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.EnvFromStrings,
+		"data-values-env",
+		nil,
+		"Extract data values (as strings) from prefixed env vars (format: PREFIX for PREFIX_all__key1=str) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.EnvFromYAML,
+		"data-values-env-yaml",
+		nil,
+		"Extract data values (parsed as YAML) from prefixed env vars (format: PREFIX for PREFIX_all__key1=true) (can be specified multiple times)",
+	)
+
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.KVsFromStrings,
+		"data-value",
+		nil,
+		"Set specific data value to given value, as string (format: all.key1.subkey=123) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.KVsFromYAML,
+		"data-value-yaml",
+		nil,
+		"Set specific data value to given value, parsed as YAML (format: all.key1.subkey=true) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.KVsFromFiles,
+		"data-value-file",
+		nil,
+		"Set specific data value to contents of a file (format: [@lib1:]all.key1.subkey={file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
+	)
+	c.Flags().StringArrayVar(
+		&o.DataValuesFlags.FromFiles,
+		"data-values-file",
+		nil,
+		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
+	)
+
+	c.MarkFlagRequired("owner")
+
+	c.RegisterFlagCompletionFunc("portal", completeTrainingPortalNames)
+	c.RegisterFlagCompletionFunc("kind", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return backstageKinds, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return c
+}
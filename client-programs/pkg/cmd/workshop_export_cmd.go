@@ -14,6 +14,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/kubectl/pkg/scheme"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 )
 
 type FilesExportOptions struct {
@@ -24,6 +27,20 @@ type FilesExportOptions struct {
 }
 
 func (o *FilesExportOptions) Run(args []string) error {
+	directory, err := workshopDirectory(args)
+
+	if err != nil {
+		return err
+	}
+
+	return o.Export(directory)
+}
+
+/*
+Return the absolute path of the workshop directory given as an optional
+command argument, defaulting to the current working directory.
+*/
+func workshopDirectory(args []string) (string, error) {
 	var err error
 
 	var directory string
@@ -35,22 +52,23 @@ func (o *FilesExportOptions) Run(args []string) error {
 	}
 
 	if directory, err = filepath.Abs(directory); err != nil {
-		return errors.Wrap(err, "couldn't convert workshop directory to absolute path")
+		return "", errors.Wrap(err, "couldn't convert workshop directory to absolute path")
 	}
 
 	fileInfo, err := os.Stat(directory)
 
 	if err != nil || !fileInfo.IsDir() {
-		return errors.New("workshop directory does not exist or path is not a directory")
+		return "", failures.NewValidationError(errors.New("workshop directory does not exist or path is not a directory"), "")
 	}
 
-	return o.Export(directory)
+	return directory, nil
 }
 
-func (o *FilesExportOptions) Export(directory string) error {
-	// If image name hasn't been supplied read workshop definition file and
-	// try to work out image name to Export workshop as.
-
+/*
+Load the workshop definition from the workshop directory, processing it as a
+ytt template and substituting the image repository and workshop version.
+*/
+func (o *FilesExportOptions) loadWorkshop(directory string) (*unstructured.Unstructured, error) {
 	rootDirectory := directory
 	workshopFilePath := o.WorkshopFile
 
@@ -61,13 +79,13 @@ func (o *FilesExportOptions) Export(directory string) error {
 	workshopFileData, err := os.ReadFile(workshopFilePath)
 
 	if err != nil {
-		return errors.Wrapf(err, "cannot open workshop definition %q", workshopFilePath)
+		return nil, errors.Wrapf(err, "cannot open workshop definition %q", workshopFilePath)
 	}
 
 	// Process the workshop YAML data for ytt templating and data variables.
 
-	if workshopFileData, err = processWorkshopDefinition(workshopFileData, o.DataValuesFlags); err != nil {
-		return errors.Wrap(err, "unable to process workshop definition as template")
+	if workshopFileData, err = training.ProcessWorkshopDefinition(workshopFileData, o.DataValuesFlags); err != nil {
+		return nil, errors.Wrap(err, "unable to process workshop definition as template")
 	}
 
 	workshopFileData = []byte(strings.ReplaceAll(string(workshopFileData), "$(image_repository)", o.Repository))
@@ -80,11 +98,21 @@ func (o *FilesExportOptions) Export(directory string) error {
 	err = runtime.DecodeInto(decoder, workshopFileData, workshop)
 
 	if err != nil {
-		return errors.Wrap(err, "couldn't parse workshop definition")
+		return nil, errors.Wrap(err, "couldn't parse workshop definition")
 	}
 
 	if workshop.GetAPIVersion() != "training.educates.dev/v1beta1" || workshop.GetKind() != "Workshop" {
-		return errors.New("invalid type for workshop definition")
+		return nil, errors.New("invalid type for workshop definition")
+	}
+
+	return workshop, nil
+}
+
+func (o *FilesExportOptions) Export(directory string) error {
+	workshop, err := o.loadWorkshop(directory)
+
+	if err != nil {
+		return err
 	}
 
 	// Insert workshop version property if not specified.
@@ -101,7 +129,7 @@ func (o *FilesExportOptions) Export(directory string) error {
 
 	// Export modified workshop definition file.
 
-	workshopFileData, err = yaml.Marshal(&workshop.Object)
+	workshopFileData, err := yaml.Marshal(&workshop.Object)
 
 	if err != nil {
 		return errors.Wrap(err, "couldn't convert workshop definition back to YAML")
@@ -0,0 +1,483 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+/*
+An image used by a workshop, with the locations in the workshop definition
+or workshop files it is referenced from.
+*/
+type workshopImage struct {
+	Image     string   `json:"image"`
+	Locations []string `json:"locations"`
+}
+
+type WorkshopImagesOptions struct {
+	WorkshopFile    string
+	WorkshopVersion string
+	Repository      string
+	ImageVersion    string
+	Output          string
+	SBOM            string
+	DataValuesFlags yttcmd.DataValuesFlags
+}
+
+func (o *WorkshopImagesOptions) Run(args []string) error {
+	var err error
+
+	var directory string
+
+	if len(args) != 0 {
+		directory = filepath.Clean(args[0])
+	} else {
+		directory = "."
+	}
+
+	if directory, err = filepath.Abs(directory); err != nil {
+		return errors.Wrap(err, "couldn't convert workshop directory to absolute path")
+	}
+
+	fileInfo, err := os.Stat(directory)
+
+	if err != nil || !fileInfo.IsDir() {
+		return failures.NewValidationError(errors.New("workshop directory does not exist or path is not a directory"), "")
+	}
+
+	if o.Output != "table" && o.Output != "json" && o.Output != "names" {
+		return failures.NewValidationError(errors.Errorf("unsupported output format %q", o.Output), "supported formats are table, json and names")
+	}
+
+	workshop, err := training.LoadWorkshopDefinition(&training.DefinitionOptions{
+		Path:            directory,
+		Portal:          "educates-cli",
+		WorkshopFile:    o.WorkshopFile,
+		WorkshopVersion: o.WorkshopVersion,
+		DataValuesFlags: o.DataValuesFlags,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	var images []*workshopImage
+
+	add := func(location string, image string) {
+		if image == "" {
+			return
+		}
+
+		for _, entry := range images {
+			if entry.Image == image {
+				if !containsString(entry.Locations, location) {
+					entry.Locations = append(entry.Locations, location)
+				}
+
+				return
+			}
+		}
+
+		images = append(images, &workshopImage{Image: image, Locations: []string{location}})
+	}
+
+	// Images referenced from the workshop definition. The workshop image is
+	// resolved to the image which would be used by the platform.
+
+	imageName, err := generateWorkshopImageName(workshop, o.Repository, o.ImageVersion, "", o.WorkshopVersion)
+
+	if err != nil {
+		return err
+	}
+
+	add("spec.workshop.image", imageName)
+
+	err = visitWorkshopImages(workshop.DeepCopy(), func(location string, image string) string {
+		if location != "spec.workshop.image" {
+			add(location, strings.ReplaceAll(image, "$(image_repository)", o.Repository))
+		}
+
+		return image
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to find images in workshop definition")
+	}
+
+	for _, image := range sessionApplicationImages(workshop, o.ImageVersion) {
+		add("spec.session.applications", image)
+	}
+
+	// Images referenced from files in the workshop directory, such as
+	// resources applied from the workshop instructions and Dockerfiles.
+
+	workshopFilePath := o.WorkshopFile
+
+	if !filepath.IsAbs(workshopFilePath) {
+		workshopFilePath = filepath.Join(directory, workshopFilePath)
+	}
+
+	err = filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if name := info.Name(); path != directory && (name == ".git" || name == "node_modules" || name == "public") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if path == workshopFilePath {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(directory, path)
+
+		name := info.Name()
+
+		switch {
+		case name == "Dockerfile" || name == "Containerfile" || strings.HasSuffix(name, ".Dockerfile") || strings.HasPrefix(name, "Dockerfile."):
+			data, err := os.ReadFile(path)
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to read %q", relPath)
+			}
+
+			for _, image := range dockerfileBaseImages(data) {
+				add(relPath, image)
+			}
+		case strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml"):
+			data, err := os.ReadFile(path)
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to read %q", relPath)
+			}
+
+			for _, image := range manifestImages(data) {
+				add(relPath, image)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to find images in workshop files")
+	}
+
+	sort.Slice(images, func(i, j int) bool { return images[i].Image < images[j].Image })
+
+	if o.SBOM != "" {
+		if err = generateWorkshopSBOM(o.SBOM, workshop.GetName(), images); err != nil {
+			return err
+		}
+
+		if o.SBOM == "-" {
+			return nil
+		}
+	}
+
+	switch o.Output {
+	case "json":
+		jsonData, err := json.MarshalIndent(images, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode images")
+		}
+
+		fmt.Println(string(jsonData))
+
+		return nil
+	case "names":
+		for _, entry := range images {
+			fmt.Println(entry.Image)
+		}
+
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\n", "IMAGE", "LOCATION")
+
+	for _, entry := range images {
+		for _, location := range entry.Locations {
+			fmt.Fprintf(w, "%s\t%s\n", entry.Image, location)
+		}
+	}
+
+	return nil
+}
+
+/*
+Return the images named in FROM instructions of a Dockerfile. Images given
+using build arguments and references to earlier build stages are skipped.
+*/
+func dockerfileBaseImages(data []byte) []string {
+	var images []string
+
+	stages := map[string]bool{"scratch": true}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+
+		fields = fields[1:]
+
+		for len(fields) != 0 && strings.HasPrefix(fields[0], "--") {
+			fields = fields[1:]
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		image := fields[0]
+
+		if !stages[strings.ToLower(image)] && !strings.Contains(image, "$") {
+			images = append(images, image)
+		}
+
+		if len(fields) >= 3 && strings.EqualFold(fields[1], "AS") {
+			stages[strings.ToLower(fields[2])] = true
+		}
+	}
+
+	return images
+}
+
+/*
+Return the images for containers in Kubernetes resources, and for services in
+docker compose files, held in a YAML file. Files which can't be parsed are
+ignored, as aren't necessarily meant to be used as is.
+*/
+func manifestImages(data []byte) []string {
+	var images []string
+
+	documents := yamlutil.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+
+	for {
+		document, err := documents.Read()
+
+		if err != nil {
+			break
+		}
+
+		var value interface{}
+
+		if err := yaml.Unmarshal(document, &value); err != nil {
+			continue
+		}
+
+		images = append(images, containerImages(value)...)
+
+		if object, ok := value.(map[string]interface{}); ok {
+			if services, ok := object["services"].(map[string]interface{}); ok {
+				for _, service := range services {
+					if service, ok := service.(map[string]interface{}); ok {
+						if image, ok := service["image"].(string); ok {
+							images = append(images, image)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return images
+}
+
+/*
+Generate a combined software bill of materials in CycloneDX format for the
+images used by a workshop, by running "syft" against each image. Each image
+is a component of the workshop, with the components found in the image by
+syft nested within it. Images which depend on session variables can't be
+analyzed and are skipped.
+*/
+func generateWorkshopSBOM(path string, name string, images []*workshopImage) error {
+	var components []interface{}
+
+	specVersion := "1.4"
+
+	for _, entry := range images {
+		if strings.Contains(entry.Image, "$(") {
+			fmt.Fprintf(os.Stderr, "Warning: skipping image %s as it depends on session variables.\n", entry.Image)
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "Generating SBOM for %s\n", entry.Image)
+
+		var stdout bytes.Buffer
+
+		syftCommand := exec.Command("syft", entry.Image, "--output", "cyclonedx-json", "--quiet")
+
+		syftCommand.Stdout = &stdout
+		syftCommand.Stderr = os.Stderr
+
+		if err := syftCommand.Run(); err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return failures.NewValidationError(errors.New("the syft command is required to generate an SBOM"), "install syft from https://github.com/anchore/syft")
+			}
+
+			return errors.Wrapf(err, "unable to generate SBOM for image %s", entry.Image)
+		}
+
+		var bom map[string]interface{}
+
+		if err := json.Unmarshal(stdout.Bytes(), &bom); err != nil {
+			return errors.Wrapf(err, "unable to decode SBOM for image %s", entry.Image)
+		}
+
+		if version, ok := bom["specVersion"].(string); ok {
+			specVersion = version
+		}
+
+		component := map[string]interface{}{
+			"type":    "container",
+			"name":    entry.Image,
+			"bom-ref": entry.Image,
+		}
+
+		if nested, ok := bom["components"].([]interface{}); ok && len(nested) != 0 {
+			component["components"] = nested
+		}
+
+		components = append(components, component)
+	}
+
+	combined := map[string]interface{}{
+		"bomFormat":   "CycloneDX",
+		"specVersion": specVersion,
+		"version":     1,
+		"metadata": map[string]interface{}{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"component": map[string]interface{}{
+				"type": "application",
+				"name": name,
+			},
+		},
+		"components": components,
+	}
+
+	jsonData, err := json.MarshalIndent(combined, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode SBOM")
+	}
+
+	var out io.Writer = os.Stdout
+
+	if path != "-" {
+		file, err := os.Create(path)
+
+		if err != nil {
+			return errors.Wrap(err, "unable to create SBOM file")
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	if _, err = fmt.Fprintln(out, string(jsonData)); err != nil {
+		return errors.Wrap(err, "unable to write SBOM file")
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewWorkshopImagesCmd() *cobra.Command {
+	var o WorkshopImagesOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "images [PATH]",
+		Short: "List container images used by workshop",
+		Long: `List the container images used by a workshop.
+
+Analyzes the workshop definition and the files in the workshop directory for
+all the container images the workshop will use, without deploying it. This
+includes the workshop image, images used for workshop files and packages,
+images for session applications such as docker and virtual clusters, images
+in environment and session objects, services run using docker compose, and
+images in YAML resources and Dockerfiles included in the workshop files.
+
+Using --sbom, a combined software bill of materials in CycloneDX format is
+also generated for all the images, by running "syft" against each image,
+which can be provided for a security review of the workshop. If the SBOM is
+written to stdout, the list of images is not output.`,
+		RunE: func(_ *cobra.Command, args []string) error { return o.Run(args) },
+	}
+
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopVersion,
+		"workshop-version",
+		"latest",
+		"version of the workshop definition",
+	)
+	c.Flags().StringVar(
+		&o.Repository,
+		"image-repository",
+		"localhost:5001",
+		"the address of the image repository",
+	)
+	c.Flags().StringVar(
+		&o.ImageVersion,
+		"image-version",
+		p.Version,
+		"version of workshop base images to be used",
+	)
+	c.Flags().StringVarP(
+		&o.Output,
+		"output",
+		"o",
+		"table",
+		"output format, one of table, json or names",
+	)
+	c.Flags().StringVar(
+		&o.SBOM,
+		"sbom",
+		"",
+		"file to write combined CycloneDX SBOM for images to, or - for stdout",
+	)
+
+	addLocalDataValuesFlags(c, &o.DataValuesFlags)
+
+	return c
+}
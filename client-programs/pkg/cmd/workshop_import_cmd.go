@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/importer"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/templates"
+)
+
+type WorkshopImportOptions struct {
+	From  string
+	Name  string
+	Image string
+}
+
+func (o *WorkshopImportOptions) Run(source string, directory string) error {
+	var err error
+
+	if !containsString(importer.Formats, o.From) {
+		return failures.NewValidationError(errors.Errorf("unsupported course format %q", o.From), fmt.Sprintf("supported formats are %s", strings.Join(importer.Formats, ", ")))
+	}
+
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		return failures.NewValidationError(errors.Errorf("course directory %q does not exist", source), "")
+	}
+
+	if directory == "" {
+		directory = filepath.Base(filepath.Clean(source))
+	}
+
+	if directory, err = filepath.Abs(filepath.Clean(directory)); err != nil {
+		return errors.Wrapf(err, "could not convert path name %q to absolute path", directory)
+	}
+
+	if _, err = os.Stat(directory); err == nil {
+		return failures.NewValidationError(errors.Errorf("target path name %q already exists", directory), "")
+	}
+
+	name := o.Name
+
+	if name == "" {
+		name = filepath.Base(directory)
+	}
+
+	if match, _ := regexp.MatchString("^[a-z0-9-]+$", name); !match {
+		return failures.NewValidationError(errors.Errorf("invalid workshop name %q", name), "workshop names must be valid Kubernetes resource names, use --name to supply one")
+	}
+
+	course, err := importer.ReadCourse(o.From, source)
+
+	if err != nil {
+		return err
+	}
+
+	// Create the workshop from the classic template as the conversion is to
+	// Markdown using the extensions supported by the classic renderer, then
+	// replace the placeholder content of the template with the course.
+
+	parameters := map[string]string{
+		"WorkshopName":        name,
+		"WorkshopTitle":       course.Title,
+		"WorkshopDescription": course.Description,
+		"WorkshopImage":       o.Image,
+	}
+
+	if err = templates.InternalTemplate("classic").Apply(directory, parameters); err != nil {
+		return err
+	}
+
+	if err = course.Write(directory); err != nil {
+		return err
+	}
+
+	if course.Duration != "" {
+		if err = setWorkshopDuration(filepath.Join(directory, "resources", "workshop.yaml"), course.Duration); err != nil {
+			return err
+		}
+	}
+
+	for _, warning := range course.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", warning)
+	}
+
+	fmt.Printf("Imported %d pages from %s into workshop %s.\n", len(course.Pages), source, directory)
+
+	return nil
+}
+
+/*
+Add the duration for the workshop to the workshop definition created from
+the template, placing it after the description.
+*/
+func setWorkshopDuration(file string, duration string) error {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to read workshop definition %q", file)
+	}
+
+	var lines []string
+
+	for _, line := range strings.Split(string(data), "\n") {
+		lines = append(lines, line)
+
+		if strings.HasPrefix(line, "  description:") {
+			lines = append(lines, fmt.Sprintf("  duration: %s", duration))
+		}
+	}
+
+	if err = os.WriteFile(file, []byte(strings.Join(lines, "\n")), 0664); err != nil {
+		return errors.Wrapf(err, "unable to write workshop definition %q", file)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewWorkshopImportCmd() *cobra.Command {
+	var o WorkshopImportOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.RangeArgs(1, 2),
+		Use:   "import SOURCE [PATH]",
+		Short: "Create workshop files from course for another platform",
+		Long: `Create workshop files from course for another platform.
+
+Converts a course written for another interactive learning platform into the
+files for a workshop, to lower the cost of moving existing content. The
+SOURCE is the directory holding the course, and PATH the directory to create
+for the workshop, defaulting to a directory in the current working directory
+with the same name as the course directory.
+
+For courses written for Katacoda or Killercoda, each step listed in the
+index.json file becomes a page of the workshop, with commands marked to be
+executed or copied converted to clickable actions. Background scripts are
+converted to setup scripts run when the workshop session starts, and assets
+are copied into the exercises directory.
+
+For tracks written for Instruqt, each challenge becomes a page of the
+workshop, with runnable code blocks converted to clickable actions and setup
+scripts converted to setup scripts run when the workshop session starts.
+
+Parts of the course which can't be converted, such as scripts to verify a
+step has been completed, are reported as warnings so they can be reworked by
+hand.`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			directory := ""
+
+			if len(args) > 1 {
+				directory = args[1]
+			}
+
+			return o.Run(args[0], directory)
+		},
+	}
+
+	c.Flags().StringVar(
+		&o.From,
+		"from",
+		"katacoda",
+		"format of the course being imported (katacoda, killercoda or instruqt)",
+	)
+	c.Flags().StringVarP(
+		&o.Name,
+		"name",
+		"n",
+		"",
+		"override name of the workshop",
+	)
+	c.Flags().StringVar(
+		&o.Image,
+		"image",
+		"",
+		"name of the workshop base image to use",
+	)
+
+	c.RegisterFlagCompletionFunc("from", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return importer.Formats, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return withAuditLogging(c)
+}
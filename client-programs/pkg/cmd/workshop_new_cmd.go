@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -8,6 +9,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/renderer"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/templates"
 )
 
@@ -17,6 +20,7 @@ type WorkshopNewOptions struct {
 	Title       string
 	Description string
 	Image       string
+	Locales     []string
 }
 
 func (p *ProjectInfo) NewWorkshopNewCmd() *cobra.Command {
@@ -26,6 +30,15 @@ func (p *ProjectInfo) NewWorkshopNewCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Use:   "new PATH",
 		Short: "Create workshop files from template",
+		Long: `Create workshop files from a template.
+
+Creates a new workshop directory with the files for a workshop, using one of
+the templates bundled with the CLI. For workshops which are to be delivered
+in more than one language, use --locale to also create a copy of the workshop
+instructions for each locale, in a directory alongside the default content
+directory named with the locale as suffix, such as "workshop/content.fr",
+ready to be translated. Pages not translated for a locale are taken from the
+default content directory.`,
 		RunE: func(_ *cobra.Command, args []string) error {
 			var err error
 
@@ -36,7 +49,7 @@ func (p *ProjectInfo) NewWorkshopNewCmd() *cobra.Command {
 			}
 
 			if _, err = os.Stat(directory); err == nil {
-				return errors.Errorf("target path name %q already exists", directory)
+				return failures.NewValidationError(errors.Errorf("target path name %q already exists", directory), "")
 			}
 
 			name := o.Name
@@ -46,7 +59,7 @@ func (p *ProjectInfo) NewWorkshopNewCmd() *cobra.Command {
 			}
 
 			if match, _ := regexp.MatchString("^[a-z0-9-]+$", name); !match {
-				return errors.Errorf("invalid workshop name %q", name)
+				return failures.NewValidationError(errors.Errorf("invalid workshop name %q", name), "workshop names must be valid Kubernetes resource names")
 			}
 
 			parameters := map[string]string{
@@ -56,9 +69,19 @@ func (p *ProjectInfo) NewWorkshopNewCmd() *cobra.Command {
 				"WorkshopImage":       o.Image,
 			}
 
+			for _, locale := range o.Locales {
+				if !renderer.IsValidLocale(locale) {
+					return failures.NewValidationError(errors.Errorf("invalid locale %q", locale), "locales must be a language code optionally followed by a region, such as fr or fr-CA")
+				}
+			}
+
 			template := templates.InternalTemplate(o.Template)
 
-			return template.Apply(directory, parameters)
+			if err = template.Apply(directory, parameters); err != nil {
+				return err
+			}
+
+			return createLocaleContent(directory, o.Locales)
 		},
 	}
 
@@ -94,6 +117,58 @@ func (p *ProjectInfo) NewWorkshopNewCmd() *cobra.Command {
 		"",
 		"name of the workshop base image to use",
 	)
+	c.Flags().StringSliceVar(
+		&o.Locales,
+		"locale",
+		[]string{},
+		"locale to create a copy of the workshop instructions for (can be specified multiple times)",
+	)
 
 	return c
 }
+
+/*
+Create the content directories for each locale of the workshop instructions
+from a copy of the default content directory.
+*/
+func createLocaleContent(directory string, locales []string) error {
+	contentDirectory := filepath.Join(directory, "workshop", "content")
+
+	if len(locales) == 0 {
+		return nil
+	}
+
+	if info, err := os.Stat(contentDirectory); err != nil || !info.IsDir() {
+		return failures.NewValidationError(errors.New("workshop template has no workshop instructions to copy for locales"), "")
+	}
+
+	for _, locale := range locales {
+		target := filepath.Join(directory, "workshop", fmt.Sprintf("content.%s", locale))
+
+		err := filepath.Walk(contentDirectory, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relPath, _ := filepath.Rel(contentDirectory, path)
+
+			if info.IsDir() {
+				return os.MkdirAll(filepath.Join(target, relPath), info.Mode().Perm()|0700)
+			}
+
+			data, err := os.ReadFile(path)
+
+			if err != nil {
+				return err
+			}
+
+			return os.WriteFile(filepath.Join(target, relPath), data, info.Mode().Perm())
+		})
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create workshop instructions for locale %q", locale)
+		}
+	}
+
+	return nil
+}
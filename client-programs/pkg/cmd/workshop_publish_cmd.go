@@ -1,44 +1,32 @@
 package cmd
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
-	"github.com/cppforlife/go-cli-ui/ui"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	imgpkgcmd "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/cmd"
-	"github.com/vmware-tanzu/carvel-kapp/pkg/kapp/cmd"
-	vendirsync "github.com/vmware-tanzu/carvel-vendir/pkg/vendir/cmd"
-	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
-	yttcmdui "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/ui"
-	"github.com/vmware-tanzu/carvel-ytt/pkg/files"
-	"github.com/vmware-tanzu/carvel-ytt/pkg/yamlmeta"
-	"gopkg.in/yaml.v2"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/serializer"
-	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/apimachinery/pkg/api/resource"
 
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/registry"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
 )
 
 type FilesPublishOptions struct {
-	Image           string
-	Repository      string
-	WorkshopFile    string
-	ExportWorkshop  string
-	WorkshopVersion string
-	RegistryFlags   imgpkgcmd.RegistryFlags
-	DataValuesFlags yttcmd.DataValuesFlags
+	training.PublishOptions
+	ImageVersion string
+	Scan         bool
+	Scanner      string
+	ScanSeverity string
+	ScanWarnOnly bool
+	MaxLayerSize string
 }
 
-func (o *FilesPublishOptions) Run(args []string) error {
+func (o *FilesPublishOptions) Run(ctx context.Context, args []string) error {
 	var err error
 
 	var directory string
@@ -53,242 +41,73 @@ func (o *FilesPublishOptions) Run(args []string) error {
 		return errors.Wrap(err, "couldn't convert workshop directory to absolute path")
 	}
 
-	fileInfo, err := os.Stat(directory)
-
-	if err != nil || !fileInfo.IsDir() {
-		return errors.New("workshop directory does not exist or path is not a directory")
-	}
+	if o.MaxLayerSize != "" {
+		quantity, err := resource.ParseQuantity(o.MaxLayerSize)
 
-	if o.Repository == "localhost:5001" {
-		err = registry.DeployRegistry()
-
-		if err != nil {
-			return errors.Wrap(err, "failed to deploy registry")
+		if err != nil || quantity.Sign() < 0 {
+			return failures.NewValidationError(errors.Errorf("invalid layer size %q", o.MaxLayerSize), "give the size as a byte quantity such as 512Mi, or 0 for no limit")
 		}
-	}
-
-	return o.Publish(directory)
-}
-
-func (o *FilesPublishOptions) Publish(directory string) error {
-	// If image name hasn't been supplied read workshop definition file and
-	// try to work out image name to publish workshop as.
-
-	rootDirectory := directory
-	workshopFilePath := o.WorkshopFile
-
-	workingDirectory, err := os.Getwd()
-
-	if err != nil {
-		return errors.Wrap(err, "cannot determine current working directory")
-	}
 
-	includePaths := []string{directory}
-	excludePaths := []string{".git"}
-
-	if !filepath.IsAbs(workshopFilePath) {
-		workshopFilePath = filepath.Join(rootDirectory, workshopFilePath)
-	}
-
-	workshopFileData, err := os.ReadFile(workshopFilePath)
-
-	if err != nil {
-		return errors.Wrapf(err, "cannot open workshop definition %q", workshopFilePath)
-	}
-
-	// Process the workshop YAML data for ytt templating and data variables.
-
-	if workshopFileData, err = processWorkshopDefinition(workshopFileData, o.DataValuesFlags); err != nil {
-		return errors.Wrap(err, "unable to process workshop definition as template")
-	}
-
-	workshopFileData = []byte(strings.ReplaceAll(string(workshopFileData), "$(image_repository)", o.Repository))
-	workshopFileData = []byte(strings.ReplaceAll(string(workshopFileData), "$(workshop_version)", o.WorkshopVersion))
-
-	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder()
-
-	workshop := &unstructured.Unstructured{}
-
-	err = runtime.DecodeInto(decoder, workshopFileData, workshop)
-
-	if err != nil {
-		return errors.Wrap(err, "couldn't parse workshop definition")
-	}
-
-	if workshop.GetAPIVersion() != "training.educates.dev/v1beta1" || workshop.GetKind() != "Workshop" {
-		return errors.New("invalid type for workshop definition")
+		o.LayerSize = quantity.Value()
 	}
 
-	image := o.Image
-
-	if image == "" {
-		image, _, _ = unstructured.NestedString(workshop.Object, "spec", "publish", "image")
+	if o.ParallelUploads < 1 {
+		return failures.NewValidationError(errors.Errorf("invalid number of parallel uploads %d", o.ParallelUploads), "")
 	}
 
-	if image == "" {
-		return errors.Errorf("cannot find image name for publishing workshop %q", workshopFilePath)
-	}
-
-	// Extract vendir snippet describing subset of files to package up as the
-	// workshop image.
-
-	confUI := ui.NewConfUI(ui.NewNoopLogger())
+	fileInfo, err := os.Stat(directory)
 
-	uiFlags := cmd.UIFlags{
-		Color:          true,
-		JSON:           false,
-		NonInteractive: true,
+	if err != nil || !fileInfo.IsDir() {
+		return failures.NewValidationError(errors.New("workshop directory does not exist or path is not a directory"), "")
 	}
 
-	uiFlags.ConfigureUI(confUI)
-
-	defer confUI.Flush()
+	// Scan the workshop image before publishing anything so that a workshop
+	// using an image with vulnerabilities isn't made available.
 
-	if fileArtifacts, found, _ := unstructured.NestedSlice(workshop.Object, "spec", "publish", "files"); found && len(fileArtifacts) != 0 {
-		tempDir, err := os.MkdirTemp("", "educates-imgpkg")
+	if o.Scan {
+		workshop, err := training.LoadWorkshopDefinition(&training.DefinitionOptions{
+			Path:            directory,
+			Portal:          "educates-cli",
+			WorkshopFile:    o.WorkshopFile,
+			WorkshopVersion: o.WorkshopVersion,
+			DataValuesFlags: o.DataValuesFlags,
+		})
 
 		if err != nil {
-			return errors.Wrapf(err, "unable to create temporary working directory")
-		}
-
-		defer os.RemoveAll(tempDir)
-
-		for _, artifactEntry := range fileArtifacts {
-			vendirConfig := map[string]interface{}{
-				"apiVersion":  "vendir.k14s.io/v1alpha1",
-				"kind":        "Config",
-				"directories": []interface{}{},
-			}
-
-			dir := filepath.Join(tempDir, "files")
-
-			if filePath, found := artifactEntry.(map[string]interface{})["path"].(string); found {
-				dir = filepath.Join(tempDir, "files", filepath.Clean(filePath))
-			}
-
-			if directoryConfig, found := artifactEntry.(map[string]interface{})["directory"]; found {
-				if directoryPath, found := directoryConfig.(map[string]interface{})["path"].(string); found {
-					if !filepath.IsAbs(directoryPath) {
-						directoryConfig.(map[string]interface{})["path"] = filepath.Join(directory, directoryPath)
-					}
-				}
-			}
-
-			artifactEntry.(map[string]interface{})["path"] = "."
-
-			directoryConfig := map[string]interface{}{
-				"path":     dir,
-				"contents": []interface{}{artifactEntry},
-			}
-
-			vendirConfig["directories"] = append(vendirConfig["directories"].([]interface{}), directoryConfig)
-
-			yamlData, err := yaml.Marshal(&vendirConfig)
-
-			if err != nil {
-				return errors.Wrap(err, "unable to generate vendir config")
-			}
-
-			vendirConfigFile, err := os.Create(filepath.Join(tempDir, "vendir.yml"))
-
-			if err != nil {
-				return errors.Wrap(err, "unable to create vendir config file")
-			}
-
-			defer vendirConfigFile.Close()
-
-			_, err = vendirConfigFile.Write(yamlData)
-
-			if err != nil {
-				return errors.Wrap(err, "unable to write vendir config file")
-			}
-
-			syncOptions := vendirsync.NewSyncOptions(confUI)
-
-			syncOptions.Directories = nil
-			syncOptions.Files = []string{filepath.Join(tempDir, "vendir.yml")}
-
-			// Note that Chdir here actually changes the process working directory.
-
-			syncOptions.LockFile = filepath.Join(tempDir, "lock-file")
-			syncOptions.Locked = false
-			syncOptions.Chdir = tempDir
-			syncOptions.AllowAllSymlinkDestinations = false
-
-			if err = syncOptions.Run(); err != nil {
-				fmt.Println(string(yamlData))
-
-				return errors.Wrap(err, "failed to prepare image files for publishing")
-			}
-		}
-
-		// Restore working directory as was changed.
-
-		os.Chdir((workingDirectory))
-
-		rootDirectory = filepath.Join(tempDir, "files")
-		includePaths = []string{rootDirectory}
-	}
-
-	// Now publish workshop directory contents as OCI image artifact.
-
-	pushOptions := imgpkgcmd.NewPushOptions(confUI)
-
-	pushOptions.ImageFlags.Image = image
-	pushOptions.FileFlags.Files = append(pushOptions.FileFlags.Files, includePaths...)
-	pushOptions.FileFlags.ExcludedFilePaths = append(pushOptions.FileFlags.ExcludedFilePaths, excludePaths...)
-
-	pushOptions.RegistryFlags = o.RegistryFlags
-
-	err = pushOptions.Run()
-
-	if err != nil {
-		return errors.Wrap(err, "unable to push image artifact for workshop")
-	}
-
-	// Export modified workshop definition file.
-
-	exportWorkshop := o.ExportWorkshop
-
-	if exportWorkshop != "" {
-		// Insert workshop version property if not specified.
-
-		_, found, _ := unstructured.NestedString(workshop.Object, "spec", "version")
-
-		if !found && o.WorkshopVersion != "latest" {
-			unstructured.SetNestedField(workshop.Object, o.WorkshopVersion, "spec", "version")
+			return err
 		}
 
-		// Remove the publish section as will not be accurate after publising.
-
-		unstructured.RemoveNestedField(workshop.Object, "spec", "publish")
-
-		workshopFileData, err = yaml.Marshal(&workshop.Object)
+		image, err := generateWorkshopImageName(workshop, o.Repository, o.ImageVersion, "", o.WorkshopVersion)
 
 		if err != nil {
-			return errors.Wrap(err, "couldn't convert workshop definition back to YAML")
+			return err
 		}
 
-		if !filepath.IsAbs(exportWorkshop) {
-			exportWorkshop = filepath.Join(workingDirectory, exportWorkshop)
+		if err = checkImageVulnerabilities(o.Scanner, image, o.ScanSeverity, o.ScanWarnOnly); err != nil {
+			return err
 		}
+	}
 
-		exportWorkshopFile, err := os.Create(exportWorkshop)
+	if o.Repository == "localhost:5001" {
+		err = registry.DeployRegistry(ctx)
 
 		if err != nil {
-			return errors.Wrap(err, "unable to create exported workshop definition file")
+			return errors.Wrap(err, "failed to deploy registry")
 		}
+	}
 
-		defer exportWorkshopFile.Close()
+	// Workshop instructions are built using the same workshop base image as
+	// workshop sessions use, so the version of Hugo and the theme match.
 
-		_, err = exportWorkshopFile.Write(workshopFileData)
+	if o.BuildContent {
+		o.ContentImage = fmt.Sprintf("ghcr.io/vmware-tanzu-labs/educates-base-environment:%s", o.ImageVersion)
 
-		if err != nil {
-			return errors.Wrap(err, "unable to write exported workshop definition file")
+		if o.ImageVersion == "latest" {
+			o.ContentImage = fmt.Sprintf("localhost:5001/educates-base-environment:%s", o.ImageVersion)
 		}
 	}
 
-	return nil
+	return o.Publish(ctx, directory)
 }
 
 func (p *ProjectInfo) NewWorkshopPublishCmd() *cobra.Command {
@@ -298,7 +117,53 @@ func (p *ProjectInfo) NewWorkshopPublishCmd() *cobra.Command {
 		Args:  cobra.MaximumNArgs(1),
 		Use:   "publish [PATH]",
 		Short: "Publish workshop files to repository",
-		RunE:  func(cmd *cobra.Command, args []string) error { return o.Run(args) },
+		Long: `Publish workshop files to repository.
+
+Packages up the files for a workshop as an OCI image artifact and pushes it
+to the image repository. The image name and the files to include are taken
+from the publish section of the workshop definition.
+
+For workshops using the Hugo renderer for instructions, --build-content can
+be used to build the instructions before publishing them, using the same
+workshop base image as workshop sessions use. Publishing fails if there are
+errors in building the instructions, so they are found before a class rather
+than when a user starts a workshop session. Only the generated files for the
+instructions are then published, in place of the Hugo sources, and workshop
+sessions use them as is rather than building the instructions on startup.
+Because the instructions are built outside of a workshop session, any data
+variables for the session used in the instructions will be empty.
+
+For workshops with instructions in more than one language, --locale can be
+used with --build-content to build the instructions for a locale. Pages for
+a locale are held in a directory alongside the default content directory
+named with the locale as suffix, such as "workshop/content.fr", with pages
+missing for the locale taken from the default content directory. Use
+--image to publish the workshop for each locale as a separate image.
+
+Using --scan, the workshop image is first scanned for vulnerabilities using
+"grype" or "trivy", and nothing is published if any vulnerabilities are found
+of the severity given by --scan-severity or higher. With --scan-warn-only, a
+warning is output instead and the workshop is still published.
+
+Files are uploaded to the registry in chunks, with the progress of each upload
+saved as it goes. Where uploading fails part way through, such as when the
+network connection drops, it is retried the number of times given by
+--registry-retry-count, continuing from where it got to. If publishing is
+interrupted, running the same command again resumes the upload, provided the
+workshop files haven't changed. Use --restart to discard what was uploaded
+before and start again. When all files are uploaded, the image manifest held
+by the registry is checked against that pushed.
+
+So that workshops bundling large files, such as virtual machine images or
+datasets, are practical to publish, files are split over more than one layer
+of the image where together they are larger than --layer-size, with a file
+larger than that in a layer by itself. Up to --parallel-uploads layers are
+compressed and uploaded at the same time, and layers the registry already
+has from an earlier publish aren't uploaded again. Workshop sessions
+download the files the same whether in one layer or many. Once published, a
+report of the number of files and size of each layer, and how much of it
+was uploaded, is output.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
 	}
 
 	c.Flags().StringVar(
@@ -332,6 +197,49 @@ func (p *ProjectInfo) NewWorkshopPublishCmd() *cobra.Command {
 		"latest",
 		"version of the workshop being published",
 	)
+	c.Flags().BoolVar(
+		&o.BuildContent,
+		"build-content",
+		false,
+		"build workshop instructions using Hugo and publish only the generated files",
+	)
+	c.Flags().StringVar(
+		&o.Locale,
+		"locale",
+		"",
+		"locale to build the workshop instructions for",
+	)
+	c.Flags().StringVar(
+		&o.ImageVersion,
+		"image-version",
+		p.Version,
+		"version of workshop base image used to build workshop instructions",
+	)
+
+	c.Flags().BoolVar(
+		&o.Scan,
+		"scan",
+		false,
+		"scan the workshop image for vulnerabilities before publishing",
+	)
+	c.Flags().StringVar(
+		&o.Scanner,
+		"scanner",
+		"grype",
+		"image scanner to use, one of grype or trivy",
+	)
+	c.Flags().StringVar(
+		&o.ScanSeverity,
+		"scan-severity",
+		"high",
+		"lowest severity of vulnerability which prevents publishing",
+	)
+	c.Flags().BoolVar(
+		&o.ScanWarnOnly,
+		"scan-warn-only",
+		false,
+		"warn about vulnerabilities found rather than failing",
+	)
 
 	c.Flags().StringSliceVar(
 		&o.RegistryFlags.CACertPaths,
@@ -387,7 +295,25 @@ func (p *ProjectInfo) NewWorkshopPublishCmd() *cobra.Command {
 		&o.RegistryFlags.RetryCount,
 		"registry-retry-count",
 		5,
-		"Set the number of times imgpkg retries to send requests to the registry in case of an error",
+		"Set the number of times to retry pushing to the registry in case of an error",
+	)
+	c.Flags().BoolVar(
+		&o.Restart,
+		"restart",
+		false,
+		"discard progress of an interrupted publish and upload all files again",
+	)
+	c.Flags().StringVar(
+		&o.MaxLayerSize,
+		"layer-size",
+		"512Mi",
+		"size above which workshop files are split over more than one layer, or 0 for no limit",
+	)
+	c.Flags().IntVar(
+		&o.ParallelUploads,
+		"parallel-uploads",
+		4,
+		"maximum number of layers to upload at the same time",
 	)
 
 	c.Flags().StringArrayVar(
@@ -428,37 +354,5 @@ func (p *ProjectInfo) NewWorkshopPublishCmd() *cobra.Command {
 		"Set multiple data values via plain YAML files (format: [@lib1:]{file path, HTTP URL, or '-' (i.e. stdin)}) (can be specified multiple times)",
 	)
 
-	return c
-}
-
-func processWorkshopDefinition(yamlData []byte, dataValueFlags yttcmd.DataValuesFlags) ([]byte, error) {
-	templatingOptions := yttcmd.NewOptions()
-
-	templatingOptions.IgnoreUnknownComments = true
-
-	templatingOptions.DataValuesFlags = dataValueFlags
-
-	var filesToProcess []*files.File
-
-	mainInputFile := files.MustNewFileFromSource(files.NewBytesSource("workshop.yaml", yamlData))
-
-	filesToProcess = append(filesToProcess, mainInputFile)
-
-	logUI := yttcmdui.NewCustomWriterTTY(false, log.Writer(), log.Writer())
-
-	output := templatingOptions.RunWithFiles(yttcmd.Input{Files: filesToProcess}, logUI)
-
-	if output.Err != nil {
-		return []byte{}, fmt.Errorf("execution of ytt failed: %s", output.Err)
-	}
-
-	if len(output.DocSet.Items) == 0 {
-		return []byte{}, nil
-	}
-
-	var buf bytes.Buffer
-
-	yamlmeta.NewYAMLPrinter(&buf).Print(output.DocSet.Items[0])
-
-	return buf.Bytes(), nil
+	return withAuditLogging(c)
 }
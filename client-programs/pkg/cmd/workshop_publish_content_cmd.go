@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/kubectl/pkg/scheme"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/renderer"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/training"
+)
+
+type WorkshopPublishContentOptions struct {
+	Target       string
+	BaseURL      string
+	WorkshopFile string
+	ImageVersion string
+	Locale       string
+	SkipUpdate   bool
+}
+
+func (o *WorkshopPublishContentOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	var directory string
+
+	if len(args) != 0 {
+		directory = filepath.Clean(args[0])
+	} else {
+		directory = "."
+	}
+
+	if directory, err = filepath.Abs(directory); err != nil {
+		return errors.Wrap(err, "couldn't convert workshop directory to absolute path")
+	}
+
+	fileInfo, err := os.Stat(directory)
+
+	if err != nil || !fileInfo.IsDir() {
+		return failures.NewValidationError(errors.New("workshop directory does not exist or path is not a directory"), "")
+	}
+
+	host, err := training.NewContentHost(o.Target)
+
+	if err != nil {
+		return err
+	}
+
+	baseURL := strings.TrimSuffix(o.BaseURL, "/")
+
+	if baseURL == "" {
+		baseURL = host.BaseURL()
+	} else if parsed, err := url.Parse(baseURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return failures.NewValidationError(errors.Errorf("invalid base URL %q", o.BaseURL), "base URL must be an http or https URL")
+	}
+
+	workshopDirectory := filepath.Join(directory, "workshop")
+
+	if _, err := os.Stat(filepath.Join(workshopDirectory, "config.yaml")); err != nil {
+		return failures.NewValidationError(errors.New("workshop instructions are not rendered using Hugo"), "only Hugo content can be published to static hosting")
+	}
+
+	workshopFilePath := o.WorkshopFile
+
+	if !filepath.IsAbs(workshopFilePath) {
+		workshopFilePath = filepath.Join(directory, workshopFilePath)
+	}
+
+	workshopFileInfo, err := os.Stat(workshopFilePath)
+
+	if err != nil {
+		return errors.Wrapf(err, "cannot open workshop definition %q", workshopFilePath)
+	}
+
+	workshopFileData, err := os.ReadFile(workshopFilePath)
+
+	if err != nil {
+		return errors.Wrapf(err, "cannot open workshop definition %q", workshopFilePath)
+	}
+
+	processedData, err := training.ProcessWorkshopDefinition(workshopFileData, yttcmd.DataValuesFlags{})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to process workshop definition as template")
+	}
+
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder()
+
+	workshop := &unstructured.Unstructured{}
+
+	if err = runtime.DecodeInto(decoder, processedData, workshop); err != nil {
+		return errors.Wrap(err, "couldn't parse workshop definition")
+	}
+
+	if workshop.GetAPIVersion() != "training.educates.dev/v1beta1" || workshop.GetKind() != "Workshop" {
+		return errors.New("invalid type for workshop definition")
+	}
+
+	// Workshop instructions are built using the same workshop base image as
+	// workshop sessions use, so the version of Hugo and the theme match.
+
+	image := fmt.Sprintf("ghcr.io/vmware-tanzu-labs/educates-base-environment:%s", o.ImageVersion)
+
+	if o.ImageVersion == "latest" {
+		image = fmt.Sprintf("localhost:5001/educates-base-environment:%s", o.ImageVersion)
+	}
+
+	// The instructions are uploaded under a directory named from a hash of
+	// the sources, so the URL for them is known before they are built and
+	// links in the generated files can be relative to it.
+
+	version, err := training.ContentVersion(workshopDirectory, image, o.Locale, baseURL)
+
+	if err != nil {
+		return err
+	}
+
+	contentURL := fmt.Sprintf("%s/%s/", baseURL, version)
+
+	outputDir, err := os.MkdirTemp("", "educates-content")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create directory for workshop instructions")
+	}
+
+	defer os.RemoveAll(outputDir)
+
+	title, _, _ := unstructured.NestedString(workshop.Object, "spec", "title")
+	description, _, _ := unstructured.NestedString(workshop.Object, "spec", "description")
+
+	if o.Locale != "" {
+		fmt.Printf("Building workshop instructions for locale %s using %s.\n", o.Locale, image)
+	} else {
+		fmt.Printf("Building workshop instructions using %s.\n", image)
+	}
+
+	if err = renderer.BuildHugoContent(ctx, workshopDirectory, outputDir, image, title, description, o.Locale, contentURL); err != nil {
+		return err
+	}
+
+	fmt.Printf("Uploading workshop instructions to %s.\n", host)
+
+	uploaded, err := host.Upload(ctx, outputDir, version)
+
+	if err != nil {
+		return err
+	}
+
+	if !uploaded {
+		fmt.Printf("Workshop instructions %s were already uploaded.\n", version)
+	}
+
+	fmt.Printf("Workshop instructions available at %s\n", contentURL)
+
+	if o.SkipUpdate {
+		return nil
+	}
+
+	// Update the workshop definition so the workshop dashboard loads the
+	// instructions from the static hosting.
+
+	updatedData, err := updateWorkshopDefinition(workshopFileData, func(root *yaml.Node) {
+		session := yamlMappingEntry(yamlMappingEntry(root, "spec"), "session")
+		workshop := yamlMappingEntry(yamlMappingEntry(session, "applications"), "workshop")
+
+		yamlSetString(workshop, "url", contentURL)
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update workshop definition %q", workshopFilePath)
+	}
+
+	if !bytes.Equal(updatedData, workshopFileData) {
+		if err = os.WriteFile(workshopFilePath, updatedData, workshopFileInfo.Mode()); err != nil {
+			return errors.Wrapf(err, "unable to write workshop definition %q", workshopFilePath)
+		}
+
+		fmt.Printf("Updated workshop instructions URL in %s\n", workshopFilePath)
+	}
+
+	return nil
+}
+
+func (p *ProjectInfo) NewWorkshopPublishContentCmd() *cobra.Command {
+	var o WorkshopPublishContentOptions
+
+	var c = &cobra.Command{
+		Args:  cobra.MaximumNArgs(1),
+		Use:   "publish-content [PATH]",
+		Short: "Publish workshop instructions to static hosting",
+		Long: `Publish workshop instructions to static hosting.
+
+Builds the instructions for a workshop using the Hugo renderer, in the same
+way as "educates workshop publish --build-content", and uploads the generated
+files to static hosting given by --target. The workshop definition is then
+updated so the workshop dashboard loads the instructions from the static
+hosting rather than from the workshop session. For very large classes this
+offloads serving the instructions from the cluster, and allows a CDN to be
+placed in front of them.
+
+Supported targets are S3 buckets, given as s3://BUCKET/PREFIX, Google Cloud
+Storage buckets, given as gs://BUCKET/PREFIX, and GitHub Pages, given as
+pages://OWNER/REPO/PREFIX. For S3, the region and an endpoint for S3
+compatible storage can be given by appending "?region=" and "?endpoint=" to
+the target. For GitHub Pages, the files are committed and pushed to the
+"gh-pages" branch, or that given by appending "?branch=" to the target, and
+the repository can be given by appending "?remote=". Credentials are those
+used by the AWS CLI, the Google Cloud SDK and git respectively, and the files
+must be publicly readable once uploaded.
+
+The files are uploaded under a directory named from a hash of the workshop
+instructions and how they were built. Files are never changed once uploaded,
+so they are marked as able to be cached forever, and a new version of the
+instructions is published alongside rather than replacing the old version,
+leaving workshop sessions already running unaffected. Nothing is uploaded if
+the same version was already published. Use --base-url where the files are
+served from a different URL to that of the target, such as through a CDN.
+
+Because the instructions are built outside of a workshop session, any data
+variables for the session used in the instructions will be empty.`,
+		RunE: func(cmd *cobra.Command, args []string) error { return o.Run(cmd.Context(), args) },
+	}
+
+	c.Flags().StringVar(
+		&o.Target,
+		"target",
+		"",
+		"static hosting to upload the workshop instructions to",
+	)
+	c.Flags().StringVar(
+		&o.BaseURL,
+		"base-url",
+		"",
+		"URL the uploaded files are served from, if not that of the target",
+	)
+	c.Flags().StringVar(
+		&o.WorkshopFile,
+		"workshop-file",
+		"resources/workshop.yaml",
+		"location of the workshop definition file",
+	)
+	c.Flags().StringVar(
+		&o.ImageVersion,
+		"image-version",
+		p.Version,
+		"version of workshop base image used to build workshop instructions",
+	)
+	c.Flags().StringVar(
+		&o.Locale,
+		"locale",
+		"",
+		"locale to build the workshop instructions for",
+	)
+	c.Flags().BoolVar(
+		&o.SkipUpdate,
+		"skip-update",
+		false,
+		"don't update the workshop instructions URL in the workshop definition",
+	)
+
+	c.MarkFlagRequired("target")
+
+	return withAuditLogging(c)
+}
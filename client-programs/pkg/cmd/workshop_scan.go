@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Severities of vulnerabilities reported by image scanners, from least to most
+severe. Severities reported by a scanner are matched ignoring case.
+*/
+var vulnerabilitySeverities = []string{"unknown", "negligible", "low", "medium", "high", "critical"}
+
+/*
+A vulnerability found in an image by an image scanner.
+*/
+type imageVulnerability struct {
+	ID       string
+	Severity string
+	Package  string
+	Version  string
+}
+
+/*
+Return the rank of a severity, with higher being more severe, or -1 if the
+severity isn't known.
+*/
+func severityRank(severity string) int {
+	severity = strings.ToLower(severity)
+
+	for i, name := range vulnerabilitySeverities {
+		if name == severity {
+			return i
+		}
+	}
+
+	return -1
+}
+
+/*
+Scan an image for vulnerabilities using either "grype" or "trivy".
+*/
+func scanImageVulnerabilities(scanner string, image string) ([]imageVulnerability, error) {
+	var args []string
+
+	switch scanner {
+	case "grype":
+		args = []string{image, "--output", "json", "--quiet"}
+	case "trivy":
+		args = []string{"image", "--format", "json", "--quiet", image}
+	default:
+		return nil, failures.NewValidationError(errors.Errorf("unsupported image scanner %q", scanner), "supported scanners are grype and trivy")
+	}
+
+	var stdout bytes.Buffer
+
+	scanCommand := exec.Command(scanner, args...)
+
+	scanCommand.Stdout = &stdout
+	scanCommand.Stderr = os.Stderr
+
+	if err := scanCommand.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, failures.NewValidationError(errors.Errorf("the %s command is required to scan images", scanner), "install the image scanner or select a different one using --scanner")
+		}
+
+		return nil, errors.Wrapf(err, "unable to scan image %s", image)
+	}
+
+	var vulnerabilities []imageVulnerability
+
+	if scanner == "grype" {
+		var report struct {
+			Matches []struct {
+				Vulnerability struct {
+					ID       string `json:"id"`
+					Severity string `json:"severity"`
+				} `json:"vulnerability"`
+				Artifact struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"artifact"`
+			} `json:"matches"`
+		}
+
+		if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+			return nil, errors.Wrapf(err, "unable to decode scan results for image %s", image)
+		}
+
+		for _, match := range report.Matches {
+			vulnerabilities = append(vulnerabilities, imageVulnerability{
+				ID:       match.Vulnerability.ID,
+				Severity: strings.ToLower(match.Vulnerability.Severity),
+				Package:  match.Artifact.Name,
+				Version:  match.Artifact.Version,
+			})
+		}
+	} else {
+		var report struct {
+			Results []struct {
+				Vulnerabilities []struct {
+					VulnerabilityID  string `json:"VulnerabilityID"`
+					Severity         string `json:"Severity"`
+					PkgName          string `json:"PkgName"`
+					InstalledVersion string `json:"InstalledVersion"`
+				} `json:"Vulnerabilities"`
+			} `json:"Results"`
+		}
+
+		if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+			return nil, errors.Wrapf(err, "unable to decode scan results for image %s", image)
+		}
+
+		for _, result := range report.Results {
+			for _, vulnerability := range result.Vulnerabilities {
+				vulnerabilities = append(vulnerabilities, imageVulnerability{
+					ID:       vulnerability.VulnerabilityID,
+					Severity: strings.ToLower(vulnerability.Severity),
+					Package:  vulnerability.PkgName,
+					Version:  vulnerability.InstalledVersion,
+				})
+			}
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+/*
+Scan an image for vulnerabilities and report a count of those found for each
+severity, listing those at or above the severity threshold. An error is
+returned if any are at or above the threshold, unless only warning, in which
+case a warning is output instead.
+*/
+func checkImageVulnerabilities(scanner string, image string, threshold string, warnOnly bool) error {
+	if severityRank(threshold) < 0 {
+		return failures.NewValidationError(errors.Errorf("unsupported severity threshold %q", threshold), fmt.Sprintf("supported severities are %s", strings.Join(vulnerabilitySeverities, ", ")))
+	}
+
+	fmt.Printf("Scanning image %s using %s\n", image, scanner)
+
+	vulnerabilities, err := scanImageVulnerabilities(scanner, image)
+
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+
+	var blocking []imageVulnerability
+
+	for _, vulnerability := range vulnerabilities {
+		counts[vulnerability.Severity]++
+
+		if severityRank(vulnerability.Severity) >= severityRank(threshold) {
+			blocking = append(blocking, vulnerability)
+		}
+	}
+
+	var summary []string
+
+	for i := len(vulnerabilitySeverities) - 1; i >= 0; i-- {
+		if count := counts[vulnerabilitySeverities[i]]; count != 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", count, vulnerabilitySeverities[i]))
+		}
+	}
+
+	if len(summary) == 0 {
+		fmt.Println("No vulnerabilities found.")
+
+		return nil
+	}
+
+	fmt.Printf("Vulnerabilities found: %s.\n", strings.Join(summary, ", "))
+
+	if len(blocking) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(blocking, func(i, j int) bool {
+		return severityRank(blocking[i].Severity) > severityRank(blocking[j].Severity)
+	})
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 8, 8, 3, ' ', 0)
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "ID", "SEVERITY", "PACKAGE", "VERSION")
+
+	for _, vulnerability := range blocking {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", vulnerability.ID, vulnerability.Severity, vulnerability.Package, vulnerability.Version)
+	}
+
+	w.Flush()
+
+	if warnOnly {
+		fmt.Fprintf(os.Stderr, "Warning: image %s has %d vulnerabilities of %s severity or higher.\n", image, len(blocking), threshold)
+
+		return nil
+	}
+
+	return failures.NewValidationError(errors.Errorf("image %s has %d vulnerabilities of %s severity or higher", image, len(blocking), threshold), "update the workshop image, raise the threshold using --scan-severity, or use --scan-warn-only")
+}
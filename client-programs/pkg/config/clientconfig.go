@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/credentials"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/hooks"
+)
+
+/*
+Settings for the behaviour of the CLI itself, as distinct from the settings
+for installing Educates into a cluster.
+*/
+type ClientConfig struct {
+	CACertificates         []string           `yaml:"caCertificates,omitempty"`
+	WorkshopCACertificates []string           `yaml:"workshopCACertificates,omitempty"`
+	Credentials            credentials.Config `yaml:"credentials,omitempty"`
+	Hooks                  []hooks.Config     `yaml:"hooks,omitempty"`
+	ShortLinks             ShortLinksConfig   `yaml:"shortLinks,omitempty"`
+	Telemetry              TelemetryConfig    `yaml:"telemetry,omitempty"`
+	Defaults               DefaultsConfig     `yaml:"defaults,omitempty"`
+}
+
+/*
+Default values for command line flags, keyed by the path of the command with
+the words separated by dots, such as "cluster.workshop.deploy", and then by
+the name of the flag. Values are given as they would be on the command line.
+*/
+type DefaultsConfig map[string]map[string]string
+
+/*
+Settings for generating short links for URLs. Where no endpoint is given, a
+redirector deployed to the cluster is used instead. The endpoint can include
+"{url}" to mark where the URL to be shortened is inserted, otherwise it is
+appended as the "url" query string parameter. The short link is expected to
+be returned as the body of the response.
+*/
+type ShortLinksConfig struct {
+	Endpoint string `yaml:"endpoint,omitempty"`
+}
+
+/*
+Settings for recording usage of the CLI. Nothing is recorded unless enabled,
+and records are only kept locally unless exported to the endpoint given,
+which must accept the records as JSON in a POST request.
+*/
+type TelemetryConfig struct {
+	Enabled  bool   `yaml:"enabled,omitempty"`
+	Endpoint string `yaml:"endpoint,omitempty"`
+}
+
+func ClientConfigFile() string {
+	return filepath.Join(xdg.DataHome, "educates", "client.yaml")
+}
+
+/*
+Load the settings for the CLI. If the config file doesn't exist the settings
+are empty.
+*/
+func LoadClientConfig() (*ClientConfig, error) {
+	var config ClientConfig
+
+	data, err := os.ReadFile(ClientConfigFile())
+
+	if os.IsNotExist(err) {
+		return &config, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read client config file %s", ClientConfigFile())
+	}
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse client config file %s", ClientConfigFile())
+	}
+
+	return &config, nil
+}
+
+func SaveClientConfig(config *ClientConfig) error {
+	err := os.MkdirAll(filepath.Dir(ClientConfigFile()), os.ModePerm)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create config directory")
+	}
+
+	data, err := yaml.Marshal(config)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to generate client config")
+	}
+
+	err = os.WriteFile(ClientConfigFile(), data, 0644)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write client config file %s", ClientConfigFile())
+	}
+
+	return nil
+}
+
+/*
+Open the backend holding credentials managed by the CLI. Returns nil if no
+backend has been configured, in which case credentials are held in the config
+files they were supplied in.
+*/
+func OpenCredentialsStore() (credentials.Store, error) {
+	clientConfig, err := LoadClientConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.Open(clientConfig.Credentials)
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Settings for the training portal for an event. Any setting also used for
+training portals created implicitly when deploying a workshop defaults to
+the same value, with the maximum number of sessions otherwise defaulting to
+the combined capacity of the workshops.
+*/
+type EventPortalConfig struct {
+	Name                 string `yaml:"name,omitempty"`
+	Hostname             string `yaml:"hostname,omitempty"`
+	PortalDefaultsConfig `yaml:",inline"`
+}
+
+/*
+Settings for a workshop deployed for an event. The path can be a local
+workshop directory, a workshop definition file, or the URL for a workshop
+definition file. A relative path is relative to the directory holding the
+event manifest.
+*/
+type EventWorkshopConfig struct {
+	Name     string   `yaml:"name,omitempty"`
+	Path     string   `yaml:"path"`
+	Capacity uint     `yaml:"capacity,omitempty"`
+	Reserved uint     `yaml:"reserved,omitempty"`
+	Initial  uint     `yaml:"initial,omitempty"`
+	Expires  string   `yaml:"expires,omitempty"`
+	Overtime string   `yaml:"overtime,omitempty"`
+	Deadline string   `yaml:"deadline,omitempty"`
+	Orphaned string   `yaml:"orphaned,omitempty"`
+	Environ  []string `yaml:"env,omitempty"`
+}
+
+/*
+Access codes to be issued for an event. Codes expire at the end of the event
+where an end time is given, otherwise after the given duration. Where no
+output file is given the codes are written to stdout.
+*/
+type EventAccessCodesConfig struct {
+	Count   uint          `yaml:"count,omitempty"`
+	Expires time.Duration `yaml:"expires,omitempty"`
+	Output  string        `yaml:"output,omitempty"`
+}
+
+/*
+Window during which the workshops for an event are available from the
+training portal.
+*/
+type EventScheduleConfig struct {
+	Start string `yaml:"start,omitempty"`
+	End   string `yaml:"end,omitempty"`
+}
+
+/*
+An event describes everything needed for running a training day, being the
+training portal, the workshops hosted by it, access codes for attendees and
+when the workshops are available.
+*/
+type EventConfig struct {
+	Name        string                 `yaml:"name"`
+	Portal      EventPortalConfig      `yaml:"portal,omitempty"`
+	Schedule    EventScheduleConfig    `yaml:"schedule,omitempty"`
+	AccessCodes EventAccessCodesConfig `yaml:"accessCodes,omitempty"`
+	Workshops   []EventWorkshopConfig  `yaml:"workshops"`
+}
+
+/*
+Load an event manifest. Relative paths for workshops and the access codes
+output file are resolved against the directory holding the manifest.
+*/
+func LoadEventConfig(configFile string) (*EventConfig, error) {
+	data, err := os.ReadFile(configFile)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read event manifest %s", configFile)
+	}
+
+	event := &EventConfig{
+		Portal: EventPortalConfig{
+			PortalDefaultsConfig: *NewDefaultPortalDefaultsConfig(),
+		},
+		AccessCodes: EventAccessCodesConfig{
+			Expires: 8 * time.Hour,
+		},
+	}
+
+	// Maximum sessions for the training portal is only defaulted after
+	// parsing as it depends on the workshops.
+
+	event.Portal.Sessions.Maximum = 0
+
+	if err := yaml.Unmarshal(data, event); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse event manifest %s", configFile)
+	}
+
+	if err := event.validate(); err != nil {
+		return nil, failures.NewValidationError(errors.Wrapf(err, "invalid event manifest %s", configFile), "")
+	}
+
+	if event.Portal.Name == "" {
+		event.Portal.Name = event.Name
+	}
+
+	if event.Portal.Sessions.Maximum == 0 {
+		for _, workshop := range event.Workshops {
+			event.Portal.Sessions.Maximum += int64(workshop.Capacity)
+		}
+	}
+
+	directory := filepath.Dir(configFile)
+
+	for i, workshop := range event.Workshops {
+		if isLocalPath(workshop.Path) && !filepath.IsAbs(workshop.Path) {
+			event.Workshops[i].Path = filepath.Join(directory, workshop.Path)
+		}
+	}
+
+	if event.AccessCodes.Output != "" && !filepath.IsAbs(event.AccessCodes.Output) {
+		event.AccessCodes.Output = filepath.Join(directory, event.AccessCodes.Output)
+	}
+
+	return event, nil
+}
+
+func (e *EventConfig) validate() error {
+	if e.Name == "" {
+		return errors.New("event name must be supplied")
+	}
+
+	if len(e.Workshops) == 0 {
+		return errors.New("at least one workshop must be supplied")
+	}
+
+	for i := range e.Workshops {
+		workshop := &e.Workshops[i]
+
+		if workshop.Path == "" {
+			return errors.Errorf("path must be supplied for workshop %d", i+1)
+		}
+
+		if workshop.Capacity == 0 {
+			workshop.Capacity = 1
+		}
+
+		if workshop.Reserved > workshop.Capacity || workshop.Initial > workshop.Capacity {
+			return errors.Errorf("reserved and initial sessions for workshop %q cannot exceed its capacity", workshop.Path)
+		}
+	}
+
+	if e.AccessCodes.Count != 0 && e.AccessCodes.Expires <= 0 && e.Schedule.End == "" {
+		return errors.New("access codes must expire after a duration greater than zero")
+	}
+
+	return e.Portal.validate()
+}
+
+func isLocalPath(path string) bool {
+	return !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://")
+}
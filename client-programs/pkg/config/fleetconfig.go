@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const fleetHint = "run `educates cluster fleet list` to see available fleets"
+
+/*
+A fleet is a named set of kubeconfig contexts which commands can be applied to
+in one go, rather than needing to be run separately against each cluster.
+*/
+type FleetConfig struct {
+	Name     string   `yaml:"name"`
+	Contexts []string `yaml:"contexts"`
+}
+
+func fleetConfigDir() string {
+	return filepath.Join(xdg.DataHome, "educates", "fleets")
+}
+
+func fleetConfigFile(name string) string {
+	return filepath.Join(fleetConfigDir(), name+".yaml")
+}
+
+func LoadFleetConfig(name string) (*FleetConfig, error) {
+	data, err := os.ReadFile(fleetConfigFile(name))
+
+	if os.IsNotExist(err) {
+		return nil, failures.NewNotFoundError(errors.Errorf("no fleet named %q", name), fleetHint)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read fleet config file %s", fleetConfigFile(name))
+	}
+
+	var fleet FleetConfig
+
+	if err := yaml.Unmarshal(data, &fleet); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse fleet config file %s", fleetConfigFile(name))
+	}
+
+	fleet.Name = name
+
+	return &fleet, nil
+}
+
+func SaveFleetConfig(fleet *FleetConfig) error {
+	err := os.MkdirAll(fleetConfigDir(), os.ModePerm)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create fleets directory")
+	}
+
+	data, err := yaml.Marshal(fleet)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to generate fleet config")
+	}
+
+	err = os.WriteFile(fleetConfigFile(fleet.Name), data, 0644)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write fleet config file %s", fleetConfigFile(fleet.Name))
+	}
+
+	return nil
+}
+
+func DeleteFleetConfig(name string) error {
+	err := os.Remove(fleetConfigFile(name))
+
+	if os.IsNotExist(err) {
+		return failures.NewNotFoundError(errors.Errorf("no fleet named %q", name), fleetHint)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to delete fleet config file %s", fleetConfigFile(name))
+	}
+
+	return nil
+}
+
+func ListFleetConfigs() ([]*FleetConfig, error) {
+	files, err := os.ReadDir(fleetConfigDir())
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read fleets directory")
+	}
+
+	var fleets []*FleetConfig
+
+	for _, f := range files {
+		name := f.Name()
+
+		if f.IsDir() || !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+
+		fleet, err := LoadFleetConfig(strings.TrimSuffix(name, ".yaml"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		fleets = append(fleets, fleet)
+	}
+
+	sort.Slice(fleets, func(i, j int) bool { return fleets[i].Name < fleets[j].Name })
+
+	return fleets, nil
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"net"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -42,3 +44,77 @@ func HostIP() (string, error) {
 	}
 	return "", errors.New("are you connected to the network?")
 }
+
+/*
+Return the first global IPv6 address of the host. Link local addresses are
+skipped as they can't be used to access the host from elsewhere.
+*/
+func HostIPv6() (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return "", err
+		}
+		for _, addr := range addrs {
+			var ip net.IP
+			switch v := addr.(type) {
+			case *net.IPNet:
+				ip = v.IP
+			case *net.IPAddr:
+				ip = v.IP
+			}
+			if ip == nil || ip.To4() != nil || !ip.IsGlobalUnicast() {
+				continue
+			}
+			return ip.String(), nil
+		}
+	}
+	return "", errors.New("no IPv6 address found, are you connected to an IPv6 network?")
+}
+
+/*
+Return the IP address of the host to use for a cluster of the given IP
+family. Dual stack clusters use the IPv4 address.
+*/
+func HostIPForFamily(ipFamily string) (string, error) {
+	if ipFamily == IPFamilyIPv6 {
+		return HostIPv6()
+	}
+
+	return HostIP()
+}
+
+/*
+Return a wildcard DNS domain which resolves to an IP address. The nip.io
+service only handles IPv4 addresses, so sslip.io is used for IPv6 addresses,
+with the colons in the address replaced by dashes.
+*/
+func WildcardDomainForAddress(address string) string {
+	ip := net.ParseIP(address)
+
+	if ip == nil || ip.To4() != nil {
+		return fmt.Sprintf("%s.nip.io", address)
+	}
+
+	label := strings.ReplaceAll(ip.String(), ":", "-")
+
+	if strings.HasPrefix(label, "-") {
+		label = "0" + label
+	}
+
+	if strings.HasSuffix(label, "-") {
+		label = label + "0"
+	}
+
+	return fmt.Sprintf("%s.sslip.io", label)
+}
@@ -1,11 +1,8 @@
 package config
 
 import (
-	"fmt"
 	"os"
-	"path"
 
-	"github.com/adrg/xdg"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 )
@@ -16,8 +13,21 @@ type VolumeMountConfig struct {
 	ReadOnly      bool   `yaml:"readOnly,omitempty"`
 }
 
+const (
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
+	IPFamilyDual = "dual"
+)
+
+const (
+	IngressControllerContour  = "contour"
+	IngressControllerNginx    = "nginx"
+	IngressControllerExisting = "existing"
+)
+
 type LocalKindClusterConfig struct {
 	ListenAddress string              `yaml:"listenAddress,omitempty"`
+	IPFamily      string              `yaml:"ipFamily,omitempty"`
 	VolumeMounts  []VolumeMountConfig `yaml:"volumeMounts,omitempty"`
 }
 
@@ -38,6 +48,7 @@ type ClusterPackagesConfig struct {
 	Contour        PackageConfig `yaml:"contour"`
 	Kyverno        PackageConfig `yaml:"kyverno"`
 	MetaController PackageConfig `yaml:"metacontroller,omitempty"`
+	Nginx          PackageConfig `yaml:"nginx,omitempty"`
 }
 
 type TLSCertificateConfig struct {
@@ -88,6 +99,18 @@ type ClusterStorageConfig struct {
 	Group int    `yaml:"group,omitempty"`
 }
 
+type ServiceRefConfig struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+}
+
+type ClusterDNSConfig struct {
+	Provider   string           `yaml:"provider,omitempty"`
+	Zone       string           `yaml:"zone,omitempty"`
+	TTL        int              `yaml:"ttl,omitempty"`
+	ServiceRef ServiceRefConfig `yaml:"serviceRef,omitempty"`
+}
+
 type ClusterSecurityConfig struct {
 	PolicyEngine string `yaml:"policyEngine"`
 }
@@ -215,6 +238,7 @@ type TrainingPlatformConfig struct {
 	ClusterNetwork    ClusterNetworkConfig    `yaml:"clusterNetwork,omitempty"`
 	WorkshopAnalytics WorkshopAnalyticsConfig `yaml:"workshopAnalytics,omitempty"`
 	WebsiteStyling    WebsiteStylingConfig    `yaml:"websiteStyling,omitempty"`
+	FeatureGates      map[string]bool         `yaml:"featureGates,omitempty"`
 }
 
 type InstallationConfig struct {
@@ -225,6 +249,7 @@ type InstallationConfig struct {
 	ClusterSecurity       ClusterSecurityConfig       `yaml:"clusterSecurity,omitempty"`
 	ClusterRuntime        ClusterRuntimeConfig        `yaml:"clusterRuntime,omitempty"`
 	ClusterIngress        ClusterIngressConfig        `yaml:"clusterIngress,omitempty"`
+	ClusterDNS            ClusterDNSConfig            `yaml:"clusterDNS,omitempty"`
 	SessionCookies        SessionCookiesConfig        `yaml:"sessionCookies,omitempty"`
 	ClusterStorage        ClusterStorageConfig        `yaml:"clusterStorage,omitempty"`
 	ClusterSecrets        ClusterSecretsConfig        `yaml:"clusterSecrets,omitempty"`
@@ -236,6 +261,7 @@ type InstallationConfig struct {
 	ClusterNetwork        ClusterNetworkConfig        `yaml:"clusterNetwork,omitempty"`
 	WorkshopAnalytics     WorkshopAnalyticsConfig     `yaml:"workshopAnalytics,omitempty"`
 	WebsiteStyling        WebsiteStylingConfig        `yaml:"websiteStyling,omitempty"`
+	FeatureGates          map[string]bool             `yaml:"featureGates,omitempty"`
 }
 
 func NewDefaultInstallationConfig() *InstallationConfig {
@@ -261,7 +287,7 @@ func NewDefaultInstallationConfig() *InstallationConfig {
 			PolicyEngine: "kyverno",
 		},
 		ClusterIngress: ClusterIngressConfig{
-			Domain: fmt.Sprintf("%s.nip.io", localIPAddress),
+			Domain: WildcardDomainForAddress(localIPAddress),
 		},
 		WorkshopSecurity: WorkshopSecurityConfig{
 			RulesEngine: "kyverno",
@@ -269,6 +295,27 @@ func NewDefaultInstallationConfig() *InstallationConfig {
 	}
 }
 
+/*
+Load the local platform configuration from the default values file, falling
+back to the defaults if it doesn't exist. Credentials held in a credentials
+store are not resolved.
+*/
+func NewInstallationConfigFromDefaultValues() (*InstallationConfig, error) {
+	config := NewDefaultInstallationConfig()
+
+	valuesFile := DefaultValuesFile()
+
+	data, err := os.ReadFile(valuesFile)
+
+	if err == nil && len(data) != 0 {
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse default config file %s", valuesFile)
+		}
+	}
+
+	return config, nil
+}
+
 func NewInstallationConfigFromFile(configFile string) (*InstallationConfig, error) {
 	config := NewDefaultInstallationConfig()
 
@@ -283,17 +330,127 @@ func NewInstallationConfigFromFile(configFile string) (*InstallationConfig, erro
 			return nil, errors.Wrapf(err, "unable to parse installation config file %s", configFile)
 		}
 	} else {
-		configFileDir := path.Join(xdg.DataHome, "educates")
-		valuesFile := path.Join(configFileDir, "values.yaml")
+		var err error
 
-		data, err := os.ReadFile(valuesFile)
+		if config, err = NewInstallationConfigFromDefaultValues(); err != nil {
+			return nil, err
+		}
 
-		if err == nil && len(data) != 0 {
-			if err := yaml.Unmarshal(data, &config); err != nil {
-				return nil, errors.Wrapf(err, "unable to parse default config file %s", valuesFile)
-			}
+		if err := config.resolveStoredCredentials(); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.LocalKindCluster.IPFamily != "" {
+		if err := config.SetIPFamily(config.LocalKindCluster.IPFamily); err != nil {
+			return nil, err
+		}
+	}
+
+	// Contour is enabled by default, so where nginx has been enabled in the
+	// config file instead, make sure Contour is not also installed.
+
+	if config.ClusterPackages.Nginx.Enabled {
+		if err := config.SetIngressController(IngressControllerNginx, ""); err != nil {
+			return nil, err
 		}
 	}
 
 	return config, nil
 }
+
+/*
+Set the IP family used for networking in the local Kind cluster. For an IPv6
+only cluster, if the ingress domain is still the default generated from the
+IPv4 address of the host, it is replaced with one generated from the IPv6
+address of the host, as the IPv4 address can't be used to reach it.
+*/
+func (c *InstallationConfig) SetIPFamily(ipFamily string) error {
+	switch ipFamily {
+	case "", IPFamilyIPv4, IPFamilyIPv6, IPFamilyDual:
+	default:
+		return errors.Errorf("unknown IP family %q, must be ipv4, ipv6 or dual", ipFamily)
+	}
+
+	c.LocalKindCluster.IPFamily = ipFamily
+
+	if ipFamily != IPFamilyIPv6 {
+		return nil
+	}
+
+	localIPAddress, err := HostIP()
+
+	if err != nil {
+		localIPAddress = "127.0.0.1"
+	}
+
+	if c.ClusterIngress.Domain != WildcardDomainForAddress(localIPAddress) {
+		return nil
+	}
+
+	localIPv6Address, err := HostIPv6()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate ingress domain for IPv6 cluster")
+	}
+
+	c.ClusterIngress.Domain = WildcardDomainForAddress(localIPv6Address)
+
+	return nil
+}
+
+/*
+Return the ingress controller the installation is configured to use, being
+one installed with the cluster services, or an existing ingress controller
+when neither Contour or nginx are being installed.
+*/
+func (c *InstallationConfig) IngressController() string {
+	switch {
+	case c.ClusterPackages.Nginx.Enabled:
+		return IngressControllerNginx
+	case c.ClusterPackages.Contour.Enabled:
+		return IngressControllerContour
+	default:
+		return IngressControllerExisting
+	}
+}
+
+/*
+Set the ingress controller to use, enabling installation of only that ingress
+controller with the cluster services. For nginx the ingress class defaults to
+that of nginx, and the service for the load balancer used when managing DNS
+to that of nginx. For an existing ingress controller, neither is installed and
+the ingress class given, or the default ingress class for the cluster if none
+is given, is used. Where an ingress class is given it overrides that from the
+installation config.
+*/
+func (c *InstallationConfig) SetIngressController(controller string, ingressClass string) error {
+	switch controller {
+	case IngressControllerContour:
+		c.ClusterPackages.Contour.Enabled = true
+		c.ClusterPackages.Nginx.Enabled = false
+	case IngressControllerNginx:
+		c.ClusterPackages.Contour.Enabled = false
+		c.ClusterPackages.Nginx.Enabled = true
+
+		if ingressClass == "" && c.ClusterIngress.Class == "" {
+			ingressClass = "nginx"
+		}
+
+		if c.ClusterDNS.ServiceRef.Namespace == "" && c.ClusterDNS.ServiceRef.Name == "" {
+			c.ClusterDNS.ServiceRef.Namespace = "ingress-nginx"
+			c.ClusterDNS.ServiceRef.Name = "ingress-nginx-controller"
+		}
+	case IngressControllerExisting:
+		c.ClusterPackages.Contour.Enabled = false
+		c.ClusterPackages.Nginx.Enabled = false
+	default:
+		return errors.Errorf("unknown ingress controller %q, must be contour, nginx or existing", controller)
+	}
+
+	if ingressClass != "" {
+		c.ClusterIngress.Class = ingressClass
+	}
+
+	return nil
+}
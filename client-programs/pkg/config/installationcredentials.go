@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Credentials from the installation config are stored in the credentials
+// backend under this prefix, followed by their location in the config.
+
+const InstallationCredentialsPrefix = "installation/"
+
+func DefaultValuesFile() string {
+	return filepath.Join(xdg.DataHome, "educates", "values.yaml")
+}
+
+/*
+Return the credentials which can be held in the credentials backend rather
+than in the default installation config, keyed by their location in the
+config.
+*/
+func (c *InstallationConfig) Credentials() map[string]*string {
+	return map[string]*string{
+		"trainingPortal.credentials.admin.password": &c.TrainingPortal.Credentials.Admin.Password,
+		"trainingPortal.credentials.robot.password": &c.TrainingPortal.Credentials.Robot.Password,
+		"dockerDaemon.proxyCache.password":          &c.DockerDaemon.ProxyCache.Password,
+	}
+}
+
+/*
+Fill in any credentials not set in the default installation config from the
+credentials backend, where they were placed by `educates credentials migrate`.
+*/
+func (c *InstallationConfig) resolveStoredCredentials() error {
+	store, err := OpenCredentialsStore()
+
+	if err != nil || store == nil {
+		return err
+	}
+
+	for key, value := range c.Credentials() {
+		if *value != "" {
+			continue
+		}
+
+		stored, found, err := store.Get(InstallationCredentialsPrefix + key)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read %s from credentials backend", key)
+		}
+
+		if found {
+			*value = stored
+		}
+	}
+
+	return nil
+}
+
+/*
+Remove credentials from the default installation config file, so they are
+only held in the credentials backend. Other settings in the file are kept,
+but any comments in the file are lost.
+*/
+func RemoveDefaultValuesCredentials(keys []string) error {
+	data, err := os.ReadFile(DefaultValuesFile())
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to read default config file %s", DefaultValuesFile())
+	}
+
+	var values yaml.MapSlice
+
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return errors.Wrapf(err, "unable to parse default config file %s", DefaultValuesFile())
+	}
+
+	for _, key := range keys {
+		values = removeMapSliceKey(values, strings.Split(key, "."))
+	}
+
+	data, err = yaml.Marshal(values)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to generate default config file")
+	}
+
+	if err := os.WriteFile(DefaultValuesFile(), data, 0644); err != nil {
+		return errors.Wrapf(err, "unable to write default config file %s", DefaultValuesFile())
+	}
+
+	return nil
+}
+
+func removeMapSliceKey(values yaml.MapSlice, keys []string) yaml.MapSlice {
+	var result yaml.MapSlice
+
+	for _, item := range values {
+		if item.Key != keys[0] {
+			result = append(result, item)
+			continue
+		}
+
+		if len(keys) == 1 {
+			continue
+		}
+
+		if nested, ok := item.Value.(yaml.MapSlice); ok {
+			item.Value = removeMapSliceKey(nested, keys[1:])
+		}
+
+		result = append(result, item)
+	}
+
+	return result
+}
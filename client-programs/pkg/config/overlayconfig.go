@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+var overlayFileNamePattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+/*
+Directory holding ytt overlay files which are applied on top of the platform
+templates when the platform is installed or its configuration updated. As the
+directory is separate from the templates bundled with the platform, any
+customizations made in overlays are retained across upgrades.
+*/
+func PlatformOverlaysDir() string {
+	return filepath.Join(xdg.DataHome, "educates", "overlays")
+}
+
+/*
+Load the ytt overlay files for the platform, keyed by file name. Only files
+with a .yaml or .yml extension are used, with any other files and directories
+ignored. An empty map is returned if the directory does not exist.
+*/
+func LoadPlatformOverlays() (map[string][]byte, error) {
+	overlays := map[string][]byte{}
+
+	files, err := os.ReadDir(PlatformOverlaysDir())
+
+	if os.IsNotExist(err) {
+		return overlays, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read overlays directory %s", PlatformOverlaysDir())
+	}
+
+	for _, f := range files {
+		name := f.Name()
+
+		if f.IsDir() || !(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+			continue
+		}
+
+		// File names are used as keys in the secret holding the overlays.
+
+		if !overlayFileNamePattern.MatchString(name) {
+			return nil, failures.NewValidationError(errors.Errorf("invalid name for overlay file %q", name), "overlay file names may only contain letters, digits, '-', '_' and '.'")
+		}
+
+		data, err := os.ReadFile(filepath.Join(PlatformOverlaysDir(), name))
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read overlay file %s", name)
+		}
+
+		overlays[name] = data
+	}
+
+	return overlays, nil
+}
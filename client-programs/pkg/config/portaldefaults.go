@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const (
+	PasswordPolicyRandom = "random"
+	PasswordPolicyFixed  = "fixed"
+	PasswordPolicyNone   = "none"
+)
+
+type PortalRegistrationConfig struct {
+	Type string `yaml:"type,omitempty"`
+}
+
+type PortalSessionsConfig struct {
+	Maximum int64 `yaml:"maximum"`
+}
+
+type PortalPasswordConfig struct {
+	Policy string `yaml:"policy,omitempty"`
+	Value  string `yaml:"value,omitempty"`
+	Length int    `yaml:"length,omitempty"`
+}
+
+type PortalUpdatesConfig struct {
+	Workshop bool `yaml:"workshop"`
+}
+
+/*
+Settings used for a training portal when it is created implicitly as a side
+effect of deploying a workshop, rather than being created explicitly.
+*/
+type PortalDefaultsConfig struct {
+	Registration PortalRegistrationConfig `yaml:"registration,omitempty"`
+	Sessions     PortalSessionsConfig     `yaml:"sessions,omitempty"`
+	Password     PortalPasswordConfig     `yaml:"password,omitempty"`
+	Updates      PortalUpdatesConfig      `yaml:"updates,omitempty"`
+}
+
+func NewDefaultPortalDefaultsConfig() *PortalDefaultsConfig {
+	return &PortalDefaultsConfig{
+		Registration: PortalRegistrationConfig{
+			Type: "anonymous",
+		},
+		Sessions: PortalSessionsConfig{
+			Maximum: 1,
+		},
+		Password: PortalPasswordConfig{
+			Policy: PasswordPolicyRandom,
+			Length: 12,
+		},
+		Updates: PortalUpdatesConfig{
+			Workshop: true,
+		},
+	}
+}
+
+func PortalDefaultsConfigFile() string {
+	return filepath.Join(xdg.DataHome, "educates", "portal-defaults.yaml")
+}
+
+/*
+Load the defaults for implicitly created training portals. If no file is
+supplied the defaults are read from the user's local config if it exists.
+Any setting not given in the file retains its built-in default.
+*/
+func NewPortalDefaultsConfigFromFile(configFile string) (*PortalDefaultsConfig, error) {
+	config := NewDefaultPortalDefaultsConfig()
+
+	var data []byte
+	var err error
+
+	if configFile != "" {
+		data, err = os.ReadFile(configFile)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read portal defaults file %s", configFile)
+		}
+	} else {
+		configFile = PortalDefaultsConfigFile()
+
+		data, err = os.ReadFile(configFile)
+
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read portal defaults file %s", configFile)
+		}
+	}
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse portal defaults file %s", configFile)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, failures.NewValidationError(errors.Wrapf(err, "invalid portal defaults file %s", configFile), "")
+	}
+
+	return config, nil
+}
+
+func (c *PortalDefaultsConfig) validate() error {
+	switch c.Registration.Type {
+	case "anonymous", "one-step":
+	default:
+		return errors.Errorf("unknown registration type %q, must be anonymous or one-step", c.Registration.Type)
+	}
+
+	if c.Sessions.Maximum < 0 {
+		return errors.New("maximum sessions cannot be negative")
+	}
+
+	switch c.Password.Policy {
+	case PasswordPolicyRandom:
+		if c.Password.Length <= 0 {
+			return errors.New("password length must be greater than zero")
+		}
+	case PasswordPolicyFixed:
+		if c.Password.Value == "" {
+			return errors.New("password value must be supplied for fixed password policy")
+		}
+	case PasswordPolicyNone:
+	default:
+		return errors.Errorf("unknown password policy %q, must be random, fixed or none", c.Password.Policy)
+	}
+
+	return nil
+}
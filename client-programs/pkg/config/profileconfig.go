@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const profileHint = "run `educates cloud list` to see available profiles"
+
+/*
+A profile records a managed Kubernetes cluster created with a cloud provider
+for running Educates, so the cluster can be targeted by name and deleted when
+no longer required. The kubeconfig file for accessing the cluster is kept
+alongside the profile.
+*/
+type ProfileConfig struct {
+	Name       string `yaml:"name"`
+	Provider   string `yaml:"provider"`
+	Region     string `yaml:"region"`
+	Cluster    string `yaml:"cluster"`
+	Size       string `yaml:"size,omitempty"`
+	Domain     string `yaml:"domain,omitempty"`
+	Kubeconfig string `yaml:"kubeconfig"`
+}
+
+func profileConfigDir() string {
+	return filepath.Join(xdg.DataHome, "educates", "profiles")
+}
+
+func profileConfigFile(name string) string {
+	return filepath.Join(profileConfigDir(), name+".yaml")
+}
+
+/*
+Location of the kubeconfig file for accessing the cluster for a profile.
+*/
+func ProfileKubeconfigFile(name string) string {
+	return filepath.Join(profileConfigDir(), name+".kubeconfig")
+}
+
+func LoadProfileConfig(name string) (*ProfileConfig, error) {
+	data, err := os.ReadFile(profileConfigFile(name))
+
+	if os.IsNotExist(err) {
+		return nil, failures.NewNotFoundError(errors.Errorf("no profile named %q", name), profileHint)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read profile config file %s", profileConfigFile(name))
+	}
+
+	var profile ProfileConfig
+
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse profile config file %s", profileConfigFile(name))
+	}
+
+	profile.Name = name
+
+	return &profile, nil
+}
+
+func SaveProfileConfig(profile *ProfileConfig) error {
+	err := os.MkdirAll(profileConfigDir(), os.ModePerm)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create profiles directory")
+	}
+
+	data, err := yaml.Marshal(profile)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to generate profile config")
+	}
+
+	err = os.WriteFile(profileConfigFile(profile.Name), data, 0644)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write profile config file %s", profileConfigFile(profile.Name))
+	}
+
+	return nil
+}
+
+func DeleteProfileConfig(name string) error {
+	err := os.Remove(profileConfigFile(name))
+
+	if os.IsNotExist(err) {
+		return failures.NewNotFoundError(errors.Errorf("no profile named %q", name), profileHint)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to delete profile config file %s", profileConfigFile(name))
+	}
+
+	return nil
+}
+
+func ListProfileConfigs() ([]*ProfileConfig, error) {
+	files, err := os.ReadDir(profileConfigDir())
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read profiles directory")
+	}
+
+	var profiles []*ProfileConfig
+
+	for _, f := range files {
+		name := f.Name()
+
+		if f.IsDir() || !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+
+		profile, err := LoadProfileConfig(strings.TrimSuffix(name, ".yaml"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+
+	return profiles, nil
+}
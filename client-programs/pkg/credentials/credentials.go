@@ -0,0 +1,96 @@
+/*
+Storage for credentials managed by the CLI, such as training portal passwords
+and image registry credentials, so they can be held in an OS keychain, Vault
+or an encrypted file rather than in plaintext config files.
+*/
+package credentials
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const (
+	BackendFile     = "file"
+	BackendKeychain = "keychain"
+	BackendVault    = "vault"
+	BackendSOPS     = "sops"
+)
+
+var Backends = []string{BackendFile, BackendKeychain, BackendVault, BackendSOPS}
+
+/*
+Settings for where credentials are stored, held in the client config file. If
+no backend is set credentials are left in the config files they were supplied
+in, as was always done.
+*/
+type Config struct {
+	Backend string      `yaml:"backend,omitempty"`
+	Vault   VaultConfig `yaml:"vault,omitempty"`
+	SOPS    SOPSConfig  `yaml:"sops,omitempty"`
+}
+
+type VaultConfig struct {
+	Address string `yaml:"address,omitempty"`
+	Mount   string `yaml:"mount,omitempty"`
+	Path    string `yaml:"path,omitempty"`
+}
+
+type SOPSConfig struct {
+	File string `yaml:"file,omitempty"`
+}
+
+/*
+Whether a backend has been configured for holding credentials.
+*/
+func (c *Config) Enabled() bool {
+	return c.Backend != ""
+}
+
+/*
+A backend holding credentials. Keys are slash separated paths, such as
+"secret/registry" or "installation/trainingPortal.credentials.admin.password".
+*/
+type Store interface {
+	Name() string
+	Get(key string) (string, bool, error)
+	Set(key string, value string) error
+	Delete(key string) error
+	List() ([]string, error)
+}
+
+/*
+Open the backend for holding credentials given by the config. Returns nil if
+no backend has been configured.
+*/
+func Open(config Config) (Store, error) {
+	switch config.Backend {
+	case "":
+		return nil, nil
+	case BackendFile:
+		return newFileStore(), nil
+	case BackendKeychain:
+		return newKeychainStore()
+	case BackendVault:
+		return newVaultStore(config.Vault)
+	case BackendSOPS:
+		return newSOPSStore(config.SOPS)
+	}
+
+	return nil, failures.NewValidationError(errors.Errorf("unknown credentials backend %q", config.Backend), "backend must be one of file, keychain, vault or sops")
+}
+
+func sortedKeys(values map[string]string) []string {
+	var keys []string
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
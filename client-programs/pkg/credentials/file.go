@@ -0,0 +1,115 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+/*
+Credentials held in a single file readable only by the user. This doesn't
+protect the credentials any more than the config files would, but keeps them
+in one place, separate from settings which may be shared.
+*/
+type fileStore struct {
+	file string
+}
+
+func newFileStore() *fileStore {
+	return &fileStore{file: filepath.Join(xdg.DataHome, "educates", "credentials.yaml")}
+}
+
+func (s *fileStore) Name() string {
+	return BackendFile
+}
+
+func (s *fileStore) load() (map[string]string, error) {
+	values := map[string]string{}
+
+	data, err := os.ReadFile(s.file)
+
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read credentials file %s", s.file)
+	}
+
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse credentials file %s", s.file)
+	}
+
+	return values, nil
+}
+
+func (s *fileStore) save(values map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(s.file), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "unable to create config directory")
+	}
+
+	data, err := yaml.Marshal(values)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate credentials file")
+	}
+
+	if err := os.WriteFile(s.file, data, 0600); err != nil {
+		return errors.Wrapf(err, "unable to write credentials file %s", s.file)
+	}
+
+	return nil
+}
+
+func (s *fileStore) Get(key string) (string, bool, error) {
+	values, err := s.load()
+
+	if err != nil {
+		return "", false, err
+	}
+
+	value, found := values[key]
+
+	return value, found, nil
+}
+
+func (s *fileStore) Set(key string, value string) error {
+	values, err := s.load()
+
+	if err != nil {
+		return err
+	}
+
+	values[key] = value
+
+	return s.save(values)
+}
+
+func (s *fileStore) Delete(key string) error {
+	values, err := s.load()
+
+	if err != nil {
+		return err
+	}
+
+	if _, found := values[key]; !found {
+		return nil
+	}
+
+	delete(values, key)
+
+	return s.save(values)
+}
+
+func (s *fileStore) List() ([]string, error) {
+	values, err := s.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedKeys(values), nil
+}
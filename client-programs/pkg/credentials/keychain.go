@@ -0,0 +1,223 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const keychainService = "educates"
+
+// The keychain tools can't enumerate entries for a service, so the keys of
+// the credentials stored are recorded in a separate entry.
+
+const keychainIndexKey = ".index"
+
+/*
+Credentials held in the OS keychain, using the "security" command on macOS
+and "secret-tool" from libsecret on Linux. Values are base64 encoded so that
+credentials such as JSON documents survive being passed to the commands.
+*/
+type keychainStore struct {
+	tool string
+}
+
+func newKeychainStore() (*keychainStore, error) {
+	var tool string
+
+	switch runtime.GOOS {
+	case "darwin":
+		tool = "security"
+	case "linux":
+		tool = "secret-tool"
+	default:
+		return nil, failures.NewValidationError(errors.Errorf("keychain credentials backend is not supported on %s", runtime.GOOS), "use the vault or sops credentials backend instead")
+	}
+
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil, failures.NewValidationError(errors.Errorf("%s command required for keychain credentials backend not found", tool), "")
+	}
+
+	return &keychainStore{tool: tool}, nil
+}
+
+func (s *keychainStore) Name() string {
+	return BackendKeychain
+}
+
+func (s *keychainStore) run(stdin string, args ...string) (string, int, error) {
+	var stdout, stderr bytes.Buffer
+
+	command := exec.Command(s.tool, args...)
+
+	command.Stdin = strings.NewReader(stdin)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	err := command.Run()
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return stdout.String(), exitErr.ExitCode(), errors.Errorf("%s failed: %s", s.tool, strings.TrimSpace(stderr.String()))
+	}
+
+	if err != nil {
+		return "", -1, errors.Wrapf(err, "unable to run %s", s.tool)
+	}
+
+	return stdout.String(), 0, nil
+}
+
+func (s *keychainStore) read(key string) (string, bool, error) {
+	var output string
+	var status int
+	var err error
+
+	if s.tool == "security" {
+		output, status, err = s.run("", "find-generic-password", "-s", keychainService, "-a", key, "-w")
+
+		// Exit status 44 is errSecItemNotFound.
+
+		if status == 44 {
+			return "", false, nil
+		}
+	} else {
+		output, status, err = s.run("", "lookup", "service", keychainService, "key", key)
+
+		if status == 1 && output == "" {
+			return "", false, nil
+		}
+	}
+
+	if err != nil {
+		return "", false, errors.Wrapf(err, "unable to read credential %q from keychain", key)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(output))
+
+	if err != nil {
+		return "", false, errors.Wrapf(err, "unable to decode credential %q from keychain", key)
+	}
+
+	return string(value), true, nil
+}
+
+func (s *keychainStore) write(key string, value string) error {
+	var err error
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(value))
+
+	if s.tool == "security" {
+		// Pass the command on stdin using interactive mode so the value
+		// doesn't appear in the process list.
+
+		_, _, err = s.run(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", keychainService, key, encoded), "-i")
+	} else {
+		_, _, err = s.run(encoded, "store", "--label", "Educates "+key, "service", keychainService, "key", key)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write credential %q to keychain", key)
+	}
+
+	return nil
+}
+
+func (s *keychainStore) remove(key string) error {
+	var status int
+	var err error
+
+	if s.tool == "security" {
+		_, status, err = s.run("", "delete-generic-password", "-s", keychainService, "-a", key)
+
+		if status == 44 {
+			return nil
+		}
+	} else {
+		_, _, err = s.run("", "clear", "service", keychainService, "key", key)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to delete credential %q from keychain", key)
+	}
+
+	return nil
+}
+
+func (s *keychainStore) index() ([]string, error) {
+	var keys []string
+
+	data, found, err := s.read(keychainIndexKey)
+
+	if err != nil || !found {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(data), &keys); err != nil {
+		return nil, errors.Wrap(err, "unable to decode index of credentials in keychain")
+	}
+
+	return keys, nil
+}
+
+func (s *keychainStore) updateIndex(key string, present bool) error {
+	keys, err := s.index()
+
+	if err != nil {
+		return err
+	}
+
+	updated := []string{}
+
+	for _, existing := range keys {
+		if existing != key {
+			updated = append(updated, existing)
+		}
+	}
+
+	if present {
+		updated = append(updated, key)
+	}
+
+	sort.Strings(updated)
+
+	data, err := json.Marshal(updated)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode index of credentials in keychain")
+	}
+
+	return s.write(keychainIndexKey, string(data))
+}
+
+func (s *keychainStore) Get(key string) (string, bool, error) {
+	return s.read(key)
+}
+
+func (s *keychainStore) Set(key string, value string) error {
+	if err := s.write(key, value); err != nil {
+		return err
+	}
+
+	return s.updateIndex(key, true)
+}
+
+func (s *keychainStore) Delete(key string) error {
+	if err := s.remove(key); err != nil {
+		return err
+	}
+
+	return s.updateIndex(key, false)
+}
+
+func (s *keychainStore) List() ([]string, error) {
+	return s.index()
+}
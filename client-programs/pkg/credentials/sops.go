@@ -0,0 +1,170 @@
+package credentials
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
+)
+
+/*
+Credentials held in a YAML file encrypted using SOPS. The keys used to encrypt
+the file are determined by SOPS, either from a .sops.yaml file with creation
+rules matching the file, or environment variables such as SOPS_AGE_RECIPIENTS.
+Version 3.8 or later of SOPS is required, as the plaintext is passed via
+stdin so it is never written to disk. Windows has no equivalent of /dev/stdin
+for naming stdin as the input file, so there the plaintext is instead written
+to a file only readable by the user, which is removed as soon as SOPS exits.
+*/
+type sopsStore struct {
+	file string
+}
+
+func newSOPSStore(config SOPSConfig) (*sopsStore, error) {
+	if _, err := exec.LookPath("sops"); err != nil {
+		return nil, failures.NewValidationError(errors.New("sops command required for sops credentials backend not found"), "")
+	}
+
+	file := config.File
+
+	if file == "" {
+		file = filepath.Join(xdg.DataHome, "educates", "credentials.sops.yaml")
+	}
+
+	return &sopsStore{file: file}, nil
+}
+
+func (s *sopsStore) Name() string {
+	return BackendSOPS
+}
+
+func (s *sopsStore) load() (map[string]string, error) {
+	values := map[string]string{}
+
+	if _, err := os.Stat(s.file); os.IsNotExist(err) {
+		return values, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	command := exec.Command("sops", "--decrypt", "--input-type", "yaml", "--output-type", "yaml", s.file)
+
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	if err := command.Run(); err != nil {
+		return nil, errors.Errorf("unable to decrypt credentials file %s: %s", s.file, strings.TrimSpace(stderr.String()))
+	}
+
+	if err := yaml.Unmarshal(stdout.Bytes(), &values); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse credentials file %s", s.file)
+	}
+
+	return values, nil
+}
+
+func (s *sopsStore) save(values map[string]string) error {
+	data, err := yaml.Marshal(values)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate credentials file")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.file), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "unable to create directory for credentials file")
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	input := "/dev/stdin"
+
+	if platform.IsWindows() {
+		tempDir, err := os.MkdirTemp("", "educates-sops")
+
+		if err != nil {
+			return errors.Wrap(err, "unable to create temporary directory for credentials")
+		}
+
+		defer os.RemoveAll(tempDir)
+
+		input = filepath.Join(tempDir, "credentials.yaml")
+
+		if err := os.WriteFile(input, data, 0600); err != nil {
+			return errors.Wrap(err, "unable to write temporary credentials file")
+		}
+	}
+
+	command := exec.Command("sops", "--encrypt", "--input-type", "yaml", "--output-type", "yaml", "--filename-override", s.file, input)
+
+	command.Stdin = bytes.NewReader(data)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	if err := command.Run(); err != nil {
+		return errors.Errorf("unable to encrypt credentials file %s: %s", s.file, strings.TrimSpace(stderr.String()))
+	}
+
+	if err := os.WriteFile(s.file, stdout.Bytes(), 0600); err != nil {
+		return errors.Wrapf(err, "unable to write credentials file %s", s.file)
+	}
+
+	return nil
+}
+
+func (s *sopsStore) Get(key string) (string, bool, error) {
+	values, err := s.load()
+
+	if err != nil {
+		return "", false, err
+	}
+
+	value, found := values[key]
+
+	return value, found, nil
+}
+
+func (s *sopsStore) Set(key string, value string) error {
+	values, err := s.load()
+
+	if err != nil {
+		return err
+	}
+
+	values[key] = value
+
+	return s.save(values)
+}
+
+func (s *sopsStore) Delete(key string) error {
+	values, err := s.load()
+
+	if err != nil {
+		return err
+	}
+
+	if _, found := values[key]; !found {
+		return nil
+	}
+
+	delete(values, key)
+
+	return s.save(values)
+}
+
+func (s *sopsStore) List() ([]string, error) {
+	values, err := s.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedKeys(values), nil
+}
@@ -0,0 +1,238 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Credentials held in a HashiCorp Vault KV version 2 secrets engine, with each
+credential stored as a separate secret under a common path. The address and
+token are taken from the VAULT_ADDR and VAULT_TOKEN environment variables,
+or the token file written by "vault login", the same as the Vault CLI.
+*/
+type vaultStore struct {
+	address   string
+	token     string
+	namespace string
+	mount     string
+	path      string
+	client    *http.Client
+}
+
+func newVaultStore(config VaultConfig) (*vaultStore, error) {
+	store := &vaultStore{
+		address:   strings.TrimSuffix(config.Address, "/"),
+		token:     os.Getenv("VAULT_TOKEN"),
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		mount:     strings.Trim(config.Mount, "/"),
+		path:      strings.Trim(config.Path, "/"),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if store.address == "" {
+		store.address = strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	}
+
+	if store.address == "" {
+		return nil, failures.NewValidationError(errors.New("no address for Vault credentials backend"), "set VAULT_ADDR or credentials.vault.address in the client config")
+	}
+
+	if store.token == "" {
+		home, _ := os.UserHomeDir()
+
+		if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			store.token = strings.TrimSpace(string(data))
+		}
+	}
+
+	if store.token == "" {
+		return nil, failures.NewValidationError(errors.New("no token for Vault credentials backend"), "set VAULT_TOKEN or run `vault login`")
+	}
+
+	if store.mount == "" {
+		store.mount = "secret"
+	}
+
+	if store.path == "" {
+		store.path = "educates"
+	}
+
+	return store, nil
+}
+
+func (s *vaultStore) Name() string {
+	return BackendVault
+}
+
+func (s *vaultStore) request(method string, api string, key string, body interface{}) (int, []byte, error) {
+	var reader io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "unable to encode request to Vault")
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", s.address, s.mount, api, path.Join(s.path, key))
+
+	req, err := http.NewRequest(method, url, reader)
+
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "unable to create request to Vault")
+	}
+
+	req.Header.Set("X-Vault-Token", s.token)
+
+	if s.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := s.client.Do(req)
+
+	if err != nil {
+		return 0, nil, failures.NewConnectionError(errors.Wrapf(err, "unable to connect to Vault at %s", s.address), "")
+	}
+
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "unable to read response from Vault")
+	}
+
+	return res.StatusCode, data, nil
+}
+
+func (s *vaultStore) Get(key string) (string, bool, error) {
+	status, data, err := s.request("GET", "data", key, nil)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	if status == 404 {
+		return "", false, nil
+	}
+
+	if status != 200 {
+		return "", false, errors.Errorf("unable to read credential %q from Vault, status %d", key, status)
+	}
+
+	var response struct {
+		Data struct {
+			Data struct {
+				Value string `json:"value"`
+			} `json:"data"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", false, errors.Wrap(err, "unable to decode response from Vault")
+	}
+
+	return response.Data.Data.Value, true, nil
+}
+
+func (s *vaultStore) Set(key string, value string) error {
+	body := map[string]interface{}{"data": map[string]string{"value": value}}
+
+	status, _, err := s.request("POST", "data", key, body)
+
+	if err != nil {
+		return err
+	}
+
+	if status != 200 && status != 204 {
+		return errors.Errorf("unable to write credential %q to Vault, status %d", key, status)
+	}
+
+	return nil
+}
+
+func (s *vaultStore) Delete(key string) error {
+	// Deleting the metadata removes all versions of the secret.
+
+	status, _, err := s.request("DELETE", "metadata", key, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if status != 204 && status != 404 {
+		return errors.Errorf("unable to delete credential %q from Vault, status %d", key, status)
+	}
+
+	return nil
+}
+
+func (s *vaultStore) List() ([]string, error) {
+	return s.list("")
+}
+
+func (s *vaultStore) list(prefix string) ([]string, error) {
+	status, data, err := s.request("LIST", "metadata", prefix, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if status == 404 {
+		return nil, nil
+	}
+
+	if status != 200 {
+		return nil, errors.Errorf("unable to list credentials in Vault, status %d", status)
+	}
+
+	var response struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, errors.Wrap(err, "unable to decode response from Vault")
+	}
+
+	var keys []string
+
+	// Keys ending in a slash are folders holding further secrets.
+
+	for _, key := range response.Data.Keys {
+		if strings.HasSuffix(key, "/") {
+			nested, err := s.list(prefix + key)
+
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, nested...)
+		} else {
+			keys = append(keys, prefix+key)
+		}
+	}
+
+	return keys, nil
+}
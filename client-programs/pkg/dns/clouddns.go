@@ -0,0 +1,187 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const cloudDNSEndpoint = "https://dns.googleapis.com/dns/v1"
+
+/*
+Provider for Google Cloud DNS. Credentials are the Google application default
+credentials. The project is that of the credentials, or can be set using the
+GOOGLE_CLOUD_PROJECT environment variable. Private managed zones are ignored.
+*/
+type CloudDNSProvider struct {
+	Zone string
+}
+
+func (p *CloudDNSProvider) String() string {
+	return "google"
+}
+
+type cloudDNSRecordSet struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	TTL     int      `json:"ttl"`
+	RRDatas []string `json:"rrdatas"`
+}
+
+func (p *CloudDNSProvider) request(ctx context.Context, client *http.Client, method string, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode Cloud DNS request")
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, cloudDNSEndpoint+path, reader)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create Cloud DNS request")
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrap(err, "unable to connect to Cloud DNS"), "")
+	}
+
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read response from Cloud DNS")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return providerError("Cloud DNS", res.StatusCode, resBody)
+	}
+
+	if result != nil {
+		if err = json.Unmarshal(resBody, result); err != nil {
+			return errors.Wrap(err, "unable to decode response from Cloud DNS")
+		}
+	}
+
+	return nil
+}
+
+func (p *CloudDNSProvider) findZone(ctx context.Context, client *http.Client, project string, name string) (string, error) {
+	candidates := candidateZones(name)
+
+	if p.Zone != "" {
+		candidates = []string{p.Zone}
+	}
+
+	for _, candidate := range candidates {
+		var zones struct {
+			ManagedZones []struct {
+				Name       string `json:"name"`
+				DNSName    string `json:"dnsName"`
+				Visibility string `json:"visibility"`
+			} `json:"managedZones"`
+		}
+
+		if err := p.request(ctx, client, http.MethodGet, fmt.Sprintf("/projects/%s/managedZones?dnsName=%s", url.PathEscape(project), url.QueryEscape(candidate+".")), nil, &zones); err != nil {
+			return "", err
+		}
+
+		for _, zone := range zones.ManagedZones {
+			if normalizeName(zone.DNSName) == candidate && zone.Visibility != "private" {
+				return zone.Name, nil
+			}
+		}
+	}
+
+	return "", failures.NewNotFoundError(errors.Errorf("no Cloud DNS managed zone found for %s in project %s", name, project), "create a public managed zone for the ingress domain or a parent domain")
+}
+
+func (p *CloudDNSProvider) UpsertRecord(ctx context.Context, record Record) error {
+	credentials, err := google.FindDefaultCredentials(ctx, "https://www.googleapis.com/auth/ndev.clouddns.readwrite")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to load Google application default credentials")
+	}
+
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+
+	if project == "" {
+		project = credentials.ProjectID
+	}
+
+	if project == "" {
+		return failures.NewValidationError(errors.New("unable to determine Google Cloud project"), "set the GOOGLE_CLOUD_PROJECT environment variable")
+	}
+
+	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/ndev.clouddns.readwrite")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to load Google application default credentials")
+	}
+
+	zone, err := p.findZone(ctx, client, project, record.Name)
+
+	if err != nil {
+		return err
+	}
+
+	zonePath := fmt.Sprintf("/projects/%s/managedZones/%s", url.PathEscape(project), url.PathEscape(zone))
+
+	var existing struct {
+		RRSets []cloudDNSRecordSet `json:"rrsets"`
+	}
+
+	if err = p.request(ctx, client, http.MethodGet, fmt.Sprintf("%s/rrsets?name=%s", zonePath, url.QueryEscape(record.Name+".")), nil, &existing); err != nil {
+		return err
+	}
+
+	// Any existing record set is replaced as part of the same change.
+
+	var change struct {
+		Additions []cloudDNSRecordSet `json:"additions"`
+		Deletions []cloudDNSRecordSet `json:"deletions,omitempty"`
+	}
+
+	for _, item := range existing.RRSets {
+		if item.Type == RecordTypeA || item.Type == RecordTypeAAAA || item.Type == RecordTypeCNAME {
+			change.Deletions = append(change.Deletions, item)
+		}
+	}
+
+	values := record.Values
+
+	if record.Type == RecordTypeCNAME {
+		values = []string{record.Values[0] + "."}
+	}
+
+	change.Additions = append(change.Additions, cloudDNSRecordSet{
+		Name:    record.Name + ".",
+		Type:    record.Type,
+		TTL:     record.TTL,
+		RRDatas: values,
+	})
+
+	return p.request(ctx, client, http.MethodPost, zonePath+"/changes", change, nil)
+}
@@ -0,0 +1,192 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const cloudflareEndpoint = "https://api.cloudflare.com/client/v4"
+
+/*
+Provider for Cloudflare DNS. The API token is read from the CLOUDFLARE_API_TOKEN
+environment variable and needs permission to edit DNS records of the zone.
+Records are created with the Cloudflare proxy disabled so they resolve to the
+load balancer itself.
+*/
+type CloudflareProvider struct {
+	Zone  string
+	Token string
+}
+
+func newCloudflareProvider(zone string) (*CloudflareProvider, error) {
+	token := os.Getenv("CLOUDFLARE_API_TOKEN")
+
+	if token == "" {
+		return nil, failures.NewValidationError(errors.New("no Cloudflare API token provided"), "set the CLOUDFLARE_API_TOKEN environment variable")
+	}
+
+	return &CloudflareProvider{Zone: zone, Token: token}, nil
+}
+
+func (p *CloudflareProvider) String() string {
+	return "cloudflare"
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+}
+
+func (p *CloudflareProvider) request(ctx context.Context, method string, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+
+		if err != nil {
+			return errors.Wrap(err, "unable to encode Cloudflare request")
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, cloudflareEndpoint+path, reader)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create Cloudflare request")
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrap(err, "unable to connect to Cloudflare"), "")
+	}
+
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read response from Cloudflare")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return providerError("Cloudflare", res.StatusCode, resBody)
+	}
+
+	if result != nil {
+		response := struct {
+			Result interface{} `json:"result"`
+		}{Result: result}
+
+		if err = json.Unmarshal(resBody, &response); err != nil {
+			return errors.Wrap(err, "unable to decode response from Cloudflare")
+		}
+	}
+
+	return nil
+}
+
+func (p *CloudflareProvider) findZone(ctx context.Context, name string) (string, error) {
+	candidates := candidateZones(name)
+
+	if p.Zone != "" {
+		candidates = []string{p.Zone}
+	}
+
+	for _, candidate := range candidates {
+		var zones []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+
+		if err := p.request(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(candidate), nil, &zones); err != nil {
+			return "", err
+		}
+
+		for _, zone := range zones {
+			if normalizeName(zone.Name) == candidate {
+				return zone.ID, nil
+			}
+		}
+	}
+
+	return "", failures.NewNotFoundError(errors.Errorf("no Cloudflare zone found for %s", name), "add the ingress domain or a parent domain as a zone in Cloudflare")
+}
+
+func (p *CloudflareProvider) UpsertRecord(ctx context.Context, record Record) error {
+	zoneID, err := p.findZone(ctx, record.Name)
+
+	if err != nil {
+		return err
+	}
+
+	// Cloudflare holds a separate record for each value. Existing records
+	// which match are kept, with any others for the name being deleted.
+
+	var existing []cloudflareRecord
+
+	if err = p.request(ctx, http.MethodGet, fmt.Sprintf("/zones/%s/dns_records?per_page=100&name=%s", zoneID, url.QueryEscape(record.Name)), nil, &existing); err != nil {
+		return err
+	}
+
+	wanted := map[string]bool{}
+
+	for _, value := range record.Values {
+		wanted[value] = true
+	}
+
+	for _, item := range existing {
+		if item.Type != RecordTypeA && item.Type != RecordTypeAAAA && item.Type != RecordTypeCNAME {
+			continue
+		}
+
+		if item.Type == record.Type && wanted[normalizeName(item.Content)] && item.TTL == record.TTL && !item.Proxied {
+			delete(wanted, normalizeName(item.Content))
+			continue
+		}
+
+		if err = p.request(ctx, http.MethodDelete, fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, item.ID), nil, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, value := range record.Values {
+		if !wanted[value] {
+			continue
+		}
+
+		item := cloudflareRecord{
+			Type:    record.Type,
+			Name:    record.Name,
+			Content: value,
+			TTL:     record.TTL,
+		}
+
+		if err = p.request(ctx, http.MethodPost, fmt.Sprintf("/zones/%s/dns_records", zoneID), item, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+Management of DNS records for the ingress domain of a hosted installation of
+Educates, so the wildcard record for the ingress domain points at the load
+balancer of the ingress controller without needing to be created by hand.
+*/
+package dns
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const (
+	RecordTypeA     = "A"
+	RecordTypeAAAA  = "AAAA"
+	RecordTypeCNAME = "CNAME"
+)
+
+/*
+A DNS record set. The name and any CNAME target are fully qualified domain
+names without a trailing period.
+*/
+type Record struct {
+	Name   string
+	Type   string
+	Values []string
+	TTL    int
+}
+
+/*
+A DNS provider hosting the zone containing the ingress domain. Creating or
+updating a record replaces any existing A, AAAA or CNAME records of the same
+name, so that switching between an IP address and a host name works.
+*/
+type Provider interface {
+	String() string
+	UpsertRecord(ctx context.Context, record Record) error
+}
+
+/*
+Return the DNS provider with the given name. The zone is the domain name of
+the hosted zone holding the record, and if it isn't given the zone is found
+by searching for the longest parent domain of the record hosted by the
+provider.
+*/
+func NewProvider(name string, zone string) (Provider, error) {
+	zone = normalizeName(zone)
+
+	switch name {
+	case "route53":
+		return &Route53Provider{Zone: zone}, nil
+	case "cloudflare":
+		return newCloudflareProvider(zone)
+	case "google":
+		return &CloudDNSProvider{Zone: zone}, nil
+	}
+
+	return nil, failures.NewValidationError(errors.Errorf("unsupported DNS provider %q", name), "DNS provider must be one of route53, cloudflare or google")
+}
+
+/*
+Return the wildcard record for the ingress domain, pointing at the addresses
+of the load balancer. A load balancer with a host name, as used by AWS, is
+pointed at by a CNAME record. Otherwise an A record is used for any IPv4
+addresses, falling back to an AAAA record when only IPv6 addresses exist.
+*/
+func WildcardRecord(domain string, addresses []string, ttl int) (Record, error) {
+	if ttl <= 0 {
+		ttl = 300
+	}
+
+	record := Record{
+		Name: "*." + normalizeName(domain),
+		TTL:  ttl,
+	}
+
+	var ipv4, ipv6 []string
+
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+
+		switch {
+		case ip == nil:
+			record.Type = RecordTypeCNAME
+			record.Values = []string{normalizeName(address)}
+
+			return record, nil
+		case ip.To4() != nil:
+			ipv4 = append(ipv4, ip.String())
+		default:
+			ipv6 = append(ipv6, ip.String())
+		}
+	}
+
+	switch {
+	case len(ipv4) != 0:
+		record.Type = RecordTypeA
+		record.Values = ipv4
+	case len(ipv6) != 0:
+		record.Type = RecordTypeAAAA
+		record.Values = ipv6
+	default:
+		return record, errors.New("no address for load balancer")
+	}
+
+	sort.Strings(record.Values)
+
+	return record, nil
+}
+
+/*
+Wait until the record resolves to the expected values using the DNS resolver
+of the local host. A different random host name under the wildcard is looked
+up each time, so a failed lookup cached by the resolver doesn't delay seeing
+the record once it has propagated.
+*/
+func WaitForPropagation(ctx context.Context, record Record, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	defer cancel()
+
+	var lastErr error
+
+	for {
+		label := make([]byte, 6)
+
+		if _, err := rand.Read(label); err != nil {
+			return errors.Wrap(err, "unable to generate host name")
+		}
+
+		host := hex.EncodeToString(label) + strings.TrimPrefix(record.Name, "*")
+
+		matched, err := recordResolves(ctx, host, record)
+
+		if matched {
+			return nil
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = errors.Errorf("host %s does not resolve to %s", host, strings.Join(record.Values, ", "))
+		}
+
+		select {
+		case <-ctx.Done():
+			return failures.NewTimeoutError(errors.Wrapf(lastErr, "DNS record %s has not propagated", record.Name), "the record may still propagate, check it later using a DNS lookup tool")
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+func recordResolves(ctx context.Context, host string, record Record) (bool, error) {
+	resolver := net.DefaultResolver
+
+	if record.Type == RecordTypeCNAME {
+		target, err := resolver.LookupCNAME(ctx, host)
+
+		if err != nil {
+			return false, err
+		}
+
+		return normalizeName(target) == record.Values[0], nil
+	}
+
+	addresses, err := resolver.LookupIPAddr(ctx, host)
+
+	if err != nil {
+		return false, err
+	}
+
+	found := map[string]bool{}
+
+	for _, address := range addresses {
+		found[address.IP.String()] = true
+	}
+
+	for _, value := range record.Values {
+		if !found[value] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+/*
+Return the candidate zones for a record, being each parent domain of the
+record name, longest first. A top level domain is never a candidate.
+*/
+func candidateZones(name string) []string {
+	labels := strings.Split(strings.TrimPrefix(name, "*."), ".")
+
+	var zones []string
+
+	for i := 0; i < len(labels)-1; i++ {
+		zones = append(zones, strings.Join(labels[i:], "."))
+	}
+
+	return zones
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+func providerError(provider string, status int, message []byte) error {
+	return errors.Errorf("%s returned status %d: %s", provider, status, strings.TrimSpace(string(message)))
+}
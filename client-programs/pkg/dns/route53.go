@@ -0,0 +1,223 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const route53Endpoint = "https://route53.amazonaws.com/2013-04-01"
+
+/*
+Provider for AWS Route 53. Credentials are determined in the same way as by
+the AWS CLI. Private hosted zones are ignored.
+*/
+type Route53Provider struct {
+	Zone string
+}
+
+func (p *Route53Provider) String() string {
+	return "route53"
+}
+
+type route53HostedZones struct {
+	HostedZones []struct {
+		ID     string `xml:"Id"`
+		Name   string `xml:"Name"`
+		Config struct {
+			PrivateZone bool `xml:"PrivateZone"`
+		} `xml:"Config"`
+	} `xml:"HostedZones>HostedZone"`
+}
+
+type route53ResourceRecord struct {
+	Value string `xml:"Value"`
+}
+
+type route53RecordSet struct {
+	Name            string                  `xml:"Name"`
+	Type            string                  `xml:"Type"`
+	TTL             int                     `xml:"TTL,omitempty"`
+	ResourceRecords []route53ResourceRecord `xml:"ResourceRecords>ResourceRecord"`
+}
+
+type route53RecordSets struct {
+	RecordSets []route53RecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+}
+
+type route53Change struct {
+	Action string           `xml:"Action"`
+	Record route53RecordSet `xml:"ResourceRecordSet"`
+}
+
+type route53ChangeRequest struct {
+	XMLName xml.Name        `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+	Changes []route53Change `xml:"ChangeBatch>Changes>Change"`
+}
+
+func (p *Route53Provider) request(ctx context.Context, credentials aws.Credentials, method string, path string, body []byte, result interface{}) error {
+	digest := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(digest[:])
+
+	req, err := http.NewRequestWithContext(ctx, method, route53Endpoint+path, bytes.NewReader(body))
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create Route 53 request")
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/xml")
+	}
+
+	// Route 53 is a global service, with requests always signed for the
+	// us-east-1 region.
+
+	if err = v4.NewSigner().SignHTTP(ctx, credentials, req, payloadHash, "route53", "us-east-1", time.Now().UTC()); err != nil {
+		return errors.Wrap(err, "unable to sign Route 53 request")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrap(err, "unable to connect to Route 53"), "")
+	}
+
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read response from Route 53")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return providerError("Route 53", res.StatusCode, resBody)
+	}
+
+	if result != nil {
+		if err = xml.Unmarshal(resBody, result); err != nil {
+			return errors.Wrap(err, "unable to decode response from Route 53")
+		}
+	}
+
+	return nil
+}
+
+func (p *Route53Provider) findZone(ctx context.Context, credentials aws.Credentials, name string) (string, error) {
+	candidates := candidateZones(name)
+
+	if p.Zone != "" {
+		candidates = []string{p.Zone}
+	}
+
+	for _, candidate := range candidates {
+		var zones route53HostedZones
+
+		query := url.Values{}
+		query.Set("dnsname", candidate)
+		query.Set("maxitems", "10")
+
+		if err := p.request(ctx, credentials, http.MethodGet, "/hostedzonesbyname?"+query.Encode(), nil, &zones); err != nil {
+			return "", err
+		}
+
+		for _, zone := range zones.HostedZones {
+			if normalizeName(zone.Name) == candidate && !zone.Config.PrivateZone {
+				return strings.TrimPrefix(zone.ID, "/hostedzone/"), nil
+			}
+		}
+	}
+
+	return "", failures.NewNotFoundError(errors.Errorf("no Route 53 hosted zone found for %s", name), "create a public hosted zone for the ingress domain or a parent domain")
+}
+
+func (p *Route53Provider) UpsertRecord(ctx context.Context, record Record) error {
+	awsConfig, err := config.LoadDefaultConfig(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to load AWS configuration")
+	}
+
+	credentials, err := awsConfig.Credentials.Retrieve(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve AWS credentials")
+	}
+
+	zoneID, err := p.findZone(ctx, credentials, record.Name)
+
+	if err != nil {
+		return err
+	}
+
+	// Route 53 returns an asterisk in a name as an escaped octal sequence.
+	// The existing record needs to be deleted in the same change if it is
+	// of a different type, as A and CNAME records can't exist together.
+
+	var existing route53RecordSets
+
+	query := url.Values{}
+	query.Set("name", record.Name)
+	query.Set("maxitems", "10")
+
+	if err = p.request(ctx, credentials, http.MethodGet, fmt.Sprintf("/hostedzone/%s/rrset?%s", zoneID, query.Encode()), nil, &existing); err != nil {
+		return err
+	}
+
+	var changes []route53Change
+
+	for _, item := range existing.RecordSets {
+		if normalizeName(strings.ReplaceAll(item.Name, `\052`, "*")) != record.Name {
+			continue
+		}
+
+		// Alias records have no resource records and are left alone, with
+		// the change then failing if the alias is of a conflicting type.
+
+		if len(item.ResourceRecords) == 0 {
+			continue
+		}
+
+		if item.Type != record.Type && (item.Type == RecordTypeA || item.Type == RecordTypeAAAA || item.Type == RecordTypeCNAME) {
+			changes = append(changes, route53Change{Action: "DELETE", Record: item})
+		}
+	}
+
+	upsert := route53Change{
+		Action: "UPSERT",
+		Record: route53RecordSet{
+			Name: record.Name + ".",
+			Type: record.Type,
+			TTL:  record.TTL,
+		},
+	}
+
+	for _, value := range record.Values {
+		upsert.Record.ResourceRecords = append(upsert.Record.ResourceRecords, route53ResourceRecord{Value: value})
+	}
+
+	changes = append(changes, upsert)
+
+	body, err := xml.Marshal(route53ChangeRequest{Changes: changes})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode Route 53 change request")
+	}
+
+	return p.request(ctx, credentials, http.MethodPost, fmt.Sprintf("/hostedzone/%s/rrset", zoneID), append([]byte(xml.Header), body...), nil)
+}
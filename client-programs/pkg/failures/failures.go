@@ -0,0 +1,210 @@
+/*
+Typed errors for the Educates CLI. Each error carries a kind which determines
+the exit code of the CLI when it is returned from a command, along with an
+optional hint suggesting to the user how the problem may be remedied.
+*/
+package failures
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"net/url"
+	"syscall"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+type Kind int
+
+const (
+	GeneralFailure Kind = iota
+	ValidationFailure
+	ConnectionFailure
+	NotFoundFailure
+	TimeoutFailure
+	DriftFailure
+	InterruptedFailure
+)
+
+var kindNames = map[Kind]string{
+	GeneralFailure:     "general",
+	ValidationFailure:  "validation",
+	ConnectionFailure:  "connection",
+	NotFoundFailure:    "not-found",
+	TimeoutFailure:     "timeout",
+	DriftFailure:       "drift",
+	InterruptedFailure: "interrupted",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// NOTE: Exit codes are part of the interface of the CLI and scripts may rely
+// on them, so existing values should never be changed.
+
+var exitCodes = map[Kind]int{
+	GeneralFailure:     1,
+	ValidationFailure:  2,
+	ConnectionFailure:  3,
+	NotFoundFailure:    4,
+	TimeoutFailure:     5,
+	DriftFailure:       6,
+	InterruptedFailure: 130,
+}
+
+const PlatformHint = "is the platform installed? run `educates admin platform deploy`"
+
+const ClusterHint = "is the cluster running? check your kubeconfig, or create a local cluster with `educates admin cluster create`"
+
+const PortalHint = "is the training portal running? check its status with `educates cluster portal list`"
+
+type Error struct {
+	Kind Kind
+	Hint string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Cause() error {
+	return e.Err
+}
+
+func newError(kind Kind, err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Kind: kind, Hint: hint, Err: err}
+}
+
+func NewValidationError(err error, hint string) error {
+	return newError(ValidationFailure, err, hint)
+}
+
+func NewConnectionError(err error, hint string) error {
+	return newError(ConnectionFailure, err, hint)
+}
+
+func NewNotFoundError(err error, hint string) error {
+	return newError(NotFoundFailure, err, hint)
+}
+
+func NewTimeoutError(err error, hint string) error {
+	return newError(TimeoutFailure, err, hint)
+}
+
+func NewDriftError(err error, hint string) error {
+	return newError(DriftFailure, err, hint)
+}
+
+func NewInterruptedError(err error, hint string) error {
+	return newError(InterruptedFailure, err, hint)
+}
+
+/*
+Determine the type of failure for an error. Where an error has not explicitly
+been given a type, errors from the Kubernetes client and network layer are
+inspected to try and infer what kind of failure it was.
+*/
+func Classify(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var typedErr *Error
+
+	if stderrors.As(err, &typedErr) {
+		return typedErr
+	}
+
+	// A command is cancelled when the user interrupts the CLI, in which case
+	// the same exit code as a shell uses for SIGINT is used.
+
+	if stderrors.Is(err, context.Canceled) {
+		return &Error{Kind: InterruptedFailure, Err: err}
+	}
+
+	if meta.IsNoMatchError(err) {
+		return &Error{Kind: NotFoundFailure, Hint: PlatformHint, Err: err}
+	}
+
+	if k8serrors.IsNotFound(err) {
+		// If the API server has no details about the name of a resource the
+		// resource type itself doesn't exist, meaning the Educates custom
+		// resource definitions aren't installed.
+
+		hint := ""
+
+		var status k8serrors.APIStatus
+
+		if stderrors.As(err, &status) {
+			details := status.Status().Details
+
+			if details == nil || details.Name == "" {
+				hint = PlatformHint
+			}
+		}
+
+		return &Error{Kind: NotFoundFailure, Hint: hint, Err: err}
+	}
+
+	if k8serrors.IsInvalid(err) || k8serrors.IsBadRequest(err) {
+		return &Error{Kind: ValidationFailure, Err: err}
+	}
+
+	if k8serrors.IsTimeout(err) || k8serrors.IsServerTimeout(err) || stderrors.Is(err, context.DeadlineExceeded) {
+		return &Error{Kind: TimeoutFailure, Err: err}
+	}
+
+	var netErr net.Error
+
+	if stderrors.As(err, &netErr) && netErr.Timeout() {
+		return &Error{Kind: TimeoutFailure, Err: err}
+	}
+
+	var urlErr *url.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+
+	if stderrors.As(err, &opErr) || stderrors.As(err, &dnsErr) || stderrors.Is(err, syscall.ECONNREFUSED) {
+		return &Error{Kind: ConnectionFailure, Hint: ClusterHint, Err: err}
+	}
+
+	if stderrors.As(err, &urlErr) {
+		return &Error{Kind: ConnectionFailure, Err: err}
+	}
+
+	return &Error{Kind: GeneralFailure, Err: err}
+}
+
+/*
+Return the exit code the CLI should use for an error.
+*/
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	return exitCodes[Classify(err).Kind]
+}
+
+/*
+Return any hint associated with an error describing how to remedy it.
+*/
+func HintFor(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return Classify(err).Hint
+}
@@ -0,0 +1,190 @@
+/*
+Support for running user supplied hooks after changes made by the CLI, such
+as a workshop being deployed, so that custom automation can be chained onto
+them. A hook can be a shell command or a webhook.
+*/
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/webhook"
+)
+
+const (
+	EventWorkshopDeploy = "workshop-deploy"
+	EventWorkshopDelete = "workshop-delete"
+)
+
+var EventTypes = []string{EventWorkshopDeploy, EventWorkshopDelete}
+
+// Commands are given a generous time to complete as they may be doing work
+// such as pre-warming caches, but shouldn't block the CLI indefinitely.
+
+const (
+	commandTimeout = 5 * time.Minute
+	webhookTimeout = 10 * time.Second
+)
+
+/*
+Configuration for a hook. Exactly one of a command or a webhook URL should be
+given. If no event types are given the hook is run for all events.
+*/
+type Config struct {
+	Name    string   `yaml:"name,omitempty"`
+	Events  []string `yaml:"events,omitempty"`
+	Command string   `yaml:"command,omitempty"`
+	URL     string   `yaml:"url,omitempty"`
+}
+
+/*
+Details of a change passed to a hook. For a command these are passed as
+environment variables, for a webhook as the JSON payload of the request.
+*/
+type Event struct {
+	Type            string `json:"event"`
+	Workshop        string `json:"workshop"`
+	WorkshopVersion string `json:"workshopVersion,omitempty"`
+	Portal          string `json:"portal"`
+	PortalURL       string `json:"portalURL,omitempty"`
+	Context         string `json:"context,omitempty"`
+}
+
+/*
+Return the environment variables describing the event for a command.
+*/
+func (e Event) Environ() []string {
+	return []string{
+		"EDUCATES_HOOK_EVENT=" + e.Type,
+		"EDUCATES_WORKSHOP_NAME=" + e.Workshop,
+		"EDUCATES_WORKSHOP_VERSION=" + e.WorkshopVersion,
+		"EDUCATES_PORTAL_NAME=" + e.Portal,
+		"EDUCATES_PORTAL_URL=" + e.PortalURL,
+		"EDUCATES_CLUSTER_CONTEXT=" + e.Context,
+	}
+}
+
+/*
+Return a name for the hook for use in messages.
+*/
+func (c *Config) DisplayName() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.Command != "":
+		return c.Command
+	}
+
+	return c.URL
+}
+
+/*
+Check the hook configuration is usable.
+*/
+func (c *Config) Validate() error {
+	if (c.Command == "") == (c.URL == "") {
+		return errors.Errorf("hook %q must give exactly one of a command or url", c.DisplayName())
+	}
+
+	for _, event := range c.Events {
+		found := false
+
+		for _, item := range EventTypes {
+			if item == event {
+				found = true
+			}
+		}
+
+		if !found {
+			return errors.Errorf("hook %q has unknown event type %q", c.DisplayName(), event)
+		}
+	}
+
+	return nil
+}
+
+/*
+Check whether the hook should be run for the type of event.
+*/
+func (c *Config) Enabled(eventType string) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+
+	for _, item := range c.Events {
+		if item == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Run the hook for the event, if enabled for the type of event. Any output from
+a command is written to stderr so that it doesn't mix with the output of the
+CLI itself.
+*/
+func (c *Config) Run(event Event) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if !c.Enabled(event.Type) {
+		return nil
+	}
+
+	if c.Command != "" {
+		return c.runCommand(event)
+	}
+
+	return c.callWebhook(event)
+}
+
+func (c *Config) runCommand(event Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+
+	defer cancel()
+
+	cmd := platform.ShellCommand(ctx, c.Command)
+
+	cmd.Env = append(os.Environ(), event.Environ()...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return errors.Errorf("hook %q timed out after %s", c.DisplayName(), commandTimeout)
+		}
+
+		return errors.Wrapf(err, "hook %q failed", c.DisplayName())
+	}
+
+	return nil
+}
+
+func (c *Config) callWebhook(event Event) error {
+	payload, err := json.Marshal(event)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate hook payload")
+	}
+
+	err = webhook.Post(c.URL, payload, webhookTimeout)
+
+	if status, ok := err.(*webhook.StatusError); ok {
+		return errors.Errorf("hook %q returned status %d", c.DisplayName(), status.StatusCode)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to call hook %q", c.DisplayName())
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+Configuration of HTTP clients used for fetching remote content, such as
+workshop definitions, OCI images and updates to the CLI, so that they work
+behind corporate proxies which intercept TLS connections.
+*/
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Files holding additional CA certificates to trust when making requests, in
+addition to those of the operating system.
+*/
+var CACertFiles []string
+
+/*
+Configure the default HTTP transport, which is used by any HTTP client which
+doesn't supply its own, to trust the additional CA certificates. Proxies are
+always determined from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+variables.
+*/
+func Configure() error {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+
+	if !ok {
+		return nil
+	}
+
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if len(CACertFiles) == 0 {
+		return nil
+	}
+
+	pool, err := x509.SystemCertPool()
+
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, file := range CACertFiles {
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read CA certificate file %s", file)
+		}
+
+		if !pool.AppendCertsFromPEM(data) {
+			return failures.NewValidationError(errors.Errorf("no PEM encoded certificates found in CA certificate file %s", file), "")
+		}
+	}
+
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+
+	return nil
+}
+
+/*
+Return the CA certificate files to use for accessing an image registry. This
+is those given explicitly for the registry, along with the additional CA
+certificates configured for all requests.
+*/
+func RegistryCACertPaths(paths []string) []string {
+	var result []string
+
+	result = append(result, paths...)
+	result = append(result, CACertFiles...)
+
+	return result
+}
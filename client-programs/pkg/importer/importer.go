@@ -0,0 +1,249 @@
+/*
+Conversion of courses written for other interactive learning platforms into
+the files for an Educates workshop. A converter reads the course from a
+directory into a common description, which is then written out on top of the
+classic workshop template.
+*/
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+/*
+A page of workshop instructions. The content is Markdown in the form used by
+the classic workshop renderer, with the title of the page being given by the
+workshop modules rather than in the page itself.
+*/
+type Page struct {
+	Name    string
+	Title   string
+	Content string
+}
+
+/*
+A script to be run when a workshop session starts.
+*/
+type Script struct {
+	Name    string
+	Content string
+}
+
+/*
+A file to be copied into the workshop. The source is the path of the file
+within the course directory and the target the path relative to the home
+directory of the workshop user.
+*/
+type Asset struct {
+	Source string
+	Target string
+}
+
+/*
+The description of a course as read by a converter.
+*/
+type Course struct {
+	Title       string
+	Description string
+	Duration    string
+	Pages       []Page
+	Scripts     []Script
+	Assets      []Asset
+	Warnings    []string
+}
+
+/*
+Read the course held in a directory using the named converter.
+*/
+func ReadCourse(format string, directory string) (*Course, error) {
+	switch format {
+	case "katacoda", "killercoda":
+		return readKatacodaCourse(directory)
+	case "instruqt":
+		return readInstruqtCourse(directory)
+	}
+
+	return nil, errors.Errorf("unsupported course format %q", format)
+}
+
+/*
+Names of the course formats which can be converted.
+*/
+var Formats = []string{"katacoda", "killercoda", "instruqt"}
+
+/*
+Write the pages, setup scripts and assets for the course into the directory
+for a workshop created from the classic workshop template, replacing the
+placeholder pages from the template.
+*/
+func (c *Course) Write(directory string) error {
+	contentDirectory := filepath.Join(directory, "workshop", "content")
+
+	placeholders, _ := filepath.Glob(filepath.Join(contentDirectory, "*.md"))
+
+	for _, file := range placeholders {
+		os.Remove(file)
+	}
+
+	if err := os.MkdirAll(contentDirectory, 0775); err != nil {
+		return errors.Wrapf(err, "unable to create workshop directory %q", contentDirectory)
+	}
+
+	type moduleDetails struct {
+		Name     string `yaml:"name"`
+		ExitSign string `yaml:"exit_sign,omitempty"`
+	}
+
+	modules := yaml.MapSlice{}
+
+	var activate []string
+
+	for i, page := range c.Pages {
+		name := fmt.Sprintf("%02d-%s", i, slugify(page.Name))
+
+		details := moduleDetails{Name: page.Title}
+
+		if i == 0 && len(c.Pages) > 1 {
+			details.ExitSign = "Start Workshop"
+		} else if i == len(c.Pages)-1 {
+			details.ExitSign = "Finish Workshop"
+		}
+
+		modules = append(modules, yaml.MapItem{Key: name, Value: details})
+
+		activate = append(activate, name)
+
+		file := filepath.Join(contentDirectory, name+".md")
+
+		if err := os.WriteFile(file, []byte(strings.TrimSpace(page.Content)+"\n"), 0664); err != nil {
+			return errors.Wrapf(err, "unable to write workshop page %q", file)
+		}
+	}
+
+	modulesData, err := yaml.Marshal(map[string]interface{}{"modules": modules})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate workshop modules")
+	}
+
+	if err = os.WriteFile(filepath.Join(directory, "workshop", "modules.yaml"), modulesData, 0664); err != nil {
+		return errors.Wrap(err, "unable to write workshop modules")
+	}
+
+	workshopData, err := yaml.Marshal(yaml.MapSlice{
+		{Key: "name", Value: c.Title},
+		{Key: "modules", Value: map[string]interface{}{"activate": activate}},
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate workshop configuration")
+	}
+
+	if err = os.WriteFile(filepath.Join(directory, "workshop", "workshop.yaml"), workshopData, 0664); err != nil {
+		return errors.Wrap(err, "unable to write workshop configuration")
+	}
+
+	if len(c.Scripts) != 0 {
+		setupDirectory := filepath.Join(directory, "workshop", "setup.d")
+
+		if err = os.MkdirAll(setupDirectory, 0775); err != nil {
+			return errors.Wrapf(err, "unable to create workshop directory %q", setupDirectory)
+		}
+
+		for i, script := range c.Scripts {
+			file := filepath.Join(setupDirectory, fmt.Sprintf("%02d-%s.sh", i+1, slugify(script.Name)))
+
+			if err = os.WriteFile(file, []byte(script.Content), 0775); err != nil {
+				return errors.Wrapf(err, "unable to write setup script %q", file)
+			}
+		}
+	}
+
+	for _, asset := range c.Assets {
+		target := filepath.Join(directory, "exercises", filepath.FromSlash(asset.Target))
+
+		if err = copyAsset(asset.Source, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyAsset(source string, target string) error {
+	info, err := os.Stat(source)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to read asset %q", source)
+	}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(source)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read asset directory %q", source)
+		}
+
+		for _, entry := range entries {
+			if err = copyAsset(filepath.Join(source, entry.Name()), filepath.Join(target, entry.Name())); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	data, err := os.ReadFile(source)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to read asset %q", source)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(target), 0775); err != nil {
+		return errors.Wrapf(err, "unable to create workshop directory %q", filepath.Dir(target))
+	}
+
+	if err = os.WriteFile(target, data, info.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "unable to write asset %q", target)
+	}
+
+	return nil
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(name string) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+
+	if slug == "" {
+		slug = "page"
+	}
+
+	return slug
+}
+
+/*
+Split YAML front matter from the start of a Markdown file.
+*/
+func splitFrontMatter(data string) (string, string) {
+	if !strings.HasPrefix(data, "---\n") {
+		return "", data
+	}
+
+	end := strings.Index(data[4:], "\n---")
+
+	if end == -1 {
+		return "", data
+	}
+
+	body := data[4+end+4:]
+
+	return data[4 : 4+end], strings.TrimPrefix(body, "\n")
+}
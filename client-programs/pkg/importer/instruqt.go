@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type instruqtTrack struct {
+	Title       string `yaml:"title"`
+	Teaser      string `yaml:"teaser"`
+	Description string `yaml:"description"`
+}
+
+type instruqtChallenge struct {
+	Slug      string `yaml:"slug"`
+	Title     string `yaml:"title"`
+	Teaser    string `yaml:"teaser"`
+	TimeLimit int    `yaml:"timelimit"`
+}
+
+/*
+Read a track in the format used by Instruqt. The track is described by a
+track.yml file, with each challenge held in a sub directory containing an
+assignment.md file with YAML front matter, along with any scripts for the
+hosts of the environment. Setup scripts for the track as a whole are held in
+the track_scripts directory.
+*/
+func readInstruqtCourse(directory string) (*Course, error) {
+	data, err := os.ReadFile(filepath.Join(directory, "track.yml"))
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read track definition in %q", directory)
+	}
+
+	var track instruqtTrack
+
+	if err = yaml.Unmarshal(data, &track); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse track definition in %q", directory)
+	}
+
+	course := &Course{
+		Title:       track.Title,
+		Description: track.Teaser,
+	}
+
+	if course.Description == "" {
+		course.Description = strings.TrimSpace(track.Description)
+	}
+
+	if description := strings.TrimSpace(track.Description); description != "" {
+		course.Pages = append(course.Pages, Page{
+			Name:    "overview",
+			Title:   "Overview",
+			Content: description,
+		})
+	}
+
+	scripts, _ := filepath.Glob(filepath.Join(directory, "track_scripts", "setup-*"))
+
+	sort.Strings(scripts)
+
+	for _, name := range scripts {
+		script, err := os.ReadFile(name)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read track script %q", name)
+		}
+
+		course.Scripts = append(course.Scripts, Script{
+			Name:    filepath.Base(name),
+			Content: string(script),
+		})
+	}
+
+	assignments, _ := filepath.Glob(filepath.Join(directory, "*", "assignment.md"))
+
+	sort.Strings(assignments)
+
+	var timeLimit int
+
+	for _, file := range assignments {
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read challenge %q", file)
+		}
+
+		frontMatter, body := splitFrontMatter(string(data))
+
+		var challenge instruqtChallenge
+
+		if err = yaml.Unmarshal([]byte(frontMatter), &challenge); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse challenge %q", file)
+		}
+
+		challengeDirectory := filepath.Dir(file)
+
+		if challenge.Slug == "" {
+			challenge.Slug = filepath.Base(challengeDirectory)
+		}
+
+		if challenge.Title == "" {
+			challenge.Title = challenge.Slug
+		}
+
+		timeLimit += challenge.TimeLimit
+
+		course.Pages = append(course.Pages, Page{
+			Name:    challenge.Slug,
+			Title:   challenge.Title,
+			Content: convertInstruqtMarkdown(body),
+		})
+
+		// Setup scripts for a challenge are run when the session starts, as
+		// the workshop has no equivalent of running a script when a page is
+		// shown. Check and solve scripts have no equivalent.
+
+		scripts, _ := filepath.Glob(filepath.Join(challengeDirectory, "setup-*"))
+
+		sort.Strings(scripts)
+
+		for _, name := range scripts {
+			script, err := os.ReadFile(name)
+
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to read challenge script %q", name)
+			}
+
+			course.Warnings = append(course.Warnings, fmt.Sprintf("%s: setup script for challenge %q now runs when the session starts", name, challenge.Title))
+
+			course.Scripts = append(course.Scripts, Script{
+				Name:    challenge.Slug + "-" + filepath.Base(name),
+				Content: string(script),
+			})
+		}
+
+		for _, kind := range []string{"check", "solve", "cleanup"} {
+			scripts, _ := filepath.Glob(filepath.Join(challengeDirectory, kind+"-*"))
+
+			for _, name := range scripts {
+				course.Warnings = append(course.Warnings, fmt.Sprintf("%s: %s script for challenge %q was not converted", name, kind, challenge.Title))
+			}
+		}
+	}
+
+	if len(assignments) == 0 {
+		return nil, errors.Errorf("no challenges found in track %q", directory)
+	}
+
+	if timeLimit != 0 {
+		course.Duration = fmt.Sprintf("%dm", (timeLimit+59)/60)
+	}
+
+	if len(course.Scripts) != 0 {
+		course.Warnings = append(course.Warnings, "setup scripts run as the workshop user rather than as root, check they don't require root access")
+	}
+
+	return course, nil
+}
+
+var instruqtRunPattern = regexp.MustCompile("(?m)^```[a-zA-Z]*,run\\s*$")
+
+/*
+Convert code blocks which Instruqt marks as runnable into code blocks which
+the classic workshop renderer executes in the terminal.
+*/
+func convertInstruqtMarkdown(text string) string {
+	return instruqtRunPattern.ReplaceAllString(text, "```execute")
+}
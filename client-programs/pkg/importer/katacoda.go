@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type katacodaStep struct {
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+	Code       string `json:"code"`
+	CourseData string `json:"courseData"`
+	Background string `json:"background"`
+	Foreground string `json:"foreground"`
+	Verify     string `json:"verify"`
+}
+
+type katacodaAsset struct {
+	File   string `json:"file"`
+	Target string `json:"target"`
+}
+
+type katacodaIndex struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Time        string `json:"time"`
+	Details     struct {
+		Intro  katacodaStep               `json:"intro"`
+		Steps  []katacodaStep             `json:"steps"`
+		Finish katacodaStep               `json:"finish"`
+		Assets map[string][]katacodaAsset `json:"assets"`
+	} `json:"details"`
+}
+
+/*
+Read a course in the format used by Katacoda, and by Killercoda which took
+over the same format. The course is described by an index.json file naming
+the Markdown file for each step along with scripts to run, with any files to
+be copied into the environment held in an assets directory.
+*/
+func readKatacodaCourse(directory string) (*Course, error) {
+	data, err := os.ReadFile(filepath.Join(directory, "index.json"))
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read course index in %q", directory)
+	}
+
+	var index katacodaIndex
+
+	if err = json.Unmarshal(data, &index); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse course index in %q", directory)
+	}
+
+	course := &Course{
+		Title:       index.Title,
+		Description: index.Description,
+		Duration:    katacodaDuration(index.Time),
+	}
+
+	steps := []katacodaStep{}
+
+	if index.Details.Intro.Text != "" {
+		intro := index.Details.Intro
+
+		if intro.Title == "" {
+			intro.Title = "Introduction"
+		}
+
+		steps = append(steps, intro)
+	}
+
+	steps = append(steps, index.Details.Steps...)
+
+	if index.Details.Finish.Text != "" {
+		finish := index.Details.Finish
+
+		if finish.Title == "" {
+			finish.Title = "Summary"
+		}
+
+		steps = append(steps, finish)
+	}
+
+	for i, step := range steps {
+		if step.Text == "" {
+			continue
+		}
+
+		text, err := os.ReadFile(filepath.Join(directory, step.Text))
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read course step %q", step.Text)
+		}
+
+		content, warnings := convertKatacodaMarkdown(string(text))
+
+		for _, warning := range warnings {
+			course.Warnings = append(course.Warnings, fmt.Sprintf("%s: %s", step.Text, warning))
+		}
+
+		course.Pages = append(course.Pages, Page{
+			Name:    strings.TrimSuffix(filepath.Base(step.Text), filepath.Ext(step.Text)),
+			Title:   step.Title,
+			Content: content,
+		})
+
+		// Scripts run in the background for a step are run when the session
+		// starts, as the workshop has no equivalent of running a script
+		// when a page is shown.
+
+		for _, name := range []string{step.CourseData, step.Background} {
+			if name == "" {
+				continue
+			}
+
+			script, err := os.ReadFile(filepath.Join(directory, name))
+
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to read course script %q", name)
+			}
+
+			if i != 0 || index.Details.Intro.Text == "" {
+				course.Warnings = append(course.Warnings, fmt.Sprintf("%s: background script for step %q now runs when the session starts", name, step.Title))
+			}
+
+			course.Scripts = append(course.Scripts, Script{
+				Name:    strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)),
+				Content: string(script),
+			})
+		}
+
+		for _, name := range []string{step.Code, step.Foreground} {
+			if name != "" {
+				course.Warnings = append(course.Warnings, fmt.Sprintf("%s: foreground script for step %q was not converted", name, step.Title))
+			}
+		}
+
+		if step.Verify != "" {
+			course.Warnings = append(course.Warnings, fmt.Sprintf("%s: verify script for step %q was not converted", step.Verify, step.Title))
+		}
+	}
+
+	if len(course.Scripts) != 0 {
+		course.Warnings = append(course.Warnings, "setup scripts run as the workshop user rather than as root, check they don't require root access")
+	}
+
+	// Assets can be given for each host of the environment, but as there is
+	// only the one workshop container they are all copied into it.
+
+	for _, assets := range index.Details.Assets {
+		for _, asset := range assets {
+			matches, _ := filepath.Glob(filepath.Join(directory, "assets", asset.File))
+
+			if len(matches) == 0 {
+				course.Warnings = append(course.Warnings, fmt.Sprintf("no assets found matching %q", asset.File))
+			}
+
+			target := asset.Target
+
+			if target == "~" || strings.HasPrefix(target, "~/") {
+				target = strings.TrimPrefix(strings.TrimPrefix(target, "~"), "/")
+			} else {
+				course.Warnings = append(course.Warnings, fmt.Sprintf("assets for %q copied to exercises directory rather than %q", asset.File, target))
+
+				target = strings.TrimPrefix(target, "/")
+			}
+
+			for _, match := range matches {
+				course.Assets = append(course.Assets, Asset{
+					Source: match,
+					Target: filepath.ToSlash(filepath.Join(target, filepath.Base(match))),
+				})
+			}
+		}
+	}
+
+	return course, nil
+}
+
+var katacodaDurationPattern = regexp.MustCompile(`^(\d+)\s*(minute|min|hour|hr)s?$`)
+
+/*
+Convert the time given for a Katacoda course, such as "15 minutes", to a
+duration.
+*/
+func katacodaDuration(value string) string {
+	match := katacodaDurationPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+
+	if match == nil {
+		return ""
+	}
+
+	if strings.HasPrefix(match[2], "h") {
+		return match[1] + "h"
+	}
+
+	return match[1] + "m"
+}
+
+var (
+	katacodaBlockPattern   = regexp.MustCompile("(?s)```[a-zA-Z]*\n(.*?)\n?```\\{\\{(execute|copy)([^}]*)\\}\\}")
+	katacodaInlinePattern  = regexp.MustCompile("[ \t]*`([^`\n]+)`\\{\\{(execute|copy)([^}]*)\\}\\}[ \t]*")
+	katacodaMarkerPattern  = regexp.MustCompile("`([^`\n]+)`\\{\\{[a-z]+[^}]*\\}\\}")
+	katacodaTerminalTarget = regexp.MustCompile(`\bT(\d+)\b`)
+)
+
+/*
+Convert the Katacoda extensions to Markdown for executing and copying
+commands into the equivalent code blocks of the classic workshop renderer.
+*/
+func convertKatacodaMarkdown(text string) (string, []string) {
+	var warnings []string
+
+	codeBlock := func(code string, action string, options string) string {
+		language := action
+
+		if action == "execute" {
+			if match := katacodaTerminalTarget.FindStringSubmatch(options); match != nil {
+				language = "execute-" + match[1]
+			}
+		}
+
+		return fmt.Sprintf("```%s\n%s\n```", language, strings.TrimRight(code, "\n"))
+	}
+
+	text = katacodaBlockPattern.ReplaceAllStringFunc(text, func(block string) string {
+		match := katacodaBlockPattern.FindStringSubmatch(block)
+
+		return codeBlock(match[1], match[2], match[3])
+	})
+
+	text = katacodaInlinePattern.ReplaceAllStringFunc(text, func(inline string) string {
+		match := katacodaInlinePattern.FindStringSubmatch(inline)
+
+		return "\n\n" + codeBlock(match[1], match[2], match[3]) + "\n\n"
+	})
+
+	text = katacodaMarkerPattern.ReplaceAllString(text, "`$1`")
+
+	if strings.Contains(text, "[[HOST_SUBDOMAIN]]") || strings.Contains(text, "[[KATACODA_HOST]]") {
+		warnings = append(warnings, "links to environment hosts need to be replaced with workshop session ingresses")
+	}
+
+	return text, warnings
+}
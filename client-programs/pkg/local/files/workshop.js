@@ -0,0 +1,62 @@
+(function () {
+  var terminal = document.body.dataset.terminal === "true";
+
+  function notify(message) {
+    var element = document.getElementById("notification");
+
+    element.textContent = message;
+    element.classList.add("visible");
+
+    clearTimeout(element.timer);
+
+    element.timer = setTimeout(function () {
+      element.classList.remove("visible");
+    }, 2000);
+  }
+
+  function copy(text) {
+    navigator.clipboard.writeText(text).then(function () {
+      notify("Copied to clipboard.");
+    }, function () {
+      notify("Unable to copy to clipboard.");
+    });
+  }
+
+  document.querySelectorAll("pre.action[data-action]").forEach(function (block) {
+    block.addEventListener("click", function () {
+      var kind = block.dataset.action;
+      var text = block.dataset.text;
+
+      if (kind === "copy") {
+        copy(text);
+        return;
+      }
+
+      // Without a local terminal, commands are copied to the clipboard so
+      // they can be pasted into a terminal by hand.
+
+      if (!terminal) {
+        if (text) {
+          copy(text);
+        }
+
+        return;
+      }
+
+      fetch("/action", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ kind: kind, text: text }),
+      }).then(function (response) {
+        if (response.ok) {
+          block.classList.add("done");
+          notify("Sent to terminal.");
+        } else {
+          notify("Unable to send to terminal.");
+        }
+      }, function () {
+        notify("Workshop is no longer running.");
+      });
+    });
+  });
+})();
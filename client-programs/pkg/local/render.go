@@ -0,0 +1,221 @@
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/russross/blackfriday/v2"
+	"gopkg.in/yaml.v2"
+)
+
+/*
+A clickable action in the workshop instructions, as rendered into the page.
+The kind is "execute" to run a command in the local shell, "input" to type
+text into the local shell without running it, "interrupt" and "clear" to
+control the local shell, and "copy" to copy text to the clipboard.
+*/
+type action struct {
+	Kind string
+	Text string
+}
+
+/*
+Work out the clickable action for a code block from its language. Returns
+nil if the code block isn't a clickable action, and an error if it is one
+which can't be performed when running locally.
+*/
+func codeBlockAction(language string, body string) (*action, error) {
+	switch {
+	case language == "execute" || language == "execute-all" || strings.HasPrefix(language, "execute-"):
+		return &action{Kind: "execute", Text: strings.TrimRight(body, "\n")}, nil
+	case language == "copy" || language == "copy-and-edit":
+		return &action{Kind: "copy", Text: strings.TrimRight(body, "\n")}, nil
+	case !strings.Contains(language, ":"):
+		return nil, nil
+	}
+
+	var args map[string]interface{}
+
+	if err := yaml.Unmarshal([]byte(body), &args); err != nil {
+		return nil, errors.Errorf("invalid arguments for %s", language)
+	}
+
+	text := func(name string) string {
+		if value, ok := args[name]; ok && value != nil {
+			return strings.TrimRight(fmt.Sprint(value), "\n")
+		}
+
+		return ""
+	}
+
+	switch language {
+	case "terminal:execute", "terminal:execute-all":
+		return &action{Kind: "execute", Text: text("command")}, nil
+	case "terminal:input":
+		return &action{Kind: "input", Text: text("text")}, nil
+	case "terminal:interrupt", "terminal:interrupt-all":
+		return &action{Kind: "interrupt"}, nil
+	case "terminal:clear", "terminal:clear-all":
+		return &action{Kind: "clear"}, nil
+	case "workshop:copy", "workshop:copy-and-edit":
+		return &action{Kind: "copy", Text: text("text")}, nil
+	}
+
+	return nil, errors.Errorf("%s action is not available when running locally", language)
+}
+
+/*
+Renderer for markdown which presents code blocks for clickable actions so
+they can be clicked on in the web browser. Clickable actions which can't be
+performed locally are shown as plain code blocks.
+*/
+type pageRenderer struct {
+	*blackfriday.HTMLRenderer
+	warnings map[string]bool
+}
+
+func (r *pageRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	if node.Type != blackfriday.CodeBlock {
+		return r.HTMLRenderer.RenderNode(w, node, entering)
+	}
+
+	language := strings.TrimSpace(string(node.CodeBlockData.Info))
+
+	if fields := strings.Fields(language); len(fields) != 0 {
+		language = fields[0]
+	}
+
+	body := string(node.Literal)
+
+	clickable, err := codeBlockAction(language, body)
+
+	if err != nil {
+		r.warnings[err.Error()] = true
+
+		fmt.Fprintf(w, "<pre class=\"action unavailable\" title=\"%s\"><code>%s</code></pre>\n", html.EscapeString(err.Error()), html.EscapeString(body))
+
+		return blackfriday.GoToNext
+	}
+
+	if clickable == nil {
+		return r.HTMLRenderer.RenderNode(w, node, entering)
+	}
+
+	content := clickable.Text
+
+	switch clickable.Kind {
+	case "interrupt":
+		content = "<ctrl+c>"
+	case "clear":
+		content = "clear"
+	}
+
+	fmt.Fprintf(w, "<pre class=\"action %s\" data-action=\"%s\" data-text=\"%s\"><code>%s</code></pre>\n", clickable.Kind, clickable.Kind, html.EscapeString(clickable.Text), html.EscapeString(content))
+
+	return blackfriday.GoToNext
+}
+
+var (
+	hugoParamPattern     = regexp.MustCompile(`\{\{<\s*param\s+"?([\w.-]+)"?\s*>\}\}`)
+	hugoShortcodePattern = regexp.MustCompile(`\{\{[<%]\s*(/?)([\w-]+)[^}]*[>%]\}\}`)
+	classicVarPattern    = regexp.MustCompile(`%([a-z][a-z0-9_]*)%`)
+)
+
+/*
+Admonitions provided as shortcodes by the Hugo theme for workshops. These are
+replaced by markers before the page is rendered, so the content of the
+admonition is still rendered as markdown, with the markers then replaced by
+the HTML for the admonition.
+*/
+var admonitions = []string{"note", "warning", "danger"}
+
+/*
+Render a page of workshop instructions as HTML. Data variables are expanded
+using the syntax of the renderer used by the workshop. Any problems with the
+page, such as clickable actions which aren't available locally, are returned
+as warnings.
+*/
+func RenderPage(file string, classic bool, variables map[string]string) (string, []string, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "unable to read page %q", file)
+	}
+
+	_, body := splitFrontMatter(data)
+
+	text := string(body)
+
+	warnings := map[string]bool{}
+
+	if classic {
+		text = classicVarPattern.ReplaceAllStringFunc(text, func(match string) string {
+			if value, found := variables[strings.Trim(match, "%")]; found {
+				return value
+			}
+
+			return match
+		})
+	} else {
+		text = hugoParamPattern.ReplaceAllStringFunc(text, func(match string) string {
+			name := hugoParamPattern.FindStringSubmatch(match)[1]
+
+			if value, found := variables[name]; found {
+				return value
+			}
+
+			warnings[fmt.Sprintf("data variable %s is not available when running locally", name)] = true
+
+			return ""
+		})
+
+		text = hugoShortcodePattern.ReplaceAllStringFunc(text, func(match string) string {
+			parts := hugoShortcodePattern.FindStringSubmatch(match)
+
+			for _, name := range admonitions {
+				if parts[2] == name {
+					if parts[1] == "/" {
+						return fmt.Sprintf("\n\nEDUCATES-LOCAL-END-%s\n\n", name)
+					}
+
+					return fmt.Sprintf("\n\nEDUCATES-LOCAL-START-%s\n\n", name)
+				}
+			}
+
+			warnings[fmt.Sprintf("shortcode %s is not supported when running locally", parts[2])] = true
+
+			return ""
+		})
+	}
+
+	renderer := &pageRenderer{
+		HTMLRenderer: blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+			Flags: blackfriday.CommonHTMLFlags,
+		}),
+		warnings: warnings,
+	}
+
+	output := blackfriday.Run([]byte(text), blackfriday.WithRenderer(renderer), blackfriday.WithExtensions(blackfriday.CommonExtensions|blackfriday.AutoHeadingIDs))
+
+	for _, name := range admonitions {
+		output = bytes.ReplaceAll(output, []byte(fmt.Sprintf("<p>EDUCATES-LOCAL-START-%s</p>", name)), []byte(fmt.Sprintf("<div class=\"admonition %s\">", name)))
+		output = bytes.ReplaceAll(output, []byte(fmt.Sprintf("<p>EDUCATES-LOCAL-END-%s</p>", name)), []byte("</div>"))
+	}
+
+	var messages []string
+
+	for message := range warnings {
+		messages = append(messages, message)
+	}
+
+	sort.Strings(messages)
+
+	return string(output), messages, nil
+}
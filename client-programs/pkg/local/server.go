@@ -0,0 +1,232 @@
+package local
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed files/*
+var serverFiles embed.FS
+
+var pageTemplate = template.Must(template.ParseFS(serverFiles, "files/page.html"))
+
+const tokenCookieName = "educates-local-token"
+
+/*
+Web server for the workshop instructions. Clickable actions are sent back to
+the server, which passes them on to the local terminal. As actions run
+commands in the local shell, requests for actions must present the token
+for the server, which is set as a cookie when the URL returned by URL() is
+first visited, and must be addressed to the loopback interface.
+*/
+type Server struct {
+	Workshop *Workshop
+	Terminal *Terminal
+	Token    string
+	Address  string
+}
+
+/*
+Create the web server for a workshop, with a random token for authorizing
+requests for clickable actions. The terminal can be nil where there is no
+local terminal, in which case commands are copied to the clipboard instead.
+*/
+func NewServer(workshop *Workshop, terminal *Terminal, address string) (*Server, error) {
+	data := make([]byte, 16)
+
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		Workshop: workshop,
+		Terminal: terminal,
+		Token:    hex.EncodeToString(data),
+		Address:  address,
+	}, nil
+}
+
+/*
+Return the URL for opening the workshop instructions in a web browser.
+*/
+func (s *Server) URL() string {
+	return fmt.Sprintf("http://%s/?token=%s", s.Address, s.Token)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Only accept requests addressed to the loopback interface, to stop a
+	// web site using DNS rebinding to make requests against the server.
+
+	host, _, err := net.SplitHostPort(r.Host)
+
+	if err != nil {
+		host = r.Host
+	}
+
+	if host != "localhost" && host != "127.0.0.1" && host != "::1" {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/":
+		if token := r.URL.Query().Get("token"); token != "" {
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+
+		http.Redirect(w, r, "/content/"+s.Workshop.Pages[0].Name, http.StatusFound)
+	case r.URL.Path == "/action":
+		s.serveAction(w, r)
+	case strings.HasPrefix(r.URL.Path, "/static/"):
+		s.serveStatic(w, r)
+	case strings.HasPrefix(r.URL.Path, "/content/"):
+		s.serveContent(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, err := r.Cookie(tokenCookieName)
+
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(s.Token)) != 1 {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if s.Terminal == nil {
+		http.Error(w, "No local terminal", http.StatusServiceUnavailable)
+		return
+	}
+
+	var request struct {
+		Kind string `json:"kind"`
+		Text string `json:"text"`
+	}
+
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Malformed request", http.StatusBadRequest)
+		return
+	}
+
+	if err = s.Terminal.Send(request.Kind, request.Text); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveStatic(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/static/")
+
+	if name == "page.html" {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := serverFiles.ReadFile("files/" + path.Clean(name))
+
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch path.Ext(name) {
+	case ".css":
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	case ".js":
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	}
+
+	w.Write(data)
+}
+
+/*
+Serve a page of the workshop instructions, or any other file from the
+workshop content directory, such as images referenced by the pages.
+*/
+func (s *Server) serveContent(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(path.Clean(strings.TrimPrefix(r.URL.Path, "/content/")), "/")
+
+	index := s.Workshop.PageIndex(name)
+
+	if index == -1 {
+		if strings.HasSuffix(name, ".md") || strings.HasPrefix(name, "..") {
+			http.NotFound(w, r)
+			return
+		}
+
+		file := filepath.Join(s.Workshop.Directory, "workshop", "content", filepath.FromSlash(name))
+
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.ServeFile(w, r, file)
+
+		return
+	}
+
+	page := s.Workshop.Pages[index]
+
+	content, _, err := RenderPage(page.File, s.Workshop.Classic, s.Workshop.Variables)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Workshop *Workshop
+		Page     Page
+		Index    int
+		Previous *Page
+		Next     *Page
+		Content  template.HTML
+		Terminal bool
+	}{
+		Workshop: s.Workshop,
+		Page:     page,
+		Index:    index,
+		Content:  template.HTML(content),
+		Terminal: s.Terminal != nil,
+	}
+
+	if index > 0 {
+		data.Previous = &s.Workshop.Pages[index-1]
+	}
+
+	if index < len(s.Workshop.Pages)-1 {
+		data.Next = &s.Workshop.Pages[index+1]
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if err = pageTemplate.Execute(w, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to render page %s: %s.\r\n", page.Name, err)
+	}
+}
@@ -0,0 +1,138 @@
+package local
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
+)
+
+/*
+Local shell for a workshop, run in a pseudo terminal attached to the
+terminal the CLI is run from. Clickable actions in the workshop instructions
+send input to the shell as if it had been typed.
+*/
+type Terminal struct {
+	pty     *os.File
+	command *exec.Cmd
+	lock    sync.Mutex
+}
+
+/*
+Start the local shell in the directory given, with the additional environment
+variables set. An error is returned where the terminal the CLI is run from
+isn't interactive or pseudo terminals aren't supported on the platform.
+*/
+func StartTerminal(directory string, environ []string) (*Terminal, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, errors.New("standard input and output are not a terminal")
+	}
+
+	command := exec.Command(platform.UserShell())
+
+	command.Dir = directory
+	command.Env = append(os.Environ(), environ...)
+
+	ptmx, err := pty.Start(command)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to start local shell")
+	}
+
+	return &Terminal{pty: ptmx, command: command}, nil
+}
+
+/*
+Attach the local shell to the terminal the CLI is run from until the shell
+exits or the stop channel is closed. The terminal is put in raw mode so all
+input is passed through to the shell, and the size of the pseudo terminal is
+kept in step with that of the terminal.
+*/
+func (t *Terminal) Attach(stop <-chan struct{}) error {
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+
+	if err != nil {
+		return errors.Wrap(err, "unable to put terminal in raw mode")
+	}
+
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	// The size is polled rather than relying on a signal for the window size
+	// changing, as that signal isn't available on all platforms.
+
+	done := make(chan struct{})
+
+	defer close(done)
+
+	go func() {
+		var rows, cols int
+
+		for {
+			if r, c, err := pty.Getsize(os.Stdin); err == nil && (r != rows || c != cols) {
+				rows, cols = r, c
+
+				pty.Setsize(t.pty, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)})
+			}
+
+			select {
+			case <-done:
+				return
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}()
+
+	go io.Copy(t.pty, os.Stdin)
+	go io.Copy(os.Stdout, t.pty)
+
+	exited := make(chan error, 1)
+
+	go func() {
+		exited <- t.command.Wait()
+	}()
+
+	select {
+	case <-stop:
+		t.command.Process.Kill()
+		<-exited
+	case <-exited:
+	}
+
+	t.pty.Close()
+
+	return nil
+}
+
+/*
+Send input to the local shell for a clickable action.
+*/
+func (t *Terminal) Send(kind string, text string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var input string
+
+	switch kind {
+	case "execute":
+		input = text + "\r"
+	case "input":
+		input = text
+	case "interrupt":
+		input = "\x03"
+	case "clear":
+		input = "\x0c"
+	default:
+		return errors.Errorf("unsupported terminal action %q", kind)
+	}
+
+	_, err := io.WriteString(t.pty, input)
+
+	return err
+}
@@ -0,0 +1,460 @@
+/*
+Running of a single workshop entirely from the CLI, without Docker or
+Kubernetes. The workshop instructions are rendered by the CLI itself and
+served to a local web browser, with clickable actions in the instructions
+being run in a local shell attached to the terminal the CLI is run from.
+Only the features of a workshop which make sense on the local machine are
+supported, with anything else being reported in the warnings for the
+workshop.
+*/
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/renderer"
+)
+
+/*
+A page of the workshop instructions. The name is the path of the page
+relative to the workshop content directory, without the file extension.
+*/
+type Page struct {
+	Name  string
+	Title string
+	File  string
+}
+
+/*
+A workshop loaded from a local workshop directory.
+*/
+type Workshop struct {
+	Name      string
+	Title     string
+	Directory string
+	Locale    string
+	Classic   bool
+	Pages     []Page
+	Variables map[string]string
+	Environ   []string
+	Warnings  []string
+}
+
+/*
+Load the workshop instructions from a workshop directory. The workshop
+definition is used for the name, title and environment variables of the
+workshop. Workshops using either the Hugo or classic renderer are supported,
+with the order of the pages being worked out the same way as the renderer
+would. Where a locale is given, the pages for that locale are used, falling
+back to the default pages where there is no page for the locale.
+*/
+func LoadWorkshop(directory string, definition *unstructured.Unstructured, locale string) (*Workshop, error) {
+	workshopDirectory := filepath.Join(directory, "workshop")
+	contentDirectory := filepath.Join(workshopDirectory, "content")
+
+	if _, err := os.Stat(contentDirectory); err != nil {
+		return nil, errors.Errorf("no workshop instructions found in %q", directory)
+	}
+
+	name := definition.GetAnnotations()["training.educates.dev/workshop"]
+
+	if name == "" {
+		name = definition.GetName()
+	}
+
+	title, _, _ := unstructured.NestedString(definition.Object, "spec", "title")
+
+	if title == "" {
+		title = name
+	}
+
+	workshop := &Workshop{
+		Name:      name,
+		Title:     title,
+		Directory: directory,
+		Locale:    locale,
+		Variables: map[string]string{},
+	}
+
+	workshop.Variables["workshop_name"] = name
+	workshop.Variables["session_name"] = "local"
+	workshop.Variables["session_namespace"] = "local"
+	workshop.Variables["workshop_namespace"] = "local"
+	workshop.Variables["ingress_protocol"] = "http"
+	workshop.Variables["ingress_domain"] = "localhost"
+	workshop.Variables["ingress_port_suffix"] = ""
+	workshop.Variables["session_hostname"] = "localhost"
+	workshop.Variables["home_directory"] = workshop.HomeDirectory()
+
+	env, _, _ := unstructured.NestedSlice(definition.Object, "spec", "session", "env")
+
+	for _, item := range env {
+		if entry, ok := item.(map[string]interface{}); ok {
+			if name, ok := entry["name"].(string); ok {
+				if _, ok := entry["value"]; !ok {
+					workshop.Warnings = append(workshop.Warnings, fmt.Sprintf("environment variable %s is not set as it doesn't have a value", name))
+					continue
+				}
+
+				workshop.Environ = append(workshop.Environ, fmt.Sprintf("%s=%v", name, entry["value"]))
+			}
+		}
+	}
+
+	applications, _, _ := unstructured.NestedMap(definition.Object, "spec", "session", "applications")
+
+	var unsupported []string
+
+	for application, config := range applications {
+		if application == "terminal" || application == "workshop" {
+			continue
+		}
+
+		if settings, ok := config.(map[string]interface{}); ok && settings["enabled"] == true {
+			unsupported = append(unsupported, application)
+		}
+	}
+
+	sort.Strings(unsupported)
+
+	for _, application := range unsupported {
+		workshop.Warnings = append(workshop.Warnings, fmt.Sprintf("session application %q is not available when running locally", application))
+	}
+
+	var err error
+
+	if _, err = os.Stat(filepath.Join(workshopDirectory, "config.yaml")); err == nil {
+		err = workshop.loadHugoPages(workshopDirectory)
+	} else {
+		workshop.Classic = true
+		err = workshop.loadClassicPages(workshopDirectory)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(workshop.Pages) == 0 {
+		return nil, errors.Errorf("no pages of workshop instructions found in %q", contentDirectory)
+	}
+
+	return workshop, nil
+}
+
+/*
+Return the directory that the local shell for the workshop is run in. The
+exercises directory of the workshop is used if it has one, as that is what
+would be copied into the home directory of a workshop session.
+*/
+func (w *Workshop) HomeDirectory() string {
+	exercises := filepath.Join(w.Directory, "exercises")
+
+	if info, err := os.Stat(exercises); err == nil && info.IsDir() {
+		return exercises
+	}
+
+	return w.Directory
+}
+
+/*
+Return the index of the named page, or -1 if there is no page of that name.
+*/
+func (w *Workshop) PageIndex(name string) int {
+	for i, page := range w.Pages {
+		if page.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+/*
+Find the markdown files for the pages of the workshop instructions, keyed by
+the name of the page, taking pages from the content directories for the
+locale of the workshop in order of precedence.
+*/
+func (w *Workshop) findPages(workshopDirectory string) (map[string]string, error) {
+	contentDirectories, err := renderer.LocaleContentDirectories(workshopDirectory, w.Locale)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+
+	for i := len(contentDirectories) - 1; i >= 0; i-- {
+		found, err := w.findContentPages(filepath.Join(workshopDirectory, contentDirectories[i]))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for name, file := range found {
+			files[name] = file
+		}
+	}
+
+	return files, nil
+}
+
+/*
+Find the markdown files for the pages in a content directory, keyed by the
+name of the page. AsciiDoc pages aren't supported and are reported in a
+warning.
+*/
+func (w *Workshop) findContentPages(contentDirectory string) (map[string]string, error) {
+	files := map[string]string{}
+
+	err := filepath.WalkDir(contentDirectory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		relative, _ := filepath.Rel(contentDirectory, path)
+		name := filepath.ToSlash(strings.TrimSuffix(relative, filepath.Ext(relative)))
+
+		switch filepath.Ext(path) {
+		case ".md":
+			if strings.TrimSuffix(filepath.Base(relative), ".md") == "_index" {
+				return nil
+			}
+
+			files[name] = path
+		case ".adoc":
+			w.Warnings = append(w.Warnings, fmt.Sprintf("page %s is AsciiDoc which cannot be rendered locally", relative))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read workshop instructions in %q", contentDirectory)
+	}
+
+	return files, nil
+}
+
+type hugoFrontMatter struct {
+	Title  string `yaml:"title"`
+	Weight int    `yaml:"weight"`
+}
+
+/*
+Load the pages for a workshop using the Hugo renderer. Where the workshop
+configuration defines a default pathway, the pages in the steps of that are
+used in order. Otherwise all pages are used, ordered by their weight and then
+their path, the same as Hugo would order them.
+*/
+func (w *Workshop) loadHugoPages(workshopDirectory string) error {
+	files, err := w.findPages(workshopDirectory)
+
+	if err != nil {
+		return err
+	}
+
+	type hugoConfig struct {
+		Pathways struct {
+			Default string `yaml:"default"`
+			Paths   map[string]struct {
+				Steps  []string `yaml:"steps"`
+				Params []struct {
+					Name  string `yaml:"name"`
+					Value string `yaml:"value"`
+				} `yaml:"params"`
+			} `yaml:"paths"`
+		} `yaml:"pathways"`
+		Params []struct {
+			Name    string   `yaml:"name"`
+			Value   string   `yaml:"value"`
+			Aliases []string `yaml:"aliases"`
+		} `yaml:"params"`
+	}
+
+	var config hugoConfig
+
+	data, err := os.ReadFile(filepath.Join(workshopDirectory, "config.yaml"))
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read workshop configuration")
+	}
+
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		return errors.Wrap(err, "unable to parse workshop configuration")
+	}
+
+	for _, param := range config.Params {
+		w.Variables[param.Name] = param.Value
+
+		for _, alias := range param.Aliases {
+			w.Variables[alias] = param.Value
+		}
+	}
+
+	type hugoPage struct {
+		Page
+		Weight int
+	}
+
+	var pages []hugoPage
+
+	for name, file := range files {
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read page %q", file)
+		}
+
+		frontMatter, _ := splitFrontMatter(data)
+
+		var details hugoFrontMatter
+
+		if err = yaml.Unmarshal(frontMatter, &details); err != nil {
+			return errors.Wrapf(err, "unable to parse front matter of page %q", file)
+		}
+
+		if details.Title == "" {
+			details.Title = filepath.Base(name)
+		}
+
+		pages = append(pages, hugoPage{Page: Page{Name: name, Title: details.Title, File: file}, Weight: details.Weight})
+	}
+
+	if pathway, found := config.Pathways.Paths[config.Pathways.Default]; found && len(pathway.Steps) != 0 {
+		for _, param := range pathway.Params {
+			w.Variables[param.Name] = param.Value
+		}
+
+		for _, step := range pathway.Steps {
+			found := false
+
+			for _, page := range pages {
+				if page.Name == step {
+					w.Pages = append(w.Pages, page.Page)
+					found = true
+				}
+			}
+
+			if !found {
+				w.Warnings = append(w.Warnings, fmt.Sprintf("page %s in pathway %s doesn't exist", step, config.Pathways.Default))
+			}
+		}
+
+		return nil
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Weight != pages[j].Weight {
+			return pages[i].Weight < pages[j].Weight
+		}
+
+		return pages[i].Name < pages[j].Name
+	})
+
+	for _, page := range pages {
+		w.Pages = append(w.Pages, page.Page)
+	}
+
+	return nil
+}
+
+/*
+Load the pages for a workshop using the classic renderer. The pages are those
+activated in the workshop.yaml file, with titles from the modules.yaml file.
+If there is no workshop.yaml file, all pages are used in order of their path.
+*/
+func (w *Workshop) loadClassicPages(workshopDirectory string) error {
+	files, err := w.findPages(workshopDirectory)
+
+	if err != nil {
+		return err
+	}
+
+	var modules struct {
+		Modules map[string]struct {
+			Name string `yaml:"name"`
+		} `yaml:"modules"`
+	}
+
+	if data, err := os.ReadFile(filepath.Join(workshopDirectory, "modules.yaml")); err == nil {
+		if err = yaml.Unmarshal(data, &modules); err != nil {
+			return errors.Wrap(err, "unable to parse workshop modules")
+		}
+	}
+
+	var activate struct {
+		Modules struct {
+			Activate []string `yaml:"activate"`
+		} `yaml:"modules"`
+	}
+
+	if data, err := os.ReadFile(filepath.Join(workshopDirectory, "workshop.yaml")); err == nil {
+		if err = yaml.Unmarshal(data, &activate); err != nil {
+			return errors.Wrap(err, "unable to parse workshop configuration")
+		}
+	}
+
+	names := activate.Modules.Activate
+
+	if len(names) == 0 {
+		for name := range files {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		file, found := files[name]
+
+		if !found {
+			w.Warnings = append(w.Warnings, fmt.Sprintf("page %s in workshop modules doesn't exist", name))
+			continue
+		}
+
+		title := modules.Modules[name].Name
+
+		if title == "" {
+			title = filepath.Base(name)
+		}
+
+		w.Pages = append(w.Pages, Page{Name: name, Title: title, File: file})
+	}
+
+	return nil
+}
+
+/*
+Split YAML front matter from the start of a page.
+*/
+func splitFrontMatter(data []byte) ([]byte, []byte) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return nil, data
+	}
+
+	end := bytes.Index(data[4:], []byte("\n---"))
+
+	if end == -1 {
+		return nil, data
+	}
+
+	body := data[4+end+4:]
+
+	return data[4 : 4+end], bytes.TrimPrefix(body, []byte("\n"))
+}
@@ -0,0 +1,336 @@
+/*
+Conversion of workshop instructions between Markdown and AsciiDoc, as both
+are supported by the classic workshop renderer. Only the common subset of
+the two formats is converted, with code blocks for clickable actions being
+converted to the form the renderer expects for each format. Anything which
+can't be converted is left as is and reported in the warnings returned.
+*/
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/*
+Return whether the language of a code block names a clickable action rather
+than a language for syntax highlighting. Actions with YAML arguments are
+always qualified with a category, such as "terminal:execute".
+*/
+func isAction(language string) bool {
+	if strings.Contains(language, ":") {
+		return true
+	}
+
+	switch language {
+	case "execute", "execute-all", "copy", "copy-and-edit", "dashboard", "terminal":
+		return true
+	}
+
+	return strings.HasPrefix(language, "execute-")
+}
+
+/*
+Apply a conversion to the text of a line outside any inline code spans, so
+that markup within inline code is left as is.
+*/
+func outsideCode(line string, convert func(string) string) string {
+	parts := strings.Split(line, "`")
+
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = convert(parts[i])
+	}
+
+	return strings.Join(parts, "`")
+}
+
+var (
+	markdownFencePattern    = regexp.MustCompile("^```\\s*([^\\s`]*)\\s*$")
+	markdownHeadingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownBulletPattern   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	markdownNumberPattern   = regexp.MustCompile(`^(\s*)\d+[.)]\s+(.*)$`)
+	markdownRulePattern     = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	markdownBoldPattern     = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	markdownItalicPattern   = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*`)
+	markdownImagePattern    = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	markdownLinkPattern     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	markdownTablePattern    = regexp.MustCompile(`^\s*\|.*\|\s*$`)
+	markdownBlockImageRegex = regexp.MustCompile(`^!\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)$`)
+)
+
+/*
+Convert a page of workshop instructions from Markdown to AsciiDoc.
+*/
+func MarkdownToAsciidoc(text string) (string, []string) {
+	var output []string
+	var warnings []string
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	inFence := false
+	inQuote := false
+	tableWarned := false
+
+	for number, line := range lines {
+		if inFence {
+			if strings.TrimSpace(line) == "```" {
+				output = append(output, "----")
+				inFence = false
+			} else {
+				output = append(output, line)
+			}
+
+			continue
+		}
+
+		if match := markdownFencePattern.FindStringSubmatch(line); match != nil {
+			language := match[1]
+
+			switch {
+			case language == "":
+			case isAction(language) && strings.Contains(language, ":"):
+				output = append(output, fmt.Sprintf("[source,yaml,role=%s]", language))
+			case isAction(language):
+				output = append(output, fmt.Sprintf("[source,bash,role=%s]", language))
+			default:
+				output = append(output, fmt.Sprintf("[source,%s]", language))
+			}
+
+			output = append(output, "----")
+			inFence = true
+
+			continue
+		}
+
+		if strings.HasPrefix(line, ">") {
+			if !inQuote {
+				output = append(output, "____")
+				inQuote = true
+			}
+
+			line = strings.TrimPrefix(strings.TrimPrefix(line, ">"), " ")
+		} else if inQuote {
+			output = append(output, "____")
+			inQuote = false
+		}
+
+		if markdownTablePattern.MatchString(line) && !tableWarned {
+			warnings = append(warnings, fmt.Sprintf("line %d: tables are not converted", number+1))
+			tableWarned = true
+		}
+
+		if markdownRulePattern.MatchString(line) {
+			output = append(output, "'''")
+			continue
+		}
+
+		if match := markdownBlockImageRegex.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			output = append(output, fmt.Sprintf("image::%s[%s]", match[2], match[1]))
+			continue
+		}
+
+		// Separate any heading or list marker from the text so that the
+		// marker isn't mistaken for emphasis when the text is converted.
+
+		prefix := ""
+
+		if match := markdownHeadingPattern.FindStringSubmatch(line); match != nil {
+			prefix, line = strings.Repeat("=", len(match[1])+1)+" ", match[2]
+		} else if match := markdownBulletPattern.FindStringSubmatch(line); match != nil {
+			prefix, line = strings.Repeat("*", len(match[1])/2+1)+" ", match[2]
+		} else if match := markdownNumberPattern.FindStringSubmatch(line); match != nil {
+			prefix, line = strings.Repeat(".", len(match[1])/2+1)+" ", match[2]
+		}
+
+		line = outsideCode(line, func(segment string) string {
+			segment = markdownImagePattern.ReplaceAllString(segment, "image:$2[$1]")
+
+			segment = markdownLinkPattern.ReplaceAllStringFunc(segment, func(link string) string {
+				match := markdownLinkPattern.FindStringSubmatch(link)
+
+				if strings.Contains(match[2], "://") || strings.HasPrefix(match[2], "mailto:") {
+					return fmt.Sprintf("%s[%s]", match[2], match[1])
+				}
+
+				return fmt.Sprintf("link:%s[%s]", match[2], match[1])
+			})
+
+			// Bold text is marked with a placeholder while italic text is
+			// converted, as both use asterisks in AsciiDoc.
+
+			segment = markdownBoldPattern.ReplaceAllString(segment, "\x00$1$2\x00")
+			segment = markdownItalicPattern.ReplaceAllString(segment, "_${1}_")
+
+			return strings.ReplaceAll(segment, "\x00", "*")
+		})
+
+		line = prefix + line
+
+		output = append(output, line)
+	}
+
+	if inQuote {
+		output = append(output, "____")
+	}
+
+	if inFence {
+		warnings = append(warnings, "code block is not terminated")
+		output = append(output, "----")
+	}
+
+	return strings.Join(output, "\n"), warnings
+}
+
+var (
+	asciidocBlockPattern     = regexp.MustCompile(`^\[source(?:,([^,\]]*))?(?:,role=([^,\]]+))?[^\]]*\]\s*$`)
+	asciidocHeadingPattern   = regexp.MustCompile(`^(={1,6})\s+(.*)$`)
+	asciidocBulletPattern    = regexp.MustCompile(`^(\*{1,5}|-)\s+(.*)$`)
+	asciidocNumberPattern    = regexp.MustCompile(`^(\.{1,5})\s+(.*)$`)
+	asciidocAttributePattern = regexp.MustCompile(`^:[\w-]+!?:.*$`)
+	asciidocAdmonition       = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.*)$`)
+	asciidocBoldPattern      = regexp.MustCompile(`\*\*(\S(?:[^*]*?\S)?)\*\*|\B\*(\S(?:[^*]*?\S)?)\*\B`)
+	asciidocItalicPattern    = regexp.MustCompile(`__(\S(?:[^_]*?\S)?)__|\b_(\S(?:[^_]*?\S)?)_\b`)
+	asciidocBlockImage       = regexp.MustCompile(`^image::([^\[\s]+)\[([^\]]*)\]$`)
+	asciidocImagePattern     = regexp.MustCompile(`image:([^\[\s:][^\[\s]*)\[([^\]]*)\]`)
+	asciidocURLPattern       = regexp.MustCompile(`((?:https?|ftp)://[^\s\[]+|mailto:[^\s\[]+)\[([^\]]*)\]`)
+	asciidocLinkPattern      = regexp.MustCompile(`link:([^\s\[]+)\[([^\]]*)\]`)
+	asciidocTablePattern     = regexp.MustCompile(`^\|===\s*$`)
+)
+
+/*
+Convert a page of workshop instructions from AsciiDoc to Markdown.
+*/
+func AsciidocToMarkdown(text string) (string, []string) {
+	var output []string
+	var warnings []string
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	language := ""
+	delimiter := ""
+	inQuote := false
+	tableWarned := false
+	attributesWarned := false
+
+	for number, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if delimiter != "" {
+			if trimmed == delimiter {
+				output = append(output, "```")
+				delimiter = ""
+			} else {
+				output = append(output, line)
+			}
+
+			continue
+		}
+
+		if match := asciidocBlockPattern.FindStringSubmatch(trimmed); match != nil {
+			language = match[1]
+
+			if match[2] != "" {
+				language = match[2]
+			}
+
+			continue
+		}
+
+		if trimmed == "----" || trimmed == "...." {
+			output = append(output, "```"+language)
+			delimiter = trimmed
+			language = ""
+
+			continue
+		}
+
+		language = ""
+
+		if trimmed == "____" {
+			inQuote = !inQuote
+			continue
+		}
+
+		if asciidocAttributePattern.MatchString(line) {
+			if !attributesWarned {
+				warnings = append(warnings, fmt.Sprintf("line %d: document attributes are not converted", number+1))
+				attributesWarned = true
+			}
+
+			continue
+		}
+
+		if asciidocTablePattern.MatchString(line) && !tableWarned {
+			warnings = append(warnings, fmt.Sprintf("line %d: tables are not converted", number+1))
+			tableWarned = true
+		}
+
+		if trimmed == "'''" {
+			output = append(output, "---")
+			continue
+		}
+
+		if match := asciidocBlockImage.FindStringSubmatch(trimmed); match != nil {
+			output = append(output, fmt.Sprintf("![%s](%s)", match[2], match[1]))
+			continue
+		}
+
+		if match := asciidocHeadingPattern.FindStringSubmatch(line); match != nil {
+			level := len(match[1]) - 1
+
+			if level == 0 {
+				level = 1
+			}
+
+			line = strings.Repeat("#", level) + " " + match[2]
+		} else if match := asciidocBulletPattern.FindStringSubmatch(line); match != nil {
+			depth := len(match[1])
+
+			if match[1] == "-" {
+				depth = 1
+			}
+
+			line = strings.Repeat("  ", depth-1) + "- " + match[2]
+		} else if match := asciidocNumberPattern.FindStringSubmatch(line); match != nil {
+			line = strings.Repeat("   ", len(match[1])-1) + "1. " + match[2]
+		} else if match := asciidocAdmonition.FindStringSubmatch(line); match != nil {
+			line = fmt.Sprintf("**%s%s:** %s", match[1][:1], strings.ToLower(match[1][1:]), match[2])
+		}
+
+		line = outsideCode(line, func(segment string) string {
+			segment = asciidocImagePattern.ReplaceAllString(segment, "![$2]($1)")
+			segment = asciidocURLPattern.ReplaceAllStringFunc(segment, func(link string) string {
+				match := asciidocURLPattern.FindStringSubmatch(link)
+
+				if match[2] == "" {
+					return fmt.Sprintf("<%s>", match[1])
+				}
+
+				return fmt.Sprintf("[%s](%s)", match[2], match[1])
+			})
+			segment = asciidocLinkPattern.ReplaceAllString(segment, "[$2]($1)")
+
+			// Italic text is marked with a placeholder while bold text is
+			// converted, as both use asterisks in Markdown.
+
+			segment = asciidocItalicPattern.ReplaceAllString(segment, "\x00$1$2\x00")
+			segment = asciidocBoldPattern.ReplaceAllString(segment, "**$1$2**")
+
+			return strings.ReplaceAll(segment, "\x00", "*")
+		})
+
+		if inQuote {
+			line = strings.TrimRight("> "+line, " ")
+		}
+
+		output = append(output, line)
+	}
+
+	if delimiter != "" {
+		warnings = append(warnings, "code block is not terminated")
+		output = append(output, "```")
+	}
+
+	return strings.Join(output, "\n"), warnings
+}
@@ -0,0 +1,196 @@
+/*
+Support for sending notifications about events such as workshop deployments
+to a webhook, such as a Slack incoming webhook.
+*/
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/webhook"
+)
+
+const (
+	EventDeploy            = "deploy"
+	EventEnvironmentFailed = "environment-failed"
+	EventCapacityExhausted = "capacity-exhausted"
+	EventTest              = "test"
+)
+
+var EventTypes = []string{EventDeploy, EventEnvironmentFailed, EventCapacityExhausted}
+
+// The default payload is compatible with Slack incoming webhooks, as well as
+// other chat services which accept the same format.
+
+const DefaultTemplate = `{"text": {{ json .Message }}}`
+
+type Config struct {
+	URL      string   `yaml:"url"`
+	Template string   `yaml:"template,omitempty"`
+	Events   []string `yaml:"events,omitempty"`
+}
+
+/*
+Details of an event passed to the payload template.
+*/
+type Event struct {
+	Type     string
+	Portal   string
+	Workshop string
+	Message  string
+	Time     time.Time
+}
+
+func configFile() string {
+	return filepath.Join(xdg.DataHome, "educates", "notifications.yaml")
+}
+
+/*
+Load the notification config. If notifications have not been configured nil
+is returned.
+*/
+func LoadConfig() (*Config, error) {
+	data, err := os.ReadFile(configFile())
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read notifications config file %s", configFile())
+	}
+
+	var config Config
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse notifications config file %s", configFile())
+	}
+
+	return &config, nil
+}
+
+func SaveConfig(config *Config) error {
+	err := os.MkdirAll(filepath.Dir(configFile()), os.ModePerm)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to create config directory")
+	}
+
+	data, err := yaml.Marshal(config)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to generate notifications config")
+	}
+
+	// The webhook URL usually embeds a token so restrict access to the file.
+
+	err = os.WriteFile(configFile(), data, 0600)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write notifications config file %s", configFile())
+	}
+
+	return nil
+}
+
+func DeleteConfig() error {
+	err := os.Remove(configFile())
+
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to delete notifications config file %s", configFile())
+	}
+
+	return nil
+}
+
+/*
+Check whether notifications should be sent for the type of event. If no event
+types were specified notifications are sent for all events.
+*/
+func (c *Config) Enabled(eventType string) bool {
+	if eventType == EventTest || len(c.Events) == 0 {
+		return true
+	}
+
+	for _, item := range c.Events {
+		if item == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Generate the payload for an event from the configured template.
+*/
+func (c *Config) Payload(event Event) ([]byte, error) {
+	text := c.Template
+
+	if text == "" {
+		text = DefaultTemplate
+	}
+
+	tmpl, err := template.New("payload").Funcs(template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}).Parse(text)
+
+	if err != nil {
+		return nil, failures.NewValidationError(errors.Wrap(err, "unable to parse notification template"), "")
+	}
+
+	var buffer bytes.Buffer
+
+	err = tmpl.Execute(&buffer, event)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate notification payload")
+	}
+
+	return buffer.Bytes(), nil
+}
+
+/*
+Send a notification for the event to the webhook, if enabled for the type of
+event.
+*/
+func (c *Config) Send(event Event) error {
+	if !c.Enabled(event.Type) {
+		return nil
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	payload, err := c.Payload(event)
+
+	if err != nil {
+		return err
+	}
+
+	err = webhook.Post(c.URL, payload, 10*time.Second)
+
+	if status, ok := err.(*webhook.StatusError); ok {
+		return errors.Errorf("notification webhook returned status %d", status.StatusCode)
+	}
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrap(err, "unable to send notification"), "check the webhook URL with `educates cluster notify view`")
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package operators
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -12,17 +13,37 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 )
 
 var kappAppResource = schema.GroupVersionResource{Group: "kappctrl.k14s.io", Version: "v1alpha1", Resource: "apps"}
 
-func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platformConfig *config.TrainingPlatformConfig) error {
+/*
+Secret holding ytt overlay files supplied by the user. The files are passed
+to ytt along with the platform templates so the overlays are applied on top
+of them. The secret always exists, even if there are no overlays, as the
+App resource refers to it.
+*/
+const overlaysSecretName = "educates-training-platform-overlays"
+
+var overlaysInline = map[string]interface{}{
+	"pathsFrom": []interface{}{
+		map[string]interface{}{
+			"secretRef": map[string]interface{}{
+				"name": overlaysSecretName,
+			},
+		},
+	},
+}
+
+func DeployOperators(ctx context.Context, version string, packageRepository string, clusterConfig *cluster.ClusterConfig, platformConfig *config.TrainingPlatformConfig) error {
 	fmt.Println("Deploying platform operators ...")
 
 	client, err := clusterConfig.GetClient()
@@ -39,7 +60,7 @@ func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platf
 
 	namespacesClient := client.CoreV1().Namespaces()
 
-	_, err = namespacesClient.Get(context.TODO(), "educates-package", metav1.GetOptions{})
+	_, err = namespacesClient.Get(ctx, "educates-package", metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
 		namespaceObj := apiv1.Namespace{
@@ -48,7 +69,7 @@ func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platf
 			},
 		}
 
-		namespacesClient.Create(context.TODO(), &namespaceObj, metav1.CreateOptions{})
+		namespacesClient.Create(ctx, &namespaceObj, metav1.CreateOptions{})
 	}
 
 	secretsClient := client.CoreV1().Secrets("educates-package")
@@ -62,12 +83,35 @@ func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platf
 		},
 	}
 
-	_, err = secretsClient.Create(context.TODO(), secret, metav1.CreateOptions{})
+	_, err = secretsClient.Create(ctx, secret, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create operators config secret")
 	}
 
+	overlays, err := config.LoadPlatformOverlays()
+
+	if err != nil {
+		return err
+	}
+
+	if len(overlays) != 0 {
+		fmt.Printf("Applying %d platform overlays from %s ...\n", len(overlays), config.PlatformOverlaysDir())
+	}
+
+	overlaysSecret := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: overlaysSecretName,
+		},
+		Data: overlays,
+	}
+
+	_, err = secretsClient.Create(ctx, overlaysSecret, metav1.CreateOptions{})
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create operators overlays secret")
+	}
+
 	serviceAccountsClient := client.CoreV1().ServiceAccounts("educates-package")
 
 	serviceAccount := &apiv1.ServiceAccount{
@@ -76,7 +120,7 @@ func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platf
 		},
 	}
 
-	_, err = serviceAccountsClient.Create(context.TODO(), serviceAccount, metav1.CreateOptions{})
+	_, err = serviceAccountsClient.Create(ctx, serviceAccount, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create operators service account")
@@ -102,7 +146,7 @@ func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platf
 		},
 	}
 
-	_, err = clusterRoleBindingClient.Create(context.TODO(), clusterRoleBinding, metav1.CreateOptions{})
+	_, err = clusterRoleBindingClient.Create(ctx, clusterRoleBinding, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create operators role binding")
@@ -125,7 +169,7 @@ func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platf
 			"fetch": []map[string]interface{}{
 				{
 					"imgpkgBundle": map[string]interface{}{
-						"image": "ghcr.io/vmware-tanzu-labs/educates-training-platform:" + version,
+						"image": packageRepository + "/educates-training-platform:" + version,
 					},
 				},
 			},
@@ -146,6 +190,7 @@ func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platf
 								},
 							},
 						},
+						"inline": overlaysInline,
 					},
 				},
 				{
@@ -177,19 +222,16 @@ func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platf
 
 	appResourceClient := dynamicClient.Resource(kappAppResource).Namespace("educates-package")
 
-	_, err = appResourceClient.Create(context.TODO(), appResource, metav1.CreateOptions{})
+	_, err = appResourceClient.Create(ctx, appResource, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create operators app resource")
 	}
 
-	if err := wait.Poll(time.Duration(1)*time.Second, time.Duration(10)*time.Minute, func() (done bool, err error) {
-		resource, err := appResourceClient.Get(context.TODO(), "educates-training-platform", metav1.GetOptions{})
-
-		if err != nil {
-			return false, err
-		}
+	// Watch the App resource for changes to its status rather than polling
+	// it, so we react as soon as reconciliation has completed.
 
+	if err := cluster.WaitForResource(ctx, appResourceClient, "educates-training-platform", time.Duration(10)*time.Minute, func(resource *unstructured.Unstructured) (done bool, err error) {
 		observedGeneration, exists, err := unstructured.NestedInt64(resource.Object, "status", "observedGeneration")
 
 		if err != nil || !exists || resource.GetGeneration() != observedGeneration {
@@ -220,13 +262,17 @@ func DeployOperators(version string, clusterConfig *cluster.ClusterConfig, platf
 		}
 		return false, nil
 	}); err != nil {
+		if err == wait.ErrWaitTimeout {
+			return failures.NewTimeoutError(errors.Wrap(err, "timed out reconciling educates-package/educates-training-platform"), "check the status of the App resource with `kubectl get app -n educates-package`")
+		}
+
 		return fmt.Errorf("%s: Reconciling: educates-package/educates-training-platform", err)
 	}
 
 	return nil
 }
 
-func DeleteOperators(clusterConfig *cluster.ClusterConfig, platformConfig *config.TrainingPlatformConfig) error {
+func DeleteOperators(ctx context.Context, clusterConfig *cluster.ClusterConfig, platformConfig *config.TrainingPlatformConfig) error {
 	dynamicClient, err := clusterConfig.GetDynamicClient()
 
 	if err != nil {
@@ -235,12 +281,12 @@ func DeleteOperators(clusterConfig *cluster.ClusterConfig, platformConfig *confi
 
 	appResourceClient := dynamicClient.Resource(kappAppResource).Namespace("educates-package")
 
-	err = appResourceClient.Delete(context.TODO(), "educates-training-platform", metav1.DeleteOptions{})
+	err = appResourceClient.Delete(ctx, "educates-training-platform", metav1.DeleteOptions{})
 
 	if err == nil {
 		timeout := int64(300)
 
-		watcher, err := appResourceClient.Watch(context.TODO(), metav1.ListOptions{
+		watcher, err := appResourceClient.Watch(ctx, metav1.ListOptions{
 			LabelSelector:  "training.educates.dev/package=training-platform",
 			TimeoutSeconds: &timeout,
 		})
@@ -258,7 +304,7 @@ func DeleteOperators(clusterConfig *cluster.ClusterConfig, platformConfig *confi
 				if event.Type == watch.Deleted {
 					break watch
 				}
-			case <-context.TODO().Done():
+			case <-ctx.Done():
 				return errors.New("timeout waiting for operator deletion")
 			}
 		}
@@ -274,7 +320,7 @@ func DeleteOperators(clusterConfig *cluster.ClusterConfig, platformConfig *confi
 
 	clusterRoleBindingClient := client.RbacV1().ClusterRoleBindings()
 
-	err = clusterRoleBindingClient.Delete(context.TODO(), "educates-training-platform-deploy", metav1.DeleteOptions{})
+	err = clusterRoleBindingClient.Delete(ctx, "educates-training-platform-deploy", metav1.DeleteOptions{})
 
 	// if err != nil {
 	// 	return err
@@ -282,7 +328,7 @@ func DeleteOperators(clusterConfig *cluster.ClusterConfig, platformConfig *confi
 
 	serviceAccountsClient := client.CoreV1().ServiceAccounts("educates-package")
 
-	err = serviceAccountsClient.Delete(context.TODO(), "educates-training-platform-deploy", metav1.DeleteOptions{})
+	err = serviceAccountsClient.Delete(ctx, "educates-training-platform-deploy", metav1.DeleteOptions{})
 
 	// if err != nil {
 	// 	return err
@@ -290,7 +336,13 @@ func DeleteOperators(clusterConfig *cluster.ClusterConfig, platformConfig *confi
 
 	secretsClient := client.CoreV1().Secrets("educates-package")
 
-	err = secretsClient.Delete(context.TODO(), "educates-training-platform-values", metav1.DeleteOptions{})
+	err = secretsClient.Delete(ctx, "educates-training-platform-values", metav1.DeleteOptions{})
+
+	// if err != nil {
+	// 	return err
+	// }
+
+	err = secretsClient.Delete(ctx, overlaysSecretName, metav1.DeleteOptions{})
 
 	// if err != nil {
 	// 	return err
@@ -298,3 +350,183 @@ func DeleteOperators(clusterConfig *cluster.ClusterConfig, platformConfig *confi
 
 	return nil
 }
+
+/*
+Update the secret holding the ytt overlays for the platform from the files in
+the overlays directory, returning the number of overlays applied. Where the
+platform was installed before overlays were supported, the App resource is
+also updated to refer to the secret.
+*/
+func ApplyOverlays(ctx context.Context, clusterConfig *cluster.ClusterConfig) (int, error) {
+	overlays, err := config.LoadPlatformOverlays()
+
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return 0, err
+	}
+
+	secretsClient := client.CoreV1().Secrets("educates-package")
+
+	secret, err := secretsClient.Get(ctx, overlaysSecretName, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		secret = &apiv1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: overlaysSecretName,
+			},
+			Data: overlays,
+		}
+
+		_, err = secretsClient.Create(ctx, secret, metav1.CreateOptions{})
+	} else if err == nil {
+		secret.Data = overlays
+
+		_, err = secretsClient.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to update operators overlays secret")
+	}
+
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return 0, err
+	}
+
+	appResourceClient := dynamicClient.Resource(kappAppResource).Namespace("educates-package")
+
+	resource, err := appResourceClient.Get(ctx, "educates-training-platform", metav1.GetOptions{})
+
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to retrieve operators app resource")
+	}
+
+	templates, _, _ := unstructured.NestedSlice(resource.Object, "spec", "template")
+
+	for i, item := range templates {
+		object, ok := item.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		if _, found, _ := unstructured.NestedMap(object, "ytt"); !found {
+			continue
+		}
+
+		if _, found, _ := unstructured.NestedMap(object, "ytt", "inline"); found {
+			return len(overlays), nil
+		}
+
+		unstructured.SetNestedField(object, runtime.DeepCopyJSONValue(overlaysInline), "ytt", "inline")
+
+		templates[i] = object
+
+		break
+	}
+
+	unstructured.SetNestedSlice(resource.Object, templates, "spec", "template")
+
+	_, err = appResourceClient.Update(ctx, resource, metav1.UpdateOptions{})
+
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to update operators app resource")
+	}
+
+	return len(overlays), nil
+}
+
+/*
+Determine the image reference of the bundle used by the kapp App resource for
+the platform installed in the cluster. This gives the package repository the
+platform was installed from, which will differ from the default where it was
+installed from a relocated platform bundle. An empty string is returned if
+the platform is not installed.
+*/
+func InstalledPackage(ctx context.Context, clusterConfig *cluster.ClusterConfig) (string, error) {
+	dynamicClient, err := clusterConfig.GetDynamicClient()
+
+	if err != nil {
+		return "", err
+	}
+
+	appResourceClient := dynamicClient.Resource(kappAppResource).Namespace("educates-package")
+
+	resource, err := appResourceClient.Get(ctx, "educates-training-platform", metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to retrieve operators app resource")
+	}
+
+	fetch, _, _ := unstructured.NestedSlice(resource.Object, "spec", "fetch")
+
+	for _, item := range fetch {
+		if object, ok := item.(map[string]interface{}); ok {
+			if image, _, _ := unstructured.NestedString(object, "imgpkgBundle", "image"); image != "" {
+				return image, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+/*
+Determine the version of Educates installed in the cluster from the image
+reference of the bundle used by the kapp App resource. An empty string is
+returned if the platform is not installed.
+*/
+func InstalledVersion(ctx context.Context, clusterConfig *cluster.ClusterConfig) (string, error) {
+	image, err := InstalledPackage(ctx, clusterConfig)
+
+	if err != nil {
+		return "", err
+	}
+
+	if index := strings.LastIndex(image, ":"); index != -1 && !strings.Contains(image[index:], "/") {
+		return image[index+1:], nil
+	}
+
+	return "", nil
+}
+
+/*
+Retrieve the configuration the platform was installed with from the secret
+holding the data values for the kapp App resource. Nil is returned if the
+platform is not installed.
+*/
+func InstalledConfig(ctx context.Context, clusterConfig *cluster.ClusterConfig) (*config.TrainingPlatformConfig, error) {
+	client, err := clusterConfig.GetClient()
+
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := client.CoreV1().Secrets("educates-package").Get(ctx, "educates-training-platform-values", metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve operators config secret")
+	}
+
+	platformConfig := &config.TrainingPlatformConfig{}
+
+	if err := yaml.Unmarshal(secret.Data["values.yml"], platformConfig); err != nil {
+		return nil, errors.Wrap(err, "unable to parse operators config")
+	}
+
+	return platformConfig, nil
+}
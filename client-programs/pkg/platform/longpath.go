@@ -0,0 +1,12 @@
+//go:build !windows
+
+package platform
+
+/*
+Return a path in a form which can be used with file operations regardless of
+its length, or the length of paths for files below it. Only Windows limits
+the length of paths, so elsewhere the path is returned unchanged.
+*/
+func LongPath(path string) string {
+	return path
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package platform
+
+import "testing"
+
+func TestLongPath(t *testing.T) {
+	tests := []string{
+		"workshop/content",
+		"/workshops/lab",
+		"/workshops/./lab/../lab",
+		"",
+	}
+
+	for _, path := range tests {
+		t.Run(path, func(t *testing.T) {
+			if got := LongPath(path); got != path {
+				t.Errorf("LongPath(%q) = %q, want path unchanged", path, got)
+			}
+		})
+	}
+}
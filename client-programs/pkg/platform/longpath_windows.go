@@ -0,0 +1,34 @@
+//go:build windows
+
+package platform
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+/*
+Return a path in a form which can be used with file operations regardless of
+its length, or the length of paths for files below it. Windows limits paths
+to 260 characters unless they are absolute paths with the "\\?\" prefix. The
+Go runtime adds the prefix itself for long absolute paths, but not relative
+ones, so paths given by the user, such as to a workshop directory holding
+deeply nested files, need to be converted before they are walked.
+*/
+func LongPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+
+	absPath, err := filepath.Abs(path)
+
+	if err != nil {
+		return path
+	}
+
+	if strings.HasPrefix(absPath, `\\`) {
+		return `\\?\UNC\` + absPath[2:]
+	}
+
+	return `\\?\` + absPath
+}
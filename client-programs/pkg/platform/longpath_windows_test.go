@@ -0,0 +1,79 @@
+//go:build windows
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	workingDirectory, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "relative path", path: `workshop\content`, want: `\\?\` + filepath.Join(workingDirectory, "workshop", "content")},
+		{name: "absolute path", path: `C:\workshops\lab`, want: `\\?\C:\workshops\lab`},
+		{name: "absolute path cleaned", path: `C:\workshops\.\lab\..\lab`, want: `\\?\C:\workshops\lab`},
+		{name: "forward slashes", path: `C:/workshops/lab`, want: `\\?\C:\workshops\lab`},
+		{name: "already prefixed", path: `\\?\C:\workshops\lab`, want: `\\?\C:\workshops\lab`},
+		{name: "prefixed UNC path", path: `\\?\UNC\server\share\lab`, want: `\\?\UNC\server\share\lab`},
+		{name: "UNC path", path: `\\server\share\lab`, want: `\\?\UNC\server\share\lab`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LongPath(tt.path); got != tt.want {
+				t.Errorf("LongPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLongPathAccess(t *testing.T) {
+	// Create a file whose path is longer than Windows allows without the
+	// prefix, using a relative path, and check it can be read back.
+
+	directory := t.TempDir()
+
+	workingDirectory, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(directory); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(workingDirectory) })
+
+	path := strings.Repeat(`directory-with-a-long-name\`, 12) + "file.txt"
+
+	if err := os.MkdirAll(LongPath(filepath.Dir(path)), 0755); err != nil {
+		t.Fatalf("unable to create directory: %v", err)
+	}
+
+	if err := os.WriteFile(LongPath(path), []byte("content"), 0644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+
+	data, err := os.ReadFile(LongPath(path))
+
+	if err != nil {
+		t.Fatalf("unable to read file: %v", err)
+	}
+
+	if string(data) != "content" {
+		t.Errorf("expected file content %q but got %q", "content", data)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Helpers for behaviour which differs between the platforms the CLI runs on,
+so that code elsewhere does not need to assume Unix semantics for running
+commands, opening files and URLs, or handling file permissions.
+*/
+package platform
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Return whether the CLI is running on Windows.
+*/
+func IsWindows() bool {
+	return runtime.GOOS == "windows"
+}
+
+/*
+Return a command which runs a command line using the shell for the platform,
+being "cmd /C" on Windows and "sh -c" elsewhere.
+*/
+func ShellCommand(ctx context.Context, command string) *exec.Cmd {
+	if IsWindows() {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+/*
+Return the interactive shell for the user. On Windows this is given by the
+COMSPEC environment variable, unless SHELL is set such as when running from
+Git Bash. Elsewhere it falls back to /bin/sh.
+*/
+func UserShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+
+	if IsWindows() {
+		if shell := os.Getenv("COMSPEC"); shell != "" {
+			return shell
+		}
+
+		return "cmd.exe"
+	}
+
+	return "/bin/sh"
+}
+
+/*
+Return the editor to use for editing files, as given by the EDITOR environment
+variable, or the default editor for the platform.
+*/
+func UserEditor() string {
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+
+	if IsWindows() {
+		return "notepad"
+	}
+
+	return "vi"
+}
+
+/*
+Open a URL, or a local file, using the default application for it on the
+local system, such as the default web browser for web sites.
+*/
+func Open(target string) error {
+	var command *exec.Cmd
+
+	switch runtime.GOOS {
+	case "linux":
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return errors.New("no graphical display available")
+		}
+
+		command = exec.Command("xdg-open", target)
+	case "windows":
+		// The URL is passed to the protocol handler directly rather than
+		// via "cmd /C start", as cmd would interpret characters such as
+		// "&" in query strings.
+
+		command = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	case "darwin":
+		command = exec.Command("open", target)
+	default:
+		return errors.Errorf("opening files is not supported on %s", runtime.GOOS)
+	}
+
+	return command.Start()
+}
+
+/*
+Return the name of an executable as needed to find it on the platform, with
+the ".exe" extension added on Windows where no extension is given.
+*/
+func ExecutableName(name string) string {
+	if IsWindows() && filepath.Ext(name) == "" {
+		return name + ".exe"
+	}
+
+	return name
+}
+
+/*
+Return the permissions to record for a file when packaging it in an archive.
+Windows doesn't track whether a file is executable, so files are treated as
+executable where they start with "#!", as scripts do, or have an extension
+for a script or program. Elsewhere the permissions of the file are used.
+*/
+func FileMode(path string, info fs.FileInfo) fs.FileMode {
+	mode := info.Mode()
+
+	if !IsWindows() || !mode.IsRegular() {
+		return mode
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".sh", ".bash", ".exe":
+		return mode | 0o111
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return mode
+	}
+
+	defer file.Close()
+
+	header := make([]byte, 2)
+
+	if _, err := io.ReadFull(file, header); err == nil && bytes.Equal(header, []byte("#!")) {
+		return mode | 0o111
+	}
+
+	return mode
+}
@@ -0,0 +1,219 @@
+package platform
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		perm        fs.FileMode
+		wantWindows bool
+		wantUnix    bool
+	}{
+		{name: "shell script by extension", file: "setup.sh", content: "echo setup\n", perm: 0644, wantWindows: true, wantUnix: false},
+		{name: "bash script by extension", file: "setup.BASH", content: "echo setup\n", perm: 0644, wantWindows: true, wantUnix: false},
+		{name: "program by extension", file: "tool.exe", content: "MZ", perm: 0644, wantWindows: true, wantUnix: false},
+		{name: "script by shebang", file: "setup", content: "#!/bin/bash\necho setup\n", perm: 0644, wantWindows: true, wantUnix: false},
+		{name: "python script by shebang", file: "setup.py", content: "#!/usr/bin/env python3\n", perm: 0644, wantWindows: true, wantUnix: false},
+		{name: "executable permissions", file: "setup", content: "echo setup\n", perm: 0755, wantWindows: false, wantUnix: true},
+		{name: "markdown file", file: "README.md", content: "# Workshop\n", perm: 0644, wantWindows: false, wantUnix: false},
+		{name: "hash without bang", file: "config", content: "# comment\n", perm: 0644, wantWindows: false, wantUnix: false},
+		{name: "single byte file", file: "short", content: "#", perm: 0644, wantWindows: false, wantUnix: false},
+		{name: "empty file", file: "empty", content: "", perm: 0644, wantWindows: false, wantUnix: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+
+			if err := os.WriteFile(path, []byte(tt.content), tt.perm); err != nil {
+				t.Fatal(err)
+			}
+
+			// The permissions given when creating a file are subject to
+			// the umask, so they are set explicitly.
+
+			if err := os.Chmod(path, tt.perm); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mode := FileMode(path, info)
+
+			want := tt.wantUnix
+
+			if IsWindows() {
+				want = tt.wantWindows
+			}
+
+			if executable := mode&0o100 != 0; executable != want {
+				t.Errorf("FileMode(%q) = %v, want executable %v", tt.file, mode, want)
+			}
+
+			if mode&^0o111 != info.Mode()&^0o111 {
+				t.Errorf("FileMode(%q) = %v, expected only execute bits to change from %v", tt.file, mode, info.Mode())
+			}
+		})
+	}
+}
+
+func TestFileModeDirectory(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "setup.sh")
+
+	if err := os.Mkdir(directory, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(directory)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mode := FileMode(directory, info); mode != info.Mode() {
+		t.Errorf("FileMode(%q) = %v, want %v for directory", directory, mode, info.Mode())
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr bool
+	}{
+		{name: "echo", command: "echo hello", want: "hello"},
+		{name: "multiple commands", command: "echo first && echo second", want: "first\nsecond"},
+		{name: "exit status", command: "exit 3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := ShellCommand(context.Background(), tt.command)
+
+			want := []string{"sh", "-c", tt.command}
+
+			if IsWindows() {
+				want = []string{"cmd", "/C", tt.command}
+			}
+
+			if strings.Join(command.Args, "\x00") != strings.Join(want, "\x00") {
+				t.Errorf("expected arguments %q but got %q", want, command.Args)
+			}
+
+			output, err := command.Output()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected command %q to fail", tt.command)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unable to run %q: %v", tt.command, err)
+			}
+
+			got := strings.ReplaceAll(string(output), "\r\n", "\n")
+
+			// The space before "&&" is echoed by cmd on Windows.
+
+			got = strings.ReplaceAll(got, " \n", "\n")
+
+			if strings.TrimSpace(got) != tt.want {
+				t.Errorf("expected output %q but got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestShellCommandCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	if err := ShellCommand(ctx, "echo hello").Run(); err == nil {
+		t.Errorf("expected cancelled command to fail")
+	}
+}
+
+func TestExecutableName(t *testing.T) {
+	tests := []struct {
+		name        string
+		wantWindows string
+		wantUnix    string
+	}{
+		{name: "hugo", wantWindows: "hugo.exe", wantUnix: "hugo"},
+		{name: "hugo.exe", wantWindows: "hugo.exe", wantUnix: "hugo.exe"},
+		{name: "script.cmd", wantWindows: "script.cmd", wantUnix: "script.cmd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.wantUnix
+
+			if IsWindows() {
+				want = tt.wantWindows
+			}
+
+			if got := ExecutableName(tt.name); got != want {
+				t.Errorf("ExecutableName(%q) = %q, want %q", tt.name, got, want)
+			}
+		})
+	}
+}
+
+func TestUserShell(t *testing.T) {
+	t.Setenv("SHELL", "/usr/bin/zsh")
+
+	if got := UserShell(); got != "/usr/bin/zsh" {
+		t.Errorf("expected SHELL to be used but got %q", got)
+	}
+
+	t.Setenv("SHELL", "")
+	t.Setenv("COMSPEC", `C:\Windows\system32\cmd.exe`)
+
+	want := "/bin/sh"
+
+	if IsWindows() {
+		want = `C:\Windows\system32\cmd.exe`
+	}
+
+	if got := UserShell(); got != want {
+		t.Errorf("expected shell %q but got %q", want, got)
+	}
+}
+
+func TestUserEditor(t *testing.T) {
+	t.Setenv("EDITOR", "nano")
+
+	if got := UserEditor(); got != "nano" {
+		t.Errorf("expected EDITOR to be used but got %q", got)
+	}
+
+	t.Setenv("EDITOR", "")
+
+	want := "vi"
+
+	if IsWindows() {
+		want = "notepad"
+	}
+
+	if got := UserEditor(); got != want {
+		t.Errorf("expected editor %q but got %q", want, got)
+	}
+}
@@ -0,0 +1,339 @@
+package preflight
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Educates uses Kubernetes APIs which are only available from version 1.21.
+
+var minimumKubernetesVersion = version.MustParseGeneric("1.21.0")
+
+/*
+Check the cluster is running a version of Kubernetes supported by Educates.
+*/
+var KubernetesVersionCheck = Check{
+	Name:         "Kubernetes version",
+	NeedsCluster: true,
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		info, err := env.Client.Discovery().ServerVersion()
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to determine Kubernetes version: %s", err)
+		}
+
+		serverVersion, err := version.ParseGeneric(info.GitVersion)
+
+		if err != nil {
+			return StatusWarning, fmt.Sprintf("unable to parse Kubernetes version %q", info.GitVersion)
+		}
+
+		if serverVersion.LessThan(minimumKubernetesVersion) {
+			return StatusFailed, fmt.Sprintf("version %s is older than the minimum supported version %s", info.GitVersion, minimumKubernetesVersion)
+		}
+
+		return StatusPassed, info.GitVersion
+	},
+}
+
+/*
+Check an ingress controller is installed. When an ingress class is given in
+the installation config it must exist, otherwise any ingress class, or the
+Contour ingress controller installed with the cluster services, is accepted.
+*/
+var IngressControllerCheck = Check{
+	Name:         "Ingress controller",
+	NeedsCluster: true,
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		if class := env.Config.ClusterIngress.Class; class != "" {
+			_, err := env.Client.NetworkingV1().IngressClasses().Get(ctx, class, metav1.GetOptions{})
+
+			if k8serrors.IsNotFound(err) {
+				return StatusFailed, fmt.Sprintf("ingress class %q does not exist", class)
+			}
+
+			if err != nil {
+				return StatusFailed, fmt.Sprintf("unable to retrieve ingress class %q: %s", class, err)
+			}
+
+			return StatusPassed, fmt.Sprintf("ingress class %q", class)
+		}
+
+		classes, err := env.Client.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to list ingress classes: %s", err)
+		}
+
+		for _, item := range classes.Items {
+			if item.Annotations["ingressclass.kubernetes.io/is-default-class"] == "true" {
+				return StatusPassed, fmt.Sprintf("default ingress class %q", item.Name)
+			}
+		}
+
+		_, err = env.Client.CoreV1().Services("projectcontour").Get(ctx, "envoy", metav1.GetOptions{})
+
+		if err == nil {
+			return StatusPassed, "Contour ingress controller"
+		}
+
+		if len(classes.Items) != 0 {
+			return StatusWarning, fmt.Sprintf("no default ingress class, set clusterIngress.class to one of %s", ingressClassNames(classes.Items))
+		}
+
+		return StatusFailed, "no ingress controller found, install one or deploy the cluster services"
+	},
+}
+
+/*
+Check a storage class exists for the persistent volumes used by workshops.
+*/
+var StorageClassCheck = Check{
+	Name:         "Storage class",
+	NeedsCluster: true,
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		if class := env.Config.ClusterStorage.Class; class != "" {
+			_, err := env.Client.StorageV1().StorageClasses().Get(ctx, class, metav1.GetOptions{})
+
+			if k8serrors.IsNotFound(err) {
+				return StatusFailed, fmt.Sprintf("storage class %q does not exist", class)
+			}
+
+			if err != nil {
+				return StatusFailed, fmt.Sprintf("unable to retrieve storage class %q: %s", class, err)
+			}
+
+			return StatusPassed, fmt.Sprintf("storage class %q", class)
+		}
+
+		classes, err := env.Client.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to list storage classes: %s", err)
+		}
+
+		for _, item := range classes.Items {
+			if item.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" || item.Annotations["storageclass.beta.kubernetes.io/is-default-class"] == "true" {
+				return StatusPassed, fmt.Sprintf("default storage class %q", item.Name)
+			}
+		}
+
+		return StatusWarning, "no default storage class, workshops needing persistent volumes will not start unless clusterStorage.class is set"
+	},
+}
+
+/*
+Check the cluster supports the pod security policy engine and the workshop
+rules engine selected in the installation config.
+*/
+var PodSecurityCheck = Check{
+	Name:         "Pod security",
+	NeedsCluster: true,
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		engine := env.Config.ClusterSecurity.PolicyEngine
+
+		var status Status
+		var message string
+
+		switch engine {
+		case "pod-security-policies":
+			status, message = apiCheck(env, "policy/v1beta1", "podsecuritypolicies", "pod security policies")
+		case "security-context-constraints":
+			status, message = apiCheck(env, "security.openshift.io/v1", "securitycontextconstraints", "security context constraints")
+		case "pod-security-standards":
+			info, err := env.Client.Discovery().ServerVersion()
+
+			if err != nil {
+				return StatusFailed, fmt.Sprintf("unable to determine Kubernetes version: %s", err)
+			}
+
+			serverVersion, err := version.ParseGeneric(info.GitVersion)
+
+			if err == nil && serverVersion.LessThan(version.MustParseGeneric("1.23.0")) {
+				return StatusFailed, fmt.Sprintf("pod security standards require Kubernetes 1.23 or later, cluster is %s", info.GitVersion)
+			}
+
+			status, message = StatusPassed, "pod security standards"
+		case "kyverno":
+			status, message = apiCheck(env, "kyverno.io/v1", "clusterpolicies", "Kyverno")
+		case "", "none":
+			status, message = StatusWarning, "no policy engine, workshop pods are not restricted"
+		default:
+			return StatusFailed, fmt.Sprintf("unknown policy engine %q", engine)
+		}
+
+		if status != StatusFailed && env.Config.WorkshopSecurity.RulesEngine == "kyverno" && engine != "kyverno" {
+			if rulesStatus, rulesMessage := apiCheck(env, "kyverno.io/v1", "clusterpolicies", "Kyverno"); rulesStatus != StatusPassed {
+				return rulesStatus, rulesMessage
+			}
+		}
+
+		return status, message
+	},
+}
+
+/*
+Check the API for a policy engine is provided by the cluster. Kyverno is
+installed with the cluster services, so is only a warning if not present.
+*/
+func apiCheck(env *Environment, groupVersion string, resource string, description string) (Status, string) {
+	resources, err := env.Client.Discovery().ServerResourcesForGroupVersion(groupVersion)
+
+	if err == nil {
+		for _, item := range resources.APIResources {
+			if item.Name == resource {
+				return StatusPassed, description
+			}
+		}
+	}
+
+	if groupVersion == "kyverno.io/v1" {
+		return StatusWarning, "Kyverno is not installed, deploy the cluster services before the training platform"
+	}
+
+	return StatusFailed, fmt.Sprintf("%s are not supported by the cluster", description)
+}
+
+/*
+Check host names under the ingress domain resolve. A random host name is
+used as it is only a wildcard DNS record which allows all the host names used
+by Educates to resolve. Not resolving is only a warning as the DNS record may
+be created after installing.
+*/
+var WildcardDNSCheck = Check{
+	Name: "Wildcard DNS",
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		domain := strings.TrimSuffix(env.Config.ClusterIngress.Domain, ".")
+
+		if domain == "" {
+			return StatusFailed, "no ingress domain specified"
+		}
+
+		label := make([]byte, 6)
+
+		if _, err := rand.Read(label); err != nil {
+			return StatusFailed, fmt.Sprintf("unable to generate host name: %s", err)
+		}
+
+		host := fmt.Sprintf("preflight-%s.%s", hex.EncodeToString(label), domain)
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+
+		defer cancel()
+
+		addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+
+		if err != nil || len(addresses) == 0 {
+			return StatusWarning, fmt.Sprintf("host names under %s do not resolve, a wildcard DNS record for *.%s is required", domain, domain)
+		}
+
+		return StatusPassed, fmt.Sprintf("*.%s resolves to %s", domain, strings.Join(addresses, ", "))
+	},
+	Fixes: map[string]string{
+		"darwin":  "for a nip.io domain, check the DNS server for the network isn't blocking responses with private IP addresses, or use a public DNS server such as 8.8.8.8 in System Settings > Network > Details > DNS",
+		"linux":   "for a nip.io domain, check the DNS server for the network isn't blocking responses with private IP addresses, or use a public DNS server such as 8.8.8.8, for example by setting DNS in /etc/systemd/resolved.conf and running `sudo systemctl restart systemd-resolved`",
+		"windows": "for a nip.io domain, check the DNS server for the network isn't blocking responses with private IP addresses, or use a public DNS server such as 8.8.8.8 in Settings > Network & internet > Properties > DNS server assignment",
+		"":        "create a wildcard DNS record for the ingress domain, or use a nip.io domain",
+	},
+}
+
+/*
+Check the image registry Educates images are pulled from can be reached from
+the local host. This is only a warning as it is the cluster nodes which need
+to be able to reach the registry, which may not be the case for the local
+host, or the reverse.
+*/
+var ImageRegistryCheck = Check{
+	Name: "Image registry",
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		host := env.Config.ImageRegistry.Host
+
+		if host == "" {
+			host = "ghcr.io"
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/v2/", host), nil)
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("invalid image registry host %q", host)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+
+		if err != nil {
+			return StatusWarning, fmt.Sprintf("unable to connect to image registry %s: %s", host, err)
+		}
+
+		res.Body.Close()
+
+		// The registry API returns unauthorized when credentials are needed,
+		// which still shows the registry is reachable.
+
+		if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusUnauthorized {
+			return StatusWarning, fmt.Sprintf("image registry %s returned status %d", host, res.StatusCode)
+		}
+
+		return StatusPassed, host
+	},
+}
+
+func ingressClassNames(items []networkingv1.IngressClass) string {
+	var names []string
+
+	for _, item := range items {
+		names = append(names, fmt.Sprintf("%q", item.Name))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+/*
+Checks run before deploying the cluster services, which install the ingress
+controller and policy engine, so those are not checked for.
+*/
+func ServicesChecks() []Check {
+	return []Check{
+		KubernetesVersionCheck,
+		StorageClassCheck,
+		ImageRegistryCheck,
+	}
+}
+
+/*
+Checks run before deploying the training platform.
+*/
+func PlatformChecks() []Check {
+	return []Check{
+		KubernetesVersionCheck,
+		IngressControllerCheck,
+		StorageClassCheck,
+		PodSecurityCheck,
+		WildcardDNSCheck,
+		ImageRegistryCheck,
+	}
+}
+
+/*
+Checks run before creating a local cluster, which can't depend on the cluster.
+*/
+func LocalClusterChecks() []Check {
+	return []Check{
+		WildcardDNSCheck,
+		ImageRegistryCheck,
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package preflight
+
+import "golang.org/x/sys/unix"
+
+/*
+Return the number of bytes free on the filesystem holding a path which are
+available to unprivileged users.
+*/
+func freeDiskSpace(path string) (uint64, error) {
+	var stat unix.Statfs_t
+
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package preflight
+
+import "golang.org/x/sys/windows"
+
+/*
+Return the number of bytes free on the volume holding a path which are
+available to the current user.
+*/
+func freeDiskSpace(path string) (uint64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var free uint64
+
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &free, nil, nil); err != nil {
+		return 0, err
+	}
+
+	return free, nil
+}
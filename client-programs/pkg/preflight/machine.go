@@ -0,0 +1,382 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Educates relies on docker features which are only available from 20.10.
+
+var minimumDockerVersion = version.MustParseGeneric("20.10.0")
+
+const gibibyte = 1024 * 1024 * 1024
+
+/*
+Check the docker daemon can be reached and is a supported version. A separate
+client is created to the one in the environment, as that is only set once the
+docker daemon is known to be reachable.
+*/
+var DockerDaemonCheck = Check{
+	Name: "Docker daemon",
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to create docker client: %s", err)
+		}
+
+		defer cli.Close()
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+
+		defer cancel()
+
+		serverVersion, err := cli.ServerVersion(ctx)
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to connect to docker daemon at %s", cli.DaemonHost())
+		}
+
+		parsedVersion, err := version.ParseGeneric(serverVersion.Version)
+
+		if err != nil {
+			return StatusWarning, fmt.Sprintf("unable to parse docker version %q", serverVersion.Version)
+		}
+
+		if parsedVersion.LessThan(minimumDockerVersion) {
+			return StatusFailed, fmt.Sprintf("version %s is older than the minimum supported version %s", serverVersion.Version, minimumDockerVersion)
+		}
+
+		return StatusPassed, fmt.Sprintf("version %s (%s/%s)", serverVersion.Version, serverVersion.Os, serverVersion.Arch)
+	},
+	Fixes: map[string]string{
+		"darwin":  "start Docker Desktop, or install it from https://docs.docker.com/desktop/install/mac-install/",
+		"linux":   "start docker with `sudo systemctl start docker`, and add your user to the docker group with `sudo usermod -aG docker $USER` then log in again",
+		"windows": "start Docker Desktop, or install it from https://docs.docker.com/desktop/install/windows-install/, and run the command from a WSL 2 shell",
+		"":        "start the docker daemon, or set DOCKER_HOST to the address of the docker daemon",
+	},
+}
+
+/*
+Check the memory and CPUs available to docker. On macOS and Windows these
+are the resources of the virtual machine docker runs in, not of the host.
+*/
+var DockerResourcesCheck = Check{
+	Name:        "Docker resources",
+	NeedsDocker: true,
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		info, err := env.Docker.Info(ctx)
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to query docker daemon: %s", err)
+		}
+
+		memory := float64(info.MemTotal) / gibibyte
+
+		message := fmt.Sprintf("%.1f GiB memory, %d CPUs", memory, info.NCPU)
+
+		if memory < 4 {
+			return StatusFailed, fmt.Sprintf("%s, at least 4 GiB memory is required", message)
+		}
+
+		if memory < 8 || info.NCPU < 2 {
+			return StatusWarning, fmt.Sprintf("%s, 8 GiB memory and 2 CPUs or more are recommended", message)
+		}
+
+		return StatusPassed, message
+	},
+	Fixes: map[string]string{
+		"darwin":  "increase the memory and CPUs for Docker Desktop in Settings > Resources > Advanced",
+		"linux":   "stop other programs using memory, or add memory to the host, as docker uses the memory of the host directly",
+		"windows": "increase the memory and CPUs for WSL 2 by setting memory and processors in the [wsl2] section of %UserProfile%\\.wslconfig, then run `wsl --shutdown` and restart Docker Desktop",
+		"":        "increase the memory and CPUs available to docker",
+	},
+}
+
+/*
+Check free disk space where docker stores images. When docker runs in a
+virtual machine its storage can't be inspected, so the free space of the home
+directory is checked instead as a guide.
+*/
+var DiskSpaceCheck = Check{
+	Name:        "Disk space",
+	NeedsDocker: true,
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		info, err := env.Docker.Info(ctx)
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to query docker daemon: %s", err)
+		}
+
+		path := info.DockerRootDir
+
+		if _, err := os.Stat(path); path == "" || err != nil {
+			if path, err = os.UserHomeDir(); err != nil {
+				return StatusWarning, "unable to determine home directory"
+			}
+		}
+
+		free, err := freeDiskSpace(path)
+
+		if err != nil {
+			return StatusWarning, fmt.Sprintf("unable to determine free disk space for %s: %s", path, err)
+		}
+
+		message := fmt.Sprintf("%.1f GiB free in %s", float64(free)/gibibyte, path)
+
+		if free < 10*gibibyte {
+			return StatusFailed, fmt.Sprintf("%s, at least 10 GiB is required", message)
+		}
+
+		if free < 20*gibibyte {
+			return StatusWarning, fmt.Sprintf("%s, 20 GiB or more is recommended", message)
+		}
+
+		return StatusPassed, message
+	},
+	Fixes: map[string]string{
+		"darwin":  "free up space with `docker system prune`, or increase the virtual disk limit for Docker Desktop in Settings > Resources > Advanced",
+		"linux":   "free up space with `docker system prune`, or move the docker data directory to a larger disk by setting data-root in /etc/docker/daemon.json",
+		"windows": "free up space with `docker system prune`, or move the disk image for Docker Desktop to a larger disk in Settings > Resources > Advanced",
+		"":        "free up space with `docker system prune`",
+	},
+}
+
+/*
+Check for Kind clusters other than the one for Educates. The Educates CLI
+creates its cluster without needing the kind command, but clusters created
+with it also map host ports, so can stop the Educates cluster starting.
+*/
+var KindClustersCheck = Check{
+	Name:        "Kind clusters",
+	NeedsDocker: true,
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		containers, err := env.Docker.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: filters.NewArgs(filters.Arg("label", "io.x-k8s.kind.cluster"))})
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to list docker containers: %s", err)
+		}
+
+		var others []string
+
+		educates := false
+
+		for _, container := range containers {
+			name := container.Labels["io.x-k8s.kind.cluster"]
+
+			if name == "educates" {
+				educates = true
+			} else if container.State == "running" && !containsString(others, name) {
+				others = append(others, name)
+			}
+		}
+
+		sort.Strings(others)
+
+		var details []string
+
+		if path, err := exec.LookPath("kind"); err == nil {
+			details = append(details, fmt.Sprintf("kind command found at %s", path))
+		}
+
+		if len(others) != 0 {
+			return StatusWarning, strings.Join(append(details, fmt.Sprintf("other Kind clusters are running: %s", strings.Join(others, ", "))), ", ")
+		}
+
+		if educates {
+			details = append(details, "Educates cluster exists")
+		} else {
+			details = append(details, "no Kind clusters exist")
+		}
+
+		return StatusPassed, strings.Join(details, ", ")
+	},
+	Fixes: map[string]string{
+		"": "stop the other Kind clusters with `docker stop NAME-control-plane`, or delete them with `kind delete cluster --name NAME`",
+	},
+}
+
+/*
+Check the subnets of the docker network used by Kind don't overlap with
+other docker networks, or with networks the host is attached to, such as
+when connected to a VPN, as addresses in that range would not be reachable
+from the cluster. The network is only created with the first Kind cluster.
+*/
+var KindNetworkCheck = Check{
+	Name:        "Kind network",
+	NeedsDocker: true,
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		kindNetwork, err := env.Docker.NetworkInspect(ctx, "kind", types.NetworkInspectOptions{})
+
+		if client.IsErrNotFound(err) {
+			return StatusPassed, "kind network doesn't exist yet"
+		}
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to inspect kind network: %s", err)
+		}
+
+		var subnets []*net.IPNet
+
+		for _, item := range kindNetwork.IPAM.Config {
+			if _, subnet, err := net.ParseCIDR(item.Subnet); err == nil {
+				subnets = append(subnets, subnet)
+			}
+		}
+
+		overlaps := func(subnet *net.IPNet, other *net.IPNet) bool {
+			return subnet.Contains(other.IP) || other.Contains(subnet.IP)
+		}
+
+		var conflicts []string
+
+		networks, err := env.Docker.NetworkList(ctx, types.NetworkListOptions{})
+
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("unable to list docker networks: %s", err)
+		}
+
+		for _, item := range networks {
+			if item.ID == kindNetwork.ID {
+				continue
+			}
+
+			for _, config := range item.IPAM.Config {
+				if _, other, err := net.ParseCIDR(config.Subnet); err == nil {
+					for _, subnet := range subnets {
+						if overlaps(subnet, other) {
+							conflicts = append(conflicts, fmt.Sprintf("docker network %s (%s)", item.Name, other))
+						}
+					}
+				}
+			}
+		}
+
+		// Bridges and virtual interfaces created by docker itself on Linux
+		// hosts will have addresses in the subnet, so are ignored.
+
+		interfaces, _ := net.Interfaces()
+
+		for _, iface := range interfaces {
+			if strings.HasPrefix(iface.Name, "br-") || strings.HasPrefix(iface.Name, "docker") || strings.HasPrefix(iface.Name, "veth") {
+				continue
+			}
+
+			addresses, _ := iface.Addrs()
+
+			for _, address := range addresses {
+				if ip, other, err := net.ParseCIDR(address.String()); err == nil {
+					for _, subnet := range subnets {
+						if overlaps(subnet, other) {
+							conflicts = append(conflicts, fmt.Sprintf("interface %s (%s)", iface.Name, ip))
+						}
+					}
+				}
+			}
+		}
+
+		var names []string
+
+		for _, subnet := range subnets {
+			names = append(names, subnet.String())
+		}
+
+		if len(conflicts) != 0 {
+			return StatusFailed, fmt.Sprintf("subnet %s overlaps with %s", strings.Join(names, ", "), strings.Join(conflicts, ", "))
+		}
+
+		return StatusPassed, fmt.Sprintf("subnet %s", strings.Join(names, ", "))
+	},
+	Fixes: map[string]string{
+		"": "delete the Educates cluster and any other Kind clusters, remove the network with `docker network rm kind`, and create the cluster again, disconnecting from any VPN first so docker picks a different subnet",
+	},
+}
+
+/*
+Check the host ports the ingress controller of the local cluster is exposed
+on are not in use by other programs. The ports being in use by the Educates
+cluster is fine. Where the port can't be listened on, such as when not
+running as root on Linux, connecting to the port shows whether it is in use.
+*/
+var HostPortsCheck = Check{
+	Name: "Host ports",
+	Run: func(ctx context.Context, env *Environment) (Status, string) {
+		if env.Docker != nil {
+			container, err := env.Docker.ContainerInspect(ctx, "educates-control-plane")
+
+			if err == nil && container.State != nil && container.State.Running {
+				return StatusPassed, "ports 80 and 443 are used by the Educates cluster"
+			}
+		}
+
+		var used []string
+
+		for _, port := range []int{80, 443} {
+			address := fmt.Sprintf(":%d", port)
+
+			listener, err := net.Listen("tcp", address)
+
+			if err == nil {
+				listener.Close()
+				continue
+			}
+
+			connection, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), time.Second)
+
+			if err == nil {
+				connection.Close()
+
+				used = append(used, fmt.Sprint(port))
+			}
+		}
+
+		if len(used) != 0 {
+			return StatusFailed, fmt.Sprintf("port %s in use by another program", strings.Join(used, " and "))
+		}
+
+		return StatusPassed, "ports 80 and 443 are free"
+	},
+	Fixes: map[string]string{
+		"darwin":  "find the program using the port with `sudo lsof -nP -iTCP:80 -iTCP:443 -sTCP:LISTEN` and stop it",
+		"linux":   "find the program using the port with `sudo ss -ltnp '( sport = :80 or sport = :443 )'` and stop it, such as with `sudo systemctl stop apache2` or `sudo systemctl stop nginx`",
+		"windows": "find the process using the port with `netstat -ano | findstr LISTENING` and stop it, the port often being used by IIS or the World Wide Web Publishing Service",
+		"":        "stop the program using the port",
+	},
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Checks run against the local machine before creating a local cluster, or
+when diagnosing problems with the local environment.
+*/
+func MachineChecks() []Check {
+	return []Check{
+		DockerDaemonCheck,
+		DockerResourcesCheck,
+		DiskSpaceCheck,
+		KindClustersCheck,
+		KindNetworkCheck,
+		WildcardDNSCheck,
+		HostPortsCheck,
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Pre-flight checks run before installing or deploying Educates, so problems
+with the cluster or the installation config which would cause the install
+to fail, or Educates not to work once installed, are reported up front.
+*/
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/docker/docker/client"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusWarning Status = "warning"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+/*
+Environment the checks are run against. The client is nil when checks are run
+before the cluster exists, in which case checks needing the cluster are
+skipped. Similarly, the docker client is nil when the docker daemon can't be
+reached, or isn't needed, and checks needing docker are skipped.
+*/
+type Environment struct {
+	Client kubernetes.Interface
+	Docker client.APIClient
+	Config *config.InstallationConfig
+}
+
+/*
+A single pre-flight check. A check returns a warning rather than failing when
+the problem would not stop the install from succeeding, or may be resolved
+after the install. Instructions for fixing a problem can be given for each
+operating system, keyed by the value of runtime.GOOS, with an empty key used
+for any operating system not listed.
+*/
+type Check struct {
+	Name         string
+	NeedsCluster bool
+	NeedsDocker  bool
+	Run          func(ctx context.Context, env *Environment) (Status, string)
+	Fixes        map[string]string
+}
+
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+/*
+Run each of the checks in turn, returning the results in the same order.
+*/
+func RunChecks(ctx context.Context, env *Environment, checks []Check) []Result {
+	var results []Result
+
+	for _, check := range checks {
+		if check.NeedsCluster && env.Client == nil {
+			results = append(results, Result{Name: check.Name, Status: StatusSkipped, Message: "no cluster available"})
+			continue
+		}
+
+		if check.NeedsDocker && env.Docker == nil {
+			results = append(results, Result{Name: check.Name, Status: StatusSkipped, Message: "docker not available"})
+			continue
+		}
+
+		status, message := check.Run(ctx, env)
+
+		result := Result{Name: check.Name, Status: status, Message: message}
+
+		if status == StatusWarning || status == StatusFailed {
+			result.Fix = check.fix(runtime.GOOS)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+/*
+Return the instructions for fixing a problem found by the check on an
+operating system.
+*/
+func (c *Check) fix(goos string) string {
+	if fix, found := c.Fixes[goos]; found {
+		return fix
+	}
+
+	return c.Fixes[""]
+}
+
+/*
+Return whether any of the checks failed.
+*/
+func Failed(results []Result) bool {
+	for _, result := range results {
+		if result.Status == StatusFailed {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Write the results of the checks, one per line, with instructions for fixing
+any problems found on the line following.
+*/
+func Report(out io.Writer, results []Result) {
+	labels := map[Status]string{
+		StatusPassed:  "PASS",
+		StatusWarning: "WARN",
+		StatusFailed:  "FAIL",
+		StatusSkipped: "SKIP",
+	}
+
+	for _, result := range results {
+		fmt.Fprintf(out, "[%s] %s: %s\n", labels[result.Status], result.Name, result.Message)
+
+		if result.Fix != "" {
+			fmt.Fprintf(out, "       Fix: %s\n", result.Fix)
+		}
+	}
+}
+
+/*
+Return the checks with any of the named checks removed.
+*/
+func Without(checks []Check, names ...string) []Check {
+	var remaining []Check
+
+	for _, check := range checks {
+		excluded := false
+
+		for _, name := range names {
+			if check.Name == name {
+				excluded = true
+			}
+		}
+
+		if !excluded {
+			remaining = append(remaining, check)
+		}
+	}
+
+	return remaining
+}
@@ -0,0 +1,78 @@
+/*
+Display of QR codes in the terminal, so that URLs can be scanned by a phone
+or tablet when a web browser can't be opened on the local machine. Encoding
+of the QR code is done using github.com/skip2/go-qrcode.
+*/
+package qrcode
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	goqrcode "github.com/skip2/go-qrcode"
+)
+
+/*
+A QR code, with the modules indexed by row and then column. A true value is
+a dark module. The modules include the quiet zone required around the code
+for it to be scanned.
+*/
+type Code struct {
+	Size    int
+	Modules [][]bool
+}
+
+/*
+Encode text as a QR code with low error correction, using the smallest
+version which can hold it.
+*/
+func Encode(text string) (*Code, error) {
+	code, err := goqrcode.New(text, goqrcode.Low)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encode QR code")
+	}
+
+	modules := code.Bitmap()
+
+	return &Code{Size: len(modules), Modules: modules}, nil
+}
+
+/*
+Write the QR code to a terminal. Two rows of modules are output per line of
+text using half block characters, with explicit colours so the code displays
+correctly whatever the colours used by the terminal.
+*/
+func (c *Code) WriteTerminal(w io.Writer) error {
+	module := func(x int, y int) bool {
+		if y >= c.Size {
+			return false
+		}
+
+		return c.Modules[y][x]
+	}
+
+	colour := func(dark bool, base int) int {
+		if dark {
+			return base
+		}
+
+		return base + 67
+	}
+
+	var output strings.Builder
+
+	for y := 0; y < c.Size; y += 2 {
+		for x := 0; x < c.Size; x++ {
+			fmt.Fprintf(&output, "\033[%d;%dm▀", colour(module(x, y), 30), colour(module(x, y+1), 40))
+		}
+
+		output.WriteString("\033[0m\n")
+	}
+
+	_, err := io.WriteString(w, output.String())
+
+	return err
+}
@@ -0,0 +1,134 @@
+package qrcode
+
+import (
+	"strings"
+	"testing"
+)
+
+const testURL = "https://educates.dev/workshops/lab-markdown-sample?session=abc123"
+
+const quietZone = 4
+
+func encodeTestURL(t *testing.T) *Code {
+	t.Helper()
+
+	code, err := Encode(testURL)
+
+	if err != nil {
+		t.Fatalf("unable to encode %q: %v", testURL, err)
+	}
+
+	return code
+}
+
+func TestEncodeSize(t *testing.T) {
+	code := encodeTestURL(t)
+
+	if len(code.Modules) != code.Size {
+		t.Fatalf("expected %d rows, got %d", code.Size, len(code.Modules))
+	}
+
+	for y, row := range code.Modules {
+		if len(row) != code.Size {
+			t.Fatalf("expected %d modules in row %d, got %d", code.Size, y, len(row))
+		}
+	}
+
+	// Each version adds four modules to the 21 modules of version 1.
+
+	symbol := code.Size - 2*quietZone
+
+	if symbol < 21 || (symbol-21)%4 != 0 {
+		t.Fatalf("size %d without quiet zone is not a valid QR code size", symbol)
+	}
+}
+
+func TestEncodeQuietZone(t *testing.T) {
+	code := encodeTestURL(t)
+
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			inside := x >= quietZone && x < code.Size-quietZone && y >= quietZone && y < code.Size-quietZone
+
+			if !inside && code.Modules[y][x] {
+				t.Fatalf("module (%d, %d) in quiet zone is dark", x, y)
+			}
+		}
+	}
+}
+
+func TestEncodeFinderPatterns(t *testing.T) {
+	code := encodeTestURL(t)
+
+	far := code.Size - quietZone - 7
+
+	corners := [][2]int{
+		{quietZone, quietZone},
+		{far, quietZone},
+		{quietZone, far},
+	}
+
+	for _, corner := range corners {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				// Outer ring of 7x7 and inner 3x3 square are dark, with a
+				// light ring between them.
+
+				border := dx == 0 || dx == 6 || dy == 0 || dy == 6
+				centre := dx >= 2 && dx <= 4 && dy >= 2 && dy <= 4
+
+				x, y := corner[0]+dx, corner[1]+dy
+
+				if code.Modules[y][x] != (border || centre) {
+					t.Fatalf("finder pattern at (%d, %d) has wrong module at (%d, %d)", corner[0], corner[1], x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteTerminal(t *testing.T) {
+	code := encodeTestURL(t)
+
+	var output strings.Builder
+
+	if err := code.WriteTerminal(&output); err != nil {
+		t.Fatalf("unable to write QR code: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+
+	if len(lines) != (code.Size+1)/2 {
+		t.Fatalf("expected %d lines, got %d", (code.Size+1)/2, len(lines))
+	}
+
+	// Each line holds two rows of modules, so the first line falls wholly
+	// within the quiet zone and must be light in both halves.
+
+	light := "\033[97;107m▀"
+
+	if lines[0] != strings.Repeat(light, code.Size)+"\033[0m" {
+		t.Fatalf("expected first line to be blank quiet zone, got %q", lines[0])
+	}
+
+	for i, line := range lines {
+		if !strings.HasSuffix(line, "\033[0m") {
+			t.Fatalf("line %d does not reset colours", i)
+		}
+
+		if count := strings.Count(line, "▀"); count != code.Size {
+			t.Fatalf("expected %d modules on line %d, got %d", code.Size, i, count)
+		}
+	}
+
+	// Line containing the top row of the finder patterns has the top edge
+	// dark on both the top left and top right finder patterns.
+
+	row := strings.Split(strings.TrimSuffix(lines[quietZone/2], "\033[0m"), "▀")
+
+	for _, x := range []int{quietZone, code.Size - quietZone - 1} {
+		if !strings.HasPrefix(row[x], "\033[30;") {
+			t.Fatalf("expected finder pattern edge at column %d, got %q", x, row[x])
+		}
+	}
+}
@@ -20,9 +20,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func DeployRegistry() error {
-	ctx := context.Background()
-
+func DeployRegistry(ctx context.Context) error {
 	fmt.Println("Deploying local image registry")
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
@@ -103,9 +101,7 @@ func DeployRegistry() error {
 	return nil
 }
 
-func LinkRegistryToCluster() error {
-	ctx := context.Background()
-
+func LinkRegistryToCluster(ctx context.Context) error {
 	fmt.Println("Linking local image registry to cluster")
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
@@ -125,9 +121,7 @@ func LinkRegistryToCluster() error {
 	return nil
 }
 
-func DeleteRegistry() error {
-	ctx := context.Background()
-
+func DeleteRegistry(ctx context.Context) error {
 	fmt.Println("Deleting local image registry")
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
@@ -166,9 +160,7 @@ func DeleteRegistry() error {
 	return nil
 }
 
-func UpdateRegistryService(k8sclient *kubernetes.Clientset) error {
-	ctx := context.Background()
-
+func UpdateRegistryService(ctx context.Context, k8sclient *kubernetes.Clientset) error {
 	cli, err := client.NewClientWithOpts(client.FromEnv)
 
 	if err != nil {
@@ -234,19 +226,19 @@ func UpdateRegistryService(k8sclient *kubernetes.Clientset) error {
 
 	endpointSliceClient := k8sclient.DiscoveryV1().EndpointSlices("default")
 
-	endpointSliceClient.Delete(context.TODO(), "registry-1", *metav1.NewDeleteOptions(0))
+	endpointSliceClient.Delete(ctx, "registry-1", *metav1.NewDeleteOptions(0))
 
 	servicesClient := k8sclient.CoreV1().Services("default")
 
-	servicesClient.Delete(context.TODO(), "registry", *metav1.NewDeleteOptions(0))
+	servicesClient.Delete(ctx, "registry", *metav1.NewDeleteOptions(0))
 
-	_, err = endpointSliceClient.Create(context.TODO(), &endpointSlice, metav1.CreateOptions{})
+	_, err = endpointSliceClient.Create(ctx, &endpointSlice, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create registry headless service endpoint")
 	}
 
-	_, err = servicesClient.Create(context.TODO(), &service, metav1.CreateOptions{})
+	_, err = servicesClient.Create(ctx, &service, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create registry headless service")
@@ -0,0 +1,177 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+)
+
+// Script run in the workshop base image to build the workshop instructions.
+// It mirrors the steps the workshop session runs on startup, but without any
+// details of a workshop session being available.
+
+const hugoBuildScript = `
+set -eo pipefail
+
+umask 0000
+
+BUILD_DIR=/tmp/educates-build
+WORKSHOP_DIR=$BUILD_DIR/workshop
+
+mkdir -p $BUILD_DIR
+cp -rL /source $WORKSHOP_DIR
+
+jq -n env >$BUILD_DIR/workshop-environment.json
+
+CONFIG_ARGS=()
+
+if [ -f $WORKSHOP_DIR/config.yaml ]; then
+    CONFIG_ARGS+=(-f $WORKSHOP_DIR/config.yaml --file-mark config.yaml:path=workshop-configuration.yaml --file-mark config.yaml:type=data)
+fi
+
+ytt -f /opt/eduk8s/etc/templates/workshop-variables.yaml \
+    -f $BUILD_DIR/workshop-environment.json --file-mark workshop-environment.json:type=data \
+    --data-value workshop_title="$WORKSHOP_TITLE" \
+    --data-value workshop_description="$WORKSHOP_DESCRIPTION" \
+    "${CONFIG_ARGS[@]}" -o json >$BUILD_DIR/workshop-variables.json
+
+ytt -f /opt/eduk8s/etc/templates/hugo-configuration.yaml \
+    -f $BUILD_DIR/workshop-variables.json --file-mark workshop-variables.json:type=data \
+    "${CONFIG_ARGS[@]}" >$BUILD_DIR/hugo-configuration.yaml
+
+if [ -n "$WORKSHOP_LOCALE" ]; then
+    echo "languageCode: $WORKSHOP_LOCALE" >$BUILD_DIR/hugo-locale.yaml
+    echo "module:" >>$BUILD_DIR/hugo-locale.yaml
+    echo "  mounts:" >>$BUILD_DIR/hugo-locale.yaml
+    for CONTENT_DIR in $WORKSHOP_CONTENT_DIRS; do
+        echo "  - source: $CONTENT_DIR" >>$BUILD_DIR/hugo-locale.yaml
+        echo "    target: content" >>$BUILD_DIR/hugo-locale.yaml
+    done
+    CONFIG_FILES=$BUILD_DIR/hugo-configuration.yaml,$BUILD_DIR/hugo-locale.yaml
+else
+    CONFIG_FILES=$BUILD_DIR/hugo-configuration.yaml
+fi
+
+CONFIG_DIR_ARGS=()
+
+if [ -d $WORKSHOP_DIR/config ]; then
+    CONFIG_DIR_ARGS+=(--configDir $WORKSHOP_DIR/config)
+fi
+
+HUGO_CACHEDIR=$BUILD_DIR/cache hugo --ignoreCache --cleanDestinationDir --minify \
+    "${CONFIG_DIR_ARGS[@]}" \
+    --config $CONFIG_FILES \
+    --source $WORKSHOP_DIR \
+    --destination /output \
+    --themesDir /opt/eduk8s/etc/themes \
+    --theme educates \
+    --baseURL "$WORKSHOP_BASE_URL"
+
+chmod -R a+rwX /output
+`
+
+/*
+Build the instructions for a workshop using the Hugo renderer, running Hugo
+in a container using the workshop base image so the same version and theme
+are used as when a workshop session is started. The generated files are
+written to the output directory, with links in the generated files relative
+to the base URL given. If a locale is given, the instructions are built for
+that locale. If the build fails the error includes the output from Hugo.
+*/
+func BuildHugoContent(ctx context.Context, workshopDir string, outputDir string, image string, title string, description string, locale string, baseURL string) error {
+	contentDirs, err := LocaleContentDirectories(workshopDir, locale)
+
+	if err != nil {
+		return err
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create docker client")
+	}
+
+	// Use a local copy of the image if pulling it fails, as can be the case
+	// for images only built locally.
+
+	reader, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+
+	if err == nil {
+		io.Copy(io.Discard, reader)
+		reader.Close()
+	} else if _, _, err := cli.ImageInspectWithRaw(ctx, image); err != nil {
+		return errors.Wrapf(err, "cannot pull workshop base image %q", image)
+	}
+
+	// The container runs as a different user to the host, so the output
+	// directory must be writable by anyone.
+
+	if err = os.Chmod(outputDir, 0777); err != nil {
+		return errors.Wrapf(err, "unable to set permissions of %q", outputDir)
+	}
+
+	hostConfig := &container.HostConfig{
+		Binds: []string{
+			fmt.Sprintf("%s:/source:ro", workshopDir),
+			fmt.Sprintf("%s:/output", outputDir),
+		},
+	}
+
+	resp, err := cli.ContainerCreate(ctx, &container.Config{
+		Image:      image,
+		Entrypoint: []string{"/bin/bash", "-c", hugoBuildScript},
+		Env: []string{
+			fmt.Sprintf("WORKSHOP_TITLE=%s", title),
+			fmt.Sprintf("WORKSHOP_DESCRIPTION=%s", description),
+			fmt.Sprintf("WORKSHOP_LOCALE=%s", locale),
+			fmt.Sprintf("WORKSHOP_CONTENT_DIRS=%s", strings.Join(contentDirs, " ")),
+			fmt.Sprintf("WORKSHOP_BASE_URL=%s", baseURL),
+		},
+		Tty: false,
+	}, hostConfig, nil, nil, "")
+
+	if err != nil {
+		return errors.Wrap(err, "cannot create container for building workshop instructions")
+	}
+
+	defer cli.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
+
+	if err = cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return errors.Wrap(err, "cannot start container for building workshop instructions")
+	}
+
+	var exitCode int64
+
+	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return errors.Wrap(err, "failed waiting for build of workshop instructions")
+		}
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	}
+
+	if exitCode != 0 {
+		var output bytes.Buffer
+
+		if logs, err := cli.ContainerLogs(ctx, resp.ID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true}); err == nil {
+			stdcopy.StdCopy(&output, &output, logs)
+			logs.Close()
+		}
+
+		return errors.Errorf("build of workshop instructions failed:\n%s", strings.TrimSpace(output.String()))
+	}
+
+	return nil
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
 	"gopkg.in/yaml.v2"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -195,7 +196,7 @@ func fetchSessionVariables(sessionURL string, password string) (map[string]strin
 	return params, nil
 }
 
-func generateHugoConfiguration(workshopDir string, target string, params map[string]string, sessionURL string) error {
+func generateHugoConfiguration(workshopDir string, target string, params map[string]string, sessionURL string, locale string, contentDirs []string) error {
 	var err error
 
 	// Read user workshop config with details of any pathways.
@@ -268,15 +269,29 @@ func generateHugoConfiguration(workshopDir string, target string, params map[str
 		}
 	}
 
+	type HugoModuleConfig struct {
+		Mounts []map[string]string `yaml:"mounts"`
+	}
+
 	type HugoConfig struct {
-		BaseURL string                 `yaml:"baseURL"`
-		Params  map[string]interface{} `yaml:"params"`
+		BaseURL      string                 `yaml:"baseURL"`
+		LanguageCode string                 `yaml:"languageCode,omitempty"`
+		Module       *HugoModuleConfig      `yaml:"module,omitempty"`
+		Params       map[string]interface{} `yaml:"params"`
 	}
 
 	config := HugoConfig{Params: make(map[string]interface{})}
 
 	config.BaseURL = fmt.Sprintf("%s/workshop/content/", sessionURL)
 
+	// Where a locale is given, the content directories for the locale are
+	// mounted over the default content directory.
+
+	if locale != "" {
+		config.LanguageCode = locale
+		config.Module = &HugoModuleConfig{Mounts: localeContentMounts(contentDirs)}
+	}
+
 	for paramName, paramValue := range params {
 		config.Params[paramName] = paramValue
 	}
@@ -389,12 +404,18 @@ func populateTemporaryDirectory() (string, error) {
 
 type ServerCleanupFunc func()
 
-func RunHugoServer(workshopRoot string, kubeconfig string, workshop string, portal string, localHost string, localPort int, hugoPort int, token string, files bool, cleanupFunc ServerCleanupFunc) error {
+func RunHugoServer(workshopRoot string, kubeconfig string, workshop string, portal string, localHost string, localPort int, hugoPort int, token string, files bool, ingresses map[string]int, locale string, cleanupFunc ServerCleanupFunc) error {
 	var err error
 	var tempDir string
 
 	workshopDir := filepath.Join(workshopRoot, "workshop")
 
+	contentDirs, err := LocaleContentDirectories(workshopDir, locale)
+
+	if err != nil {
+		return err
+	}
+
 	// First create directory to hold unpacked files for Hugo to use.
 
 	if tempDir, err = populateTemporaryDirectory(); err != nil {
@@ -405,7 +426,7 @@ func RunHugoServer(workshopRoot string, kubeconfig string, workshop string, port
 
 	// Also catch signals so we can try and cleanup temporary directory.
 
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
@@ -490,7 +511,7 @@ func RunHugoServer(workshopRoot string, kubeconfig string, workshop string, port
 
 			// Generate (or regenerate) the Hugo configuration.
 
-			err = generateHugoConfiguration(workshopDir, tempDir, params, sessionURL)
+			err = generateHugoConfiguration(workshopDir, tempDir, params, sessionURL, locale, contentDirs)
 
 			if err != nil {
 				fmt.Println("Unable to generate Hugo configuration:", err)
@@ -565,12 +586,15 @@ func RunHugoServer(workshopRoot string, kubeconfig string, workshop string, port
 				return err
 			}
 
-			header.Name, err = filepath.Rel(workshopRoot, filepath.ToSlash(file))
+			relPath, err := filepath.Rel(workshopRoot, file)
 
 			if err != nil {
 				return err
 			}
 
+			header.Name = filepath.ToSlash(relPath)
+			header.Mode = int64(platform.FileMode(file, fi).Perm())
+
 			if header.Name == ".git" || strings.HasPrefix(header.Name, ".git/") {
 				return nil
 			}
@@ -601,7 +625,11 @@ func RunHugoServer(workshopRoot string, kubeconfig string, workshop string, port
 
 	fmt.Println("Proxy listening on:", portString)
 
-	log.Fatal(http.ListenAndServe(portString, nil))
+	for name, port := range ingresses {
+		fmt.Printf("Proxying ingress %s to localhost:%d\n", name, port)
+	}
+
+	log.Fatal(http.ListenAndServe(portString, ingressProxyHandler(http.DefaultServeMux, ingresses, token)))
 
 	return nil
 }
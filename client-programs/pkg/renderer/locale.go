@@ -0,0 +1,84 @@
+package renderer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+/*
+Return whether a locale is valid, being a language code optionally followed
+by region or other subtags separated by hyphens, such as "fr" or "fr-CA".
+*/
+func IsValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+/*
+Return the content directories of the workshop instructions to use for a
+locale, in order of precedence. Pages for a locale are held in a directory
+alongside the default content directory, named with the locale as suffix,
+such as "content.fr-CA". Where a page doesn't exist for the locale, that of
+the more general locale, such as "content.fr", is used instead, falling back
+to the default content directory. Directories for the locale which don't
+exist are skipped, but at least one must exist if a locale is given.
+*/
+func LocaleContentDirectories(workshopDir string, locale string) ([]string, error) {
+	if locale == "" {
+		return []string{"content"}, nil
+	}
+
+	if !IsValidLocale(locale) {
+		return nil, errors.Errorf("invalid locale %q", locale)
+	}
+
+	var directories []string
+
+	parts := strings.Split(locale, "-")
+
+	for i := len(parts); i > 0; i-- {
+		name := fmt.Sprintf("content.%s", strings.Join(parts[:i], "-"))
+
+		if info, err := os.Stat(filepath.Join(workshopDir, name)); err == nil && info.IsDir() {
+			directories = append(directories, name)
+		}
+	}
+
+	if len(directories) == 0 {
+		return nil, errors.Errorf("no workshop instructions found for locale %q", locale)
+	}
+
+	return append(directories, "content"), nil
+}
+
+/*
+Return whether a directory of the workshop directory holds the workshop
+instructions for a locale.
+*/
+func IsLocaleContentDirectory(name string) bool {
+	return strings.HasPrefix(name, "content.") && IsValidLocale(strings.TrimPrefix(name, "content."))
+}
+
+/*
+Generate the Hugo module mounts for the content directories for a locale.
+Where more than one mount has the same target Hugo uses the file from the
+first mount listed, giving the fallback between locales.
+*/
+func localeContentMounts(directories []string) []map[string]string {
+	var mounts []map[string]string
+
+	for _, directory := range directories {
+		mounts = append(mounts, map[string]string{
+			"source": directory,
+			"target": "content",
+		})
+	}
+
+	return mounts
+}
@@ -0,0 +1,82 @@
+package renderer
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+/*
+Wrap the handler for the local proxy so that requests for session ingresses
+are passed through to services on the local machine. The ingress is given
+by the X-Ingress-Name header added when the hosted workshop is patched to
+proxy the ingress to the local proxy, or else is worked out from the host
+name, with ingress host names having the form NAME-SESSION.DOMAIN.
+*/
+func ingressProxyHandler(next http.Handler, ingresses map[string]int, token string) http.Handler {
+	if len(ingresses) == 0 {
+		return next
+	}
+
+	// Check longer names first so that where the name of one ingress is a
+	// prefix of that of another, the host name matches the right one.
+
+	var names []string
+
+	proxies := map[string]*httputil.ReverseProxy{}
+
+	for name, port := range ingresses {
+		names = append(names, name)
+
+		target, _ := url.Parse(fmt.Sprintf("http://localhost:%d", port))
+
+		proxies[name] = httputil.NewSingleHostReverseProxy(target)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ingress := r.Header.Get("X-Ingress-Name")
+
+		if _, found := proxies[ingress]; !found {
+			ingress = ""
+
+			host := r.Host
+
+			if hostname, _, err := net.SplitHostPort(host); err == nil {
+				host = hostname
+			}
+
+			label := strings.SplitN(host, ".", 2)[0]
+
+			for _, name := range names {
+				if label == name || strings.HasPrefix(label, name+"-") {
+					ingress = name
+					break
+				}
+			}
+		}
+
+		if ingress == "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if token != "" && r.Header.Get("X-Access-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("403 - Invalid access token"))
+
+			return
+		}
+
+		r.Header.Del("X-Access-Token")
+		r.Header.Del("X-Ingress-Name")
+
+		proxies[ingress].ServeHTTP(w, r)
+	})
+}
@@ -7,7 +7,7 @@ import (
 	"html/template"
 	"io"
 	"os"
-	"path"
+	"path/filepath"
 
 	"github.com/adrg/xdg"
 	"github.com/docker/docker/api/types"
@@ -33,9 +33,7 @@ address=/{{$Domain}}/{{$.TargetAddress}}
 {{- end }}
 `
 
-func DeployResolver(domain string, targetAddress string, extraDomains []string) error {
-	ctx := context.Background()
-
+func DeployResolver(ctx context.Context, domain string, targetAddress string, extraDomains []string) error {
 	fmt.Println("Deploying local DNS resolver")
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
@@ -99,8 +97,8 @@ func DeployResolver(domain string, targetAddress string, extraDomains []string)
 		return errors.Wrap(err, "failed to generate dnsmasq config")
 	}
 
-	configFileDir := path.Join(xdg.DataHome, "educates")
-	configFileName := path.Join(configFileDir, "dnsmasq.conf")
+	configFileDir := filepath.Join(xdg.DataHome, "educates")
+	configFileName := filepath.Join(configFileDir, "dnsmasq.conf")
 
 	_ = os.Mkdir(configFileDir, os.ModePerm)
 
@@ -156,9 +154,7 @@ func DeployResolver(domain string, targetAddress string, extraDomains []string)
 	return nil
 }
 
-func DeleteResolver() error {
-	ctx := context.Background()
-
+func DeleteResolver(ctx context.Context) error {
 	fmt.Println("Deleting local DNS resolver")
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
@@ -18,11 +18,12 @@ import (
 
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cluster"
 	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
 )
 
 var kappAppResource = schema.GroupVersionResource{Group: "kappctrl.k14s.io", Version: "v1alpha1", Resource: "apps"}
 
-func DeployServices(version string, clusterConfig *cluster.ClusterConfig, servicesConfig *config.ClusterEssentialsConfig) error {
+func DeployServices(ctx context.Context, version string, packageRepository string, clusterConfig *cluster.ClusterConfig, servicesConfig *config.ClusterEssentialsConfig) error {
 	fmt.Println("Deploying cluster services ...")
 
 	client, err := clusterConfig.GetClient()
@@ -39,7 +40,7 @@ func DeployServices(version string, clusterConfig *cluster.ClusterConfig, servic
 
 	namespacesClient := client.CoreV1().Namespaces()
 
-	_, err = namespacesClient.Get(context.TODO(), "educates-package", metav1.GetOptions{})
+	_, err = namespacesClient.Get(ctx, "educates-package", metav1.GetOptions{})
 
 	if k8serrors.IsNotFound(err) {
 		namespaceObj := apiv1.Namespace{
@@ -48,7 +49,7 @@ func DeployServices(version string, clusterConfig *cluster.ClusterConfig, servic
 			},
 		}
 
-		_, err = namespacesClient.Create(context.TODO(), &namespaceObj, metav1.CreateOptions{})
+		_, err = namespacesClient.Create(ctx, &namespaceObj, metav1.CreateOptions{})
 	}
 
 	secretsClient := client.CoreV1().Secrets("educates-package")
@@ -62,7 +63,7 @@ func DeployServices(version string, clusterConfig *cluster.ClusterConfig, servic
 		},
 	}
 
-	_, err = secretsClient.Create(context.TODO(), secret, metav1.CreateOptions{})
+	_, err = secretsClient.Create(ctx, secret, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create services config secret")
@@ -76,7 +77,7 @@ func DeployServices(version string, clusterConfig *cluster.ClusterConfig, servic
 		},
 	}
 
-	_, err = serviceAccountsClient.Create(context.TODO(), serviceAccount, metav1.CreateOptions{})
+	_, err = serviceAccountsClient.Create(ctx, serviceAccount, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create services service account")
@@ -102,7 +103,7 @@ func DeployServices(version string, clusterConfig *cluster.ClusterConfig, servic
 		},
 	}
 
-	_, err = clusterRoleBindingClient.Create(context.TODO(), clusterRoleBinding, metav1.CreateOptions{})
+	_, err = clusterRoleBindingClient.Create(ctx, clusterRoleBinding, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create services role binding")
@@ -125,7 +126,7 @@ func DeployServices(version string, clusterConfig *cluster.ClusterConfig, servic
 			"fetch": []map[string]interface{}{
 				{
 					"imgpkgBundle": map[string]interface{}{
-						"image": "ghcr.io/vmware-tanzu-labs/educates-cluster-essentials:" + version,
+						"image": packageRepository + "/educates-cluster-essentials:" + version,
 					},
 				},
 			},
@@ -173,19 +174,16 @@ func DeployServices(version string, clusterConfig *cluster.ClusterConfig, servic
 
 	appResourceClient := dynamicClient.Resource(kappAppResource).Namespace("educates-package")
 
-	_, err = appResourceClient.Create(context.TODO(), appResource, metav1.CreateOptions{})
+	_, err = appResourceClient.Create(ctx, appResource, metav1.CreateOptions{})
 
 	if err != nil {
 		return errors.Wrap(err, "unable to create services app resource")
 	}
 
-	if err := wait.Poll(time.Duration(1)*time.Second, time.Duration(10)*time.Minute, func() (done bool, err error) {
-		resource, err := appResourceClient.Get(context.TODO(), "educates-cluster-essentials", metav1.GetOptions{})
-
-		if err != nil {
-			return false, err
-		}
+	// Watch the App resource for changes to its status rather than polling
+	// it, so we react as soon as reconciliation has completed.
 
+	if err := cluster.WaitForResource(ctx, appResourceClient, "educates-cluster-essentials", time.Duration(10)*time.Minute, func(resource *unstructured.Unstructured) (done bool, err error) {
 		observedGeneration, exists, err := unstructured.NestedInt64(resource.Object, "status", "observedGeneration")
 
 		if err != nil || !exists || resource.GetGeneration() != observedGeneration {
@@ -216,13 +214,17 @@ func DeployServices(version string, clusterConfig *cluster.ClusterConfig, servic
 		}
 		return false, nil
 	}); err != nil {
+		if err == wait.ErrWaitTimeout {
+			return failures.NewTimeoutError(errors.Wrap(err, "timed out reconciling educates-package/educates-cluster-essentials"), "check the status of the App resource with `kubectl get app -n educates-package`")
+		}
+
 		return fmt.Errorf("%s: Reconciling: educates-package/educates-cluster-essentials", err)
 	}
 
 	return nil
 }
 
-func DeleteServices(clusterConfig *cluster.ClusterConfig, servicesConfig *config.ClusterEssentialsConfig) error {
+func DeleteServices(ctx context.Context, clusterConfig *cluster.ClusterConfig, servicesConfig *config.ClusterEssentialsConfig) error {
 	dynamicClient, err := clusterConfig.GetDynamicClient()
 
 	if err != nil {
@@ -231,12 +233,12 @@ func DeleteServices(clusterConfig *cluster.ClusterConfig, servicesConfig *config
 
 	appResourceClient := dynamicClient.Resource(kappAppResource).Namespace("educates-package")
 
-	err = appResourceClient.Delete(context.TODO(), "educates-cluster-essentials", metav1.DeleteOptions{})
+	err = appResourceClient.Delete(ctx, "educates-cluster-essentials", metav1.DeleteOptions{})
 
 	if err == nil {
 		timeout := int64(300)
 
-		watcher, err := appResourceClient.Watch(context.TODO(), metav1.ListOptions{
+		watcher, err := appResourceClient.Watch(ctx, metav1.ListOptions{
 			LabelSelector:  "training.educates.dev/package=cluster-essentials",
 			TimeoutSeconds: &timeout,
 		})
@@ -254,7 +256,7 @@ func DeleteServices(clusterConfig *cluster.ClusterConfig, servicesConfig *config
 				if event.Type == watch.Deleted {
 					break watch
 				}
-			case <-context.TODO().Done():
+			case <-ctx.Done():
 				return errors.New("timeout waiting for service deletion")
 			}
 		}
@@ -270,7 +272,7 @@ func DeleteServices(clusterConfig *cluster.ClusterConfig, servicesConfig *config
 
 	clusterRoleBindingClient := client.RbacV1().ClusterRoleBindings()
 
-	err = clusterRoleBindingClient.Delete(context.TODO(), "educates-cluster-essentials-deploy", metav1.DeleteOptions{})
+	err = clusterRoleBindingClient.Delete(ctx, "educates-cluster-essentials-deploy", metav1.DeleteOptions{})
 
 	// if err != nil {
 	// 	return err
@@ -278,7 +280,7 @@ func DeleteServices(clusterConfig *cluster.ClusterConfig, servicesConfig *config
 
 	serviceAccountsClient := client.CoreV1().ServiceAccounts("educates-package")
 
-	err = serviceAccountsClient.Delete(context.TODO(), "educates-cluster-essentials-deploy", metav1.DeleteOptions{})
+	err = serviceAccountsClient.Delete(ctx, "educates-cluster-essentials-deploy", metav1.DeleteOptions{})
 
 	// if err != nil {
 	// 	return err
@@ -286,7 +288,7 @@ func DeleteServices(clusterConfig *cluster.ClusterConfig, servicesConfig *config
 
 	secretsClient := client.CoreV1().Secrets("educates-package")
 
-	err = secretsClient.Delete(context.TODO(), "educates-cluster-essentials-values", metav1.DeleteOptions{})
+	err = secretsClient.Delete(ctx, "educates-cluster-essentials-values", metav1.DeleteOptions{})
 
 	// if err != nil {
 	// 	return err
@@ -0,0 +1,213 @@
+/*
+Local record of how the Educates CLI is used, kept only when the user opts in.
+*/
+package telemetry
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/webhook"
+)
+
+/*
+A record of running a command. Only the name of the command is recorded, and
+not any arguments, flags or error messages, as these may identify the user or
+the resources they are working with. Failures are recorded by category only.
+*/
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Command  string    `json:"command"`
+	Duration float64   `json:"duration"`
+	Result   string    `json:"result"`
+	Category string    `json:"category,omitempty"`
+	Version  string    `json:"version"`
+	OS       string    `json:"os"`
+	Arch     string    `json:"arch"`
+}
+
+/*
+Records as sent when exported. The installation ID is generated at random the
+first time records are exported so records from the same installation of the
+CLI can be grouped without identifying the user.
+*/
+type Export struct {
+	Installation string  `json:"installation"`
+	Entries      []Entry `json:"entries"`
+}
+
+func LogFile() string {
+	return filepath.Join(xdg.StateHome, "educates", "telemetry.log")
+}
+
+func installationFile() string {
+	return filepath.Join(xdg.StateHome, "educates", "telemetry.id")
+}
+
+/*
+Append an entry to the telemetry log, with each entry written as a single
+line of JSON.
+*/
+func Record(entry Entry) error {
+	logFile := LogFile()
+
+	err := os.MkdirAll(filepath.Dir(logFile), os.ModePerm)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to create telemetry log directory")
+	}
+
+	data, err := json.Marshal(&entry)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode telemetry log entry")
+	}
+
+	file, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to open telemetry log %s", logFile)
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to write telemetry log %s", logFile)
+	}
+
+	return nil
+}
+
+/*
+Read all entries from the telemetry log, oldest first. Lines which cannot be
+decoded are skipped.
+*/
+func Read() ([]Entry, error) {
+	var entries []Entry
+
+	file, err := os.Open(LogFile())
+
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open telemetry log %s", LogFile())
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		var entry Entry
+
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "unable to read telemetry log %s", LogFile())
+	}
+
+	return entries, nil
+}
+
+/*
+Remove the telemetry log, discarding all entries recorded so far.
+*/
+func Clear() error {
+	err := os.Remove(LogFile())
+
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to remove telemetry log %s", LogFile())
+	}
+
+	return nil
+}
+
+/*
+Return the random ID for this installation of the CLI, generating it if it
+doesn't already exist.
+*/
+func InstallationID() (string, error) {
+	data, err := os.ReadFile(installationFile())
+
+	if err == nil && len(strings.TrimSpace(string(data))) != 0 {
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	if err != nil && !os.IsNotExist(err) {
+		return "", errors.Wrapf(err, "unable to read telemetry installation ID %s", installationFile())
+	}
+
+	value := make([]byte, 16)
+
+	if _, err := rand.Read(value); err != nil {
+		return "", errors.Wrap(err, "unable to generate telemetry installation ID")
+	}
+
+	id := hex.EncodeToString(value)
+
+	if err := os.MkdirAll(filepath.Dir(installationFile()), os.ModePerm); err != nil {
+		return "", errors.Wrap(err, "unable to create telemetry log directory")
+	}
+
+	if err := os.WriteFile(installationFile(), []byte(id+"\n"), 0o600); err != nil {
+		return "", errors.Wrapf(err, "unable to write telemetry installation ID %s", installationFile())
+	}
+
+	return id, nil
+}
+
+/*
+Send entries to an endpoint as JSON. Times are rounded down to the hour
+before being sent so that individual runs of commands can't be linked to
+other records of activity.
+*/
+func Send(endpoint string, entries []Entry) error {
+	id, err := InstallationID()
+
+	if err != nil {
+		return err
+	}
+
+	export := Export{Installation: id, Entries: []Entry{}}
+
+	for _, entry := range entries {
+		entry.Time = entry.Time.UTC().Truncate(time.Hour)
+
+		export.Entries = append(export.Entries, entry)
+	}
+
+	data, err := json.Marshal(&export)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to encode telemetry entries")
+	}
+
+	err = webhook.Post(endpoint, data, 30*time.Second)
+
+	if status, ok := err.(*webhook.StatusError); ok {
+		return errors.Errorf("telemetry endpoint returned status %d", status.StatusCode)
+	}
+
+	if err != nil {
+		return failures.NewConnectionError(errors.Wrap(err, "unable to contact telemetry endpoint"), "check the telemetry endpoint in the client config")
+	}
+
+	return nil
+}
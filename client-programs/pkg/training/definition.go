@@ -0,0 +1,334 @@
+package training
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/kubectl/pkg/scheme"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/cache"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Annotation on a workshop definition selecting how the name for the workshop
+in the cluster is generated when a name isn't supplied. With "location", the
+default, the name is derived from the name in the workshop definition and the
+location it was loaded from. With "title" the name is derived from the title
+of the workshop, so the name stays the same when the workshop is moved.
+*/
+const WorkshopNamingAnnotation = "training.educates.dev/naming"
+
+/*
+Maximum length of the name for a workshop in the cluster. The name is used as
+the value of labels on resources created for workshop sessions, so can be no
+longer than is allowed for a label value.
+*/
+const maxWorkshopNameLength = 63
+
+/*
+Options for loading workshop definitions. The path can be a local directory
+or file, or a HTTP/HTTPS URL, with the workshop file being the location of
+the workshop definition file relative to a directory. Where a name isn't
+given, the name for the workshop in the cluster is generated from the name
+of the training portal and the workshop definition.
+*/
+type DefinitionOptions struct {
+	Name            string
+	Path            string
+	Portal          string
+	WorkshopFile    string
+	WorkshopVersion string
+	DataValuesFlags yttcmd.DataValuesFlags
+}
+
+/*
+Load a workshop definition, failing if the workshop definition file holds
+more than one definition.
+*/
+func LoadWorkshopDefinition(options *DefinitionOptions) (*unstructured.Unstructured, error) {
+	workshops, err := LoadWorkshopDefinitions(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(workshops) != 1 {
+		return nil, failures.NewValidationError(errors.Errorf("expected a single workshop definition but found %d", len(workshops)), "")
+	}
+
+	return workshops[0], nil
+}
+
+/*
+Load all the workshop definitions from a workshop definition file, which may
+hold more than one definition.
+*/
+func LoadWorkshopDefinitions(options *DefinitionOptions) ([]*unstructured.Unstructured, error) {
+	path := options.Path
+	workshopFile := options.WorkshopFile
+
+	// Parse the workshop location so we can determine if it is a local file
+	// or accessible using a HTTP/HTTPS URL.
+
+	var urlInfo *url.URL
+	var err error
+
+	if urlInfo, err = url.Parse(path); err != nil {
+		return nil, errors.Wrap(err, "unable to parse workshop location")
+	}
+
+	// Check if file system path first (not HTTP/HTTPS) and if so normalize
+	// the path. If it the path references a directory, then extend the path
+	// so we look for the workshop file within that directory.
+
+	if urlInfo.Scheme != "http" && urlInfo.Scheme != "https" {
+		path = filepath.Clean(path)
+
+		if path, err = filepath.Abs(path); err != nil {
+			return nil, errors.Wrap(err, "couldn't convert workshop location to absolute path")
+		}
+
+		if !filepath.IsAbs(workshopFile) {
+			fileInfo, err := os.Stat(path)
+
+			if err != nil {
+				return nil, errors.Wrap(err, "couldn't test if workshop location is a directory")
+			}
+
+			if fileInfo.IsDir() {
+				path = filepath.Join(path, workshopFile)
+			}
+		} else {
+			path = workshopFile
+		}
+	}
+
+	// Read in the workshop definition as raw data ready for parsing.
+
+	var workshopData []byte
+
+	if urlInfo.Scheme != "http" && urlInfo.Scheme != "https" {
+		if workshopData, err = os.ReadFile(path); err != nil {
+			return nil, errors.Wrap(err, "couldn't read workshop definition data file")
+		}
+	} else if cachedData, found := cache.Get("downloads", path); found {
+		// Use a recent copy of the workshop definition if we have one, to
+		// avoid downloading it again when iterating on a workshop.
+
+		workshopData = cachedData
+	} else {
+		var client http.Client
+
+		resp, err := client.Get(path)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't download workshop definition from host")
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New("failed to download workshop definition from host")
+		}
+
+		workshopData, err = io.ReadAll(resp.Body)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read workshop definition from host")
+		}
+
+		cache.Put("downloads", path, workshopData)
+	}
+
+	// Process the workshop YAML data in case it contains ytt templating.
+
+	if workshopData, err = ProcessWorkshopDefinitions(workshopData, options.DataValuesFlags); err != nil {
+		return nil, errors.Wrap(err, "unable to process workshop definition as template")
+	}
+
+	// Parse the workshop definitions. A file may hold more than one
+	// workshop definition, separated as distinct YAML documents.
+
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder()
+
+	documents := yamlutil.NewYAMLReader(bufio.NewReader(bytes.NewReader(workshopData)))
+
+	var workshops []*unstructured.Unstructured
+
+	for {
+		document, err := documents.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't parse workshop definition")
+		}
+
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+
+		workshop := &unstructured.Unstructured{}
+
+		err = runtime.DecodeInto(decoder, document, workshop)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't parse workshop definition")
+		}
+
+		// Verify the type of resource definition.
+
+		if workshop.GetAPIVersion() != "training.educates.dev/v1beta1" || workshop.GetKind() != "Workshop" {
+			return nil, errors.New("invalid type for workshop definition")
+		}
+
+		workshops = append(workshops, workshop)
+	}
+
+	if len(workshops) == 0 {
+		return nil, errors.New("no workshop definition found")
+	}
+
+	if options.Name != "" && len(workshops) > 1 {
+		return nil, failures.NewValidationError(errors.New("name cannot be supplied when there are multiple workshop definitions"), "")
+	}
+
+	for _, workshop := range workshops {
+		if err = finishWorkshopDefinition(workshop, options.Name, path, urlInfo, options.Portal, options.WorkshopVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	return workshops, nil
+}
+
+/*
+Record where a workshop definition was loaded from and derive the name used
+for it in the cluster.
+*/
+func finishWorkshopDefinition(workshop *unstructured.Unstructured, name string, path string, urlInfo *url.URL, portal string, workshopVersion string) error {
+	// Add annotations recording details about original workshop location.
+
+	annotations := workshop.GetAnnotations()
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations["training.educates.dev/workshop"] = workshop.GetName()
+
+	if urlInfo.Scheme != "http" && urlInfo.Scheme != "https" {
+		annotations["training.educates.dev/source"] = fmt.Sprintf("file://%s", path)
+	} else {
+		annotations["training.educates.dev/source"] = path
+	}
+
+	workshop.SetAnnotations(annotations)
+
+	// Update the name for the workshop such that it incorporates a hash of
+	// the workshop location.
+
+	if name == "" {
+		var err error
+
+		if name, err = generateWorkshopName(path, workshop, portal); err != nil {
+			return err
+		}
+	} else if len(name) > maxWorkshopNameLength {
+		return failures.NewValidationError(errors.Errorf("workshop name %q is longer than %d characters", name, maxWorkshopNameLength), "supply a shorter name")
+	}
+
+	workshop.SetName(name)
+
+	// Insert workshop version property if not specified.
+
+	_, found, _ := unstructured.NestedString(workshop.Object, "spec", "version")
+
+	if !found && workshopVersion != "latest" {
+		unstructured.SetNestedField(workshop.Object, workshopVersion, "spec", "version")
+	}
+
+	// Remove the publish section as will not be accurate after publising.
+
+	unstructured.RemoveNestedField(workshop.Object, "spec", "publish")
+
+	return nil
+}
+
+var workshopNameInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+/*
+Generate the name for a workshop in the cluster from the training portal and
+either the name in the workshop definition and a hash of where it was loaded
+from, or the title of the workshop and a hash of the title, depending on the
+naming annotation of the workshop definition. Where the name would be too
+long, the part of the name derived from the workshop is truncated, with the
+hash then also covering the full name so truncated names remain distinct.
+*/
+func generateWorkshopName(path string, workshop *unstructured.Unstructured, portal string) (string, error) {
+	name := workshop.GetName()
+	key := path
+
+	switch naming := workshop.GetAnnotations()[WorkshopNamingAnnotation]; naming {
+	case "", "location":
+	case "title":
+		title, _, _ := unstructured.NestedString(workshop.Object, "spec", "title")
+
+		if title = strings.TrimSpace(title); title == "" {
+			return "", failures.NewValidationError(errors.Errorf("workshop %q has no title to derive its name from", name), "add a title to the workshop definition or supply a name")
+		}
+
+		name = strings.Trim(workshopNameInvalidChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+		key = title
+
+		if name == "" {
+			name = "workshop"
+		}
+	default:
+		return "", failures.NewValidationError(errors.Errorf("unsupported naming %q for workshop %q", naming, name), fmt.Sprintf("the %s annotation must be location or title", WorkshopNamingAnnotation))
+	}
+
+	hash := func(value string) string {
+		h := sha1.New()
+
+		io.WriteString(h, value)
+
+		hv := fmt.Sprintf("%x", h.Sum(nil))
+
+		return hv[len(hv)-7:]
+	}
+
+	generated := fmt.Sprintf("%s--%s-%s", portal, name, hash(key))
+
+	if len(generated) <= maxWorkshopNameLength {
+		return generated, nil
+	}
+
+	available := maxWorkshopNameLength - len(portal) - len("---") - 7
+
+	if available < 1 {
+		return "", failures.NewValidationError(errors.Errorf("training portal name %q is too long to generate a name for workshop %q", portal, workshop.GetName()), "use a shorter training portal name or supply a name")
+	}
+
+	truncated := strings.TrimRight(name[:available], "-")
+
+	return fmt.Sprintf("%s--%s-%s", portal, truncated, hash(key+"\n"+name)), nil
+}
@@ -0,0 +1,318 @@
+package training
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testWorkshopDefinition = `apiVersion: training.educates.dev/v1beta1
+kind: Workshop
+metadata:
+  name: lab-markdown-sample
+spec:
+  title: Markdown Sample
+  publish:
+    image: registry.example.com/lab-markdown-sample-files:latest
+`
+
+const testWorkshopTemplate = `#@ load("@ytt:data", "data")
+apiVersion: training.educates.dev/v1beta1
+kind: Workshop
+metadata:
+  name: lab-markdown-sample
+spec:
+  title: #@ "Workshop on " + data.values.ingressDomain
+`
+
+const testWorkshopDefinitions = `apiVersion: training.educates.dev/v1beta1
+kind: Workshop
+metadata:
+  name: lab-first
+spec:
+  title: First
+---
+apiVersion: training.educates.dev/v1beta1
+kind: Workshop
+metadata:
+  name: lab-second
+spec:
+  title: Second
+  version: "1.0"
+`
+
+func writeTestFile(t *testing.T, path string, data string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadWorkshopDefinitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		options  DefinitionOptions
+		wantErr  bool
+		want     []string
+		titles   []string
+		versions []string
+	}{
+		{
+			name:     "directory with default workshop file",
+			contents: testWorkshopDefinition,
+			options:  DefinitionOptions{Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"},
+			titles:   []string{"Markdown Sample"},
+			versions: []string{""},
+		},
+		{
+			name:     "name supplied",
+			contents: testWorkshopDefinition,
+			options:  DefinitionOptions{Name: "my-workshop", Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"},
+			want:     []string{"my-workshop"},
+			titles:   []string{"Markdown Sample"},
+			versions: []string{""},
+		},
+		{
+			name:     "name too long",
+			contents: testWorkshopDefinition,
+			options:  DefinitionOptions{Name: strings.Repeat("x", 64), Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"},
+			wantErr:  true,
+		},
+		{
+			name:     "workshop version added",
+			contents: testWorkshopDefinition,
+			options:  DefinitionOptions{Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "2.0"},
+			titles:   []string{"Markdown Sample"},
+			versions: []string{"2.0"},
+		},
+		{
+			name:     "data values applied to template",
+			contents: testWorkshopTemplate,
+			options: DefinitionOptions{
+				Portal:          "educates-cli",
+				WorkshopFile:    "resources/workshop.yaml",
+				WorkshopVersion: "latest",
+				DataValuesFlags: yttcmd.DataValuesFlags{KVsFromStrings: []string{"ingressDomain=example.com"}},
+			},
+			titles:   []string{"Workshop on example.com"},
+			versions: []string{""},
+		},
+		{
+			name:     "data value not defined",
+			contents: strings.ReplaceAll(testWorkshopTemplate, "ingressDomain", "undefinedValue"),
+			options:  DefinitionOptions{Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"},
+			wantErr:  true,
+		},
+		{
+			name:     "multiple workshop definitions",
+			contents: testWorkshopDefinitions,
+			options:  DefinitionOptions{Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "3.0"},
+			titles:   []string{"First", "Second"},
+			versions: []string{"3.0", "1.0"},
+		},
+		{
+			name:     "name supplied with multiple workshop definitions",
+			contents: testWorkshopDefinitions,
+			options:  DefinitionOptions{Name: "my-workshop", Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"},
+			wantErr:  true,
+		},
+		{
+			name:     "not a workshop definition",
+			contents: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: lab\n",
+			options:  DefinitionOptions{Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"},
+			wantErr:  true,
+		},
+		{
+			name:    "missing workshop file",
+			options: DefinitionOptions{Portal: "educates-cli", WorkshopFile: "resources/missing.yaml", WorkshopVersion: "latest"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := t.TempDir()
+
+			if tt.contents != "" {
+				writeTestFile(t, filepath.Join(directory, "resources", "workshop.yaml"), tt.contents)
+			}
+
+			options := tt.options
+			options.Path = directory
+
+			workshops, err := LoadWorkshopDefinitions(&options)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error but loaded %d workshop definitions", len(workshops))
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(workshops) != len(tt.titles) {
+				t.Fatalf("expected %d workshop definitions but found %d", len(tt.titles), len(workshops))
+			}
+
+			source := "file://" + filepath.Join(directory, "resources", "workshop.yaml")
+
+			for i, workshop := range workshops {
+				if tt.want != nil && workshop.GetName() != tt.want[i] {
+					t.Errorf("expected name %q but got %q", tt.want[i], workshop.GetName())
+				}
+
+				if tt.want == nil && !strings.HasPrefix(workshop.GetName(), "educates-cli--lab-") {
+					t.Errorf("expected generated name for training portal but got %q", workshop.GetName())
+				}
+
+				if annotation := workshop.GetAnnotations()["training.educates.dev/source"]; annotation != source {
+					t.Errorf("expected source %q but got %q", source, annotation)
+				}
+
+				if title, _, _ := unstructured.NestedString(workshop.Object, "spec", "title"); title != tt.titles[i] {
+					t.Errorf("expected title %q but got %q", tt.titles[i], title)
+				}
+
+				if version, _, _ := unstructured.NestedString(workshop.Object, "spec", "version"); version != tt.versions[i] {
+					t.Errorf("expected version %q but got %q", tt.versions[i], version)
+				}
+
+				if _, found, _ := unstructured.NestedFieldNoCopy(workshop.Object, "spec", "publish"); found {
+					t.Errorf("expected publish section to be removed")
+				}
+			}
+		})
+	}
+}
+
+func TestLoadWorkshopDefinitionFile(t *testing.T) {
+	directory := t.TempDir()
+
+	path := filepath.Join(directory, "workshop.yaml")
+
+	writeTestFile(t, path, testWorkshopDefinition)
+
+	workshop, err := LoadWorkshopDefinition(&DefinitionOptions{Path: path, Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if workshop.GetAnnotations()["training.educates.dev/workshop"] != "lab-markdown-sample" {
+		t.Errorf("expected original name to be recorded, got annotations %v", workshop.GetAnnotations())
+	}
+
+	// The same location gives the same generated name each time, and a
+	// different location a different name.
+
+	again, err := LoadWorkshopDefinition(&DefinitionOptions{Path: path, Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if again.GetName() != workshop.GetName() {
+		t.Errorf("expected name %q to be stable but got %q", workshop.GetName(), again.GetName())
+	}
+
+	other := filepath.Join(directory, "other", "workshop.yaml")
+
+	writeTestFile(t, other, testWorkshopDefinition)
+
+	moved, err := LoadWorkshopDefinition(&DefinitionOptions{Path: other, Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if moved.GetName() == workshop.GetName() {
+		t.Errorf("expected different name for different location but got %q", moved.GetName())
+	}
+
+	if _, err = LoadWorkshopDefinition(&DefinitionOptions{Path: filepath.Join(directory, "missing.yaml"), Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"}); err == nil {
+		t.Errorf("expected error for missing workshop definition file")
+	}
+}
+
+func TestLoadWorkshopDefinitionSingle(t *testing.T) {
+	directory := t.TempDir()
+
+	writeTestFile(t, filepath.Join(directory, "resources", "workshop.yaml"), testWorkshopDefinitions)
+
+	if _, err := LoadWorkshopDefinition(&DefinitionOptions{Path: directory, Portal: "educates-cli", WorkshopFile: "resources/workshop.yaml", WorkshopVersion: "latest"}); err == nil {
+		t.Errorf("expected error when file holds more than one workshop definition")
+	}
+}
+
+func TestGenerateWorkshopName(t *testing.T) {
+	tests := []struct {
+		name       string
+		portal     string
+		workshop   string
+		title      string
+		naming     string
+		wantErr    bool
+		wantPrefix string
+	}{
+		{name: "location naming", portal: "educates-cli", workshop: "lab-sample", wantPrefix: "educates-cli--lab-sample-"},
+		{name: "title naming", portal: "educates-cli", workshop: "lab-sample", title: "My Sample Workshop!", naming: "title", wantPrefix: "educates-cli--my-sample-workshop-"},
+		{name: "title naming without title", portal: "educates-cli", workshop: "lab-sample", naming: "title", wantErr: true},
+		{name: "unknown naming", portal: "educates-cli", workshop: "lab-sample", naming: "other", wantErr: true},
+		{name: "long name truncated", portal: "educates-cli", workshop: strings.Repeat("lab-", 20), wantPrefix: "educates-cli--lab-"},
+		{name: "portal name too long", portal: strings.Repeat("p", 60), workshop: "lab-sample", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workshop := &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{},
+			}}
+
+			workshop.SetName(tt.workshop)
+
+			if tt.title != "" {
+				unstructured.SetNestedField(workshop.Object, tt.title, "spec", "title")
+			}
+
+			if tt.naming != "" {
+				workshop.SetAnnotations(map[string]string{WorkshopNamingAnnotation: tt.naming})
+			}
+
+			name, err := generateWorkshopName("/workshops/lab-sample", workshop, tt.portal)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error but generated %q", name)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.HasPrefix(name, tt.wantPrefix) {
+				t.Errorf("expected name with prefix %q but got %q", tt.wantPrefix, name)
+			}
+
+			if len(name) > maxWorkshopNameLength {
+				t.Errorf("expected name of at most %d characters but got %q", maxWorkshopNameLength, name)
+			}
+		})
+	}
+}
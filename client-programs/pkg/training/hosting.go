@@ -0,0 +1,534 @@
+package training
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Cache control set on uploaded files. Files for each version of the workshop
+instructions are uploaded under a separate directory named from a hash of
+the content, so they never change once uploaded and can be cached forever.
+*/
+const contentCacheControl = "public, max-age=31536000, immutable"
+
+/*
+Static hosting to which the generated files for workshop instructions can be
+uploaded, so that they are served from there rather than from the workshop
+sessions in the cluster.
+*/
+type ContentHost interface {
+	// Return the location files are uploaded to.
+	String() string
+
+	// Return the URL from which uploaded files are publicly served.
+	BaseURL() string
+
+	// Upload the files in the directory under the given version, returning
+	// false if that version had already been uploaded.
+	Upload(ctx context.Context, directory string, version string) (bool, error)
+}
+
+/*
+Create the static hosting for a target given as a URL. Supported targets are
+S3 buckets (s3://BUCKET/PREFIX), Google Cloud Storage buckets
+(gs://BUCKET/PREFIX) and GitHub Pages (pages://OWNER/REPO/PREFIX).
+*/
+func NewContentHost(target string) (ContentHost, error) {
+	location, err := url.Parse(target)
+
+	if err != nil || location.Host == "" {
+		return nil, failures.NewValidationError(errors.Errorf("invalid publish target %q", target), "use s3://BUCKET/PREFIX, gs://BUCKET/PREFIX or pages://OWNER/REPO/PREFIX")
+	}
+
+	switch location.Scheme {
+	case "s3":
+		region := location.Query().Get("region")
+
+		if region == "" {
+			region = "us-east-1"
+		}
+
+		return &s3ContentHost{
+			Bucket:   location.Host,
+			Prefix:   strings.Trim(location.Path, "/"),
+			Region:   region,
+			Endpoint: strings.TrimSuffix(location.Query().Get("endpoint"), "/"),
+		}, nil
+	case "gs":
+		return &gcsContentHost{
+			Bucket: location.Host,
+			Prefix: strings.Trim(location.Path, "/"),
+		}, nil
+	case "pages":
+		parts := strings.SplitN(strings.Trim(location.Path, "/"), "/", 2)
+
+		if parts[0] == "" {
+			return nil, failures.NewValidationError(errors.Errorf("no repository given in publish target %q", target), "use pages://OWNER/REPO/PREFIX")
+		}
+
+		host := &pagesContentHost{
+			Owner:  location.Host,
+			Repo:   parts[0],
+			Branch: location.Query().Get("branch"),
+			Remote: location.Query().Get("remote"),
+		}
+
+		if len(parts) == 2 {
+			host.Prefix = strings.Trim(parts[1], "/")
+		}
+
+		if host.Branch == "" {
+			host.Branch = "gh-pages"
+		}
+
+		if host.Remote == "" {
+			host.Remote = fmt.Sprintf("https://github.com/%s/%s.git", host.Owner, host.Repo)
+		}
+
+		return host, nil
+	}
+
+	return nil, failures.NewValidationError(errors.Errorf("unsupported publish target %q", target), "use s3://BUCKET/PREFIX, gs://BUCKET/PREFIX or pages://OWNER/REPO/PREFIX")
+}
+
+/*
+Calculate the version of the workshop instructions from a hash of the files
+in the workshop directory, along with the details of how they are built, so
+that the same instructions always map to the same location when uploaded.
+*/
+func ContentVersion(workshopDir string, image string, locale string, baseURL string) (string, error) {
+	hash := sha256.New()
+
+	fmt.Fprintf(hash, "%s\x00%s\x00%s\x00", image, locale, baseURL)
+
+	err := filepath.WalkDir(workshopDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(workshopDir, filePath)
+
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if relPath == "public" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		data, err := os.ReadFile(filePath)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(relPath), len(data))
+
+		hash.Write(data)
+
+		return nil
+	})
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to calculate hash of workshop instructions")
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
+
+/*
+Return the files in a directory as paths relative to the directory, using
+forward slashes as separator.
+*/
+func contentFiles(directory string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(directory, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(directory, filePath)
+
+		if err != nil {
+			return err
+		}
+
+		files = append(files, filepath.ToSlash(relPath))
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read generated workshop instructions")
+	}
+
+	return files, nil
+}
+
+/*
+Return the content type for a file based on its extension.
+*/
+func contentType(name string) string {
+	if value := mime.TypeByExtension(path.Ext(name)); value != "" {
+		return value
+	}
+
+	return "application/octet-stream"
+}
+
+/*
+Escape each segment of an object key for use in a URL path.
+*/
+func escapeObjectKey(key string) string {
+	segments := strings.Split(key, "/")
+
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+/*
+Static hosting using an S3 bucket. Credentials are determined in the same way
+as by the AWS CLI. An alternate endpoint can be given for S3 compatible
+storage such as MinIO, in which case path style URLs are used.
+*/
+type s3ContentHost struct {
+	Bucket   string
+	Prefix   string
+	Region   string
+	Endpoint string
+}
+
+func (s *s3ContentHost) String() string {
+	return fmt.Sprintf("s3://%s/%s", s.Bucket, s.Prefix)
+}
+
+func (s *s3ContentHost) BaseURL() string {
+	return strings.TrimSuffix(s.objectURL(s.Prefix), "/")
+}
+
+func (s *s3ContentHost) objectURL(key string) string {
+	if s.Endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", s.Endpoint, s.Bucket, escapeObjectKey(key))
+	}
+
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, s.Region, escapeObjectKey(key))
+}
+
+func (s *s3ContentHost) request(ctx context.Context, method string, key string, body []byte, headers map[string]string) (*http.Response, error) {
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(s.Region))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load AWS configuration")
+	}
+
+	credentials, err := awsConfig.Credentials.Retrieve(ctx)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve AWS credentials")
+	}
+
+	digest := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(digest[:])
+
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), bytes.NewReader(body))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create S3 request")
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	if err = v4.NewSigner().SignHTTP(ctx, credentials, req, payloadHash, "s3", s.Region, time.Now().UTC()); err != nil {
+		return nil, errors.Wrap(err, "unable to sign S3 request")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return nil, failures.NewConnectionError(errors.Wrapf(err, "unable to access object %s in S3", key), "")
+	}
+
+	return res, nil
+}
+
+func (s *s3ContentHost) Upload(ctx context.Context, directory string, version string) (bool, error) {
+	prefix := path.Join(s.Prefix, version)
+
+	res, err := s.request(ctx, http.MethodHead, path.Join(prefix, "index.html"), nil, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return false, nil
+	}
+
+	files, err := contentFiles(directory)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(directory, filepath.FromSlash(name)))
+
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to read %s", name)
+		}
+
+		key := path.Join(prefix, name)
+
+		headers := map[string]string{
+			"Content-Type":  contentType(name),
+			"Cache-Control": contentCacheControl,
+		}
+
+		res, err := s.request(ctx, http.MethodPut, key, data, headers)
+
+		if err != nil {
+			return false, err
+		}
+
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return false, errors.Errorf("unable to write object %s to S3, status %d: %s", key, res.StatusCode, strings.TrimSpace(string(message)))
+		}
+	}
+
+	return true, nil
+}
+
+/*
+Static hosting using a Google Cloud Storage bucket. Credentials are the
+application default credentials for the Google Cloud SDK.
+*/
+type gcsContentHost struct {
+	Bucket string
+	Prefix string
+}
+
+func (s *gcsContentHost) String() string {
+	return fmt.Sprintf("gs://%s/%s", s.Bucket, s.Prefix)
+}
+
+func (s *gcsContentHost) BaseURL() string {
+	return strings.TrimSuffix(s.objectURL(s.Prefix), "/")
+}
+
+func (s *gcsContentHost) objectURL(key string) string {
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", s.Bucket, escapeObjectKey(key))
+}
+
+func (s *gcsContentHost) Upload(ctx context.Context, directory string, version string) (bool, error) {
+	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/devstorage.read_write")
+
+	if err != nil {
+		return false, errors.Wrap(err, "unable to load Google Cloud credentials")
+	}
+
+	prefix := path.Join(s.Prefix, version)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(path.Join(prefix, "index.html")), nil)
+
+	if err != nil {
+		return false, errors.Wrap(err, "unable to create Cloud Storage request")
+	}
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return false, failures.NewConnectionError(errors.Wrap(err, "unable to contact Cloud Storage"), "")
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return false, nil
+	}
+
+	files, err := contentFiles(directory)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(directory, filepath.FromSlash(name)))
+
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to read %s", name)
+		}
+
+		key := path.Join(prefix, name)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+
+		if err != nil {
+			return false, errors.Wrap(err, "unable to create Cloud Storage request")
+		}
+
+		req.Header.Set("Content-Type", contentType(name))
+		req.Header.Set("Cache-Control", contentCacheControl)
+
+		res, err := client.Do(req)
+
+		if err != nil {
+			return false, failures.NewConnectionError(errors.Wrapf(err, "unable to write object %s to Cloud Storage", key), "")
+		}
+
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return false, errors.Errorf("unable to write object %s to Cloud Storage, status %d: %s", key, res.StatusCode, strings.TrimSpace(string(message)))
+		}
+	}
+
+	return true, nil
+}
+
+/*
+Static hosting using GitHub Pages. The files are committed to the branch for
+GitHub Pages of the repository and pushed using the "git" command, so the
+credentials used are those git is configured with.
+*/
+type pagesContentHost struct {
+	Owner  string
+	Repo   string
+	Prefix string
+	Branch string
+	Remote string
+}
+
+func (s *pagesContentHost) String() string {
+	return fmt.Sprintf("pages://%s/%s/%s", s.Owner, s.Repo, s.Prefix)
+}
+
+func (s *pagesContentHost) BaseURL() string {
+	siteURL := fmt.Sprintf("https://%s.github.io/%s", s.Owner, s.Repo)
+
+	// A repository named after the GitHub Pages domain for the owner is
+	// served from the root of the domain.
+
+	if strings.EqualFold(s.Repo, fmt.Sprintf("%s.github.io", s.Owner)) {
+		siteURL = fmt.Sprintf("https://%s.github.io", s.Owner)
+	}
+
+	if s.Prefix != "" {
+		siteURL = fmt.Sprintf("%s/%s", siteURL, s.Prefix)
+	}
+
+	return siteURL
+}
+
+func (s *pagesContentHost) Upload(ctx context.Context, directory string, version string) (bool, error) {
+	commandPath, err := exec.LookPath("git")
+
+	if err != nil {
+		return false, failures.NewValidationError(errors.Wrap(err, "unable to find git program"), "install git to publish to GitHub Pages")
+	}
+
+	git := func(args ...string) error {
+		output, err := exec.CommandContext(ctx, commandPath, args...).CombinedOutput()
+
+		if err != nil {
+			return errors.Wrapf(err, "git %s failed: %s", args[0], strings.TrimSpace(string(output)))
+		}
+
+		return nil
+	}
+
+	cloneDir, err := os.MkdirTemp("", "educates-pages")
+
+	if err != nil {
+		return false, errors.Wrap(err, "unable to create temporary directory for repository")
+	}
+
+	defer os.RemoveAll(cloneDir)
+
+	if err = git("clone", "--quiet", "--depth", "1", "--branch", s.Branch, s.Remote, cloneDir); err != nil {
+		return false, failures.NewNotFoundError(err, fmt.Sprintf("check the repository exists and has a %q branch", s.Branch))
+	}
+
+	targetDir := filepath.Join(cloneDir, filepath.FromSlash(s.Prefix), version)
+
+	if _, err := os.Stat(filepath.Join(targetDir, "index.html")); err == nil {
+		return false, nil
+	}
+
+	if err = os.MkdirAll(targetDir, 0o755); err != nil {
+		return false, errors.Wrap(err, "unable to create directory in repository")
+	}
+
+	err = copyDirectory(directory, targetDir, func(relPath string) bool { return false })
+
+	if err != nil {
+		return false, errors.Wrap(err, "unable to copy workshop instructions into repository")
+	}
+
+	// Without this file GitHub Pages would process the files using Jekyll,
+	// which skips any files with names starting with an underscore.
+
+	if err = os.WriteFile(filepath.Join(cloneDir, ".nojekyll"), []byte{}, 0o644); err != nil {
+		return false, errors.Wrap(err, "unable to write .nojekyll file")
+	}
+
+	if err = git("-C", cloneDir, "add", "--all"); err != nil {
+		return false, err
+	}
+
+	if err = git("-C", cloneDir, "commit", "--quiet", "-m", fmt.Sprintf("Publish workshop instructions %s.", version)); err != nil {
+		return false, err
+	}
+
+	if err = git("-C", cloneDir, "push", "--quiet", "origin", s.Branch); err != nil {
+		return false, failures.NewConnectionError(err, "check git is configured with credentials for the repository")
+	}
+
+	return true, nil
+}
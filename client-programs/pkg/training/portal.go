@@ -0,0 +1,581 @@
+package training
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Settings for a workshop hosted by a training portal. These correspond to the
+options of the command for deploying a workshop, with expires defaulting to
+the duration of the workshop when not set, and capacity to that available
+for the training portal.
+*/
+type WorkshopSettings struct {
+	Capacity uint
+	Fit      bool
+	Reserved uint
+	Initial  uint
+	Expires  string
+	Overtime string
+	Deadline string
+	Orphaned string
+	Overdue  string
+	Refresh  string
+	Registry string
+	Environ  []string
+
+	OrphanedWarning string
+	IdleExempt      bool
+
+	ExpiryWarnings []string
+
+	NodeSelector []string
+	Tolerations  []string
+}
+
+/*
+Construct the scheduling constraints for workshop sessions from node selectors
+given as "key=value" and tolerations given as "key[=value][:effect]". Where a
+toleration has no value it matches any value of the taint. Returns nil when no
+constraints are given.
+*/
+func WorkshopScheduling(nodeSelector []string, tolerations []string) (map[string]interface{}, error) {
+	if len(nodeSelector) == 0 && len(tolerations) == 0 {
+		return nil, nil
+	}
+
+	scheduling := map[string]interface{}{}
+
+	if len(nodeSelector) != 0 {
+		labels := map[string]interface{}{}
+
+		for _, item := range nodeSelector {
+			parts := strings.SplitN(item, "=", 2)
+
+			if len(parts) != 2 || parts[0] == "" {
+				return nil, failures.NewValidationError(errors.Errorf("invalid node selector %q", item), "node selectors must be given as key=value")
+			}
+
+			labels[parts[0]] = parts[1]
+		}
+
+		scheduling["nodeSelector"] = labels
+	}
+
+	if len(tolerations) != 0 {
+		var entries []interface{}
+
+		effects := []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}
+
+		for _, item := range tolerations {
+			toleration := map[string]interface{}{}
+
+			spec := item
+
+			if i := strings.LastIndex(spec, ":"); i != -1 {
+				effect := spec[i+1:]
+
+				found := false
+
+				for _, name := range effects {
+					if effect == name {
+						found = true
+					}
+				}
+
+				if !found {
+					return nil, failures.NewValidationError(errors.Errorf("invalid effect %q for toleration %q", effect, item), "effect must be one of NoSchedule, PreferNoSchedule or NoExecute")
+				}
+
+				toleration["effect"] = effect
+
+				spec = spec[:i]
+			}
+
+			parts := strings.SplitN(spec, "=", 2)
+
+			if parts[0] == "" {
+				return nil, failures.NewValidationError(errors.Errorf("invalid toleration %q", item), "tolerations must be given as key[=value][:effect]")
+			}
+
+			toleration["key"] = parts[0]
+
+			if len(parts) == 2 {
+				toleration["operator"] = "Equal"
+				toleration["value"] = parts[1]
+			} else {
+				toleration["operator"] = "Exists"
+			}
+
+			entries = append(entries, toleration)
+		}
+
+		scheduling["tolerations"] = entries
+	}
+
+	return scheduling, nil
+}
+
+/*
+Add the workshop to the training portal in the cluster, creating the
+training portal if it doesn't exist.
+*/
+func DeployWorkshopResource(ctx context.Context, client dynamic.Interface, workshop *unstructured.Unstructured, portal string, portalDefaults *config.PortalDefaultsConfig, hostname string, settings *WorkshopSettings) error {
+	trainingPortalClient := client.Resource(TrainingPortalResource)
+
+	trainingPortal, trainingPortalExists, err := PrepareTrainingPortal(ctx, client, workshop, portal, portalDefaults, hostname, settings)
+
+	if err != nil {
+		return err
+	}
+
+	if trainingPortalExists {
+		_, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+	} else {
+		_, err = trainingPortalClient.Create(ctx, trainingPortal, metav1.CreateOptions{FieldManager: "educates-cli"})
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal %q in cluster", portal)
+	}
+
+	return nil
+}
+
+/*
+Retrieve the training portal from the cluster, or the definition for a new
+training portal if it doesn't exist, and add the workshop to it. Whether the
+training portal already exists in the cluster is also returned.
+*/
+func PrepareTrainingPortal(ctx context.Context, client dynamic.Interface, workshop *unstructured.Unstructured, portal string, portalDefaults *config.PortalDefaultsConfig, hostname string, settings *WorkshopSettings) (*unstructured.Unstructured, bool, error) {
+	trainingPortal, err := client.Resource(TrainingPortalResource).Get(ctx, portal, metav1.GetOptions{})
+
+	var trainingPortalExists = true
+
+	if k8serrors.IsNotFound(err) {
+		trainingPortalExists = false
+
+		trainingPortal = NewTrainingPortal(portal, portalDefaults)
+	} else if err != nil {
+		return nil, false, errors.Wrapf(err, "unable to query training portal %q in cluster", portal)
+	}
+
+	err = AddWorkshopToTrainingPortal(trainingPortal, trainingPortalExists, workshop, settings)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	SetTrainingPortalHostname(trainingPortal, hostname)
+
+	return trainingPortal, trainingPortalExists, nil
+}
+
+/*
+Create the definition for a new training portal with the defaults used when
+the portal doesn't already exist.
+*/
+func NewTrainingPortal(portal string, defaults *config.PortalDefaultsConfig) *unstructured.Unstructured {
+	trainingPortal := &unstructured.Unstructured{}
+
+	portalSettings := map[string]interface{}{
+		"registration": map[string]interface{}{
+			"type": defaults.Registration.Type,
+		},
+		"updates": map[string]interface{}{
+			"workshop": defaults.Updates.Workshop,
+		},
+		"sessions": map[string]interface{}{
+			"maximum": defaults.Sessions.Maximum,
+		},
+		"workshop": map[string]interface{}{
+			"defaults": map[string]interface{}{
+				"reserved": int64(0),
+			},
+		},
+	}
+
+	switch defaults.Password.Policy {
+	case config.PasswordPolicyRandom:
+		portalSettings["password"] = RandomPassword(defaults.Password.Length)
+	case config.PasswordPolicyFixed:
+		portalSettings["password"] = defaults.Password.Value
+	}
+
+	trainingPortal.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "training.educates.dev/v1beta1",
+		"kind":       "TrainingPortal",
+		"metadata": map[string]interface{}{
+			"name": portal,
+		},
+		"spec": map[string]interface{}{
+			"portal":    portalSettings,
+			"workshops": []interface{}{},
+		},
+	})
+
+	return trainingPortal
+}
+
+/*
+Set the custom hostname for the training portal. Changing the hostname of an
+existing training portal affects access to all workshops it hosts, so a
+warning is given when that happens.
+*/
+func SetTrainingPortalHostname(trainingPortal *unstructured.Unstructured, hostname string) {
+	if hostname == "" {
+		return
+	}
+
+	existing, _, _ := unstructured.NestedString(trainingPortal.Object, "spec", "portal", "ingress", "hostname")
+
+	if existing != "" && existing != hostname {
+		fmt.Fprintf(os.Stderr, "Warning: changing hostname of training portal %s from %s to %s affects all workshops it hosts.\n", trainingPortal.GetName(), existing, hostname)
+	}
+
+	unstructured.SetNestedField(trainingPortal.Object, hostname, "spec", "portal", "ingress", "hostname")
+}
+
+/*
+Add the workshop to the list of workshops hosted by the training portal, or
+update the settings for it if already present.
+*/
+func AddWorkshopToTrainingPortal(trainingPortal *unstructured.Unstructured, trainingPortalExists bool, workshop *unstructured.Unstructured, settings *WorkshopSettings) error {
+	capacity := settings.Capacity
+	fit := settings.Fit
+	reserved := settings.Reserved
+	initial := settings.Initial
+	expires := settings.Expires
+	overtime := settings.Overtime
+	deadline := settings.Deadline
+	orphaned := settings.Orphaned
+	overdue := settings.Overdue
+	refresh := settings.Refresh
+	registry := settings.Registry
+	environ := settings.Environ
+
+	orphanedWarning := settings.OrphanedWarning
+	expiryWarnings := settings.ExpiryWarnings
+
+	// A workshop exempt from idle termination has the inactivity timeout set
+	// explicitly to zero, so that any default for the training portal does
+	// not apply to it.
+
+	if settings.IdleExempt {
+		orphaned = "0s"
+		orphanedWarning = ""
+	}
+
+	scheduling, err := WorkshopScheduling(settings.NodeSelector, settings.Tolerations)
+
+	if err != nil {
+		return err
+	}
+
+	sessionsMaximum, propertyExists, err := unstructured.NestedInt64(trainingPortal.Object, "spec", "portal", "sessions", "maximum")
+
+	if err != nil || !propertyExists {
+		sessionsMaximum = 0
+	} else if sessionsMaximum == 0 {
+		capacity = 0
+	} else if !trainingPortalExists && capacity == 0 {
+		capacity = 1
+	}
+
+	// Check that the capacity of the workshop, along with that of the other
+	// workshops hosted by the training portal, fits within the maximum number
+	// of sessions for the training portal. If asked to fit the workshop the
+	// maximum is increased as necessary, otherwise the capacity of the
+	// workshop is reduced to the maximum.
+
+	if sessionsMaximum > 0 {
+		required := otherWorkshopsCapacity(trainingPortal, workshop.GetName()) + int64(capacity)
+
+		if fit {
+			if required > sessionsMaximum {
+				sessionsMaximum = required
+
+				err = unstructured.SetNestedField(trainingPortal.Object, sessionsMaximum, "spec", "portal", "sessions", "maximum")
+
+				if err != nil {
+					return errors.Wrap(err, "unable to update maximum sessions for training portal")
+				}
+			}
+		} else if int64(capacity) > sessionsMaximum {
+			fmt.Fprintf(os.Stderr, "Warning: capacity of workshop %q reduced from %d to %d as the maximum sessions for training portal %q is %d, use --fit to increase the maximum.\n", workshop.GetName(), capacity, sessionsMaximum, trainingPortal.GetName(), sessionsMaximum)
+
+			capacity = uint(sessionsMaximum)
+		} else if required > sessionsMaximum {
+			fmt.Fprintf(os.Stderr, "Warning: combined capacity of %d for workshops exceeds the maximum sessions for training portal %q of %d, use --fit to increase the maximum.\n", required, trainingPortal.GetName(), sessionsMaximum)
+		}
+	}
+
+	if capacity != 0 {
+		if reserved > capacity {
+			reserved = capacity
+		}
+		if initial > capacity {
+			initial = capacity
+		}
+	} else if sessionsMaximum != 0 {
+		if reserved > uint(sessionsMaximum) {
+			reserved = uint(sessionsMaximum)
+		}
+		if initial > uint(sessionsMaximum) {
+			initial = uint(sessionsMaximum)
+		}
+	}
+
+	workshops, _, err := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve workshops from training portal")
+	}
+
+	var updatedWorkshops []interface{}
+
+	if expires == "" {
+		duration, propertyExists, err := unstructured.NestedString(workshop.Object, "spec", "duration")
+
+		if err != nil || !propertyExists {
+			expires = "60m"
+		} else {
+			expires = duration
+		}
+	}
+
+	type EnvironDetails struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	var environVariables []EnvironDetails
+
+	for _, value := range environ {
+		parts := strings.SplitN(value, "=", 2)
+		environVariables = append(environVariables, EnvironDetails{
+			Name:  parts[0],
+			Value: parts[1],
+		})
+	}
+
+	var foundWorkshop = false
+
+	for _, item := range workshops {
+		object := item.(map[string]interface{})
+
+		updatedWorkshops = append(updatedWorkshops, object)
+
+		if object["name"] == workshop.GetName() {
+			foundWorkshop = true
+
+			object["reserved"] = int64(reserved)
+			object["initial"] = int64(initial)
+
+			if capacity != 0 {
+				object["capacity"] = int64(capacity)
+			} else {
+				delete(object, "capacity")
+			}
+
+			if expires != "" {
+				object["expires"] = expires
+			} else {
+				delete(object, "expires")
+			}
+
+			if overtime != "" {
+				object["overtime"] = overtime
+			} else {
+				delete(object, "overtime")
+			}
+
+			if deadline != "" {
+				object["deadline"] = deadline
+			} else {
+				delete(object, "deadline")
+			}
+
+			if orphaned != "" {
+				object["orphaned"] = orphaned
+			} else {
+				delete(object, "orphaned")
+			}
+
+			if orphanedWarning != "" {
+				object["orphanedWarning"] = orphanedWarning
+			} else {
+				delete(object, "orphanedWarning")
+			}
+
+			if len(expiryWarnings) != 0 {
+				var tmpExpiryWarnings []interface{}
+
+				for _, item := range expiryWarnings {
+					tmpExpiryWarnings = append(tmpExpiryWarnings, item)
+				}
+
+				object["expiryWarnings"] = tmpExpiryWarnings
+			} else {
+				delete(object, "expiryWarnings")
+			}
+
+			if overdue != "" {
+				object["overdue"] = overdue
+			} else {
+				delete(object, "overdue")
+			}
+
+			if refresh != "" {
+				object["refresh"] = refresh
+			} else {
+				delete(object, "refresh")
+			}
+
+			var tmpEnvironVariables []interface{}
+
+			for _, item := range environVariables {
+				tmpEnvironVariables = append(tmpEnvironVariables, map[string]interface{}{
+					"name":  item.Name,
+					"value": item.Value,
+				})
+			}
+
+			object["env"] = tmpEnvironVariables
+
+			if scheduling != nil {
+				object["scheduling"] = scheduling
+			} else {
+				delete(object, "scheduling")
+			}
+		}
+	}
+
+	type RegistryDetails struct {
+		Host      string `json:"host"`
+		Namespace string `json:"namespace,omitempty"`
+	}
+
+	type WorkshopDetails struct {
+		Name     string           `json:"name"`
+		Capacity int64            `json:"capacity,omitempty"`
+		Initial  int64            `json:"initial"`
+		Reserved int64            `json:"reserved"`
+		Expires  string           `json:"expires,omitempty"`
+		Overtime string           `json:"overtime,omitempty"`
+		Deadline string           `json:"deadline,omitempty"`
+		Orphaned string           `json:"orphaned,omitempty"`
+		Overdue  string           `json:"overdue,omitempty"`
+		Refresh  string           `json:"refresh,omitempty"`
+		Registry *RegistryDetails `json:"registry,omitempty"`
+		Environ  []EnvironDetails `json:"env"`
+
+		OrphanedWarning string   `json:"orphanedWarning,omitempty"`
+		ExpiryWarnings  []string `json:"expiryWarnings,omitempty"`
+
+		Scheduling map[string]interface{} `json:"scheduling,omitempty"`
+	}
+
+	if !foundWorkshop {
+		workshopDetails := WorkshopDetails{
+			Name:     workshop.GetName(),
+			Initial:  int64(initial),
+			Reserved: int64(reserved),
+			Expires:  expires,
+			Overtime: overtime,
+			Deadline: deadline,
+			Orphaned: orphaned,
+			Overdue:  overdue,
+			Refresh:  refresh,
+			Environ:  environVariables,
+
+			OrphanedWarning: orphanedWarning,
+			ExpiryWarnings:  expiryWarnings,
+
+			Scheduling: scheduling,
+		}
+
+		if capacity != 0 {
+			workshopDetails.Capacity = int64(capacity)
+		}
+
+		if registry != "" {
+			parts := strings.SplitN(registry, "/", 2)
+
+			host := parts[0]
+			var namespace string
+
+			if len(parts) > 1 {
+				namespace = parts[1]
+			}
+
+			registryDetails := RegistryDetails{
+				Host:      host,
+				Namespace: namespace,
+			}
+
+			workshopDetails.Registry = &registryDetails
+		}
+
+		var workshopDetailsMap map[string]interface{}
+
+		data, _ := json.Marshal(workshopDetails)
+		json.Unmarshal(data, &workshopDetailsMap)
+
+		updatedWorkshops = append(updatedWorkshops, workshopDetailsMap)
+	}
+
+	unstructured.SetNestedSlice(trainingPortal.Object, updatedWorkshops, "spec", "workshops")
+
+	return nil
+}
+
+/*
+Calculate the combined capacity of the workshops hosted by the training
+portal, excluding the named workshop. Workshops without a capacity set are
+ignored as they share the maximum sessions for the training portal.
+*/
+func otherWorkshopsCapacity(trainingPortal *unstructured.Unstructured, name string) int64 {
+	var total int64
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	for _, item := range workshops {
+		if object, ok := item.(map[string]interface{}); ok && object["name"] != name {
+			total += IntegerValue(object["capacity"])
+		}
+	}
+
+	return total
+}
+
+/*
+Generate a random password of the given length.
+*/
+func RandomPassword(length int) string {
+	rand.Seed(time.Now().UnixNano())
+
+	chars := []rune("!#%+23456789:=?@ABCDEFGHJKLMNPRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
+
+	var b strings.Builder
+
+	for i := 0; i < length; i++ {
+		b.WriteRune(chars[rand.Intn(len(chars))])
+	}
+	return b.String()
+}
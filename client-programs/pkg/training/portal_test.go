@@ -0,0 +1,335 @@
+package training
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestTrainingPortal(maximum interface{}, workshops ...map[string]interface{}) *unstructured.Unstructured {
+	trainingPortal := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "training.educates.dev/v1beta1",
+		"kind":       "TrainingPortal",
+		"metadata": map[string]interface{}{
+			"name": "educates-cli",
+		},
+	}}
+
+	if maximum != nil {
+		unstructured.SetNestedField(trainingPortal.Object, maximum, "spec", "portal", "sessions", "maximum")
+	}
+
+	var entries []interface{}
+
+	for _, workshop := range workshops {
+		entries = append(entries, workshop)
+	}
+
+	unstructured.SetNestedSlice(trainingPortal.Object, entries, "spec", "workshops")
+
+	return trainingPortal
+}
+
+func newTestWorkshop(name string, duration string) *unstructured.Unstructured {
+	workshop := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "training.educates.dev/v1beta1",
+		"kind":       "Workshop",
+		"spec":       map[string]interface{}{},
+	}}
+
+	workshop.SetName(name)
+
+	if duration != "" {
+		unstructured.SetNestedField(workshop.Object, duration, "spec", "duration")
+	}
+
+	return workshop
+}
+
+func findPortalWorkshop(t *testing.T, trainingPortal *unstructured.Unstructured, name string) map[string]interface{} {
+	t.Helper()
+
+	workshops, _, _ := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	var found map[string]interface{}
+
+	for _, item := range workshops {
+		if object := item.(map[string]interface{}); object["name"] == name {
+			if found != nil {
+				t.Fatalf("workshop %q listed more than once", name)
+			}
+
+			found = object
+		}
+	}
+
+	if found == nil {
+		t.Fatalf("workshop %q not found in training portal", name)
+	}
+
+	return found
+}
+
+func TestAddWorkshopToTrainingPortal(t *testing.T) {
+	tests := []struct {
+		name         string
+		maximum      interface{}
+		exists       bool
+		others       []map[string]interface{}
+		settings     WorkshopSettings
+		wantCapacity int64
+		wantReserved int64
+		wantInitial  int64
+		wantMaximum  int64
+	}{
+		{
+			name:         "no maximum keeps capacity",
+			settings:     WorkshopSettings{Capacity: 5, Reserved: 1},
+			wantCapacity: 5,
+			wantReserved: 1,
+		},
+		{
+			name:         "reserved and initial limited to capacity",
+			settings:     WorkshopSettings{Capacity: 2, Reserved: 3, Initial: 4},
+			wantCapacity: 2,
+			wantReserved: 2,
+			wantInitial:  2,
+		},
+		{
+			name:         "new training portal defaults capacity to one",
+			maximum:      int64(10),
+			settings:     WorkshopSettings{Reserved: 2},
+			wantCapacity: 1,
+			wantReserved: 1,
+			wantMaximum:  10,
+		},
+		{
+			name:         "existing training portal leaves capacity unset",
+			maximum:      int64(4),
+			exists:       true,
+			settings:     WorkshopSettings{Reserved: 6, Initial: 5},
+			wantReserved: 4,
+			wantInitial:  4,
+			wantMaximum:  4,
+		},
+		{
+			name:         "maximum of zero removes capacity",
+			maximum:      int64(0),
+			exists:       true,
+			settings:     WorkshopSettings{Capacity: 3, Reserved: 2},
+			wantReserved: 2,
+		},
+		{
+			name:         "capacity reduced to maximum",
+			maximum:      int64(3),
+			exists:       true,
+			settings:     WorkshopSettings{Capacity: 5, Reserved: 5},
+			wantCapacity: 3,
+			wantReserved: 3,
+			wantMaximum:  3,
+		},
+		{
+			name:         "combined capacity over maximum only warns",
+			maximum:      int64(3),
+			exists:       true,
+			others:       []map[string]interface{}{{"name": "other", "capacity": int64(2)}},
+			settings:     WorkshopSettings{Capacity: 2},
+			wantCapacity: 2,
+			wantMaximum:  3,
+		},
+		{
+			name:         "fit increases maximum for combined capacity",
+			maximum:      int64(3),
+			exists:       true,
+			others:       []map[string]interface{}{{"name": "other", "capacity": int64(2)}, {"name": "shared"}},
+			settings:     WorkshopSettings{Capacity: 2, Fit: true},
+			wantCapacity: 2,
+			wantMaximum:  4,
+		},
+		{
+			name:         "fit leaves maximum when capacity fits",
+			maximum:      int64(8),
+			exists:       true,
+			others:       []map[string]interface{}{{"name": "other", "capacity": int64(2)}},
+			settings:     WorkshopSettings{Capacity: 2, Fit: true},
+			wantCapacity: 2,
+			wantMaximum:  8,
+		},
+		{
+			name:         "existing capacity of workshop is not counted",
+			maximum:      int64(3),
+			exists:       true,
+			others:       []map[string]interface{}{{"name": "lab-sample", "capacity": int64(3)}},
+			settings:     WorkshopSettings{Capacity: 3, Fit: true},
+			wantCapacity: 3,
+			wantMaximum:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trainingPortal := newTestTrainingPortal(tt.maximum, tt.others...)
+
+			settings := tt.settings
+
+			if err := AddWorkshopToTrainingPortal(trainingPortal, tt.exists, newTestWorkshop("lab-sample", ""), &settings); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			object := findPortalWorkshop(t, trainingPortal, "lab-sample")
+
+			if capacity := IntegerValue(object["capacity"]); capacity != tt.wantCapacity {
+				t.Errorf("expected capacity %d but got %d", tt.wantCapacity, capacity)
+			}
+
+			if reserved := IntegerValue(object["reserved"]); reserved != tt.wantReserved {
+				t.Errorf("expected reserved %d but got %d", tt.wantReserved, reserved)
+			}
+
+			if initial := IntegerValue(object["initial"]); initial != tt.wantInitial {
+				t.Errorf("expected initial %d but got %d", tt.wantInitial, initial)
+			}
+
+			maximum, _, _ := unstructured.NestedFieldNoCopy(trainingPortal.Object, "spec", "portal", "sessions", "maximum")
+
+			if IntegerValue(maximum) != tt.wantMaximum {
+				t.Errorf("expected maximum sessions %d but got %v", tt.wantMaximum, maximum)
+			}
+		})
+	}
+}
+
+func TestAddWorkshopToTrainingPortalSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration string
+		existing map[string]interface{}
+		settings WorkshopSettings
+		check    func(t *testing.T, object map[string]interface{})
+	}{
+		{
+			name: "expires defaults to sixty minutes",
+			check: func(t *testing.T, object map[string]interface{}) {
+				if object["expires"] != "60m" {
+					t.Errorf("expected expires of 60m but got %v", object["expires"])
+				}
+			},
+		},
+		{
+			name:     "expires defaults to duration of workshop",
+			duration: "30m",
+			check: func(t *testing.T, object map[string]interface{}) {
+				if object["expires"] != "30m" {
+					t.Errorf("expected expires of 30m but got %v", object["expires"])
+				}
+			},
+		},
+		{
+			name:     "idle exempt sets orphaned to zero",
+			settings: WorkshopSettings{Orphaned: "5m", OrphanedWarning: "1m", IdleExempt: true},
+			check: func(t *testing.T, object map[string]interface{}) {
+				if object["orphaned"] != "0s" {
+					t.Errorf("expected orphaned of 0s but got %v", object["orphaned"])
+				}
+
+				if _, found := object["orphanedWarning"]; found {
+					t.Errorf("expected no orphaned warning but got %v", object["orphanedWarning"])
+				}
+			},
+		},
+		{
+			name:     "registry split into host and namespace",
+			settings: WorkshopSettings{Registry: "registry.example.com/workshops"},
+			check: func(t *testing.T, object map[string]interface{}) {
+				registry, _ := object["registry"].(map[string]interface{})
+
+				if registry["host"] != "registry.example.com" || registry["namespace"] != "workshops" {
+					t.Errorf("expected registry host and namespace but got %v", object["registry"])
+				}
+			},
+		},
+		{
+			name:     "environment variables added",
+			settings: WorkshopSettings{Environ: []string{"NAME=value=with=equals"}},
+			check: func(t *testing.T, object map[string]interface{}) {
+				env, _ := object["env"].([]interface{})
+
+				if len(env) != 1 {
+					t.Fatalf("expected one environment variable but got %v", object["env"])
+				}
+
+				if variable := env[0].(map[string]interface{}); variable["name"] != "NAME" || variable["value"] != "value=with=equals" {
+					t.Errorf("unexpected environment variable %v", variable)
+				}
+			},
+		},
+		{
+			name:     "scheduling added",
+			settings: WorkshopSettings{NodeSelector: []string{"pool=workshops"}, Tolerations: []string{"dedicated=workshops:NoSchedule"}},
+			check: func(t *testing.T, object map[string]interface{}) {
+				scheduling, _ := object["scheduling"].(map[string]interface{})
+
+				if selector, _ := scheduling["nodeSelector"].(map[string]interface{}); selector["pool"] != "workshops" {
+					t.Errorf("expected node selector but got %v", object["scheduling"])
+				}
+
+				if tolerations, _ := scheduling["tolerations"].([]interface{}); len(tolerations) != 1 {
+					t.Errorf("expected toleration but got %v", object["scheduling"])
+				}
+			},
+		},
+		{
+			name: "existing settings removed when not given",
+			existing: map[string]interface{}{
+				"name":       "lab-sample",
+				"capacity":   int64(4),
+				"overtime":   "10m",
+				"deadline":   "2h",
+				"refresh":    "1h",
+				"scheduling": map[string]interface{}{"nodeSelector": map[string]interface{}{"pool": "old"}},
+			},
+			settings: WorkshopSettings{Expires: "15m"},
+			check: func(t *testing.T, object map[string]interface{}) {
+				for _, key := range []string{"capacity", "overtime", "deadline", "refresh", "scheduling"} {
+					if _, found := object[key]; found {
+						t.Errorf("expected %s to be removed but got %v", key, object[key])
+					}
+				}
+
+				if object["expires"] != "15m" {
+					t.Errorf("expected expires of 15m but got %v", object["expires"])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var trainingPortal *unstructured.Unstructured
+
+			if tt.existing != nil {
+				trainingPortal = newTestTrainingPortal(nil, tt.existing)
+			} else {
+				trainingPortal = newTestTrainingPortal(nil)
+			}
+
+			settings := tt.settings
+
+			if err := AddWorkshopToTrainingPortal(trainingPortal, true, newTestWorkshop("lab-sample", tt.duration), &settings); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tt.check(t, findPortalWorkshop(t, trainingPortal, "lab-sample"))
+		})
+	}
+}
+
+func TestAddWorkshopToTrainingPortalInvalidScheduling(t *testing.T) {
+	trainingPortal := newTestTrainingPortal(nil)
+
+	settings := WorkshopSettings{Tolerations: []string{"dedicated:Sometimes"}}
+
+	if err := AddWorkshopToTrainingPortal(trainingPortal, true, newTestWorkshop("lab-sample", ""), &settings); err == nil {
+		t.Errorf("expected error for invalid toleration")
+	}
+}
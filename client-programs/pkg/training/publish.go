@@ -0,0 +1,512 @@
+package training
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/pkg/errors"
+	imgpkgcmd "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/cmd"
+	"github.com/vmware-tanzu/carvel-kapp/pkg/kapp/cmd"
+	vendirsync "github.com/vmware-tanzu/carvel-vendir/pkg/vendir/cmd"
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/kubectl/pkg/scheme"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/renderer"
+)
+
+/*
+Options for publishing the contents of a workshop as an OCI image artifact.
+The image defaults to that given in the workshop definition, and the
+registry flags are those used by imgpkg when pushing the image. The layer
+size is the size in bytes above which files are split over more than one
+layer, with no limit if zero.
+*/
+type PublishOptions struct {
+	Image           string
+	Repository      string
+	WorkshopFile    string
+	ExportWorkshop  string
+	WorkshopVersion string
+	BuildContent    bool
+	ContentImage    string
+	Locale          string
+	Restart         bool
+	LayerSize       int64
+	ParallelUploads int
+	RegistryFlags   imgpkgcmd.RegistryFlags
+	DataValuesFlags yttcmd.DataValuesFlags
+}
+
+/*
+Publish the workshop in the directory. If a file is given for exporting the
+workshop definition, a copy of it with the publish section removed is also
+written out, ready to be deployed using the published image.
+*/
+func (o *PublishOptions) Publish(ctx context.Context, directory string) error {
+	// If image name hasn't been supplied read workshop definition file and
+	// try to work out image name to publish workshop as.
+
+	rootDirectory := directory
+	workshopFilePath := o.WorkshopFile
+
+	workingDirectory, err := os.Getwd()
+
+	if err != nil {
+		return errors.Wrap(err, "cannot determine current working directory")
+	}
+
+	includePaths := []string{directory}
+	excludePaths := []string{".git"}
+
+	if !filepath.IsAbs(workshopFilePath) {
+		workshopFilePath = filepath.Join(rootDirectory, workshopFilePath)
+	}
+
+	workshopFileData, err := os.ReadFile(workshopFilePath)
+
+	if err != nil {
+		return errors.Wrapf(err, "cannot open workshop definition %q", workshopFilePath)
+	}
+
+	// Process the workshop YAML data for ytt templating and data variables.
+
+	if workshopFileData, err = ProcessWorkshopDefinition(workshopFileData, o.DataValuesFlags); err != nil {
+		return errors.Wrap(err, "unable to process workshop definition as template")
+	}
+
+	workshopFileData = []byte(strings.ReplaceAll(string(workshopFileData), "$(image_repository)", o.Repository))
+	workshopFileData = []byte(strings.ReplaceAll(string(workshopFileData), "$(workshop_version)", o.WorkshopVersion))
+
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder()
+
+	workshop := &unstructured.Unstructured{}
+
+	err = runtime.DecodeInto(decoder, workshopFileData, workshop)
+
+	if err != nil {
+		return errors.Wrap(err, "couldn't parse workshop definition")
+	}
+
+	if workshop.GetAPIVersion() != "training.educates.dev/v1beta1" || workshop.GetKind() != "Workshop" {
+		return errors.New("invalid type for workshop definition")
+	}
+
+	image := o.Image
+
+	if image == "" {
+		image, _, _ = unstructured.NestedString(workshop.Object, "spec", "publish", "image")
+	}
+
+	if image == "" {
+		return errors.Errorf("cannot find image name for publishing workshop %q", workshopFilePath)
+	}
+
+	// Extract vendir snippet describing subset of files to package up as the
+	// workshop image.
+
+	confUI := ui.NewConfUI(ui.NewNoopLogger())
+
+	uiFlags := cmd.UIFlags{
+		Color:          true,
+		JSON:           false,
+		NonInteractive: true,
+	}
+
+	uiFlags.ConfigureUI(confUI)
+
+	defer confUI.Flush()
+
+	if fileArtifacts, found, _ := unstructured.NestedSlice(workshop.Object, "spec", "publish", "files"); found && len(fileArtifacts) != 0 {
+		tempDir, err := os.MkdirTemp("", "educates-imgpkg")
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to create temporary working directory")
+		}
+
+		defer os.RemoveAll(tempDir)
+
+		for _, artifactEntry := range fileArtifacts {
+			vendirConfig := map[string]interface{}{
+				"apiVersion":  "vendir.k14s.io/v1alpha1",
+				"kind":        "Config",
+				"directories": []interface{}{},
+			}
+
+			dir := filepath.Join(tempDir, "files")
+
+			if filePath, found := artifactEntry.(map[string]interface{})["path"].(string); found {
+				dir = filepath.Join(tempDir, "files", filepath.Clean(filePath))
+			}
+
+			if directoryConfig, found := artifactEntry.(map[string]interface{})["directory"]; found {
+				if directoryPath, found := directoryConfig.(map[string]interface{})["path"].(string); found {
+					if !filepath.IsAbs(directoryPath) {
+						directoryConfig.(map[string]interface{})["path"] = filepath.Join(directory, directoryPath)
+					}
+				}
+			}
+
+			artifactEntry.(map[string]interface{})["path"] = "."
+
+			directoryConfig := map[string]interface{}{
+				"path":     dir,
+				"contents": []interface{}{artifactEntry},
+			}
+
+			vendirConfig["directories"] = append(vendirConfig["directories"].([]interface{}), directoryConfig)
+
+			yamlData, err := yaml.Marshal(&vendirConfig)
+
+			if err != nil {
+				return errors.Wrap(err, "unable to generate vendir config")
+			}
+
+			vendirConfigFile, err := os.Create(filepath.Join(tempDir, "vendir.yml"))
+
+			if err != nil {
+				return errors.Wrap(err, "unable to create vendir config file")
+			}
+
+			defer vendirConfigFile.Close()
+
+			_, err = vendirConfigFile.Write(yamlData)
+
+			if err != nil {
+				return errors.Wrap(err, "unable to write vendir config file")
+			}
+
+			syncOptions := vendirsync.NewSyncOptions(confUI)
+
+			syncOptions.Directories = nil
+			syncOptions.Files = []string{filepath.Join(tempDir, "vendir.yml")}
+
+			// Note that Chdir here actually changes the process working directory.
+
+			syncOptions.LockFile = filepath.Join(tempDir, "lock-file")
+			syncOptions.Locked = false
+			syncOptions.Chdir = tempDir
+			syncOptions.AllowAllSymlinkDestinations = false
+
+			if err = syncOptions.Run(); err != nil {
+				fmt.Println(string(yamlData))
+
+				return errors.Wrap(err, "failed to prepare image files for publishing")
+			}
+		}
+
+		// Restore working directory as was changed.
+
+		os.Chdir((workingDirectory))
+
+		rootDirectory = filepath.Join(tempDir, "files")
+		includePaths = []string{rootDirectory}
+	}
+
+	// Build the workshop instructions if requested, publishing only the
+	// generated files for the instructions in place of the Hugo sources.
+
+	if o.Locale != "" && !o.BuildContent {
+		return errors.New("a locale can only be given when building the workshop instructions")
+	}
+
+	if o.BuildContent {
+		stagingDir, err := o.buildContent(ctx, workshop, rootDirectory)
+
+		if stagingDir != "" {
+			defer os.RemoveAll(stagingDir)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		includePaths = []string{stagingDir}
+	}
+
+	// Now publish workshop directory contents as OCI image artifact. The
+	// image is packaged the same way as imgpkg does, with file times and
+	// permissions fixed so the image digest is the same each time, which
+	// allows an interrupted publish to be resumed.
+
+	fileImage, err := newFilesImage(includePaths, excludePaths, o.LayerSize)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to package workshop files as image artifact")
+	}
+
+	defer fileImage.Remove()
+
+	imageURL, sent, err := pushImage(ctx, image, fileImage, o.RegistryFlags, o.Restart, o.ParallelUploads)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to push image artifact for workshop")
+	}
+
+	fmt.Printf("Pushed '%s'\n", imageURL)
+
+	if err = printPublishReport(os.Stdout, fileImage, sent); err != nil {
+		return err
+	}
+
+	// Export modified workshop definition file.
+
+	exportWorkshop := o.ExportWorkshop
+
+	if exportWorkshop != "" {
+		// Insert workshop version property if not specified.
+
+		_, found, _ := unstructured.NestedString(workshop.Object, "spec", "version")
+
+		if !found && o.WorkshopVersion != "latest" {
+			unstructured.SetNestedField(workshop.Object, o.WorkshopVersion, "spec", "version")
+		}
+
+		// Remove the publish section as will not be accurate after publising.
+
+		unstructured.RemoveNestedField(workshop.Object, "spec", "publish")
+
+		workshopFileData, err = yaml.Marshal(&workshop.Object)
+
+		if err != nil {
+			return errors.Wrap(err, "couldn't convert workshop definition back to YAML")
+		}
+
+		if !filepath.IsAbs(exportWorkshop) {
+			exportWorkshop = filepath.Join(workingDirectory, exportWorkshop)
+		}
+
+		exportWorkshopFile, err := os.Create(exportWorkshop)
+
+		if err != nil {
+			return errors.Wrap(err, "unable to create exported workshop definition file")
+		}
+
+		defer exportWorkshopFile.Close()
+
+		_, err = exportWorkshopFile.Write(workshopFileData)
+
+		if err != nil {
+			return errors.Wrap(err, "unable to write exported workshop definition file")
+		}
+	}
+
+	return nil
+}
+
+/*
+Output the number of files and size of each layer of the published image,
+along with how much of each was uploaded, being none where the registry
+already held the layer from an earlier publish.
+*/
+func printPublishReport(out io.Writer, image *filesImage, sent map[string]int64) error {
+	layers, err := image.Layers()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read image layers")
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(out, 8, 8, 3, ' ', 0)
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "LAYER", "FILES", "SIZE", "COMPRESSED", "UPLOADED")
+
+	var totalFiles int
+	var totalSize, totalCompressed, totalSent int64
+
+	for i, layer := range layers {
+		digest, err := layer.Digest()
+
+		if err != nil {
+			return errors.Wrap(err, "unable to calculate layer digest")
+		}
+
+		compressed, err := layer.Size()
+
+		if err != nil {
+			return errors.Wrap(err, "unable to calculate layer size")
+		}
+
+		contents := image.layers[i]
+
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", digest.Hex[:12], contents.Files, formatSize(contents.Size), formatSize(compressed), formatSize(sent[digest.String()]))
+
+		totalFiles += contents.Files
+		totalSize += contents.Size
+		totalCompressed += compressed
+		totalSent += sent[digest.String()]
+	}
+
+	if len(layers) > 1 {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", "TOTAL", totalFiles, formatSize(totalSize), formatSize(totalCompressed), formatSize(totalSent))
+	}
+
+	return w.Flush()
+}
+
+/*
+Format a size in bytes using binary units.
+*/
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// Directories in the workshop directory holding the sources for Hugo, which
+// are not published when the workshop instructions are built beforehand.
+
+var hugoSourceDirectories = []string{"archetypes", "assets", "content", "data", "i18n", "layouts", "public", "resources", "static"}
+
+/*
+Build the workshop instructions for a workshop using the Hugo renderer and
+stage the files to publish in a temporary directory, which is returned so it
+can be removed later. The staged files are those of the root directory with
+the Hugo sources replaced by the generated files.
+*/
+func (o *PublishOptions) buildContent(ctx context.Context, workshop *unstructured.Unstructured, rootDirectory string) (string, error) {
+	workshopDirectory := filepath.Join(rootDirectory, "workshop")
+
+	if _, err := os.Stat(filepath.Join(workshopDirectory, "config.yaml")); err != nil {
+		return "", errors.New("workshop instructions are not rendered using Hugo, only Hugo content can be built")
+	}
+
+	stagingDir, err := os.MkdirTemp("", "educates-publish")
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create temporary staging directory")
+	}
+
+	err = copyDirectory(rootDirectory, stagingDir, isUnpublishedContentPath)
+
+	if err != nil {
+		return stagingDir, err
+	}
+
+	outputDir := filepath.Join(stagingDir, "workshop", "public")
+
+	if err = os.MkdirAll(outputDir, 0775); err != nil {
+		return stagingDir, errors.Wrap(err, "unable to create directory for workshop instructions")
+	}
+
+	title, _, _ := unstructured.NestedString(workshop.Object, "spec", "title")
+	description, _, _ := unstructured.NestedString(workshop.Object, "spec", "description")
+
+	if o.Locale != "" {
+		fmt.Printf("Building workshop instructions for locale %s using %s.\n", o.Locale, o.ContentImage)
+	} else {
+		fmt.Printf("Building workshop instructions using %s.\n", o.ContentImage)
+	}
+
+	if err = renderer.BuildHugoContent(ctx, workshopDirectory, outputDir, o.ContentImage, title, description, o.Locale, "/workshop/content/"); err != nil {
+		return stagingDir, err
+	}
+
+	return stagingDir, nil
+}
+
+/*
+Return whether a path relative to the root directory of a workshop is one
+which isn't published when the workshop instructions are built, being the
+Hugo sources for the instructions, including those for other locales, and
+the Git repository.
+*/
+func isUnpublishedContentPath(relPath string) bool {
+	if relPath == ".git" {
+		return true
+	}
+
+	for _, name := range hugoSourceDirectories {
+		if relPath == filepath.Join("workshop", name) {
+			return true
+		}
+	}
+
+	if filepath.Dir(relPath) == "workshop" && renderer.IsLocaleContentDirectory(filepath.Base(relPath)) {
+		return true
+	}
+
+	return false
+}
+
+/*
+Copy the contents of a directory, skipping any files or directories for which
+the exclude function returns true when passed the path relative to the source
+directory. Symbolic links are copied as links.
+*/
+func copyDirectory(src string, dst string, exclude func(relPath string) bool) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(src, path)
+
+		if err != nil {
+			return err
+		}
+
+		if relPath == "." {
+			return nil
+		}
+
+		if exclude(relPath) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		target := filepath.Join(dst, relPath)
+
+		info, err := entry.Info()
+
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		default:
+			data, err := os.ReadFile(path)
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to read %q", path)
+			}
+
+			return os.WriteFile(target, data, info.Mode().Perm())
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package training
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestIsUnpublishedContentPath(t *testing.T) {
+	tests := []struct {
+		relPath string
+		want    bool
+	}{
+		{relPath: ".git", want: true},
+		{relPath: filepath.Join("workshop", "content"), want: true},
+		{relPath: filepath.Join("workshop", "layouts"), want: true},
+		{relPath: filepath.Join("workshop", "static"), want: true},
+		{relPath: filepath.Join("workshop", "content.fr"), want: true},
+		{relPath: filepath.Join("workshop", "content.notalocale"), want: false},
+		{relPath: filepath.Join("workshop", "public"), want: true},
+		{relPath: filepath.Join("workshop", "config.yaml"), want: false},
+		{relPath: filepath.Join("workshop", "setup.d"), want: false},
+		{relPath: "content", want: false},
+		{relPath: filepath.Join("exercises", ".git"), want: false},
+		{relPath: filepath.Join("exercises", "workshop", "content"), want: false},
+		{relPath: "resources", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.relPath, func(t *testing.T) {
+			if got := isUnpublishedContentPath(tt.relPath); got != tt.want {
+				t.Errorf("isUnpublishedContentPath(%q) = %v, want %v", tt.relPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsExcludedPath(t *testing.T) {
+	tests := []struct {
+		relPath      string
+		excludePaths []string
+		want         bool
+	}{
+		{relPath: ".git", excludePaths: []string{".git"}, want: true},
+		{relPath: filepath.Join("exercises", ".git"), excludePaths: []string{".git"}, want: false},
+		{relPath: ".github", excludePaths: []string{".git"}, want: false},
+		{relPath: "README.md", excludePaths: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.relPath, func(t *testing.T) {
+			if got := isExcludedPath(tt.relPath, tt.excludePaths); got != tt.want {
+				t.Errorf("isExcludedPath(%q, %v) = %v, want %v", tt.relPath, tt.excludePaths, got, tt.want)
+			}
+		})
+	}
+}
+
+/*
+Create a workshop directory holding the given files, with the contents of
+each file being its name.
+*/
+func createTestWorkshopDirectory(t *testing.T, names ...string) string {
+	t.Helper()
+
+	directory := t.TempDir()
+
+	for _, name := range names {
+		writeTestFile(t, filepath.Join(directory, filepath.FromSlash(name)), name)
+	}
+
+	return directory
+}
+
+/*
+Return the names of the regular files held in the layers of an image.
+*/
+func filesImageNames(t *testing.T, image *filesImage) []string {
+	t.Helper()
+
+	var names []string
+
+	for _, path := range image.paths {
+		file, err := os.Open(path)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reader := tar.NewReader(file)
+
+		for {
+			header, err := reader.Next()
+
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				file.Close()
+				t.Fatal(err)
+			}
+
+			if header.Typeflag == tar.TypeReg {
+				names = append(names, header.Name)
+			}
+		}
+
+		file.Close()
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func TestNewFilesImageIncludeExclude(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        []string
+		include      func(directory string) []string
+		excludePaths []string
+		layerSize    int64
+		want         []string
+		wantLayers   int
+	}{
+		{
+			name:         "git repository excluded",
+			files:        []string{"README.md", ".git/config", "workshop/content/intro.md", "exercises/.git/config"},
+			include:      func(directory string) []string { return []string{directory} },
+			excludePaths: []string{".git"},
+			want:         []string{"README.md", "exercises/.git/config", "workshop/content/intro.md"},
+			wantLayers:   1,
+		},
+		{
+			name:       "nothing excluded",
+			files:      []string{".git/config", "README.md"},
+			include:    func(directory string) []string { return []string{directory} },
+			want:       []string{".git/config", "README.md"},
+			wantLayers: 1,
+		},
+		{
+			name:  "single file included by name",
+			files: []string{"resources/workshop.yaml", "README.md"},
+			include: func(directory string) []string {
+				return []string{filepath.Join(directory, "resources", "workshop.yaml")}
+			},
+			want:       []string{"workshop.yaml"},
+			wantLayers: 1,
+		},
+		{
+			name:       "empty directory gives single layer",
+			include:    func(directory string) []string { return []string{directory} },
+			wantLayers: 1,
+		},
+		{
+			name:       "files split over layers",
+			files:      []string{"a.txt", "b.txt", "c.txt"},
+			include:    func(directory string) []string { return []string{directory} },
+			layerSize:  1536,
+			want:       []string{"a.txt", "b.txt", "c.txt"},
+			wantLayers: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := createTestWorkshopDirectory(t, tt.files...)
+
+			image, err := newFilesImage(tt.include(directory), tt.excludePaths, tt.layerSize)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			defer image.Remove()
+
+			if got := filesImageNames(t, image); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected files %v but got %v", tt.want, got)
+			}
+
+			if len(image.layers) != tt.wantLayers {
+				t.Errorf("expected %d layers but got %d", tt.wantLayers, len(image.layers))
+			}
+
+			files := 0
+
+			for _, layer := range image.layers {
+				files += layer.Files
+			}
+
+			if files != len(tt.want) {
+				t.Errorf("expected layers to count %d files but counted %d", len(tt.want), files)
+			}
+		})
+	}
+}
+
+func TestCopyDirectoryExcludesUnpublishedContent(t *testing.T) {
+	directory := createTestWorkshopDirectory(t,
+		".git/config",
+		"README.md",
+		"resources/workshop.yaml",
+		"workshop/config.yaml",
+		"workshop/content/intro.md",
+		"workshop/content.fr/intro.md",
+		"workshop/layouts/partials/header.html",
+		"workshop/setup.d/01-setup.sh",
+	)
+
+	target := t.TempDir()
+
+	if err := copyDirectory(directory, target, isUnpublishedContentPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+
+	err := filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(target, path)
+
+		got = append(got, filepath.ToSlash(relPath))
+
+		return err
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(got)
+
+	want := []string{"README.md", "resources/workshop.yaml", "workshop/config.yaml", "workshop/setup.d/01-setup.sh"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected files %v but got %v", want, got)
+	}
+}
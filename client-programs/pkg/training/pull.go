@@ -0,0 +1,27 @@
+package training
+
+import (
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/pkg/errors"
+	imgpkgcmd "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/cmd"
+)
+
+/*
+Pull the files from a published workshop image artifact into a directory, in
+the same way as workshop sessions download the workshop files. The
+directory is replaced if it already exists.
+*/
+func PullWorkshopFiles(image string, directory string, flags imgpkgcmd.RegistryFlags) error {
+	pullOptions := imgpkgcmd.NewPullOptions(ui.NewNoopUI())
+
+	pullOptions.ImageFlags.Image = image
+	pullOptions.ImageIsBundleCheck = true
+	pullOptions.OutputPath = directory
+	pullOptions.RegistryFlags = flags
+
+	if err := pullOptions.Run(); err != nil {
+		return errors.Wrapf(err, "unable to pull workshop files from %s", image)
+	}
+
+	return nil
+}
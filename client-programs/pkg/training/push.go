@@ -0,0 +1,744 @@
+package training
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pkg/errors"
+	imgpkgcmd "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/cmd"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry/auth"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/httpclient"
+)
+
+/*
+Size of the chunks blobs are uploaded in. The state of an upload is saved
+after each chunk is accepted by the registry, so at most this much needs to be
+sent again when publishing is interrupted.
+*/
+const pushChunkSize = 8 * 1024 * 1024
+
+const pushInitialBackoff = 2 * time.Second
+
+const pushMaximumBackoff = 30 * time.Second
+
+/*
+State of a publish saved between runs. Only the locations of uploads which
+were started are recorded, along with the checksums of the chunks sent, as
+the registry is asked how much of each upload it has received, and which
+blobs it already has, when the publish resumes. The state applies only to
+the image digest recorded, so a publish after the workshop files have
+changed starts again.
+*/
+type pushState struct {
+	Image   string              `json:"image"`
+	Digest  string              `json:"digest"`
+	Uploads map[string]string   `json:"uploads"`
+	Chunks  map[string][]string `json:"chunks,omitempty"`
+}
+
+/*
+Push of an image to a registry using the OCI distribution API directly, so
+blob uploads can be resumed part way through using the same upload session.
+Layers are uploaded in parallel, so the state and the count of bytes sent
+for each blob are guarded by the mutex. Requests are made using the context
+of the push, so are cancelled when the command is interrupted.
+*/
+type imagePusher struct {
+	ctx       context.Context
+	client    *http.Client
+	ref       name.Tag
+	parallel  int
+	mutex     sync.Mutex
+	state     *pushState
+	stateFile string
+	sent      map[string]int64
+}
+
+func pushStateFile(image string) string {
+	hash := sha256.Sum256([]byte(image))
+
+	return filepath.Join(xdg.StateHome, "educates", "publish", hex.EncodeToString(hash[:8])+".json")
+}
+
+func loadPushState(file string, image string, digest string) *pushState {
+	state := &pushState{}
+
+	if data, err := os.ReadFile(file); err == nil {
+		json.Unmarshal(data, state)
+	}
+
+	if state.Image != image || state.Digest != digest || state.Uploads == nil {
+		state = &pushState{Image: image, Digest: digest, Uploads: map[string]string{}}
+	}
+
+	if state.Chunks == nil {
+		state.Chunks = map[string][]string{}
+	}
+
+	return state
+}
+
+/*
+Record where the upload of a blob has got to and save the state, or when the
+location is empty, that the upload has completed.
+*/
+func (p *imagePusher) updateUpload(digest regv1.Hash, location string, chunks []string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if location == "" {
+		delete(p.state.Uploads, digest.String())
+		delete(p.state.Chunks, digest.String())
+	} else {
+		p.state.Uploads[digest.String()] = location
+		p.state.Chunks[digest.String()] = append([]string(nil), chunks...)
+	}
+
+	return p.saveState()
+}
+
+func (p *imagePusher) savedUpload(digest regv1.Hash) (string, []string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.state.Uploads[digest.String()], append([]string(nil), p.state.Chunks[digest.String()]...)
+}
+
+func (p *imagePusher) recordSent(digest regv1.Hash, count int64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.sent[digest.String()] += count
+}
+
+func (p *imagePusher) saveState() error {
+	data, err := json.Marshal(p.state)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to generate publish state")
+	}
+
+	if err = os.MkdirAll(filepath.Dir(p.stateFile), os.ModePerm); err != nil {
+		return errors.Wrap(err, "unable to create directory for publish state")
+	}
+
+	if err = os.WriteFile(p.stateFile, data, 0o600); err != nil {
+		return errors.Wrap(err, "unable to save publish state")
+	}
+
+	return nil
+}
+
+/*
+Create the HTTP client for pushing to the repository of the image, using the
+same registry flags and credentials as imgpkg.
+*/
+func newPushClient(ctx context.Context, ref name.Tag, flags imgpkgcmd.RegistryFlags) (*http.Client, error) {
+	opts := flags.AsRegistryOpts()
+
+	keychain, err := registry.Keychain(auth.KeychainOpts{
+		Username:                opts.Username,
+		Password:                opts.Password,
+		Token:                   opts.Token,
+		Anon:                    opts.Anon,
+		EnableIaasAuthProviders: opts.EnableIaasAuthProviders,
+		ActiveKeychains:         opts.ActiveKeychains,
+	}, opts.EnvironFunc)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create registry keychain")
+	}
+
+	authenticator, err := keychain.Resolve(ref.Context())
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find credentials for %s", ref.RegistryStr())
+	}
+
+	pool, err := x509.SystemCertPool()
+
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, file := range httpclient.RegistryCACertPaths(opts.CACertPaths) {
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read CA certificate file %s", file)
+		}
+
+		pool.AppendCertsFromPEM(data)
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	base.ForceAttemptHTTP2 = false
+	base.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+	base.TLSClientConfig = &tls.Config{RootCAs: pool, InsecureSkipVerify: !opts.VerifyCerts}
+
+	scopes := []string{ref.Context().Scope(transport.PushScope)}
+
+	roundTripper, err := transport.NewWithContext(ctx, ref.Context().Registry, authenticator, base, scopes)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to authenticate to %s", ref.RegistryStr())
+	}
+
+	return &http.Client{Transport: roundTripper}, nil
+}
+
+/*
+Push an image to a registry, returning the reference to it by digest and the
+number of bytes sent for each blob, which is zero for blobs the registry
+already had. When a publish of the same image was interrupted, uploads of
+blobs are continued from where they got to. Failures part way through are
+retried the number of times given by the registry retry count, again
+continuing from where they got to, and once the manifest is pushed it is
+checked the registry holds the same manifest. Layers are compressed and
+uploaded with up to the given number in parallel. The saved state is
+discarded first if restart is true.
+*/
+func pushImage(ctx context.Context, image string, img regv1.Image, flags imgpkgcmd.RegistryFlags, restart bool, parallel int) (string, map[string]int64, error) {
+	var nameOptions []name.Option
+
+	if flags.Insecure {
+		nameOptions = append(nameOptions, name.Insecure)
+	}
+
+	ref, err := name.NewTag(image, nameOptions...)
+
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "invalid image name %q", image)
+	}
+
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	// Calculating the digest of the image requires the digests of all the
+	// layers, which means compressing them, so do that in parallel first.
+
+	layers, err := img.Layers()
+
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to read image layers")
+	}
+
+	err = forEachParallel(len(layers), parallel, func(i int) error {
+		_, err := layers[i].Digest()
+
+		return err
+	})
+
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to calculate layer digest")
+	}
+
+	digest, err := img.Digest()
+
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to calculate image digest")
+	}
+
+	client, err := newPushClient(ctx, ref, flags)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	stateFile := pushStateFile(ref.Name())
+
+	if restart {
+		os.Remove(stateFile)
+	}
+
+	pusher := &imagePusher{
+		ctx:       ctx,
+		client:    client,
+		ref:       ref,
+		parallel:  parallel,
+		state:     loadPushState(stateFile, ref.Name(), digest.String()),
+		stateFile: stateFile,
+		sent:      map[string]int64{},
+	}
+
+	if len(pusher.state.Uploads) != 0 {
+		fmt.Printf("Resuming interrupted publish of %s.\n", ref.Name())
+	}
+
+	backoff := pushInitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		err = pusher.push(img)
+
+		if err == nil {
+			break
+		}
+
+		if attempt >= flags.RetryCount || ctx.Err() != nil {
+			return "", nil, errors.Wrapf(err, "unable to push image %s, run the command again to resume", ref.Name())
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: push of image interrupted, retrying in %s: %s.\n", backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return "", nil, errors.Wrapf(ctx.Err(), "unable to push image %s, run the command again to resume", ref.Name())
+		}
+
+		if backoff *= 2; backoff > pushMaximumBackoff {
+			backoff = pushMaximumBackoff
+		}
+	}
+
+	os.Remove(stateFile)
+
+	return fmt.Sprintf("%s@%s", ref.Context().Name(), digest), pusher.sent, nil
+}
+
+func (p *imagePusher) push(img regv1.Image) error {
+	layers, err := img.Layers()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read image layers")
+	}
+
+	err = forEachParallel(len(layers), p.parallel, func(i int) error {
+		digest, err := layers[i].Digest()
+
+		if err != nil {
+			return errors.Wrap(err, "unable to calculate layer digest")
+		}
+
+		return p.uploadBlob(digest, layers[i].Compressed)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	configName, err := img.ConfigName()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to calculate config digest")
+	}
+
+	configData, err := img.RawConfigFile()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read image config")
+	}
+
+	err = p.uploadBlob(configName, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(configData)), nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	manifest, err := img.RawManifest()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read image manifest")
+	}
+
+	mediaType, err := img.MediaType()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to read image media type")
+	}
+
+	digest, err := img.Digest()
+
+	if err != nil {
+		return errors.Wrap(err, "unable to calculate image digest")
+	}
+
+	// Also tag the image by digest in the same way as imgpkg does, so images
+	// are not garbage collected by registries which remove untagged images.
+
+	tags := []string{p.ref.TagStr(), fmt.Sprintf("%s-%s.imgpkg", digest.Algorithm, digest.Hex)}
+
+	for _, tag := range tags {
+		if err = p.putManifest(tag, manifest, string(mediaType)); err != nil {
+			return err
+		}
+	}
+
+	return p.verifyManifest(p.ref.TagStr(), digest, string(mediaType))
+}
+
+/*
+Call the function for each index up to the count, with up to the given number
+of calls running in parallel. All calls are allowed to finish, with the first
+error returned.
+*/
+func forEachParallel(count int, parallel int, fn func(i int) error) error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	limit := make(chan struct{}, parallel)
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+
+		limit <- struct{}{}
+
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			if err := fn(i); err != nil {
+				once.Do(func() { firstErr = err })
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+func (p *imagePusher) url(format string, args ...interface{}) string {
+	return fmt.Sprintf("%s://%s/v2/%s/%s", p.ref.Context().Registry.Scheme(), p.ref.RegistryStr(), p.ref.RepositoryStr(), fmt.Sprintf(format, args...))
+}
+
+/*
+Resolve the location of an upload returned by the registry, which can be
+relative to the URL of the request.
+*/
+func (p *imagePusher) location(response *http.Response) (string, error) {
+	location, err := response.Location()
+
+	if err != nil {
+		return "", errors.Wrap(err, "registry didn't return location of upload")
+	}
+
+	return location.String(), nil
+}
+
+func (p *imagePusher) do(method string, location string, body []byte, headers map[string]string, expected ...int) (*http.Response, error) {
+	var reader io.Reader
+
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	request, err := http.NewRequestWithContext(p.ctx, method, location, reader)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid request for %s", location)
+	}
+
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := p.client.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, code := range expected {
+		if response.StatusCode == code {
+			return response, nil
+		}
+	}
+
+	defer response.Body.Close()
+
+	if err = transport.CheckError(response, expected...); err != nil {
+		return nil, err
+	}
+
+	return nil, errors.Errorf("unexpected status %s from registry", response.Status)
+}
+
+/*
+Upload a blob unless the registry already has it. Where the upload of the blob
+was started by an earlier publish, the registry is asked how much it received
+and the upload continues from there. The blob contents are reproduced by
+calling open, with the part already uploaded skipped. The checksum of each
+chunk sent is saved, and the part skipped is checked against them, so that an
+upload is only continued when the data being sent is the same as before.
+*/
+func (p *imagePusher) uploadBlob(digest regv1.Hash, open func() (io.ReadCloser, error)) error {
+	response, err := p.do(http.MethodHead, p.url("blobs/%s", digest), nil, nil, http.StatusOK, http.StatusNotFound)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to check for blob %s", digest)
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var offset int64
+
+	location, chunks := p.savedUpload(digest)
+
+	if location != "" {
+		response, err := p.do(http.MethodGet, location, nil, nil, http.StatusNoContent, http.StatusNotFound)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to check upload of blob %s", digest)
+		}
+
+		response.Body.Close()
+
+		if response.StatusCode == http.StatusNotFound {
+			location = ""
+		} else {
+			if value := response.Header.Get("Range"); value != "" {
+				parts := strings.SplitN(value, "-", 2)
+
+				if len(parts) == 2 {
+					if end, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+						offset = end + 1
+					}
+				}
+			}
+
+			if response.Header.Get("Location") != "" {
+				if location, err = p.location(response); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	reader, err := open()
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to read blob %s", digest)
+	}
+
+	defer func() { reader.Close() }()
+
+	if location != "" && offset != 0 {
+		count, err := skipChunks(reader, offset, chunks)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to read blob %s", digest)
+		}
+
+		if count >= 0 {
+			fmt.Printf("Resuming upload of blob %s from %d bytes.\n", digest, offset)
+
+			chunks = chunks[:count]
+		} else {
+			fmt.Printf("Restarting upload of blob %s as data sent before doesn't match.\n", digest)
+
+			reader.Close()
+
+			if reader, err = open(); err != nil {
+				return errors.Wrapf(err, "unable to read blob %s", digest)
+			}
+
+			location = ""
+		}
+	}
+
+	if location == "" {
+		response, err := p.do(http.MethodPost, p.url("blobs/uploads/"), nil, nil, http.StatusAccepted)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to start upload of blob %s", digest)
+		}
+
+		response.Body.Close()
+
+		if location, err = p.location(response); err != nil {
+			return err
+		}
+
+		offset = 0
+		chunks = nil
+
+		if err = p.updateUpload(digest, location, chunks); err != nil {
+			return err
+		}
+	}
+
+	buffer := make([]byte, pushChunkSize)
+
+	for {
+		count, readErr := io.ReadFull(reader, buffer)
+
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return errors.Wrapf(readErr, "unable to read blob %s", digest)
+		}
+
+		if count == 0 {
+			break
+		}
+
+		headers := map[string]string{
+			"Content-Type":  "application/octet-stream",
+			"Content-Range": fmt.Sprintf("%d-%d", offset, offset+int64(count)-1),
+		}
+
+		response, err := p.do(http.MethodPatch, location, buffer[:count], headers, http.StatusAccepted, http.StatusNoContent)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to upload blob %s", digest)
+		}
+
+		response.Body.Close()
+
+		if location, err = p.location(response); err != nil {
+			return err
+		}
+
+		offset += int64(count)
+
+		p.recordSent(digest, int64(count))
+
+		checksum := sha256.Sum256(buffer[:count])
+
+		chunks = append(chunks, hex.EncodeToString(checksum[:]))
+
+		if err = p.updateUpload(digest, location, chunks); err != nil {
+			return err
+		}
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	complete, err := url.Parse(location)
+
+	if err != nil {
+		return errors.Wrapf(err, "invalid location for upload of blob %s", digest)
+	}
+
+	query := complete.Query()
+
+	query.Set("digest", digest.String())
+
+	complete.RawQuery = query.Encode()
+
+	response, err = p.do(http.MethodPut, complete.String(), nil, nil, http.StatusCreated)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to complete upload of blob %s", digest)
+	}
+
+	response.Body.Close()
+
+	return p.updateUpload(digest, "", nil)
+}
+
+/*
+Read the part of a blob already uploaded, checking it against the checksums
+saved for the chunks sent. Returns the number of chunks covered, or -1 if
+any don't match or there are none saved for part of what was uploaded.
+*/
+func skipChunks(reader io.Reader, offset int64, chunks []string) (int, error) {
+	buffer := make([]byte, pushChunkSize)
+
+	count := 0
+
+	for remaining := offset; remaining > 0; count++ {
+		size := int64(pushChunkSize)
+
+		if remaining < size {
+			size = remaining
+		}
+
+		if count >= len(chunks) {
+			return -1, nil
+		}
+
+		if _, err := io.ReadFull(reader, buffer[:size]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return -1, nil
+			}
+
+			return -1, err
+		}
+
+		checksum := sha256.Sum256(buffer[:size])
+
+		if hex.EncodeToString(checksum[:]) != chunks[count] {
+			return -1, nil
+		}
+
+		remaining -= size
+	}
+
+	return count, nil
+}
+
+func (p *imagePusher) putManifest(tag string, manifest []byte, mediaType string) error {
+	response, err := p.do(http.MethodPut, p.url("manifests/%s", tag), manifest, map[string]string{"Content-Type": mediaType}, http.StatusOK, http.StatusCreated, http.StatusAccepted)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to push manifest for tag %s", tag)
+	}
+
+	response.Body.Close()
+
+	return nil
+}
+
+/*
+Check the tag in the registry refers to the manifest pushed, which confirms
+the registry holds the complete image.
+*/
+func (p *imagePusher) verifyManifest(tag string, digest regv1.Hash, mediaType string) error {
+	response, err := p.do(http.MethodGet, p.url("manifests/%s", tag), nil, map[string]string{"Accept": mediaType}, http.StatusOK)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to fetch manifest for tag %s", tag)
+	}
+
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to fetch manifest for tag %s", tag)
+	}
+
+	actual, _, err := regv1.SHA256(bytes.NewReader(data))
+
+	if err != nil {
+		return errors.Wrap(err, "unable to calculate manifest digest")
+	}
+
+	if actual != digest {
+		return errors.Errorf("registry holds manifest %s for tag %s, expected %s", actual, tag, digest)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+/*
+Operations on the custom resources of the training platform, such as loading
+workshop definitions, adding workshops to training portals and publishing
+workshop content. These are used by the commands of the CLI, but can also be
+used by other tools wanting to perform the same operations.
+*/
+package training
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	WorkshopResource            = schema.GroupVersionResource{Group: "training.educates.dev", Version: "v1beta1", Resource: "workshops"}
+	TrainingPortalResource      = schema.GroupVersionResource{Group: "training.educates.dev", Version: "v1beta1", Resource: "trainingportals"}
+	WorkshopEnvironmentResource = schema.GroupVersionResource{Group: "training.educates.dev", Version: "v1beta1", Resource: "workshopenvironments"}
+	WorkshopSessionResource     = schema.GroupVersionResource{Group: "training.educates.dev", Version: "v1beta1", Resource: "workshopsessions"}
+)
+
+/*
+Return an integer setting from a resource as an int64. Numbers can be held as
+different types depending on whether the resource was read from the cluster
+or parsed from JSON.
+*/
+func IntegerValue(value interface{}) int64 {
+	switch value := value.(type) {
+	case int64:
+		return value
+	case int:
+		return int64(value)
+	case float64:
+		return int64(value)
+	}
+
+	return 0
+}
@@ -0,0 +1,322 @@
+package training
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/pkg/errors"
+	imgpkgimage "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/image"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
+)
+
+/*
+Files packaged as an image artifact, with the tarballs for the layers held in
+temporary files until the image has been pushed.
+*/
+type filesImage struct {
+	regv1.Image
+	paths  []string
+	layers []filesLayer
+}
+
+/*
+Number of files and uncompressed size of the tarball for a layer of an image
+artifact.
+*/
+type filesLayer struct {
+	Files int
+	Size  int64
+}
+
+func (i *filesImage) Remove() {
+	for _, path := range i.paths {
+		os.Remove(path)
+	}
+}
+
+/*
+Package files as an image artifact in the same way as imgpkg does, with file
+times and permissions fixed so the image digest is the same each time. The
+difference from imgpkg is that whether a file is executable is worked out by
+the platform helpers, as on Windows the permissions of files don't say, and
+scripts in workshops published from Windows would otherwise not be
+executable when the workshop files are downloaded into a workshop session.
+
+Where a layer size is given, the files are split over as many layers as are
+needed to keep each under that size, so that large workshops can be uploaded
+a layer at a time in parallel. Since imgpkg extracts all layers of an image
+in turn, the files are downloaded the same as when in a single layer. A file
+larger than the layer size is placed in a layer by itself. Files which fit
+within one layer give the same image as imgpkg would.
+*/
+func newFilesImage(includePaths []string, excludePaths []string, layerSize int64) (*filesImage, error) {
+	writer := &layeredTarballWriter{limit: layerSize}
+
+	err := writer.writeFiles(includePaths, excludePaths)
+
+	if closeErr := writer.close(); err == nil {
+		err = closeErr
+	}
+
+	image := &filesImage{paths: writer.paths, layers: writer.layers}
+
+	if err != nil {
+		image.Remove()
+
+		return nil, err
+	}
+
+	var addenda []mutate.Addendum
+
+	for _, path := range image.paths {
+		fileImage, err := imgpkgimage.NewFileImage(path, nil)
+
+		if err != nil {
+			image.Remove()
+
+			return nil, err
+		}
+
+		if len(image.paths) == 1 {
+			image.Image = fileImage
+
+			return image, nil
+		}
+
+		layers, err := fileImage.Layers()
+
+		if err != nil {
+			image.Remove()
+
+			return nil, err
+		}
+
+		addenda = append(addenda, mutate.Addendum{
+			Layer: layers[0],
+			History: regv1.History{
+				Author:    "imgpkg",
+				CreatedBy: "imgpkg",
+				Created:   regv1.Time{},
+			},
+		})
+	}
+
+	if image.Image, err = mutate.Append(empty.Image, addenda...); err != nil {
+		image.Remove()
+
+		return nil, err
+	}
+
+	return image, nil
+}
+
+/*
+Writer for the tarballs of the layers of an image artifact, which starts a
+new tarball when adding a file would take the current one over the limit.
+*/
+type layeredTarballWriter struct {
+	limit     int64
+	paths     []string
+	layers    []filesLayer
+	file      *os.File
+	counter   *countingWriter
+	tarWriter *tar.Writer
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(data []byte) (int, error) {
+	count, err := w.writer.Write(data)
+
+	w.count += int64(count)
+
+	return count, err
+}
+
+/*
+Return the tarball writer for the next entry, of the size given, first
+starting a new tarball if needed. A new tarball is only started where the
+current one already holds a file, so a file larger than the limit is placed
+in a tarball by itself.
+*/
+func (w *layeredTarballWriter) next(size int64) (*tar.Writer, error) {
+	if w.tarWriter != nil {
+		layer := &w.layers[len(w.layers)-1]
+
+		if w.limit <= 0 || layer.Files == 0 || w.counter.count+size+1024 <= w.limit {
+			return w.tarWriter, nil
+		}
+
+		if err := w.closeLayer(); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.CreateTemp("", "educates-files-image")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create temporary file for image")
+	}
+
+	w.paths = append(w.paths, file.Name())
+	w.layers = append(w.layers, filesLayer{})
+
+	w.file = file
+	w.counter = &countingWriter{writer: file}
+	w.tarWriter = tar.NewWriter(w.counter)
+
+	return w.tarWriter, nil
+}
+
+func (w *layeredTarballWriter) closeLayer() error {
+	err := w.tarWriter.Close()
+
+	w.layers[len(w.layers)-1].Size = w.counter.count
+
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	w.tarWriter = nil
+
+	return err
+}
+
+/*
+Finish the last tarball, creating an empty one if there were no files.
+*/
+func (w *layeredTarballWriter) close() error {
+	if w.tarWriter == nil && len(w.paths) == 0 {
+		if _, err := w.next(0); err != nil {
+			return err
+		}
+	}
+
+	if w.tarWriter == nil {
+		return nil
+	}
+
+	return w.closeLayer()
+}
+
+func isExcludedPath(relPath string, excludePaths []string) bool {
+	for _, excludePath := range excludePaths {
+		if relPath == excludePath {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *layeredTarballWriter) writeFiles(includePaths []string, excludePaths []string) error {
+	for _, includePath := range includePaths {
+		root := platform.LongPath(includePath)
+
+		info, err := os.Stat(root)
+
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			if err = w.addFile(root, filepath.Base(includePath), info); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err = filepath.Walk(root, func(walkedPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(root, walkedPath)
+
+			if err != nil {
+				return err
+			}
+
+			if isExcludedPath(relPath, excludePaths) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if info.IsDir() {
+				tarWriter, err := w.next(0)
+
+				if err != nil {
+					return err
+				}
+
+				return tarWriter.WriteHeader(&tar.Header{
+					Name:     filepath.ToSlash(relPath),
+					Mode:     0700,
+					ModTime:  time.Time{},
+					Typeflag: tar.TypeDir,
+				})
+			}
+
+			if info.Mode()&os.ModeType != 0 {
+				return fmt.Errorf("expected file '%s' to be a regular file", walkedPath)
+			}
+
+			return w.addFile(walkedPath, relPath, info)
+		})
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to add %q to image", includePath)
+		}
+	}
+
+	return nil
+}
+
+func (w *layeredTarballWriter) addFile(path string, relPath string, info os.FileInfo) error {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	tarWriter, err := w.next(info.Size())
+
+	if err != nil {
+		return err
+	}
+
+	w.layers[len(w.layers)-1].Files++
+
+	header := &tar.Header{
+		Name:     filepath.ToSlash(relPath),
+		Size:     info.Size(),
+		Mode:     int64(platform.FileMode(path, info) & 0700),
+		ModTime:  time.Time{},
+		Typeflag: tar.TypeReg,
+	}
+
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tarWriter, file)
+
+	return err
+}
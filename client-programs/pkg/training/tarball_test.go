@@ -0,0 +1,132 @@
+package training
+
+import (
+	"archive/tar"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/platform"
+)
+
+func TestNewFilesImagePermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		perm        fs.FileMode
+		wantWindows int64
+		wantUnix    int64
+	}{
+		{name: "shell script", file: "workshop/setup.d/01-setup.sh", content: "echo setup\n", perm: 0644, wantWindows: 0700, wantUnix: 0600},
+		{name: "script with shebang", file: "exercises/run", content: "#!/bin/bash\necho run\n", perm: 0644, wantWindows: 0700, wantUnix: 0600},
+		{name: "executable file", file: "exercises/build", content: "make\n", perm: 0755, wantWindows: 0600, wantUnix: 0700},
+		{name: "read only file", file: "README.md", content: "# Workshop\n", perm: 0444, wantWindows: 0400, wantUnix: 0400},
+		{name: "regular file", file: "resources/workshop.yaml", content: "kind: Workshop\n", perm: 0664, wantWindows: 0600, wantUnix: 0600},
+	}
+
+	directory := t.TempDir()
+
+	for _, tt := range tests {
+		path := filepath.Join(directory, filepath.FromSlash(tt.file))
+
+		writeTestFile(t, path, tt.content)
+
+		if err := os.Chmod(path, tt.perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Read only files need to be made writable again so the temporary
+	// directory can be removed on Windows.
+
+	t.Cleanup(func() {
+		for _, tt := range tests {
+			os.Chmod(filepath.Join(directory, filepath.FromSlash(tt.file)), 0644)
+		}
+	})
+
+	image, err := newFilesImage([]string{directory}, nil, 0)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer image.Remove()
+
+	if len(image.paths) != 1 {
+		t.Fatalf("expected a single layer but got %d", len(image.paths))
+	}
+
+	file, err := os.Open(image.paths[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	headers := map[string]*tar.Header{}
+
+	reader := tar.NewReader(file)
+
+	for {
+		header, err := reader.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		headers[header.Name] = header
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, found := headers[tt.file]
+
+			if !found {
+				t.Fatalf("expected %q in image", tt.file)
+			}
+
+			want := tt.wantUnix
+
+			if platform.IsWindows() {
+				want = tt.wantWindows
+			}
+
+			if header.Mode != want {
+				t.Errorf("expected mode %o for %q but got %o", want, tt.file, header.Mode)
+			}
+
+			// The zero time used for entries is written to the tarball as
+			// the Unix epoch.
+
+			if !header.ModTime.Equal(time.Unix(0, 0)) {
+				t.Errorf("expected fixed modification time for %q but got %v", tt.file, header.ModTime)
+			}
+		})
+	}
+
+	// Directories are always recorded with the same permissions and use
+	// forward slashes as separators, regardless of the platform.
+
+	for _, name := range []string{"workshop", "workshop/setup.d", "exercises", "resources"} {
+		header, found := headers[name]
+
+		if !found {
+			t.Errorf("expected directory %q in image", name)
+			continue
+		}
+
+		if header.Typeflag != tar.TypeDir || header.Mode != 0700 {
+			t.Errorf("expected directory %q with mode 700 but got type %c mode %o", name, header.Typeflag, header.Mode)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package training
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	yttcmd "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/template"
+	yttcmdui "github.com/vmware-tanzu/carvel-ytt/pkg/cmd/ui"
+	"github.com/vmware-tanzu/carvel-ytt/pkg/files"
+	"github.com/vmware-tanzu/carvel-ytt/pkg/yamlmeta"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/config"
+)
+
+/*
+Process a workshop definition as a ytt template, returning only the first
+document from the output.
+*/
+func ProcessWorkshopDefinition(yamlData []byte, dataValueFlags yttcmd.DataValuesFlags) ([]byte, error) {
+	return processWorkshopTemplate(yamlData, dataValueFlags, false)
+}
+
+/*
+Process a file of workshop definitions as a ytt template, returning all
+documents from the output.
+*/
+func ProcessWorkshopDefinitions(yamlData []byte, dataValueFlags yttcmd.DataValuesFlags) ([]byte, error) {
+	return processWorkshopTemplate(yamlData, dataValueFlags, true)
+}
+
+/*
+Generate a ytt data values document holding settings from the local platform
+configuration, so that workshop templates can reference values such as
+"data.values.ingressDomain" without them needing to be supplied as options.
+*/
+func platformDataValues() []byte {
+	platformConfig, err := config.NewInstallationConfigFromDefaultValues()
+
+	if err != nil {
+		return nil
+	}
+
+	ingressProtocol := platformConfig.ClusterIngress.Protocol
+
+	if ingressProtocol == "" {
+		ingressProtocol = "http"
+
+		if platformConfig.ClusterIngress.TLSCertificate.Certificate != "" || platformConfig.ClusterIngress.TLSCertificateRef.Name != "" {
+			ingressProtocol = "https"
+		}
+	}
+
+	values := []struct {
+		key   string
+		value string
+	}{
+		{"ingressDomain", platformConfig.ClusterIngress.Domain},
+		{"ingressClass", platformConfig.ClusterIngress.Class},
+		{"ingressProtocol", ingressProtocol},
+		{"ingressSecret", platformConfig.ClusterIngress.TLSCertificateRef.Name},
+		{"registryHost", platformConfig.ImageRegistry.Host},
+		{"registryNamespace", platformConfig.ImageRegistry.Namespace},
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("#@data/values\n---\n")
+
+	for _, item := range values {
+		encoded, _ := json.Marshal(item.value)
+
+		fmt.Fprintf(&buf, "#@overlay/match missing_ok=True\n%s: %s\n", item.key, encoded)
+	}
+
+	return buf.Bytes()
+}
+
+func processWorkshopTemplate(yamlData []byte, dataValueFlags yttcmd.DataValuesFlags, allDocuments bool) ([]byte, error) {
+	templatingOptions := yttcmd.NewOptions()
+
+	templatingOptions.IgnoreUnknownComments = true
+
+	templatingOptions.DataValuesFlags = dataValueFlags
+
+	var filesToProcess []*files.File
+
+	mainInputFile := files.MustNewFileFromSource(files.NewBytesSource("workshop.yaml", yamlData))
+
+	filesToProcess = append(filesToProcess, mainInputFile)
+
+	// Data values from the local platform configuration are supplied as a
+	// separate data values file, so any data values given as options still
+	// override them. They are skipped where the workshop definition declares
+	// a schema for data values, as the schema would reject any keys it
+	// doesn't declare.
+
+	if !bytes.Contains(yamlData, []byte("#@data/values-schema")) {
+		if platformValues := platformDataValues(); platformValues != nil {
+			filesToProcess = append(filesToProcess, files.MustNewFileFromSource(files.NewBytesSource("platform-values.yaml", platformValues)))
+		}
+	}
+
+	logUI := yttcmdui.NewCustomWriterTTY(false, log.Writer(), log.Writer())
+
+	output := templatingOptions.RunWithFiles(yttcmd.Input{Files: files.NewSortedFiles(filesToProcess)}, logUI)
+
+	if output.Err != nil {
+		return []byte{}, fmt.Errorf("execution of ytt failed: %s", output.Err)
+	}
+
+	if len(output.DocSet.Items) == 0 {
+		return []byte{}, nil
+	}
+
+	var buf bytes.Buffer
+
+	yamlmeta.NewYAMLPrinter(&buf).Print(output.DocSet.Items[0])
+
+	// Any further documents are only included if requested, when a file
+	// is permitted to hold more than one workshop definition.
+
+	if allDocuments {
+		for _, item := range output.DocSet.Items[1:] {
+			buf.WriteString("---\n")
+
+			yamlmeta.NewYAMLPrinter(&buf).Print(item)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
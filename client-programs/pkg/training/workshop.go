@@ -0,0 +1,133 @@
+package training
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+Check that a generated name for a workshop isn't already used in the cluster
+by a different workshop, as identified by its name in the original workshop
+definition and where it was loaded from. This guards against deploying one
+workshop over the top of another, such as when two workshops have the same
+title and names are derived from titles.
+*/
+func CheckWorkshopNameAvailable(ctx context.Context, client dynamic.Interface, workshop *unstructured.Unstructured) error {
+	existing, err := client.Resource(WorkshopResource).Get(ctx, workshop.GetName(), metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to query workshop definition in cluster %q", workshop.GetName())
+	}
+
+	annotations := workshop.GetAnnotations()
+	existingAnnotations := existing.GetAnnotations()
+
+	source := existingAnnotations["training.educates.dev/source"]
+	original := existingAnnotations["training.educates.dev/workshop"]
+
+	// Where the name is derived from the title of the workshop, the same
+	// workshop can be deployed from a different location.
+
+	sameSource := source == annotations["training.educates.dev/source"] || annotations[WorkshopNamingAnnotation] == "title"
+
+	if sameSource && original == annotations["training.educates.dev/workshop"] {
+		return nil
+	}
+
+	// Workshop definitions created other than by the CLI may not have the
+	// annotations, in which case they are still treated as a collision.
+
+	if original == "" {
+		original = existing.GetName()
+	}
+
+	if source == "" {
+		source = "an unknown location"
+	}
+
+	return failures.NewValidationError(errors.Errorf("workshop name %q is already used by workshop %q from %s", workshop.GetName(), original, source), "supply a name for the workshop using --name")
+}
+
+/*
+Create or update the workshop definition in the cluster.
+*/
+func UpdateWorkshopResource(ctx context.Context, client dynamic.Interface, workshop *unstructured.Unstructured) error {
+	workshopsClient := client.Resource(WorkshopResource)
+
+	// _, err := workshopsClient.Apply(context.TODO(), workshop.GetName(), workshop, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true})
+
+	workshopBytes, err := runtime.Encode(unstructured.UnstructuredJSONScheme, workshop)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update workshop definition in cluster %q", workshop.GetName())
+	}
+
+	_, err = workshopsClient.Patch(ctx, workshop.GetName(), types.ApplyPatchType, workshopBytes, metav1.ApplyOptions{FieldManager: "educates-cli", Force: true}.ToPatchOptions())
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update workshop definition in cluster %q", workshop.GetName())
+	}
+
+	return nil
+}
+
+/*
+Remove the named workshop from the training portal. Nothing is done if the
+training portal doesn't exist or doesn't host the workshop.
+*/
+func DeleteWorkshopResource(ctx context.Context, client dynamic.Interface, name string, portal string) error {
+	trainingPortalClient := client.Resource(TrainingPortalResource)
+
+	trainingPortal, err := trainingPortalClient.Get(ctx, portal, metav1.GetOptions{})
+
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	workshops, _, err := unstructured.NestedSlice(trainingPortal.Object, "spec", "workshops")
+
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve workshops from training portal")
+	}
+
+	var found = false
+
+	var updatedWorkshops []interface{}
+
+	for _, item := range workshops {
+		object := item.(map[string]interface{})
+
+		if object["name"] != name {
+			updatedWorkshops = append(updatedWorkshops, object)
+		} else {
+			found = true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	unstructured.SetNestedSlice(trainingPortal.Object, updatedWorkshops, "spec", "workshops")
+
+	_, err = trainingPortalClient.Update(ctx, trainingPortal, metav1.UpdateOptions{FieldManager: "educates-cli"})
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to update training portal %q in cluster", portal)
+	}
+
+	return nil
+}
@@ -0,0 +1,286 @@
+/*
+Reverse tunnels over SSH, used to expose a cluster running on a local machine
+via a remote server which is reachable by others.
+*/
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+/*
+A port on the remote server to be forwarded to an address on the local
+machine.
+*/
+type Forward struct {
+	RemoteAddress string
+	LocalAddress  string
+}
+
+type ReverseTunnelConfig struct {
+	Server           string
+	User             string
+	IdentityFile     string
+	KnownHostsFile   string
+	SkipHostKeyCheck bool
+	Forwards         []Forward
+	KeepAlive        time.Duration
+	RetryDelay       time.Duration
+}
+
+/*
+Run the reverse tunnel until the context is cancelled. If the connection to
+the remote server is lost, it is re-established after a delay. An error is
+only returned if the tunnel could not be established the first time.
+*/
+func Run(ctx context.Context, config *ReverseTunnelConfig) error {
+	clientConfig, err := config.clientConfig()
+
+	if err != nil {
+		return err
+	}
+
+	connected := false
+
+	for {
+		err := config.runOnce(ctx, clientConfig, func() {
+			connected = true
+
+			for _, forward := range config.Forwards {
+				fmt.Printf("Forwarding %s on %s to %s.\n", forward.RemoteAddress, config.Server, forward.LocalAddress)
+			}
+		})
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if !connected {
+			return failures.NewConnectionError(err, "check the remote server is reachable and permits remote port forwarding (\"GatewayPorts yes\" in sshd_config)")
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: tunnel to %s lost: %s, reconnecting in %s.\n", config.Server, err, config.RetryDelay)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(config.RetryDelay):
+		}
+	}
+}
+
+func (config *ReverseTunnelConfig) clientConfig() (*ssh.ClientConfig, error) {
+	var methods []ssh.AuthMethod
+
+	if config.IdentityFile != "" {
+		data, err := os.ReadFile(config.IdentityFile)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read identity file %s", config.IdentityFile)
+		}
+
+		signer, err := ssh.ParsePrivateKey(data)
+
+		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			return nil, errors.Errorf("identity file %s is protected by a passphrase, add it to ssh-agent instead", config.IdentityFile)
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse identity file %s", config.IdentityFile)
+		}
+
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	if len(methods) == 0 {
+		if home, err := os.UserHomeDir(); err == nil {
+			for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+				data, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+
+				if err != nil {
+					continue
+				}
+
+				if signer, err := ssh.ParsePrivateKey(data); err == nil {
+					methods = append(methods, ssh.PublicKeys(signer))
+				}
+			}
+		}
+	}
+
+	if len(methods) == 0 {
+		return nil, failures.NewValidationError(errors.New("no SSH credentials available"), "supply an identity file or run ssh-agent")
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+
+	if !config.SkipHostKeyCheck {
+		knownHostsFile := config.KnownHostsFile
+
+		if knownHostsFile == "" {
+			home, err := os.UserHomeDir()
+
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to determine home directory")
+			}
+
+			knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+		}
+
+		callback, err := knownhosts.New(knownHostsFile)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read known hosts file %s", knownHostsFile)
+		}
+
+		hostKeyCallback = callback
+	}
+
+	return &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
+	}, nil
+}
+
+/*
+Connect to the remote server, listen on the remote ports and forward any
+connections until the connection to the server is lost or the context is
+cancelled.
+*/
+func (config *ReverseTunnelConfig) runOnce(ctx context.Context, clientConfig *ssh.ClientConfig, established func()) error {
+	client, err := ssh.Dial("tcp", config.Server, clientConfig)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to connect to %s", config.Server)
+	}
+
+	defer client.Close()
+
+	var listeners []net.Listener
+
+	defer func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}()
+
+	for _, forward := range config.Forwards {
+		listener, err := client.Listen("tcp", forward.RemoteAddress)
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to listen on %s on %s", forward.RemoteAddress, config.Server)
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	established()
+
+	done := make(chan error, len(listeners)+1)
+
+	for i, listener := range listeners {
+		go func(listener net.Listener, localAddress string) {
+			for {
+				remote, err := listener.Accept()
+
+				if err != nil {
+					done <- err
+					return
+				}
+
+				go proxyConnection(remote, localAddress)
+			}
+		}(listener, config.Forwards[i].LocalAddress)
+	}
+
+	// Send keep alive requests so that a dead connection is detected, as
+	// well as preventing idle connections being dropped.
+
+	if config.KeepAlive > 0 {
+		go func() {
+			ticker := time.NewTicker(config.KeepAlive)
+
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+						done <- err
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-done:
+		return err
+	}
+}
+
+func proxyConnection(remote net.Conn, localAddress string) {
+	defer remote.Close()
+
+	local, err := net.DialTimeout("tcp", localAddress, 10*time.Second)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to connect to %s: %s.\n", localAddress, err)
+		return
+	}
+
+	defer local.Close()
+
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		io.Copy(local, remote)
+		closeWrite(local)
+	}()
+
+	go func() {
+		defer wg.Done()
+		io.Copy(remote, local)
+		closeWrite(remote)
+	}()
+
+	wg.Wait()
+}
+
+func closeWrite(conn net.Conn) {
+	if halfCloser, ok := conn.(interface{ CloseWrite() error }); ok {
+		halfCloser.CloseWrite()
+	} else {
+		conn.Close()
+	}
+}
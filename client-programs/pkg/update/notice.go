@@ -0,0 +1,68 @@
+package update
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+// NOTE: The check for a newer version is made at most once a day, with the
+// result cached so that running commands isn't slowed down by the lookup.
+
+const noticeCheckInterval = 24 * time.Hour
+
+const noticeCheckTimeout = 2 * time.Second
+
+const DisableNoticeEnvVar = "EDUCATES_DISABLE_UPDATE_NOTICE"
+
+type noticeCache struct {
+	CheckedAt     time.Time `json:"checkedAt"`
+	LatestVersion string    `json:"latestVersion"`
+}
+
+func noticeCacheFile() string {
+	return filepath.Join(xdg.CacheHome, "educates", "update-check.json")
+}
+
+/*
+Return the version of the latest release if it is newer than the version of
+the CLI being run. An empty string is returned if there is no newer version,
+the check is disabled, or the latest version could not be determined.
+*/
+func NewerVersionAvailable(current string) string {
+	if os.Getenv(DisableNoticeEnvVar) != "" {
+		return ""
+	}
+
+	if _, _, ok := parseVersion(current); !ok {
+		return ""
+	}
+
+	var cache noticeCache
+
+	if data, err := os.ReadFile(noticeCacheFile()); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+
+	if time.Since(cache.CheckedAt) > noticeCheckInterval {
+		cache.CheckedAt = time.Now()
+
+		if release, err := LatestRelease(noticeCheckTimeout); err == nil {
+			cache.LatestVersion = release.TagName
+		}
+
+		if data, err := json.Marshal(&cache); err == nil {
+			os.MkdirAll(filepath.Dir(noticeCacheFile()), os.ModePerm)
+			os.WriteFile(noticeCacheFile(), data, 0o644)
+		}
+	}
+
+	if cache.LatestVersion != "" && CompareVersions(current, cache.LatestVersion) < 0 {
+		return cache.LatestVersion
+	}
+
+	return ""
+}
@@ -0,0 +1,357 @@
+/*
+Support for updating the Educates CLI binary from GitHub releases.
+*/
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu-labs/educates-training-platform/client-programs/pkg/failures"
+)
+
+const releasesRepository = "vmware-tanzu-labs/educates-training-platform"
+
+const checksumsAssetName = "checksums.txt"
+
+type ReleaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+type Release struct {
+	TagName    string         `json:"tag_name"`
+	HTMLURL    string         `json:"html_url"`
+	Draft      bool           `json:"draft"`
+	Prerelease bool           `json:"prerelease"`
+	Assets     []ReleaseAsset `json:"assets"`
+}
+
+/*
+Retrieve details of the most recent release of Educates.
+*/
+func LatestRelease(timeout time.Duration) (*Release, error) {
+	return fetchRelease(fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", releasesRepository), timeout)
+}
+
+/*
+Retrieve details of the release of Educates with a specific version.
+*/
+func ReleaseForVersion(version string, timeout time.Duration) (*Release, error) {
+	return fetchRelease(fmt.Sprintf("https://api.github.com/repos/%s/releases/tags/%s", releasesRepository, version), timeout)
+}
+
+func fetchRelease(url string, timeout time.Duration) (*Release, error) {
+	client := &http.Client{Timeout: timeout}
+
+	req, err := http.NewRequest("GET", url, nil)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed request for release details")
+	}
+
+	req.Header.Add("Accept", "application/vnd.github+json")
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return nil, failures.NewConnectionError(errors.Wrap(err, "unable to query GitHub releases"), "check that you have network access to api.github.com")
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, failures.NewNotFoundError(errors.New("no matching release found"), "")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("query of GitHub releases failed with status %d", res.StatusCode)
+	}
+
+	var release Release
+
+	err = json.NewDecoder(res.Body).Decode(&release)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode release details")
+	}
+
+	return &release, nil
+}
+
+/*
+Name of the release asset holding the CLI binary for the current platform.
+*/
+func BinaryAssetName() string {
+	name := fmt.Sprintf("educates-%s-%s", runtime.GOOS, runtime.GOARCH)
+
+	if runtime.GOOS == "windows" {
+		name = name + ".exe"
+	}
+
+	return name
+}
+
+func (r *Release) assetURL(name string) (string, error) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.BrowserDownloadURL, nil
+		}
+	}
+
+	return "", errors.Errorf("release %s has no asset named %s", r.TagName, name)
+}
+
+func download(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 10 * time.Minute}
+
+	res, err := client.Get(url)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to download %s", url)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("download of %s failed with status %d", url, res.StatusCode)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+/*
+Download the CLI binary for the current platform from the release and verify
+it against the SHA256 checksum published with the release. The release must
+include a checksums file, otherwise the binary is rejected. As the checksums
+file comes from the same release and isn't signed, this only guards against a
+corrupted download, not against the release assets having been replaced.
+Verifying signatures of releases is out of scope until releases are signed.
+*/
+func DownloadBinary(release *Release) ([]byte, error) {
+	assetName := BinaryAssetName()
+
+	binaryURL, err := release.assetURL(assetName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	checksumsURL, err := release.assetURL(checksumsAssetName)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to verify binary")
+	}
+
+	checksums, err := download(checksumsURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expected := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == assetName {
+			expected = strings.ToLower(fields[0])
+		}
+	}
+
+	if expected == "" {
+		return nil, errors.Errorf("no checksum published for %s", assetName)
+	}
+
+	binary, err := download(binaryURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(binary)
+
+	if hex.EncodeToString(digest[:]) != expected {
+		return nil, errors.Errorf("checksum mismatch for downloaded %s", assetName)
+	}
+
+	return binary, nil
+}
+
+/*
+Replace the currently running executable with the supplied binary. The new
+binary is written alongside the existing one and then renamed over the top of
+it so the replacement is atomic. On Windows a running executable cannot be
+overwritten but can be renamed, so the old binary is moved aside first.
+*/
+func ReplaceExecutable(binary []byte) (string, error) {
+	executable, err := os.Executable()
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to determine path of executable")
+	}
+
+	executable, err = filepath.EvalSymlinks(executable)
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve path of executable")
+	}
+
+	info, err := os.Stat(executable)
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to stat executable")
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(executable), ".educates-update-*")
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create temporary file for update")
+	}
+
+	tmpPath := tmpFile.Name()
+
+	defer os.Remove(tmpPath)
+
+	_, err = tmpFile.Write(binary)
+
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to write updated executable")
+	}
+
+	err = os.Chmod(tmpPath, info.Mode().Perm()|0o111)
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to set permissions on updated executable")
+	}
+
+	if runtime.GOOS == "windows" {
+		oldPath := executable + ".old"
+
+		os.Remove(oldPath)
+
+		err = os.Rename(executable, oldPath)
+
+		if err != nil {
+			return "", errors.Wrap(err, "unable to move aside existing executable")
+		}
+	}
+
+	err = os.Rename(tmpPath, executable)
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to replace existing executable")
+	}
+
+	return executable, nil
+}
+
+/*
+Compare two version strings of the form X.Y.Z with optional pre-release
+suffix, returning -1, 0 or 1. A version with a pre-release suffix is older
+than the same version without one. Versions which cannot be parsed, such as
+"develop" for local builds, compare as equal so no update is suggested.
+*/
+func CompareVersions(a string, b string) int {
+	aParts, aPre, aOk := parseVersion(a)
+	bParts, bPre, bOk := parseVersion(b)
+
+	if !aOk || !bOk {
+		return 0
+	}
+
+	for i := 0; i < 3; i++ {
+		if aParts[i] < bParts[i] {
+			return -1
+		}
+
+		if aParts[i] > bParts[i] {
+			return 1
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	}
+
+	return 1
+}
+
+/*
+Compare only the major and minor components of two version strings, returning
+-1, 0 or 1. Patch releases are expected to be compatible with each other so
+aren't considered. Versions which cannot be parsed compare as equal.
+*/
+func CompareMinorVersions(a string, b string) int {
+	aParts, _, aOk := parseVersion(a)
+	bParts, _, bOk := parseVersion(b)
+
+	if !aOk || !bOk {
+		return 0
+	}
+
+	for i := 0; i < 2; i++ {
+		if aParts[i] < bParts[i] {
+			return -1
+		}
+
+		if aParts[i] > bParts[i] {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func parseVersion(version string) ([3]int, string, bool) {
+	var parts [3]int
+
+	version = strings.TrimPrefix(version, "v")
+
+	version, prerelease, _ := strings.Cut(version, "-")
+
+	fields := strings.Split(version, ".")
+
+	if len(fields) != 3 {
+		return parts, "", false
+	}
+
+	for i, field := range fields {
+		value, err := strconv.Atoi(field)
+
+		if err != nil {
+			return parts, "", false
+		}
+
+		parts[i] = value
+	}
+
+	return parts, prerelease, true
+}
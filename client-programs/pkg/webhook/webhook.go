@@ -0,0 +1,46 @@
+/*
+Support for sending JSON payloads to webhooks, as used for notifications,
+hooks and telemetry.
+*/
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+/*
+Error returned when a webhook responds with a status other than success.
+*/
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.StatusCode)
+}
+
+/*
+Send a JSON payload to a webhook, giving up if no response is received within
+the timeout. Any response other than a success status is returned as a
+StatusError, so callers can tell it apart from the webhook not being reached.
+*/
+func Post(url string, payload []byte, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+
+	res, err := client.Post(url, "application/json", bytes.NewReader(payload))
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &StatusError{StatusCode: res.StatusCode}
+	}
+
+	return nil
+}
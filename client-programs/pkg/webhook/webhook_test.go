@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPost(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus int
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "no content", status: http.StatusNoContent},
+		{name: "not found", status: http.StatusNotFound, wantStatus: http.StatusNotFound},
+		{name: "server error", status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			var contentType string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+
+				body = string(data)
+				contentType = r.Header.Get("Content-Type")
+
+				w.WriteHeader(tt.status)
+			}))
+
+			defer server.Close()
+
+			err := Post(server.URL, []byte(`{"text": "hello"}`), time.Second)
+
+			if body != `{"text": "hello"}` || contentType != "application/json" {
+				t.Errorf("expected JSON payload but got %q with content type %q", body, contentType)
+			}
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			status, ok := err.(*StatusError)
+
+			if !ok {
+				t.Fatalf("expected status error but got %v", err)
+			}
+
+			if status.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d but got %d", tt.wantStatus, status.StatusCode)
+			}
+		})
+	}
+}
+
+func TestPostUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+
+	url := server.URL
+
+	server.Close()
+
+	err := Post(url, []byte(`{}`), time.Second)
+
+	if err == nil {
+		t.Fatalf("expected error for unreachable webhook")
+	}
+
+	if _, ok := err.(*StatusError); ok {
+		t.Errorf("expected connection error but got %v", err)
+	}
+}
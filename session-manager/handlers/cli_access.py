@@ -0,0 +1,67 @@
+import logging
+
+import pykube
+
+from .operator_config import OPERATOR_API_GROUP
+
+__all__ = ["bind_cli_session_access"]
+
+logger = logging.getLogger("educates")
+
+api = pykube.HTTPClient(pykube.KubeConfig.from_env())
+
+
+def bind_cli_session_access(namespace, labels):
+    """Grants users of the Educates CLI, who were given a role using the
+    `educates admin rbac generate` command, access to the pods of workshop
+    sessions in a namespace created for a workshop environment or session.
+
+    Access to view logs of pods and exec into them is only granted in these
+    namespaces, as other namespaces hold pods such as the session manager and
+    training portals whose service accounts have cluster wide access. Each
+    cluster role binding created by the CLI for a role is mirrored as a role
+    binding of the same name in the namespace, bound to the cluster role
+    holding the session rules for that role.
+
+    """
+
+    role_label = f"training.{OPERATOR_API_GROUP}/cli.role"
+
+    try:
+        bindings = pykube.ClusterRoleBinding.objects(api).filter(selector=role_label)
+
+        items = [binding.obj for binding in bindings]
+
+    except pykube.exceptions.PyKubeError:
+        logger.exception("Unable to query role bindings for users of the CLI.")
+
+        return
+
+    for binding in items:
+        name = binding["metadata"]["name"]
+        role = binding["metadata"]["labels"][role_label]
+
+        role_binding_body = {
+            "apiVersion": "rbac.authorization.k8s.io/v1",
+            "kind": "RoleBinding",
+            "metadata": {
+                "name": name,
+                "namespace": namespace,
+                "labels": {**labels, role_label: role},
+            },
+            "roleRef": {
+                "apiGroup": "rbac.authorization.k8s.io",
+                "kind": "ClusterRole",
+                "name": f"{binding['roleRef']['name']}-sessions",
+            },
+            "subjects": binding.get("subjects", []),
+        }
+
+        try:
+            pykube.RoleBinding(api, role_binding_body).create()
+
+        except pykube.exceptions.PyKubeError as e:
+            if e.code != 409:
+                logger.exception(
+                    f"Unable to create role binding {name} in namespace {namespace}."
+                )
@@ -99,6 +99,20 @@ AMPLITUDE_TRACKING_ID = xget(config_values, "workshopAnalytics.amplitude.trackin
 
 ANALYTICS_WEBHOOK_URL = xget(config_values, "workshopAnalytics.webhook.url", "")
 
+# Feature gates supported by the operator and their defaults. These must be
+# kept in sync with the list in the feature gates config map of the package
+# bundle, which is what the CLI reads to determine what can be enabled.
+
+SUPPORTED_FEATURE_GATES = {
+    "disableServiceLinks": False,
+}
+
+FEATURE_GATES = xget(config_values, "featureGates", {}) or {}
+
+
+def feature_gate_enabled(name):
+    return bool(FEATURE_GATES.get(name, SUPPORTED_FEATURE_GATES.get(name, False)))
+
 
 def generate_password(length):
     characters = string.ascii_letters + string.digits
@@ -130,11 +130,17 @@ def training_portal_create(name, uid, body, spec, status, patch, runtime, retry,
 
     catalog_visibility = xget(spec, "portal.catalog.visibility", "private")
 
+    oidc_provider = xget(spec, "portal.authentication.oidc.provider", "")
+    oidc_issuer = xget(spec, "portal.authentication.oidc.issuer", "")
+    oidc_client_id = xget(spec, "portal.authentication.oidc.clientId", "")
+    oidc_groups_claim = xget(spec, "portal.authentication.oidc.groupsClaim", "groups")
+
     google_tracking_id = xget(spec, "analytics.google.trackingId", GOOGLE_TRACKING_ID)
     clarity_tracking_id = xget(spec, "analytics.clarity.trackingId", CLARITY_TRACKING_ID)
     amplitude_tracking_id = xget(spec, "analytics.amplitude.trackingId", AMPLITUDE_TRACKING_ID)
 
     analytics_webhook_url = xget(spec, "analytics.webhook.url", ANALYTICS_WEBHOOK_URL)
+    analytics_webhook_events = ",".join(xget(spec, "analytics.webhook.events", []))
 
     # Create the namespace for holding the training portal. Before we attempt to
     # create the namespace, we first see whether it may already exist. This
@@ -683,6 +689,32 @@ def training_portal_create(name, uid, body, spec, status, patch, runtime, retry,
                                     "name": "CATALOG_VISIBILITY",
                                     "value": catalog_visibility,
                                 },
+                                {
+                                    "name": "OIDC_PROVIDER",
+                                    "value": oidc_provider,
+                                },
+                                {
+                                    "name": "OIDC_ISSUER",
+                                    "value": oidc_issuer,
+                                },
+                                {
+                                    "name": "OIDC_CLIENT_ID",
+                                    "value": oidc_client_id,
+                                },
+                                {
+                                    "name": "OIDC_GROUPS_CLAIM",
+                                    "value": oidc_groups_claim,
+                                },
+                                {
+                                    "name": "OIDC_CLIENT_SECRET",
+                                    "valueFrom": {
+                                        "secretKeyRef": {
+                                            "name": f"{portal_name}-oidc-client",
+                                            "key": "client-secret",
+                                            "optional": True,
+                                        }
+                                    },
+                                },
                                 {
                                     "name": "INGRESS_CLASS",
                                     "value": INGRESS_CLASS,
@@ -711,6 +743,20 @@ def training_portal_create(name, uid, body, spec, status, patch, runtime, retry,
                                     "name": "ANALYTICS_WEBHOOK_URL",
                                     "value": analytics_webhook_url,
                                 },
+                                {
+                                    "name": "ANALYTICS_WEBHOOK_TOKEN",
+                                    "valueFrom": {
+                                        "secretKeyRef": {
+                                            "name": f"{portal_name}-analytics-webhook",
+                                            "key": "token",
+                                            "optional": True,
+                                        }
+                                    },
+                                },
+                                {
+                                    "name": "ANALYTICS_WEBHOOK_EVENTS",
+                                    "value": analytics_webhook_events,
+                                },
                             ],
                             "volumeMounts": [
                                 {"name": "data", "mountPath": "/opt/app-root/data"},
@@ -19,6 +19,7 @@ from .helpers import (
 from .applications import environment_objects_list, workshop_spec_patches
 from .kyverno_rules import kyverno_environment_rules
 from .analytics import report_analytics_event
+from .cli_access import bind_cli_session_access
 
 from .operator_config import (
     resolve_workshop_image,
@@ -473,6 +474,19 @@ def workshop_environment_create(
 
         pykube.RoleBinding(api, scc_role_binding_body).create()
 
+    # Grant users of the CLI who have been given a role access to the pods for
+    # workshop sessions, which run in this namespace.
+
+    bind_cli_session_access(
+        workshop_namespace,
+        {
+            f"training.{OPERATOR_API_GROUP}/component": "environment",
+            f"training.{OPERATOR_API_GROUP}/workshop.name": workshop_name,
+            f"training.{OPERATOR_API_GROUP}/portal.name": portal_name,
+            f"training.{OPERATOR_API_GROUP}/environment.name": environment_name,
+        },
+    )
+
     # Delete any limit ranges applied to the namespace so they don't cause
     # issues with workshop instance deployments or any workshop deployments.
     # This can be an issue where namespace/project templates apply them
@@ -25,9 +25,11 @@ from .helpers import (
 )
 from .applications import session_objects_list, pod_template_spec_patches
 from .analytics import report_analytics_event
+from .cli_access import bind_cli_session_access
 
 from .operator_config import (
     resolve_workshop_image,
+    feature_gate_enabled,
     PLATFORM_ARCH,
     OPERATOR_API_GROUP,
     OPERATOR_STATUS_KEY,
@@ -334,6 +336,20 @@ def _setup_session_namespace(
 
         pykube.RoleBinding(api, scc_role_binding_body).create()
 
+    # Grant users of the CLI who have been given a role access to the pods
+    # deployed in the namespace by the workshop session.
+
+    bind_cli_session_access(
+        target_namespace,
+        {
+            f"training.{OPERATOR_API_GROUP}/component": "session",
+            f"training.{OPERATOR_API_GROUP}/workshop.name": workshop_name,
+            f"training.{OPERATOR_API_GROUP}/portal.name": portal_name,
+            f"training.{OPERATOR_API_GROUP}/environment.name": environment_name,
+            f"training.{OPERATOR_API_GROUP}/session.name": session_name,
+        },
+    )
+
     # Create secret which holds image registry '.docker/config.json' and apply
     # it to the default service account in the target namespace so that any
     # deployment using that service account can pull images from the image
@@ -1583,6 +1599,45 @@ def workshop_session_create(name, meta, uid, spec, status, patch, logger, retry,
     if RUNTIME_CLASS:
         deployment_pod_template_spec["runtimeClassName"] = RUNTIME_CLASS
 
+    # Allocate GPUs to the workshop container if the workshop requires them.
+    # The workshop can also override the runtime class, as is necessary with
+    # some GPU operators for GPU devices to be exposed to the container.
+
+    gpu_count = xget(workshop_spec, "session.resources.gpu.count", 0)
+
+    if gpu_count:
+        gpu_type = xget(workshop_spec, "session.resources.gpu.type", "nvidia.com/gpu")
+
+        workshop_resources = deployment_pod_template_spec["containers"][0][
+            "resources"
+        ]
+
+        workshop_resources["requests"][gpu_type] = gpu_count
+        workshop_resources["limits"][gpu_type] = gpu_count
+
+    gpu_runtime_class = xget(workshop_spec, "session.resources.gpu.runtimeClass", "")
+
+    if gpu_runtime_class:
+        deployment_pod_template_spec["runtimeClassName"] = gpu_runtime_class
+
+    # Constrain which nodes the workshop session can be scheduled on where
+    # the workshop environment requests it, such as to run workshops needing
+    # special hardware on a dedicated node pool.
+
+    node_selector = xget(
+        environment_instance.obj, "spec.session.scheduling.nodeSelector", {}
+    )
+
+    if node_selector:
+        deployment_pod_template_spec["nodeSelector"] = node_selector
+
+    tolerations = xget(
+        environment_instance.obj, "spec.session.scheduling.tolerations", []
+    )
+
+    if tolerations:
+        deployment_pod_template_spec["tolerations"] = tolerations
+
     token_enabled = (
         workshop_spec["session"]
         .get("namespaces", {})
@@ -1593,6 +1648,9 @@ def workshop_session_create(name, meta, uid, spec, status, patch, logger, retry,
 
     deployment_pod_template_spec["automountServiceAccountToken"] = False
 
+    if feature_gate_enabled("disableServiceLinks"):
+        deployment_pod_template_spec["enableServiceLinks"] = False
+
     deployment_pod_template_spec["volumes"].append(
         {
             "name": "cluster-token",
@@ -1609,20 +1667,43 @@ def workshop_session_create(name, meta, uid, spec, status, patch, logger, retry,
             },
         )
 
+    # Work out the CA certificates which need to be added to the trust store
+    # of the workshop container and docker daemon. This is the CA for the
+    # cluster ingress, if one is configured, as well as any CA bundle from
+    # the workshop definition, such as for an organization's internally
+    # signed image registries and Git servers. The secret for the latter
+    # must exist in the workshop namespace.
+
+    ca_certificates = []
+
     if INGRESS_CA_SECRET:
-        deployment_pod_template_spec["volumes"].extend(
-            [
+        ca_certificates.append(
+            ("workshop-ca", INGRESS_CA_SECRET, "Cluster_Ingress_CA")
+        )
+
+    trusted_ca_secret = xget(workshop_spec, "session.trust.caCertificateRef.name")
+
+    if trusted_ca_secret:
+        ca_certificates.append(
+            ("workshop-trusted-ca", trusted_ca_secret, "Workshop_Trusted_CA")
+        )
+
+    if ca_certificates:
+        for volume_name, secret_name, _ in ca_certificates:
+            deployment_pod_template_spec["volumes"].append(
                 {
-                    "name": "workshop-ca",
+                    "name": volume_name,
                     "secret": {
-                        "secretName": INGRESS_CA_SECRET,
+                        "secretName": secret_name,
                     },
-                },
-                {
-                    "name": "workshop-ca-trust",
-                    "emptyDir": {},
-                },
-            ]
+                }
+            )
+
+        deployment_pod_template_spec["volumes"].append(
+            {
+                "name": "workshop-ca-trust",
+                "emptyDir": {},
+            }
         )
 
         certificates_init_container = {
@@ -1644,11 +1725,14 @@ def workshop_session_create(name, meta, uid, spec, status, patch, logger, retry,
             },
             "volumeMounts": [
                 {
-                    "name": "workshop-ca",
-                    "mountPath": "/etc/pki/ca-trust/source/anchors/Cluster_Ingress_CA.pem",
+                    "name": volume_name,
+                    "mountPath": f"/etc/pki/ca-trust/source/anchors/{file_name}.pem",
                     # "readOnly": True,
                     "subPath": "ca.crt",
-                },
+                }
+                for volume_name, _, file_name in ca_certificates
+            ]
+            + [
                 {"name": "workshop-ca-trust", "mountPath": "/mnt"},
             ],
         }
@@ -1910,7 +1994,7 @@ def workshop_session_create(name, meta, uid, spec, status, patch, logger, retry,
             ],
         }
 
-        if INGRESS_CA_SECRET:
+        if ca_certificates:
             downloads_init_container["volumeMounts"].append(
                 {
                     "name": "workshop-ca-trust",
@@ -2019,6 +2103,54 @@ def workshop_session_create(name, meta, uid, spec, status, patch, logger, retry,
             }
         )
 
+        # When terminal recording is enabled, recordings are by default kept
+        # in the home directory of the workshop user and so only survive as
+        # long as the session. If a persistent volume claim is given, the
+        # recordings are instead written to a directory for the session on
+        # that volume so they can be retrieved after the session has ended.
+
+        if applications.property("terminal", "recording.enabled", False):
+            additional_env.append(
+                {"name": "ENABLE_TERMINAL_RECORDING", "value": "true"}
+            )
+
+            recording_volume_name = substitute_variables(
+                applications.property("terminal", "recording.volume.name", ""),
+                session_variables,
+            )
+
+            if recording_volume_name:
+                recording_volume_subpath = substitute_variables(
+                    applications.property("terminal", "recording.volume.subPath", ""),
+                    session_variables,
+                )
+
+                if recording_volume_subpath:
+                    recording_volume_subpath = (
+                        f"{recording_volume_subpath}/{session_name}"
+                    )
+                else:
+                    recording_volume_subpath = session_name
+
+                deployment_pod_template_spec["volumes"].append(
+                    {
+                        "name": "terminal-recordings",
+                        "persistentVolumeClaim": {"claimName": recording_volume_name},
+                    }
+                )
+
+                deployment_pod_template_spec["containers"][0]["volumeMounts"].append(
+                    {
+                        "name": "terminal-recordings",
+                        "mountPath": "/opt/recordings",
+                        "subPath": recording_volume_subpath,
+                    }
+                )
+
+                additional_env.append(
+                    {"name": "TERMINAL_RECORDINGS_DIR", "value": "/opt/recordings"}
+                )
+
     # Add in extra configuation for web console.
 
     if applications.is_enabled("console"):
@@ -2097,7 +2229,7 @@ def workshop_session_create(name, meta, uid, spec, status, patch, logger, retry,
         dockerd_args = [
             "/bin/sh",
             "-c",
-            f"mkdir -p /var/run/workshop && ln -s /var/run/workshop/docker.sock /var/run/docker.sock && (test -f /usr/local/share/ca-certificates/Cluster_Ingress_CA.crt && /usr/sbin/update-ca-certificates || true) && dockerd --host=unix:///var/run/workshop/docker.sock --mtu={DOCKERD_MTU}",
+            f"mkdir -p /var/run/workshop && ln -s /var/run/workshop/docker.sock /var/run/docker.sock && (ls /usr/local/share/ca-certificates/*.crt >/dev/null 2>&1 && /usr/sbin/update-ca-certificates || true) && dockerd --host=unix:///var/run/workshop/docker.sock --mtu={DOCKERD_MTU}",
         ]
 
         if applications.is_enabled("registry"):
@@ -2158,11 +2290,11 @@ def workshop_session_create(name, meta, uid, spec, status, patch, logger, retry,
                 }
             )
 
-        if INGRESS_CA_SECRET:
+        for volume_name, _, file_name in ca_certificates:
             docker_container["volumeMounts"].append(
                 {
-                    "name": "workshop-ca",
-                    "mountPath": "/usr/local/share/ca-certificates/Cluster_Ingress_CA.crt",
+                    "name": volume_name,
+                    "mountPath": f"/usr/local/share/ca-certificates/{file_name}.crt",
                     # "readOnly": True,
                     "subPath": "ca.crt",
                 },
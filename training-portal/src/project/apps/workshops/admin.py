@@ -10,6 +10,7 @@ from .models import (
     SessionState,
     Environment,
     EnvironmentState,
+    AccessCode,
 )
 
 
@@ -22,6 +23,7 @@ class TrainingPortalAdmin(admin.ModelAdmin):
         "available_sessions_count",
         "allocated_sessions_count",
         "update_workshop",
+        "maintenance",
     ]
 
     fields = [
@@ -42,7 +44,10 @@ class TrainingPortalAdmin(admin.ModelAdmin):
         "default_refresh",
         "default_registry",
         "default_env",
+        "default_scheduling",
         "update_workshop",
+        "maintenance",
+        "maintenance_message",
     ]
 
     def has_add_permission(self, request):
@@ -80,6 +85,9 @@ class EnvironmentAdmin(admin.ModelAdmin):
         "capacity",
         "available_sessions_count",
         "allocated_sessions_count",
+        "is_paused",
+        "is_hidden",
+        "is_featured",
         "tally",
     ]
 
@@ -93,6 +101,8 @@ class EnvironmentAdmin(admin.ModelAdmin):
         "overtime",
         "deadline",
         "orphaned",
+        "orphaned_warning",
+        "expiry_warnings",
         "overdue",
         "refresh",
         "capacity",
@@ -101,7 +111,11 @@ class EnvironmentAdmin(admin.ModelAdmin):
         "initial",
         "registry",
         "env",
+        "scheduling",
         "tally",
+        "paused",
+        "catalog",
+        "broadcast",
     ]
 
     def has_add_permission(self, request):
@@ -237,7 +251,13 @@ class SessionAdmin(admin.ModelAdmin):
     purge_sessions.short_description = "Purge Sessions"
 
 
+class AccessCodeAdmin(admin.ModelAdmin):
+    list_display = ["code", "user", "redeemed"]
+    readonly_fields = ["code", "user", "redeemed"]
+
+
 admin.site.register(TrainingPortal, TrainingPortalAdmin)
 admin.site.register(Workshop, WorkshopAdmin)
 admin.site.register(Environment, EnvironmentAdmin)
 admin.site.register(Session, SessionAdmin)
+admin.site.register(AccessCode, AccessCodeAdmin)
@@ -4,6 +4,9 @@
 
 from django.conf import settings
 
+from .models import TrainingPortal
+from .manager.codes import portal_access_codes
+
 
 def portal(request):
     """Adds context variables for portal configuration."""
@@ -17,4 +20,27 @@ def portal(request):
     context["training_portal"] = settings.TRAINING_PORTAL
     context["ingress_domain"] = settings.INGRESS_DOMAIN
     context["ingress_protocol"] = settings.INGRESS_PROTOCOL
+
+    # Login page offers login using the identity provider when configured.
+
+    if settings.OIDC_ISSUER and settings.OIDC_CLIENT_ID:
+        context["oidc_login_enabled"] = True
+        context["oidc_provider"] = settings.OIDC_PROVIDER or "identity provider"
+
+    # Login page offers redeeming an access code when any have been generated.
+    # This requires reading them from the cluster so is only done for it.
+
+    match = request.resolver_match
+
+    if match and match.url_name == "login" and portal_access_codes():
+        context["access_codes_enabled"] = True
+
+    # Banner is shown on all pages while the portal is in maintenance mode.
+
+    portal = TrainingPortal.objects.filter(name=settings.TRAINING_PORTAL).first()
+
+    if portal and portal.maintenance:
+        context["portal_maintenance"] = True
+        context["portal_maintenance_message"] = portal.maintenance_message
+
     return context
@@ -12,3 +12,9 @@ class AccessTokenForm(forms.Form):
         label="Password", widget=forms.PasswordInput(), max_length=64, required=True
     )
     redirect_url = forms.CharField(widget=forms.HiddenInput(), required=True)
+
+
+class AccessCodeForm(forms.Form):
+    """Input for redeeming an access code for the training portal."""
+
+    code = forms.CharField(label="Access code", max_length=32, required=True)
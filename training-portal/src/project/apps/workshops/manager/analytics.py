@@ -1,3 +1,4 @@
+import fnmatch
 import logging
 
 import requests
@@ -9,9 +10,14 @@ from .operator import background_task
 
 
 @background_task
-def send_event_to_webhook(url, message):
+def send_event_to_webhook(url, message, token=None):
+    headers = {}
+
+    if token:
+        headers["Authorization"] = f"Bearer {token}"
+
     try:
-        requests.post(url, json=message, timeout=2.5)
+        requests.post(url, json=message, headers=headers, timeout=2.5)
     except Exception:
         logging.exception("Unable to report event to %s: %s", url, message)
 
@@ -22,6 +28,15 @@ def report_analytics_event(entity, event, data={}):
     if not settings.ANALYTICS_WEBHOOK_URL:
         return
 
+    # Only report events matching one of the event name patterns, if any
+    # patterns have been configured.
+
+    if settings.ANALYTICS_WEBHOOK_EVENTS and not any(
+        fnmatch.fnmatchcase(event, pattern)
+        for pattern in settings.ANALYTICS_WEBHOOK_EVENTS
+    ):
+        return
+
     if event.startswith("User/"):
         user = entity
 
@@ -84,4 +99,6 @@ def report_analytics_event(entity, event, data={}):
         }
 
     if message:
-        send_event_to_webhook(settings.ANALYTICS_WEBHOOK_URL, message).schedule()
+        send_event_to_webhook(
+            settings.ANALYTICS_WEBHOOK_URL, message, settings.ANALYTICS_WEBHOOK_TOKEN
+        ).schedule()
@@ -13,10 +13,11 @@ import requests
 
 from django.conf import settings
 from django.db import transaction
+from django.db.models import Q
 from django.utils import timezone
 from django.contrib.auth import get_user_model
 
-from ..models import SessionState, Session
+from ..models import EnvironmentState, Environment, SessionState, Session
 
 from .sessions import replace_reserved_session
 from .locking import resources_lock
@@ -26,6 +27,11 @@ from .analytics import report_analytics_event
 
 api = pykube.HTTPClient(pykube.KubeConfig.from_env())
 
+# Names of workshop sessions for which a warning has been reported that they
+# are close to being deemed orphaned, so it is only reported once.
+
+_idle_warnings = set()
+
 
 @background_task
 @resources_lock
@@ -111,6 +117,37 @@ def purge_expired_workshop_sessions():
 
                             delete_workshop_session(session).schedule()
 
+                            _idle_warnings.discard(session.name)
+
+                        elif session.environment.orphaned_warning:
+                            # If a warning period is set for the workshop,
+                            # report once that the session is close to being
+                            # deemed orphaned, so that the user can be warned
+                            # by whatever is receiving analytics events. The
+                            # warning is reset if the session becomes active
+                            # again.
+
+                            remaining = session.environment.orphaned - idle_time
+
+                            if remaining <= session.environment.orphaned_warning:
+                                if session.name not in _idle_warnings:
+                                    logging.info(
+                                        "Session %s idle. Orphaned in %s.",
+                                        session.name,
+                                        remaining,
+                                    )
+
+                                    report_analytics_event(
+                                        session,
+                                        "Session/Idle",
+                                        {"remaining": int(remaining.total_seconds())},
+                                    )
+
+                                    _idle_warnings.add(session.name)
+
+                            else:
+                                _idle_warnings.discard(session.name)
+
                     else:
                         # XXX If we don't get a valid response then not
                         # currently doing anything. Need a better method to
@@ -218,3 +255,140 @@ def cleanup_old_sessions_and_users():
                 logging.info("Deleting anonymous user %s.", user.get_username())
                 report_analytics_event(user, "User/Delete", {"group": "anonymous"})
                 user.delete()
+
+
+def clean_portal_records(accounts_retention, sessions_retention, dry_run=False):
+    """Delete anonymous user accounts older than the account retention period
+    which have no active sessions, records of sessions which have stopped, or
+    which belong to a workshop environment which has been deleted, where the
+    session was last in use before the session retention period, and records
+    of deleted workshop environments which no longer have any sessions. The
+    progress recorded for remaining stopped sessions is compacted to what is
+    needed for reporting workshop results. This is the same as is done by the
+    periodic cleanup, except that the retention periods can be supplied, and
+    is used to stop long lived training portals accumulating records between
+    events. Must be called with the resources lock held and in a transaction.
+    When a dry run is requested, the counts of what would be deleted or
+    compacted is returned without making any changes.
+
+    """
+
+    now = timezone.now()
+
+    sessions_cutoff = now - sessions_retention
+    accounts_cutoff = now - accounts_retention
+
+    deleted_sessions = set()
+
+    def last_used(session):
+        return session.expires or session.started or session.created
+
+    # Delete records of sessions which are no longer active. Sessions which
+    # have never been used and so have no times recorded are only deleted if
+    # the workshop environment has been deleted.
+
+    for session in Session.objects.filter(
+        Q(state=SessionState.STOPPED) | Q(environment__state=EnvironmentState.STOPPED)
+    ):
+        used = last_used(session)
+
+        if used is None and not session.environment.is_stopped():
+            continue
+
+        if used is None or used <= sessions_cutoff:
+            deleted_sessions.add(session.name)
+
+            if not dry_run:
+                logging.info("Deleting stale session %s.", session.name)
+                session.delete()
+
+    # Delete anonymous users older than the retention period which don't
+    # have any active sessions. Any remaining records of stopped sessions for
+    # the user also need to be deleted as they reference the user.
+
+    User = get_user_model()  # pylint: disable=invalid-name
+
+    deleted_accounts = 0
+
+    users = User.objects.filter(
+        groups__name="anonymous", date_joined__lte=accounts_cutoff
+    )
+
+    for user in users:
+        sessions = [
+            session
+            for session in Session.objects.filter(owner=user)
+            if session.name not in deleted_sessions
+        ]
+
+        if any(not session.is_stopped() for session in sessions):
+            continue
+
+        deleted_accounts += 1
+
+        for session in sessions:
+            deleted_sessions.add(session.name)
+
+            if not dry_run:
+                logging.info("Deleting stale session %s.", session.name)
+                session.delete()
+
+        if not dry_run:
+            logging.info("Deleting anonymous user %s.", user.get_username())
+            report_analytics_event(user, "User/Delete", {"group": "anonymous"})
+            user.delete()
+
+    # Delete records of workshop environments which have been deleted and
+    # which no longer have any records of sessions.
+
+    deleted_environments = 0
+
+    for environment in Environment.objects.filter(state=EnvironmentState.STOPPED):
+        remaining = [
+            session
+            for session in environment.session_set.all()
+            if session.name not in deleted_sessions
+        ]
+
+        if not remaining:
+            deleted_environments += 1
+
+            if not dry_run:
+                logging.info("Deleting stale environment %s.", environment.name)
+                environment.delete()
+
+    # Compact the progress recorded for stopped sessions which are being
+    # kept, discarding details of the last update for each task which are
+    # only of use while the session is active.
+
+    compacted_sessions = 0
+
+    for session in Session.objects.filter(state=SessionState.STOPPED):
+        if session.name in deleted_sessions:
+            continue
+
+        progress = session.progress or {}
+        tasks = progress.get("tasks", {})
+
+        compacted = {
+            name: {
+                key: value
+                for key, value in task.items()
+                if key in ("attempts", "passed")
+            }
+            for name, task in tasks.items()
+        }
+
+        if compacted != tasks:
+            compacted_sessions += 1
+
+            if not dry_run:
+                session.progress = dict(progress, tasks=compacted)
+                session.save()
+
+    return {
+        "accounts": deleted_accounts,
+        "sessions": len(deleted_sessions),
+        "environments": deleted_environments,
+        "compacted": compacted_sessions,
+    }
@@ -0,0 +1,100 @@
+"""Defines functions for redeeming access codes for the training portal.
+
+Access codes are generated using the educates CLI and held in a secret which
+is copied into the namespace for the training portal. The secret is read each
+time an access code is redeemed so that codes which have been added or
+revoked since the training portal was started are honoured. Access codes
+which have been redeemed are recorded in the database.
+
+"""
+
+import base64
+import json
+import logging
+import uuid
+
+import pykube
+
+from dateutil.parser import isoparse
+
+from django.conf import settings
+from django.contrib.auth import get_user_model
+from django.db import IntegrityError, transaction
+from django.utils import timezone
+
+from ..models import AccessCode
+
+from .analytics import report_analytics_event
+
+api = pykube.HTTPClient(pykube.KubeConfig.from_env())
+
+
+def portal_access_codes():
+    """Returns the access codes for the training portal, mapping each code
+    to the time it expires. If no access codes have been generated an empty
+    dictionary is returned.
+
+    """
+
+    try:
+        secret = pykube.Secret.objects(
+            api, namespace=f"{settings.PORTAL_NAME}-ui"
+        ).get(name=f"{settings.PORTAL_NAME}-access-codes")
+
+    except pykube.exceptions.ObjectDoesNotExist:
+        return {}
+
+    except pykube.exceptions.KubernetesError:
+        logging.exception("Unable to read access codes for training portal.")
+        return {}
+
+    try:
+        data = base64.b64decode(secret.obj.get("data", {}).get("codes.json", ""))
+        codes = json.loads(data or "{}")
+
+        return {code: isoparse(details["expires"]) for code, details in codes.items()}
+
+    except (ValueError, TypeError, KeyError, AttributeError):
+        logging.exception("Invalid access codes for training portal.")
+        return {}
+
+
+def normalize_access_code(code):
+    """Returns the access code in the form it was generated in, allowing for
+    it to have been entered in lower case or without the separator.
+
+    """
+
+    code = "".join(code.split()).upper()
+
+    if len(code) == 8 and "-" not in code:
+        code = f"{code[:4]}-{code[4:]}"
+
+    return code
+
+
+def redeem_access_code(code):
+    """Redeem the access code, creating an account for the user redeeming it.
+    Returns the user if the access code was valid, hadn't expired and hadn't
+    already been redeemed, otherwise returns None.
+
+    """
+
+    expires = portal_access_codes().get(code)
+
+    if expires is None or expires <= timezone.now():
+        return None
+
+    User = get_user_model()  # pylint: disable=invalid-name
+
+    try:
+        with transaction.atomic():
+            user = User.objects.create_user(str(uuid.uuid4()))
+            AccessCode.objects.create(code=code, user=user)
+
+    except IntegrityError:
+        return None
+
+    report_analytics_event(user, "User/Create", {"group": "access-code"})
+
+    return user
@@ -61,6 +61,19 @@ def duration_as_timedelta(duration):
     return timedelta(seconds=max(0, convert_duration_to_seconds(duration)))
 
 
+def expiry_warnings_as_seconds(warnings):
+    """Converts the times before expiry at which users are to be warned their
+    workshop session is about to expire to seconds, longest first.
+
+    """
+
+    seconds = set(
+        int(duration_as_timedelta(warning).total_seconds()) for warning in warnings
+    )
+
+    return sorted(seconds, reverse=True)
+
+
 def update_environment_status(name, phase):
     """Update the status of the Kubernetes resource object for the workshop
     environment.
@@ -381,6 +394,12 @@ def update_workshop_environments(training_portal, workshops):
             environment.overtime = duration_as_timedelta(workshop["overtime"])
             environment.deadline = duration_as_timedelta(workshop["deadline"])
             environment.orphaned = duration_as_timedelta(workshop["orphaned"])
+            environment.orphaned_warning = duration_as_timedelta(
+                workshop["orphanedWarning"]
+            )
+            environment.expiry_warnings = expiry_warnings_as_seconds(
+                workshop["expiryWarnings"]
+            )
             environment.overdue = duration_as_timedelta(workshop["overdue"])
             environment.refresh = duration_as_timedelta(workshop["refresh"])
 
@@ -392,6 +411,8 @@ def update_workshop_environments(training_portal, workshops):
 
             environment.position = position
 
+            environment.catalog = workshop["catalog"]
+
             environment.save()
 
 
@@ -418,6 +439,7 @@ def process_workshop_environment(portal, workshop, position):
     environment_overtime = duration_as_timedelta(workshop["overtime"])
     environment_deadline = duration_as_timedelta(workshop["deadline"])
     environment_orphaned = duration_as_timedelta(workshop["orphaned"])
+    environment_orphaned_warning = duration_as_timedelta(workshop["orphanedWarning"])
     environment_overdue = duration_as_timedelta(workshop["overdue"])
     environment_refresh = duration_as_timedelta(workshop["refresh"])
 
@@ -435,10 +457,15 @@ def process_workshop_environment(portal, workshop, position):
         overtime=environment_overtime,
         deadline=environment_deadline,
         orphaned=environment_orphaned,
+        orphaned_warning=environment_orphaned_warning,
         overdue=environment_overdue,
         refresh=environment_refresh,
         registry=workshop["registry"],
         env=workshop["env"],
+        scheduling=workshop["scheduling"],
+        paused=workshop.get("paused", False),
+        catalog=workshop["catalog"],
+        expiry_warnings=expiry_warnings_as_seconds(workshop["expiryWarnings"]),
     )
 
     # Save it so that the database record ID is allocated as we use that in
@@ -485,6 +512,7 @@ def process_workshop_environment(portal, workshop, position):
                     "class": settings.INGRESS_CLASS,
                 },
                 "env": environment.env,
+                "scheduling": environment.scheduling or None,
             },
             "environment": {"objects": [], "secrets": []},
             "registry": environment.registry or None,
@@ -562,10 +590,15 @@ def replace_workshop_environment(environment):
         "overtime": int(environment.overtime.total_seconds()),
         "deadline": int(environment.deadline.total_seconds()),
         "orphaned": int(environment.orphaned.total_seconds()),
+        "orphanedWarning": int(environment.orphaned_warning.total_seconds()),
         "overdue": int(environment.overdue.total_seconds()),
         "refresh": int(environment.refresh.total_seconds()),
         "registry": environment.registry,
         "env": environment.env,
+        "scheduling": environment.scheduling,
+        "paused": environment.paused,
+        "catalog": environment.catalog,
+        "expiryWarnings": environment.expiry_warnings,
     }
 
     position = environment.position
@@ -6,6 +6,7 @@ process instance is for.
 """
 
 import copy
+import json
 import logging
 
 import kopf
@@ -134,13 +135,18 @@ def workshop_configuration(portal, workshop):
     workshop.setdefault("overtime", portal.default_overtime)
     workshop.setdefault("deadline", portal.default_deadline)
     workshop.setdefault("orphaned", portal.default_orphaned)
+    workshop.setdefault("orphanedWarning", "0")
+    workshop.setdefault("expiryWarnings", [])
     workshop.setdefault("overdue", portal.default_overdue)
     workshop.setdefault("refresh", portal.default_refresh)
 
     if workshop["deadline"] == "0":
         workshop["deadline"] = workshop["expires"]
 
+    workshop.setdefault("catalog", {})
+
     workshop.setdefault("registry", portal.default_registry)
+    workshop.setdefault("scheduling", portal.default_scheduling)
 
     # Need to merge environment settings and can't just let workshop specific
     # list override the default list of environment variables.
@@ -271,10 +277,35 @@ def process_training_portal(resource):
 
     portal.default_env = env_variables
 
+    portal.default_scheduling = dict(
+        spec.get("portal.workshop.defaults.scheduling", {})
+    )
+
     update_workshop = spec.get("portal.updates.workshop", False)
 
     portal.update_workshop = update_workshop
 
+    # Access rules mapping groups onto the workshops members of the group can
+    # see and start are recorded as an annotation on the training portal.
+
+    access_rules = {}
+
+    try:
+        value = json.loads(
+            metadata.annotations.get(f"training.{settings.OPERATOR_API_GROUP}/access.rules", "{}")
+        )
+
+    except ValueError:
+        logging.error("Invalid access rules for training portal %s.", metadata.name)
+
+    else:
+        if isinstance(value, dict):
+            for group, workshops in value.items():
+                if isinstance(workshops, list):
+                    access_rules[group] = [str(workshop) for workshop in workshops]
+
+    portal.access_rules = access_rules
+
     portal.save()
 
     # Calculate the list of workshops, filling in any configuration defaults.
@@ -162,6 +162,88 @@ def update_session_status(name, phase):
         traceback.print_exc()
 
 
+def record_session_progress(session, event, data):
+    """Record progress of the user through the workshop from events reported
+    by the workshop session, so results can be retrieved later for grading.
+    Only the furthest page reached, whether the workshop was finished, and
+    the outcome of examiner tests are kept.
+
+    """
+
+    if not isinstance(data, dict):
+        data = {}
+
+    # Events can be reported concurrently from separate browser windows, so
+    # lock the database record while it is being updated.
+
+    with transaction.atomic():
+        session = Session.objects.select_for_update().get(name=session.name)
+
+        progress = _updated_session_progress(session.progress or {}, event, data)
+
+        if progress is not None:
+            session.progress = progress
+            session.save()
+
+
+def _updated_session_progress(progress, event, data):
+    """Return the session progress updated for the event, or None if the
+    event doesn't affect the progress.
+
+    """
+
+    progress = dict(progress)
+
+    if event == "Workshop/View":
+        try:
+            page_number = int(data.get("page_number") or 0)
+            pages_total = int(data.get("pages_total") or 0)
+        except (TypeError, ValueError):
+            return None
+
+        pages = dict(progress.get("pages", {}))
+
+        pages["total"] = pages_total
+        pages["furthest"] = max(pages.get("furthest", 0), page_number)
+
+        progress["pages"] = pages
+
+    elif event == "Workshop/Finish":
+        progress.setdefault("finished", timezone.now().isoformat())
+
+    elif event in ("Examiner/Success", "Examiner/Failure"):
+        name = str(data.get("test_name") or "")[:256]
+
+        if not name:
+            return None
+
+        tasks = dict(progress.get("tasks", {}))
+
+        # Limit how many tests are tracked as the names are supplied from the
+        # workshop session and so can't be trusted.
+
+        if name not in tasks and len(tasks) >= 256:
+            return None
+
+        task = dict(tasks.get(name, {}))
+
+        task["attempts"] = task.get("attempts", 0) + 1
+        task["passed"] = task.get("passed", False) or event == "Examiner/Success"
+        task["updated"] = timezone.now().isoformat()
+
+        if data.get("current_page"):
+            task["page"] = str(data["current_page"])[:256]
+
+        tasks[name] = task
+
+        progress["tasks"] = tasks
+
+    else:
+        return None
+
+    return progress
+
+
 def create_workshop_session(session):
     """Triggers the deployment of a new workshop session to the cluster."""
 
@@ -774,12 +856,15 @@ def create_session_for_user(environment, user, token, timeout=None, params={}):
     return session
 
 
-def retrieve_session_for_user(environment, user, token=None, timeout=None, params={}):
+def retrieve_session_for_user(
+    environment, user, token=None, timeout=None, params={}, groups=()
+):
     """Determine if there is already an allocated session for this workshop
     environment which the user is an owner of. If there is return it. Note
     that if we have a token because this is being requested via the REST API,
     it will not overwrite any existing token as we want to reuse the existing
-    one and not generate a new one.
+    one and not generate a new one. Any groups supplied are used in addition
+    to those the user is a member of when checking access rules.
 
     """
 
@@ -795,13 +880,26 @@ def retrieve_session_for_user(environment, user, token=None, timeout=None, param
             session.mark_as_pending(user, token, timeout)
         return session
 
-    # Determine if the user is permitted to create a workshop session.
+    # No new sessions are allocated while the workshop environment is paused,
+    # although users can still return to sessions they already have.
+
+    if environment.paused:
+        return
+
+    # Determine if the user is permitted to create a workshop session. While
+    # the training portal is in maintenance mode only staff can do so.
 
     portal = environment.portal
 
+    if portal.maintenance and not user.is_staff:
+        return
+
     if not portal.session_permitted_for_user(user):
         return
 
+    if not portal.workshop_permitted_for_user(environment.workshop_name, user, groups):
+        return
+
     # Attempt to allocate a session to the user for the workshop environment
     # from any set of reserved sessions.
 
@@ -0,0 +1,24 @@
+# Generated by Django 3.2.20 on 2026-10-14 09:12
+
+from django.db import migrations
+import project.apps.workshops.models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('workshops', '0006_environment_created_at'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='environment',
+            name='scheduling',
+            field=project.apps.workshops.models.JSONField(default={}, verbose_name='scheduling constraints'),
+        ),
+        migrations.AddField(
+            model_name='trainingportal',
+            name='default_scheduling',
+            field=project.apps.workshops.models.JSONField(default={}, verbose_name='default scheduling'),
+        ),
+    ]
@@ -0,0 +1,19 @@
+# Generated by Django 3.2.20 on 2026-10-14 15:52
+
+from django.db import migrations
+import project.apps.workshops.models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('workshops', '0007_scheduling'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='session',
+            name='progress',
+            field=project.apps.workshops.models.JSONField(default={}, verbose_name='session progress'),
+        ),
+    ]
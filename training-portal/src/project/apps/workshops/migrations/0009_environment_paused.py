@@ -0,0 +1,18 @@
+# Generated by Django 3.2.20 on 2026-10-14 16:08
+
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('workshops', '0008_session_progress'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='environment',
+            name='paused',
+            field=models.BooleanField(default=False, verbose_name='paused'),
+        ),
+    ]
@@ -0,0 +1,23 @@
+# Generated by Django 3.2.20 on 2026-10-14 17:21
+
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('workshops', '0009_environment_paused'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='trainingportal',
+            name='maintenance',
+            field=models.BooleanField(default=False, verbose_name='maintenance mode'),
+        ),
+        migrations.AddField(
+            model_name='trainingportal',
+            name='maintenance_message',
+            field=models.TextField(default='', verbose_name='maintenance message'),
+        ),
+    ]
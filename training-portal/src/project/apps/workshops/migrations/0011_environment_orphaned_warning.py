@@ -0,0 +1,19 @@
+# Generated by Django 3.2.20 on 2026-10-14 18:42
+
+import datetime
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('workshops', '0010_trainingportal_maintenance'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='environment',
+            name='orphaned_warning',
+            field=models.DurationField(default=datetime.timedelta(0), verbose_name='inactivity warning'),
+        ),
+    ]
@@ -0,0 +1,19 @@
+# Generated by Django 3.2.20 on 2026-10-14 19:20
+
+from django.db import migrations
+import project.apps.workshops.models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('workshops', '0011_environment_orphaned_warning'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='environment',
+            name='catalog',
+            field=project.apps.workshops.models.JSONField(default={}, verbose_name='catalog settings'),
+        ),
+    ]
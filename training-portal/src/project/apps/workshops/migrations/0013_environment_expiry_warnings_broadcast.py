@@ -0,0 +1,24 @@
+# Generated by Django 3.2.20 on 2026-10-14 21:05
+
+from django.db import migrations
+import project.apps.workshops.models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('workshops', '0012_environment_catalog'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='environment',
+            name='expiry_warnings',
+            field=project.apps.workshops.models.JSONField(default=[], verbose_name='expiry warnings'),
+        ),
+        migrations.AddField(
+            model_name='environment',
+            name='broadcast',
+            field=project.apps.workshops.models.JSONField(default={}, verbose_name='broadcast message'),
+        ),
+    ]
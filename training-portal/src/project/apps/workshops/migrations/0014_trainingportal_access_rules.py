@@ -0,0 +1,19 @@
+# Generated by Django 3.2.20 on 2026-10-14 23:40
+
+from django.db import migrations
+import project.apps.workshops.models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('workshops', '0013_environment_expiry_warnings_broadcast'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='trainingportal',
+            name='access_rules',
+            field=project.apps.workshops.models.JSONField(default={}, verbose_name='access rules'),
+        ),
+    ]
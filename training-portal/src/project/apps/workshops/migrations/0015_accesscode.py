@@ -0,0 +1,24 @@
+# Generated by Django 3.2.20 on 2026-10-14 23:55
+
+from django.conf import settings
+from django.db import migrations, models
+import django.db.models.deletion
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        migrations.swappable_dependency(settings.AUTH_USER_MODEL),
+        ('workshops', '0014_trainingportal_access_rules'),
+    ]
+
+    operations = [
+        migrations.CreateModel(
+            name='AccessCode',
+            fields=[
+                ('code', models.CharField(max_length=32, primary_key=True, serialize=False, verbose_name='access code')),
+                ('redeemed', models.DateTimeField(auto_now_add=True, verbose_name='redeemed at')),
+                ('user', models.ForeignKey(blank=True, null=True, on_delete=django.db.models.deletion.SET_NULL, to=settings.AUTH_USER_MODEL)),
+            ],
+        ),
+    ]
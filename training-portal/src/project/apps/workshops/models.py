@@ -5,7 +5,7 @@
 import json
 import enum
 
-from datetime import timedelta
+from datetime import datetime, timedelta
 
 from django.db import models
 from django.contrib.auth import get_user_model
@@ -95,9 +95,15 @@ class TrainingPortal(models.Model):
     )
     default_registry = JSONField(verbose_name="default registry", default={})
     default_env = JSONField(verbose_name="default environment", default=[])
+    default_scheduling = JSONField(verbose_name="default scheduling", default={})
     update_workshop = models.BooleanField(
         verbose_name="workshop updates", default=False
     )
+    maintenance = models.BooleanField(verbose_name="maintenance mode", default=False)
+    maintenance_message = models.TextField(
+        verbose_name="maintenance message", default=""
+    )
+    access_rules = JSONField(verbose_name="access rules", default={})
 
     def starting_environments(self):
         """Returns the set of workshop environments which are still in the
@@ -377,6 +383,37 @@ class TrainingPortal(models.Model):
 
         return True
 
+    def workshop_permitted_for_user(self, workshop, user, groups=()):
+        """Returns whether the specified user is permitted to see and create
+        a session for the workshop. Workshops not named in any access rule
+        are available to all users. Otherwise the user must be a member of
+        one of the groups granted access to the workshop, either as a group
+        of the training portal, or as a group from the identity provider the
+        user logged in with. Additional groups the user is a member of can be
+        supplied, such as when a session is being requested via the REST API.
+        If the user is staff, they are always permitted.
+
+        """
+
+        if user.is_staff:
+            return True
+
+        granted = [
+            group
+            for group, workshops in self.access_rules.items()
+            if workshop in workshops
+        ]
+
+        if not granted:
+            return True
+
+        names = set(groups)
+
+        if user.is_authenticated:
+            names.update(user.groups.values_list("name", flat=True))
+
+        return any(group in names or f"idp:{group}" in names for group in granted)
+
 
 class Workshop(models.Model):
     name = models.CharField(verbose_name="workshop name", max_length=255)
@@ -433,6 +470,9 @@ class Environment(models.Model):
     orphaned = models.DurationField(
         verbose_name="inactivity timeout", default=timedelta()
     )
+    orphaned_warning = models.DurationField(
+        verbose_name="inactivity warning", default=timedelta()
+    )
     overdue = models.DurationField(
         verbose_name="startup timeout", default=timedelta()
     )
@@ -441,7 +481,12 @@ class Environment(models.Model):
     )
     registry = JSONField(verbose_name="registry override", default={})
     env = JSONField(verbose_name="environment overrides", default=[])
+    scheduling = JSONField(verbose_name="scheduling constraints", default={})
     tally = models.IntegerField(verbose_name="workshop tally", default=0)
+    paused = models.BooleanField(verbose_name="paused", default=False)
+    catalog = JSONField(verbose_name="catalog settings", default={})
+    expiry_warnings = JSONField(verbose_name="expiry warnings", default=[])
+    broadcast = JSONField(verbose_name="broadcast message", default={})
 
     def portal_name(self):
         return self.portal.name
@@ -484,6 +529,46 @@ class Environment(models.Model):
     is_stopped.short_description = "Stopped"
     is_stopped.boolean = True
 
+    def is_paused(self):
+        return self.paused
+
+    is_paused.short_description = "Paused"
+    is_paused.boolean = True
+
+    def active_broadcast(self):
+        """Returns any message broadcast to users of the workshop environment
+        which has not yet expired.
+
+        """
+
+        if not self.broadcast:
+            return None
+
+        expires = self.broadcast.get("expires")
+
+        if expires and datetime.fromisoformat(expires) <= timezone.now():
+            return None
+
+        return self.broadcast
+
+    def is_hidden(self):
+        return self.catalog.get("hidden", False)
+
+    is_hidden.short_description = "Hidden"
+    is_hidden.boolean = True
+
+    def is_featured(self):
+        return self.catalog.get("featured", False)
+
+    is_featured.short_description = "Featured"
+    is_featured.boolean = True
+
+    def catalog_difficulty(self):
+        return self.catalog.get("difficulty") or self.workshop.difficulty
+
+    def catalog_tags(self):
+        return self.catalog.get("tags") or self.workshop.tags
+
     def mark_as_running(self):
         self.state = EnvironmentState.RUNNING
         self.save()
@@ -623,6 +708,7 @@ class Session(models.Model):
     url = models.URLField(verbose_name="session url", null=True)
     params = JSONField(verbose_name="session params", default={})
     password = models.CharField(verbose_name="config password", max_length=256, null=True, blank=True)
+    progress = JSONField(verbose_name="session progress", default={})
 
     def environment_name(self):
         return self.environment.name
@@ -814,3 +900,16 @@ class Session(models.Model):
             self.save()
             return True
         return False
+
+
+class AccessCode(models.Model):
+    """Database model type recording access codes for the training portal
+    which have been redeemed, so that each can only be used once.
+
+    """
+
+    code = models.CharField(
+        verbose_name="access code", max_length=32, primary_key=True
+    )
+    user = models.ForeignKey(User, blank=True, null=True, on_delete=models.SET_NULL)
+    redeemed = models.DateTimeField(verbose_name="redeemed at", auto_now_add=True)
@@ -1,3 +1,95 @@
+"""Tests for the workshops application.
+
+"""
+
+import json
+from datetime import timedelta
+
+from django.contrib.auth import get_user_model
+from django.contrib.auth.models import Group
+from django.shortcuts import reverse
 from django.test import TestCase
+from django.utils import timezone
+
+from oauth2_provider.models import AccessToken, Application
+
+
+class UserImportTests(TestCase):
+    """Tests for creating and updating user accounts via the REST API."""
+
+    def setUp(self):
+        User = get_user_model()  # pylint: disable=invalid-name
+
+        robot = User.objects.create_user("robot@educates")
+        robot.groups.add(Group.objects.get_or_create(name="robots")[0])
+
+        application = Application.objects.create(
+            name="robot@educates",
+            user=robot,
+            client_type=Application.CLIENT_PUBLIC,
+            authorization_grant_type=Application.GRANT_PASSWORD,
+        )
+
+        AccessToken.objects.create(
+            user=robot,
+            application=application,
+            token="robot-token",
+            scope="user:info",
+            expires=timezone.now() + timedelta(hours=1),
+        )
+
+    def import_users(self, users):
+        """Posts user accounts to the REST API as the robot account."""
+
+        return self.client.post(
+            reverse("workshops_users"),
+            data=json.dumps({"users": users}),
+            content_type="application/json",
+            HTTP_AUTHORIZATION="Bearer robot-token",
+        )
+
+    def test_staff_account_protected(self):
+        """Password of an existing staff account can't be changed."""
+
+        User = get_user_model()  # pylint: disable=invalid-name
+
+        User.objects.create_user("trainer", password="original", is_staff=True)
+
+        response = self.import_users([{"username": "trainer", "password": "changed"}])
+
+        self.assertEqual(response.status_code, 200)
+        self.assertEqual(
+            response.json()["errors"],
+            [{"username": "trainer", "error": "Account is protected"}],
+        )
+
+        user = User.objects.get(username="trainer")
+
+        self.assertTrue(user.check_password("original"))
+        self.assertTrue(user.is_staff)
+
+    def test_identity_provider_username_rejected(self):
+        """Usernames reserved for identity provider users are rejected."""
+
+        User = get_user_model()  # pylint: disable=invalid-name
+
+        User.objects.create_user("idp:existing", email="user@example.com")
+
+        response = self.import_users(
+            [
+                {"username": "developer", "password": "secret"},
+                {"username": "idp:existing", "password": "secret"},
+                {"username": "idp:new", "password": "secret"},
+            ]
+        )
+
+        self.assertEqual(response.status_code, 400)
+        self.assertEqual(
+            [error["entry"] for error in response.json()["errors"]], [1, 2]
+        )
 
-# Create your tests here.
+        self.assertFalse(User.objects.filter(username="developer").exists())
+        self.assertFalse(User.objects.filter(username="idp:new").exists())
+        self.assertFalse(
+            User.objects.get(username="idp:existing").has_usable_password()
+        )
@@ -4,6 +4,7 @@ from . import views
 
 urlpatterns = [
     path("access/", views.access, name="workshops_access"),
+    path("access/code/", views.access_code, name="workshops_access_code"),
     path("catalog/", views.catalog, name="workshops_catalog"),
     path(
         "catalog/environments/",
@@ -22,6 +23,31 @@ urlpatterns = [
         views.environment_request,
         name="workshops_environment_request",
     ),
+    path(
+        "environment/<slug:name>/replace/",
+        views.environment_replace,
+        name="workshops_environment_replace",
+    ),
+    path(
+        "environment/<slug:name>/pause/",
+        views.environment_pause,
+        name="workshops_environment_pause",
+    ),
+    path(
+        "environment/<slug:name>/resume/",
+        views.environment_resume,
+        name="workshops_environment_resume",
+    ),
+    path(
+        "environment/<slug:name>/broadcast/",
+        views.environment_broadcast,
+        name="workshops_environment_broadcast",
+    ),
+    path(
+        "workshop/<slug:name>/results/",
+        views.workshop_results,
+        name="workshops_workshop_results",
+    ),
     path("session/<slug:name>/", views.session, name="workshops_session"),
     path(
         "session/<slug:name>/activate/",
@@ -63,6 +89,14 @@ urlpatterns = [
         views.session_event,
         name="workshops_session_event",
     ),
+    path("maintenance/", views.maintenance, name="workshops_maintenance"),
+    path("cleanup/", views.cleanup, name="workshops_cleanup"),
+    path("users/", views.users, name="workshops_users"),
+    path(
+        "user/<str:name>/delete/",
+        views.user_delete,
+        name="workshops_user_delete",
+    ),
     path(
         "user/<slug:name>/sessions/",
         views.user_sessions,
@@ -6,4 +6,7 @@ from .access import *
 from .environment import *
 from .catalog import *
 from .session import *
+from .results import *
+from .maintenance import *
+from .cleanup import *
 from .user import *
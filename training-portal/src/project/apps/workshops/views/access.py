@@ -3,16 +3,18 @@ interface.
 
 """
 
-__all__ = ["access"]
+__all__ = ["access", "access_code"]
 
 import os
 
 from django.shortcuts import render, redirect
 from django.views.decorators.http import require_http_methods
 from django.http import HttpResponseBadRequest
+from django.contrib.auth import login
 from django.conf import settings
 
-from ..forms import AccessTokenForm
+from ..forms import AccessTokenForm, AccessCodeForm
+from ..manager.codes import normalize_access_code, redeem_access_code
 
 
 @require_http_methods(["GET", "POST"])
@@ -48,3 +50,41 @@ def access(request):
         context["portal_head_html"] = ""
 
     return render(request, "workshops/access.html", context)
+
+
+@require_http_methods(["GET", "POST"])
+def access_code(request):
+    """Renders form for redeeming an access code for the training portal. An
+    account is created for the user and they are logged in, with access to
+    the training portal being granted where it also requires an access
+    token.
+
+    """
+
+    if request.user.is_authenticated:
+        return redirect("workshops_catalog")
+
+    if request.method == "POST":
+        form = AccessCodeForm(request.POST)
+        if form.is_valid():
+            code = normalize_access_code(form.cleaned_data["code"])
+            user = redeem_access_code(code)
+            if user:
+                login(request, user, backend=settings.AUTHENTICATION_BACKENDS[0])
+                request.session["is_allowed_access_to_event"] = True
+                return redirect("workshops_catalog")
+            form.add_error(
+                "code", "The access code is invalid, has expired or was already used."
+            )
+    else:
+        form = AccessCodeForm()
+
+    context = {"form": form}
+
+    try:
+        with open("/opt/app-root/static/theme/training-portal.html") as fp:
+            context["portal_head_html"] = fp.read()
+    except Exception:
+        context["portal_head_html"] = ""
+
+    return render(request, "workshops/access-code.html", context)
@@ -40,6 +40,35 @@ def catalog(request):
     portal = TrainingPortal.objects.get(name=settings.TRAINING_PORTAL)
 
     for environment in portal.running_environments():
+        # Paused workshops are hidden from the catalog unless the user
+        # already has a session for the workshop.
+
+        if environment.paused and not (
+            request.user.is_authenticated
+            and environment.allocated_session_for_user(request.user)
+        ):
+            continue
+
+        # Hidden workshops are likewise not listed, but can still be started
+        # by anyone given a direct link to the workshop.
+
+        if environment.is_hidden() and not (
+            request.user.is_authenticated
+            and environment.allocated_session_for_user(request.user)
+        ):
+            continue
+
+        # Workshops restricted by access rules to groups the user isn't a
+        # member of are also not listed.
+
+        if not portal.workshop_permitted_for_user(
+            environment.workshop_name, request.user
+        ) and not (
+            request.user.is_authenticated
+            and environment.allocated_session_for_user(request.user)
+        ):
+            continue
+
         details = {}
         details["environment"] = environment.name
         details["workshop"] = environment.workshop
@@ -47,6 +76,11 @@ def catalog(request):
         capacity = max(0, environment.capacity - environment.allocated_sessions_count())
         details["capacity"] = capacity
 
+        details["category"] = environment.catalog.get("category", "")
+        details["tags"] = environment.catalog_tags()
+        details["difficulty"] = environment.catalog_difficulty()
+        details["featured"] = environment.is_featured()
+
         details["session"] = None
 
         if notification != "session-deleted" and request.user.is_authenticated:
@@ -54,7 +88,24 @@ def catalog(request):
 
         entries.append(details)
 
-    context = {"catalog": entries, "notification": request.GET.get("notification", "")}
+    # Workshops are grouped by category in the order categories are first
+    # seen, with featured workshops shown first within each category, but
+    # otherwise in the order given in the training portal.
+
+    groups = {}
+
+    for details in sorted(entries, key=lambda details: not details["featured"]):
+        groups.setdefault(details["category"], []).append(details)
+
+    categories = [
+        {"name": name, "entries": group} for name, group in groups.items()
+    ]
+
+    context = {
+        "catalog": entries,
+        "categories": categories,
+        "notification": request.GET.get("notification", ""),
+    }
 
     try:
         with open("/opt/app-root/static/theme/training-portal.html") as fp:
@@ -103,14 +154,34 @@ def catalog_environments(request):
     environment_states = []
 
     include_sessions = False
+    include_paused = False
+    include_hidden = False
+
+    # Robot accounts see all workshops as they request sessions on behalf of
+    # users, supplying the groups of the user when doing so. For any other
+    # user workshops are restricted by the access rules.
+
+    include_restricted = False
 
     if request.user.is_authenticated:
         if request.user.groups.filter(name="robots").exists():
+            include_restricted = True
+
             include_sessions = request.GET.get("sessions", "").lower() in (
                 "true",
                 "1",
             )
 
+            include_paused = request.GET.get("paused", "").lower() in (
+                "true",
+                "1",
+            )
+
+            include_hidden = request.GET.get("hidden", "").lower() in (
+                "true",
+                "1",
+            )
+
             include_states = map(str.lower, request.GET.getlist("state"))
 
             if "starting" in include_states:
@@ -132,10 +203,30 @@ def catalog_environments(request):
         environment_states.append(EnvironmentState.RUNNING)
 
     for environment in portal.environments_in_state(environment_states):
+        if environment.paused and not include_paused:
+            continue
+
+        if environment.is_hidden() and not include_hidden:
+            continue
+
+        if not include_restricted and not portal.workshop_permitted_for_user(
+            environment.workshop_name, request.user
+        ):
+            continue
+
         details = {}
 
         details["name"] = environment.name
         details["state"] = EnvironmentState(environment.state).name
+        details["paused"] = environment.paused
+
+        details["catalog"] = {
+            "category": environment.catalog.get("category", ""),
+            "tags": environment.catalog_tags(),
+            "difficulty": environment.catalog_difficulty(),
+            "featured": environment.is_featured(),
+            "hidden": environment.is_hidden(),
+        }
 
         details["workshop"] = {
             "name": environment.workshop.name,
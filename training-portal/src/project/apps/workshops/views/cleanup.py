@@ -0,0 +1,69 @@
+"""Defines view handlers for purging stale records from the training portal
+via the REST API.
+
+"""
+
+__all__ = ["cleanup"]
+
+import json
+
+from datetime import timedelta
+
+from django.http import HttpResponseForbidden, HttpResponseBadRequest
+from django.views.decorators.csrf import csrf_exempt
+from django.views.decorators.http import require_http_methods
+from django.http import JsonResponse
+from django.db import transaction
+
+from oauth2_provider.decorators import protected_resource
+
+from ..manager.cleanup import clean_portal_records
+from ..manager.locking import resources_lock
+
+
+@csrf_exempt
+@protected_resource()
+@require_http_methods(["POST"])
+@resources_lock
+@transaction.atomic
+def cleanup(request):
+    """Deletes anonymous user accounts, records of workshop sessions and
+    records of deleted workshop environments which are older than the
+    retention periods supplied in seconds, and compacts the progress recorded
+    for stopped workshop sessions. Returns counts of what was deleted or
+    compacted, without making any changes if a dry run is requested.
+
+    """
+
+    # Only allow user who is in the robots group to cleanup records.
+
+    if not request.user.groups.filter(name="robots").exists():
+        return HttpResponseForbidden("Cleanup of records not permitted")
+
+    if request.content_type != "application/json":
+        return HttpResponseBadRequest("No cleanup details provided")
+
+    try:
+        details = json.loads(request.body)
+    except ValueError:
+        return HttpResponseBadRequest("Malformed cleanup details provided")
+
+    if not isinstance(details, dict):
+        return HttpResponseBadRequest("Malformed cleanup details provided")
+
+    accounts = details.get("accounts", 36 * 60 * 60)
+    sessions = details.get("sessions", 36 * 60 * 60)
+    dry_run = details.get("dry_run", False)
+
+    for value in (accounts, sessions):
+        if not isinstance(value, int) or isinstance(value, bool) or value < 0:
+            return HttpResponseBadRequest("Malformed cleanup details provided")
+
+    if not isinstance(dry_run, bool):
+        return HttpResponseBadRequest("Malformed cleanup details provided")
+
+    results = clean_portal_records(
+        timedelta(seconds=accounts), timedelta(seconds=sessions), dry_run
+    )
+
+    return JsonResponse(dict(results, dry_run=dry_run))
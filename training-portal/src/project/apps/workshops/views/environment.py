@@ -2,13 +2,23 @@
 
 """
 
-__all__ = ["environment", "environment_create", "environment_request"]
+__all__ = [
+    "environment",
+    "environment_create",
+    "environment_request",
+    "environment_replace",
+    "environment_pause",
+    "environment_resume",
+    "environment_broadcast",
+]
 
 import uuid
 import string
 import random
 import json
 
+from datetime import timedelta
+
 from django.shortcuts import redirect, reverse
 from django.contrib.auth.decorators import login_required
 from django.views.decorators.csrf import csrf_exempt
@@ -23,11 +33,13 @@ from django.http import JsonResponse
 from django.db import transaction
 from django.contrib.auth import login
 from django.conf import settings
+from django.utils import timezone
 
 from oauth2_provider.decorators import protected_resource
 
 from ..manager.analytics import report_analytics_event
 from ..manager.sessions import retrieve_session_for_user
+from ..manager.environments import replace_workshop_environment
 from ..manager.locking import resources_lock
 from ..models import Environment
 
@@ -210,6 +222,12 @@ def environment_request(request, name):
 
     timeout = int(request.GET.get("timeout", "60").strip())
 
+    # Groups the user is a member of can be supplied by a front end which has
+    # authenticated the user, for checking against access rules for the
+    # workshop. They are not recorded against the user.
+
+    groups = [group.strip() for group in request.GET.getlist("group") if group.strip()]
+
     # Extract any request parameters from the request body for using in late
     # binding of workshop session configuration.
 
@@ -271,7 +289,9 @@ def environment_request(request, name):
     characters = string.ascii_letters + string.digits
     token = "".join(random.sample(characters, 32))
 
-    session = retrieve_session_for_user(instance, user, token, timeout, params)
+    session = retrieve_session_for_user(
+        instance, user, token, timeout, params, groups
+    )
 
     if not session:
         return JsonResponse({"error": "No session available"}, status=503)
@@ -302,3 +322,157 @@ def environment_request(request, name):
     details["environment"] = session.environment_name()
 
     return JsonResponse(details)
+
+
+@csrf_exempt
+@protected_resource()
+@require_http_methods(["POST"])
+@resources_lock
+@transaction.atomic
+def environment_replace(request, name):
+    """URL for requesting replacement of a workshop environment with a new one
+    using the current workshop definition, via the REST API. The existing
+    workshop environment is marked as stopping, with any workshop sessions
+    already allocated to users left running until they end.
+
+    """
+
+    # Only allow user who is in the robots group to replace environment.
+
+    if not request.user.groups.filter(name="robots").exists():
+        return HttpResponseForbidden("Environment replacement not permitted")
+
+    # Ensure there is an environment which the specified name in existance.
+
+    try:
+        instance = Environment.objects.get(name=name)
+    except Environment.DoesNotExist:
+        return HttpResponseForbidden("Environment does not exist")
+
+    if instance.is_stopping() or instance.is_stopped():
+        return HttpResponseBadRequest("Environment is already stopping")
+
+    replace_workshop_environment(instance)
+
+    return JsonResponse({"environment": name, "replaced": True})
+
+
+def _set_environment_paused(request, name, paused):
+    """Mark a workshop environment as paused or resumed. While paused, the
+    workshop environment is hidden from the catalog and no new workshop
+    sessions are allocated, but the workshop environment and any existing
+    workshop sessions are left running.
+
+    """
+
+    # Only allow user who is in the robots group to pause environment.
+
+    if not request.user.groups.filter(name="robots").exists():
+        return HttpResponseForbidden("Environment updates not permitted")
+
+    # Ensure there is an environment which the specified name in existance.
+
+    try:
+        instance = Environment.objects.get(name=name)
+    except Environment.DoesNotExist:
+        return HttpResponseForbidden("Environment does not exist")
+
+    if instance.is_stopping() or instance.is_stopped():
+        return HttpResponseBadRequest("Environment is already stopping")
+
+    if instance.paused != paused:
+        instance.paused = paused
+        instance.save()
+
+        report_analytics_event(
+            instance, paused and "Environment/Paused" or "Environment/Resumed"
+        )
+
+    return JsonResponse({"environment": name, "paused": paused})
+
+
+@csrf_exempt
+@protected_resource()
+@require_http_methods(["POST"])
+@resources_lock
+@transaction.atomic
+def environment_pause(request, name):
+    """URL for pausing a workshop environment via the REST API."""
+
+    return _set_environment_paused(request, name, True)
+
+
+@csrf_exempt
+@protected_resource()
+@require_http_methods(["POST"])
+@resources_lock
+@transaction.atomic
+def environment_resume(request, name):
+    """URL for resuming a paused workshop environment via the REST API."""
+
+    return _set_environment_paused(request, name, False)
+
+
+@csrf_exempt
+@protected_resource()
+@require_http_methods(["POST"])
+@resources_lock
+@transaction.atomic
+def environment_broadcast(request, name):
+    """URL for sending a message to all users of a workshop environment via
+    the REST API. The message is shown in the workshop dashboard of any
+    workshop session for the workshop environment, until the message expires
+    or is replaced. An empty message clears any existing message.
+
+    """
+
+    # Only allow user who is in the robots group to send a message.
+
+    if not request.user.groups.filter(name="robots").exists():
+        return HttpResponseForbidden("Environment updates not permitted")
+
+    # Ensure there is an environment which the specified name in existance.
+
+    try:
+        instance = Environment.objects.get(name=name)
+    except Environment.DoesNotExist:
+        return HttpResponseForbidden("Environment does not exist")
+
+    try:
+        details = json.loads(request.body)
+    except ValueError:
+        return HttpResponseBadRequest("Malformed broadcast details provided")
+
+    if not isinstance(details, dict) or not isinstance(details.get("message"), str):
+        return HttpResponseBadRequest("Malformed broadcast details provided")
+
+    message = details["message"].strip()
+    duration = details.get("duration", 0)
+
+    if not isinstance(duration, int) or duration < 0:
+        return HttpResponseBadRequest("Malformed broadcast details provided")
+
+    if message:
+        now = timezone.now()
+
+        instance.broadcast = {
+            "id": uuid.uuid4().hex,
+            "message": message,
+            "sent": now.isoformat(),
+            "expires": None,
+        }
+
+        if duration:
+            expires = now + timedelta(seconds=duration)
+            instance.broadcast["expires"] = expires.isoformat()
+
+        report_analytics_event(
+            instance, "Environment/Broadcast", {"message": message}
+        )
+
+    else:
+        instance.broadcast = {}
+
+    instance.save()
+
+    return JsonResponse({"environment": name, "broadcast": instance.broadcast})
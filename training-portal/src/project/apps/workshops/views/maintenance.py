@@ -0,0 +1,69 @@
+"""Defines view handlers for controlling maintenance mode of the training
+portal via the REST API.
+
+"""
+
+__all__ = ["maintenance"]
+
+import json
+
+from django.conf import settings
+from django.http import HttpResponseForbidden, HttpResponseBadRequest
+from django.views.decorators.csrf import csrf_exempt
+from django.views.decorators.http import require_http_methods
+from django.http import JsonResponse
+from django.db import transaction
+
+from oauth2_provider.decorators import protected_resource
+
+from ..manager.locking import resources_lock
+from ..models import TrainingPortal
+
+
+@csrf_exempt
+@protected_resource()
+@require_http_methods(["GET", "POST"])
+@resources_lock
+@transaction.atomic
+def maintenance(request):
+    """Returns whether the training portal is in maintenance mode, or turns
+    maintenance mode on or off. While in maintenance mode, a banner with the
+    supplied message is shown on all pages of the training portal and only
+    staff can start new workshop sessions. Existing workshop sessions keep
+    running and users can still return to them.
+
+    """
+
+    # Only allow user who is in the robots group to control maintenance mode.
+
+    if not request.user.groups.filter(name="robots").exists():
+        return HttpResponseForbidden("Maintenance mode changes not permitted")
+
+    portal = TrainingPortal.objects.get(name=settings.TRAINING_PORTAL)
+
+    if request.method == "POST":
+        if request.content_type != "application/json":
+            return HttpResponseBadRequest("No maintenance details provided")
+
+        try:
+            details = json.loads(request.body)
+        except ValueError:
+            return HttpResponseBadRequest("Malformed maintenance details provided")
+
+        if not isinstance(details, dict) or not isinstance(
+            details.get("enabled"), bool
+        ):
+            return HttpResponseBadRequest("Malformed maintenance details provided")
+
+        portal.maintenance = details["enabled"]
+        portal.maintenance_message = (
+            details["enabled"] and str(details.get("message", "")).strip() or ""
+        )
+        portal.save()
+
+    return JsonResponse(
+        {
+            "enabled": portal.maintenance,
+            "message": portal.maintenance_message,
+        }
+    )
@@ -0,0 +1,60 @@
+"""Defines view handlers for retrieving the progress of users through
+workshops via the REST API.
+
+"""
+
+__all__ = ["workshop_results"]
+
+from django.http import HttpResponseForbidden
+from django.views.decorators.http import require_http_methods
+from django.http import JsonResponse
+
+from oauth2_provider.decorators import protected_resource
+
+from ..models import Session, SessionState
+
+
+@protected_resource()
+@require_http_methods(["GET"])
+def workshop_results(request, name):
+    """Returns the progress recorded for each user who has been allocated a
+    session for the workshop, including sessions which have been stopped but
+    for which the record of the session has not yet been deleted.
+
+    """
+
+    # Only allow user who is in the robots group to request details.
+
+    if not request.user.groups.filter(name="robots").exists():
+        return HttpResponseForbidden("Results requests not permitted")
+
+    sessions = Session.objects.filter(
+        environment__workshop_name=name, owner__isnull=False
+    ).order_by("started")
+
+    results = []
+
+    for session in sessions:
+        progress = session.progress or {}
+
+        pages = progress.get("pages", {})
+        tasks = progress.get("tasks", {})
+
+        details = {
+            "session": session.name,
+            "environment": session.environment_name(),
+            "user": session.owner.get_username(),
+            "email": session.owner.email,
+            "state": SessionState(session.state).name.lower(),
+            "started": session.started,
+            "pages": {
+                "furthest": pages.get("furthest", 0),
+                "total": pages.get("total", 0),
+            },
+            "finished": progress.get("finished"),
+            "tasks": tasks,
+        }
+
+        results.append(details)
+
+    return JsonResponse({"workshop": name, "results": results})
@@ -39,7 +39,11 @@ from csp.decorators import csp_update
 
 from ..manager.locking import resources_lock
 from ..manager.cleanup import delete_workshop_session
-from ..manager.sessions import update_session_status, create_request_resources
+from ..manager.sessions import (
+    update_session_status,
+    create_request_resources,
+    record_session_progress,
+)
 from ..manager.analytics import report_analytics_event
 from ..models import TrainingPortal, SessionState
 
@@ -364,6 +368,21 @@ def session_schedule(request, name):
         details["countdown"] = remaining
         details["extendable"] = instance.is_extension_permitted()
 
+    # Include the times before expiry at which the workshop dashboard should
+    # warn the user, along with any message broadcast to all users of the
+    # workshop, as the workshop dashboard polls this periodically.
+
+    details["warnings"] = instance.environment.expiry_warnings
+
+    broadcast = instance.environment.active_broadcast()
+
+    if broadcast:
+        details["broadcast"] = {
+            "id": broadcast["id"],
+            "message": broadcast["message"],
+            "sent": broadcast["sent"],
+        }
+
     return JsonResponse(details)
 
 
@@ -483,6 +502,8 @@ def session_event(request, name):
     if not event:
         return HttpResponseBadRequest("No event data provided")
 
+    record_session_progress(instance, event, data)
+
     report_analytics_event(instance, event, data)
 
     return JsonResponse({})
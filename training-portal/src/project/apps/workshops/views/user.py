@@ -3,10 +3,17 @@ REST API.
 
 """
 
-__all__ = ["user_sessions"]
+__all__ = ["user_sessions", "users", "user_delete"]
 
-from django.http import HttpResponseForbidden
+import json
+
+from django.http import HttpResponseForbidden, HttpResponseBadRequest, Http404
 from django.contrib.auth import get_user_model
+from django.contrib.auth.models import Group
+from django.core.exceptions import ValidationError
+from django.core.validators import validate_email
+from django.db import transaction
+from django.views.decorators.csrf import csrf_exempt
 from django.views.decorators.http import require_http_methods
 from django.http import JsonResponse
 
@@ -69,3 +76,256 @@ def user_sessions(request, name):
     result = {"user": name, "sessions": sessions}
 
     return JsonResponse(result)
+
+
+def user_details(user):
+    """Returns details of a user account."""
+
+    return {
+        "username": user.get_username(),
+        "email": user.email,
+        "first_name": user.first_name,
+        "last_name": user.last_name,
+        "staff": user.is_staff,
+        "sso": not user.has_usable_password(),
+        "active": user.is_active,
+        "joined": user.date_joined,
+        "last_login": user.last_login,
+        "groups": [group.name for group in user.groups.all()],
+    }
+
+
+# Groups which the training portal relies on to identify the robot account and
+# anonymous users, or which are managed by the training portal when users
+# login using an identity provider, can't be assigned to users via the REST
+# API.
+
+RESERVED_GROUPS = ["robots", "anonymous"]
+RESERVED_GROUP_PREFIXES = ["idp:"]
+
+
+def is_reserved_group(name):
+    """Returns whether group is one managed by the training portal itself."""
+
+    return name in RESERVED_GROUPS or any(
+        name.startswith(prefix) for prefix in RESERVED_GROUP_PREFIXES
+    )
+
+
+# Accounts for users who login using an identity provider have names with a
+# prefix which can't be used by accounts created via the REST API, so they
+# can't be created in advance or taken over by setting a password.
+
+RESERVED_USERNAME_PREFIXES = ["idp:"]
+
+
+def is_reserved_username(name):
+    """Returns whether username is one managed by the training portal itself."""
+
+    return any(name.startswith(prefix) for prefix in RESERVED_USERNAME_PREFIXES)
+
+
+def is_protected_user(user):
+    """Returns whether user account is one created for the training portal
+    itself, or is an administrator or staff account, which must not be
+    modified via the REST API."""
+
+    return (
+        user.is_superuser
+        or user.is_staff
+        or user.groups.filter(name="robots").exists()
+    )
+
+
+@csrf_exempt
+@protected_resource()
+@require_http_methods(["GET", "POST"])
+def users(request):
+    """Returns list of user accounts, or creates and updates user accounts
+    in bulk."""
+
+    # Only allow user who is in the robots group to manage users.
+
+    if not request.user.groups.filter(name="robots").exists():
+        return HttpResponseForbidden("User management not permitted")
+
+    User = get_user_model()  # pylint: disable=invalid-name
+
+    if request.method == "GET":
+        accounts = []
+
+        for user in User.objects.order_by("username"):
+            if not is_protected_user(user):
+                accounts.append(user_details(user))
+
+        return JsonResponse({"users": accounts})
+
+    if request.content_type != "application/json":
+        return HttpResponseBadRequest("No user details provided")
+
+    try:
+        entries = json.loads(request.body).get("users", [])
+    except (ValueError, AttributeError):
+        return HttpResponseBadRequest("Malformed user details provided")
+
+    if not isinstance(entries, list):
+        return HttpResponseBadRequest("Malformed user details provided")
+
+    # Entries which aren't structured as expected are a problem with the
+    # request as a whole, so reject it, listing each bad entry by position.
+
+    malformed = []
+
+    for index, entry in enumerate(entries):
+        if not isinstance(entry, dict):
+            malformed.append({"entry": index, "error": "Malformed user details"})
+            continue
+
+        fields = [
+            field
+            for field in ("username", "email", "first_name", "last_name", "password")
+            if field in entry and not isinstance(entry[field], str)
+        ]
+
+        if fields:
+            malformed.append(
+                {
+                    "entry": index,
+                    "error": f"Fields {', '.join(fields)} must be strings",
+                }
+            )
+            continue
+
+        username = entry.get("username", "").strip() or entry.get("email", "").strip()
+
+        if is_reserved_username(username):
+            malformed.append(
+                {
+                    "entry": index,
+                    "error": f"Reserved username {username} not permitted",
+                }
+            )
+
+    if malformed:
+        return JsonResponse({"errors": malformed}, status=400)
+
+    created = []
+    updated = []
+    errors = []
+
+    # Each user account is created or updated independently, so that a
+    # problem with one entry doesn't prevent the remainder being processed.
+
+    for entry in entries:
+        email = entry.get("email", "").strip()
+        username = entry.get("username", "").strip() or email
+
+        if not username:
+            errors.append({"username": "", "error": "No username or email"})
+            continue
+
+        if email:
+            try:
+                validate_email(email)
+            except ValidationError:
+                errors.append({"username": username, "error": "Invalid email"})
+                continue
+
+        groups = entry.get("groups", [])
+
+        if not isinstance(groups, list) or not all(
+            isinstance(name, str) and name for name in groups
+        ):
+            errors.append({"username": username, "error": "Malformed groups"})
+            continue
+
+        reserved = [name for name in groups if is_reserved_group(name)]
+
+        if reserved:
+            errors.append(
+                {
+                    "username": username,
+                    "error": f"Reserved groups {', '.join(reserved)} not permitted",
+                }
+            )
+            continue
+
+        # Staff users can see and start all workshops, including while the
+        # training portal is in maintenance mode, so staff access can only be
+        # granted by the administrator of the training portal. Accounts which
+        # already have staff access are protected from changes via the REST
+        # API in the same way as administrator accounts.
+
+        if entry.get("staff"):
+            errors.append(
+                {"username": username, "error": "Staff access not permitted"}
+            )
+            continue
+
+        try:
+            with transaction.atomic():
+                user, is_new = User.objects.get_or_create(username=username)
+
+                if not is_new and is_protected_user(user):
+                    errors.append(
+                        {"username": username, "error": "Account is protected"}
+                    )
+                    continue
+
+                for field in ("email", "first_name", "last_name"):
+                    if field in entry:
+                        setattr(user, field, entry[field].strip())
+
+                # Accounts linked to a single sign on identity provider
+                # are given an unusable password so they can't be used
+                # to login directly to the training portal.
+
+                if entry.get("sso"):
+                    user.set_unusable_password()
+                elif entry.get("password"):
+                    user.set_password(entry["password"])
+                elif is_new:
+                    user.set_unusable_password()
+
+                user.save()
+
+                for name in groups:
+                    group, _ = Group.objects.get_or_create(name=name)
+                    user.groups.add(group)
+
+        except Exception as exc:  # pylint: disable=broad-except
+            errors.append({"username": username, "error": str(exc)})
+            continue
+
+        if is_new:
+            created.append(username)
+        else:
+            updated.append(username)
+
+    return JsonResponse({"created": created, "updated": updated, "errors": errors})
+
+
+@csrf_exempt
+@protected_resource()
+@require_http_methods(["DELETE"])
+def user_delete(request, name):
+    """Deletes a user account."""
+
+    # Only allow user who is in the robots group to manage users.
+
+    if not request.user.groups.filter(name="robots").exists():
+        return HttpResponseForbidden("User management not permitted")
+
+    User = get_user_model()  # pylint: disable=invalid-name
+
+    try:
+        user = User.objects.get(username=name)
+    except User.DoesNotExist:
+        raise Http404("User does not exist")
+
+    if is_protected_user(user):
+        return HttpResponseForbidden("Account is protected")
+
+    user.delete()
+
+    return JsonResponse({"user": name, "deleted": True})
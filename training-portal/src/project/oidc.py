@@ -0,0 +1,255 @@
+"""Login to the training portal using an OpenID Connect identity provider.
+
+The authorization code flow is used, with PKCE, and the ID token returned by
+the identity provider is validated against the keys published by the issuer
+before the user is logged in. Users are given local accounts with names
+derived from the subject claim so they can never collide with accounts
+created by the training portal. Groups from the identity provider are added
+to the user as groups with the "idp:" prefix, so they cannot be used to gain
+membership of groups the training portal relies on, such as "robots".
+
+"""
+
+import base64
+import hashlib
+import json
+import logging
+import secrets
+
+import requests
+
+from jwcrypto import jwk, jwt
+from jwcrypto.common import JWException
+
+from django.conf import settings
+from django.shortcuts import redirect, reverse
+from django.contrib.auth import get_user_model, login
+from django.contrib.auth.models import Group
+from django.http import HttpResponseBadRequest, HttpResponseForbidden, HttpResponse
+from django.utils.http import urlencode, url_has_allowed_host_and_scheme
+
+from .apps.workshops.manager.analytics import report_analytics_event
+
+logger = logging.getLogger("educates")
+
+USERNAME_PREFIX = "idp:"
+GROUP_PREFIX = "idp:"
+
+
+def oidc_enabled():
+    """Returns whether login using an identity provider is configured."""
+
+    return bool(settings.OIDC_ISSUER and settings.OIDC_CLIENT_ID)
+
+
+def oidc_configuration():
+    """Returns the discovery document for the identity provider."""
+
+    issuer = settings.OIDC_ISSUER.rstrip("/")
+
+    response = requests.get(f"{issuer}/.well-known/openid-configuration", timeout=30)
+    response.raise_for_status()
+
+    return response.json()
+
+
+def oidc_redirect_uri():
+    """Returns the URI the identity provider redirects back to after login."""
+
+    return f"{settings.INGRESS_PROTOCOL}://{settings.PORTAL_HOSTNAME}{reverse('oidc_callback')}"
+
+
+def oidc_login(request):
+    """Redirects the user to the identity provider to login."""
+
+    if not oidc_enabled():
+        return redirect("login")
+
+    next_url = request.GET.get("next", "")
+
+    if not url_has_allowed_host_and_scheme(
+        next_url, allowed_hosts={request.get_host()}
+    ):
+        next_url = ""
+
+    try:
+        configuration = oidc_configuration()
+
+    except (requests.RequestException, ValueError):
+        logger.exception("Unable to retrieve identity provider configuration.")
+
+        return HttpResponse("Identity provider unavailable", status=503)
+
+    state = secrets.token_urlsafe(32)
+    nonce = secrets.token_urlsafe(32)
+    verifier = secrets.token_urlsafe(64)
+
+    challenge = (
+        base64.urlsafe_b64encode(hashlib.sha256(verifier.encode()).digest())
+        .decode()
+        .rstrip("=")
+    )
+
+    request.session["oidc_login"] = {
+        "state": state,
+        "nonce": nonce,
+        "verifier": verifier,
+        "next": next_url,
+    }
+
+    params = {
+        "response_type": "code",
+        "client_id": settings.OIDC_CLIENT_ID,
+        "redirect_uri": oidc_redirect_uri(),
+        "scope": "openid email profile",
+        "state": state,
+        "nonce": nonce,
+        "code_challenge": challenge,
+        "code_challenge_method": "S256",
+    }
+
+    return redirect(configuration["authorization_endpoint"] + "?" + urlencode(params))
+
+
+def oidc_callback(request):
+    """Completes login after being redirected back by the identity provider."""
+
+    if not oidc_enabled():
+        return redirect("login")
+
+    pending = request.session.pop("oidc_login", None)
+
+    if not pending or not secrets.compare_digest(
+        request.GET.get("state", ""), pending["state"]
+    ):
+        return HttpResponseBadRequest("Invalid login request")
+
+    if request.GET.get("error"):
+        return HttpResponseForbidden("Login with identity provider failed")
+
+    code = request.GET.get("code")
+
+    if not code:
+        return HttpResponseBadRequest("Invalid login request")
+
+    try:
+        configuration = oidc_configuration()
+
+        data = {
+            "grant_type": "authorization_code",
+            "code": code,
+            "redirect_uri": oidc_redirect_uri(),
+            "client_id": settings.OIDC_CLIENT_ID,
+            "code_verifier": pending["verifier"],
+        }
+
+        auth = None
+
+        if settings.OIDC_CLIENT_SECRET:
+            auth = (settings.OIDC_CLIENT_ID, settings.OIDC_CLIENT_SECRET)
+
+        response = requests.post(
+            configuration["token_endpoint"], data=data, auth=auth, timeout=30
+        )
+
+        if response.status_code != 200:
+            logger.error(
+                f"Identity provider token request failed with status {response.status_code}."
+            )
+
+            return HttpResponseForbidden("Login with identity provider failed")
+
+        id_token = response.json().get("id_token", "")
+
+        keys = requests.get(configuration["jwks_uri"], timeout=30)
+        keys.raise_for_status()
+
+    except (requests.RequestException, ValueError, KeyError):
+        logger.exception("Unable to complete login with identity provider.")
+
+        return HttpResponse("Identity provider unavailable", status=503)
+
+    claims = oidc_verify_token(configuration, keys.text, id_token, pending["nonce"])
+
+    if claims is None:
+        return HttpResponseForbidden("Login with identity provider failed")
+
+    user = oidc_user(claims)
+
+    login(request, user, backend=settings.AUTHENTICATION_BACKENDS[0])
+
+    return redirect(pending["next"] or "index")
+
+
+def oidc_verify_token(configuration, keys, token, nonce):
+    """Returns the claims of the ID token if it is valid, otherwise None."""
+
+    try:
+        checked = jwt.JWT(
+            jwt=token,
+            key=jwk.JWKSet.from_json(keys),
+            check_claims={
+                "iss": configuration["issuer"],
+                "aud": settings.OIDC_CLIENT_ID,
+                "exp": None,
+                "nonce": nonce,
+            },
+        )
+
+        claims = json.loads(checked.claims)
+
+    except (JWException, ValueError, KeyError):
+        logger.exception("Invalid ID token returned by identity provider.")
+
+        return None
+
+    if not claims.get("sub"):
+        return None
+
+    return claims
+
+
+def oidc_user(claims):
+    """Returns the local user for the identity provider user, creating it if
+    it doesn't exist, and updating it to match the claims of the ID token.
+
+    """
+
+    User = get_user_model()  # pylint: disable=invalid-name
+
+    username = f"{USERNAME_PREFIX}{claims['sub']}"[:150]
+
+    user, created = User.objects.get_or_create(username=username)
+
+    if created:
+        user.set_unusable_password()
+
+    user.email = claims.get("email", "")
+    user.first_name = claims.get("given_name", "")[:150]
+    user.last_name = claims.get("family_name", "")[:150]
+    user.save()
+
+    # Groups from a prior login which the user is no longer a member of with
+    # the identity provider are removed.
+
+    groups = claims.get(settings.OIDC_GROUPS_CLAIM, [])
+
+    if isinstance(groups, str):
+        groups = [groups]
+
+    names = set(
+        f"{GROUP_PREFIX}{name}" for name in groups if isinstance(name, str) and name
+    )
+
+    for group in user.groups.filter(name__startswith=GROUP_PREFIX):
+        if group.name not in names:
+            user.groups.remove(group)
+
+    for name in names:
+        group, _ = Group.objects.get_or_create(name=name[:150])
+        user.groups.add(group)
+
+    if created:
+        report_analytics_event(user, "User/Create", {"group": "oidc"})
+
+    return user
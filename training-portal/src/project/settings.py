@@ -179,6 +179,12 @@ CLARITY_TRACKING_ID = os.environ.get("CLARITY_TRACKING_ID", "")
 AMPLITUDE_TRACKING_ID = os.environ.get("AMPLITUDE_TRACKING_ID", "")
 
 ANALYTICS_WEBHOOK_URL = os.environ.get("ANALYTICS_WEBHOOK_URL", "")
+ANALYTICS_WEBHOOK_TOKEN = os.environ.get("ANALYTICS_WEBHOOK_TOKEN", "")
+ANALYTICS_WEBHOOK_EVENTS = [
+    event.strip()
+    for event in os.environ.get("ANALYTICS_WEBHOOK_EVENTS", "").split(",")
+    if event.strip()
+]
 
 OPERATOR_API_GROUP = os.environ.get("OPERATOR_API_GROUP", "educates.dev")
 
@@ -211,6 +217,12 @@ if REGISTRATION_TYPE == "one-step" and ENABLE_REGISTRATION == "true":
 else:
     REGISTRATION_OPEN = False
 
+OIDC_PROVIDER = os.environ.get("OIDC_PROVIDER", "")
+OIDC_ISSUER = os.environ.get("OIDC_ISSUER", "")
+OIDC_CLIENT_ID = os.environ.get("OIDC_CLIENT_ID", "")
+OIDC_CLIENT_SECRET = os.environ.get("OIDC_CLIENT_SECRET", "")
+OIDC_GROUPS_CLAIM = os.environ.get("OIDC_GROUPS_CLAIM") or "groups"
+
 DEFAULT_AUTO_FIELD = "django.db.models.AutoField"
 
 # Assorted configuration for CORS, CSP, OAuth etc.
@@ -2,6 +2,7 @@ from django.contrib import admin
 from django.urls import include, path
 
 from . import views
+from . import oidc
 
 import oauth2_provider.views as oauth2_views
 
@@ -14,6 +15,8 @@ oauth2_endpoint_views = [
 urlpatterns = [
     path("admin/", admin.site.urls),
     path("accounts/create/", views.accounts_create, name="accounts_create"),
+    path("accounts/oidc/login/", oidc.oidc_login, name="oidc_login"),
+    path("accounts/oidc/callback/", oidc.oidc_callback, name="oidc_callback"),
     path("accounts/", include("django_registration.backends.one_step.urls")),
     path("accounts/", include("django.contrib.auth.urls")),
     path("workshops/", include("project.apps.workshops.urls")),
@@ -1,5 +1,7 @@
 import * as express from "express"
+import * as fs from "fs"
 import * as http from "http"
+import * as os from "os"
 import * as path from "path"
 import * as WebSocket from "ws"
 import * as url from "url"
@@ -11,6 +13,11 @@ import { IPty } from "node-pty"
 
 const BASEDIR = path.dirname(path.dirname(path.dirname(__dirname)))
 
+const ENABLE_TERMINAL_RECORDING = process.env.ENABLE_TERMINAL_RECORDING == "true"
+
+const TERMINAL_RECORDINGS_DIR = process.env.TERMINAL_RECORDINGS_DIR ||
+    path.join(os.homedir(), ".local/share/workshop/recordings")
+
 enum PacketType {
     HELLO,
     PING,
@@ -52,10 +59,80 @@ interface ErrorPacketArgs {
     reason: string
 }
 
+// Recording of terminal output in asciicast v2 format, as used by asciinema.
+// A new recording file is started each time the terminal process for a
+// terminal session is created. Each line after the header is an event giving
+// the time in seconds since the recording started, the event type, and the
+// event data.
+
+class TerminalRecording {
+    private stream: fs.WriteStream
+    private start: number
+
+    constructor(id: string, cols: number, rows: number) {
+        this.start = Date.now()
+
+        let timestamp = new Date(this.start).toISOString().replace(/[:.]/g, "-")
+        let name = id.replace(/[^A-Za-z0-9_-]/g, "_")
+
+        let pathname = path.join(TERMINAL_RECORDINGS_DIR, `terminal-${name}-${timestamp}.cast`)
+
+        try {
+            fs.mkdirSync(TERMINAL_RECORDINGS_DIR, { recursive: true })
+
+            this.stream = fs.createWriteStream(pathname, { flags: "a" })
+
+            this.stream.on("error", (err) => {
+                console.log("Unable to write terminal recording", pathname, err)
+                this.stream = null
+            })
+        } catch (err) {
+            console.log("Unable to create terminal recording", pathname, err)
+            return
+        }
+
+        console.log("Recording terminal session", id, "to", pathname)
+
+        this.write_line({
+            version: 2,
+            width: cols,
+            height: rows,
+            timestamp: Math.floor(this.start / 1000),
+            title: `Terminal ${id}`,
+            env: { TERM: "xterm-color", SHELL: process.env.SHELL || "/bin/bash" }
+        })
+    }
+
+    private write_line(value: any) {
+        if (this.stream)
+            this.stream.write(JSON.stringify(value) + "\n")
+    }
+
+    private event(type: string, data: string) {
+        this.write_line([(Date.now() - this.start) / 1000, type, data])
+    }
+
+    output(data: string) {
+        this.event("o", data)
+    }
+
+    resize(cols: number, rows: number) {
+        this.event("r", `${cols}x${rows}`)
+    }
+
+    close() {
+        if (this.stream)
+            this.stream.end()
+
+        this.stream = null
+    }
+}
+
 class TerminalSession {
     private sockets: WebSocket[] = []
 
     private terminal: IPty
+    private recording: TerminalRecording
     private buffer: OutboundDataPacketArgs[]
     private buffer_size: number
     private buffer_limit: number = 50000
@@ -80,7 +157,13 @@ class TerminalSession {
         this.buffer_size = 0
         this.sequence = 0
 
+        if (ENABLE_TERMINAL_RECORDING)
+            this.recording = new TerminalRecording(this.id, 80, 25)
+
         this.terminal.onData((data) => {
+            if (this.recording)
+                this.recording.output(data)
+
             // A incrementing sequence number is attached to each data message
             // sent so if a client needs to reconnect, it can indicate what
             // data it has seen previously so not replaying data it has
@@ -122,7 +205,11 @@ class TerminalSession {
 
             this.close_connections()
 
+            if (this.recording)
+                this.recording.close()
+
             this.terminal = null
+            this.recording = null
             this.buffer = []
             this.buffer_size = 0
             this.sequence = 0
@@ -269,6 +356,9 @@ class TerminalSession {
                     }
                     else {
                         this.terminal.resize(args.cols, args.rows)
+
+                        if (this.recording)
+                            this.recording.resize(args.cols, args.rows)
                     }
                 }
 
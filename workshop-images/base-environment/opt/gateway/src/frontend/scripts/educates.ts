@@ -1351,6 +1351,86 @@ class Dashboard {
             })
         }
 
+        // Periodically check the session schedule for notices to be shown to
+        // the user in a banner. These are warnings that the workshop session
+        // is about to expire, at times before expiry configured for the
+        // workshop, and any message broadcast to all users of the workshop.
+        // The last message seen is remembered so it isn't shown again when
+        // the dashboard is reloaded.
+
+        let warned_at = 0
+
+        function show_notice(message: string, alert: string) {
+            let banner = $("#session-notice-banner")
+
+            banner.removeClass("alert-info alert-warning alert-danger")
+            banner.addClass(alert)
+
+            $("#session-notice-banner-message").text(message)
+
+            banner.removeClass("d-none")
+        }
+
+        function check_notices() {
+            $.ajax({
+                type: 'GET',
+                url: "/session/schedule",
+                cache: false,
+                success: (data, textStatus, xhr) => {
+                    if (data.countdown !== undefined && data.warnings) {
+                        let countdown = Math.floor(data.countdown)
+
+                        let reached = data.warnings.filter((warning: number) => {
+                            return countdown > 0 && countdown <= warning
+                        })
+
+                        if (reached.length) {
+                            let warning = Math.min(...reached)
+
+                            if (!warned_at || warning < warned_at) {
+                                warned_at = warning
+
+                                let minutes = Math.max(1, Math.ceil(countdown / 60))
+
+                                let message = `Your workshop session will expire in ${minutes} minute${minutes == 1 ? "" : "s"}.`
+
+                                if (data.extendable && $("#countdown-button").length)
+                                    message = message + " Click on the countdown timer to extend it."
+
+                                show_notice(message, data.extendable ? "alert-warning" : "alert-danger")
+                            }
+                        }
+                        else {
+                            // Session has time remaining outside of any of
+                            // the warning periods, such as when it has been
+                            // extended, so the warnings can be shown again.
+
+                            warned_at = 0
+                        }
+                    }
+
+                    if (data.broadcast && data.broadcast.id != localStorage.getItem("educates-broadcast")) {
+                        localStorage.setItem("educates-broadcast", data.broadcast.id)
+
+                        show_notice(data.broadcast.message, "alert-info")
+                    }
+
+                    setTimeout(check_notices, 15000)
+                },
+                error: () => {
+                    setTimeout(check_notices, 15000)
+                }
+            })
+        }
+
+        if ($("#session-notice-banner").length) {
+            setTimeout(check_notices, 1000)
+
+            $("#session-notice-banner-close").on("click", () => {
+                $("#session-notice-banner").addClass("d-none")
+            })
+        }
+
         // Hide the startup cover panel across the dashboard once the page has
         // finished loading. The cover panel hides adjustments in dashboard as
         // it is being displayed.
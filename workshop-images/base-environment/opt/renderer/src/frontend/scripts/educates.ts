@@ -1598,6 +1598,20 @@ $(document).ready(async () => {
                 })
                 form_values = object
             }
+            // Report the outcome of the test so the training portal can
+            // record the progress of the user through the workshop.
+
+            let report = (event: string) => {
+                let $body = $("body")
+
+                send_analytics_event(event, {
+                    current_page: $body.data("current-page"),
+                    page_number: $body.data("page-number"),
+                    pages_total: $body.data("pages-total"),
+                    test_name: args.name,
+                })
+            }
+
             execute_examiner_test(
                 args.name,
                 args.url || "",
@@ -1607,8 +1621,14 @@ $(document).ready(async () => {
                 args.retries || 0,
                 args.delay || 1,
                 args.cascade || false,
-                done,
-                fail)
+                () => {
+                    report("Examiner/Success")
+                    done()
+                },
+                (message) => {
+                    report("Examiner/Failure")
+                    fail(message)
+                })
         },
         waiting: "fa-cog",
         spinner: true,